@@ -20,12 +20,16 @@ use rayon::prelude::*;
 use rocketindex::git;
 use rocketindex::{
     batch::{BatchProcessor, BatchStats, DEFAULT_BATCH_INTERVAL},
+    callgraph::{CallGraph, CallGraphOptions, DeadCodeOptions},
     config::Config,
     db::DEFAULT_DB_NAME,
-    find_fsproj_files, parse_fsproj,
+    diff::{self, ChangeKind},
+    find_fsproj_files,
+    indexer::read_source,
+    parse::{extract_symbols_with, ParseResult},
+    parse_fsproj,
     pidfile::{acquire_watch_lock, find_watch_process, PidFileGuard},
     spider::{format_spider_result, reverse_spider, spider},
-    watch::find_source_files_with_config,
     CodeIndex, SqliteIndex,
 };
 use tracing_indicatif::IndicatifLayer;
@@ -192,6 +196,10 @@ enum Commands {
         /// Force full rebuild (ignore cached index)
         #[arg(long)]
         rebuild: bool,
+
+        /// Don't store doc comments (smaller index)
+        #[arg(long)]
+        no_docs: bool,
     },
 
     /// Find the definition of a symbol
@@ -273,6 +281,20 @@ enum Commands {
         interface: String,
     },
 
+    /// Find functions and methods never called from the given entry points
+    DeadCode {
+        /// Entry points (qualified or bare names, e.g. "main")
+        roots: Vec<String>,
+
+        /// Treat every exported symbol as an entry point
+        #[arg(long)]
+        exported: bool,
+
+        /// Leave symbols from generated files out of the report
+        #[arg(long)]
+        exclude_generated: bool,
+    },
+
     /// Watch for file changes and update the index
     Watch {
         /// Root directory to watch (defaults to current directory)
@@ -315,6 +337,16 @@ enum Commands {
         target: String,
     },
 
+    /// Show symbols added, removed, modified, or moved between two git revisions
+    Changes {
+        /// Old revision (e.g. "main", "HEAD~1", a commit hash)
+        old: String,
+
+        /// New revision
+        #[arg(default_value = "HEAD")]
+        new: String,
+    },
+
     /// Show git history for a symbol
     History {
         /// Symbol name
@@ -448,7 +480,16 @@ fn run(command: Commands, format: OutputFormat, quiet: bool, concise: bool) -> R
             extract_types,
             batch_size,
             rebuild,
-        } => cmd_index(&root, extract_types, batch_size, rebuild, format, quiet),
+            no_docs,
+        } => cmd_index(
+            &root,
+            extract_types,
+            batch_size,
+            rebuild,
+            no_docs,
+            format,
+            quiet,
+        ),
 
         Commands::Def {
             symbol,
@@ -482,6 +523,17 @@ fn run(command: Commands, format: OutputFormat, quiet: bool, concise: bool) -> R
         Commands::Callers { symbol } => cmd_callers(&symbol, format, quiet, concise),
         Commands::Subclasses { parent } => cmd_subclasses(&parent, format, quiet, concise),
         Commands::Implements { interface } => cmd_implements(&interface, format, quiet, concise),
+        Commands::DeadCode {
+            roots,
+            exported,
+            exclude_generated,
+        } => {
+            let options = DeadCodeOptions {
+                exported_as_roots: exported,
+                exclude_generated,
+            };
+            cmd_dead_code(&roots, &options, format, quiet, concise)
+        }
         Commands::Watch { root } => cmd_watch(&root, format, quiet),
         Commands::ExtractTypes {
             project,
@@ -492,6 +544,7 @@ fn run(command: Commands, format: OutputFormat, quiet: bool, concise: bool) -> R
             cmd_type_info(symbol.as_deref(), members_of.as_deref(), format, quiet)
         }
         Commands::Blame { target } => cmd_blame(&target, format, quiet, concise),
+        Commands::Changes { old, new } => cmd_changes(&old, &new, format, quiet, concise),
         Commands::History { symbol } => cmd_history(&symbol, format, quiet, concise),
         Commands::Doctor => cmd_doctor(format, quiet),
         Commands::Doc { symbol } => cmd_doc(&symbol, format, quiet),
@@ -651,6 +704,7 @@ fn cmd_index(
     extract_types: bool,
     batch_size: usize,
     rebuild: bool,
+    no_docs: bool,
     format: OutputFormat,
     quiet: bool,
 ) -> Result<u8> {
@@ -660,13 +714,16 @@ fn cmd_index(
 
     // Load configuration
     let config = Config::load(&root);
-    let exclude_dirs = config.excluded_dirs();
 
     if !quiet && !config.exclude_dirs.is_empty() {
         eprintln!("Custom exclusions: {}", config.exclude_dirs.join(", "));
     }
+    if !quiet && !config.ignore_patterns.is_empty() {
+        eprintln!("Ignore patterns: {}", config.ignore_patterns.join(", "));
+    }
 
-    let all_files = find_source_files_with_config(&root, &exclude_dirs, config.respect_gitignore)
+    let all_files = config
+        .find_source_files(&root)
         .context("Failed to find source files")?;
 
     // Try to find and parse .fsproj files for compilation order
@@ -737,14 +794,14 @@ fn cmd_index(
         for (path, reason) in stale {
             match reason {
                 "deleted" => {
-                    index.delete_symbols_in_file(&path)?;
+                    index.clear_file(&path)?;
                     index.delete_file_mtime(&path)?;
                     deleted += 1;
                 }
                 "modified" | "new" => {
                     if reason == "modified" {
                         // Clear old data for modified files
-                        index.delete_symbols_in_file(&path)?;
+                        index.clear_file(&path)?;
                     }
                     files_to_update.push(path);
                 }
@@ -789,6 +846,11 @@ fn cmd_index(
     }
 
     let max_depth = config.max_recursion_depth;
+    let max_file_bytes = config.max_file_bytes;
+    let skip_generated = config.skip_generated;
+    let languages = config.language_configs();
+    let docs = config.index_docs && !no_docs;
+    let kinds = config.symbol_kinds();
     let files = &files_to_process;
     let total_files = files.len();
     let batch_size = batch_size.max(1); // Ensure at least 1
@@ -820,9 +882,18 @@ fn cmd_index(
         let parse_results: Vec<_> = chunk
             .par_iter()
             .map(|file| {
-                let result = match std::fs::read_to_string(file) {
+                let result = match read_source(file, max_file_bytes) {
                     Ok(source) => {
-                        let result = rocketindex::extract_symbols(file, &source, max_depth);
+                        let mut result = if skip_generated && languages.is_generated(file, &source)
+                        {
+                            ParseResult::default()
+                        } else {
+                            extract_symbols_with(file, &source, max_depth, &languages)
+                        };
+                        if !docs {
+                            result.strip_docs();
+                        }
+                        result.retain_kinds(kinds);
                         Ok((file.clone(), result))
                     }
                     Err(e) => Err(format!("{}: {}", file.display(), e)),
@@ -923,6 +994,15 @@ fn cmd_index(
         pb.finish_with_message("Indexing complete");
     }
 
+    // Calls can only be resolved once every file's symbols are stored
+    let call_count = match index.resolve_calls(files, CallGraphOptions::default()) {
+        Ok(count) => count,
+        Err(e) => {
+            errors.push(format!("Failed to resolve calls: {}", e));
+            0
+        }
+    };
+
     let symbol_count = total_symbols;
     let _ref_count = total_refs;
     let _open_count = total_opens;
@@ -941,6 +1021,7 @@ fn cmd_index(
             "files_deleted": deleted_count,
             "symbols": total_symbol_count,
             "symbols_added": symbol_count,
+            "calls": call_count,
             "incremental": is_incremental,
             "fsproj_files": fsproj_count,
             "file_order_count": file_order.len(),
@@ -1471,6 +1552,7 @@ fn cmd_refs_symbol(
                     "file": r.location.file.display().to_string(),
                     "line": r.location.line,
                     "column": r.location.column,
+                    "kind": r.kind,
                 });
 
                 // Add context if requested
@@ -1556,6 +1638,7 @@ fn cmd_refs_file(
                     "name": r.name,
                     "line": r.location.line,
                     "column": r.location.column,
+                    "kind": r.kind,
                 })
             })
             .collect();
@@ -1810,6 +1893,79 @@ fn cmd_callers(symbol: &str, format: OutputFormat, quiet: bool, concise: bool) -
     Ok(exit_codes::SUCCESS)
 }
 
+/// Find functions and methods unreachable from the given entry points
+fn cmd_dead_code(
+    roots: &[String],
+    options: &DeadCodeOptions,
+    format: OutputFormat,
+    quiet: bool,
+    concise: bool,
+) -> Result<u8> {
+    warn_if_no_session(quiet);
+    let index = load_code_index()?;
+    let graph = CallGraph::build(&index);
+
+    let roots: Vec<&str> = roots.iter().map(|r| r.as_str()).collect();
+    let dead = graph.unreachable_symbols_with_options(&index, &roots, options);
+
+    if format == OutputFormat::Json {
+        let symbol_list: Vec<_> = dead
+            .iter()
+            .map(|s| {
+                if concise {
+                    serde_json::json!({
+                        "qualified": s.qualified,
+                        "file": s.location.file.display().to_string(),
+                        "line": s.location.line,
+                    })
+                } else {
+                    serde_json::json!({
+                        "name": s.name,
+                        "qualified": s.qualified,
+                        "kind": format!("{}", s.kind),
+                        "file": s.location.file.display().to_string(),
+                        "line": s.location.line,
+                        "column": s.location.column,
+                    })
+                }
+            })
+            .collect();
+
+        let output = serde_json::json!({
+            "roots": roots,
+            "unreachable": symbol_list,
+        });
+        println!(
+            "{}",
+            if concise {
+                serde_json::to_string(&output)?
+            } else {
+                serde_json::to_string_pretty(&output)?
+            }
+        );
+    } else if !quiet {
+        if dead.is_empty() {
+            println!("No unreachable symbols found");
+        } else {
+            println!("Unreachable symbols ({}):", dead.len());
+            for symbol in &dead {
+                println!(
+                    "  {} ({}:{})",
+                    symbol.qualified,
+                    symbol.location.file.display(),
+                    symbol.location.line
+                );
+            }
+        }
+    }
+
+    if dead.is_empty() {
+        Ok(exit_codes::NOT_FOUND)
+    } else {
+        Ok(exit_codes::SUCCESS)
+    }
+}
+
 /// Find classes that inherit from a parent class
 fn cmd_subclasses(parent: &str, format: OutputFormat, quiet: bool, concise: bool) -> Result<u8> {
     warn_if_no_session(quiet);
@@ -2134,7 +2290,7 @@ fn cmd_watch(root: &Path, format: OutputFormat, quiet: bool) -> Result<u8> {
     if !quiet {
         println!("Building initial index...");
     }
-    cmd_index(&root, false, 1000, false, format, quiet)?;
+    cmd_index(&root, false, 1000, false, false, format, quiet)?;
 
     // Load config for recursion depth
     let config = Config::load(&root);
@@ -2149,7 +2305,12 @@ fn cmd_watch(root: &Path, format: OutputFormat, quiet: bool) -> Result<u8> {
     watcher.start().context("Failed to start watching")?;
 
     // Create batch processor for efficient event handling
-    let mut batch = BatchProcessor::new(DEFAULT_BATCH_INTERVAL, max_depth);
+    let mut batch = BatchProcessor::new(DEFAULT_BATCH_INTERVAL, max_depth)
+        .with_docs(config.index_docs)
+        .with_kinds(config.symbol_kinds())
+        .with_max_file_bytes(config.max_file_bytes)
+        .with_skip_generated(config.skip_generated)
+        .with_languages(config.language_configs());
 
     // Set up graceful shutdown handler
     let running = Arc::new(AtomicBool::new(true));
@@ -2218,6 +2379,7 @@ fn print_batch_stats(stats: &BatchStats, format: OutputFormat) {
                 "files_deleted": stats.files_deleted,
                 "symbols_inserted": stats.symbols_inserted,
                 "references_inserted": stats.references_inserted,
+                "diagnostics": stats.diagnostics,
                 "duration_ms": stats.duration.as_millis()
             })
         );
@@ -2234,6 +2396,9 @@ fn print_batch_stats(stats: &BatchStats, format: OutputFormat) {
         if stats.files_deleted > 0 {
             println!("Deleted {} file(s) from index", stats.files_deleted);
         }
+        for diagnostic in &stats.diagnostics {
+            println!("{}: {}", diagnostic.file.display(), diagnostic.message);
+        }
     }
 }
 
@@ -2264,11 +2429,10 @@ fn load_sqlite_index_with_refresh(auto_refresh: bool) -> Result<SqliteIndex> {
 fn ensure_index_fresh(index: &SqliteIndex, workspace_root: &Path) -> Result<()> {
     // Load config to get source files
     let config = Config::load(workspace_root);
-    let exclude_dirs = config.excluded_dirs();
 
-    let files =
-        find_source_files_with_config(workspace_root, &exclude_dirs, config.respect_gitignore)
-            .context("Failed to find source files")?;
+    let files = config
+        .find_source_files(workspace_root)
+        .context("Failed to find source files")?;
 
     // Check for stale files
     let stale = index.find_stale_files(&files)?;
@@ -2280,7 +2444,12 @@ fn ensure_index_fresh(index: &SqliteIndex, workspace_root: &Path) -> Result<()>
     tracing::info!("Auto-refreshing {} stale file(s)", stale.len());
 
     // Use batch processor for efficient update
-    let mut batch = rocketindex::batch::BatchProcessor::with_defaults(config.max_recursion_depth);
+    let mut batch = rocketindex::batch::BatchProcessor::with_defaults(config.max_recursion_depth)
+        .with_docs(config.index_docs)
+        .with_kinds(config.symbol_kinds())
+        .with_max_file_bytes(config.max_file_bytes)
+        .with_skip_generated(config.skip_generated)
+        .with_languages(config.language_configs());
 
     for (path, reason) in &stale {
         match *reason {
@@ -2482,6 +2651,67 @@ fn cmd_history(symbol: &str, format: OutputFormat, quiet: bool, _concise: bool)
     Ok(exit_codes::SUCCESS)
 }
 
+/// Show symbol-level changes between two git revisions
+fn cmd_changes(
+    old: &str,
+    new: &str,
+    format: OutputFormat,
+    quiet: bool,
+    concise: bool,
+) -> Result<u8> {
+    let cwd = std::env::current_dir()?;
+    let config = Config::load(&cwd);
+    let diff = diff::diff_revisions(&cwd, old, new, config.max_recursion_depth)?;
+
+    if format == OutputFormat::Json {
+        println!(
+            "{}",
+            if concise {
+                serde_json::to_string(&diff)?
+            } else {
+                serde_json::to_string_pretty(&diff)?
+            }
+        );
+    } else if !quiet {
+        if diff.is_empty() {
+            println!("No symbol changes between {} and {}", old, new);
+        }
+        for file in &diff.files {
+            println!("{}:", file.file.display());
+            for change in &file.changes {
+                let label = match change.change {
+                    ChangeKind::Added => "added",
+                    ChangeKind::Removed => "removed",
+                    ChangeKind::Modified => "modified",
+                    ChangeKind::Moved => "moved",
+                };
+                let from = match (&change.old_qualified, &change.old_location) {
+                    (Some(qualified), Some(location)) => format!(
+                        " (from {} at {}:{})",
+                        qualified,
+                        location.file.display(),
+                        location.line
+                    ),
+                    (None, Some(location)) if change.change == ChangeKind::Moved => {
+                        format!(" (from {}:{})", location.file.display(), location.line)
+                    }
+                    _ => String::new(),
+                };
+                println!(
+                    "  {:<8} {} (line {}){}",
+                    label, change.qualified, change.location.line, from
+                );
+            }
+        }
+    }
+
+    if diff.is_empty() {
+        Ok(exit_codes::NOT_FOUND)
+    } else {
+        Ok(exit_codes::SUCCESS)
+    }
+}
+
 /// Check RocketIndex health and configuration
 fn cmd_doctor(format: OutputFormat, quiet: bool) -> Result<u8> {
     let cwd = std::env::current_dir()?;
@@ -3298,7 +3528,7 @@ Estimated time: ~1-3 seconds per 1,000 files
     let started = Instant::now();
     println!("\nIndexing codebase...");
 
-    match cmd_index(cwd, false, 1000, false, format, quiet) {
+    match cmd_index(cwd, false, 1000, false, false, format, quiet) {
         Ok(code) if code == exit_codes::SUCCESS => {
             if !quiet {
                 println!("Indexed in {:.1?}", started.elapsed());
@@ -3477,7 +3707,7 @@ fn ensure_initial_index(cwd: &Path, format: OutputFormat, quiet: bool) -> Result
         println!("Building initial RocketIndex index (rkt index)...");
     }
 
-    match cmd_index(cwd, false, 1000, false, format, quiet) {
+    match cmd_index(cwd, false, 1000, false, false, format, quiet) {
         Ok(code) if code == exit_codes::SUCCESS => {
             if show_feedback {
                 println!(
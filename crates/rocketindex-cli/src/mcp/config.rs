@@ -17,6 +17,10 @@ pub struct McpConfig {
     /// Debounce duration for file watching (milliseconds)
     #[serde(default = "default_debounce_ms")]
     pub debounce_ms: u64,
+
+    /// Time budget for one graph traversal or fuzzy search request (milliseconds)
+    #[serde(default = "default_request_timeout_ms")]
+    pub request_timeout_ms: u64,
 }
 
 impl Default for McpConfig {
@@ -25,6 +29,7 @@ impl Default for McpConfig {
             projects: Vec::new(),
             auto_watch: default_auto_watch(),
             debounce_ms: default_debounce_ms(),
+            request_timeout_ms: default_request_timeout_ms(),
         }
     }
 }
@@ -37,6 +42,10 @@ fn default_debounce_ms() -> u64 {
     200
 }
 
+fn default_request_timeout_ms() -> u64 {
+    10_000
+}
+
 impl McpConfig {
     /// Get the config file path
     pub fn config_path() -> PathBuf {
@@ -148,6 +157,7 @@ mod tests {
         assert!(config.projects.is_empty());
         assert!(config.auto_watch);
         assert_eq!(config.debounce_ms, 200);
+        assert_eq!(config.request_timeout_ms, 10_000);
     }
 
     #[test]
@@ -2,13 +2,17 @@
 
 use anyhow::{Context, Result};
 use rayon::prelude::*;
+use rocketindex::batch::BatchProcessor;
 use rocketindex::config::Config;
-use rocketindex::watch::find_source_files_with_config;
-use rocketindex::{CodeIndex, SqliteIndex};
+use rocketindex::indexer::read_source;
+use rocketindex::parse::{extract_symbols_with, ParseResult};
+use rocketindex::warm::{LiveIndex, WarmOptions};
+use rocketindex::watch::WatchEvent;
+use rocketindex::{CodeIndex, IndexUpdate, SqliteIndex};
 use std::collections::HashMap;
 use std::path::{Path, PathBuf};
-use std::sync::{Arc, Mutex};
-use std::time::Instant;
+use std::sync::{mpsc, Arc, Mutex};
+use std::time::{Duration, Instant};
 use tokio::sync::RwLock;
 use tracing::{info, warn};
 
@@ -20,8 +24,10 @@ pub struct ProjectState {
     pub root: PathBuf,
     /// SQLite index for persistent storage
     pub sqlite: SqliteIndex,
-    /// In-memory CodeIndex for resolution
-    pub code_index: CodeIndex,
+    /// In-memory index and call graph for resolution, rebuilt in the
+    /// background after each change while the previous generation keeps
+    /// answering queries
+    pub live: LiveIndex,
     /// Whether the project has active watchers (managed by WatcherPool)
     #[allow(dead_code)]
     pub watching: bool,
@@ -46,11 +52,12 @@ impl ProjectState {
 
         // Load symbols into CodeIndex for resolution
         Self::load_code_index(&sqlite, &mut code_index)?;
+        let live = LiveIndex::new(code_index, WarmOptions::default());
 
         Ok(Self {
             root,
             sqlite,
-            code_index,
+            live,
             watching: false,
         })
     }
@@ -62,24 +69,35 @@ impl ProjectState {
 
         // Load configuration
         let config = Config::load(root);
-        let exclude_dirs = config.excluded_dirs();
 
         // Find source files
-        let files = find_source_files_with_config(root, &exclude_dirs, config.respect_gitignore)
+        let files = config
+            .find_source_files(root)
             .with_context(|| format!("Failed to find source files in {}", root.display()))?;
 
         let max_depth = config.max_recursion_depth;
+        let kinds = config.symbol_kinds();
+        let languages = config.language_configs();
 
         // Parse files in parallel
         let parse_results: Vec<_> = files
             .par_iter()
-            .filter_map(|file| match std::fs::read_to_string(file) {
+            .filter_map(|file| match read_source(file, config.max_file_bytes) {
                 Ok(source) => {
-                    let result = rocketindex::extract_symbols(file, &source, max_depth);
+                    let mut result =
+                        if config.skip_generated && languages.is_generated(file, &source) {
+                            ParseResult::default()
+                        } else {
+                            extract_symbols_with(file, &source, max_depth, &languages)
+                        };
+                    if !config.index_docs {
+                        result.strip_docs();
+                    }
+                    result.retain_kinds(kinds);
                     Some((file.clone(), result))
                 }
-                Err(e) => {
-                    warn!("Failed to read {}: {}", file.display(), e);
+                Err(reason) => {
+                    warn!("Skipping {}: {}", file.display(), reason);
                     None
                 }
             })
@@ -131,6 +149,15 @@ impl ProjectState {
 
         let symbol_count = all_symbols.len();
 
+        // Record mtimes so later refreshes only pick up changed files
+        for file in &files {
+            if let Some(mtime) = file_mtime(file) {
+                if let Err(e) = index.set_file_mtime(file, mtime) {
+                    warn!("Failed to record mtime for {}: {}", file.display(), e);
+                }
+            }
+        }
+
         // Batch insert symbols
         index
             .insert_symbols(&all_symbols)
@@ -193,15 +220,141 @@ impl ProjectState {
         Ok(())
     }
 
-    /// Reload the index from SQLite
-    pub fn reload(&mut self) -> Result<()> {
-        let db_path = self.root.join(".rocketindex").join("index.db");
-        self.sqlite = SqliteIndex::open(&db_path)?;
-        self.code_index = CodeIndex::new();
-        self.code_index.set_workspace_root(self.root.clone());
-        Self::load_code_index(&self.sqlite, &mut self.code_index)?;
-        Ok(())
+    /// Queue files already written to SQLite for the in-memory index.
+    ///
+    /// The rows of `updated` are read back from SQLite and `deleted` files
+    /// are removed. The live index applies the change in the background and
+    /// keeps serving the previous generation until it is swapped in. Returns
+    /// a receiver for what the change did, sent once it has been applied.
+    pub fn submit_changes(
+        &self,
+        updated: &[PathBuf],
+        deleted: &[PathBuf],
+    ) -> Result<mpsc::Receiver<IndexUpdate>> {
+        let mut results = Vec::with_capacity(updated.len());
+        for path in updated {
+            let result = ParseResult {
+                symbols: self.sqlite.symbols_in_file(path)?,
+                references: self.sqlite.references_in_file(path)?,
+                opens: self.sqlite.opens_for_file(path)?,
+                ..ParseResult::default()
+            };
+            results.push((path.clone(), result));
+        }
+        let deleted = deleted.to_vec();
+
+        let (applied, receiver) = mpsc::channel();
+        self.live.update(move |index| {
+            let mut update = IndexUpdate::default();
+            for path in &deleted {
+                update.merge(index.remove_file(path));
+            }
+            for (path, result) in &results {
+                update.merge(index.replace_file(path, result));
+            }
+            // The watcher does not wait for the result
+            let _ = applied.send(update.clone());
+            update
+        });
+        Ok(receiver)
     }
+
+    /// Re-index files that changed on disk since they were last indexed.
+    ///
+    /// Stale files are re-parsed into SQLite and then swapped into the
+    /// in-memory index and call graph; unchanged files are left alone.
+    /// Returns once the live index serves the refreshed files, or after
+    /// [`REFRESH_WAIT`] if it is still rebuilding.
+    pub fn refresh(&self) -> Result<RefreshStats> {
+        let config = Config::load(&self.root);
+        let files = config
+            .find_source_files(&self.root)
+            .with_context(|| format!("Failed to find source files in {}", self.root.display()))?;
+
+        let stale = self.sqlite.find_stale_files(&files)?;
+        if stale.is_empty() {
+            return Ok(RefreshStats::default());
+        }
+
+        let mut batch = BatchProcessor::with_defaults(config.max_recursion_depth)
+            .with_docs(config.index_docs)
+            .with_kinds(config.symbol_kinds())
+            .with_max_file_bytes(config.max_file_bytes)
+            .with_skip_generated(config.skip_generated)
+            .with_languages(config.language_configs());
+        for (path, reason) in &stale {
+            if *reason == "deleted" {
+                batch.add_event(WatchEvent::Deleted(path.clone()));
+            } else {
+                batch.add_event(WatchEvent::Modified(path.clone()));
+            }
+        }
+        let batch_stats = batch
+            .flush(&self.sqlite)
+            .context("Failed to write refreshed files to the index")?;
+
+        // Swap the new rows into the in-memory index without re-parsing
+        let mut updated = Vec::new();
+        let mut deleted = Vec::new();
+        for (path, reason) in &stale {
+            if *reason == "deleted" {
+                let _ = self.sqlite.delete_file_mtime(path);
+                deleted.push(path.clone());
+                continue;
+            }
+
+            if let Some(mtime) = file_mtime(path) {
+                let _ = self.sqlite.set_file_mtime(path, mtime);
+            }
+            updated.push(path.clone());
+        }
+        let update = self
+            .submit_changes(&updated, &deleted)?
+            .recv()
+            .context("The in-memory index dropped the refresh")?;
+        if !self.live.wait_ready(REFRESH_WAIT) {
+            warn!(
+                "Index for {} is still rebuilding after {:?}",
+                self.root.display(),
+                REFRESH_WAIT
+            );
+        }
+
+        Ok(RefreshStats {
+            files_updated: batch_stats.files_updated,
+            files_deleted: batch_stats.files_deleted,
+            symbols_added: update.added.len(),
+            symbols_removed: update.removed.len(),
+        })
+    }
+}
+
+/// How long [`ProjectState::refresh`] waits for the live index to swap in
+/// the refreshed files.
+pub const REFRESH_WAIT: Duration = Duration::from_secs(30);
+
+/// Summary of a [`ProjectState::refresh`]
+#[derive(Debug, Default, Clone, Copy, PartialEq, Eq)]
+pub struct RefreshStats {
+    /// Files re-indexed because they were new or modified
+    pub files_updated: usize,
+    /// Files removed because they no longer exist
+    pub files_deleted: usize,
+    /// Symbols defined by the refreshed files after the update
+    pub symbols_added: usize,
+    /// Symbols those files defined before the update
+    pub symbols_removed: usize,
+}
+
+/// Modification time of `path` in seconds since the epoch, as stored in `file_mtimes`.
+fn file_mtime(path: &Path) -> Option<u64> {
+    let modified = std::fs::metadata(path).ok()?.modified().ok()?;
+    Some(
+        modified
+            .duration_since(std::time::UNIX_EPOCH)
+            .map(|d| d.as_secs())
+            .unwrap_or(0),
+    )
 }
 
 /// Multi-project state manager
@@ -267,7 +420,7 @@ impl ProjectManager {
         info!(
             "Registered project: {} ({} symbols)",
             canonical.display(),
-            state.code_index.symbol_count()
+            state.live.current().index().symbol_count()
         );
 
         // Add to active projects
@@ -9,6 +9,7 @@ use rmcp::model::{
 };
 use rmcp::service::{RequestContext, RoleServer};
 use rmcp::{ErrorData as McpError, ServerHandler, ServiceExt};
+use rocketindex::cancel::CancelToken;
 use serde_json::json;
 use std::collections::VecDeque;
 use std::sync::Arc;
@@ -94,6 +95,8 @@ impl RateLimiter {
 pub struct RocketIndexServer {
     manager: Arc<ProjectManager>,
     rate_limiter: RateLimiter,
+    /// Deadline for each graph traversal or fuzzy search, from the start of the request
+    request_timeout: Duration,
 }
 
 impl RocketIndexServer {
@@ -103,10 +106,11 @@ impl RocketIndexServer {
     const RATE_LIMIT_WINDOW: Duration = Duration::from_secs(1);
 
     /// Create a new RocketIndex MCP server
-    pub fn new(manager: Arc<ProjectManager>) -> Self {
+    pub fn new(manager: Arc<ProjectManager>, request_timeout: Duration) -> Self {
         Self {
             manager,
             rate_limiter: RateLimiter::new(Self::RATE_LIMIT_REQUESTS, Self::RATE_LIMIT_WINDOW),
+            request_timeout,
         }
     }
 
@@ -136,6 +140,24 @@ impl RocketIndexServer {
                     "required": ["symbol"]
                 }),
             ),
+            tool(
+                "find_callees",
+                "Finds the symbols a specific function or method calls, with the location of each call. Use this tool to see what a function depends on one level down without reading its body. Calls to symbols outside the index (e.g. standard library functions) are listed separately as unresolved, each classified as external (through an imported package such as `fmt`), dynamic (through a value), or unknown.",
+                json!({
+                    "type": "object",
+                    "properties": {
+                        "symbol": {
+                            "type": "string",
+                            "description": "The fully qualified name of the calling symbol (e.g., 'MyModule.MyFunction')."
+                        },
+                        "project_root": {
+                            "type": "string",
+                            "description": "Optional path to the project root. If omitted, uses the current project context."
+                        }
+                    },
+                    "required": ["symbol"]
+                }),
+            ),
             tool(
                 "analyze_dependencies",
                 "Analyzes the dependency graph starting from a specific symbol to understand code connectivity. Use this tool to trace what a function calls (forward analysis) or what calls a function (reverse analysis) up to a specified depth. This is essential for understanding complex control flows, architectural layering, and the ripple effects of changes. Grep cannot perform this graph traversal.",
@@ -255,11 +277,89 @@ impl RocketIndexServer {
                         "limit": {
                             "type": "integer",
                             "description": "The maximum number of results to return. Default is 20."
+                        },
+                        "names": {
+                            "type": "string",
+                            "enum": ["native", "dotted", "colons", "slash"],
+                            "description": "How qualified names are written in results: as indexed (default), 'pkg.Type.Method', 'pkg::Type::Method', or 'pkg/Type/Method'."
                         }
                     },
                     "required": ["pattern"]
                 }),
             ),
+            tool(
+                "batch_query",
+                "Runs many lookups in one call: definitions, callers, callees, and symbol searches, in any mix. Use this tool instead of repeated single calls when you need several answers at once, e.g. the definitions of twenty symbols. Results come back in the same order as the queries; a query that fails reports its own error without failing the others.",
+                json!({
+                    "type": "object",
+                    "properties": {
+                        "queries": {
+                            "type": "array",
+                            "description": "The lookups to run (at most 100).",
+                            "items": {
+                                "type": "object",
+                                "properties": {
+                                    "op": {
+                                        "type": "string",
+                                        "enum": ["definition", "callers", "callees", "search"],
+                                        "description": "The kind of lookup."
+                                    },
+                                    "symbol": {
+                                        "type": "string",
+                                        "description": "For definition, callers, and callees: the qualified symbol name."
+                                    },
+                                    "pattern": {
+                                        "type": "string",
+                                        "description": "For search: the pattern, with '*' as a wildcard."
+                                    },
+                                    "limit": {
+                                        "type": "integer",
+                                        "description": "For search: maximum results. Default is 20."
+                                    }
+                                },
+                                "required": ["op"]
+                            }
+                        },
+                        "names": {
+                            "type": "string",
+                            "enum": ["native", "dotted", "colons", "slash"],
+                            "description": "How qualified names are written in answers: as indexed (default), 'pkg.Type.Method', 'pkg::Type::Method', or 'pkg/Type/Method'."
+                        },
+                        "project_root": {
+                            "type": "string",
+                            "description": "Optional path to the project root. If omitted, uses the current project context."
+                        }
+                    },
+                    "required": ["queries"]
+                }),
+            ),
+            // === MAINTENANCE ===
+            tool(
+                "reload",
+                "Re-indexes files that changed on disk since they were last indexed, without restarting the server. Use this tool after editing files if results look out of date. Only changed, new, and deleted files are processed.",
+                json!({
+                    "type": "object",
+                    "properties": {
+                        "project_root": {
+                            "type": "string",
+                            "description": "Optional path to the project root. If omitted, uses the current project context."
+                        }
+                    }
+                }),
+            ),
+            tool(
+                "index_status",
+                "Reports whether the in-memory index has caught up with changed files. After edits, the index is rebuilt in the background while queries keep answering from the previous version; this tool shows whether that rebuild is done, how many updates are pending, and how long builds took.",
+                json!({
+                    "type": "object",
+                    "properties": {
+                        "project_root": {
+                            "type": "string",
+                            "description": "Optional path to the project root. If omitted, uses the current project context."
+                        }
+                    }
+                }),
+            ),
         ]
     }
 }
@@ -287,8 +387,10 @@ impl ServerHandler for RocketIndexServer {
                  ## Tool Selection Strategy\n\
                  • **New to the code?** Start with `describe_project` to get a structural overview.\n\
                  • **Tracing logic?** Use `analyze_dependencies` to reverse-engineer how data flows through functions.\n\
-                 • **Assessing impact?** Use `find_callers` or `find_references` to see what breaks if you change a symbol.\n\
-                 • **Looking for something specific?** Use `find_definition` to jump to code, or `search_symbols` if you only know part of the name.\n\n\
+                 • **Assessing impact?** Use `find_callers` or `find_references` to see what breaks if you change a symbol, and `find_callees` to see what it calls.\n\
+                 • **Looking for something specific?** Use `find_definition` to jump to code, or `search_symbols` if you only know part of the name.\n\
+                 • **Many lookups at once?** Use `batch_query` to get several definitions, callers, callees, or searches in one call.\n\
+                 • **Edited files?** Call `reload` to re-index what changed, or `index_status` to see whether the index has caught up.\n\n\
                  Only fallback to grep if you are searching for literal strings (e.g. error messages, comments) that are not code symbols."
                     .into(),
             ),
@@ -316,6 +418,7 @@ impl ServerHandler for RocketIndexServer {
     ) -> impl std::future::Future<Output = Result<CallToolResult, McpError>> + Send + '_ {
         let manager = self.manager.clone();
         let rate_limiter = &self.rate_limiter;
+        let cancel = CancelToken::with_timeout(self.request_timeout);
         async move {
             // SECURITY: Rate limiting to prevent DoS via tool spam
             if !rate_limiter.check().await {
@@ -346,6 +449,12 @@ impl ServerHandler for RocketIndexServer {
                     Ok(tools::find_callers(manager, input).await)
                 }
 
+                "find_callees" => {
+                    let input: tools::FindCalleesInput = serde_json::from_value(args)
+                        .map_err(|e| McpError::invalid_params(e.to_string(), None))?;
+                    Ok(tools::find_callees(manager, input).await)
+                }
+
                 "find_references" => {
                     let input: tools::FindReferencesInput = serde_json::from_value(args)
                         .map_err(|e| McpError::invalid_params(e.to_string(), None))?;
@@ -355,13 +464,13 @@ impl ServerHandler for RocketIndexServer {
                 "search_symbols" => {
                     let input: tools::SearchSymbolsInput = serde_json::from_value(args)
                         .map_err(|e| McpError::invalid_params(e.to_string(), None))?;
-                    Ok(tools::search_symbols(manager, input).await)
+                    Ok(tools::search_symbols(manager, input, cancel).await)
                 }
 
                 "analyze_dependencies" => {
                     let input: tools::AnalyzeDepsInput = serde_json::from_value(args)
                         .map_err(|e| McpError::invalid_params(e.to_string(), None))?;
-                    Ok(tools::analyze_dependencies(manager, input).await)
+                    Ok(tools::analyze_dependencies(manager, input, cancel).await)
                 }
 
                 "describe_project" => {
@@ -370,6 +479,24 @@ impl ServerHandler for RocketIndexServer {
                     Ok(tools::describe_project(manager, input).await)
                 }
 
+                "batch_query" => {
+                    let input: tools::BatchQueryInput = serde_json::from_value(args)
+                        .map_err(|e| McpError::invalid_params(e.to_string(), None))?;
+                    Ok(tools::batch_query(manager, input).await)
+                }
+
+                "reload" => {
+                    let input: tools::ReloadInput = serde_json::from_value(args)
+                        .map_err(|e| McpError::invalid_params(e.to_string(), None))?;
+                    Ok(tools::reload(manager, input).await)
+                }
+
+                "index_status" => {
+                    let input: tools::IndexStatusInput = serde_json::from_value(args)
+                        .map_err(|e| McpError::invalid_params(e.to_string(), None))?;
+                    Ok(tools::index_status(manager, input).await)
+                }
+
                 _ => Ok(CallToolResult::error(vec![Content::text(format!(
                     "Unknown tool: {}",
                     name
@@ -400,7 +527,7 @@ pub async fn run_server(manager: Arc<ProjectManager>) -> anyhow::Result<()> {
         None
     };
 
-    let server = RocketIndexServer::new(manager);
+    let server = RocketIndexServer::new(manager, Duration::from_millis(config.request_timeout_ms));
     let transport = rmcp::transport::stdio();
 
     info!("Starting RocketIndex MCP server...");
@@ -361,3 +361,147 @@ async fn test_mcp_tools_use_relative_paths() {
         json
     );
 }
+
+#[tokio::test]
+async fn test_find_callees_and_reload_picks_up_new_files() {
+    use crate::mcp::tools::callees::{find_callees, FindCalleesInput};
+    use crate::mcp::tools::reload::{reload, ReloadInput};
+    use crate::mcp::tools::status::{index_status, IndexStatusInput};
+
+    let _guard = CWD_MUTEX.lock().await;
+
+    let dir = TempDir::new().unwrap();
+    let root = dir.path();
+    std::fs::write(
+        root.join("app.py"),
+        "def helper():\n    pass\n\ndef main():\n    helper()\n",
+    )
+    .unwrap();
+
+    // No index yet, so registering auto-indexes the project
+    let manager = ProjectManager::new_empty().await.unwrap();
+    manager
+        .register_in_memory(root.to_path_buf())
+        .await
+        .unwrap();
+    let manager = Arc::new(manager);
+    let root_str = root.to_str().unwrap().to_string();
+
+    let result = find_callees(
+        manager.clone(),
+        FindCalleesInput {
+            symbol: "main".to_string(),
+            project_root: Some(root_str.clone()),
+        },
+    )
+    .await;
+    let json = serde_json::to_string(&result).unwrap();
+    assert!(json.contains("helper"), "expected helper callee: {}", json);
+    assert!(json.contains("app.py"), "expected relative path: {}", json);
+
+    // Nothing changed yet, so reload has nothing to do
+    let result = reload(
+        manager.clone(),
+        ReloadInput {
+            project_root: Some(root_str.clone()),
+        },
+    )
+    .await;
+    let json = serde_json::to_string(&result).unwrap();
+    assert!(json.contains("files_updated\\\":0"), "{}", json);
+
+    std::fs::write(root.join("worker.py"), "def work():\n    helper()\n").unwrap();
+    let result = reload(
+        manager.clone(),
+        ReloadInput {
+            project_root: Some(root_str.clone()),
+        },
+    )
+    .await;
+    let json = serde_json::to_string(&result).unwrap();
+    assert!(json.contains("files_updated\\\":1"), "{}", json);
+
+    let result = find_callees(
+        manager.clone(),
+        FindCalleesInput {
+            symbol: "work".to_string(),
+            project_root: Some(root_str.clone()),
+        },
+    )
+    .await;
+    let json = serde_json::to_string(&result).unwrap();
+    assert!(
+        json.contains("helper"),
+        "reload should index worker.py: {}",
+        json
+    );
+
+    // reload waits for the rebuild, so the index has caught up
+    let result = index_status(
+        manager,
+        IndexStatusInput {
+            project_root: Some(root_str),
+        },
+    )
+    .await;
+    let json = serde_json::to_string(&result).unwrap();
+    assert!(json.contains("ready\\\":true"), "{}", json);
+    assert!(json.contains("generation\\\":1"), "{}", json);
+    assert!(json.contains("pending\\\":0"), "{}", json);
+}
+
+#[tokio::test]
+async fn test_batch_query_answers_in_order_with_per_query_errors() {
+    use crate::mcp::tools::batch::{batch_query, BatchQueryInput};
+    use rocketindex::query::Query;
+
+    let dir = TempDir::new().unwrap();
+    let root = dir.path();
+    std::fs::write(
+        root.join("app.py"),
+        "def helper():\n    pass\n\ndef main():\n    helper()\n",
+    )
+    .unwrap();
+
+    let manager = ProjectManager::new_empty().await.unwrap();
+    manager
+        .register_in_memory(root.to_path_buf())
+        .await
+        .unwrap();
+    let manager = Arc::new(manager);
+
+    let result = batch_query(
+        manager,
+        BatchQueryInput {
+            queries: vec![
+                Query::Definition {
+                    symbol: "helper".to_string(),
+                },
+                Query::Callers {
+                    symbol: "no.such.symbol".to_string(),
+                },
+                Query::Search {
+                    pattern: "hel*".to_string(),
+                    limit: 5,
+                },
+            ],
+            names: Default::default(),
+            project_root: Some(root.to_str().unwrap().to_string()),
+        },
+    )
+    .await;
+    let json = serde_json::to_string(&result).unwrap();
+    assert!(!json.contains("\"isError\":true"), "{}", json);
+
+    assert!(
+        json.contains("Symbol not found: no.such.symbol"),
+        "failed query should carry its own error: {}",
+        json
+    );
+    assert!(json.contains("app.py"), "expected relative path: {}", json);
+
+    // Answers come back in query order
+    let position = |op: &str| json.find(&format!("\\\"op\\\":\\\"{}\\\"", op)).unwrap();
+    assert!(position("definition") < position("callers"));
+    assert!(position("callers") < position("search"));
+}
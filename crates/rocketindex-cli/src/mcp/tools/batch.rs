@@ -0,0 +1,176 @@
+//! batch_query tool - many definition/callers/callees/search lookups in one call
+
+use rmcp::model::{CallToolResult, Content};
+use rocketindex::callgraph::CallSite;
+use rocketindex::naming::NameScheme;
+use rocketindex::query::{Query, QueryResult};
+use rocketindex::{CodeIndex, Symbol};
+use serde::{Deserialize, Serialize};
+use std::path::Path;
+use std::sync::Arc;
+
+use crate::mcp::format::to_relative_path;
+use crate::mcp::ProjectManager;
+
+/// Maximum number of queries accepted in one batch
+pub const MAX_BATCH_QUERIES: usize = 100;
+
+/// Input for batch_query tool
+#[derive(Debug, Deserialize)]
+pub struct BatchQueryInput {
+    /// Lookups to run, answered in order
+    pub queries: Vec<Query>,
+    /// How qualified names are written in answers (default: as indexed)
+    #[serde(default)]
+    pub names: NameScheme,
+    /// Optional project root
+    pub project_root: Option<String>,
+}
+
+/// A symbol in a definition or search answer
+#[derive(Debug, Serialize)]
+pub struct BatchSymbol {
+    pub qualified: String,
+    pub name: String,
+    pub kind: String,
+    pub file: String,
+    pub line: u32,
+    pub column: u32,
+}
+
+/// A call site in a callers or callees answer
+#[derive(Debug, Serialize)]
+pub struct BatchCall {
+    /// The caller (for callers queries) or callee (for callees queries)
+    pub symbol: String,
+    pub file: String,
+    pub line: u32,
+    pub column: u32,
+}
+
+/// The answer to one query
+#[derive(Debug, Serialize)]
+#[serde(untagged)]
+pub enum BatchAnswer {
+    Symbols(Vec<BatchSymbol>),
+    Calls(Vec<BatchCall>),
+}
+
+/// One query with its answer or error
+#[derive(Debug, Serialize)]
+pub struct BatchItem {
+    pub query: Query,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub results: Option<BatchAnswer>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub error: Option<String>,
+}
+
+/// Output for batch_query tool
+#[derive(Debug, Serialize)]
+pub struct BatchResult {
+    pub results: Vec<BatchItem>,
+    pub project_root: String,
+}
+
+/// Execute the batch_query tool
+pub async fn batch_query(manager: Arc<ProjectManager>, input: BatchQueryInput) -> CallToolResult {
+    if input.queries.len() > MAX_BATCH_QUERIES {
+        return CallToolResult::error(vec![Content::text(format!(
+            "Too many queries ({}). Send at most {} per batch.",
+            input.queries.len(),
+            MAX_BATCH_QUERIES
+        ))]);
+    }
+
+    let project_roots = manager
+        .resolve_projects(input.project_root.as_deref(), None)
+        .await;
+
+    if project_roots.is_empty() {
+        return CallToolResult::error(vec![Content::text(
+            "No projects registered. Use `register_project` to add a project first.",
+        )]);
+    }
+
+    let mut all_results = Vec::new();
+
+    for root in project_roots {
+        // One lock and one index generation for the whole batch
+        let result = manager
+            .with_project(&root, |state| {
+                let current = state.live.current();
+                let items = current
+                    .index()
+                    .batch_query(current.call_graph(), &input.queries)
+                    .into_iter()
+                    .zip(&input.queries)
+                    .map(|(outcome, query)| match outcome {
+                        Ok(answer) => BatchItem {
+                            query: query.clone(),
+                            results: Some(to_answer(answer, current.index(), &input.names, &root)),
+                            error: None,
+                        },
+                        Err(e) => BatchItem {
+                            query: query.clone(),
+                            results: None,
+                            error: Some(e.to_string()),
+                        },
+                    })
+                    .collect::<Vec<_>>();
+                items
+            })
+            .await;
+
+        if let Some(results) = result {
+            all_results.push(BatchResult {
+                results,
+                project_root: root.display().to_string(),
+            });
+        }
+    }
+
+    let json = serde_json::to_string(&all_results).unwrap_or_default();
+    CallToolResult::success(vec![Content::text(json)])
+}
+
+fn to_answer(
+    answer: QueryResult<'_>,
+    index: &CodeIndex,
+    names: &NameScheme,
+    root: &Path,
+) -> BatchAnswer {
+    // Code index locations are relative to the workspace root
+    let symbol = |s: &Symbol| BatchSymbol {
+        qualified: names.render(s).into_owned(),
+        name: s.name.clone(),
+        kind: format!("{:?}", s.kind),
+        file: to_relative_path(&root.join(&s.location.file), root),
+        line: s.location.line,
+        column: s.location.column,
+    };
+    let call = |site: &CallSite, symbol: &str| BatchCall {
+        symbol: names.render_in(index, symbol).into_owned(),
+        file: to_relative_path(&root.join(&site.location.file), root),
+        line: site.location.line,
+        column: site.location.column,
+    };
+
+    match answer {
+        QueryResult::Definition(symbols) | QueryResult::Search(symbols) => {
+            BatchAnswer::Symbols(symbols.into_iter().map(symbol).collect())
+        }
+        QueryResult::Callers(sites) => BatchAnswer::Calls(
+            sites
+                .into_iter()
+                .map(|site| call(site, &site.caller))
+                .collect(),
+        ),
+        QueryResult::Callees(sites) => BatchAnswer::Calls(
+            sites
+                .into_iter()
+                .map(|site| call(site, &site.callee))
+                .collect(),
+        ),
+    }
+}
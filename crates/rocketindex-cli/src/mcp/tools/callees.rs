@@ -0,0 +1,123 @@
+//! find_callees tool - wraps the call graph's outgoing edges
+
+use rmcp::model::{CallToolResult, Content};
+use rocketindex::callgraph::UnresolvedKind;
+use serde::{Deserialize, Serialize};
+use std::sync::Arc;
+
+use crate::mcp::format::to_relative_path;
+use crate::mcp::ProjectManager;
+
+/// Input for find_callees tool
+#[derive(Debug, Deserialize)]
+pub struct FindCalleesInput {
+    /// Symbol to find callees for (qualified name)
+    pub symbol: String,
+    /// Optional project root
+    pub project_root: Option<String>,
+}
+
+/// A single call made by the symbol
+#[derive(Debug, Serialize)]
+pub struct CalleeInfo {
+    pub callee_symbol: String,
+    /// Location of the call site
+    pub file: String,
+    pub line: u32,
+    pub column: u32,
+}
+
+/// A call whose target is not in the index (e.g. a library function)
+#[derive(Debug, Serialize)]
+pub struct UnresolvedCalleeInfo {
+    pub name: String,
+    /// `external` (imported package), `dynamic` (call through a value), or `unknown`
+    pub kind: UnresolvedKind,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub qualifier: Option<String>,
+    pub file: String,
+    pub line: u32,
+    pub column: u32,
+}
+
+/// Output for find_callees tool
+#[derive(Debug, Serialize)]
+pub struct CalleesResult {
+    pub source_symbol: String,
+    pub callees: Vec<CalleeInfo>,
+    pub callee_count: usize,
+    #[serde(skip_serializing_if = "Vec::is_empty")]
+    pub unresolved: Vec<UnresolvedCalleeInfo>,
+    pub project_root: String,
+}
+
+/// Execute the find_callees tool
+pub async fn find_callees(manager: Arc<ProjectManager>, input: FindCalleesInput) -> CallToolResult {
+    let project_roots = manager
+        .resolve_projects(input.project_root.as_deref(), None)
+        .await;
+
+    if project_roots.is_empty() {
+        return CallToolResult::error(vec![Content::text(
+            "No projects registered. Use `register_project` to add a project first.",
+        )]);
+    }
+
+    let mut all_results = Vec::new();
+
+    for root in project_roots {
+        let result = manager
+            .with_project(&root, |state| {
+                // Call graph locations are relative to the workspace root
+                let current = state.live.current();
+                let callees: Vec<CalleeInfo> = current
+                    .call_graph()
+                    .callees(&input.symbol)
+                    .into_iter()
+                    .map(|site| CalleeInfo {
+                        callee_symbol: site.callee.clone(),
+                        file: to_relative_path(&root.join(&site.location.file), &root),
+                        line: site.location.line,
+                        column: site.location.column,
+                    })
+                    .collect();
+                let unresolved: Vec<UnresolvedCalleeInfo> = current
+                    .call_graph()
+                    .unresolved_calls_from(&input.symbol)
+                    .into_iter()
+                    .map(|call| UnresolvedCalleeInfo {
+                        name: call.callee.clone(),
+                        kind: call.kind,
+                        qualifier: call.qualifier.clone(),
+                        file: to_relative_path(&root.join(&call.location.file), &root),
+                        line: call.location.line,
+                        column: call.location.column,
+                    })
+                    .collect();
+                (callees, unresolved)
+            })
+            .await;
+
+        if let Some((callees, unresolved)) = result {
+            if !callees.is_empty() || !unresolved.is_empty() {
+                all_results.push(CalleesResult {
+                    source_symbol: input.symbol.clone(),
+                    callee_count: callees.len(),
+                    callees,
+                    unresolved,
+                    project_root: root.display().to_string(),
+                });
+            }
+        }
+    }
+
+    if all_results.is_empty() {
+        return CallToolResult::success(vec![Content::text(format!(
+            "No callees found for '{}'. Use the fully qualified name, or call `reload` if the file changed recently.",
+            input.symbol
+        ))]);
+    }
+
+    let json = serde_json::to_string(&all_results).unwrap_or_default();
+    CallToolResult::success(vec![Content::text(json)])
+}
@@ -55,7 +55,8 @@ pub async fn find_callers(manager: Arc<ProjectManager>, input: FindCallersInput)
                 // Use spider with reverse=true and depth=1 to find callers
                 use rocketindex::spider::reverse_spider;
 
-                let tree = reverse_spider(&state.code_index, &input.symbol, 1);
+                let current = state.live.current();
+                let tree = reverse_spider(current.index(), &input.symbol, 1);
                 let mut callers = Vec::new();
                 for node in tree.nodes {
                     if node.depth == 1 {
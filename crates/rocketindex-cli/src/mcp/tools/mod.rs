@@ -2,16 +2,24 @@
 //!
 //! Each tool wraps an existing rkt command and exposes it via the MCP protocol.
 
+pub mod batch;
+pub mod callees;
 pub mod callers;
 pub mod definition;
 pub mod references;
+pub mod reload;
 pub mod spider;
+pub mod status;
 pub mod structure;
 pub mod symbols;
 
+pub use batch::*;
+pub use callees::*;
 pub use callers::*;
 pub use definition::*;
 pub use references::*;
+pub use reload::*;
 pub use spider::*;
+pub use status::*;
 pub use structure::*;
 pub use symbols::*;
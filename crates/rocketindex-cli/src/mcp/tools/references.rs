@@ -1,6 +1,7 @@
 //! find_references tool - wraps `rkt refs`
 
 use rmcp::model::{CallToolResult, Content};
+use rocketindex::ReferenceKind;
 use serde::{Deserialize, Serialize};
 use std::sync::Arc;
 
@@ -25,6 +26,8 @@ pub struct ReferenceInfo {
     pub file: String,
     pub line: u32,
     pub column: u32,
+    /// How the symbol is used (call, type_use, field_access, construction, unknown)
+    pub kind: ReferenceKind,
     #[serde(skip_serializing_if = "Option::is_none")]
     pub context: Option<String>,
 }
@@ -79,6 +82,7 @@ pub async fn find_references(
                                 file: to_relative_path(&r.location.file, &root_for_context),
                                 line: r.location.line,
                                 column: r.location.column,
+                                kind: r.kind,
                                 context,
                             }
                         })
@@ -0,0 +1,64 @@
+//! reload tool - re-indexes changed files without restarting the server
+
+use rmcp::model::{CallToolResult, Content};
+use serde::{Deserialize, Serialize};
+use std::sync::Arc;
+
+use crate::mcp::ProjectManager;
+
+/// Input for reload tool
+#[derive(Debug, Deserialize)]
+pub struct ReloadInput {
+    /// Optional project root
+    pub project_root: Option<String>,
+}
+
+/// Output for reload tool (one per project)
+#[derive(Debug, Serialize)]
+pub struct ReloadResult {
+    pub project_root: String,
+    pub files_updated: usize,
+    pub files_deleted: usize,
+    pub symbols_added: usize,
+    pub symbols_removed: usize,
+}
+
+/// Execute the reload tool
+pub async fn reload(manager: Arc<ProjectManager>, input: ReloadInput) -> CallToolResult {
+    let project_roots = manager
+        .resolve_projects(input.project_root.as_deref(), None)
+        .await;
+
+    if project_roots.is_empty() {
+        return CallToolResult::error(vec![Content::text(
+            "No projects registered. Use `register_project` to add a project first.",
+        )]);
+    }
+
+    let mut results = Vec::new();
+
+    for root in project_roots {
+        let refreshed = manager.with_project(&root, |state| state.refresh()).await;
+
+        match refreshed {
+            Some(Ok(stats)) => results.push(ReloadResult {
+                project_root: root.display().to_string(),
+                files_updated: stats.files_updated,
+                files_deleted: stats.files_deleted,
+                symbols_added: stats.symbols_added,
+                symbols_removed: stats.symbols_removed,
+            }),
+            Some(Err(e)) => {
+                return CallToolResult::error(vec![Content::text(format!(
+                    "Failed to reload {}: {}",
+                    root.display(),
+                    e
+                ))]);
+            }
+            None => {}
+        }
+    }
+
+    let json = serde_json::to_string(&results).unwrap_or_default();
+    CallToolResult::success(vec![Content::text(json)])
+}
@@ -1,6 +1,7 @@
 //! analyze_dependencies tool - wraps `rkt spider`
 
 use rmcp::model::{CallToolResult, Content};
+use rocketindex::cancel::CancelToken;
 use serde::{Deserialize, Serialize};
 use std::sync::Arc;
 
@@ -44,13 +45,18 @@ pub struct DependencyGraph {
     pub max_depth: usize,
     pub nodes: Vec<DependencyNode>,
     pub unresolved: Vec<String>,
+    /// Whether the traversal hit the request deadline, so `nodes` may be incomplete
+    pub truncated: bool,
     pub project_root: String,
 }
 
 /// Execute the analyze_dependencies tool
+///
+/// The traversal stops once `cancel` fires and reports what it found so far.
 pub async fn analyze_dependencies(
     manager: Arc<ProjectManager>,
     input: AnalyzeDepsInput,
+    cancel: CancelToken,
 ) -> CallToolResult {
     // Determine which project to search (CWD-aware)
     let project_roots = manager
@@ -68,15 +74,17 @@ pub async fn analyze_dependencies(
     for root in project_roots {
         let result = manager
             .with_project(&root, |state| {
-                use rocketindex::spider::{reverse_spider, spider};
+                use rocketindex::spider::{reverse_spider_with_cancel, spider_with_cancel};
 
+                let current = state.live.current();
                 let tree = if input.reverse {
-                    reverse_spider(&state.code_index, &input.symbol, input.depth)
+                    reverse_spider_with_cancel(current.index(), &input.symbol, input.depth, &cancel)
                 } else {
-                    spider(&state.code_index, &input.symbol, input.depth)
+                    spider_with_cancel(current.index(), &input.symbol, input.depth, &cancel)
                 };
 
                 let nodes: Vec<DependencyNode> = tree
+                    .value
                     .nodes
                     .into_iter()
                     .map(|n| DependencyNode {
@@ -97,7 +105,8 @@ pub async fn analyze_dependencies(
                     },
                     max_depth: input.depth,
                     nodes,
-                    unresolved: tree.unresolved,
+                    unresolved: tree.value.unresolved,
+                    truncated: tree.truncated,
                     project_root: root.display().to_string(),
                 }
             })
@@ -0,0 +1,69 @@
+//! index_status tool - reports whether the in-memory index is up to date
+
+use rmcp::model::{CallToolResult, Content};
+use serde::{Deserialize, Serialize};
+use std::sync::Arc;
+
+use crate::mcp::ProjectManager;
+
+/// Input for index_status tool
+#[derive(Debug, Deserialize)]
+pub struct IndexStatusInput {
+    /// Optional project root
+    pub project_root: Option<String>,
+}
+
+/// Output for index_status tool (one per project)
+#[derive(Debug, Serialize)]
+pub struct IndexStatusResult {
+    pub project_root: String,
+    /// Whether every change has been swapped into the served index
+    pub ready: bool,
+    /// How many updates were swapped in since the project was loaded
+    pub generation: u64,
+    /// Updates queued but not yet swapped in
+    pub pending: usize,
+    /// Symbols in the served index
+    pub symbols: usize,
+    /// Duration of the most recent background build, if there was one
+    pub last_build_ms: Option<u128>,
+    /// Total time spent in background builds
+    pub total_build_ms: u128,
+}
+
+/// Execute the index_status tool
+pub async fn index_status(manager: Arc<ProjectManager>, input: IndexStatusInput) -> CallToolResult {
+    let project_roots = manager
+        .resolve_projects(input.project_root.as_deref(), None)
+        .await;
+
+    if project_roots.is_empty() {
+        return CallToolResult::error(vec![Content::text(
+            "No projects registered. Use `register_project` to add a project first.",
+        )]);
+    }
+
+    let mut results = Vec::new();
+
+    for root in project_roots {
+        let status = manager
+            .with_project(&root, |state| {
+                let stats = state.live.stats();
+                IndexStatusResult {
+                    project_root: root.display().to_string(),
+                    ready: state.live.is_ready(),
+                    generation: stats.generation,
+                    pending: stats.pending,
+                    symbols: state.live.current().index().symbol_count(),
+                    last_build_ms: stats.last_build.map(|d| d.as_millis()),
+                    total_build_ms: stats.total_build.as_millis(),
+                }
+            })
+            .await;
+
+        results.extend(status);
+    }
+
+    let json = serde_json::to_string(&results).unwrap_or_default();
+    CallToolResult::success(vec![Content::text(json)])
+}
@@ -1,6 +1,9 @@
 //! search_symbols tool - wraps `rkt symbols`
 
 use rmcp::model::{CallToolResult, Content};
+use rocketindex::cancel::CancelToken;
+use rocketindex::naming::NameScheme;
+use rocketindex::search::{SearchError, SearchOptions};
 use serde::{Deserialize, Serialize};
 use std::sync::Arc;
 
@@ -20,6 +23,9 @@ pub struct SearchSymbolsInput {
     /// Maximum results per project (default: 20)
     #[serde(default = "default_limit")]
     pub limit: usize,
+    /// How qualified names are written in results (default: as indexed)
+    #[serde(default)]
+    pub names: NameScheme,
 }
 
 fn default_limit() -> usize {
@@ -39,9 +45,13 @@ pub struct SymbolInfo {
 }
 
 /// Execute the search_symbols tool
+///
+/// Fuzzy searches run over the in-memory index and stop with an error once
+/// `cancel` fires; pattern searches are bounded SQLite queries.
 pub async fn search_symbols(
     manager: Arc<ProjectManager>,
     input: SearchSymbolsInput,
+    cancel: CancelToken,
 ) -> CallToolResult {
     // Use CWD-aware project resolution (no explicit project_root in this tool)
     let project_roots = manager.resolve_projects(None, None).await;
@@ -56,33 +66,48 @@ pub async fn search_symbols(
 
     for root in project_roots {
         let result = manager
-            .with_project(&root, |state| {
+            .with_project(&root, |state| -> Result<Vec<SymbolInfo>, SearchError> {
                 if input.fuzzy {
-                    // Fuzzy search returns (Symbol, score)
-                    state
-                        .sqlite
-                        .fuzzy_search(&input.pattern, 2, input.limit, input.language.as_deref())
-                        .unwrap_or_default()
+                    // Filter by language before applying the limit
+                    let options = SearchOptions {
+                        names: input.names.clone(),
+                        limit: 0,
+                        cancel: Some(cancel.clone()),
+                        ..SearchOptions::default()
+                    };
+                    let current = state.live.current();
+                    let matches = current.index().search_symbols(&input.pattern, &options)?;
+                    // Code index locations are relative to the workspace root
+                    let symbols = matches
                         .into_iter()
-                        .map(|(s, _score)| SymbolInfo {
-                            qualified: s.qualified,
-                            name: s.name,
+                        .map(|m| m.symbol)
+                        .filter(|s| {
+                            input
+                                .language
+                                .as_deref()
+                                .is_none_or(|language| s.language == language)
+                        })
+                        .take(input.limit)
+                        .map(|s| SymbolInfo {
+                            qualified: input.names.render(s).into_owned(),
+                            name: s.name.clone(),
                             kind: format!("{:?}", s.kind),
-                            file: to_relative_path(&s.location.file, &root),
+                            file: to_relative_path(&root.join(&s.location.file), &root),
                             line: s.location.line,
-                            language: s.language,
+                            language: s.language.clone(),
                             project_root: root.display().to_string(),
                         })
-                        .collect::<Vec<_>>()
+                        .collect::<Vec<_>>();
+                    Ok(symbols)
                 } else {
                     // Pattern search (supports * wildcards)
-                    state
+                    Ok(state
                         .sqlite
                         .search(&input.pattern, input.limit, input.language.as_deref())
                         .unwrap_or_default()
                         .into_iter()
                         .map(|s| SymbolInfo {
-                            qualified: s.qualified,
+                            qualified: input.names.render(&s).into_owned(),
                             name: s.name,
                             kind: format!("{:?}", s.kind),
                             file: to_relative_path(&s.location.file, &root),
@@ -90,13 +115,22 @@ pub async fn search_symbols(
                             language: s.language,
                             project_root: root.display().to_string(),
                         })
-                        .collect::<Vec<_>>()
+                        .collect::<Vec<_>>())
                 }
             })
             .await
-            .unwrap_or_default();
+            .unwrap_or(Ok(Vec::new()));
 
-        all_results.extend(result);
+        match result {
+            Ok(symbols) => all_results.extend(symbols),
+            Err(SearchError::Cancelled(e)) => {
+                return CallToolResult::error(vec![Content::text(format!(
+                    "Search for '{}' stopped: {}. Try a more specific pattern.",
+                    input.pattern, e
+                ))]);
+            }
+            Err(e) => return CallToolResult::error(vec![Content::text(e.to_string())]),
+        }
     }
 
     if all_results.is_empty() {
@@ -5,7 +5,7 @@
 //! and `BatchProcessor` as the CLI `rkt watch` command.
 
 use std::collections::HashMap;
-use std::path::PathBuf;
+use std::path::{Path, PathBuf};
 use std::sync::Arc;
 use std::time::Duration;
 use tokio::sync::RwLock;
@@ -25,7 +25,7 @@ use super::ProjectManager;
 /// 1. Monitors for file changes using `DebouncedFileWatcher`
 /// 2. Batches events using `BatchProcessor`
 /// 3. Updates the SQLite index incrementally
-/// 4. Signals the ProjectManager to reload the in-memory CodeIndex
+/// 4. Queues the changed files on the project's live in-memory index
 pub struct WatcherPool {
     /// Reference to the project manager for reloading
     manager: Arc<ProjectManager>,
@@ -78,9 +78,8 @@ impl WatcherPool {
             );
         }
 
-        // Load config for max recursion depth
+        // Load config for recursion depth and indexing filters
         let config = Config::load(&canonical);
-        let max_depth = config.max_recursion_depth;
 
         // Create stop signal
         let stop_signal = Arc::new(tokio::sync::Notify::new());
@@ -97,7 +96,7 @@ impl WatcherPool {
                 root_clone.clone(),
                 db_path,
                 debounce_duration,
-                max_depth,
+                config,
                 stop_signal_clone,
                 manager,
             )
@@ -178,7 +177,7 @@ async fn run_watcher_loop(
     root: PathBuf,
     db_path: PathBuf,
     debounce_duration: Duration,
-    max_depth: usize,
+    config: Config,
     stop_signal: Arc<tokio::sync::Notify>,
     manager: Arc<ProjectManager>,
 ) -> anyhow::Result<()> {
@@ -214,7 +213,12 @@ async fn run_watcher_loop(
             return;
         }
 
-        let mut batch = BatchProcessor::new(DEFAULT_BATCH_INTERVAL, max_depth);
+        let mut batch = BatchProcessor::new(DEFAULT_BATCH_INTERVAL, config.max_recursion_depth)
+            .with_docs(config.index_docs)
+            .with_kinds(config.symbol_kinds())
+            .with_max_file_bytes(config.max_file_bytes)
+            .with_skip_generated(config.skip_generated)
+            .with_languages(config.language_configs());
 
         loop {
             // Poll for events with timeout (allows checking stop signal)
@@ -226,6 +230,10 @@ async fn run_watcher_loop(
 
             // Check if we should flush
             if batch.should_flush() {
+                let updated: Vec<PathBuf> =
+                    batch.pending_updates().map(Path::to_path_buf).collect();
+                let deleted: Vec<PathBuf> =
+                    batch.pending_deletes().map(Path::to_path_buf).collect();
                 match batch.flush(&index) {
                     Ok(stats) => {
                         if stats.files_updated > 0 || stats.files_deleted > 0 {
@@ -235,10 +243,7 @@ async fn run_watcher_loop(
                             );
                             // Signal that the index was updated
                             if event_tx
-                                .blocking_send(IndexUpdateEvent {
-                                    files_updated: stats.files_updated,
-                                    files_deleted: stats.files_deleted,
-                                })
+                                .blocking_send(IndexUpdateEvent { updated, deleted })
                                 .is_err()
                             {
                                 // Channel closed, stop the loop
@@ -274,15 +279,23 @@ async fn run_watcher_loop(
             }
             // Index was updated
             Some(event) = event_rx.recv() => {
-                if event.files_updated > 0 || event.files_deleted > 0 {
-                    // Reload the in-memory CodeIndex
-                    if manager.with_project_mut(&root, |state| {
-                        if let Err(e) = state.reload() {
-                            warn!("Failed to reload CodeIndex for {}: {}", root.display(), e);
-                        }
-                    }).await.is_some() {
-                        debug!("Reloaded CodeIndex for {}", root.display());
+                // Queue the flushed files; queries keep reading the previous
+                // index until the rebuild is swapped in
+                let submitted = manager
+                    .with_project(&root, |state| {
+                        state.submit_changes(&event.updated, &event.deleted)
+                    })
+                    .await;
+                match submitted {
+                    Some(Ok(_)) => debug!(
+                        "Queued {} changed file(s) for {}",
+                        event.updated.len() + event.deleted.len(),
+                        root.display()
+                    ),
+                    Some(Err(e)) => {
+                        warn!("Failed to update CodeIndex for {}: {}", root.display(), e)
                     }
+                    None => {}
                 }
             }
         }
@@ -296,8 +309,10 @@ async fn run_watcher_loop(
 
 /// Event sent when the index is updated
 struct IndexUpdateEvent {
-    files_updated: usize,
-    files_deleted: usize,
+    /// Files re-indexed in SQLite
+    updated: Vec<PathBuf>,
+    /// Files removed from SQLite
+    deleted: Vec<PathBuf>,
 }
 
 #[cfg(test)]
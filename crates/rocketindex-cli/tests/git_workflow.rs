@@ -156,3 +156,40 @@ fn def_git_flag_shows_provenance() -> TestResult {
 
     Ok(())
 }
+
+#[test]
+fn changes_command_reports_symbol_changes() -> TestResult {
+    let workspace = GitWorkspace::new()?;
+
+    workspace.commit_file(
+        "src/App.fs",
+        "module App\n\nlet hello() = \"world\"\n\nlet goodbye() = \"bye\"\n",
+        "Initial commit",
+    )?;
+
+    workspace.commit_file(
+        "src/App.fs",
+        "module App\n\nlet hello() = \"modified\"\n\nlet welcome() = \"hi\"\n",
+        "Replace goodbye with welcome",
+    )?;
+
+    Command::cargo_bin("rkt")?
+        .current_dir(workspace.root())
+        .args(["changes", "HEAD~1", "HEAD", "--format", "text"])
+        .assert()
+        .success()
+        .stdout(contains("src/App.fs:"))
+        .stdout(contains("modified App.hello"))
+        .stdout(contains("added    App.welcome"))
+        .stdout(contains("removed  App.goodbye"));
+
+    // Identical revisions report nothing
+    Command::cargo_bin("rkt")?
+        .current_dir(workspace.root())
+        .args(["changes", "HEAD", "HEAD", "--format", "text"])
+        .assert()
+        .code(1)
+        .stdout(contains("No symbol changes"));
+
+    Ok(())
+}
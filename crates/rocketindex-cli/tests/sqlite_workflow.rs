@@ -640,7 +640,8 @@ fn refs_symbol_json_includes_location() -> TestResult {
         .success()
         .stdout(contains("\"file\""))
         .stdout(contains("\"line\""))
-        .stdout(contains("\"column\""));
+        .stdout(contains("\"column\""))
+        .stdout(contains("\"kind\""));
 
     Ok(())
 }
@@ -875,3 +876,28 @@ fn smoke_test_all_languages() -> TestResult {
 
     Ok(())
 }
+
+#[test]
+fn index_stores_call_edges_for_sqlite_callers() -> TestResult {
+    let dir = TempDir::new()?;
+    fs::write(
+        dir.path().join("app.py"),
+        "from util import helper\n\ndef main():\n    helper()\n",
+    )?;
+    fs::write(dir.path().join("util.py"), "def helper():\n    return 1\n")?;
+
+    Command::cargo_bin("rkt")?
+        .current_dir(dir.path())
+        .args(["index", "--root", ".", "--format", "text"])
+        .assert()
+        .success();
+
+    let db =
+        rocketindex::SqliteIndex::open(&dir.path().join(".rocketindex").join(DEFAULT_DB_NAME))?;
+    let callers = db.callers("helper")?;
+    assert_eq!(callers.len(), 1);
+    assert_eq!(callers[0].caller, "main");
+    assert!(db.callees("helper")?.is_empty());
+
+    Ok(())
+}
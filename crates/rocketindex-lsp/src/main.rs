@@ -20,8 +20,8 @@ use std::sync::Arc;
 use anyhow::Result;
 use document_store::DocumentStore;
 use rocketindex::{
-    config::Config, db::DEFAULT_DB_NAME, extract_symbols, watch::find_source_files, CodeIndex,
-    SqliteIndex, SyntaxError,
+    config::Config, db::DEFAULT_DB_NAME, extract_symbols, indexer::IndexOptions,
+    watch::find_source_files, CodeIndex, SqliteIndex, SyntaxError,
 };
 use tokio::sync::RwLock;
 use tower_lsp::jsonrpc::Result as LspResult;
@@ -201,11 +201,12 @@ impl Backend {
         // Index external assemblies from .fsproj files
         self.index_external_assemblies(&mut index, &root_path).await;
 
-        for file in files {
-            if let Err(e) = self.index_file(&mut index, &file, max_depth).await {
-                warn!("Failed to index {:?}: {}", file, e);
-            }
-        }
+        // Parse in parallel; results are merged in path order
+        let options = IndexOptions {
+            max_depth,
+            ..IndexOptions::default()
+        };
+        index.index_files(&files, &options);
 
         info!(
             "Indexed {} symbols in {} files",
@@ -242,39 +243,6 @@ impl Backend {
         }
     }
 
-    /// Index a single file into the in-memory CodeIndex.
-    async fn index_file(
-        &self,
-        index: &mut CodeIndex,
-        file: &PathBuf,
-        max_depth: usize,
-    ) -> Result<()> {
-        let content = tokio::fs::read_to_string(file).await?;
-
-        // Clear existing data for this file
-        index.clear_file(file);
-
-        // Extract symbols
-        let result = extract_symbols(file, &content, max_depth);
-
-        // Add symbols to index
-        for symbol in result.symbols {
-            index.add_symbol(symbol);
-        }
-
-        // Add references
-        for reference in result.references {
-            index.add_reference(file.clone(), reference);
-        }
-
-        // Add opens
-        for open in result.opens {
-            index.add_open(file.clone(), open);
-        }
-
-        Ok(())
-    }
-
     /// Update a single file in both the in-memory index and SQLite database.
     /// Parses the file once and shares results between both indexes.
     async fn update_file(&self, file: &PathBuf) -> Result<()> {
@@ -287,17 +255,7 @@ impl Backend {
         // Update in-memory index
         {
             let mut index = self.index.write().await;
-            index.clear_file(file);
-
-            for symbol in &result.symbols {
-                index.add_symbol(symbol.clone());
-            }
-            for reference in &result.references {
-                index.add_reference(file.clone(), reference.clone());
-            }
-            for open in &result.opens {
-                index.add_open(file.clone(), open.clone());
-            }
+            index.replace_file(file, &result);
         }
 
         // Update SQLite if it exists - runs in blocking task to avoid blocking async runtime
@@ -0,0 +1,40 @@
+use criterion::{black_box, criterion_group, criterion_main, BenchmarkId, Criterion};
+use rocketindex::indexer::IndexOptions;
+use rocketindex::watch::find_source_files;
+use rocketindex::CodeIndex;
+use std::path::{Path, PathBuf};
+
+fn fixture_files() -> (PathBuf, Vec<PathBuf>) {
+    let root = Path::new(env!("CARGO_MANIFEST_DIR")).join("../../tests");
+    let files = find_source_files(&root).expect("failed to find test fixtures");
+    (root, files)
+}
+
+fn benchmark_index_files(c: &mut Criterion) {
+    let (root, files) = fixture_files();
+    let mut group = c.benchmark_group("index_files");
+
+    // threads = 0 uses one worker per available CPU
+    for threads in [1, 0] {
+        let options = IndexOptions {
+            threads,
+            ..IndexOptions::default()
+        };
+        group.bench_with_input(
+            BenchmarkId::new("threads", threads),
+            &options,
+            |b, options| {
+                b.iter(|| {
+                    let mut index = CodeIndex::with_root(root.clone());
+                    index.index_files(black_box(&files), options);
+                    index
+                })
+            },
+        );
+    }
+
+    group.finish();
+}
+
+criterion_group!(benches, benchmark_index_files);
+criterion_main!(benches);
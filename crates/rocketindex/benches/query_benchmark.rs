@@ -0,0 +1,139 @@
+//! Query latency of the in-memory index against the SQLite backend.
+//!
+//! Both hold the same synthetic workspace: `size` functions in files of 100,
+//! each calling the next one.
+
+use criterion::{black_box, criterion_group, criterion_main, BenchmarkId, Criterion};
+use rocketindex::callgraph::CallGraph;
+use rocketindex::search::SearchOptions;
+use rocketindex::{
+    CodeIndex, Location, Reference, ReferenceKind, SqliteIndex, Symbol, SymbolKind, Visibility,
+};
+use std::path::PathBuf;
+
+const SIZES: [usize; 3] = [1_000, 10_000, 100_000];
+
+struct Fixture {
+    index: CodeIndex,
+    graph: CallGraph,
+    db: SqliteIndex,
+    /// A symbol in the middle of the workspace
+    target: String,
+}
+
+fn create_fixture(size: usize) -> Fixture {
+    let mut index = CodeIndex::new();
+    for i in 0..size {
+        let file = PathBuf::from(format!("src/module_{}.py", i / 100));
+        let line = (i % 100) as u32 * 3 + 1;
+        index.add_symbol(Symbol::new(
+            format!("function_{}", i),
+            format!("function_{}", i),
+            SymbolKind::Function,
+            Location::with_end(file.clone(), line, 5, line + 2, 1),
+            Visibility::Public,
+            "python".to_string(),
+        ));
+        index.add_reference(
+            file.clone(),
+            Reference {
+                name: format!("function_{}", (i + 1) % size),
+                location: Location::new(file, line + 1, 5),
+                kind: ReferenceKind::Call,
+                target: None,
+                arguments: None,
+            },
+        );
+    }
+    let graph = CallGraph::build(&index);
+
+    let db = SqliteIndex::in_memory().unwrap();
+    let symbols: Vec<Symbol> = index.symbols().cloned().collect();
+    db.insert_symbols(&symbols).unwrap();
+    let refs: Vec<_> = index
+        .references()
+        .map(|r| (r.location.file.as_path(), r))
+        .collect();
+    db.insert_references(&refs).unwrap();
+    db.insert_calls(graph.sites()).unwrap();
+
+    Fixture {
+        index,
+        graph,
+        db,
+        target: format!("function_{}", size / 2),
+    }
+}
+
+fn benchmark_queries(c: &mut Criterion) {
+    let fixtures: Vec<(usize, Fixture)> = SIZES.iter().map(|&n| (n, create_fixture(n))).collect();
+
+    let mut group = c.benchmark_group("definition");
+    for (size, f) in &fixtures {
+        group.bench_with_input(BenchmarkId::new("memory", size), f, |b, f| {
+            b.iter(|| f.index.get(black_box(&f.target)))
+        });
+        group.bench_with_input(BenchmarkId::new("sqlite", size), f, |b, f| {
+            b.iter(|| f.db.find_by_qualified(black_box(&f.target)).unwrap())
+        });
+    }
+    group.finish();
+
+    let mut group = c.benchmark_group("references");
+    for (size, f) in &fixtures {
+        group.bench_with_input(BenchmarkId::new("memory", size), f, |b, f| {
+            b.iter(|| f.index.find_references(black_box(&f.target)))
+        });
+        group.bench_with_input(BenchmarkId::new("sqlite", size), f, |b, f| {
+            b.iter(|| f.db.find_references(black_box(&f.target)).unwrap())
+        });
+    }
+    group.finish();
+
+    let mut group = c.benchmark_group("callers");
+    for (size, f) in &fixtures {
+        group.bench_with_input(BenchmarkId::new("memory", size), f, |b, f| {
+            b.iter(|| f.graph.callers(black_box(&f.target)))
+        });
+        group.bench_with_input(BenchmarkId::new("sqlite", size), f, |b, f| {
+            b.iter(|| f.db.callers(black_box(&f.target)).unwrap())
+        });
+    }
+    group.finish();
+
+    let mut group = c.benchmark_group("callees");
+    for (size, f) in &fixtures {
+        group.bench_with_input(BenchmarkId::new("memory", size), f, |b, f| {
+            b.iter(|| f.graph.callees(black_box(&f.target)))
+        });
+        group.bench_with_input(BenchmarkId::new("sqlite", size), f, |b, f| {
+            b.iter(|| f.db.callees(black_box(&f.target)).unwrap())
+        });
+    }
+    group.finish();
+
+    let mut group = c.benchmark_group("search_symbols");
+    let options = SearchOptions {
+        limit: 20,
+        ..SearchOptions::default()
+    };
+    for (size, f) in &fixtures {
+        group.bench_with_input(BenchmarkId::new("memory", size), f, |b, f| {
+            b.iter(|| {
+                f.index
+                    .search_symbols(black_box("function_42"), &options)
+                    .unwrap()
+            })
+        });
+        group.bench_with_input(BenchmarkId::new("sqlite", size), f, |b, f| {
+            b.iter(|| {
+                f.db.search_fts(black_box("function_42*"), 20, None)
+                    .unwrap()
+            })
+        });
+    }
+    group.finish();
+}
+
+criterion_group!(benches, benchmark_queries);
+criterion_main!(benches);
@@ -0,0 +1,286 @@
+//! `TODO`, `FIXME`, `HACK` and `XXX` annotations found in comments.
+//!
+//! Every parser passes its comments through
+//! [`collect_annotations`](crate::parse::collect_annotations), which reads
+//! each comment line the same way doc comments are read and keeps the lines
+//! that start with a marker. An annotation records its kind, the author
+//! tag if one is given (`// TODO(alice): ...`), the text after the marker,
+//! and the qualified name of the innermost symbol whose declaration contains
+//! the comment. Comments outside any declaration have no symbol.
+//!
+//! Markers must be upper case and start the comment line, so prose such as
+//! `// handles the todo list` or `// see TODO.md` is not an annotation.
+//!
+//! # Examples
+//!
+//! ```
+//! use rocketindex::annotations::{AnnotationKind, Marker};
+//!
+//! let marker = Marker::parse("TODO(alice): retry on timeout").unwrap();
+//! assert_eq!(marker.kind, AnnotationKind::Todo);
+//! assert_eq!(marker.author, Some("alice"));
+//! assert_eq!(marker.text, "retry on timeout");
+//!
+//! assert!(Marker::parse("todo: lower case is prose").is_none());
+//! ```
+
+use std::fmt;
+
+use serde::{Deserialize, Serialize};
+
+use crate::{CodeIndex, Location};
+
+/// The marker that starts an annotation.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Hash, Serialize, Deserialize)]
+#[serde(rename_all = "UPPERCASE")]
+pub enum AnnotationKind {
+    Todo,
+    Fixme,
+    Hack,
+    Xxx,
+}
+
+impl AnnotationKind {
+    /// All kinds, in marker order.
+    pub const ALL: [AnnotationKind; 4] = [
+        AnnotationKind::Todo,
+        AnnotationKind::Fixme,
+        AnnotationKind::Hack,
+        AnnotationKind::Xxx,
+    ];
+
+    /// The marker as written in source.
+    pub fn marker(self) -> &'static str {
+        match self {
+            AnnotationKind::Todo => "TODO",
+            AnnotationKind::Fixme => "FIXME",
+            AnnotationKind::Hack => "HACK",
+            AnnotationKind::Xxx => "XXX",
+        }
+    }
+
+    /// Parse a kind from its marker, ignoring case (`"todo"`, `"FIXME"`).
+    pub fn parse(s: &str) -> Option<Self> {
+        Self::ALL
+            .into_iter()
+            .find(|kind| kind.marker().eq_ignore_ascii_case(s))
+    }
+}
+
+impl fmt::Display for AnnotationKind {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.write_str(self.marker())
+    }
+}
+
+/// An annotation comment in an indexed file.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct Annotation {
+    pub kind: AnnotationKind,
+    /// `alice` in `TODO(alice): ...`
+    pub author: Option<String>,
+    /// The text after the marker (may be empty)
+    pub text: String,
+    /// Where the marker is
+    pub location: Location,
+    /// Qualified name of the innermost enclosing symbol
+    pub symbol: Option<String>,
+}
+
+/// A marker at the start of one line of comment text.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub struct Marker<'a> {
+    pub kind: AnnotationKind,
+    pub author: Option<&'a str>,
+    pub text: &'a str,
+    /// Byte offset of the marker in the line
+    pub offset: usize,
+}
+
+impl<'a> Marker<'a> {
+    /// Find the marker starting `line`, a comment line with its comment
+    /// markers already removed.
+    ///
+    /// Leading whitespace and the `*` of a block comment continuation line
+    /// are skipped. The marker must be followed by the end of the line, `:`,
+    /// `(` or whitespace, so `TODOS` and `XXXL` are not markers.
+    pub fn parse(line: &'a str) -> Option<Self> {
+        let start = line.trim_start_matches(|c: char| c.is_whitespace() || c == '*');
+        let offset = line.len() - start.len();
+
+        let kind = AnnotationKind::ALL
+            .into_iter()
+            .find(|kind| start.starts_with(kind.marker()))?;
+        let mut rest = &start[kind.marker().len()..];
+        if !(rest.is_empty()
+            || rest.starts_with([':', '('])
+            || rest.starts_with(char::is_whitespace))
+        {
+            return None;
+        }
+
+        let mut author = None;
+        if let Some(tagged) = rest.strip_prefix('(') {
+            if let Some((name, after)) = tagged.split_once(')') {
+                let name = name.trim();
+                author = (!name.is_empty()).then_some(name);
+                rest = after;
+            }
+        }
+        let text = rest.trim_start();
+        let text = text.strip_prefix(':').unwrap_or(text).trim();
+
+        Some(Self {
+            kind,
+            author,
+            text,
+            offset,
+        })
+    }
+}
+
+impl CodeIndex {
+    /// List annotations of `kind`, or of every kind when `None`, ordered by
+    /// file, line and column.
+    ///
+    /// Locations are relative to the workspace root.
+    #[must_use]
+    pub fn annotations(&self, kind: Option<AnnotationKind>) -> Vec<&Annotation> {
+        let mut found: Vec<&Annotation> = self
+            .file_annotations()
+            .filter(|annotation| kind.is_none_or(|kind| annotation.kind == kind))
+            .collect();
+        found.sort_by(|a, b| {
+            (&a.location.file, a.location.line, a.location.column).cmp(&(
+                &b.location.file,
+                b.location.line,
+                b.location.column,
+            ))
+        });
+        found
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::path::{Path, PathBuf};
+
+    fn annotation(kind: AnnotationKind, file: &str, line: u32, symbol: Option<&str>) -> Annotation {
+        Annotation {
+            kind,
+            author: None,
+            text: format!("{} at {}", kind, line),
+            location: Location::new(PathBuf::from(file), line, 4),
+            symbol: symbol.map(str::to_string),
+        }
+    }
+
+    #[test]
+    fn test_markers_and_authors() {
+        let marker = Marker::parse("TODO(alice): retry on timeout").unwrap();
+        assert_eq!(marker.kind, AnnotationKind::Todo);
+        assert_eq!(marker.author, Some("alice"));
+        assert_eq!(marker.text, "retry on timeout");
+        assert_eq!(marker.offset, 0);
+
+        let marker = Marker::parse(" * FIXME handle EOF").unwrap();
+        assert_eq!(marker.kind, AnnotationKind::Fixme);
+        assert_eq!(marker.author, None);
+        assert_eq!(marker.text, "handle EOF");
+        assert_eq!(marker.offset, 3);
+
+        let marker = Marker::parse("HACK( bob ) : works around #12").unwrap();
+        assert_eq!(marker.author, Some("bob"));
+        assert_eq!(marker.text, "works around #12");
+
+        let marker = Marker::parse("XXX").unwrap();
+        assert_eq!(marker.kind, AnnotationKind::Xxx);
+        assert_eq!(marker.text, "");
+
+        // An unclosed tag is text, not an author
+        let marker = Marker::parse("TODO(alice fix this").unwrap();
+        assert_eq!(marker.author, None);
+        assert_eq!(marker.text, "(alice fix this");
+    }
+
+    #[test]
+    fn test_prose_is_not_a_marker() {
+        for line in [
+            "todo: lower case",
+            "TODOS are tracked elsewhere",
+            "XXXL shirts",
+            "see TODO.md",
+            "handles the TODO list",
+            "",
+        ] {
+            assert_eq!(Marker::parse(line), None, "{:?}", line);
+        }
+    }
+
+    #[test]
+    fn test_kind_names() {
+        assert_eq!(AnnotationKind::parse("fixme"), Some(AnnotationKind::Fixme));
+        assert_eq!(AnnotationKind::parse("XXX"), Some(AnnotationKind::Xxx));
+        assert_eq!(AnnotationKind::parse("note"), None);
+        assert_eq!(AnnotationKind::Hack.to_string(), "HACK");
+        assert_eq!(
+            serde_json::to_string(&AnnotationKind::Todo).unwrap(),
+            "\"TODO\""
+        );
+    }
+
+    #[test]
+    fn test_annotations_by_kind_in_source_order() {
+        let mut index = CodeIndex::new();
+        index.add_annotation(
+            PathBuf::from("b.go"),
+            annotation(AnnotationKind::Todo, "b.go", 3, None),
+        );
+        index.add_annotation(
+            PathBuf::from("a.go"),
+            annotation(AnnotationKind::Todo, "a.go", 9, Some("main.run")),
+        );
+        index.add_annotation(
+            PathBuf::from("a.go"),
+            annotation(AnnotationKind::Fixme, "a.go", 2, Some("main.helper")),
+        );
+
+        let lines = |found: Vec<&Annotation>| -> Vec<(String, u32)> {
+            found
+                .iter()
+                .map(|a| (a.location.file.display().to_string(), a.location.line))
+                .collect()
+        };
+        assert_eq!(
+            lines(index.annotations(None)),
+            [("a.go".into(), 2), ("a.go".into(), 9), ("b.go".into(), 3)]
+        );
+        assert_eq!(
+            lines(index.annotations(Some(AnnotationKind::Todo))),
+            [("a.go".into(), 9), ("b.go".into(), 3)]
+        );
+        assert!(index.annotations(Some(AnnotationKind::Hack)).is_empty());
+
+        index.clear_file(Path::new("a.go"));
+        assert_eq!(lines(index.annotations(None)), [("b.go".into(), 3)]);
+    }
+
+    #[test]
+    fn test_replace_file_keeps_annotations() {
+        let mut index = CodeIndex::new();
+        index.set_workspace_root(PathBuf::from("/ws"));
+        let result = crate::parse::ParseResult {
+            annotations: vec![annotation(AnnotationKind::Hack, "/ws/main.go", 5, None)],
+            ..Default::default()
+        };
+        index.replace_file(Path::new("/ws/main.go"), &result);
+
+        let found = index.annotations(None);
+        assert_eq!(found.len(), 1);
+        assert_eq!(found[0].location.file, PathBuf::from("main.go"));
+
+        index.replace_file(Path::new("/ws/main.go"), &Default::default());
+        assert!(index.annotations(None).is_empty());
+    }
+}
@@ -0,0 +1,542 @@
+//! Public API comparison between two indexes.
+//!
+//! [`diff_api`] compares the exported symbols (see
+//! [`CodeIndex::is_exported`]) of an old and a new index and reports which
+//! were added, removed, or had their signature changed. Unexported symbols
+//! are ignored, so a symbol that stops being exported is reported as
+//! removed. Locations are not compared: moving a function to another file
+//! or line is not an API change.
+//!
+//! Each change is classified as [`Compatibility::Breaking`] or
+//! [`Compatibility::Compatible`] where that can be decided:
+//!
+//! - Removing a symbol is breaking. Adding one is compatible, except a
+//!   method added to an interface, which existing implementations lack.
+//! - For signatures that can be structured (see
+//!   [`Symbol::structured_signature`]), any change to the receiver, type
+//!   parameters, parameter types, or result types is breaking: an added
+//!   parameter breaks every call site. Renaming parameters is compatible.
+//! - Other signature changes (languages without structured signatures, or a
+//!   type's declaration) are [`Compatibility::Unknown`].
+//!
+//! Symbols from generated files (see [`Symbol::is_generated`]) are compared
+//! like any other unless [`ApiDiffOptions::exclude_generated`] is set.
+//!
+//! Overloads sharing a qualified name are paired by identical signature
+//! first, and the rest are only reported as a signature change when exactly
+//! one old and one new overload remain.
+//!
+//! The result is sorted by qualified name, kind, then change, and
+//! [`ApiDiff`]'s `Display` writes one line per change, so a saved report can
+//! serve as the expected output of a golden test. Comparing against an index
+//! [saved](CodeIndex::save) from the last release makes CI fail on
+//! unexpected API changes.
+//!
+//! # Examples
+//!
+//! ```
+//! use rocketindex::api_diff::diff_api;
+//! use rocketindex::{CodeIndex, Location, Symbol, SymbolKind, Visibility};
+//! use std::path::PathBuf;
+//!
+//! let index = |signature: &str| {
+//!     let mut index = CodeIndex::new();
+//!     index.add_symbol(
+//!         Symbol::new(
+//!             "Charge".to_string(),
+//!             "billing.Charge".to_string(),
+//!             SymbolKind::Function,
+//!             Location::new(PathBuf::from("billing/charge.go"), 7, 6),
+//!             Visibility::Public,
+//!             "go".to_string(),
+//!         )
+//!         .with_signature(Some(signature.to_string())),
+//!     );
+//!     index
+//! };
+//!
+//! let old = index("func Charge(amount int64) error");
+//! let new = index("func Charge(amount int64, currency string) error");
+//!
+//! let diff = diff_api(&old, &new);
+//! assert!(diff.is_breaking());
+//! assert_eq!(
+//!     diff.to_string(),
+//!     "breaking changed Function billing.Charge (parameter added): \
+//!      func Charge(amount int64) error => func Charge(amount int64, currency string) error\n"
+//! );
+//! ```
+
+use std::collections::BTreeMap;
+use std::fmt;
+
+use serde::Serialize;
+
+use crate::signature::{Param, Signature};
+use crate::{CodeIndex, Symbol, SymbolKind};
+
+/// How an exported symbol changed.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Serialize)]
+#[serde(rename_all = "lowercase")]
+pub enum ApiChangeKind {
+    /// Only exported in the new index
+    Added,
+    /// Only exported in the old index
+    Removed,
+    /// Exported in both, with a different signature
+    Changed,
+}
+
+impl fmt::Display for ApiChangeKind {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.write_str(match self {
+            Self::Added => "added",
+            Self::Removed => "removed",
+            Self::Changed => "changed",
+        })
+    }
+}
+
+/// Whether a change can break code that uses the API.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize)]
+#[serde(rename_all = "lowercase")]
+pub enum Compatibility {
+    /// Existing users may stop compiling
+    Breaking,
+    /// Existing users keep working
+    Compatible,
+    /// The signatures could not be compared
+    Unknown,
+}
+
+impl fmt::Display for Compatibility {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.write_str(match self {
+            Self::Breaking => "breaking",
+            Self::Compatible => "compatible",
+            Self::Unknown => "unknown",
+        })
+    }
+}
+
+/// One change to the exported API.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct ApiChange {
+    /// What happened to the symbol
+    pub change: ApiChangeKind,
+    /// Qualified name of the symbol
+    pub qualified: String,
+    /// Symbol kind
+    pub kind: SymbolKind,
+    /// Whether the change can break users
+    pub compatibility: Compatibility,
+    /// What made the change breaking or compatible, when known
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub reason: Option<&'static str>,
+    /// Signature in the old index (removed and changed symbols)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub old_signature: Option<String>,
+    /// Signature in the new index (added and changed symbols)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub new_signature: Option<String>,
+}
+
+/// Result of [`diff_api`].
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize)]
+pub struct ApiDiff {
+    /// Changes sorted by qualified name, kind, change, then signatures
+    pub changes: Vec<ApiChange>,
+}
+
+impl ApiDiff {
+    /// Whether the exported API is unchanged.
+    #[must_use]
+    pub fn is_empty(&self) -> bool {
+        self.changes.is_empty()
+    }
+
+    /// Whether any change is known to be breaking.
+    #[must_use]
+    pub fn is_breaking(&self) -> bool {
+        self.changes
+            .iter()
+            .any(|c| c.compatibility == Compatibility::Breaking)
+    }
+}
+
+/// One line per change:
+/// `<compatibility> <change> <kind> <qualified> (<reason>): <old> => <new>`,
+/// leaving out the parts that are not set.
+impl fmt::Display for ApiDiff {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        for change in &self.changes {
+            write!(
+                f,
+                "{} {} {} {}",
+                change.compatibility, change.change, change.kind, change.qualified
+            )?;
+            if let Some(reason) = change.reason {
+                write!(f, " ({})", reason)?;
+            }
+            match (&change.old_signature, &change.new_signature) {
+                (Some(old), Some(new)) => write!(f, ": {} => {}", old, new)?,
+                (Some(signature), None) | (None, Some(signature)) => write!(f, ": {}", signature)?,
+                (None, None) => {}
+            }
+            writeln!(f)?;
+        }
+        Ok(())
+    }
+}
+
+/// Options for [`diff_api_with_options`].
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq)]
+pub struct ApiDiffOptions {
+    /// Leave symbols defined in generated files (see
+    /// [`Symbol::is_generated`]) out of both APIs
+    pub exclude_generated: bool,
+}
+
+/// Compare the exported symbols of `old` and `new` (see the [module docs](self)).
+#[must_use]
+pub fn diff_api(old: &CodeIndex, new: &CodeIndex) -> ApiDiff {
+    diff_api_with_options(old, new, &ApiDiffOptions::default())
+}
+
+/// [`diff_api`] with [`ApiDiffOptions`].
+#[must_use]
+pub fn diff_api_with_options(
+    old: &CodeIndex,
+    new: &CodeIndex,
+    options: &ApiDiffOptions,
+) -> ApiDiff {
+    let old_api = exported(old, options);
+    let mut new_api = exported(new, options);
+
+    let mut changes = Vec::new();
+    for (key, mut removed) in old_api {
+        let mut added = new_api.remove(&key).unwrap_or_default();
+        // Overloads with an identical signature are unchanged
+        removed.retain(|old_symbol| {
+            match added
+                .iter()
+                .position(|s| s.signature == old_symbol.signature)
+            {
+                Some(i) => {
+                    added.remove(i);
+                    false
+                }
+                None => true,
+            }
+        });
+        if let ([old_symbol], [new_symbol]) = (removed.as_slice(), added.as_slice()) {
+            changes.push(signature_change(old_symbol, new_symbol));
+            continue;
+        }
+        changes.extend(removed.into_iter().map(removal));
+        changes.extend(added.into_iter().map(|symbol| addition(new, symbol)));
+    }
+    for added in new_api.into_values() {
+        changes.extend(added.into_iter().map(|symbol| addition(new, symbol)));
+    }
+
+    changes.sort_by(|a, b| {
+        a.qualified
+            .cmp(&b.qualified)
+            .then_with(|| a.kind.to_string().cmp(&b.kind.to_string()))
+            .then(a.change.cmp(&b.change))
+            .then_with(|| a.old_signature.cmp(&b.old_signature))
+            .then_with(|| a.new_signature.cmp(&b.new_signature))
+    });
+    ApiDiff { changes }
+}
+
+/// Exported symbols of `index`, grouped by qualified name and kind.
+fn exported<'a>(
+    index: &'a CodeIndex,
+    options: &ApiDiffOptions,
+) -> BTreeMap<(&'a str, String), Vec<&'a Symbol>> {
+    let mut api: BTreeMap<(&str, String), Vec<&Symbol>> = BTreeMap::new();
+    for symbol in index
+        .symbols()
+        .filter(|s| index.is_exported(s))
+        .filter(|s| !options.exclude_generated || !s.is_generated())
+    {
+        api.entry((symbol.qualified.as_str(), symbol.kind.to_string()))
+            .or_default()
+            .push(symbol);
+    }
+    api
+}
+
+fn removal(symbol: &Symbol) -> ApiChange {
+    ApiChange {
+        change: ApiChangeKind::Removed,
+        qualified: symbol.qualified.clone(),
+        kind: symbol.kind,
+        compatibility: Compatibility::Breaking,
+        reason: None,
+        old_signature: symbol.signature.clone(),
+        new_signature: None,
+    }
+}
+
+fn addition(index: &CodeIndex, symbol: &Symbol) -> ApiChange {
+    let to_interface = index
+        .enclosing_type(symbol)
+        .is_some_and(|owner| owner.kind == SymbolKind::Interface);
+    let (compatibility, reason) = if to_interface {
+        (Compatibility::Breaking, Some("method added to interface"))
+    } else {
+        (Compatibility::Compatible, None)
+    };
+    ApiChange {
+        change: ApiChangeKind::Added,
+        qualified: symbol.qualified.clone(),
+        kind: symbol.kind,
+        compatibility,
+        reason,
+        old_signature: None,
+        new_signature: symbol.signature.clone(),
+    }
+}
+
+fn signature_change(old: &Symbol, new: &Symbol) -> ApiChange {
+    let (compatibility, reason) = match (old.structured_signature(), new.structured_signature()) {
+        (Some(before), Some(after)) => classify(&before, &after),
+        _ => (Compatibility::Unknown, None),
+    };
+    ApiChange {
+        change: ApiChangeKind::Changed,
+        qualified: new.qualified.clone(),
+        kind: new.kind,
+        compatibility,
+        reason,
+        old_signature: old.signature.clone(),
+        new_signature: new.signature.clone(),
+    }
+}
+
+/// The first difference between two structured signatures that matters to
+/// callers, or a compatible one if only names changed.
+fn classify(before: &Signature, after: &Signature) -> (Compatibility, Option<&'static str>) {
+    let types = |params: &[Param]| {
+        params
+            .iter()
+            .map(|p| (p.ty.clone(), p.variadic))
+            .collect::<Vec<_>>()
+    };
+    let breaking =
+        if before.receiver.as_ref().map(|r| &r.ty) != after.receiver.as_ref().map(|r| &r.ty) {
+            Some("receiver changed")
+        } else if types(&before.type_params) != types(&after.type_params) {
+            Some("type parameters changed")
+        } else if after.params.len() > before.params.len() {
+            Some("parameter added")
+        } else if after.params.len() < before.params.len() {
+            Some("parameter removed")
+        } else if types(&before.params) != types(&after.params) {
+            Some("parameter type changed")
+        } else if types(&before.results) != types(&after.results) {
+            Some("results changed")
+        } else {
+            None
+        };
+    match breaking {
+        Some(reason) => (Compatibility::Breaking, Some(reason)),
+        None => (Compatibility::Compatible, Some("names changed")),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::{Location, Visibility};
+    use std::path::PathBuf;
+
+    fn symbol(
+        qualified: &str,
+        kind: SymbolKind,
+        visibility: Visibility,
+        signature: Option<&str>,
+    ) -> Symbol {
+        // `pkg.Type.Member` is a member of `Type`
+        let segments: Vec<&str> = qualified.split('.').collect();
+        let parent = (segments.len() == 3).then(|| segments[1].to_string());
+        Symbol::new(
+            segments[segments.len() - 1].to_string(),
+            qualified.to_string(),
+            kind,
+            Location::new(PathBuf::from("api.go"), 1, 6),
+            visibility,
+            "go".to_string(),
+        )
+        .with_parent(parent)
+        .with_signature(signature.map(str::to_string))
+    }
+
+    fn index(symbols: Vec<Symbol>) -> CodeIndex {
+        let mut index = CodeIndex::new();
+        for symbol in symbols {
+            index.add_symbol(symbol);
+        }
+        index
+    }
+
+    fn public(qualified: &str, kind: SymbolKind, signature: &str) -> Symbol {
+        symbol(qualified, kind, Visibility::Public, Some(signature))
+    }
+
+    #[test]
+    fn test_golden_report() {
+        use SymbolKind::{Class, Function, Interface};
+        let old = index(vec![
+            public("api.Store", Interface, "type Store interface"),
+            public("api.Store.Get", Function, "Get(key string) ([]byte, error)"),
+            public("api.Open", Function, "func Open(path string) (*DB, error)"),
+            public("api.Close", Function, "func Close(db *DB) error"),
+            public("api.Sync", Function, "func Sync(db *DB)"),
+            public("api.Legacy", Function, "func Legacy()"),
+            public("api.Config", Class, "type Config struct"),
+            symbol(
+                "api.helper",
+                Function,
+                Visibility::Private,
+                Some("func helper()"),
+            ),
+        ]);
+        let mut moved = public("api.Sync", Function, "func Sync(target *DB)");
+        moved.location = Location::new(PathBuf::from("sync.go"), 40, 6);
+        let new = index(vec![
+            public("api.Store", Interface, "type Store interface"),
+            public("api.Store.Get", Function, "Get(key string) ([]byte, error)"),
+            public(
+                "api.Store.Put",
+                Function,
+                "Put(key string, value []byte) error",
+            ),
+            public(
+                "api.Open",
+                Function,
+                "func Open(path string, readOnly bool) (*DB, error)",
+            ),
+            public("api.Close", Function, "func Close(db *DB) error"),
+            moved,
+            public("api.Config", Class, "type Config struct"),
+            public("api.Version", Function, "func Version() string"),
+            symbol(
+                "api.helper",
+                Function,
+                Visibility::Private,
+                Some("func helper(x int)"),
+            ),
+        ]);
+
+        let diff = diff_api(&old, &new);
+
+        assert_eq!(
+            diff.to_string(),
+            "\
+breaking removed Function api.Legacy: func Legacy()
+breaking changed Function api.Open (parameter added): func Open(path string) (*DB, error) => func Open(path string, readOnly bool) (*DB, error)
+breaking added Function api.Store.Put (method added to interface): Put(key string, value []byte) error
+compatible changed Function api.Sync (names changed): func Sync(db *DB) => func Sync(target *DB)
+compatible added Function api.Version: func Version() string
+"
+        );
+        assert!(diff.is_breaking());
+        assert!(diff_api(&new, &new).is_empty());
+    }
+
+    #[test]
+    fn test_unexporting_is_a_removal_and_overloads_pair_by_signature() {
+        let old = index(vec![
+            symbol(
+                "acme.Parser.parse",
+                SymbolKind::Function,
+                Visibility::Public,
+                Some("public Node parse(String source)"),
+            ),
+            symbol(
+                "acme.Parser.parse",
+                SymbolKind::Function,
+                Visibility::Public,
+                Some("public Node parse(Reader source)"),
+            ),
+            public("acme.Lexer", SymbolKind::Class, "public class Lexer"),
+        ]);
+        let new = index(vec![
+            symbol(
+                "acme.Parser.parse",
+                SymbolKind::Function,
+                Visibility::Public,
+                Some("public Node parse(Reader source)"),
+            ),
+            symbol("acme.Lexer", SymbolKind::Class, Visibility::Private, None),
+        ]);
+
+        let diff = diff_api(&old, &new);
+        let changes: Vec<(ApiChangeKind, &str, Option<&str>)> = diff
+            .changes
+            .iter()
+            .map(|c| (c.change, c.qualified.as_str(), c.old_signature.as_deref()))
+            .collect();
+        assert_eq!(
+            changes,
+            vec![
+                (
+                    ApiChangeKind::Removed,
+                    "acme.Lexer",
+                    Some("public class Lexer")
+                ),
+                (
+                    ApiChangeKind::Removed,
+                    "acme.Parser.parse",
+                    Some("public Node parse(String source)")
+                ),
+            ]
+        );
+    }
+
+    #[test]
+    fn test_unstructured_signature_change_is_unknown() {
+        let old = index(vec![public(
+            "api.Color",
+            SymbolKind::Type,
+            "type Color int",
+        )]);
+        let new = index(vec![public(
+            "api.Color",
+            SymbolKind::Type,
+            "type Color string",
+        )]);
+
+        let diff = diff_api(&old, &new);
+        assert_eq!(diff.changes[0].compatibility, Compatibility::Unknown);
+        assert!(!diff.is_breaking());
+        let json = serde_json::to_value(&diff).unwrap();
+        assert_eq!(json["changes"][0]["change"], "changed");
+        assert!(json["changes"][0].get("reason").is_none());
+    }
+
+    #[test]
+    fn test_generated_symbols_can_be_left_out() {
+        let mut stub = public("pb.Client", SymbolKind::Class, "type Client struct");
+        stub.attributes = Some(vec![crate::generated::GENERATED.to_string()]);
+        let old = index(vec![]);
+        let new = index(vec![
+            stub,
+            public("api.Open", SymbolKind::Function, "func Open()"),
+        ]);
+
+        assert_eq!(diff_api(&old, &new).changes.len(), 2);
+        let options = ApiDiffOptions {
+            exclude_generated: true,
+        };
+        let diff = diff_api_with_options(&old, &new, &options);
+        assert_eq!(
+            diff.to_string(),
+            "compatible added Function api.Open: func Open()\n"
+        );
+    }
+}
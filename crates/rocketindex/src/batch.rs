@@ -8,9 +8,16 @@ use std::collections::HashSet;
 use std::path::{Path, PathBuf};
 use std::time::{Duration, Instant};
 
-use crate::db::{symbol_kind_to_str, visibility_to_str, SqliteIndex};
+use crate::callgraph::{CallGraphOptions, CallResolver, CallSite};
+use crate::db::{
+    insert_calls_in_tx, reference_kind_to_str, short_name, symbol_kind_to_str, visibility_to_str,
+    SqliteIndex,
+};
+use crate::indexer::{read_source, Diagnostic, DEFAULT_MAX_FILE_BYTES};
+use crate::language_config::LanguageConfigs;
+use crate::parse::{extract_symbols_with, ParseResult};
 use crate::watch::WatchEvent;
-use crate::{extract_symbols, IndexError};
+use crate::{IndexError, KindSet, Location};
 
 /// Default batch interval (how long to wait before flushing)
 pub const DEFAULT_BATCH_INTERVAL: Duration = Duration::from_millis(100);
@@ -48,6 +55,16 @@ pub struct BatchProcessor {
     batch_interval: Duration,
     /// Maximum recursion depth for symbol extraction
     max_depth: usize,
+    /// Whether to keep symbol doc comments
+    docs: bool,
+    /// Symbol kinds to store
+    kinds: KindSet,
+    /// Skip files larger than this many bytes (0 = no limit)
+    max_file_bytes: u64,
+    /// Leave files with a generated-code header out of the index
+    skip_generated: bool,
+    /// Generated-code markers and doc comment syntax per language
+    languages: LanguageConfigs,
 }
 
 /// Statistics from a batch flush operation
@@ -61,6 +78,11 @@ pub struct BatchStats {
     pub symbols_inserted: usize,
     /// Number of references inserted
     pub references_inserted: usize,
+    /// Number of call sites inserted
+    pub calls_inserted: usize,
+    /// Files that were skipped and cleared instead of parsed, with the
+    /// reason (see [`read_source`])
+    pub diagnostics: Vec<Diagnostic>,
     /// Time taken to process the batch
     pub duration: Duration,
 }
@@ -74,6 +96,11 @@ impl BatchProcessor {
             batch_start: None,
             batch_interval,
             max_depth,
+            docs: true,
+            kinds: KindSet::all(),
+            max_file_bytes: DEFAULT_MAX_FILE_BYTES,
+            skip_generated: false,
+            languages: LanguageConfigs::default(),
         }
     }
 
@@ -82,6 +109,37 @@ impl BatchProcessor {
         Self::new(DEFAULT_BATCH_INTERVAL, max_depth)
     }
 
+    /// Set whether symbol doc comments are stored (default: true).
+    pub fn with_docs(mut self, docs: bool) -> Self {
+        self.docs = docs;
+        self
+    }
+
+    /// Set which symbol kinds are stored (default: all).
+    pub fn with_kinds(mut self, kinds: KindSet) -> Self {
+        self.kinds = kinds;
+        self
+    }
+
+    /// Set the size above which files are skipped (default: 4 MiB, 0 = no limit).
+    pub fn with_max_file_bytes(mut self, max_file_bytes: u64) -> Self {
+        self.max_file_bytes = max_file_bytes;
+        self
+    }
+
+    /// Set whether files with a generated-code header are left out (default: false).
+    pub fn with_skip_generated(mut self, skip_generated: bool) -> Self {
+        self.skip_generated = skip_generated;
+        self
+    }
+
+    /// Set the language conventions files are parsed with (default: the
+    /// built-in ones).
+    pub fn with_languages(mut self, languages: LanguageConfigs) -> Self {
+        self.languages = languages;
+        self
+    }
+
     /// Add a watch event to the batch.
     ///
     /// Events are deduplicated: multiple modifications to the same file
@@ -157,6 +215,10 @@ impl BatchProcessor {
 
     /// Flush the batch, processing all pending changes in a single transaction.
     ///
+    /// Calls from the updated files are resolved against every stored symbol,
+    /// which is loaded into memory for the flush, and stored calls into
+    /// symbols that were removed are deleted.
+    ///
     /// Returns statistics about the flush operation.
     pub fn flush(&mut self, index: &SqliteIndex) -> Result<BatchStats, IndexError> {
         let flush_start = Instant::now();
@@ -180,17 +242,60 @@ impl BatchProcessor {
             if !path.exists() {
                 continue;
             }
-            let source = match std::fs::read_to_string(path) {
+            let source = match read_source(path, self.max_file_bytes) {
                 Ok(s) => s,
-                Err(e) => {
-                    tracing::warn!("Failed to read file {:?}: {}", path, e);
+                Err(reason) => {
+                    // Clear the file's rows, so a file that became too large
+                    // or binary does not keep serving its old symbols
+                    tracing::warn!("Skipping file {:?}: {}", path, reason);
+                    stats.diagnostics.push(Diagnostic {
+                        file: path.clone(),
+                        message: reason.to_string(),
+                        location: Some(Location::new(path.clone(), 1, 1)),
+                    });
+                    parsed_files.push((path.clone(), ParseResult::default()));
                     continue;
                 }
             };
-            let result = extract_symbols(path, &source, self.max_depth);
+            let mut result = if self.skip_generated && self.languages.is_generated(path, &source) {
+                ParseResult::default()
+            } else {
+                extract_symbols_with(path, &source, self.max_depth, &self.languages)
+            };
+            if !self.docs {
+                result.strip_docs();
+            }
+            result.retain_kinds(self.kinds);
+            for reference in &mut result.references {
+                reference.location.file = path.clone();
+            }
             parsed_files.push((path.clone(), result));
         }
 
+        // Resolve the updated files' calls against every stored symbol, with
+        // the changed files' old definitions replaced by the new ones
+        let calls: Vec<Vec<CallSite>> = if parsed_files.is_empty() {
+            Vec::new()
+        } else {
+            let mut table = index.symbol_table(None)?;
+            for path in deletes.iter().chain(&updates) {
+                table.remove_file(path);
+            }
+            for (path, result) in &parsed_files {
+                for symbol in &result.symbols {
+                    table.add_symbol(symbol.clone());
+                }
+                for open in &result.opens {
+                    table.add_open(path.clone(), open.clone());
+                }
+            }
+            let resolver = CallResolver::new(&table, CallGraphOptions::default());
+            parsed_files
+                .iter()
+                .map(|(_, result)| resolver.resolve(&result.references).0)
+                .collect()
+        };
+
         // Now process everything in a single transaction
         index.with_transaction(|tx| {
             // Process deletes first (in case a file was renamed)
@@ -203,7 +308,7 @@ impl BatchProcessor {
             }
 
             // Process updates
-            for (path, result) in &parsed_files {
+            for ((path, result), calls) in parsed_files.iter().zip(&calls) {
                 // Clear existing data for this file
                 if let Err(e) = Self::clear_file_in_tx(tx, path) {
                     tracing::warn!("Failed to clear file {:?}: {}", path, e);
@@ -235,9 +340,22 @@ impl BatchProcessor {
                     }
                 }
 
+                // Insert call sites
+                if let Err(e) = insert_calls_in_tx(tx, calls) {
+                    tracing::warn!("Failed to insert calls: {}", e);
+                } else {
+                    stats.calls_inserted += calls.len();
+                }
+
                 stats.files_updated += 1;
             }
 
+            // Drop calls from unchanged files into symbols that were removed
+            tx.execute(
+                "DELETE FROM calls WHERE callee NOT IN (SELECT qualified FROM symbols)",
+                [],
+            )?;
+
             Ok(())
         })?;
 
@@ -260,6 +378,10 @@ impl BatchProcessor {
             "DELETE FROM opens WHERE file = ?1",
             rusqlite::params![file_str.as_ref()],
         )?;
+        tx.execute(
+            "DELETE FROM calls WHERE file = ?1",
+            rusqlite::params![file_str.as_ref()],
+        )?;
         Ok(())
     }
 
@@ -269,8 +391,8 @@ impl BatchProcessor {
         symbol: &crate::Symbol,
     ) -> Result<(), IndexError> {
         tx.execute(
-            "INSERT INTO symbols (name, qualified, kind, file, line, column, end_line, end_column, visibility, source, language, parent, mixins, attributes, implements, doc, signature)
-             VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, 'syntactic', ?10, ?11, ?12, ?13, ?14, ?15, ?16)",
+            "INSERT INTO symbols (name, qualified, kind, file, line, column, end_line, end_column, visibility, source, language, parent, mixins, attributes, implements, doc, signature, extent_line, extent_end_line)
+             VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, 'syntactic', ?10, ?11, ?12, ?13, ?14, ?15, ?16, ?17, ?18)",
             rusqlite::params![
                 symbol.name,
                 symbol.qualified,
@@ -288,6 +410,8 @@ impl BatchProcessor {
                 symbol.implements.as_ref().map(|v| serde_json::to_string(v).unwrap_or_default()),
                 symbol.doc,
                 symbol.signature,
+                symbol.extent.map(|e| e.line),
+                symbol.extent.map(|e| e.end_line),
             ],
         )?;
         Ok(())
@@ -301,12 +425,19 @@ impl BatchProcessor {
     ) -> Result<(), IndexError> {
         let file_str = file.to_string_lossy();
         tx.execute(
-            "INSERT INTO refs (name, file, line, column) VALUES (?1, ?2, ?3, ?4)",
+            "INSERT INTO refs (name, short_name, file, line, column, kind, target, arguments) VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8)",
             rusqlite::params![
                 reference.name,
+                short_name(&reference.name),
                 file_str.as_ref(),
                 reference.location.line,
                 reference.location.column,
+                reference_kind_to_str(reference.kind),
+                reference.target,
+                reference
+                    .arguments
+                    .as_ref()
+                    .map(|v| serde_json::to_string(v).unwrap_or_default()),
             ],
         )?;
         Ok(())
@@ -569,6 +700,77 @@ mod tests {
         assert!(stats.symbols_inserted > 0);
     }
 
+    #[test]
+    fn test_flushed_references_match_by_last_segment() {
+        let dir = tempfile::TempDir::new().unwrap();
+        let test_file = dir.path().join("run.py");
+        std::fs::write(&test_file, "def run():\n    self.client.get()\n").unwrap();
+
+        let mut batch = BatchProcessor::with_defaults(500);
+        batch.add_event(WatchEvent::Created(test_file));
+        let index = SqliteIndex::in_memory().unwrap();
+        batch.flush(&index).unwrap();
+
+        let refs = index.find_references("get").unwrap();
+        assert!(refs.iter().any(|r| r.name == "self.client.get"));
+    }
+
+    #[test]
+    fn test_flush_stores_calls_for_sqlite_callers() {
+        let dir = tempfile::TempDir::new().unwrap();
+        let a = dir.path().join("a.py");
+        let b = dir.path().join("b.py");
+        std::fs::write(&a, "from b import helper\n\ndef main():\n    helper()\n").unwrap();
+        std::fs::write(&b, "def helper():\n    return 1\n").unwrap();
+
+        let index = SqliteIndex::in_memory().unwrap();
+        let mut batch = BatchProcessor::with_defaults(500);
+        batch.add_event(WatchEvent::Created(a.clone()));
+        batch.add_event(WatchEvent::Created(b.clone()));
+        let stats = batch.flush(&index).unwrap();
+        assert!(stats.calls_inserted > 0);
+        assert_eq!(index.callers("helper").unwrap()[0].caller, "main");
+
+        // Editing a.py replaces its calls
+        std::fs::write(&a, "def main():\n    pass\n").unwrap();
+        batch.add_event(WatchEvent::Modified(a));
+        batch.flush(&index).unwrap();
+        assert!(index.callers("helper").unwrap().is_empty());
+
+        // Deleting b.py drops calls into it from files that were not flushed
+        let c = dir.path().join("c.py");
+        std::fs::write(&c, "from b import helper\n\ndef run():\n    helper()\n").unwrap();
+        batch.add_event(WatchEvent::Created(c));
+        batch.flush(&index).unwrap();
+        assert_eq!(index.callers("helper").unwrap()[0].caller, "run");
+        std::fs::remove_file(&b).unwrap();
+        batch.add_event(WatchEvent::Deleted(b));
+        batch.flush(&index).unwrap();
+        assert!(index.callers("helper").unwrap().is_empty());
+    }
+
+    #[test]
+    fn test_flush_clears_files_that_are_now_skipped() {
+        let dir = tempfile::TempDir::new().unwrap();
+        let file = dir.path().join("lib.rs");
+        std::fs::write(&file, "fn hello() {}\n").unwrap();
+
+        let index = SqliteIndex::in_memory().unwrap();
+        let mut batch = BatchProcessor::with_defaults(500);
+        batch.add_event(WatchEvent::Created(file.clone()));
+        batch.flush(&index).unwrap();
+        assert!(!index.symbols_in_file(&file).unwrap().is_empty());
+
+        std::fs::write(&file, b"fn hello() {}\0\n").unwrap();
+        batch.add_event(WatchEvent::Modified(file.clone()));
+        let stats = batch.flush(&index).unwrap();
+
+        assert!(index.symbols_in_file(&file).unwrap().is_empty());
+        assert_eq!(stats.diagnostics.len(), 1);
+        assert_eq!(stats.diagnostics[0].file, file);
+        assert_eq!(stats.diagnostics[0].message, "skipped: binary file");
+    }
+
     #[test]
     fn test_complex_event_sequence() {
         let mut batch = BatchProcessor::with_defaults(500);
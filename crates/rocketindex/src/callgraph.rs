@@ -0,0 +1,2613 @@
+//! Call graph: caller/callee edges derived from indexed references.
+//!
+//! The [`CallGraph`] resolves every reference in a [`CodeIndex`] to the callable
+//! symbol it names and attributes it to the callable symbol that contains it.
+//! The resulting edges can be queried in both directions:
+//!
+//! - [`CallGraph::callees`]: what does this function call?
+//! - [`CallGraph::callers`]: who calls this function?
+//! - [`CallGraph::reachable_from`]: what does this function transitively call?
+//! - [`CallGraph::paths`]: how does one function end up calling another?
+//! - [`CallGraph::cycles`]: which functions are recursive, directly or mutually?
+//! - [`CallGraph::unreachable_symbols`]: which functions are never called from the entry points?
+//!
+//! Calls the parser marked as [`ReferenceKind::Call`] that resolve to no
+//! indexed symbol (builtins, dynamic dispatch, unindexed libraries) are kept
+//! as [`UnresolvedCall`]s with the callee name as written, see
+//! [`CallGraph::unresolved_calls`]. Each is classified by its qualifier: a
+//! call through an imported package (`fmt.Println` in a file importing `fmt`)
+//! is [`UnresolvedKind::External`], a call through any other expression
+//! (`self.client.get`) is [`UnresolvedKind::Dynamic`], and a bare name is
+//! [`UnresolvedKind::Unknown`]. A call to a C or C++ function-like macro is
+//! [`UnresolvedKind::Macro`], since what it expands to is not indexed.
+//!
+//! A C or C++ function declared in a header and defined in a source file is
+//! one callee: calls go to the definition (see [`crate::declarations`]).
+//!
+//! Overloaded methods share a qualified name. When several overloads match
+//! a call and the parser recorded its argument types
+//! ([`Reference::arguments`]), the overloads those arguments select are kept
+//! by the callee language's
+//! [`crate::resolve::SymbolResolver::select_overloads`] (see
+//! [`crate::languages::java::overloads`]). An edge to a single overload
+//! records its signature in [`CallSite::overload`]; a call the arguments
+//! cannot narrow to one lists the remaining overloads in
+//! [`CallSite::candidates`].
+//!
+//! Calls through function values ([`ReferenceKind::IndirectCall`]) become
+//! [`CallKind::Indirect`] edges to the function the value holds, and a closure
+//! the parser records as a symbol is called by the function defining it and
+//! owns the calls in its body. [`CallGraphOptions::indirect_calls`] turns this
+//! off.
+//!
+//! The traversals have `_with_cancel` variants that stop when a
+//! [`CancelToken`] is cancelled or its deadline passes, returning what they
+//! found so far (see [`crate::cancel`]).
+//!
+//! After an incremental [`CodeIndex::update_files`], [`CallGraph::apply_update`]
+//! patches the affected edges instead of rebuilding the whole graph.
+//!
+//! Unlike [`crate::spider`], which resolves references lazily during a single
+//! traversal, the call graph is built once and can answer many queries cheaply.
+//!
+//! # Examples
+//!
+//! ```
+//! use rocketindex::callgraph::CallGraph;
+//! use rocketindex::{
+//!     CodeIndex, Location, Reference, ReferenceKind, Symbol, SymbolKind, Visibility,
+//! };
+//! use std::path::PathBuf;
+//!
+//! let mut index = CodeIndex::new();
+//! for (name, line) in [("helper", 1), ("run", 5)] {
+//!     index.add_symbol(Symbol::new(
+//!         name.to_string(),
+//!         format!("main.{}", name),
+//!         SymbolKind::Function,
+//!         Location::new(PathBuf::from("main.go"), line, 6),
+//!         Visibility::Private,
+//!         "go".to_string(),
+//!     ));
+//! }
+//! index.add_reference(
+//!     PathBuf::from("main.go"),
+//!     Reference {
+//!         name: "helper".to_string(),
+//!         location: Location::new(PathBuf::from("main.go"), 6, 5),
+//!         kind: ReferenceKind::Unknown,
+//!         target: None,
+//!         arguments: None,
+//!     },
+//! );
+//!
+//! let graph = CallGraph::build(&index);
+//!
+//! let callers = graph.callers("main.helper");
+//! assert_eq!(callers.len(), 1);
+//! assert_eq!(callers[0].caller, "main.run");
+//! assert_eq!(callers[0].location.line, 6);
+//!
+//! let callees = graph.callees("main.run");
+//! assert_eq!(callees[0].callee, "main.helper");
+//! ```
+
+use std::cmp::Ordering;
+use std::collections::{BTreeSet, HashMap, HashSet, VecDeque};
+use std::path::Path;
+
+use serde::{Deserialize, Serialize};
+
+use crate::cancel::{CancelToken, Partial};
+use crate::packages::strongly_connected;
+use crate::resolve::resolver_for_language;
+use crate::{CodeIndex, IndexUpdate, Location, Reference, ReferenceKind, Symbol, SymbolKind};
+
+/// A single call from one symbol to another.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct CallSite {
+    /// Qualified name of the calling symbol
+    pub caller: String,
+    /// Qualified name of the called symbol
+    pub callee: String,
+    /// Where the call appears (path is relative to workspace root)
+    pub location: Location,
+    /// Whether the call is known to reach `callee` or only might
+    pub kind: CallKind,
+    /// Signature of the overload called, when `callee` names several
+    /// overloads and the call's argument types pick one
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub overload: Option<String>,
+    /// Signatures of the overloads the call may reach, when `callee` names
+    /// several and the call's argument types could not pick one
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub candidates: Vec<String>,
+}
+
+/// A call whose callee does not resolve to any indexed symbol.
+///
+/// Only references the parser classified as [`ReferenceKind::Call`] are
+/// recorded, so plain identifier uses never show up here.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct UnresolvedCall {
+    /// Qualified name of the calling symbol
+    pub caller: String,
+    /// The callee as written at the call site ("print", "self.client.get")
+    pub callee: String,
+    /// Where the call appears (path is relative to workspace root)
+    pub location: Location,
+    /// Why the call could not be resolved
+    #[serde(default)]
+    pub kind: UnresolvedKind,
+    /// The callee text before its last segment ("fmt" for "fmt.Println",
+    /// "self.client" for "self.client.get"), if any
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub qualifier: Option<String>,
+}
+
+/// Why an [`UnresolvedCall`] has no callee.
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq, Hash, Serialize, Deserialize)]
+#[serde(rename_all = "snake_case")]
+pub enum UnresolvedKind {
+    /// The qualifier names a package the file imports that is not indexed
+    /// (`fmt.Println`, `os.path.join`)
+    External,
+    /// The qualifier is an expression, usually a call through a value whose
+    /// type is not known (`self.client.get`, `svc.Run`)
+    Dynamic,
+    /// A bare name with no matching symbol (builtins, missing definitions)
+    #[default]
+    Unknown,
+    /// A call to a function-like macro, whose expansion is not known
+    Macro,
+}
+
+/// How certain a call edge is.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Hash, Serialize, Deserialize)]
+pub enum CallKind {
+    /// The reference resolves to the callee
+    Direct,
+    /// The reference calls an interface method and the callee is one of the
+    /// interface's implementations (see [`CallGraphOptions::interface_dispatch`])
+    Possible,
+    /// The callee is reached through a function value: a variable holding a
+    /// function or method value, or a closure defined in the caller
+    /// (see [`CallGraphOptions::indirect_calls`])
+    Indirect,
+}
+
+/// Options for [`CallGraph::unreachable_symbols_with_options`].
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq)]
+pub struct DeadCodeOptions {
+    /// Treat every exported symbol ([`CodeIndex::is_exported`]) as a root
+    pub exported_as_roots: bool,
+    /// Leave symbols defined in generated files (see
+    /// [`Symbol::is_generated`]) out of the report. They are still traversed,
+    /// so what they call is reachable.
+    pub exclude_generated: bool,
+}
+
+/// Options controlling which edges [`CallGraph::build_with_options`] records.
+#[derive(Debug, Clone, Copy, Serialize, Deserialize)]
+pub struct CallGraphOptions {
+    /// For calls through an interface method, also add [`CallKind::Possible`]
+    /// edges to the matching method of every implementation
+    /// (see [`CodeIndex::implementations`]). Possible edges are returned by
+    /// [`CallGraph::callers`] and followed by [`CallGraph::reachable_from`]
+    /// like direct ones; check [`CallSite::kind`] to tell them apart.
+    #[serde(default)]
+    pub interface_dispatch: bool,
+    /// Record [`CallKind::Indirect`] edges for [`ReferenceKind::IndirectCall`]
+    /// references (on by default). Closures then own the calls in their
+    /// bodies and are called by the function that defines them; when off,
+    /// calls inside a closure belong to the enclosing function and calls
+    /// through function values are dropped.
+    #[serde(default = "default_indirect_calls")]
+    pub indirect_calls: bool,
+}
+
+impl Default for CallGraphOptions {
+    fn default() -> Self {
+        Self {
+            interface_dispatch: false,
+            indirect_calls: default_indirect_calls(),
+        }
+    }
+}
+
+fn default_indirect_calls() -> bool {
+    true
+}
+
+/// A symbol reached by transitive traversal from an entry point.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct ReachableSymbol {
+    /// Qualified name of the reached symbol
+    pub qualified: String,
+    /// Shortest call depth from the entry point (1 = called directly)
+    pub depth: usize,
+    /// First-discovered call chain from the entry point to this symbol (inclusive)
+    pub path: Vec<String>,
+}
+
+/// Caller/callee edges for every resolvable call in a [`CodeIndex`].
+///
+/// Symbols are identified by qualified name, matching [`CodeIndex::get`].
+#[derive(Debug, Clone, Default)]
+pub struct CallGraph {
+    /// All call sites, sorted by file, line, and column
+    sites: Vec<CallSite>,
+    /// Caller qualified name -> indices into `sites` (ascending)
+    outgoing: HashMap<String, Vec<usize>>,
+    /// Callee qualified name -> indices into `sites` (ascending)
+    incoming: HashMap<String, Vec<usize>>,
+    /// Calls that resolved to no symbol, sorted by file, line, and column
+    unresolved: Vec<UnresolvedCall>,
+    /// Options the graph was built with (reused by `apply_update`)
+    options: CallGraphOptions,
+}
+
+impl CallGraph {
+    /// Build the call graph from all references in the index.
+    ///
+    /// References that resolve to no callable symbol (external calls, type
+    /// usages, local variables) produce no edge; those the parser marked as
+    /// calls are kept in [`CallGraph::unresolved_calls`].
+    #[must_use]
+    pub fn build(index: &CodeIndex) -> Self {
+        Self::build_with_options(index, CallGraphOptions::default())
+    }
+
+    /// Build the call graph with explicit options.
+    #[must_use]
+    pub fn build_with_options(index: &CodeIndex, options: CallGraphOptions) -> Self {
+        let table = NameTable::new(index, options);
+        let mut sites = Vec::new();
+        let mut unresolved = Vec::new();
+
+        for reference in index.references() {
+            table.push_sites(index, reference, &mut sites, &mut unresolved);
+        }
+
+        Self::from_sites(index, sites, unresolved, options)
+    }
+
+    /// Patch the graph after an incremental index update.
+    ///
+    /// `index` must already reflect `update` (see [`CodeIndex::update_files`]).
+    /// Call sites inside the updated files are rebuilt. Calls from other files
+    /// are re-resolved only if they name a symbol the update removed or added,
+    /// so edges into deleted functions are dropped and edges into new ones
+    /// appear. The result is the same as rebuilding with the options the graph
+    /// was built with.
+    pub fn apply_update(&mut self, index: &CodeIndex, update: &IndexUpdate) {
+        if update.is_empty() {
+            return;
+        }
+
+        let changed: HashSet<&Path> = update.files.iter().map(|f| f.as_path()).collect();
+        let mut affected: HashSet<&str> = HashSet::new();
+        for symbol in update.removed.iter().chain(&update.added) {
+            if symbol.kind.is_callable() {
+                affected.insert(symbol.name.as_str());
+                affected.insert(last_segment(&symbol.qualified));
+            }
+        }
+
+        let stale: Vec<&Reference> = index
+            .references()
+            .filter(|r| {
+                changed.contains(r.location.file.as_path())
+                    || affected.contains(last_segment(&r.name))
+            })
+            .collect();
+        let stale_locations: HashSet<&Location> = stale.iter().map(|r| &r.location).collect();
+
+        let mut sites: Vec<CallSite> = std::mem::take(&mut self.sites)
+            .into_iter()
+            .filter(|site| {
+                // Possible edges are re-derived from the direct ones below
+                site.kind != CallKind::Possible
+                    && !changed.contains(site.location.file.as_path())
+                    && !stale_locations.contains(&site.location)
+            })
+            .collect();
+        let mut unresolved: Vec<UnresolvedCall> = std::mem::take(&mut self.unresolved)
+            .into_iter()
+            .filter(|call| {
+                !changed.contains(call.location.file.as_path())
+                    && !stale_locations.contains(&call.location)
+            })
+            .collect();
+
+        let table = NameTable::new(index, self.options);
+        for reference in stale {
+            table.push_sites(index, reference, &mut sites, &mut unresolved);
+        }
+
+        *self = Self::from_sites(index, sites, unresolved, self.options);
+    }
+
+    /// Build a call graph from pre-computed direct and unresolved call sites.
+    fn from_sites(
+        index: &CodeIndex,
+        mut sites: Vec<CallSite>,
+        mut unresolved: Vec<UnresolvedCall>,
+        options: CallGraphOptions,
+    ) -> Self {
+        finish_sites(index, &mut sites, &mut unresolved, options);
+        Self::from_sorted_sites(sites, unresolved, options)
+    }
+
+    /// Build the lookup tables for sites that are already sorted and deduped
+    /// (as stored by [`CallGraph::sites`] and [`CallGraph::unresolved_calls`]).
+    pub(crate) fn from_sorted_sites(
+        sites: Vec<CallSite>,
+        unresolved: Vec<UnresolvedCall>,
+        options: CallGraphOptions,
+    ) -> Self {
+        let mut outgoing: HashMap<String, Vec<usize>> = HashMap::new();
+        let mut incoming: HashMap<String, Vec<usize>> = HashMap::new();
+        for (i, site) in sites.iter().enumerate() {
+            outgoing.entry(site.caller.clone()).or_default().push(i);
+            incoming.entry(site.callee.clone()).or_default().push(i);
+        }
+
+        Self {
+            sites,
+            outgoing,
+            incoming,
+            unresolved,
+            options,
+        }
+    }
+
+    /// Find the symbols that call `qualified`.
+    ///
+    /// Returns one call site per distinct caller (its first call to the target),
+    /// ordered by file then line so output is stable across runs.
+    #[must_use]
+    pub fn callers(&self, qualified: &str) -> Vec<&CallSite> {
+        self.first_site_per(self.incoming.get(qualified), |site| &site.caller)
+    }
+
+    /// Find the symbols that `qualified` calls.
+    ///
+    /// Returns one call site per distinct callee (the first call to it),
+    /// ordered by file then line so output is stable across runs.
+    #[must_use]
+    pub fn callees(&self, qualified: &str) -> Vec<&CallSite> {
+        self.first_site_per(self.outgoing.get(qualified), |site| &site.callee)
+    }
+
+    /// Find every symbol transitively called from `qualified`.
+    ///
+    /// Traverses callees breadth-first, so each symbol is reported with the
+    /// shortest depth at which it is reachable and the first call chain that
+    /// reached it. Recursion and mutual recursion are handled by visiting each
+    /// symbol once. The entry point itself is not included.
+    ///
+    /// `max_depth == 0` means unlimited (unlike [`crate::spider::spider`],
+    /// where 0 means "entry point only").
+    #[must_use]
+    pub fn reachable_from(&self, qualified: &str, max_depth: usize) -> Vec<ReachableSymbol> {
+        self.reachable_from_with_cancel(qualified, max_depth, &CancelToken::new())
+            .value
+    }
+
+    /// [`CallGraph::reachable_from`], stopping once `cancel` fires.
+    ///
+    /// A truncated result holds the symbols reached before the traversal
+    /// stopped, each with the depth and path it was found at.
+    #[must_use]
+    pub fn reachable_from_with_cancel(
+        &self,
+        qualified: &str,
+        max_depth: usize,
+        cancel: &CancelToken,
+    ) -> Partial<Vec<ReachableSymbol>> {
+        let mut visited: HashSet<&str> = HashSet::new();
+        // Symbol -> the symbol it was first reached from
+        let mut parents: HashMap<&str, &str> = HashMap::new();
+        let mut queue: VecDeque<(&str, usize)> = VecDeque::new();
+        let mut order: Vec<(&str, usize)> = Vec::new();
+
+        visited.insert(qualified);
+        queue.push_back((qualified, 0));
+
+        let mut truncated = false;
+        while let Some((current, depth)) = queue.pop_front() {
+            if cancel.is_cancelled() {
+                truncated = true;
+                break;
+            }
+            if max_depth != 0 && depth >= max_depth {
+                continue;
+            }
+            for site in self.callees(current) {
+                let callee = site.callee.as_str();
+                if visited.insert(callee) {
+                    parents.insert(callee, current);
+                    order.push((callee, depth + 1));
+                    queue.push_back((callee, depth + 1));
+                }
+            }
+        }
+
+        let reached = order
+            .into_iter()
+            .map(|(symbol, depth)| {
+                let mut path = vec![symbol.to_string()];
+                let mut current = symbol;
+                while let Some(&parent) = parents.get(current) {
+                    path.push(parent.to_string());
+                    current = parent;
+                }
+                path.reverse();
+                ReachableSymbol {
+                    qualified: symbol.to_string(),
+                    depth,
+                    path,
+                }
+            })
+            .collect();
+        Partial {
+            value: reached,
+            truncated,
+        }
+    }
+
+    /// Find up to `max_paths` distinct call paths from `from` to `to`.
+    ///
+    /// Each path lists qualified names from `from` to `to` inclusive. Paths
+    /// are returned shortest first (ties in call-site order) and never visit a
+    /// symbol twice, so recursion cannot make the search loop. Only symbols
+    /// that can reach `to` at all are explored. If `from == to` the only path
+    /// is `[from]`.
+    #[must_use]
+    pub fn paths(&self, from: &str, to: &str, max_paths: usize) -> Vec<Vec<String>> {
+        self.paths_with_cancel(from, to, max_paths, &CancelToken::new())
+            .value
+    }
+
+    /// [`CallGraph::paths`], stopping once `cancel` fires.
+    ///
+    /// A truncated result holds the paths found before the search stopped,
+    /// still shortest first.
+    #[must_use]
+    pub fn paths_with_cancel(
+        &self,
+        from: &str,
+        to: &str,
+        max_paths: usize,
+        cancel: &CancelToken,
+    ) -> Partial<Vec<Vec<String>>> {
+        if max_paths == 0 {
+            return Partial::complete(Vec::new());
+        }
+        if from == to {
+            return Partial::complete(vec![vec![from.to_string()]]);
+        }
+
+        // Symbols with some call chain to `to`, found by walking callers back
+        let mut reaches_target: HashSet<&str> = HashSet::new();
+        let mut queue: VecDeque<&str> = VecDeque::new();
+        reaches_target.insert(to);
+        queue.push_back(to);
+        while let Some(current) = queue.pop_front() {
+            if cancel.is_cancelled() {
+                return Partial::truncated(Vec::new());
+            }
+            for site in self.callers(current) {
+                if reaches_target.insert(site.caller.as_str()) {
+                    queue.push_back(site.caller.as_str());
+                }
+            }
+        }
+        if !reaches_target.contains(from) {
+            return Partial::complete(Vec::new());
+        }
+
+        // Breadth-first over partial paths yields paths in length order
+        let mut paths = Vec::new();
+        let mut partial: VecDeque<Vec<&str>> = VecDeque::new();
+        partial.push_back(vec![from]);
+        while let Some(path) = partial.pop_front() {
+            if cancel.is_cancelled() {
+                return Partial::truncated(paths);
+            }
+            let Some(&current) = path.last() else {
+                continue;
+            };
+            for site in self.callees(current) {
+                let callee = site.callee.as_str();
+                if !reaches_target.contains(callee) || path.contains(&callee) {
+                    continue;
+                }
+                let mut next = path.clone();
+                next.push(callee);
+                if callee == to {
+                    paths.push(next.iter().map(|s| s.to_string()).collect());
+                    if paths.len() == max_paths {
+                        return Partial::complete(paths);
+                    }
+                } else {
+                    partial.push_back(next);
+                }
+            }
+        }
+        Partial::complete(paths)
+    }
+
+    /// Recursive functions: every group of symbols that call each other in
+    /// a cycle.
+    ///
+    /// Each group is a strongly connected component of the graph (found with
+    /// Tarjan's algorithm) with more than one member, or a single symbol that
+    /// calls itself. Members are sorted by qualified name and groups by their
+    /// first member, so the output is deterministic.
+    #[must_use]
+    pub fn cycles(&self) -> Vec<Vec<String>> {
+        let names: Vec<&str> = self
+            .sites
+            .iter()
+            .flat_map(|site| [site.caller.as_str(), site.callee.as_str()])
+            .collect::<BTreeSet<_>>()
+            .into_iter()
+            .collect();
+        let ids: HashMap<&str, usize> = names.iter().enumerate().map(|(i, n)| (*n, i)).collect();
+        let mut successors: Vec<Vec<usize>> = vec![Vec::new(); names.len()];
+        for site in &self.sites {
+            let (from, to) = (ids[site.caller.as_str()], ids[site.callee.as_str()]);
+            if !successors[from].contains(&to) {
+                successors[from].push(to);
+            }
+        }
+
+        let mut cycles: Vec<Vec<String>> = strongly_connected(&successors)
+            .into_iter()
+            .filter(|component| {
+                component.len() > 1 || successors[component[0]].contains(&component[0])
+            })
+            .map(|component| {
+                let mut members: Vec<String> =
+                    component.iter().map(|&i| names[i].to_string()).collect();
+                members.sort();
+                members
+            })
+            .collect();
+        cycles.sort();
+        cycles
+    }
+
+    /// Find functions and methods that are never reached from `roots`.
+    ///
+    /// Roots may be qualified names or bare names (`main` matches `main.main`).
+    /// The traversal follows every call edge and, when it reaches an interface
+    /// method, continues into each implementation of that method, so methods
+    /// only invoked through an interface are not reported. Interface method
+    /// declarations are never reported themselves. With `exported_as_roots`,
+    /// every exported symbol ([`CodeIndex::is_exported`]) is a root too, since
+    /// it may be called from outside the index.
+    ///
+    /// Results are ordered by file then line.
+    #[must_use]
+    pub fn unreachable_symbols<'a>(
+        &self,
+        index: &'a CodeIndex,
+        roots: &[&str],
+        exported_as_roots: bool,
+    ) -> Vec<&'a Symbol> {
+        let options = DeadCodeOptions {
+            exported_as_roots,
+            ..DeadCodeOptions::default()
+        };
+        self.unreachable_symbols_with_options(index, roots, &options)
+    }
+
+    /// [`CallGraph::unreachable_symbols`] with [`DeadCodeOptions`].
+    #[must_use]
+    pub fn unreachable_symbols_with_options<'a>(
+        &self,
+        index: &'a CodeIndex,
+        roots: &[&str],
+        options: &DeadCodeOptions,
+    ) -> Vec<&'a Symbol> {
+        let candidates: Vec<&Symbol> = index
+            .symbols()
+            .filter(|s| is_dead_code_candidate(index, s))
+            .collect();
+
+        let mut reached: HashSet<String> = HashSet::new();
+        let mut queue: VecDeque<String> = VecDeque::new();
+        let mut visit = |qualified: String, queue: &mut VecDeque<String>| {
+            if reached.insert(qualified.clone()) {
+                queue.push_back(qualified);
+            }
+        };
+
+        for root in roots {
+            for qualified in resolve_root(index, root) {
+                visit(qualified, &mut queue);
+            }
+        }
+        if options.exported_as_roots {
+            for symbol in candidates.iter().filter(|s| index.is_exported(s)) {
+                visit(symbol.qualified.clone(), &mut queue);
+            }
+        }
+
+        while let Some(current) = queue.pop_front() {
+            let mut next: Vec<String> = self
+                .callees(&current)
+                .iter()
+                .map(|site| site.callee.clone())
+                .collect();
+            next.extend(
+                implementing_methods(index, &current)
+                    .into_iter()
+                    .map(|s| s.qualified.clone()),
+            );
+            for qualified in next {
+                visit(qualified, &mut queue);
+            }
+        }
+
+        let mut unreachable: Vec<&Symbol> = candidates
+            .into_iter()
+            .filter(|s| !reached.contains(&s.qualified))
+            .filter(|s| !options.exclude_generated || !s.is_generated())
+            .collect();
+        unreachable.sort_by(|a, b| {
+            a.location
+                .file
+                .cmp(&b.location.file)
+                .then(a.location.line.cmp(&b.location.line))
+                .then(a.location.column.cmp(&b.location.column))
+        });
+        unreachable
+    }
+
+    /// All call sites in the graph, ordered by file then line.
+    pub fn sites(&self) -> &[CallSite] {
+        &self.sites
+    }
+
+    /// Calls that resolved to no indexed symbol, ordered by file then line.
+    pub fn unresolved_calls(&self) -> &[UnresolvedCall] {
+        &self.unresolved
+    }
+
+    /// Unresolved calls made from `qualified`, ordered by file then line.
+    #[must_use]
+    pub fn unresolved_calls_from(&self, qualified: &str) -> Vec<&UnresolvedCall> {
+        self.unresolved
+            .iter()
+            .filter(|call| call.caller == qualified)
+            .collect()
+    }
+
+    /// Options the graph was built with.
+    pub fn options(&self) -> CallGraphOptions {
+        self.options
+    }
+
+    /// Number of call sites in the graph.
+    pub fn len(&self) -> usize {
+        self.sites.len()
+    }
+
+    /// Check if the graph has no call sites.
+    pub fn is_empty(&self) -> bool {
+        self.sites.is_empty()
+    }
+
+    fn first_site_per<'a, F>(&'a self, indices: Option<&Vec<usize>>, key: F) -> Vec<&'a CallSite>
+    where
+        F: Fn(&'a CallSite) -> &'a String,
+    {
+        let mut seen: HashSet<&String> = HashSet::new();
+        indices
+            .into_iter()
+            .flatten()
+            .map(|&i| &self.sites[i])
+            .filter(|site| seen.insert(key(site)))
+            .collect()
+    }
+}
+
+/// Resolves references to call sites against a fixed set of symbols, a few
+/// references at a time.
+///
+/// This is what [`CallGraph::build_with_options`] does for the whole index at
+/// once; [`crate::stream`] uses it to resolve one file's references without
+/// holding every reference in memory. `index` only needs symbols and opens.
+pub(crate) struct CallResolver<'a> {
+    index: &'a CodeIndex,
+    table: NameTable<'a>,
+    options: CallGraphOptions,
+}
+
+impl<'a> CallResolver<'a> {
+    pub(crate) fn new(index: &'a CodeIndex, options: CallGraphOptions) -> Self {
+        Self {
+            index,
+            table: NameTable::new(index, options),
+            options,
+        }
+    }
+
+    /// Call sites and unresolved calls for `references`, ordered like
+    /// [`CallGraph::sites`] and [`CallGraph::unresolved_calls`].
+    ///
+    /// Reference paths must be relative to the workspace root.
+    pub(crate) fn resolve(&self, references: &[Reference]) -> (Vec<CallSite>, Vec<UnresolvedCall>) {
+        let mut sites = Vec::new();
+        let mut unresolved = Vec::new();
+        for reference in references {
+            self.table
+                .push_sites(self.index, reference, &mut sites, &mut unresolved);
+        }
+        finish_sites(self.index, &mut sites, &mut unresolved, self.options);
+        (sites, unresolved)
+    }
+}
+
+/// Add possible edges (if enabled), then sort and dedup.
+fn finish_sites(
+    index: &CodeIndex,
+    sites: &mut Vec<CallSite>,
+    unresolved: &mut [UnresolvedCall],
+    options: CallGraphOptions,
+) {
+    if options.interface_dispatch {
+        let possible = possible_sites(index, sites);
+        sites.extend(possible);
+    }
+
+    // Direct sorts before Possible, so a call that is both stays Direct
+    sites.sort_by(compare_sites);
+    sites.dedup_by(|a, b| a.caller == b.caller && a.callee == b.callee && a.location == b.location);
+    unresolved.sort_by(compare_unresolved);
+}
+
+/// Whether `symbol` is defined inside another callable in the same file:
+/// within its parent's [`Extent`](crate::Extent) when both have one, or else
+/// with a span covering more than its name, as parsers record closures.
+fn is_nested_callable(index: &CodeIndex, symbol: &Symbol) -> bool {
+    let location = &symbol.location;
+    let mut parents = symbol
+        .parent
+        .as_deref()
+        .map_or(&[][..], |parent| index.get_all(parent))
+        .iter()
+        .filter(|s| s.kind.is_callable() && s.location.file == location.file);
+    match symbol.extent {
+        Some(extent) => parents.any(|parent| {
+            parent
+                .extent
+                .is_some_and(|outer| outer.line <= extent.line && extent.end_line <= outer.end_line)
+        }),
+        None => {
+            let name_end = location.column + symbol.name.chars().count() as u32;
+            let has_span = (location.end_line, location.end_column) > (location.line, name_end);
+            has_span && parents.next().is_some()
+        }
+    }
+}
+
+/// Where a symbol's declaration starts, then where its name does, so that
+/// of two declarations starting on one line the later-named one is inner.
+fn declaration_start(symbol: &Symbol) -> (u32, u32, u32) {
+    (
+        symbol.lines().0,
+        symbol.location.line,
+        symbol.location.column,
+    )
+}
+
+/// Whether `location` starts strictly after the start of `span` and before
+/// its end.
+fn strictly_inside(span: &Location, location: &Location) -> bool {
+    let position = (location.line, location.column);
+    (span.line, span.column) < position && position < (span.end_line, span.end_column)
+}
+
+/// Split "fmt.Println" into ("fmt", "Println") and "std::fs::read" into
+/// ("std::fs", "read"); `None` for a bare name.
+fn split_qualifier(callee: &str) -> Option<(&str, &str)> {
+    let dot = callee.rfind('.').map(|i| (i, 1));
+    let colons = callee.rfind("::").map(|i| (i, 2));
+    let (i, len) = dot.max(colons)?;
+    Some((&callee[..i], &callee[i + len..])).filter(|(q, _)| !q.is_empty())
+}
+
+/// Whether `qualifier` starts with a package named by one of `opens`.
+///
+/// An import matches by its full path (`os.path`, `std::fs`) or by its last
+/// segment, which is how Go refers to `net/http` (`http.Get`).
+fn imports_qualifier(opens: &[String], qualifier: &str) -> bool {
+    let head = qualifier.split(['.', ':']).next().unwrap_or(qualifier);
+    opens.iter().any(|open| {
+        let last = open.rsplit(['/', '.', ':']).next().unwrap_or(open);
+        last == head
+            || qualifier == open
+            || qualifier
+                .strip_prefix(open.as_str())
+                .is_some_and(|rest| rest.starts_with('.') || rest.starts_with("::"))
+    })
+}
+
+/// Order call sites by file, line, column, then by caller/callee for determinism.
+fn compare_sites(a: &CallSite, b: &CallSite) -> Ordering {
+    a.location
+        .file
+        .cmp(&b.location.file)
+        .then(a.location.line.cmp(&b.location.line))
+        .then(a.location.column.cmp(&b.location.column))
+        .then_with(|| a.caller.cmp(&b.caller))
+        .then_with(|| a.callee.cmp(&b.callee))
+        .then(a.kind.cmp(&b.kind))
+}
+
+/// Order unresolved calls by file, line, column, then by caller/callee.
+fn compare_unresolved(a: &UnresolvedCall, b: &UnresolvedCall) -> Ordering {
+    a.location
+        .file
+        .cmp(&b.location.file)
+        .then(a.location.line.cmp(&b.location.line))
+        .then(a.location.column.cmp(&b.location.column))
+        .then_with(|| a.caller.cmp(&b.caller))
+        .then_with(|| a.callee.cmp(&b.callee))
+}
+
+/// Derive [`CallKind::Possible`] edges for direct calls to interface methods.
+fn possible_sites(index: &CodeIndex, direct: &[CallSite]) -> Vec<CallSite> {
+    // Interface method -> implementing methods, computed once per method
+    let mut dispatch: HashMap<&str, Vec<&Symbol>> = HashMap::new();
+    let mut possible = Vec::new();
+
+    for site in direct {
+        let targets = dispatch
+            .entry(site.callee.as_str())
+            .or_insert_with(|| implementing_methods(index, &site.callee));
+        for target in targets.iter() {
+            // A call to one overload of the interface method reaches the
+            // implementing overload with the same parameters
+            let overload = match &site.overload {
+                Some(called) if !same_parameters(index, &site.callee, called, target) => continue,
+                Some(_) => target.signature.clone(),
+                None => None,
+            };
+            possible.push(CallSite {
+                caller: site.caller.clone(),
+                callee: target.qualified.clone(),
+                location: site.location.clone(),
+                kind: CallKind::Possible,
+                overload,
+                candidates: Vec::new(),
+            });
+        }
+    }
+
+    possible
+}
+
+/// Functions and methods with bodies: the symbols dead-code detection reports on.
+fn is_dead_code_candidate(index: &CodeIndex, symbol: &Symbol) -> bool {
+    let callable = match symbol.kind {
+        SymbolKind::Function => true,
+        // Members with parameters are methods; the rest are fields
+        SymbolKind::Member => symbol.signature.as_deref().is_some_and(|s| s.contains('(')),
+        _ => false,
+    };
+    callable && !is_interface_method(index, symbol)
+}
+
+fn is_interface_method(index: &CodeIndex, symbol: &Symbol) -> bool {
+    symbol.parent.as_deref().is_some_and(|parent| {
+        index
+            .get_all(parent)
+            .iter()
+            .any(|s| s.kind == SymbolKind::Interface)
+    })
+}
+
+/// Resolve a root given by qualified name, falling back to callables with that bare name.
+fn resolve_root(index: &CodeIndex, root: &str) -> Vec<String> {
+    if !index.get_all(root).is_empty() {
+        return vec![root.to_string()];
+    }
+    index
+        .symbols()
+        .filter(|s| s.name == root && s.kind.is_callable())
+        .map(|s| s.qualified.clone())
+        .collect()
+}
+
+/// Whether `implementation` takes the same parameter types as the overload
+/// of `method` whose signature is `called`.
+fn same_parameters(index: &CodeIndex, method: &str, called: &str, implementation: &Symbol) -> bool {
+    let parameter_types = |symbol: &Symbol| {
+        symbol.structured_signature().map(|signature| {
+            signature
+                .params
+                .iter()
+                .map(|p| p.type_text())
+                .collect::<Vec<_>>()
+        })
+    };
+    let Some(called) = index
+        .get_all(method)
+        .iter()
+        .find(|s| s.signature.as_deref() == Some(called))
+    else {
+        return true;
+    };
+    match (parameter_types(called), parameter_types(implementation)) {
+        (Some(called), Some(implemented)) => called == implemented,
+        _ => true,
+    }
+}
+
+/// The methods implementing `method`, if it is an interface method.
+fn implementing_methods<'a>(index: &'a CodeIndex, method: &str) -> Vec<&'a Symbol> {
+    let Some(symbol) = index.get(method) else {
+        return Vec::new();
+    };
+    let Some(interface) = symbol.parent.as_deref() else {
+        return Vec::new();
+    };
+    if !is_interface_method(index, symbol) {
+        return Vec::new();
+    }
+
+    index
+        .implementations(interface)
+        .into_iter()
+        .flat_map(|implementer| {
+            index
+                .get_all(&format!("{}.{}", implementer.qualified, symbol.name))
+                .iter()
+                .filter(|s| s.kind.is_callable())
+        })
+        .collect()
+}
+
+/// Narrow each set of same-named overloads in `callees` to those a call with
+/// `arguments` selects, as the callee language's resolver decides.
+fn select_overloads<'a>(callees: Vec<&'a Symbol>, arguments: &[Option<String>]) -> Vec<&'a Symbol> {
+    let mut selected = Vec::with_capacity(callees.len());
+    let mut done: HashSet<&str> = HashSet::new();
+    for callee in &callees {
+        if !done.insert(callee.qualified.as_str()) {
+            continue;
+        }
+        let overloads: Vec<&Symbol> = callees
+            .iter()
+            .copied()
+            .filter(|c| c.qualified == callee.qualified)
+            .collect();
+        match resolver_for_language(&callee.language) {
+            Some(resolver) if overloads.len() > 1 => {
+                selected.extend(resolver.select_overloads(&overloads, arguments));
+            }
+            _ => selected.extend(overloads),
+        }
+    }
+    selected
+}
+
+/// Get the unqualified tail of a reference: "m.Method" -> "Method", "a::b" -> "b".
+fn last_segment(name: &str) -> &str {
+    let tail = name.rsplit("::").next().unwrap_or(name);
+    tail.rsplit('.').next().unwrap_or(tail)
+}
+
+/// Lookup tables built once so resolution doesn't rescan the whole index per reference.
+struct NameTable<'a> {
+    /// Short name -> callable symbols with that name
+    by_name: HashMap<&'a str, Vec<&'a Symbol>>,
+    /// File -> callable symbols in that file, sorted by where their
+    /// declarations start
+    callables_by_file: HashMap<&'a Path, Vec<&'a Symbol>>,
+    /// Qualified names of callables defined inside another callable
+    /// (closures and nested functions)
+    nested: HashSet<&'a str>,
+    indirect_calls: bool,
+}
+
+impl<'a> NameTable<'a> {
+    fn new(index: &'a CodeIndex, options: CallGraphOptions) -> Self {
+        let mut by_name: HashMap<&str, Vec<&Symbol>> = HashMap::new();
+        let mut callables_by_file: HashMap<&Path, Vec<&Symbol>> = HashMap::new();
+        let mut nested = HashSet::new();
+
+        for symbol in index.symbols().filter(|s| s.kind.is_callable()) {
+            by_name
+                .entry(symbol.name.as_str())
+                .or_default()
+                .push(symbol);
+            callables_by_file
+                .entry(symbol.location.file.as_path())
+                .or_default()
+                .push(symbol);
+            if is_nested_callable(index, symbol) {
+                nested.insert(symbol.qualified.as_str());
+            }
+        }
+
+        for symbols in by_name.values_mut() {
+            symbols.sort_by(|a, b| a.qualified.cmp(&b.qualified));
+        }
+        for symbols in callables_by_file.values_mut() {
+            symbols.sort_by_key(|s| declaration_start(s));
+        }
+
+        Self {
+            by_name,
+            callables_by_file,
+            nested,
+            indirect_calls: options.indirect_calls,
+        }
+    }
+
+    /// Find the callable symbol containing a location.
+    ///
+    /// The innermost callable whose [`Extent`](crate::Extent) covers the
+    /// location's line wins. A callable without an extent falls back to the
+    /// reverse spider's heuristic of containing every line from its definition
+    /// on, so the one defined closest before the location wins. A closure
+    /// never contains its own definition site, which belongs to the enclosing
+    /// function; with indirect calls off, closures contain nothing.
+    fn containing_callable(&self, location: &Location) -> Option<&'a Symbol> {
+        let symbols = self.callables_by_file.get(location.file.as_path())?;
+        let after = symbols.partition_point(|s| s.lines().0 <= location.line);
+        symbols[..after]
+            .iter()
+            .copied()
+            .filter(|s| self.contains(s, location))
+            .max_by(|a, b| {
+                declaration_start(a)
+                    .cmp(&declaration_start(b))
+                    .then_with(|| b.lines().1.cmp(&a.lines().1))
+            })
+    }
+
+    /// Whether callable `symbol` contains `location`; see
+    /// [`Self::containing_callable`].
+    fn contains(&self, symbol: &Symbol, location: &Location) -> bool {
+        let nested = self.nested.contains(symbol.qualified.as_str());
+        if nested && !self.indirect_calls {
+            return false;
+        }
+        match symbol.extent {
+            Some(extent) => {
+                let definition_site = (symbol.location.line, symbol.location.column)
+                    == (location.line, location.column);
+                (extent.line..=extent.end_line).contains(&location.line)
+                    && !(nested && definition_site)
+            }
+            // A closure's span is exact, so code after it on its last line
+            // is not inside it
+            None if nested => strictly_inside(&symbol.location, location),
+            None => symbol.location.line <= location.line,
+        }
+    }
+
+    /// Record a call site for each callee `reference` resolves to.
+    ///
+    /// A [`ReferenceKind::Call`] or [`ReferenceKind::IndirectCall`] reference
+    /// that resolves to nothing is recorded in `unresolved` instead.
+    fn push_sites(
+        &self,
+        index: &'a CodeIndex,
+        reference: &Reference,
+        sites: &mut Vec<CallSite>,
+        unresolved: &mut Vec<UnresolvedCall>,
+    ) {
+        let indirect = reference.kind == ReferenceKind::IndirectCall;
+        if indirect && !self.indirect_calls {
+            return;
+        }
+        let caller = match self.containing_callable(&reference.location) {
+            Some(caller) => caller,
+            None => return,
+        };
+
+        let before = sites.len();
+        let mut callees = index.prefer_definitions(self.resolve_reference(index, reference));
+        let calls_macro = callees.iter().any(|c| c.is_macro());
+        callees.retain(|c| !c.is_macro());
+        if let Some(arguments) = &reference.arguments {
+            callees = select_overloads(callees, arguments);
+        }
+        for &callee in &callees {
+            // A reference sitting on the callee's own definition is not a
+            // call, except for a closure, which is "called" where it is defined
+            if callee.location == reference.location && !indirect {
+                continue;
+            }
+            let selected = callees
+                .iter()
+                .filter(|c| c.qualified == callee.qualified)
+                .count();
+            let overloads = index
+                .get_all(&callee.qualified)
+                .iter()
+                .filter(|s| s.kind.is_callable())
+                .count();
+            let ambiguous = reference.arguments.is_some() && selected > 1 && overloads > 1;
+            sites.push(CallSite {
+                caller: caller.qualified.clone(),
+                callee: callee.qualified.clone(),
+                location: reference.location.clone(),
+                kind: if indirect {
+                    CallKind::Indirect
+                } else {
+                    CallKind::Direct
+                },
+                overload: if selected == 1 && overloads > 1 {
+                    callee.signature.clone()
+                } else {
+                    None
+                },
+                candidates: if ambiguous {
+                    callees
+                        .iter()
+                        .filter(|c| c.qualified == callee.qualified)
+                        .filter_map(|c| c.signature.clone())
+                        .collect()
+                } else {
+                    Vec::new()
+                },
+            });
+        }
+
+        let is_call = matches!(
+            reference.kind,
+            ReferenceKind::Call | ReferenceKind::IndirectCall
+        );
+        if sites.len() == before && is_call {
+            let qualifier = split_qualifier(&reference.name).map(|(q, _)| q);
+            let kind = match qualifier {
+                _ if calls_macro => UnresolvedKind::Macro,
+                Some(q) if imports_qualifier(index.opens_for_file(&reference.location.file), q) => {
+                    UnresolvedKind::External
+                }
+                Some(_) => UnresolvedKind::Dynamic,
+                None => UnresolvedKind::Unknown,
+            };
+            unresolved.push(UnresolvedCall {
+                caller: caller.qualified.clone(),
+                callee: reference.name.clone(),
+                location: reference.location.clone(),
+                kind,
+                qualifier: qualifier.map(str::to_string),
+            });
+        }
+    }
+
+    /// Resolve a reference to the callable symbols it may refer to: its
+    /// [`Reference::target`] if that names a visible callable, else its name.
+    ///
+    /// An indirect call is written as a variable name, so its target (the
+    /// function the variable was assigned, as written if the parser could not
+    /// qualify it) is resolved instead.
+    fn resolve_reference(&self, index: &'a CodeIndex, reference: &Reference) -> Vec<&'a Symbol> {
+        let from_file = &reference.location.file;
+        if let Some(target) = &reference.target {
+            let targeted: Vec<&Symbol> = index
+                .get_all(target)
+                .iter()
+                .filter(|s| {
+                    s.kind.is_callable() && index.can_reference(from_file, &s.location.file)
+                })
+                .collect();
+            if !targeted.is_empty() {
+                return targeted;
+            }
+        }
+        match (&reference.target, reference.kind) {
+            (Some(target), ReferenceKind::IndirectCall) => self.resolve(index, target, from_file),
+            (None, ReferenceKind::IndirectCall) => Vec::new(),
+            _ => self.resolve(index, &reference.name, from_file),
+        }
+    }
+
+    /// Resolve a reference name to the callable symbols it may refer to.
+    ///
+    /// Resolution order:
+    /// 1. Exact qualified name
+    /// 2. Qualified via the file's open/import statements
+    /// 3. Short-name match on the last segment, so receiver calls like
+    ///    `m.Method` resolve to the declaring type's `Method`. Candidates in the
+    ///    same file win; otherwise every visible candidate is returned.
+    fn resolve(&self, index: &'a CodeIndex, name: &str, from_file: &Path) -> Vec<&'a Symbol> {
+        let visible =
+            |s: &&Symbol| s.kind.is_callable() && index.can_reference(from_file, &s.location.file);
+
+        let exact: Vec<&Symbol> = index.get_all(name).iter().filter(visible).collect();
+        if !exact.is_empty() {
+            return exact;
+        }
+
+        for open in index.opens_for_file(from_file) {
+            let opened: Vec<&Symbol> = index
+                .get_all(&format!("{}.{}", open, name))
+                .iter()
+                .filter(visible)
+                .collect();
+            if !opened.is_empty() {
+                return opened;
+            }
+        }
+
+        let candidates: Vec<&Symbol> = self
+            .by_name
+            .get(last_segment(name))
+            .into_iter()
+            .flatten()
+            .copied()
+            .filter(visible)
+            .collect();
+
+        // A partially qualified reference ("Utils.helper") narrows by suffix
+        let short = last_segment(name);
+        if short != name {
+            let suffix = format!(".{}", name);
+            let qualified_match: Vec<&Symbol> = candidates
+                .iter()
+                .copied()
+                .filter(|s| s.qualified.ends_with(&suffix))
+                .collect();
+            if !qualified_match.is_empty() {
+                return qualified_match;
+            }
+        }
+
+        let same_file: Vec<&Symbol> = candidates
+            .iter()
+            .copied()
+            .filter(|s| s.location.file == from_file)
+            .collect();
+        if !same_file.is_empty() {
+            return same_file;
+        }
+
+        candidates
+    }
+}
+
+/// Index of Go functions in `main.go` for call graph tests.
+///
+/// `calls` are (caller line, callee) pairs; functions are declared at their line.
+#[cfg(test)]
+pub(crate) fn index_of_calls(functions: &[(&str, u32)], calls: &[(u32, &str)]) -> CodeIndex {
+    use std::path::PathBuf;
+
+    let mut index = CodeIndex::new();
+    for &(name, line) in functions {
+        index.add_symbol(Symbol::new(
+            name.to_string(),
+            format!("main.{}", name),
+            SymbolKind::Function,
+            Location::new(PathBuf::from("main.go"), line, 6),
+            crate::Visibility::Private,
+            "go".to_string(),
+        ));
+    }
+    for &(line, callee) in calls {
+        index.add_reference(
+            PathBuf::from("main.go"),
+            Reference {
+                name: callee.to_string(),
+                location: Location::new(PathBuf::from("main.go"), line, 5),
+                kind: ReferenceKind::Call,
+                target: None,
+                arguments: None,
+            },
+        );
+    }
+    index
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::indexer::IndexOptions;
+    use crate::parse::ParseResult;
+    use crate::Visibility;
+    use std::path::PathBuf;
+
+    fn make_symbol(name: &str, qualified: &str, file: &str, line: u32, kind: SymbolKind) -> Symbol {
+        Symbol::new(
+            name.to_string(),
+            qualified.to_string(),
+            kind,
+            Location::new(PathBuf::from(file), line, 6),
+            Visibility::Public,
+            "go".to_string(),
+        )
+    }
+
+    fn add_function(index: &mut CodeIndex, name: &str, file: &str, line: u32) {
+        index.add_symbol(make_symbol(
+            name,
+            &format!("main.{}", name),
+            file,
+            line,
+            SymbolKind::Function,
+        ));
+    }
+
+    fn add_call(index: &mut CodeIndex, name: &str, file: &str, line: u32) {
+        index.add_reference(
+            PathBuf::from(file),
+            Reference {
+                name: name.to_string(),
+                location: Location::new(PathBuf::from(file), line, 5),
+                kind: ReferenceKind::Unknown,
+                target: None,
+                arguments: None,
+            },
+        );
+    }
+
+    /// Add a reference the parser classified as a call (as the Python parser does).
+    fn add_marked_call(index: &mut CodeIndex, name: &str, file: &str, line: u32) {
+        index.add_reference(
+            PathBuf::from(file),
+            Reference {
+                name: name.to_string(),
+                location: Location::new(PathBuf::from(file), line, 5),
+                kind: ReferenceKind::Call,
+                target: None,
+                arguments: None,
+            },
+        );
+    }
+
+    /// Mirrors tests/fixtures/minimal/go/main.go
+    fn minimal_go_index() -> CodeIndex {
+        let mut index = CodeIndex::new();
+        add_function(&mut index, "helper", "main.go", 5);
+        add_function(&mut index, "mainFunction", "main.go", 9);
+        add_function(&mut index, "callerA", "main.go", 14);
+        add_function(&mut index, "callerB", "main.go", 18);
+        index.add_symbol(make_symbol(
+            "MyStruct",
+            "main.MyStruct",
+            "main.go",
+            23,
+            SymbolKind::Class,
+        ));
+        add_function(&mut index, "NewMyStruct", "main.go", 27);
+        index.add_symbol(
+            make_symbol(
+                "Method",
+                "main.MyStruct.Method",
+                "main.go",
+                31,
+                SymbolKind::Function,
+            )
+            .with_parent(Some("MyStruct".to_string())),
+        );
+
+        add_call(&mut index, "helper", "main.go", 10);
+        add_call(&mut index, "fmt.Println", "main.go", 11);
+        add_call(&mut index, "mainFunction", "main.go", 15);
+        add_call(&mut index, "mainFunction", "main.go", 19);
+        add_call(&mut index, "helper", "main.go", 20);
+        add_call(&mut index, "MyStruct", "main.go", 28);
+        add_call(&mut index, "helper", "main.go", 28);
+        index
+    }
+
+    fn caller_names<'a>(sites: &[&'a CallSite]) -> Vec<&'a str> {
+        sites.iter().map(|s| s.caller.as_str()).collect()
+    }
+
+    #[test]
+    fn test_callers_of_helper() {
+        let graph = CallGraph::build(&minimal_go_index());
+
+        let callers = graph.callers("main.helper");
+        assert_eq!(
+            caller_names(&callers),
+            vec!["main.mainFunction", "main.callerB", "main.NewMyStruct"]
+        );
+        assert_eq!(callers[0].location.file, PathBuf::from("main.go"));
+        assert_eq!(callers[0].location.line, 10);
+    }
+
+    #[test]
+    fn test_callees_mirror_callers() {
+        let graph = CallGraph::build(&minimal_go_index());
+
+        let callees: Vec<&str> = graph
+            .callees("main.callerB")
+            .iter()
+            .map(|s| s.callee.as_str())
+            .collect();
+        assert_eq!(callees, vec!["main.mainFunction", "main.helper"]);
+    }
+
+    #[test]
+    fn test_callers_dedupes_repeated_calls() {
+        let mut index = minimal_go_index();
+        add_call(&mut index, "helper", "main.go", 21);
+        add_call(&mut index, "helper", "main.go", 22);
+
+        let graph = CallGraph::build(&index);
+        let callers = graph.callers("main.helper");
+
+        assert_eq!(callers.len(), 3);
+        let caller_b = callers.iter().find(|s| s.caller == "main.callerB").unwrap();
+        assert_eq!(caller_b.location.line, 20, "first call site is reported");
+    }
+
+    #[test]
+    fn test_callers_resolves_receiver_method_calls() {
+        let mut index = minimal_go_index();
+        add_function(&mut index, "useStruct", "main.go", 40);
+        add_call(&mut index, "m.Method", "main.go", 42);
+
+        let graph = CallGraph::build(&index);
+        let callers = graph.callers("main.MyStruct.Method");
+
+        assert_eq!(caller_names(&callers), vec!["main.useStruct"]);
+    }
+
+    #[test]
+    fn test_callers_follow_reference_targets_across_packages() {
+        let mut index = CodeIndex::new();
+        for (name, qualified, file, line) in [
+            ("checkout", "main.checkout", "main.go", 12),
+            ("invoice", "main.invoice", "main.go", 16),
+            (
+                "ProcessPayment",
+                "payment.PaymentService.ProcessPayment",
+                "payment/payment.go",
+                14,
+            ),
+            (
+                "ProcessPayment",
+                "billing.Processor.ProcessPayment",
+                "billing/billing.go",
+                6,
+            ),
+        ] {
+            index.add_symbol(make_symbol(
+                name,
+                qualified,
+                file,
+                line,
+                SymbolKind::Function,
+            ));
+        }
+        for (name, target, line) in [
+            (
+                "svc.ProcessPayment",
+                "payment.PaymentService.ProcessPayment",
+                13,
+            ),
+            ("p.ProcessPayment", "billing.Processor.ProcessPayment", 18),
+        ] {
+            index.add_reference(
+                PathBuf::from("main.go"),
+                Reference {
+                    name: name.to_string(),
+                    location: Location::new(PathBuf::from("main.go"), line, 9),
+                    kind: ReferenceKind::Call,
+                    target: Some(target.to_string()),
+                    arguments: None,
+                },
+            );
+        }
+
+        // Without targets both calls would fan out to both methods
+        let graph = CallGraph::build(&index);
+        assert_eq!(
+            caller_names(&graph.callers("payment.PaymentService.ProcessPayment")),
+            vec!["main.checkout"]
+        );
+        assert_eq!(
+            caller_names(&graph.callers("billing.Processor.ProcessPayment")),
+            vec!["main.invoice"]
+        );
+    }
+
+    #[test]
+    fn test_cross_package_calls_in_go_multipkg_fixture() {
+        let root = Path::new(env!("CARGO_MANIFEST_DIR")).join("../../tests/fixtures/go-multipkg");
+        let files: Vec<PathBuf> = ["main.go", "payment/payment.go", "billing/billing.go"]
+            .iter()
+            .map(|f| root.join(f))
+            .collect();
+        let mut index = CodeIndex::with_root(root);
+        index.update_files(&files, &IndexOptions::default());
+        let graph = CallGraph::build(&index);
+
+        assert_eq!(
+            caller_names(&graph.callers("payment.PaymentService.ProcessPayment")),
+            vec!["main.checkout", "main.fallback"]
+        );
+        assert_eq!(
+            caller_names(&graph.callers("billing.Processor.ProcessPayment")),
+            vec!["main.invoice"]
+        );
+        assert_eq!(
+            caller_names(&graph.callers("payment.ProcessPayment")),
+            vec!["main.quick"]
+        );
+        assert_eq!(
+            caller_names(&graph.callers("payment.NewPaymentService")),
+            vec!["main.main"]
+        );
+    }
+
+    #[test]
+    fn test_unresolved_and_non_callable_references_are_skipped() {
+        let graph = CallGraph::build(&minimal_go_index());
+
+        // fmt.Println is external, MyStruct is a type
+        assert!(graph.sites().iter().all(|s| s.callee != "fmt.Println"));
+        assert!(graph.callers("main.MyStruct").is_empty());
+        assert!(graph.callers("main.callerA").is_empty());
+    }
+
+    /// app.py: `process` and `main`; `main` calls `process`, `print`, and
+    /// `self.client.get`, and reads `value`.
+    fn python_index() -> CodeIndex {
+        let mut index = CodeIndex::new();
+        for (name, line) in [("process", 1), ("main", 5)] {
+            index.add_symbol(make_symbol(
+                name,
+                &format!("app.{}", name),
+                "app.py",
+                line,
+                SymbolKind::Function,
+            ));
+        }
+        add_marked_call(&mut index, "process", "app.py", 6);
+        add_marked_call(&mut index, "print", "app.py", 7);
+        add_marked_call(&mut index, "self.client.get", "app.py", 8);
+        add_call(&mut index, "value", "app.py", 9);
+        index
+    }
+
+    #[test]
+    fn test_unresolved_calls_keep_callee_name() {
+        let graph = CallGraph::build(&python_index());
+
+        assert_eq!(
+            caller_names(&graph.callers("app.process")),
+            vec!["app.main"]
+        );
+
+        let unresolved: Vec<&str> = graph
+            .unresolved_calls()
+            .iter()
+            .map(|call| call.callee.as_str())
+            .collect();
+        // `value` was not marked as a call, so it is not reported
+        assert_eq!(unresolved, vec!["print", "self.client.get"]);
+        assert!(graph
+            .unresolved_calls()
+            .iter()
+            .all(|call| call.caller == "app.main"));
+        assert_eq!(graph.unresolved_calls()[0].location.line, 7);
+        assert_eq!(graph.unresolved_calls_from("app.main").len(), 2);
+        assert!(graph.unresolved_calls_from("app.process").is_empty());
+
+        let print = &graph.unresolved_calls()[0];
+        assert_eq!(print.kind, UnresolvedKind::Unknown);
+        assert_eq!(print.qualifier, None);
+        let get = &graph.unresolved_calls()[1];
+        assert_eq!(get.kind, UnresolvedKind::Dynamic);
+        assert_eq!(get.qualifier.as_deref(), Some("self.client"));
+    }
+
+    #[test]
+    fn test_unresolved_calls_through_imports_are_external() {
+        let mut index = CodeIndex::new();
+        index.add_symbol(Symbol::new(
+            "main".to_string(),
+            "main.main".to_string(),
+            SymbolKind::Function,
+            Location::with_end(PathBuf::from("main.go"), 5, 6, 12, 1),
+            Visibility::Private,
+            "go".to_string(),
+        ));
+        for open in ["fmt", "net/http"] {
+            index.add_open(PathBuf::from("main.go"), open.to_string());
+        }
+        add_marked_call(&mut index, "fmt.Println", "main.go", 6);
+        add_marked_call(&mut index, "http.Get", "main.go", 7);
+        add_marked_call(&mut index, "client.Do", "main.go", 8);
+
+        let graph = CallGraph::build(&index);
+        let calls: Vec<(&str, UnresolvedKind, Option<&str>)> = graph
+            .unresolved_calls()
+            .iter()
+            .map(|c| (c.callee.as_str(), c.kind, c.qualifier.as_deref()))
+            .collect();
+        assert_eq!(
+            calls,
+            vec![
+                ("fmt.Println", UnresolvedKind::External, Some("fmt")),
+                ("http.Get", UnresolvedKind::External, Some("http")),
+                ("client.Do", UnresolvedKind::Dynamic, Some("client")),
+            ]
+        );
+    }
+
+    #[test]
+    fn test_split_qualifier_and_imports() {
+        assert_eq!(split_qualifier("fmt.Println"), Some(("fmt", "Println")));
+        assert_eq!(split_qualifier("std::fs::read"), Some(("std::fs", "read")));
+        assert_eq!(split_qualifier("print"), None);
+        assert_eq!(split_qualifier(".hidden"), None);
+
+        let opens = vec!["os".to_string(), "std::fs".to_string()];
+        assert!(imports_qualifier(&opens, "os.path"));
+        assert!(imports_qualifier(&opens, "std::fs"));
+        assert!(imports_qualifier(&opens, "fs"));
+        assert!(!imports_qualifier(&opens, "self.client"));
+    }
+
+    #[test]
+    fn test_apply_update_resolves_previously_unresolved_calls() {
+        let mut index = python_index();
+        let mut graph = CallGraph::build(&index);
+        assert_eq!(graph.unresolved_calls().len(), 2);
+
+        let mut result = ParseResult::default();
+        result.symbols.push(make_symbol(
+            "print",
+            "util.print",
+            "util.py",
+            1,
+            SymbolKind::Function,
+        ));
+        let update = index.replace_file(Path::new("util.py"), &result);
+        graph.apply_update(&index, &update);
+
+        assert_eq!(caller_names(&graph.callers("util.print")), vec!["app.main"]);
+        assert_eq!(graph.unresolved_calls().len(), 1);
+        assert_eq!(graph.unresolved_calls()[0].callee, "self.client.get");
+        assert_same_as_rebuild(&graph, &index);
+
+        let update = index.remove_file(Path::new("util.py"));
+        graph.apply_update(&index, &update);
+        assert_eq!(graph.unresolved_calls().len(), 2);
+        assert_same_as_rebuild(&graph, &index);
+    }
+
+    #[test]
+    fn test_callers_ordered_by_file_then_line() {
+        let mut index = CodeIndex::new();
+        add_function(&mut index, "target", "b.go", 1);
+        add_function(&mut index, "late", "a.go", 20);
+        add_function(&mut index, "early", "a.go", 1);
+        add_function(&mut index, "other", "b.go", 10);
+        add_call(&mut index, "target", "b.go", 11);
+        add_call(&mut index, "target", "a.go", 21);
+        add_call(&mut index, "target", "a.go", 2);
+
+        let graph = CallGraph::build(&index);
+
+        assert_eq!(
+            caller_names(&graph.callers("main.target")),
+            vec!["main.early", "main.late", "main.other"]
+        );
+    }
+
+    /// Mirrors tests/claude-plugin-integration/test-repos/go-sample
+    fn go_sample_index() -> CodeIndex {
+        let mut index = CodeIndex::new();
+        add_function(&mut index, "main", "main.go", 5);
+        add_function(&mut index, "NewPaymentService", "payment.go", 7);
+        add_function(&mut index, "ProcessPayment", "payment.go", 11);
+        add_function(&mut index, "RefundPayment", "payment.go", 16);
+        add_function(&mut index, "NewUser", "user.go", 8);
+        add_function(&mut index, "FullInfo", "user.go", 12);
+
+        add_call(&mut index, "NewUser", "main.go", 6);
+        add_call(&mut index, "fmt.Println", "main.go", 7);
+        add_call(&mut index, "user.FullInfo", "main.go", 7);
+        add_call(&mut index, "NewPaymentService", "main.go", 9);
+        add_call(&mut index, "service.ProcessPayment", "main.go", 10);
+        index
+    }
+
+    #[test]
+    fn test_reachable_from_main() {
+        let graph = CallGraph::build(&go_sample_index());
+
+        let reached = graph.reachable_from("main.main", 0);
+        let names: Vec<&str> = reached.iter().map(|r| r.qualified.as_str()).collect();
+        assert_eq!(
+            names,
+            vec![
+                "main.NewUser",
+                "main.FullInfo",
+                "main.NewPaymentService",
+                "main.ProcessPayment"
+            ]
+        );
+        assert!(reached.iter().all(|r| r.depth == 1));
+        assert_eq!(reached[1].path, vec!["main.main", "main.FullInfo"]);
+    }
+
+    #[test]
+    fn test_reachable_from_reports_shortest_depth_and_path() {
+        let graph = CallGraph::build(&minimal_go_index());
+
+        let reached = graph.reachable_from("main.callerA", 0);
+        assert_eq!(reached.len(), 2);
+        assert_eq!(reached[0].qualified, "main.mainFunction");
+        assert_eq!(reached[0].depth, 1);
+        assert_eq!(reached[1].qualified, "main.helper");
+        assert_eq!(reached[1].depth, 2);
+        assert_eq!(
+            reached[1].path,
+            vec!["main.callerA", "main.mainFunction", "main.helper"]
+        );
+
+        // callerB calls helper directly and via mainFunction: shortest wins
+        let reached = graph.reachable_from("main.callerB", 0);
+        let helper = reached
+            .iter()
+            .find(|r| r.qualified == "main.helper")
+            .unwrap();
+        assert_eq!(helper.depth, 1);
+        assert_eq!(helper.path, vec!["main.callerB", "main.helper"]);
+    }
+
+    #[test]
+    fn test_reachable_from_respects_max_depth() {
+        let graph = CallGraph::build(&minimal_go_index());
+
+        let reached = graph.reachable_from("main.callerA", 1);
+        assert_eq!(reached.len(), 1);
+        assert_eq!(reached[0].qualified, "main.mainFunction");
+    }
+
+    #[test]
+    fn test_reachable_from_handles_recursion() {
+        let mut index = CodeIndex::new();
+        add_function(&mut index, "ping", "a.go", 1);
+        add_function(&mut index, "pong", "a.go", 10);
+        add_function(&mut index, "fact", "a.go", 20);
+        add_call(&mut index, "pong", "a.go", 2);
+        add_call(&mut index, "fact", "a.go", 3);
+        add_call(&mut index, "ping", "a.go", 11);
+        add_call(&mut index, "fact", "a.go", 21);
+
+        let graph = CallGraph::build(&index);
+        let reached = graph.reachable_from("main.ping", 0);
+
+        let names: Vec<&str> = reached.iter().map(|r| r.qualified.as_str()).collect();
+        assert_eq!(names, vec!["main.pong", "main.fact"]);
+    }
+
+    #[test]
+    fn test_paths_between_symbols() {
+        let graph = CallGraph::build(&minimal_go_index());
+
+        assert_eq!(
+            graph.paths("main.callerA", "main.helper", 5),
+            vec![vec!["main.callerA", "main.mainFunction", "main.helper"]]
+        );
+        // Shortest first: the direct call, then the one via mainFunction
+        assert_eq!(
+            graph.paths("main.callerB", "main.helper", 5),
+            vec![
+                vec!["main.callerB", "main.helper"],
+                vec!["main.callerB", "main.mainFunction", "main.helper"],
+            ]
+        );
+        assert_eq!(graph.paths("main.callerB", "main.helper", 1).len(), 1);
+
+        assert!(graph.paths("main.helper", "main.callerA", 5).is_empty());
+        assert!(graph.paths("main.missing", "main.helper", 5).is_empty());
+        assert!(graph.paths("main.callerA", "main.helper", 0).is_empty());
+        assert_eq!(
+            graph.paths("main.helper", "main.helper", 5),
+            vec![vec!["main.helper"]]
+        );
+    }
+
+    #[test]
+    fn test_paths_do_not_revisit_symbols_in_cycles() {
+        let mut index = CodeIndex::new();
+        add_function(&mut index, "ping", "a.go", 1);
+        add_function(&mut index, "pong", "a.go", 10);
+        add_function(&mut index, "fact", "a.go", 20);
+        add_call(&mut index, "pong", "a.go", 2);
+        add_call(&mut index, "fact", "a.go", 3);
+        add_call(&mut index, "ping", "a.go", 11);
+        add_call(&mut index, "fact", "a.go", 12);
+        add_call(&mut index, "fact", "a.go", 21);
+
+        let graph = CallGraph::build(&index);
+        let paths = graph.paths("main.ping", "main.fact", 10);
+        assert_eq!(
+            paths,
+            vec![
+                vec!["main.ping", "main.fact"],
+                vec!["main.ping", "main.pong", "main.fact"],
+            ]
+        );
+    }
+
+    #[test]
+    fn test_cycles_report_mutual_and_self_recursion() {
+        let mut index = CodeIndex::new();
+        add_function(&mut index, "ping", "a.go", 1);
+        add_function(&mut index, "pong", "a.go", 10);
+        add_function(&mut index, "fact", "a.go", 20);
+        add_function(&mut index, "run", "a.go", 30);
+        add_call(&mut index, "pong", "a.go", 2);
+        add_call(&mut index, "ping", "a.go", 11);
+        add_call(&mut index, "fact", "a.go", 12);
+        add_call(&mut index, "fact", "a.go", 21);
+        add_call(&mut index, "ping", "a.go", 31);
+
+        let graph = CallGraph::build(&index);
+        assert_eq!(
+            graph.cycles(),
+            vec![vec!["main.fact"], vec!["main.ping", "main.pong"]]
+        );
+        assert!(CallGraph::build(&minimal_go_index()).cycles().is_empty());
+    }
+
+    #[test]
+    fn test_prefers_same_file_candidates() {
+        let mut index = CodeIndex::new();
+        index.add_symbol(make_symbol(
+            "helper",
+            "a.helper",
+            "a.go",
+            1,
+            SymbolKind::Function,
+        ));
+        index.add_symbol(make_symbol(
+            "helper",
+            "b.helper",
+            "b.go",
+            1,
+            SymbolKind::Function,
+        ));
+        index.add_symbol(make_symbol("run", "a.run", "a.go", 5, SymbolKind::Function));
+        add_call(&mut index, "helper", "a.go", 6);
+
+        let graph = CallGraph::build(&index);
+
+        assert_eq!(caller_names(&graph.callers("a.helper")), vec!["a.run"]);
+        assert!(graph.callers("b.helper").is_empty());
+    }
+
+    /// payment.go from the go-sample fixture, optionally without `RefundPayment`.
+    fn payment_go(with_refund: bool) -> ParseResult {
+        let mut result = ParseResult::default();
+        let mut functions = vec![("NewPaymentService", 7), ("ProcessPayment", 11)];
+        if with_refund {
+            functions.push(("RefundPayment", 16));
+        }
+        for (name, line) in functions {
+            result.symbols.push(make_symbol(
+                name,
+                &format!("main.{}", name),
+                "payment.go",
+                line,
+                SymbolKind::Function,
+            ));
+            result.references.push(Reference {
+                name: "fmt.Printf".to_string(),
+                location: Location::new(PathBuf::from("payment.go"), line + 1, 2),
+                kind: ReferenceKind::Unknown,
+                target: None,
+                arguments: None,
+            });
+        }
+        result
+    }
+
+    fn assert_same_as_rebuild(graph: &CallGraph, index: &CodeIndex) {
+        let rebuilt = CallGraph::build(index);
+        assert_eq!(graph.sites(), rebuilt.sites());
+        assert_eq!(graph.unresolved_calls(), rebuilt.unresolved_calls());
+    }
+
+    #[test]
+    fn test_apply_update_drops_edges_to_removed_symbol() {
+        let mut index = go_sample_index();
+        add_call(&mut index, "service.RefundPayment", "main.go", 11);
+        let mut graph = CallGraph::build(&index);
+        assert_eq!(
+            caller_names(&graph.callers("main.RefundPayment")),
+            vec!["main.main"]
+        );
+
+        let update = index.replace_file(Path::new("payment.go"), &payment_go(false));
+        graph.apply_update(&index, &update);
+
+        assert!(graph.callers("main.RefundPayment").is_empty());
+        assert!(graph
+            .sites()
+            .iter()
+            .all(|s| s.callee != "main.RefundPayment"));
+        assert_eq!(
+            caller_names(&graph.callers("main.ProcessPayment")),
+            vec!["main.main"]
+        );
+        assert_same_as_rebuild(&graph, &index);
+    }
+
+    #[test]
+    fn test_apply_update_adds_edges_to_new_symbol() {
+        let mut index = go_sample_index();
+        index.replace_file(Path::new("payment.go"), &payment_go(false));
+        add_call(&mut index, "service.RefundPayment", "main.go", 11);
+        let mut graph = CallGraph::build(&index);
+        assert!(graph.callers("main.RefundPayment").is_empty());
+
+        let update = index.replace_file(Path::new("payment.go"), &payment_go(true));
+        graph.apply_update(&index, &update);
+
+        assert_eq!(
+            caller_names(&graph.callers("main.RefundPayment")),
+            vec!["main.main"]
+        );
+        assert_same_as_rebuild(&graph, &index);
+    }
+
+    #[test]
+    fn test_apply_update_for_removed_file() {
+        let mut index = go_sample_index();
+        let mut graph = CallGraph::build(&index);
+
+        let update = index.remove_file(Path::new("user.go"));
+        graph.apply_update(&index, &update);
+
+        assert!(graph.callers("main.NewUser").is_empty());
+        assert!(graph.callers("main.FullInfo").is_empty());
+        assert_eq!(
+            caller_names(&graph.callers("main.NewPaymentService")),
+            vec!["main.main"]
+        );
+        assert_same_as_rebuild(&graph, &index);
+    }
+
+    /// shapes.go declares `Shape`, implemented by `Square` and `Circle`;
+    /// `describe` calls `Shape.Area`.
+    fn shapes_index() -> CodeIndex {
+        let mut index = CodeIndex::new();
+        index.add_symbol(make_symbol(
+            "Shape",
+            "main.Shape",
+            "shapes.go",
+            1,
+            SymbolKind::Interface,
+        ));
+        index.add_symbol(
+            make_symbol(
+                "Area",
+                "main.Shape.Area",
+                "shapes.go",
+                2,
+                SymbolKind::Function,
+            )
+            .with_parent(Some("main.Shape".to_string()))
+            .with_signature(Some("func Area() float64".to_string())),
+        );
+        for (ty, file) in [("Square", "square.go"), ("Circle", "circle.go")] {
+            index.add_symbol(make_symbol(
+                ty,
+                &format!("main.{}", ty),
+                file,
+                1,
+                SymbolKind::Class,
+            ));
+            index.add_symbol(
+                make_symbol(
+                    "Area",
+                    &format!("main.{}.Area", ty),
+                    file,
+                    5,
+                    SymbolKind::Function,
+                )
+                .with_parent(Some(ty.to_string()))
+                .with_signature(Some(format!("func ({}) Area() float64", ty))),
+            );
+        }
+        add_function(&mut index, "describe", "main.go", 10);
+        add_call(&mut index, "Shape.Area", "main.go", 11);
+        index
+    }
+
+    #[test]
+    fn test_interface_calls_are_direct_by_default() {
+        let graph = CallGraph::build(&shapes_index());
+
+        let callees: Vec<&str> = graph
+            .callees("main.describe")
+            .iter()
+            .map(|s| s.callee.as_str())
+            .collect();
+        assert_eq!(callees, vec!["main.Shape.Area"]);
+        assert!(graph.sites().iter().all(|s| s.kind == CallKind::Direct));
+    }
+
+    #[test]
+    fn test_interface_dispatch_adds_possible_edges() {
+        let options = CallGraphOptions {
+            interface_dispatch: true,
+            ..CallGraphOptions::default()
+        };
+        let graph = CallGraph::build_with_options(&shapes_index(), options);
+
+        let callees: Vec<(&str, CallKind)> = graph
+            .callees("main.describe")
+            .iter()
+            .map(|s| (s.callee.as_str(), s.kind))
+            .collect();
+        assert_eq!(
+            callees,
+            vec![
+                ("main.Circle.Area", CallKind::Possible),
+                ("main.Shape.Area", CallKind::Direct),
+                ("main.Square.Area", CallKind::Possible),
+            ]
+        );
+
+        let callers = graph.callers("main.Square.Area");
+        assert_eq!(caller_names(&callers), vec!["main.describe"]);
+        assert_eq!(callers[0].kind, CallKind::Possible);
+        assert_eq!(callers[0].location.line, 11);
+    }
+
+    #[test]
+    fn test_apply_update_rederives_possible_edges() {
+        let options = CallGraphOptions {
+            interface_dispatch: true,
+            ..CallGraphOptions::default()
+        };
+        let mut index = shapes_index();
+        let mut graph = CallGraph::build_with_options(&index, options);
+
+        // Circle loses its Area method and no longer implements Shape
+        let mut circle = ParseResult::default();
+        circle.symbols.push(make_symbol(
+            "Circle",
+            "main.Circle",
+            "circle.go",
+            1,
+            SymbolKind::Class,
+        ));
+        let update = index.replace_file(Path::new("circle.go"), &circle);
+        graph.apply_update(&index, &update);
+
+        assert!(graph.callers("main.Circle.Area").is_empty());
+        assert_eq!(
+            caller_names(&graph.callers("main.Square.Area")),
+            vec!["main.describe"]
+        );
+        assert_eq!(
+            graph.sites(),
+            CallGraph::build_with_options(&index, options).sites()
+        );
+    }
+
+    /// Java overloads: `Calc.add(int, int)` and `Calc.add(double, double)`,
+    /// and `Shape.area(int)`/`Shape.area(double)` implemented by `Circle`.
+    /// `Calc.run` calls them with the argument types the parser inferred.
+    fn java_overloads_index() -> CodeIndex {
+        let java = |name: &str, qualified: &str, file: &str, line: u32, kind| Symbol {
+            language: "java".to_string(),
+            ..make_symbol(name, qualified, file, line, kind)
+        };
+        let method = |qualified: &str, file: &str, line: u32, signature: &str| {
+            let (parent, name) = qualified.rsplit_once('.').unwrap();
+            java(name, qualified, file, line, SymbolKind::Function)
+                .with_parent(Some(parent.to_string()))
+                .with_signature(Some(signature.to_string()))
+        };
+
+        let mut index = CodeIndex::new();
+        index.add_symbol(java(
+            "Shape",
+            "com.example.Shape",
+            "Shape.java",
+            1,
+            SymbolKind::Interface,
+        ));
+        let mut circle = java(
+            "Circle",
+            "com.example.Circle",
+            "Circle.java",
+            1,
+            SymbolKind::Class,
+        );
+        circle.implements = Some(vec!["Shape".to_string()]);
+        index.add_symbol(circle);
+        for (owner, file) in [("Shape", "Shape.java"), ("Circle", "Circle.java")] {
+            let qualified = format!("com.example.{}.area", owner);
+            index.add_symbol(method(&qualified, file, 3, "double area(int scale)"));
+            index.add_symbol(method(&qualified, file, 4, "double area(double scale)"));
+        }
+        index.add_symbol(method(
+            "com.example.Calc.add",
+            "Calc.java",
+            3,
+            "int add(int a, int b)",
+        ));
+        index.add_symbol(method(
+            "com.example.Calc.add",
+            "Calc.java",
+            4,
+            "double add(double a, double b)",
+        ));
+        index.add_symbol(method(
+            "com.example.Calc.run",
+            "Calc.java",
+            10,
+            "void run()",
+        ));
+
+        for (line, name, target, arguments) in [
+            (
+                11,
+                "add",
+                "com.example.Calc.add",
+                vec![Some("int"), Some("int")],
+            ),
+            (
+                12,
+                "add",
+                "com.example.Calc.add",
+                vec![Some("double"), None],
+            ),
+            (
+                13,
+                "shape.area",
+                "com.example.Shape.area",
+                vec![Some("double")],
+            ),
+            (14, "add", "com.example.Calc.add", vec![None, None]),
+        ] {
+            index.add_reference(
+                PathBuf::from("Calc.java"),
+                Reference {
+                    name: name.to_string(),
+                    location: Location::new(PathBuf::from("Calc.java"), line, 9),
+                    kind: ReferenceKind::Call,
+                    target: Some(target.to_string()),
+                    arguments: Some(
+                        arguments
+                            .into_iter()
+                            .map(|a| a.map(str::to_string))
+                            .collect(),
+                    ),
+                },
+            );
+        }
+        index
+    }
+
+    #[test]
+    fn test_calls_record_the_overload_their_arguments_select() {
+        let options = CallGraphOptions {
+            interface_dispatch: true,
+            ..CallGraphOptions::default()
+        };
+        let graph = CallGraph::build_with_options(&java_overloads_index(), options);
+
+        let sites: Vec<(u32, &str, CallKind, Option<&str>)> = graph
+            .sites()
+            .iter()
+            .filter(|s| s.caller == "com.example.Calc.run")
+            .map(|s| {
+                (
+                    s.location.line,
+                    s.callee.as_str(),
+                    s.kind,
+                    s.overload.as_deref(),
+                )
+            })
+            .collect();
+        assert_eq!(
+            sites,
+            vec![
+                (
+                    11,
+                    "com.example.Calc.add",
+                    CallKind::Direct,
+                    Some("int add(int a, int b)")
+                ),
+                (
+                    12,
+                    "com.example.Calc.add",
+                    CallKind::Direct,
+                    Some("double add(double a, double b)")
+                ),
+                // Dispatch reaches the implementing overload with the same parameters
+                (
+                    13,
+                    "com.example.Circle.area",
+                    CallKind::Possible,
+                    Some("double area(double scale)")
+                ),
+                (
+                    13,
+                    "com.example.Shape.area",
+                    CallKind::Direct,
+                    Some("double area(double scale)")
+                ),
+                // Unknown argument types match both overloads: no single overload
+                (14, "com.example.Calc.add", CallKind::Direct, None),
+            ]
+        );
+    }
+
+    #[test]
+    fn test_ambiguous_calls_list_the_candidate_overloads() {
+        let graph = CallGraph::build(&java_overloads_index());
+        let candidates: Vec<(u32, &[String])> = graph
+            .sites()
+            .iter()
+            .filter(|s| !s.candidates.is_empty())
+            .map(|s| (s.location.line, s.candidates.as_slice()))
+            .collect();
+        assert_eq!(
+            candidates,
+            vec![(
+                14,
+                &[
+                    "int add(int a, int b)".to_string(),
+                    "double add(double a, double b)".to_string()
+                ][..]
+            )]
+        );
+
+        // Without argument types (Go records none) nothing is ambiguous
+        let mut index = CodeIndex::new();
+        add_function(&mut index, "open", "open_linux.go", 3);
+        add_function(&mut index, "open", "open_windows.go", 3);
+        add_function(&mut index, "run", "main.go", 1);
+        add_call(&mut index, "open", "main.go", 2);
+        let graph = CallGraph::build(&index);
+        assert_eq!(graph.callees("main.run")[0].callee, "main.open");
+        assert!(graph.sites().iter().all(|s| s.candidates.is_empty()));
+    }
+
+    #[test]
+    fn test_declared_functions_are_one_callee_and_macros_are_unresolved() {
+        use crate::declarations::{DECLARATION, MACRO};
+
+        let mut index = CodeIndex::new();
+        let marked = |name: &str, file: &str, line: u32, attribute: &str| {
+            make_symbol(name, name, file, line, SymbolKind::Function)
+                .with_attributes(Some(vec![attribute.to_string()]))
+                .with_signature(Some(format!("int {}(int x)", name)))
+        };
+        index.add_symbol(marked("add", "math.h", 3, DECLARATION));
+        index.add_symbol(
+            make_symbol("add", "add", "math.c", 5, SymbolKind::Function)
+                .with_signature(Some("int add(int a)".to_string())),
+        );
+        index.add_symbol(marked("LOG", "log.h", 1, MACRO));
+        index.add_symbol(make_symbol(
+            "main",
+            "main",
+            "main.c",
+            1,
+            SymbolKind::Function,
+        ));
+        add_marked_call(&mut index, "add", "main.c", 2);
+        add_marked_call(&mut index, "LOG", "main.c", 3);
+        let graph = CallGraph::build(&index);
+
+        // One site, not one per declaration
+        let sites: Vec<(&str, u32)> = graph
+            .sites()
+            .iter()
+            .map(|s| (s.callee.as_str(), s.location.line))
+            .collect();
+        assert_eq!(sites, vec![("add", 2)]);
+
+        let unresolved: Vec<(&str, UnresolvedKind)> = graph
+            .unresolved_calls()
+            .iter()
+            .map(|c| (c.callee.as_str(), c.kind))
+            .collect();
+        assert_eq!(unresolved, vec![("LOG", UnresolvedKind::Macro)]);
+    }
+
+    fn qualified_names<'a>(symbols: &[&'a Symbol]) -> Vec<&'a str> {
+        symbols.iter().map(|s| s.qualified.as_str()).collect()
+    }
+
+    #[test]
+    fn test_unreachable_from_missing_main() {
+        let index = minimal_go_index();
+        let graph = CallGraph::build(&index);
+
+        // The minimal fixture has no main function, so nothing is reachable
+        let dead = graph.unreachable_symbols(&index, &["main"], false);
+        assert_eq!(
+            qualified_names(&dead),
+            vec![
+                "main.helper",
+                "main.mainFunction",
+                "main.callerA",
+                "main.callerB",
+                "main.NewMyStruct",
+                "main.MyStruct.Method",
+            ]
+        );
+    }
+
+    #[test]
+    fn test_unreachable_from_roots() {
+        let index = minimal_go_index();
+        let graph = CallGraph::build(&index);
+
+        let dead = graph.unreachable_symbols(&index, &["callerB"], false);
+        assert_eq!(
+            qualified_names(&dead),
+            vec!["main.callerA", "main.NewMyStruct", "main.MyStruct.Method"]
+        );
+
+        let dead = graph.unreachable_symbols(&index, &["main.callerA", "main.NewMyStruct"], false);
+        assert_eq!(
+            qualified_names(&dead),
+            vec!["main.callerB", "main.MyStruct.Method"]
+        );
+    }
+
+    #[test]
+    fn test_unreachable_treats_exported_symbols_as_roots() {
+        let mut index = CodeIndex::new();
+        for (name, line, visibility) in [
+            ("helper", 1, Visibility::Private),
+            ("unused", 5, Visibility::Private),
+            ("Exported", 10, Visibility::Public),
+        ] {
+            let mut symbol = make_symbol(
+                name,
+                &format!("main.{}", name),
+                "main.go",
+                line,
+                SymbolKind::Function,
+            );
+            symbol.visibility = visibility;
+            index.add_symbol(symbol);
+        }
+        // An exported method of an unexported type cannot be called from
+        // another package
+        let mut store = make_symbol("store", "main.store", "main.go", 15, SymbolKind::Class);
+        store.visibility = Visibility::Private;
+        index.add_symbol(store);
+        index.add_symbol(
+            make_symbol(
+                "Flush",
+                "main.store.Flush",
+                "main.go",
+                20,
+                SymbolKind::Function,
+            )
+            .with_parent(Some("store".to_string())),
+        );
+        add_call(&mut index, "helper", "main.go", 11);
+        let graph = CallGraph::build(&index);
+
+        assert_eq!(
+            qualified_names(&graph.unreachable_symbols(&index, &[], false)),
+            vec![
+                "main.helper",
+                "main.unused",
+                "main.Exported",
+                "main.store.Flush"
+            ]
+        );
+        assert_eq!(
+            qualified_names(&graph.unreachable_symbols(&index, &[], true)),
+            vec!["main.unused", "main.store.Flush"]
+        );
+    }
+
+    #[test]
+    fn test_unreachable_can_exclude_generated_code() {
+        let mut index = CodeIndex::new();
+        let mut stub = make_symbol("Stub", "pb.Stub", "pb/api.pb.go", 3, SymbolKind::Function);
+        stub.attributes = Some(vec![crate::generated::GENERATED.to_string()]);
+        index.add_symbol(stub);
+        add_function(&mut index, "unused", "main.go", 1);
+        let graph = CallGraph::build(&index);
+
+        assert_eq!(
+            qualified_names(&graph.unreachable_symbols(&index, &[], false)),
+            vec!["main.unused", "pb.Stub"]
+        );
+        let options = DeadCodeOptions {
+            exclude_generated: true,
+            ..DeadCodeOptions::default()
+        };
+        assert_eq!(
+            qualified_names(&graph.unreachable_symbols_with_options(&index, &[], &options)),
+            vec!["main.unused"]
+        );
+    }
+
+    #[test]
+    fn test_unreachable_keeps_methods_called_through_interfaces() {
+        let mut index = shapes_index();
+        add_function(&mut index, "unused", "main.go", 20);
+        let graph = CallGraph::build(&index);
+
+        let dead = graph.unreachable_symbols(&index, &["describe"], false);
+        assert_eq!(qualified_names(&dead), vec!["main.unused"]);
+
+        // Without a caller of Shape.Area the implementations are dead too,
+        // but the interface method declaration is never reported
+        let dead = graph.unreachable_symbols(&index, &["unused"], false);
+        assert_eq!(
+            qualified_names(&dead),
+            vec!["main.Circle.Area", "main.describe", "main.Square.Area"]
+        );
+    }
+
+    /// Mirrors `run` in the Go parser test for function values: a method
+    /// value, a closure calling `helper`, and a variable holding `helper`.
+    fn function_values_index() -> CodeIndex {
+        let mut index = CodeIndex::new();
+        add_function(&mut index, "run", "main.go", 3);
+        add_function(&mut index, "helper", "main.go", 20);
+        index.add_symbol(make_symbol(
+            "RefundPayment",
+            "main.PaymentService.RefundPayment",
+            "payment.go",
+            5,
+            SymbolKind::Function,
+        ));
+        index.add_symbol(
+            Symbol::new(
+                "func1".to_string(),
+                "main.run.func1".to_string(),
+                SymbolKind::Function,
+                Location::with_end(PathBuf::from("main.go"), 7, 5, 10, 3),
+                Visibility::Private,
+                "go".to_string(),
+            )
+            .with_parent(Some("main.run".to_string())),
+        );
+
+        let mut add = |name: &str, line, column, kind, target: Option<&str>| {
+            index.add_reference(
+                PathBuf::from("main.go"),
+                Reference {
+                    name: name.to_string(),
+                    location: Location::new(PathBuf::from("main.go"), line, column),
+                    kind,
+                    target: target.map(str::to_string),
+                    arguments: None,
+                },
+            );
+        };
+        add(
+            "callback",
+            5,
+            2,
+            ReferenceKind::IndirectCall,
+            Some("main.PaymentService.RefundPayment"),
+        );
+        add(
+            "func1",
+            7,
+            5,
+            ReferenceKind::IndirectCall,
+            Some("main.run.func1"),
+        );
+        add("helper", 8, 3, ReferenceKind::Call, None);
+        // A target as written is resolved like a reference name
+        add("f", 12, 2, ReferenceKind::IndirectCall, Some("helper"));
+        add("g", 13, 2, ReferenceKind::IndirectCall, None);
+        index
+    }
+
+    fn edges<'a>(graph: &'a CallGraph, caller: &str) -> Vec<(&'a str, u32, CallKind)> {
+        graph
+            .callees(caller)
+            .iter()
+            .map(|s| (s.callee.as_str(), s.location.line, s.kind))
+            .collect()
+    }
+
+    #[test]
+    fn test_indirect_calls_and_closures() {
+        let graph = CallGraph::build(&function_values_index());
+
+        assert_eq!(
+            edges(&graph, "main.run"),
+            vec![
+                ("main.PaymentService.RefundPayment", 5, CallKind::Indirect),
+                ("main.run.func1", 7, CallKind::Indirect),
+                ("main.helper", 12, CallKind::Indirect),
+            ]
+        );
+        // The closure owns the call in its body
+        assert_eq!(
+            edges(&graph, "main.run.func1"),
+            vec![("main.helper", 8, CallKind::Direct)]
+        );
+        // An indirect call with no known target is unresolved
+        let unresolved: Vec<&str> = graph
+            .unresolved_calls()
+            .iter()
+            .map(|c| c.callee.as_str())
+            .collect();
+        assert_eq!(unresolved, vec!["g"]);
+    }
+
+    #[test]
+    fn test_indirect_calls_can_be_disabled() {
+        let options = CallGraphOptions {
+            indirect_calls: false,
+            ..CallGraphOptions::default()
+        };
+        let graph = CallGraph::build_with_options(&function_values_index(), options);
+
+        assert_eq!(
+            edges(&graph, "main.run"),
+            vec![("main.helper", 8, CallKind::Direct)]
+        );
+        assert!(graph.callees("main.run.func1").is_empty());
+        assert!(graph.sites().iter().all(|s| s.kind == CallKind::Direct));
+    }
+
+    #[test]
+    fn test_callers_are_the_innermost_declaration_extent() {
+        // def outer():        1
+        //     def inner():    2
+        //         helper()    3
+        //     helper()        4
+        // helper()            6
+        // def helper(): ...   8
+        let mut index = CodeIndex::new();
+        let function = |name: &str, qualified: &str, line| {
+            make_symbol(name, qualified, "app.py", line, SymbolKind::Function)
+        };
+        index.add_symbol(function("outer", "outer", 1).with_extent(1, 4));
+        index.add_symbol(
+            function("inner", "outer.inner", 2)
+                .with_extent(2, 3)
+                .with_parent(Some("outer".to_string())),
+        );
+        index.add_symbol(function("helper", "helper", 8).with_extent(8, 8));
+        for line in [3, 4, 6] {
+            add_marked_call(&mut index, "helper", "app.py", line);
+        }
+
+        let graph = CallGraph::build(&index);
+        let callers: Vec<(&str, u32)> = graph
+            .callers("helper")
+            .iter()
+            .map(|s| (s.caller.as_str(), s.location.line))
+            .collect();
+        // The call after the nested function is the outer one's, and the
+        // module-level call belongs to no callable
+        assert_eq!(callers, vec![("outer.inner", 3), ("outer", 4)]);
+
+        // Without indirect calls the nested function folds into its parent
+        let options = CallGraphOptions {
+            indirect_calls: false,
+            ..CallGraphOptions::default()
+        };
+        let graph = CallGraph::build_with_options(&index, options);
+        assert_eq!(caller_names(&graph.callers("helper")), vec!["outer"]);
+    }
+}
@@ -0,0 +1,276 @@
+//! Cancellation and deadlines for long-running queries.
+//!
+//! Graph traversals can take a long time on pathological graphs: the number
+//! of call paths between two symbols grows exponentially with the depth of a
+//! densely connected graph. A [`CancelToken`] lets the caller bound that
+//! work, either by cancelling from another thread (a client disconnecting)
+//! or with a deadline (a server's per-request budget).
+//!
+//! The expensive queries take a token in their `_with_cancel` variant:
+//!
+//! - [`CallGraph::reachable_from_with_cancel`](crate::callgraph::CallGraph::reachable_from_with_cancel)
+//! - [`CallGraph::paths_with_cancel`](crate::callgraph::CallGraph::paths_with_cancel)
+//! - [`CallGraph::centrality_with_cancel`](crate::callgraph::CallGraph::centrality_with_cancel)
+//! - [`spider_with_cancel`](crate::spider::spider_with_cancel) and
+//!   [`reverse_spider_with_cancel`](crate::spider::reverse_spider_with_cancel)
+//!
+//! They check the token at every step and return what they found so far as a
+//! [`Partial`] result with [`Partial::truncated`] set. A symbol search
+//! cannot return a meaningful partial ranking, so
+//! [`CodeIndex::search_symbols`](crate::CodeIndex::search_symbols) fails with
+//! [`SearchError::Cancelled`](crate::search::SearchError::Cancelled) instead
+//! when [`SearchOptions::cancel`](crate::search::SearchOptions::cancel) fires.
+//!
+//! Clones of a token share its cancellation, so a token can be handed to a
+//! worker and cancelled from the thread that spawned it.
+//!
+//! # Examples
+//!
+//! ```
+//! use rocketindex::callgraph::CallGraph;
+//! use rocketindex::cancel::CancelToken;
+//! use rocketindex::CodeIndex;
+//! use std::time::Duration;
+//!
+//! let graph = CallGraph::build(&CodeIndex::new());
+//! let cancel = CancelToken::with_timeout(Duration::from_millis(50));
+//! let paths = graph.paths_with_cancel("main.main", "main.helper", usize::MAX, &cancel);
+//! assert!(paths.value.is_empty());
+//! assert!(!paths.truncated);
+//!
+//! cancel.cancel();
+//! assert!(cancel.check().is_err());
+//! ```
+
+use std::sync::atomic::{AtomicBool, Ordering};
+use std::sync::Arc;
+use std::time::{Duration, Instant};
+
+/// Why a query stopped early.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, thiserror::Error)]
+pub enum CancelError {
+    #[error("Query cancelled")]
+    Cancelled,
+    #[error("Query deadline exceeded")]
+    DeadlineExceeded,
+}
+
+/// A cancellation flag with an optional deadline, shared by its clones.
+#[derive(Debug, Clone, Default)]
+pub struct CancelToken {
+    cancelled: Arc<AtomicBool>,
+    deadline: Option<Instant>,
+}
+
+impl CancelToken {
+    /// A token that only stops a query when [`CancelToken::cancel`] is called.
+    #[must_use]
+    pub fn new() -> Self {
+        Self::default()
+    }
+
+    /// A token that also stops a query once `deadline` has passed.
+    #[must_use]
+    pub fn with_deadline(deadline: Instant) -> Self {
+        Self {
+            cancelled: Arc::default(),
+            deadline: Some(deadline),
+        }
+    }
+
+    /// A token that also stops a query `timeout` from now.
+    #[must_use]
+    pub fn with_timeout(timeout: Duration) -> Self {
+        Self::with_deadline(Instant::now() + timeout)
+    }
+
+    /// Cancel every query using this token or one of its clones.
+    pub fn cancel(&self) {
+        self.cancelled.store(true, Ordering::Relaxed);
+    }
+
+    /// Whether the token was cancelled or its deadline has passed.
+    #[must_use]
+    pub fn is_cancelled(&self) -> bool {
+        self.check().is_err()
+    }
+
+    /// `Ok` while a query may continue.
+    ///
+    /// # Errors
+    ///
+    /// Returns [`CancelError::Cancelled`] after [`CancelToken::cancel`], or
+    /// [`CancelError::DeadlineExceeded`] once the deadline has passed.
+    pub fn check(&self) -> Result<(), CancelError> {
+        if self.cancelled.load(Ordering::Relaxed) {
+            return Err(CancelError::Cancelled);
+        }
+        match self.deadline {
+            Some(deadline) if Instant::now() >= deadline => Err(CancelError::DeadlineExceeded),
+            _ => Ok(()),
+        }
+    }
+}
+
+/// The result of a query that may have been stopped by a [`CancelToken`].
+#[derive(Debug, Clone, PartialEq)]
+pub struct Partial<T> {
+    /// Everything found before the query stopped
+    pub value: T,
+    /// Whether the query stopped early, so `value` may be incomplete
+    pub truncated: bool,
+}
+
+impl<T> Partial<T> {
+    /// A result the query ran to completion for.
+    #[must_use]
+    pub fn complete(value: T) -> Self {
+        Self {
+            value,
+            truncated: false,
+        }
+    }
+
+    /// A result the query stopped before completing.
+    #[must_use]
+    pub fn truncated(value: T) -> Self {
+        Self {
+            value,
+            truncated: true,
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::callgraph::CallGraph;
+    use crate::{CodeIndex, Location, Reference, ReferenceKind, Symbol, SymbolKind, Visibility};
+    use std::path::PathBuf;
+    use std::thread;
+
+    /// `main.source` calls every function in the first of `layers` layers of
+    /// `width` functions, each of which calls every function in the next
+    /// layer, and the last layer calls `main.sink`: `width^layers` paths.
+    fn layered_graph(layers: usize, width: usize) -> CallGraph {
+        let mut names = vec!["source".to_string()];
+        for layer in 0..layers {
+            names.extend((0..width).map(|i| format!("f{}_{}", layer, i)));
+        }
+        names.push("sink".to_string());
+
+        let mut index = CodeIndex::new();
+        for (i, name) in names.iter().enumerate() {
+            index.add_symbol(Symbol::new(
+                name.clone(),
+                format!("main.{}", name),
+                SymbolKind::Function,
+                Location::new(PathBuf::from("main.go"), (i * 100 + 1) as u32, 6),
+                Visibility::Private,
+                "go".to_string(),
+            ));
+        }
+        let layer_of = |i: usize| i.div_ceil(width);
+        for (i, _) in names.iter().enumerate() {
+            let callees: Vec<&String> = names
+                .iter()
+                .enumerate()
+                .filter(|&(j, _)| j > i && layer_of(j) == layer_of(i) + 1)
+                .map(|(_, callee)| callee)
+                .collect();
+            for (k, callee) in callees.into_iter().enumerate() {
+                index.add_reference(
+                    PathBuf::from("main.go"),
+                    Reference {
+                        name: callee.clone(),
+                        location: Location::new(
+                            PathBuf::from("main.go"),
+                            (i * 100 + 2 + k) as u32,
+                            5,
+                        ),
+                        kind: ReferenceKind::Call,
+                        target: None,
+                        arguments: None,
+                    },
+                );
+            }
+        }
+        CallGraph::build(&index)
+    }
+
+    #[test]
+    fn test_clones_share_cancellation() {
+        let token = CancelToken::new();
+        let clone = token.clone();
+        assert_eq!(clone.check(), Ok(()));
+        token.cancel();
+        assert_eq!(clone.check(), Err(CancelError::Cancelled));
+
+        let expired = CancelToken::with_deadline(Instant::now());
+        assert_eq!(expired.check(), Err(CancelError::DeadlineExceeded));
+        assert!(!CancelToken::with_timeout(Duration::from_secs(60)).is_cancelled());
+    }
+
+    #[test]
+    fn test_small_graph_runs_to_completion() {
+        let graph = layered_graph(2, 2);
+        let cancel = CancelToken::with_timeout(Duration::from_secs(60));
+
+        let paths = graph.paths_with_cancel("main.source", "main.sink", usize::MAX, &cancel);
+        assert!(!paths.truncated);
+        assert_eq!(paths.value.len(), 4);
+        assert_eq!(
+            paths.value,
+            graph.paths("main.source", "main.sink", usize::MAX)
+        );
+
+        let reachable = graph.reachable_from_with_cancel("main.source", 0, &cancel);
+        assert!(!reachable.truncated);
+        assert_eq!(reachable.value.len(), 5);
+        assert!(
+            !graph
+                .centrality_with_cancel(Default::default(), &cancel)
+                .truncated
+        );
+    }
+
+    #[test]
+    fn test_cancel_stops_path_search_mid_traversal() {
+        // 8^8 paths: far more than the search could list before the deadline
+        let graph = layered_graph(8, 8);
+        let cancel = CancelToken::new();
+        let canceller = cancel.clone();
+        let worker = thread::spawn(move || {
+            let start = Instant::now();
+            let paths = graph.paths_with_cancel("main.source", "main.sink", usize::MAX, &cancel);
+            (paths, start.elapsed())
+        });
+
+        thread::sleep(Duration::from_millis(50));
+        canceller.cancel();
+        let (paths, elapsed) = worker.join().unwrap();
+
+        assert!(paths.truncated);
+        assert!(paths.value.len() < 8usize.pow(8));
+        assert!(elapsed < Duration::from_secs(5), "took {:?}", elapsed);
+    }
+
+    #[test]
+    fn test_expired_deadline_truncates_every_query() {
+        let graph = layered_graph(3, 3);
+        let cancel = CancelToken::with_deadline(Instant::now());
+
+        let reachable = graph.reachable_from_with_cancel("main.source", 0, &cancel);
+        assert!(reachable.truncated);
+        assert!(reachable.value.is_empty());
+
+        let paths = graph.paths_with_cancel("main.source", "main.sink", 10, &cancel);
+        assert!(paths.truncated);
+        assert!(paths.value.is_empty());
+
+        let scores = graph.centrality_with_cancel(Default::default(), &cancel);
+        assert!(scores.truncated);
+        let total: f64 = scores.value.iter().map(|s| s.score).sum();
+        assert!((total - 1.0).abs() < 1e-9);
+    }
+}
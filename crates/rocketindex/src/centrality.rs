@@ -0,0 +1,286 @@
+//! PageRank-style centrality over the call graph.
+//!
+//! [`CallGraph::centrality`] scores each symbol by the callers that reach it:
+//! a symbol called from many places, or from other central symbols, scores
+//! high, so leaf utilities used everywhere rise to the top. Every symbol with
+//! at least one call edge gets a score, and the scores sum to 1.
+//!
+//! Each caller passes its score in equal parts to its distinct callees
+//! (recursive self-calls are ignored). Symbols that call nothing pass theirs
+//! to every symbol, which also keeps disconnected parts of the graph in one
+//! ranking. Iteration stops once the scores change by less than
+//! [`CentralityOptions::tolerance`] or after
+//! [`CentralityOptions::max_iterations`]. Symbols are processed in name order,
+//! so the scores are identical across runs.
+//!
+//! [`CallGraph::centrality_with_cancel`] stops iterating once a
+//! [`CancelToken`] fires and returns the scores of the last full iteration.
+//!
+//! # Examples
+//!
+//! ```
+//! use rocketindex::callgraph::CallGraph;
+//! use rocketindex::{
+//!     CodeIndex, Location, Reference, ReferenceKind, Symbol, SymbolKind, Visibility,
+//! };
+//! use std::path::PathBuf;
+//!
+//! let mut index = CodeIndex::new();
+//! for (name, line) in [("helper", 1), ("a", 5), ("b", 9)] {
+//!     index.add_symbol(Symbol::new(
+//!         name.to_string(),
+//!         format!("main.{}", name),
+//!         SymbolKind::Function,
+//!         Location::new(PathBuf::from("main.go"), line, 6),
+//!         Visibility::Private,
+//!         "go".to_string(),
+//!     ));
+//! }
+//! for line in [6, 10] {
+//!     index.add_reference(
+//!         PathBuf::from("main.go"),
+//!         Reference {
+//!             name: "helper".to_string(),
+//!             location: Location::new(PathBuf::from("main.go"), line, 5),
+//!             kind: ReferenceKind::Call,
+//!             target: None,
+//!             arguments: None,
+//!         },
+//!     );
+//! }
+//!
+//! let scores = CallGraph::build(&index).centrality();
+//! assert_eq!(scores[0].qualified, "main.helper");
+//! ```
+
+use std::collections::BTreeSet;
+
+use serde::Serialize;
+
+use crate::callgraph::CallGraph;
+use crate::cancel::{CancelToken, Partial};
+
+/// Options for [`CallGraph::centrality_with_options`].
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub struct CentralityOptions {
+    /// Probability of following a call edge rather than jumping to a random
+    /// symbol (`0.0..1.0`)
+    pub damping: f64,
+    /// Maximum number of iterations
+    pub max_iterations: usize,
+    /// Stop once the total change in scores in one iteration is below this
+    pub tolerance: f64,
+}
+
+impl Default for CentralityOptions {
+    fn default() -> Self {
+        Self {
+            damping: 0.85,
+            max_iterations: 100,
+            tolerance: 1e-10,
+        }
+    }
+}
+
+/// A symbol's centrality score.
+#[derive(Debug, Clone, PartialEq, Serialize)]
+pub struct SymbolCentrality {
+    /// Qualified name of the symbol
+    pub qualified: String,
+    /// Share of the total centrality (all scores sum to 1)
+    pub score: f64,
+}
+
+impl CallGraph {
+    /// Centrality of every symbol in the graph with the default options.
+    ///
+    /// See [`CallGraph::centrality_with_options`].
+    #[must_use]
+    pub fn centrality(&self) -> Vec<SymbolCentrality> {
+        self.centrality_with_options(CentralityOptions::default())
+    }
+
+    /// Centrality of every symbol that calls or is called by another symbol.
+    ///
+    /// Results are sorted by descending score, ties by qualified name.
+    /// Returns an empty list for an empty graph.
+    #[must_use]
+    pub fn centrality_with_options(&self, options: CentralityOptions) -> Vec<SymbolCentrality> {
+        self.centrality_with_cancel(options, &CancelToken::new())
+            .value
+    }
+
+    /// [`CallGraph::centrality_with_options`], stopping once `cancel` fires.
+    ///
+    /// A truncated result ranks the scores of the last completed iteration,
+    /// normalized to sum to 1 like converged ones.
+    #[must_use]
+    pub fn centrality_with_cancel(
+        &self,
+        options: CentralityOptions,
+        cancel: &CancelToken,
+    ) -> Partial<Vec<SymbolCentrality>> {
+        let names: Vec<&str> = self
+            .sites()
+            .iter()
+            .flat_map(|site| [site.caller.as_str(), site.callee.as_str()])
+            .collect::<BTreeSet<_>>()
+            .into_iter()
+            .collect();
+        if names.is_empty() {
+            return Partial::complete(Vec::new());
+        }
+        let id = |name: &str| names.binary_search(&name).unwrap_or_default();
+
+        let mut callees: Vec<Vec<usize>> = vec![Vec::new(); names.len()];
+        for site in self.sites() {
+            if site.caller != site.callee {
+                callees[id(&site.caller)].push(id(&site.callee));
+            }
+        }
+        for list in &mut callees {
+            list.sort_unstable();
+            list.dedup();
+        }
+
+        let n = names.len() as f64;
+        let mut scores = vec![1.0 / n; names.len()];
+        let mut truncated = false;
+        for _ in 0..options.max_iterations {
+            if cancel.is_cancelled() {
+                truncated = true;
+                break;
+            }
+            let dangling: f64 = callees
+                .iter()
+                .zip(&scores)
+                .filter(|(list, _)| list.is_empty())
+                .map(|(_, score)| score)
+                .sum();
+            let base = (1.0 - options.damping) / n + options.damping * dangling / n;
+
+            let mut next = vec![base; names.len()];
+            for (caller, list) in callees.iter().enumerate() {
+                let share = options.damping * scores[caller] / list.len().max(1) as f64;
+                for &callee in list {
+                    next[callee] += share;
+                }
+            }
+
+            let change: f64 = next.iter().zip(&scores).map(|(a, b)| (a - b).abs()).sum();
+            scores = next;
+            if change < options.tolerance {
+                break;
+            }
+        }
+
+        let total: f64 = scores.iter().sum();
+        let mut ranked: Vec<SymbolCentrality> = names
+            .iter()
+            .zip(scores)
+            .map(|(name, score)| SymbolCentrality {
+                qualified: name.to_string(),
+                score: score / total,
+            })
+            .collect();
+        ranked.sort_by(|a, b| {
+            b.score
+                .total_cmp(&a.score)
+                .then_with(|| a.qualified.cmp(&b.qualified))
+        });
+        Partial {
+            value: ranked,
+            truncated,
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::callgraph::index_of_calls;
+
+    fn graph(functions: &[(&str, u32)], calls: &[(u32, &str)]) -> CallGraph {
+        CallGraph::build(&index_of_calls(functions, calls))
+    }
+
+    /// Mirrors tests/fixtures/minimal/go/main.go
+    fn minimal_go_graph() -> CallGraph {
+        graph(
+            &[
+                ("helper", 5),
+                ("mainFunction", 9),
+                ("callerA", 14),
+                ("callerB", 18),
+                ("NewMyStruct", 27),
+            ],
+            &[
+                (10, "helper"),
+                (15, "mainFunction"),
+                (19, "mainFunction"),
+                (20, "helper"),
+                (28, "helper"),
+            ],
+        )
+    }
+
+    #[test]
+    fn test_widely_called_helper_ranks_first() {
+        let scores = minimal_go_graph().centrality();
+        let order: Vec<&str> = scores.iter().map(|s| s.qualified.as_str()).collect();
+        assert_eq!(
+            order,
+            vec![
+                "main.helper",
+                "main.mainFunction",
+                "main.NewMyStruct",
+                "main.callerA",
+                "main.callerB"
+            ]
+        );
+        // Symbols nobody calls share the minimum score
+        assert_eq!(scores[2].score, scores[4].score);
+
+        let total: f64 = scores.iter().map(|s| s.score).sum();
+        assert!((total - 1.0).abs() < 1e-9);
+    }
+
+    #[test]
+    fn test_centrality_is_deterministic() {
+        let first = minimal_go_graph().centrality();
+        for _ in 0..5 {
+            assert_eq!(minimal_go_graph().centrality(), first);
+        }
+    }
+
+    #[test]
+    fn test_centrality_handles_disconnected_components_and_recursion() {
+        let scores = graph(
+            &[("a", 1), ("b", 5), ("x", 9), ("y", 13)],
+            &[(2, "b"), (10, "y"), (11, "x")],
+        )
+        .centrality();
+
+        assert_eq!(scores.len(), 4);
+        assert!(scores.iter().all(|s| s.score > 0.0));
+        let total: f64 = scores.iter().map(|s| s.score).sum();
+        assert!((total - 1.0).abs() < 1e-9);
+
+        // A self-call is not an in-edge
+        let scores = graph(&[("loop", 1), ("leaf", 5)], &[(2, "loop"), (3, "leaf")]).centrality();
+        assert_eq!(scores[0].qualified, "main.leaf");
+
+        assert!(CallGraph::default().centrality().is_empty());
+    }
+
+    #[test]
+    fn test_iteration_cap() {
+        let options = CentralityOptions {
+            max_iterations: 0,
+            ..CentralityOptions::default()
+        };
+        // No iterations leaves the uniform starting scores
+        let scores = minimal_go_graph().centrality_with_options(options);
+        assert!(scores.iter().all(|s| (s.score - 0.2).abs() < 1e-12));
+    }
+}
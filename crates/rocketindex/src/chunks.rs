@@ -0,0 +1,547 @@
+//! Symbol-sized chunks of source for embedding in a vector database.
+//!
+//! [`CodeIndex::chunks`] cuts the indexed source into one chunk per symbol:
+//! its source lines together with its qualified name, signature, doc
+//! comment, and location. Each chunk carries the symbol's stable ID (see
+//! [`crate::json`]), so a vector hit maps back to the symbol with
+//! [`CodeIndex::symbol_by_id`], and from there to its callers and callees.
+//!
+//! Every source line ends up in at most one chunk. A function or type nested
+//! in another symbol (a method in a class body) gets its own chunk, and its
+//! lines are left out of the enclosing symbol's; fields, values, and other
+//! one-line members nested in a symbol have no chunk of their own and stay in
+//! its source.
+//!
+//! A chunk's [`Chunk::text`] stays within [`ChunkOptions::max_bytes`] and
+//! [`ChunkOptions::max_tokens`]. A symbol too large for one chunk is split
+//! into several parts, each repeating the header, at statement boundaries
+//! where possible: after a line that closes every bracket opened since the
+//! body started, before a line indented no deeper than the body's first
+//! statement. A single line that does not fit is emitted alone, over budget.
+//!
+//! Source is read from disk relative to the workspace root; symbols whose
+//! file cannot be read are skipped.
+//!
+//! # Examples
+//!
+//! ```no_run
+//! use rocketindex::chunks::ChunkOptions;
+//! use rocketindex::indexer::IndexOptions;
+//! use rocketindex::CodeIndex;
+//! use std::path::PathBuf;
+//!
+//! let mut index = CodeIndex::with_root(PathBuf::from("."));
+//! index.update_files(&[PathBuf::from("payment.go")], &IndexOptions::default());
+//!
+//! let options = ChunkOptions {
+//!     max_tokens: 512,
+//!     ..ChunkOptions::default()
+//! };
+//! for chunk in index.chunks(&options) {
+//!     println!("{} part {}/{}\n{}", chunk.id, chunk.part + 1, chunk.parts, chunk.text());
+//! }
+//! ```
+
+use std::collections::HashMap;
+use std::fmt;
+use std::path::PathBuf;
+use std::sync::Arc;
+
+use serde::Serialize;
+
+use crate::{CodeIndex, KindSet, Symbol, SymbolKind};
+
+/// Default for [`ChunkOptions::max_bytes`].
+pub const DEFAULT_CHUNK_BYTES: usize = 4 * 1024;
+
+/// Options for [`CodeIndex::chunks`].
+#[derive(Debug, Clone)]
+pub struct ChunkOptions {
+    /// Maximum size of a chunk's [`Chunk::text`] in bytes (0 = no limit)
+    pub max_bytes: usize,
+    /// Maximum size of a chunk's [`Chunk::text`] in tokens, as counted by
+    /// [`ChunkOptions::tokens`] (0 = no limit)
+    pub max_tokens: usize,
+    /// How tokens are counted for [`ChunkOptions::max_tokens`]
+    pub tokens: TokenCounter,
+    /// Symbol kinds to chunk
+    pub kinds: KindSet,
+}
+
+impl Default for ChunkOptions {
+    fn default() -> Self {
+        Self {
+            max_bytes: DEFAULT_CHUNK_BYTES,
+            max_tokens: 0,
+            tokens: TokenCounter::default(),
+            kinds: KindSet::all(),
+        }
+    }
+}
+
+/// Counts the tokens in a chunk's text, for [`ChunkOptions::max_tokens`].
+///
+/// The default estimates one token per four bytes, which is close for code
+/// under common embedding tokenizers. Wrap a real tokenizer with
+/// [`TokenCounter::new`] for exact budgets.
+#[derive(Clone)]
+pub struct TokenCounter(Arc<CountFn>);
+
+type CountFn = dyn Fn(&str) -> usize + Send + Sync;
+
+impl TokenCounter {
+    pub fn new(count: impl Fn(&str) -> usize + Send + Sync + 'static) -> Self {
+        Self(Arc::new(count))
+    }
+
+    /// The number of tokens in `text`.
+    pub fn count(&self, text: &str) -> usize {
+        (self.0)(text)
+    }
+}
+
+impl Default for TokenCounter {
+    fn default() -> Self {
+        Self::new(|text| text.len().div_ceil(4))
+    }
+}
+
+impl fmt::Debug for TokenCounter {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.write_str("TokenCounter(..)")
+    }
+}
+
+/// One chunk of a symbol's source.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct Chunk {
+    /// Stable ID of the symbol (see [`crate::json::stable_id`])
+    pub id: String,
+    /// Qualified name of the symbol
+    pub qualified: String,
+    /// Symbol kind
+    pub kind: SymbolKind,
+    /// File the symbol is defined in
+    pub file: PathBuf,
+    /// First line of this chunk's source (1-based)
+    pub line: u32,
+    /// Last line of this chunk's source
+    pub end_line: u32,
+    /// Which part of the symbol this is, from 0
+    pub part: usize,
+    /// How many parts the symbol was split into
+    pub parts: usize,
+    /// The symbol's signature, if known
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub signature: Option<String>,
+    /// The symbol's doc comment
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub doc: Option<String>,
+    /// This part's source lines
+    pub source: String,
+}
+
+impl Chunk {
+    /// The text to embed: a header naming the symbol, then the source.
+    ///
+    /// The header is the qualified name and location, the signature, and the
+    /// doc comment, one per line.
+    #[must_use]
+    pub fn text(&self) -> String {
+        let mut text = header(&self.qualified, &self.file, self.line);
+        for extra in [&self.signature, &self.doc].into_iter().flatten() {
+            text.push_str(extra);
+            text.push('\n');
+        }
+        text.push_str(&self.source);
+        text
+    }
+}
+
+impl CodeIndex {
+    /// Chunks for every symbol of [`ChunkOptions::kinds`], ordered by file,
+    /// line, then part (see the [module docs](self)).
+    #[must_use]
+    pub fn chunks(&self, options: &ChunkOptions) -> Vec<Chunk> {
+        let mut by_file: HashMap<&PathBuf, Vec<(&Symbol, String)>> = HashMap::new();
+        for (symbol, id) in self.symbols_with_ids() {
+            if options.kinds.contains(symbol.kind) {
+                by_file
+                    .entry(&symbol.location.file)
+                    .or_default()
+                    .push((symbol, id));
+            }
+        }
+
+        let mut chunks = Vec::new();
+        for (file, symbols) in by_file {
+            let Ok(text) = std::fs::read_to_string(self.to_absolute(file)) else {
+                continue;
+            };
+            let lines: Vec<&str> = text.lines().collect();
+            for (symbol, id) in &symbols {
+                let owned = || symbols.iter().any(|(owner, _)| encloses(owner, symbol));
+                if !owns_chunk(symbol) && owned() {
+                    continue;
+                }
+                let nested: Vec<&Symbol> = symbols
+                    .iter()
+                    .map(|(s, _)| *s)
+                    .filter(|s| owns_chunk(s) && encloses(symbol, s))
+                    .collect();
+                chunk_symbol(symbol, id, &lines, &nested, options, &mut chunks);
+            }
+        }
+        chunks.sort_by(|a, b| {
+            a.file
+                .cmp(&b.file)
+                .then(a.line.cmp(&b.line))
+                .then(a.part.cmp(&b.part))
+                .then_with(|| a.qualified.cmp(&b.qualified))
+        });
+        chunks
+    }
+}
+
+/// Whether `symbol`, when nested, is chunked apart from its owner: functions
+/// and types are, members only when they span several lines (a property
+/// with a body rather than a field).
+fn owns_chunk(symbol: &Symbol) -> bool {
+    match symbol.kind {
+        SymbolKind::Value | SymbolKind::Module => false,
+        SymbolKind::Member => {
+            let (line, end_line) = symbol.lines();
+            end_line > line
+        }
+        _ => true,
+    }
+}
+
+/// Whether `outer`'s lines contain `inner`'s (and they are different symbols).
+fn encloses(outer: &Symbol, inner: &Symbol) -> bool {
+    let (o, i) = (outer.lines(), inner.lines());
+    o.0 <= i.0 && i.1 <= o.1 && o != i
+}
+
+fn header(qualified: &str, file: &std::path::Path, line: u32) -> String {
+    format!("{} ({}:{})\n", qualified, file.display(), line)
+}
+
+/// Split `symbol`'s own lines (those not in `nested`) into budgeted chunks.
+fn chunk_symbol(
+    symbol: &Symbol,
+    id: &str,
+    lines: &[&str],
+    nested: &[&Symbol],
+    options: &ChunkOptions,
+    chunks: &mut Vec<Chunk>,
+) {
+    let (first, last) = symbol.lines();
+    let first = first.max(1);
+    let last = last.min(lines.len() as u32);
+    let own: Vec<u32> = (first..=last)
+        .filter(|&line| {
+            !nested.iter().any(|n| {
+                let (start, end) = n.lines();
+                start <= line && line <= end
+            })
+        })
+        .collect();
+    if own.is_empty() {
+        return;
+    }
+
+    let template = Chunk {
+        id: id.to_string(),
+        qualified: symbol.qualified.clone(),
+        kind: symbol.kind,
+        file: symbol.location.file.clone(),
+        line: first,
+        end_line: first,
+        part: 0,
+        parts: 1,
+        signature: symbol.signature.clone(),
+        doc: symbol.doc.clone(),
+        source: String::new(),
+    };
+    let fits = |chunk: &Chunk| {
+        let text = chunk.text();
+        (options.max_bytes == 0 || text.len() <= options.max_bytes)
+            && (options.max_tokens == 0 || options.tokens.count(&text) <= options.max_tokens)
+    };
+    let boundaries = statement_boundaries(lines, &own);
+
+    let mut parts: Vec<Chunk> = Vec::new();
+    let mut start = 0;
+    while start < own.len() {
+        // Grow the part line by line, remembering the last statement boundary
+        let mut end = start + 1;
+        let mut cut = None;
+        while end < own.len() {
+            if boundaries[end - 1] {
+                cut = Some(end);
+            }
+            if !fits(&part(&template, lines, &own[start..=end])) {
+                break;
+            }
+            end += 1;
+        }
+        if end < own.len() {
+            end = cut.filter(|&cut| cut > start).unwrap_or(end);
+        }
+        parts.push(part(&template, lines, &own[start..end]));
+        start = end;
+    }
+
+    let count = parts.len();
+    for (i, mut chunk) in parts.into_iter().enumerate() {
+        chunk.part = i;
+        chunk.parts = count;
+        chunks.push(chunk);
+    }
+}
+
+/// `template` with the source of `own_lines` (1-based line numbers).
+fn part(template: &Chunk, lines: &[&str], own_lines: &[u32]) -> Chunk {
+    let source = own_lines
+        .iter()
+        .map(|&line| lines[line as usize - 1])
+        .collect::<Vec<_>>()
+        .join("\n");
+    Chunk {
+        line: own_lines[0],
+        end_line: own_lines[own_lines.len() - 1],
+        source,
+        ..template.clone()
+    }
+}
+
+/// For each of `own` lines, whether a part may end after it: every bracket
+/// opened since the first line is closed, and the next line is indented no
+/// deeper than the body's first line.
+fn statement_boundaries(lines: &[&str], own: &[u32]) -> Vec<bool> {
+    let text = |i: usize| lines[own[i] as usize - 1];
+    let indent = |line: &str| line.len() - line.trim_start().len();
+    let body_indent = own
+        .iter()
+        .skip(1)
+        .map(|&line| lines[line as usize - 1])
+        .find(|line| !line.trim().is_empty())
+        .map_or(0, indent);
+
+    let mut depth: i64 = 0;
+    let mut body_depth = None;
+    let mut boundaries = Vec::with_capacity(own.len());
+    for i in 0..own.len() {
+        for c in text(i).chars() {
+            match c {
+                '(' | '[' | '{' => depth += 1,
+                ')' | ']' | '}' => depth -= 1,
+                _ => {}
+            }
+        }
+        let base = *body_depth.get_or_insert(depth);
+        let next_is_statement = own.get(i + 1).is_some_and(|_| {
+            let next = text(i + 1);
+            next.trim().is_empty() || indent(next) <= body_indent
+        });
+        boundaries.push(depth <= base && next_is_statement);
+    }
+    boundaries
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::indexer::IndexOptions;
+    use crate::{Location, Visibility};
+    use std::path::Path;
+
+    const SOURCE: &str = "\
+package billing
+
+// Charge bills a customer.
+func Charge(amount int) error {
+\tif amount <= 0 {
+\t\treturn errInvalid
+\t}
+\ttotal := apply(
+\t\tamount,
+\t\ttax,
+\t)
+\treturn send(total)
+}
+
+type Invoice struct {
+\tTotal int
+}
+";
+
+    fn symbol(name: &str, kind: SymbolKind, line: u32, end_line: u32) -> Symbol {
+        Symbol::new(
+            name.to_string(),
+            format!("billing.{}", name),
+            kind,
+            Location::new(PathBuf::from("charge.go"), line, 6),
+            Visibility::Public,
+            "go".to_string(),
+        )
+        .with_extent(line, end_line)
+    }
+
+    fn index(root: &Path) -> CodeIndex {
+        std::fs::write(root.join("charge.go"), SOURCE).unwrap();
+        let mut index = CodeIndex::with_root(root.to_path_buf());
+        index.add_symbol(
+            symbol("Charge", SymbolKind::Function, 4, 13)
+                .with_signature(Some("func Charge(amount int) error".to_string()))
+                .with_doc(Some("Charge bills a customer.".to_string())),
+        );
+        index.add_symbol(symbol("Invoice", SymbolKind::Class, 15, 17));
+        index.add_symbol(
+            symbol("Total", SymbolKind::Value, 16, 16).with_parent(Some("Invoice".to_string())),
+        );
+        index
+    }
+
+    #[test]
+    fn test_one_chunk_per_symbol_with_stable_id() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let index = index(temp_dir.path());
+
+        let chunks = index.chunks(&ChunkOptions::default());
+        let names: Vec<&str> = chunks.iter().map(|c| c.qualified.as_str()).collect();
+        // The field stays in its struct's chunk
+        assert_eq!(names, vec!["billing.Charge", "billing.Invoice"]);
+
+        let invoice = &chunks[1];
+        assert_eq!(invoice.source, "type Invoice struct {\n\tTotal int\n}");
+        assert_eq!(
+            invoice.id,
+            crate::json::stable_id("billing.Invoice", SymbolKind::Class)
+        );
+        assert_eq!(
+            index.symbol_by_id(&invoice.id).unwrap().qualified,
+            "billing.Invoice"
+        );
+        assert!(chunks[0].text().starts_with(
+            "billing.Charge (charge.go:4)\nfunc Charge(amount int) error\nCharge bills a customer.\nfunc Charge"
+        ));
+    }
+
+    #[test]
+    fn test_large_bodies_split_at_statement_boundaries() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let index = index(temp_dir.path());
+        let options = ChunkOptions {
+            max_bytes: 160,
+            kinds: KindSet::only(&[SymbolKind::Function]),
+            ..ChunkOptions::default()
+        };
+
+        let chunks = index.chunks(&options);
+        let sources: Vec<&str> = chunks.iter().map(|c| c.source.as_str()).collect();
+        assert_eq!(
+            sources,
+            vec![
+                "func Charge(amount int) error {\n\tif amount <= 0 {\n\t\treturn errInvalid\n\t}",
+                "\ttotal := apply(\n\t\tamount,\n\t\ttax,\n\t)\n\treturn send(total)\n}",
+            ]
+        );
+        assert_eq!((chunks[1].line, chunks[1].end_line), (8, 13));
+        assert_eq!((chunks[1].part, chunks[1].parts), (1, 2));
+        assert!(chunks.iter().all(|c| c.text().len() <= 160));
+
+        // Token budgets count with the configured tokenizer: 3 header
+        // lines and 10 source lines
+        let mut options = ChunkOptions {
+            max_bytes: 0,
+            max_tokens: 13,
+            tokens: TokenCounter::new(|text| text.lines().count()),
+            kinds: KindSet::only(&[SymbolKind::Function]),
+        };
+        assert_eq!(index.chunks(&options).len(), 1);
+        options.max_tokens = 12;
+        assert_eq!(index.chunks(&options).len(), 2);
+    }
+
+    #[test]
+    fn test_nested_methods_are_cut_out_of_their_class() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let root = temp_dir.path();
+        std::fs::write(
+            root.join("cart.py"),
+            "class Cart:\n    items = []\n\n    def total(self):\n        return sum(self.items)\n",
+        )
+        .unwrap();
+        let mut index = CodeIndex::with_root(root.to_path_buf());
+        for (name, kind, line, end_line) in [
+            ("Cart", SymbolKind::Class, 1, 5),
+            ("Cart.items", SymbolKind::Value, 2, 2),
+            ("Cart.total", SymbolKind::Function, 4, 5),
+        ] {
+            index.add_symbol(
+                Symbol::new(
+                    name.to_string(),
+                    name.to_string(),
+                    kind,
+                    Location::new(PathBuf::from("cart.py"), line, 1),
+                    Visibility::Public,
+                    "python".to_string(),
+                )
+                .with_extent(line, end_line),
+            );
+        }
+
+        let chunks = index.chunks(&ChunkOptions::default());
+        let sources: Vec<(&str, &str)> = chunks
+            .iter()
+            .map(|c| (c.qualified.as_str(), c.source.as_str()))
+            .collect();
+        assert_eq!(
+            sources,
+            vec![
+                ("Cart", "class Cart:\n    items = []\n"),
+                (
+                    "Cart.total",
+                    "    def total(self):\n        return sum(self.items)"
+                ),
+            ]
+        );
+    }
+
+    #[test]
+    fn test_chunks_of_parsed_python_fixture() {
+        let root =
+            Path::new(env!("CARGO_MANIFEST_DIR")).join("../../tests/fixtures/minimal/python");
+        let mut index = CodeIndex::with_root(root.clone());
+        index.update_files(&[root.join("main.py")], &IndexOptions::default());
+
+        let chunks = index.chunks(&ChunkOptions::default());
+        let spans: Vec<(&str, u32, u32)> = chunks
+            .iter()
+            .map(|c| (c.qualified.as_str(), c.line, c.end_line))
+            .collect();
+        assert_eq!(
+            spans[..7],
+            [
+                ("helper", 3, 5),
+                ("main_function", 7, 10),
+                ("caller_a", 12, 14),
+                ("caller_b", 16, 19),
+                ("MyClass", 21, 26),
+                ("MyClass.__init__", 24, 25),
+                ("MyClass.method", 27, 28),
+            ]
+        );
+        assert_eq!(
+            chunks[0].source,
+            "def helper():\n    \"\"\"A helper function\"\"\"\n    return 42"
+        );
+        // The methods are cut out of the class body
+        assert_eq!(
+            chunks[4].source,
+            "class MyClass:\n    \"\"\"A simple class\"\"\"\n\n"
+        );
+    }
+}
@@ -7,8 +7,13 @@ use figment::{
     providers::{Format, Serialized, Toml},
     Figment,
 };
+use regex::Regex;
 use serde::{Deserialize, Serialize};
-use std::path::Path;
+use std::collections::BTreeMap;
+use std::path::{Path, PathBuf};
+
+use crate::language_config::{LanguageConfig, LanguageConfigs};
+use crate::{KindSet, SymbolKind};
 
 /// Default directories to exclude from indexing.
 ///
@@ -36,9 +41,82 @@ pub struct Config {
     #[serde(default = "default_recursion_depth")]
     pub max_recursion_depth: usize,
 
+    /// Skip files larger than this many bytes, 0 for no limit (default: 4 MiB).
+    #[serde(default = "default_max_file_bytes")]
+    pub max_file_bytes: u64,
+
+    /// Leave files with their language's generated-code header, such as
+    /// `// Code generated ... DO NOT EDIT.`, out of the index (default: false).
+    #[serde(default)]
+    pub skip_generated: bool,
+
     /// Whether to respect .gitignore files when indexing (default: true).
     #[serde(default = "default_respect_gitignore")]
     pub respect_gitignore: bool,
+
+    /// Whether to store symbol doc comments in the index (default: true).
+    #[serde(default = "default_index_docs")]
+    pub index_docs: bool,
+
+    /// Additional gitignore-style patterns to skip, e.g. `vendor/` or `**/*_generated.go`.
+    #[serde(default)]
+    pub ignore_patterns: Vec<String>,
+
+    /// Only index symbols of these kinds, e.g. `["Function", "Member"]` (default: all).
+    #[serde(default)]
+    pub include_kinds: Option<Vec<SymbolKind>>,
+
+    /// Symbol kinds to leave out of the index, applied after `include_kinds`.
+    #[serde(default)]
+    pub exclude_kinds: Vec<SymbolKind>,
+
+    /// Overrides of the built-in language conventions, keyed by language,
+    /// e.g. `[languages.go]` with `test_files = ["*_check.go"]`.
+    #[serde(default)]
+    pub languages: BTreeMap<String, LanguageOverrides>,
+}
+
+/// Changes to one language's built-in [`LanguageConfig`]; unset fields keep
+/// the built-in value.
+#[derive(Debug, Clone, Default, Deserialize, Serialize)]
+#[serde(default)]
+pub struct LanguageOverrides {
+    /// File extensions, lowercase and without the dot.
+    pub extensions: Option<Vec<String>>,
+
+    /// Globs matching test files, e.g. `["*_check.go"]`.
+    pub test_files: Option<Vec<String>>,
+
+    /// Regex matching the names of test functions in test files.
+    pub test_functions: Option<String>,
+
+    /// Whether methods can be tests, not just top-level functions.
+    pub test_methods: Option<bool>,
+
+    /// Regex matching the first non-blank line of a generated file.
+    pub generated_marker: Option<String>,
+}
+
+impl LanguageOverrides {
+    /// Apply these overrides to `config`.
+    fn apply(&self, mut config: LanguageConfig) -> Result<LanguageConfig, regex::Error> {
+        if let Some(extensions) = &self.extensions {
+            config.extensions = extensions.clone();
+        }
+        if let Some(test_files) = &self.test_files {
+            config.test_files = test_files.clone();
+        }
+        if let Some(pattern) = &self.test_functions {
+            config.test_functions = Some(Regex::new(pattern)?);
+        }
+        if let Some(test_methods) = self.test_methods {
+            config.test_methods = test_methods;
+        }
+        if let Some(marker) = &self.generated_marker {
+            config.generated_marker = Some(Regex::new(marker)?);
+        }
+        Ok(config)
+    }
 }
 
 impl Default for Config {
@@ -46,7 +124,14 @@ impl Default for Config {
         Self {
             exclude_dirs: Vec::new(),
             max_recursion_depth: default_recursion_depth(),
+            max_file_bytes: default_max_file_bytes(),
+            skip_generated: false,
             respect_gitignore: default_respect_gitignore(),
+            index_docs: default_index_docs(),
+            ignore_patterns: Vec::new(),
+            include_kinds: None,
+            exclude_kinds: Vec::new(),
+            languages: BTreeMap::new(),
         }
     }
 }
@@ -55,10 +140,18 @@ fn default_recursion_depth() -> usize {
     500
 }
 
+fn default_max_file_bytes() -> u64 {
+    crate::indexer::DEFAULT_MAX_FILE_BYTES
+}
+
 fn default_respect_gitignore() -> bool {
     true
 }
 
+fn default_index_docs() -> bool {
+    true
+}
+
 impl Config {
     /// Load configuration from `.rocketindex.toml` in the given root directory.
     ///
@@ -103,6 +196,53 @@ impl Config {
         }
         dirs
     }
+
+    /// Find the source files under `root` that this configuration indexes.
+    ///
+    /// Applies `.gitignore` (when `respect_gitignore` is set), the excluded
+    /// directories, and `ignore_patterns`, pruning ignored directories during
+    /// the walk.
+    pub fn find_source_files(&self, root: &Path) -> std::io::Result<Vec<PathBuf>> {
+        let patterns: Vec<&str> = self.ignore_patterns.iter().map(String::as_str).collect();
+        crate::watch::find_source_files_with_patterns(
+            root,
+            &self.excluded_dirs(),
+            &patterns,
+            self.respect_gitignore,
+        )
+    }
+
+    /// The symbol kinds to index, from `include_kinds` and `exclude_kinds`.
+    pub fn symbol_kinds(&self) -> KindSet {
+        let included = match &self.include_kinds {
+            Some(kinds) => KindSet::only(kinds),
+            None => KindSet::all(),
+        };
+        included.excluding(&self.exclude_kinds)
+    }
+
+    /// The language conventions to index with: the built-in configs with
+    /// `languages` applied.
+    ///
+    /// An override for a language without a built-in config starts from an
+    /// empty one. An override with an invalid regex is left out with a
+    /// warning, like an invalid config file.
+    pub fn language_configs(&self) -> LanguageConfigs {
+        let mut configs = LanguageConfigs::builtin().clone();
+        for (language, overrides) in &self.languages {
+            let base = configs
+                .get(language)
+                .cloned()
+                .unwrap_or_else(|| LanguageConfig::new(language, &[]));
+            match overrides.apply(base) {
+                Ok(config) => {
+                    configs.insert(config);
+                }
+                Err(e) => tracing::warn!("Config error in [languages.{}]: {}", language, e),
+            }
+        }
+        configs
+    }
 }
 
 #[cfg(test)]
@@ -145,6 +285,102 @@ exclude_dirs = ["fcs-fable", "vendor"]
         assert!(excluded.contains(&"node_modules")); // default still present
     }
 
+    #[test]
+    fn test_load_symbol_kinds() {
+        let temp = TempDir::new().unwrap();
+        assert!(Config::load(temp.path()).symbol_kinds().is_all());
+
+        std::fs::write(
+            temp.path().join(".rocketindex.toml"),
+            "include_kinds = [\"Function\", \"Member\", \"Class\"]\nexclude_kinds = [\"Class\"]\n",
+        )
+        .unwrap();
+        let kinds = Config::load(temp.path()).symbol_kinds();
+        assert_eq!(
+            kinds,
+            KindSet::only(&[SymbolKind::Function, SymbolKind::Member])
+        );
+    }
+
+    #[test]
+    fn test_load_language_overrides() {
+        let temp = TempDir::new().unwrap();
+        std::fs::write(
+            temp.path().join(".rocketindex.toml"),
+            r#"
+[languages.go]
+test_files = ["*_check.go"]
+generated_marker = "^// Built by gen"
+
+[languages.python]
+test_functions = "("
+"#,
+        )
+        .unwrap();
+
+        let languages = Config::load(temp.path()).language_configs();
+        let go = Path::new("pkg/user.go");
+        assert!(languages.is_generated(go, "// Built by gen\npackage pkg\n"));
+        assert!(!languages.is_generated(go, "// Code generated by gen. DO NOT EDIT.\n"));
+        let go = languages.get("go").unwrap();
+        assert_eq!(go.test_files, vec!["*_check.go"]);
+
+        // The invalid override is left out, keeping the built-in config
+        let python = languages.get("python").unwrap();
+        let builtin = LanguageConfigs::builtin().get("python").unwrap();
+        assert_eq!(
+            python.test_functions.as_ref().map(|r| r.as_str()),
+            builtin.test_functions.as_ref().map(|r| r.as_str())
+        );
+    }
+
+    #[test]
+    fn test_ignored_files_produce_no_symbols() {
+        let temp = TempDir::new().unwrap();
+        let root = temp.path();
+        std::fs::create_dir_all(root.join("src/gen")).unwrap();
+        std::fs::create_dir_all(root.join("node_modules/dep")).unwrap();
+        std::fs::write(root.join(".gitignore"), "build/\n").unwrap();
+        std::fs::write(root.join("src/.gitignore"), "scratch.py\n").unwrap();
+        std::fs::write(
+            root.join(".rocketindex.toml"),
+            "ignore_patterns = [\"**/*_generated.go\", \"src/gen/\"]\n",
+        )
+        .unwrap();
+        std::fs::create_dir_all(root.join("build")).unwrap();
+        for (file, source) in [
+            ("src/app.py", "def kept():\n    pass\n"),
+            ("src/scratch.py", "def nested_gitignored():\n    pass\n"),
+            ("build/out.py", "def gitignored():\n    pass\n"),
+            ("src/gen/models.py", "def pattern_dir():\n    pass\n"),
+            (
+                "src/api_generated.go",
+                "package src\n\nfunc Generated() {}\n",
+            ),
+            ("node_modules/dep/index.js", "function dependency() {}\n"),
+        ] {
+            std::fs::write(root.join(file), source).unwrap();
+        }
+
+        let config = Config::load(root);
+        assert_eq!(config.ignore_patterns.len(), 2);
+        let files = config.find_source_files(root).unwrap();
+        assert_eq!(files, vec![root.join("src/app.py")]);
+
+        let mut index = crate::CodeIndex::with_root(root.to_path_buf());
+        index.index_files(&files, &crate::indexer::IndexOptions::default());
+        assert!(index.get("kept").is_some());
+        for name in [
+            "nested_gitignored",
+            "gitignored",
+            "pattern_dir",
+            "src.Generated",
+            "dependency",
+        ] {
+            assert!(index.get(name).is_none(), "{} should be ignored", name);
+        }
+    }
+
     #[test]
     fn test_load_config_with_max_recursion_depth() {
         let temp = TempDir::new().unwrap();
@@ -158,6 +394,22 @@ max_recursion_depth = 1000
         assert!(config.exclude_dirs.is_empty()); // default for exclude_dirs
     }
 
+    #[test]
+    fn test_load_config_with_max_file_bytes() {
+        let temp = TempDir::new().unwrap();
+        assert_eq!(
+            Config::load(temp.path()).max_file_bytes,
+            crate::indexer::DEFAULT_MAX_FILE_BYTES
+        );
+
+        std::fs::write(
+            temp.path().join(".rocketindex.toml"),
+            "max_file_bytes = 65536\n",
+        )
+        .unwrap();
+        assert_eq!(Config::load(temp.path()).max_file_bytes, 65536);
+    }
+
     #[test]
     fn test_invalid_config_returns_defaults() {
         let temp = TempDir::new().unwrap();
@@ -185,5 +437,6 @@ respect_gitignore = false
         assert!(!config.respect_gitignore); // from config
         assert_eq!(config.max_recursion_depth, 500); // from defaults
         assert!(config.exclude_dirs.is_empty()); // from defaults
+        assert!(config.index_docs); // from defaults
     }
 }
@@ -0,0 +1,335 @@
+//! Go constructor functions and the "constructs" edges through them.
+//!
+//! Go has no constructor syntax, but `func NewUser(...) *User` is the idiom.
+//! [`CodeIndex::constructed_type`] recognizes such functions: an exported
+//! top-level `New*` function whose first result is `T` or `*T` (for a generic
+//! type, `*T[...]`), where `T` is a type declared in the same package.
+//!
+//! A call to a constructor is a "constructs" edge from the caller to the type,
+//! so [`CallGraph::constructions`] answers "where are `User` values created?"
+//! and [`CallGraph::constructs`] answers "what does `main` create?".
+//!
+//! # Examples
+//!
+//! ```
+//! use rocketindex::callgraph::CallGraph;
+//! use rocketindex::{
+//!     CodeIndex, Location, Reference, ReferenceKind, Symbol, SymbolKind, Visibility,
+//! };
+//! use std::path::PathBuf;
+//!
+//! let symbol = |name: &str, kind, line| {
+//!     Symbol::new(
+//!         name.to_string(),
+//!         format!("main.{}", name),
+//!         kind,
+//!         Location::new(PathBuf::from("main.go"), line, 6),
+//!         Visibility::Public,
+//!         "go".to_string(),
+//!     )
+//! };
+//!
+//! let mut index = CodeIndex::new();
+//! index.add_symbol(symbol("User", SymbolKind::Class, 3));
+//! index.add_symbol(
+//!     symbol("NewUser", SymbolKind::Function, 7)
+//!         .with_signature(Some("func NewUser(name string) *User".to_string())),
+//! );
+//! index.add_symbol(symbol("main", SymbolKind::Function, 11));
+//! index.add_reference(
+//!     PathBuf::from("main.go"),
+//!     Reference {
+//!         name: "NewUser".to_string(),
+//!         location: Location::new(PathBuf::from("main.go"), 12, 10),
+//!         kind: ReferenceKind::Call,
+//!         target: None,
+//!         arguments: None,
+//!     },
+//! );
+//!
+//! let new_user = index.get("main.NewUser").unwrap();
+//! assert_eq!(index.constructed_type(new_user).unwrap().qualified, "main.User");
+//!
+//! let graph = CallGraph::build(&index);
+//! let created = graph.constructions(&index, "main.User");
+//! assert_eq!(created[0].caller, "main.main");
+//! assert_eq!(created[0].constructor, "main.NewUser");
+//! ```
+
+use serde::Serialize;
+
+use crate::callgraph::{CallGraph, CallSite};
+use crate::{CodeIndex, Location, Symbol, SymbolKind};
+
+/// A call that creates a value of a type through one of its constructors.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct Construction {
+    /// Qualified name of the calling symbol
+    pub caller: String,
+    /// Qualified name of the constructed type
+    pub constructed: String,
+    /// Qualified name of the constructor called
+    pub constructor: String,
+    /// Where the call appears (path is relative to workspace root)
+    pub location: Location,
+}
+
+impl CodeIndex {
+    /// The type `symbol` constructs, if it is a Go constructor function.
+    #[must_use]
+    pub fn constructed_type(&self, symbol: &Symbol) -> Option<&Symbol> {
+        if symbol.language != "go"
+            || symbol.kind != SymbolKind::Function
+            || symbol.parent.is_some()
+            || !symbol.name.starts_with("New")
+        {
+            return None;
+        }
+        let (package, _) = symbol.qualified.rsplit_once('.')?;
+
+        let signature = symbol.structured_signature()?;
+        let first = signature.results.first()?;
+        // `*Stack[T]` constructs `Stack`
+        let returned = first.ty.trim_start_matches('*');
+        let type_name = returned.split('[').next()?;
+        // `*other.T` is not package-local
+        if type_name.is_empty() || type_name.contains('.') {
+            return None;
+        }
+
+        self.get_all(&format!("{}.{}", package, type_name))
+            .iter()
+            .find(|s| s.kind.is_type())
+    }
+
+    /// Constructor functions of the type named `type_qualified`, sorted by location.
+    #[must_use]
+    pub fn constructors(&self, type_qualified: &str) -> Vec<&Symbol> {
+        let mut constructors: Vec<&Symbol> = self
+            .symbols()
+            .filter(|s| {
+                self.constructed_type(s)
+                    .is_some_and(|t| t.qualified == type_qualified)
+            })
+            .collect();
+        constructors.sort_by(|a, b| {
+            let (a, b) = (&a.location, &b.location);
+            a.file
+                .cmp(&b.file)
+                .then(a.line.cmp(&b.line))
+                .then(a.column.cmp(&b.column))
+        });
+        constructors
+    }
+}
+
+impl CallGraph {
+    /// Every call that constructs a value of the type named `type_qualified`.
+    ///
+    /// Results are sorted by file, then line.
+    #[must_use]
+    pub fn constructions(&self, index: &CodeIndex, type_qualified: &str) -> Vec<Construction> {
+        let mut constructions: Vec<Construction> = index
+            .constructors(type_qualified)
+            .into_iter()
+            .flat_map(|constructor| self.callers(&constructor.qualified))
+            .map(|site| construction(site, type_qualified))
+            .collect();
+        sort_by_location(&mut constructions);
+        constructions
+    }
+
+    /// Every constructor call made by the symbol named `caller`.
+    ///
+    /// Results are sorted by file, then line.
+    #[must_use]
+    pub fn constructs(&self, index: &CodeIndex, caller: &str) -> Vec<Construction> {
+        let mut constructions: Vec<Construction> = self
+            .callees(caller)
+            .into_iter()
+            .filter_map(|site| {
+                let constructed = index
+                    .get_all(&site.callee)
+                    .iter()
+                    .find_map(|s| index.constructed_type(s))?;
+                Some(construction(site, &constructed.qualified))
+            })
+            .collect();
+        sort_by_location(&mut constructions);
+        constructions
+    }
+}
+
+fn construction(site: &CallSite, constructed: &str) -> Construction {
+    Construction {
+        caller: site.caller.clone(),
+        constructed: constructed.to_string(),
+        constructor: site.callee.clone(),
+        location: site.location.clone(),
+    }
+}
+
+fn sort_by_location(constructions: &mut [Construction]) {
+    constructions.sort_by(|a, b| {
+        let (a, b) = (&a.location, &b.location);
+        a.file
+            .cmp(&b.file)
+            .then(a.line.cmp(&b.line))
+            .then(a.column.cmp(&b.column))
+    });
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::indexer::IndexOptions;
+    use crate::{Reference, ReferenceKind, Visibility};
+    use std::path::{Path, PathBuf};
+
+    fn go_symbol(name: &str, kind: SymbolKind, line: u32, signature: Option<&str>) -> Symbol {
+        Symbol::new(
+            name.to_string(),
+            format!("shop.{}", name),
+            kind,
+            Location::new(PathBuf::from("shop.go"), line, 6),
+            Visibility::Public,
+            "go".to_string(),
+        )
+        .with_signature(signature.map(str::to_string))
+    }
+
+    fn add_call(index: &mut CodeIndex, name: &str, line: u32) {
+        index.add_reference(
+            PathBuf::from("shop.go"),
+            Reference {
+                name: name.to_string(),
+                location: Location::new(PathBuf::from("shop.go"), line, 9),
+                kind: ReferenceKind::Call,
+                target: None,
+                arguments: None,
+            },
+        );
+    }
+
+    fn shop_index() -> CodeIndex {
+        let mut index = CodeIndex::new();
+        for symbol in [
+            go_symbol("Cart", SymbolKind::Class, 1, None),
+            go_symbol("Stack", SymbolKind::Class, 3, Some("type Stack[T any]")),
+            go_symbol(
+                "NewCart",
+                SymbolKind::Function,
+                5,
+                Some("func NewCart() (*Cart, error)"),
+            ),
+            go_symbol(
+                "NewStack",
+                SymbolKind::Function,
+                9,
+                Some("func NewStack[T any]() *Stack[T]"),
+            ),
+            go_symbol(
+                "NewBuffer",
+                SymbolKind::Function,
+                13,
+                Some("func NewBuffer() *bytes.Buffer"),
+            ),
+            go_symbol(
+                "newCart",
+                SymbolKind::Function,
+                17,
+                Some("func newCart() *Cart"),
+            ),
+            go_symbol("Fresh", SymbolKind::Function, 21, Some("func Fresh() Cart")),
+            go_symbol(
+                "checkout",
+                SymbolKind::Function,
+                25,
+                Some("func checkout()"),
+            ),
+        ] {
+            index.add_symbol(symbol);
+        }
+        add_call(&mut index, "NewCart", 26);
+        add_call(&mut index, "NewStack", 27);
+        add_call(&mut index, "Fresh", 28);
+        index
+    }
+
+    #[test]
+    fn test_constructed_type_follows_the_new_idiom() {
+        let index = shop_index();
+        let constructed = |name: &str| {
+            let symbol = index.get(&format!("shop.{}", name)).unwrap();
+            index.constructed_type(symbol).map(|t| t.qualified.as_str())
+        };
+
+        assert_eq!(constructed("NewCart"), Some("shop.Cart"));
+        assert_eq!(constructed("NewStack"), Some("shop.Stack"));
+        // Not package-local, unexported, or not named New*
+        assert_eq!(constructed("NewBuffer"), None);
+        assert_eq!(constructed("newCart"), None);
+        assert_eq!(constructed("Fresh"), None);
+        assert_eq!(constructed("Cart"), None);
+
+        let names: Vec<&str> = index
+            .constructors("shop.Cart")
+            .iter()
+            .map(|s| s.name.as_str())
+            .collect();
+        assert_eq!(names, vec!["NewCart"]);
+    }
+
+    #[test]
+    fn test_constructs_edges_from_calls() {
+        let index = shop_index();
+        let graph = CallGraph::build(&index);
+
+        let created = graph.constructs(&index, "shop.checkout");
+        let edges: Vec<(&str, &str, u32)> = created
+            .iter()
+            .map(|c| {
+                (
+                    c.constructed.as_str(),
+                    c.constructor.as_str(),
+                    c.location.line,
+                )
+            })
+            .collect();
+        assert_eq!(
+            edges,
+            vec![
+                ("shop.Cart", "shop.NewCart", 26),
+                ("shop.Stack", "shop.NewStack", 27)
+            ]
+        );
+
+        let carts = graph.constructions(&index, "shop.Cart");
+        assert_eq!(carts.len(), 1);
+        assert_eq!(carts[0].caller, "shop.checkout");
+        assert!(graph.constructions(&index, "shop.Missing").is_empty());
+    }
+
+    #[test]
+    fn test_constructions_in_go_sample_fixture() {
+        let root = Path::new(env!("CARGO_MANIFEST_DIR"))
+            .join("../../tests/claude-plugin-integration/test-repos/go-sample");
+        let files: Vec<PathBuf> = ["main.go", "payment.go", "user.go"]
+            .iter()
+            .map(|f| root.join(f))
+            .collect();
+        let mut index = CodeIndex::with_root(root);
+        index.update_files(&files, &IndexOptions::default());
+        let graph = CallGraph::build(&index);
+
+        let created = graph.constructs(&index, "main.main");
+        let constructed: Vec<&str> = created.iter().map(|c| c.constructed.as_str()).collect();
+        assert_eq!(constructed, vec!["main.User", "main.PaymentService"]);
+
+        let users = graph.constructions(&index, "main.User");
+        assert_eq!(users.len(), 1);
+        assert_eq!(users[0].constructor, "main.NewUser");
+        assert_eq!(users[0].location.file, PathBuf::from("main.go"));
+        assert_eq!(users[0].location.line, 6);
+    }
+}
@@ -0,0 +1,399 @@
+//! Relevance ranking of symbols for prompt context.
+//!
+//! [`CodeIndex::rank_for_context`] picks the symbols most relevant to a query
+//! and returns them with their source, best first, stopping before the total
+//! source size passes a byte budget. It is meant for handing an assistant a
+//! focused slice of the codebase rather than whole files.
+//!
+//! A symbol is a candidate when any word of the query fuzzy-matches its name
+//! (see [`subsequence_score`]). Candidates are ranked by a weighted sum of
+//! three scores, each scaled to `0.0..=1.0` across the candidates:
+//!
+//! - name match: the best match of any query word; words containing `.` are
+//!   matched against the qualified name
+//! - centrality: callers plus callees in the [`CallGraph`], on a log scale
+//! - recency: modification time of the symbol's file
+//!
+//! The weights are set with [`RankWeights`]. Symbols nested in an already
+//! selected symbol (methods of a selected class) are skipped, since their
+//! source is already included.
+//!
+//! # Examples
+//!
+//! ```no_run
+//! use rocketindex::callgraph::CallGraph;
+//! use rocketindex::context::RankWeights;
+//! use rocketindex::indexer::IndexOptions;
+//! use rocketindex::CodeIndex;
+//! use std::path::PathBuf;
+//!
+//! let mut index = CodeIndex::with_root(PathBuf::from("."));
+//! index.update_files(&[PathBuf::from("payment.go")], &IndexOptions::default());
+//! let graph = CallGraph::build(&index);
+//!
+//! for item in index.rank_for_context(&graph, "payment refund", 8_000, &RankWeights::default()) {
+//!     println!("// {} ({:.2})\n{}", item.symbol.qualified, item.score, item.source);
+//! }
+//! ```
+
+use std::collections::HashMap;
+use std::path::{Path, PathBuf};
+use std::time::SystemTime;
+
+use crate::callgraph::CallGraph;
+use crate::fuzzy::subsequence_score;
+use crate::{CodeIndex, Symbol};
+
+/// Weights of the scores combined by [`CodeIndex::rank_for_context`].
+///
+/// Each score is in `0.0..=1.0`, so the weights set their relative importance.
+/// A weight of `0.0` ignores that score.
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub struct RankWeights {
+    /// How closely the symbol's name matches the query
+    pub name_match: f64,
+    /// How many callers and callees the symbol has
+    pub centrality: f64,
+    /// How recently the symbol's file was modified
+    pub recency: f64,
+}
+
+impl Default for RankWeights {
+    fn default() -> Self {
+        Self {
+            name_match: 1.0,
+            centrality: 0.5,
+            recency: 0.25,
+        }
+    }
+}
+
+/// A symbol selected by [`CodeIndex::rank_for_context`].
+#[derive(Debug, Clone)]
+pub struct ContextSymbol<'a> {
+    /// The selected symbol
+    pub symbol: &'a Symbol,
+    /// Combined weighted score (higher is more relevant)
+    pub score: f64,
+    /// Source text of the symbol's lines
+    pub source: String,
+}
+
+struct Candidate<'a> {
+    symbol: &'a Symbol,
+    name: i64,
+    degree: usize,
+    modified: Option<SystemTime>,
+}
+
+impl CodeIndex {
+    /// Symbols most relevant to `query`, best first, whose source fits in
+    /// `budget` bytes in total.
+    ///
+    /// Selection stops at the first symbol whose source would exceed the
+    /// budget. Symbols whose file cannot be read are skipped. Ties are broken
+    /// by qualified name, then location, so the order is deterministic.
+    #[must_use]
+    pub fn rank_for_context(
+        &self,
+        graph: &CallGraph,
+        query: &str,
+        budget: usize,
+        weights: &RankWeights,
+    ) -> Vec<ContextSymbol<'_>> {
+        let words: Vec<&str> = query.split_whitespace().collect();
+        if words.is_empty() {
+            return Vec::new();
+        }
+
+        let mut modified: HashMap<&Path, Option<SystemTime>> = HashMap::new();
+        let candidates: Vec<Candidate<'_>> = self
+            .symbols()
+            .filter_map(|symbol| {
+                let name = words.iter().filter_map(|w| name_score(w, symbol)).max()?;
+                let file = symbol.location.file.as_path();
+                let modified = *modified.entry(file).or_insert_with(|| {
+                    std::fs::metadata(self.to_absolute(file))
+                        .and_then(|m| m.modified())
+                        .ok()
+                });
+                Some(Candidate {
+                    symbol,
+                    name,
+                    degree: graph.callers(&symbol.qualified).len()
+                        + graph.callees(&symbol.qualified).len(),
+                    modified,
+                })
+            })
+            .collect();
+        let (min_name, max_name) = match min_max(candidates.iter().map(|c| c.name)) {
+            (Some(min), Some(max)) => (min, max),
+            _ => return Vec::new(),
+        };
+        let max_degree = candidates.iter().map(|c| c.degree).max().unwrap_or(0);
+        let (oldest, newest) = min_max(candidates.iter().filter_map(|c| c.modified));
+
+        let mut ranked: Vec<(f64, &Symbol)> = candidates
+            .iter()
+            .map(|c| {
+                let name = scale(c.name as f64, min_name as f64, max_name as f64, 1.0);
+                let centrality = if max_degree == 0 {
+                    0.0
+                } else {
+                    (c.degree as f64).ln_1p() / (max_degree as f64).ln_1p()
+                };
+                let recency = match (c.modified, oldest, newest) {
+                    (Some(t), Some(oldest), Some(newest)) => scale(
+                        seconds_since(oldest, t),
+                        0.0,
+                        seconds_since(oldest, newest),
+                        0.0,
+                    ),
+                    _ => 0.0,
+                };
+                let score = weights.name_match * name
+                    + weights.centrality * centrality
+                    + weights.recency * recency;
+                (score, c.symbol)
+            })
+            .collect();
+
+        ranked.sort_by(|(a_score, a), (b_score, b)| {
+            b_score
+                .total_cmp(a_score)
+                .then_with(|| a.qualified.cmp(&b.qualified))
+                .then_with(|| a.location.file.cmp(&b.location.file))
+                .then_with(|| a.location.line.cmp(&b.location.line))
+                .then_with(|| a.location.column.cmp(&b.location.column))
+        });
+
+        let mut sources: HashMap<PathBuf, Option<String>> = HashMap::new();
+        let mut selected: Vec<ContextSymbol<'_>> = Vec::new();
+        let mut used = 0;
+        for (score, symbol) in ranked {
+            if selected.iter().any(|s| encloses(s.symbol, symbol)) {
+                continue;
+            }
+            let file = &symbol.location.file;
+            let text = sources
+                .entry(file.clone())
+                .or_insert_with(|| std::fs::read_to_string(self.to_absolute(file)).ok());
+            let Some(source) = text.as_deref().map(|text| symbol_source(text, symbol)) else {
+                continue;
+            };
+            if used + source.len() > budget {
+                break;
+            }
+            used += source.len();
+            selected.push(ContextSymbol {
+                symbol,
+                score,
+                source,
+            });
+        }
+        selected
+    }
+}
+
+/// Fuzzy match score of one query word against a symbol.
+fn name_score(word: &str, symbol: &Symbol) -> Option<i64> {
+    let target = if word.contains('.') || word.contains("::") {
+        &symbol.qualified
+    } else {
+        &symbol.name
+    };
+    subsequence_score(word, target)
+}
+
+fn min_max<T: Copy + Ord>(values: impl Iterator<Item = T>) -> (Option<T>, Option<T>) {
+    values.fold((None, None), |(min, max), v| {
+        (
+            Some(min.map_or(v, |m: T| m.min(v))),
+            Some(max.map_or(v, |m: T| m.max(v))),
+        )
+    })
+}
+
+/// Scale `value` from `min..=max` to `0.0..=1.0`, or `flat` if all values are equal.
+fn scale(value: f64, min: f64, max: f64, flat: f64) -> f64 {
+    if max > min {
+        (value - min) / (max - min)
+    } else {
+        flat
+    }
+}
+
+fn seconds_since(earlier: SystemTime, later: SystemTime) -> f64 {
+    later
+        .duration_since(earlier)
+        .map(|d| d.as_secs_f64())
+        .unwrap_or(0.0)
+}
+
+/// Whether `outer`'s lines contain `inner`'s (and they are different symbols).
+fn encloses(outer: &Symbol, inner: &Symbol) -> bool {
+    let (o, i) = (outer.lines(), inner.lines());
+    outer.location.file == inner.location.file && o.0 <= i.0 && i.1 <= o.1 && o != i
+}
+
+/// The full source lines of `symbol`'s declaration.
+fn symbol_source(text: &str, symbol: &Symbol) -> String {
+    let (line, end_line) = symbol.lines();
+    let start = line.max(1) as usize - 1;
+    let end = end_line as usize;
+    text.lines()
+        .skip(start)
+        .take(end - start)
+        .collect::<Vec<_>>()
+        .join("\n")
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::indexer::IndexOptions;
+    use crate::{Location, Reference, ReferenceKind, SymbolKind, Visibility};
+
+    const PAYMENT: &str = "def process_payment(amount):\n    return charge(amount)\n\n\ndef charge(amount):\n    return amount\n\n\ndef refund_payment(amount):\n    return charge(-amount)\n";
+
+    fn function(name: &str, file: &str, line: u32, end_line: u32) -> Symbol {
+        Symbol::new(
+            name.to_string(),
+            name.to_string(),
+            SymbolKind::Function,
+            Location::with_end(PathBuf::from(file), line, 5, end_line, 1),
+            Visibility::Public,
+            "python".to_string(),
+        )
+    }
+
+    fn call(index: &mut CodeIndex, name: &str, file: &str, line: u32) {
+        index.add_reference(
+            PathBuf::from(file),
+            Reference {
+                name: name.to_string(),
+                location: Location::new(PathBuf::from(file), line, 12),
+                kind: ReferenceKind::Call,
+                target: None,
+                arguments: None,
+            },
+        );
+    }
+
+    fn payment_index(root: &Path) -> CodeIndex {
+        std::fs::write(root.join("payment.py"), PAYMENT).unwrap();
+        let mut index = CodeIndex::with_root(root.to_path_buf());
+        index.add_symbol(function("process_payment", "payment.py", 1, 2));
+        index.add_symbol(function("charge", "payment.py", 5, 6));
+        index.add_symbol(function("refund_payment", "payment.py", 9, 10));
+        call(&mut index, "charge", "payment.py", 2);
+        call(&mut index, "charge", "payment.py", 10);
+        index
+    }
+
+    fn names<'a>(items: &[ContextSymbol<'a>]) -> Vec<&'a str> {
+        items.iter().map(|item| item.symbol.name.as_str()).collect()
+    }
+
+    #[test]
+    fn test_rank_for_context_orders_by_name_match_and_returns_source() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let index = payment_index(temp_dir.path());
+        let graph = CallGraph::build(&index);
+
+        let items = index.rank_for_context(&graph, "refund", 10_000, &RankWeights::default());
+        assert_eq!(names(&items), vec!["refund_payment"]);
+        assert_eq!(
+            items[0].source,
+            "def refund_payment(amount):\n    return charge(-amount)"
+        );
+
+        // charge does not match; the shorter, earlier match ranks first
+        let items = index.rank_for_context(&graph, "payment", 10_000, &RankWeights::default());
+        assert_eq!(names(&items), vec!["refund_payment", "process_payment"]);
+        assert!(index
+            .rank_for_context(&graph, "  ", 10_000, &RankWeights::default())
+            .is_empty());
+    }
+
+    #[test]
+    fn test_rank_for_context_weights_centrality() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let index = payment_index(temp_dir.path());
+        let graph = CallGraph::build(&index);
+
+        // "a" matches all three names; charge has two callers
+        let central = RankWeights {
+            name_match: 0.0,
+            centrality: 1.0,
+            recency: 0.0,
+        };
+        let items = index.rank_for_context(&graph, "a", 10_000, &central);
+        assert_eq!(names(&items)[0], "charge");
+        assert!(items[0].score > items[1].score);
+    }
+
+    #[test]
+    fn test_rank_for_context_stops_at_budget() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let index = payment_index(temp_dir.path());
+        let graph = CallGraph::build(&index);
+
+        let all = index.rank_for_context(&graph, "payment", 10_000, &RankWeights::default());
+        let first = all[0].source.len();
+        let items = index.rank_for_context(&graph, "payment", first, &RankWeights::default());
+        assert_eq!(items.len(), 1);
+        assert!(index
+            .rank_for_context(&graph, "payment", first - 1, &RankWeights::default())
+            .is_empty());
+    }
+
+    #[test]
+    fn test_rank_for_context_skips_nested_and_unreadable_symbols() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let root = temp_dir.path();
+        std::fs::write(
+            root.join("cart.py"),
+            "class Cart:\n    def cart_total(self):\n        return 0\n",
+        )
+        .unwrap();
+        let mut index = CodeIndex::with_root(root.to_path_buf());
+        index.add_symbol(function("Cart", "cart.py", 1, 3));
+        index.add_symbol(function("cart_total", "cart.py", 2, 3));
+        index.add_symbol(function("cart_missing", "missing.py", 1, 2));
+        let graph = CallGraph::build(&index);
+
+        let items = index.rank_for_context(&graph, "Cart", 10_000, &RankWeights::default());
+        assert_eq!(names(&items), vec!["Cart"]);
+        assert!(items[0].source.contains("cart_total"));
+    }
+
+    #[test]
+    fn test_rank_for_context_uses_parsed_declarations() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let root = temp_dir.path();
+        std::fs::write(root.join("payment.py"), PAYMENT).unwrap();
+        std::fs::write(
+            root.join("cart.py"),
+            "class Cart:\n    def cart_total(self):\n        return 0\n",
+        )
+        .unwrap();
+        let mut index = CodeIndex::with_root(root.to_path_buf());
+        index.update_files(
+            &[root.join("payment.py"), root.join("cart.py")],
+            &IndexOptions::default(),
+        );
+        let graph = CallGraph::build(&index);
+
+        let items = index.rank_for_context(&graph, "refund", 10_000, &RankWeights::default());
+        assert_eq!(names(&items), vec!["refund_payment"]);
+        assert_eq!(
+            items[0].source,
+            "def refund_payment(amount):\n    return charge(-amount)"
+        );
+
+        let items = index.rank_for_context(&graph, "Cart", 10_000, &RankWeights::default());
+        assert_eq!(names(&items), vec!["Cart"]);
+        assert!(items[0].source.ends_with("return 0"));
+    }
+}
@@ -0,0 +1,298 @@
+//! Static test coverage: which tests reach which symbols through the call graph.
+//!
+//! [`CallGraph::test_coverage`] treats every test function as a root and
+//! records, for each production symbol, the tests that transitively call it.
+//! This is static reachability, not runtime coverage: a test counts as
+//! covering everything its call graph reaches, whether or not those calls
+//! run. It answers "which tests should I run for this change?".
+//!
+//! What counts as a test is decided per language by a [`TestDetector`].
+//! [`DefaultTestDetector`] follows the test file and test function patterns
+//! of the built-in [`LanguageConfigs`], chosen by file extension like the
+//! name resolvers, for example:
+//!
+//! - Go: [`GoTestDetector`], `TestXxx` functions in `_test.go` files
+//! - Python: [`PythonTestDetector`], `test*` functions in `test_*.py` or `*_test.py` files
+//! - TypeScript: every function in `*.test.ts` or `*.spec.ts` files
+//!
+//! [`CallGraph::test_coverage_with`] takes any other detector, such as
+//! [`LanguageConfigs`] with one language's conventions replaced. Symbols in
+//! test files (helpers, fixtures, other tests) are never reported as covered.
+//!
+//! # Examples
+//!
+//! ```
+//! use rocketindex::callgraph::CallGraph;
+//! use rocketindex::{
+//!     CodeIndex, Location, Reference, ReferenceKind, Symbol, SymbolKind, Visibility,
+//! };
+//! use std::path::PathBuf;
+//!
+//! let mut index = CodeIndex::new();
+//! for (name, file, line) in [("helper", "main.go", 1), ("TestHelper", "main_test.go", 5)] {
+//!     index.add_symbol(Symbol::new(
+//!         name.to_string(),
+//!         format!("main.{}", name),
+//!         SymbolKind::Function,
+//!         Location::new(PathBuf::from(file), line, 6),
+//!         Visibility::Public,
+//!         "go".to_string(),
+//!     ));
+//! }
+//! index.add_reference(
+//!     PathBuf::from("main_test.go"),
+//!     Reference {
+//!         name: "helper".to_string(),
+//!         location: Location::new(PathBuf::from("main_test.go"), 6, 5),
+//!         kind: ReferenceKind::Call,
+//!         target: None,
+//!         arguments: None,
+//!     },
+//! );
+//!
+//! let coverage = CallGraph::build(&index).test_coverage(&index);
+//! assert_eq!(coverage.tests_covering("main.helper"), ["main.TestHelper"]);
+//! assert_eq!(coverage.symbols_covered_by("main.TestHelper"), ["main.helper"]);
+//! ```
+//!
+//! [`GoTestDetector`]: crate::languages::go::GoTestDetector
+//! [`PythonTestDetector`]: crate::languages::python::PythonTestDetector
+
+use std::collections::BTreeMap;
+use std::path::Path;
+
+use crate::callgraph::CallGraph;
+use crate::language_config::LanguageConfigs;
+use crate::{CodeIndex, Symbol};
+
+/// Trait for language-specific test detection.
+pub trait TestDetector: Send + Sync {
+    /// Whether `path` holds tests rather than production code.
+    fn is_test_file(&self, path: &Path) -> bool;
+
+    /// Whether `symbol` is a test the test runner would run.
+    fn is_test(&self, symbol: &Symbol) -> bool;
+}
+
+/// The conventions of [`LanguageConfigs::builtin`], dispatched on file
+/// extension. Files in other languages contain no tests.
+pub struct DefaultTestDetector;
+
+impl TestDetector for DefaultTestDetector {
+    fn is_test_file(&self, path: &Path) -> bool {
+        LanguageConfigs::builtin().is_test_file(path)
+    }
+
+    fn is_test(&self, symbol: &Symbol) -> bool {
+        LanguageConfigs::builtin().is_test(symbol)
+    }
+}
+
+/// Tests mapped to the production symbols they reach, and back.
+#[derive(Debug, Clone, Default)]
+pub struct TestCoverage {
+    /// Qualified names of every detected test, sorted
+    tests: Vec<String>,
+    /// Production symbol -> tests reaching it, sorted
+    covering: BTreeMap<String, Vec<String>>,
+    /// Test -> production symbols it reaches, sorted
+    covered: BTreeMap<String, Vec<String>>,
+}
+
+impl TestCoverage {
+    /// Qualified names of every detected test, sorted.
+    #[must_use]
+    pub fn tests(&self) -> &[String] {
+        &self.tests
+    }
+
+    /// Tests that transitively call `qualified`, sorted by qualified name.
+    #[must_use]
+    pub fn tests_covering(&self, qualified: &str) -> &[String] {
+        self.covering.get(qualified).map_or(&[], Vec::as_slice)
+    }
+
+    /// Production symbols `test` transitively calls, sorted by qualified name.
+    #[must_use]
+    pub fn symbols_covered_by(&self, test: &str) -> &[String] {
+        self.covered.get(test).map_or(&[], Vec::as_slice)
+    }
+
+    /// Production symbols reached by at least one test, sorted.
+    pub fn covered_symbols(&self) -> impl Iterator<Item = &str> {
+        self.covering.keys().map(String::as_str)
+    }
+}
+
+impl CallGraph {
+    /// Map tests to the symbols they reach, detecting tests with
+    /// [`DefaultTestDetector`].
+    #[must_use]
+    pub fn test_coverage(&self, index: &CodeIndex) -> TestCoverage {
+        self.test_coverage_with(index, &DefaultTestDetector)
+    }
+
+    /// Map the tests `detector` finds in `index` to the symbols they reach.
+    #[must_use]
+    pub fn test_coverage_with(
+        &self,
+        index: &CodeIndex,
+        detector: &dyn TestDetector,
+    ) -> TestCoverage {
+        let mut tests: Vec<String> = index
+            .symbols()
+            .filter(|s| detector.is_test(s))
+            .map(|s| s.qualified.clone())
+            .collect();
+        tests.sort();
+        tests.dedup();
+
+        let mut coverage = TestCoverage::default();
+        for test in &tests {
+            let mut reached: Vec<String> = self
+                .reachable_from(test, 0)
+                .into_iter()
+                .map(|r| r.qualified)
+                .filter(|qualified| is_production(index, detector, qualified))
+                .collect();
+            reached.sort();
+            for symbol in &reached {
+                // Tests are visited in order, so each list stays sorted
+                coverage
+                    .covering
+                    .entry(symbol.clone())
+                    .or_default()
+                    .push(test.clone());
+            }
+            if !reached.is_empty() {
+                coverage.covered.insert(test.clone(), reached);
+            }
+        }
+        coverage.tests = tests;
+        coverage
+    }
+}
+
+/// Whether `qualified` is defined outside test files. Unindexed names are not.
+fn is_production(index: &CodeIndex, detector: &dyn TestDetector, qualified: &str) -> bool {
+    let definitions = index.get_all(qualified);
+    !definitions.is_empty()
+        && definitions
+            .iter()
+            .any(|s| !detector.is_test_file(&s.location.file))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::{Location, Reference, ReferenceKind, SymbolKind, Visibility};
+    use std::path::PathBuf;
+
+    fn add_function(index: &mut CodeIndex, name: &str, file: &str, line: u32) {
+        index.add_symbol(Symbol::new(
+            name.to_string(),
+            format!("main.{}", name),
+            SymbolKind::Function,
+            Location::new(PathBuf::from(file), line, 6),
+            Visibility::Public,
+            "go".to_string(),
+        ));
+    }
+
+    fn add_call(index: &mut CodeIndex, name: &str, file: &str, line: u32) {
+        index.add_reference(
+            PathBuf::from(file),
+            Reference {
+                name: name.to_string(),
+                location: Location::new(PathBuf::from(file), line, 5),
+                kind: ReferenceKind::Call,
+                target: None,
+                arguments: None,
+            },
+        );
+    }
+
+    /// Production: run -> load -> parse, and unused.
+    /// Tests: TestRun -> run, TestParse -> setup -> parse.
+    fn go_index() -> CodeIndex {
+        let mut index = CodeIndex::new();
+        add_function(&mut index, "run", "main.go", 1);
+        add_call(&mut index, "load", "main.go", 2);
+        add_function(&mut index, "load", "main.go", 5);
+        add_call(&mut index, "parse", "main.go", 6);
+        add_function(&mut index, "parse", "main.go", 9);
+        add_function(&mut index, "unused", "main.go", 13);
+
+        add_function(&mut index, "TestRun", "main_test.go", 1);
+        add_call(&mut index, "run", "main_test.go", 2);
+        add_function(&mut index, "TestParse", "main_test.go", 5);
+        add_call(&mut index, "setup", "main_test.go", 6);
+        add_function(&mut index, "setup", "main_test.go", 9);
+        add_call(&mut index, "parse", "main_test.go", 10);
+        index
+    }
+
+    #[test]
+    fn test_tests_covering_and_symbols_covered_by() {
+        let index = go_index();
+        let coverage = CallGraph::build(&index).test_coverage(&index);
+
+        assert_eq!(coverage.tests(), ["main.TestParse", "main.TestRun"]);
+        assert_eq!(
+            coverage.tests_covering("main.parse"),
+            ["main.TestParse", "main.TestRun"]
+        );
+        assert_eq!(coverage.tests_covering("main.load"), ["main.TestRun"]);
+        assert!(coverage.tests_covering("main.unused").is_empty());
+        // Test helpers are not production code
+        assert!(coverage.tests_covering("main.setup").is_empty());
+
+        assert_eq!(
+            coverage.symbols_covered_by("main.TestRun"),
+            ["main.load", "main.parse", "main.run"]
+        );
+        assert_eq!(
+            coverage.symbols_covered_by("main.TestParse"),
+            ["main.parse"]
+        );
+        assert!(coverage.symbols_covered_by("main.run").is_empty());
+        assert_eq!(
+            coverage.covered_symbols().collect::<Vec<_>>(),
+            vec!["main.load", "main.parse", "main.run"]
+        );
+    }
+
+    #[test]
+    fn test_custom_detector() {
+        struct SetupIsATest;
+        impl TestDetector for SetupIsATest {
+            fn is_test_file(&self, path: &Path) -> bool {
+                path.to_str().is_some_and(|p| p.ends_with("_test.go"))
+            }
+            fn is_test(&self, symbol: &Symbol) -> bool {
+                symbol.name == "setup"
+            }
+        }
+
+        let index = go_index();
+        let coverage = CallGraph::build(&index).test_coverage_with(&index, &SetupIsATest);
+        assert_eq!(coverage.tests(), ["main.setup"]);
+        assert_eq!(coverage.tests_covering("main.parse"), ["main.setup"]);
+        assert!(coverage.tests_covering("main.run").is_empty());
+    }
+
+    #[test]
+    fn test_files_without_a_detector_have_no_tests() {
+        let mut index = CodeIndex::new();
+        index.add_symbol(Symbol::new(
+            "TestHelper".to_string(),
+            "TestHelper".to_string(),
+            SymbolKind::Function,
+            Location::new(PathBuf::from("helper_test.lua"), 1, 5),
+            Visibility::Public,
+            "lua".to_string(),
+        ));
+        let coverage = CallGraph::build(&index).test_coverage(&index);
+        assert!(coverage.tests().is_empty());
+    }
+}
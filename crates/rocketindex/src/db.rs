@@ -53,16 +53,17 @@ use std::sync::{Mutex, MutexGuard};
 
 use rusqlite::{params, Connection, OptionalExtension};
 
-use crate::index::Reference;
+use crate::callgraph::{CallKind, CallSite};
+use crate::index::{Reference, ReferenceKind};
 use crate::type_cache::{MemberKind, TypeMember};
-use crate::{IndexError, Location, Result, Symbol, SymbolKind, Visibility};
+use crate::{Extent, IndexError, Location, Result, Symbol, SymbolKind, Visibility};
 
 /// Current schema version. Increment when making breaking changes.
-pub const SCHEMA_VERSION: u32 = 4;
+pub const SCHEMA_VERSION: u32 = 11;
 
 /// Standard columns selected when querying symbols.
 /// Must match the order expected by `row_to_symbol`.
-const SYMBOL_COLUMNS: &str = "name, qualified, kind, file, line, column, end_line, end_column, visibility, language, parent, mixins, attributes, implements, doc, signature";
+const SYMBOL_COLUMNS: &str = "name, qualified, kind, file, line, column, end_line, end_column, visibility, language, parent, mixins, attributes, implements, doc, signature, extent_line, extent_end_line";
 
 /// Default database filename within .rocketindex/
 pub const DEFAULT_DB_NAME: &str = "index.db";
@@ -196,6 +197,102 @@ impl SqliteIndex {
             tracing::info!("Migrated database schema from v{} to v4", from_version);
         }
 
+        // Migration v4 -> v5: Add refs.kind column
+        if from_version < 5 {
+            self.conn().execute_batch(
+                "ALTER TABLE refs ADD COLUMN kind TEXT NOT NULL DEFAULT 'unknown';",
+            )?;
+            self.set_metadata("schema_version", "5")?;
+            tracing::info!("Migrated database schema from v{} to v5", from_version);
+        }
+
+        // Migration v5 -> v6: Add calls table
+        if from_version < 6 {
+            self.conn().execute_batch(CALLS_SCHEMA_SQL)?;
+            self.set_metadata("schema_version", "6")?;
+            tracing::info!("Migrated database schema from v{} to v6", from_version);
+        }
+
+        // Migration v6 -> v7: Add refs.short_name column for indexed reference lookup
+        if from_version < 7 {
+            let conn = self.conn();
+            let tx = conn.unchecked_transaction()?;
+            tx.execute_batch(
+                "ALTER TABLE refs ADD COLUMN short_name TEXT NOT NULL DEFAULT '';
+                 CREATE INDEX IF NOT EXISTS idx_refs_short_name ON refs(short_name);",
+            )?;
+            {
+                let mut select = tx.prepare("SELECT id, name FROM refs")?;
+                let mut update = tx.prepare("UPDATE refs SET short_name = ?1 WHERE id = ?2")?;
+                let rows = select
+                    .query_map([], |row| {
+                        Ok((row.get::<_, i64>(0)?, row.get::<_, String>(1)?))
+                    })?
+                    .collect::<std::result::Result<Vec<_>, _>>()?;
+                for (id, name) in rows {
+                    update.execute(params![short_name(&name), id])?;
+                }
+            }
+            tx.commit()?;
+            drop(conn);
+            self.set_metadata("schema_version", "7")?;
+            tracing::info!("Migrated database schema from v{} to v7", from_version);
+        }
+
+        // Migration v7 -> v8: Add calls.overload column, unless the calls
+        // table was just created with it by the v6 migration
+        if from_version < 8 {
+            let conn = self.conn();
+            let has_overload: i64 = conn.query_row(
+                "SELECT COUNT(*) FROM pragma_table_info('calls') WHERE name = 'overload'",
+                [],
+                |row| row.get(0),
+            )?;
+            if has_overload == 0 {
+                conn.execute_batch("ALTER TABLE calls ADD COLUMN overload TEXT;")?;
+            }
+            drop(conn);
+            self.set_metadata("schema_version", "8")?;
+            tracing::info!("Migrated database schema from v{} to v8", from_version);
+        }
+
+        // Migration v8 -> v9: Add calls.candidates column, unless the calls
+        // table was just created with it by the v6 migration
+        if from_version < 9 {
+            let conn = self.conn();
+            let has_candidates: i64 = conn.query_row(
+                "SELECT COUNT(*) FROM pragma_table_info('calls') WHERE name = 'candidates'",
+                [],
+                |row| row.get(0),
+            )?;
+            if has_candidates == 0 {
+                conn.execute_batch("ALTER TABLE calls ADD COLUMN candidates TEXT;")?;
+            }
+            drop(conn);
+            self.set_metadata("schema_version", "9")?;
+            tracing::info!("Migrated database schema from v{} to v9", from_version);
+        }
+
+        // Migration v9 -> v10: Add symbols.extent_line/extent_end_line columns
+        if from_version < 10 {
+            self.conn().execute_batch(
+                "ALTER TABLE symbols ADD COLUMN extent_line INTEGER;
+                 ALTER TABLE symbols ADD COLUMN extent_end_line INTEGER;",
+            )?;
+            self.set_metadata("schema_version", "10")?;
+            tracing::info!("Migrated database schema from v{} to v10", from_version);
+        }
+
+        // Migration v10 -> v11: Add refs.target/arguments columns
+        if from_version < 11 {
+            self.conn().execute_batch(
+                "ALTER TABLE refs ADD COLUMN target TEXT;
+                 ALTER TABLE refs ADD COLUMN arguments TEXT;",
+            )?;
+            self.set_metadata("schema_version", "11")?;
+            tracing::info!("Migrated database schema from v{} to v11", from_version);
+        }
+
         Ok(())
     }
 
@@ -235,6 +332,7 @@ impl SqliteIndex {
              PRAGMA locking_mode = EXCLUSIVE;",
         )?;
         self.conn().execute_batch(SCHEMA_SQL)?;
+        self.conn().execute_batch(CALLS_SCHEMA_SQL)?;
         self.set_metadata("schema_version", &SCHEMA_VERSION.to_string())?;
         Ok(())
     }
@@ -290,8 +388,8 @@ impl SqliteIndex {
     /// Insert a symbol into the database. Returns the inserted row ID.
     pub fn insert_symbol(&self, symbol: &Symbol) -> Result<i64> {
         self.conn().execute(
-            "INSERT INTO symbols (name, qualified, kind, file, line, column, end_line, end_column, visibility, source, language, parent, mixins, attributes, implements, doc, signature)
-             VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, 'syntactic', ?10, ?11, ?12, ?13, ?14, ?15, ?16)",
+            "INSERT INTO symbols (name, qualified, kind, file, line, column, end_line, end_column, visibility, source, language, parent, mixins, attributes, implements, doc, signature, extent_line, extent_end_line)
+             VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, 'syntactic', ?10, ?11, ?12, ?13, ?14, ?15, ?16, ?17, ?18)",
             params![
                 symbol.name,
                 symbol.qualified,
@@ -309,6 +407,8 @@ impl SqliteIndex {
                 symbol.implements.as_ref().map(|v| serde_json::to_string(v).unwrap_or_default()),
                 symbol.doc,
                 symbol.signature,
+                symbol.extent.map(|e| e.line),
+                symbol.extent.map(|e| e.end_line),
             ],
         )?;
         Ok(self.conn().last_insert_rowid())
@@ -317,8 +417,8 @@ impl SqliteIndex {
     /// Insert a symbol with type signature.
     pub fn insert_symbol_with_type(&self, symbol: &Symbol, type_signature: &str) -> Result<i64> {
         self.conn().execute(
-            "INSERT INTO symbols (name, qualified, kind, type_signature, file, line, column, end_line, end_column, visibility, source, language, parent, mixins, attributes, implements, doc, signature)
-             VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, 'semantic', ?11, ?12, ?13, ?14, ?15, ?16, ?17)",
+            "INSERT INTO symbols (name, qualified, kind, type_signature, file, line, column, end_line, end_column, visibility, source, language, parent, mixins, attributes, implements, doc, signature, extent_line, extent_end_line)
+             VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, 'semantic', ?11, ?12, ?13, ?14, ?15, ?16, ?17, ?18, ?19)",
             params![
                 symbol.name,
                 symbol.qualified,
@@ -337,6 +437,8 @@ impl SqliteIndex {
                 symbol.implements.as_ref().map(|v| serde_json::to_string(v).unwrap_or_default()),
                 symbol.doc,
                 symbol.signature,
+                symbol.extent.map(|e| e.line),
+                symbol.extent.map(|e| e.end_line),
             ],
         )?;
         Ok(self.conn().last_insert_rowid())
@@ -348,8 +450,8 @@ impl SqliteIndex {
         let tx = conn.unchecked_transaction()?;
         {
             let mut stmt = tx.prepare(
-                "INSERT INTO symbols (name, qualified, kind, file, line, column, end_line, end_column, visibility, language, source, parent, mixins, attributes, implements, doc, signature)
-                 VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, 'syntactic', ?11, ?12, ?13, ?14, ?15, ?16)",
+                "INSERT INTO symbols (name, qualified, kind, file, line, column, end_line, end_column, visibility, language, source, parent, mixins, attributes, implements, doc, signature, extent_line, extent_end_line)
+                 VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, 'syntactic', ?11, ?12, ?13, ?14, ?15, ?16, ?17, ?18)",
             )?;
 
             for symbol in symbols {
@@ -379,6 +481,8 @@ impl SqliteIndex {
                         .map(|v| serde_json::to_string(v).unwrap_or_default()),
                     symbol.doc,
                     symbol.signature,
+                    symbol.extent.map(|e| e.line),
+                    symbol.extent.map(|e| e.end_line),
                 ])?;
             }
         }
@@ -823,12 +927,19 @@ impl SqliteIndex {
     pub fn insert_reference(&self, file: &Path, reference: &Reference) -> Result<i64> {
         let file_str = file.to_string_lossy();
         self.conn().execute(
-            "INSERT INTO refs (name, file, line, column) VALUES (?1, ?2, ?3, ?4)",
+            "INSERT INTO refs (name, short_name, file, line, column, kind, target, arguments) VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8)",
             params![
                 reference.name,
+                short_name(&reference.name),
                 file_str.as_ref(),
                 reference.location.line,
                 reference.location.column,
+                reference_kind_to_str(reference.kind),
+                reference.target,
+                reference
+                    .arguments
+                    .as_ref()
+                    .map(|v| serde_json::to_string(v).unwrap_or_default()),
             ],
         )?;
         Ok(self.conn().last_insert_rowid())
@@ -839,16 +950,24 @@ impl SqliteIndex {
         let conn = self.conn();
         let tx = conn.unchecked_transaction()?;
         {
-            let mut stmt =
-                tx.prepare("INSERT INTO refs (name, file, line, column) VALUES (?1, ?2, ?3, ?4)")?;
+            let mut stmt = tx.prepare(
+                "INSERT INTO refs (name, short_name, file, line, column, kind, target, arguments) VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8)",
+            )?;
 
             for (file, reference) in refs {
                 let file_str = file.to_string_lossy();
                 stmt.execute(params![
                     reference.name,
+                    short_name(&reference.name),
                     file_str.as_ref(),
                     reference.location.line,
                     reference.location.column,
+                    reference_kind_to_str(reference.kind),
+                    reference.target,
+                    reference
+                        .arguments
+                        .as_ref()
+                        .map(|v| serde_json::to_string(v).unwrap_or_default()),
                 ])?;
             }
         }
@@ -859,27 +978,22 @@ impl SqliteIndex {
     /// Find all references to a name (short or qualified).
     /// Matches exact name or qualified names ending with the name (e.g., "User" matches
     /// "User", "Module.User", "Module::User", "Namespace\User", etc.)
+    ///
+    /// Both cases are answered from indexes: the suffix match only considers
+    /// references whose last segment equals the name's last segment.
     pub fn find_references(&self, name: &str) -> Result<Vec<Reference>> {
         let conn = self.conn();
         let mut stmt = conn.prepare(
-            "SELECT name, file, line, column FROM refs
+            "SELECT name, file, line, column, kind, target, arguments FROM refs
              WHERE name = ?1
-                OR name LIKE '%.' || ?1
-                OR name LIKE '%::' || ?1
-                OR name LIKE '%\\' || ?1",
+                OR (short_name = ?2
+                    AND (name LIKE '%.' || ?1
+                         OR name LIKE '%::' || ?1
+                         OR name LIKE '%\\' || ?1))",
         )?;
 
         let refs = stmt
-            .query_map(params![name], |row| {
-                let name: String = row.get(0)?;
-                let file: String = row.get(1)?;
-                let line: u32 = row.get(2)?;
-                let column: u32 = row.get(3)?;
-                Ok(Reference {
-                    name,
-                    location: Location::new(PathBuf::from(file), line, column),
-                })
-            })?
+            .query_map(params![name, short_name(name)], row_to_reference)?
             .collect::<std::result::Result<Vec<_>, _>>()?;
 
         Ok(refs)
@@ -889,19 +1003,12 @@ impl SqliteIndex {
     pub fn references_in_file(&self, file: &Path) -> Result<Vec<Reference>> {
         let file_str = file.to_string_lossy();
         let conn = self.conn();
-        let mut stmt = conn.prepare("SELECT name, file, line, column FROM refs WHERE file = ?1")?;
+        let mut stmt = conn.prepare(
+            "SELECT name, file, line, column, kind, target, arguments FROM refs WHERE file = ?1",
+        )?;
 
         let refs = stmt
-            .query_map(params![file_str.as_ref()], |row| {
-                let name: String = row.get(0)?;
-                let file: String = row.get(1)?;
-                let line: u32 = row.get(2)?;
-                let column: u32 = row.get(3)?;
-                Ok(Reference {
-                    name,
-                    location: Location::new(PathBuf::from(file), line, column),
-                })
-            })?
+            .query_map(params![file_str.as_ref()], row_to_reference)?
             .collect::<std::result::Result<Vec<_>, _>>()?;
 
         Ok(refs)
@@ -917,6 +1024,112 @@ impl SqliteIndex {
         Ok(count)
     }
 
+    // =========================================================================
+    // Call Edge Operations
+    // =========================================================================
+
+    /// Insert resolved call edges in a single transaction.
+    pub fn insert_calls(&self, calls: &[CallSite]) -> Result<()> {
+        let conn = self.conn();
+        let tx = conn.unchecked_transaction()?;
+        insert_calls_in_tx(&tx, calls)?;
+        tx.commit()?;
+        Ok(())
+    }
+
+    /// Replace the call edges whose call site is in `file` with `calls`, in
+    /// a single transaction.
+    pub fn replace_calls_in_file(&self, file: &Path, calls: &[CallSite]) -> Result<()> {
+        let conn = self.conn();
+        let tx = conn.unchecked_transaction()?;
+        tx.execute(
+            "DELETE FROM calls WHERE file = ?1",
+            params![file.to_string_lossy().as_ref()],
+        )?;
+        insert_calls_in_tx(&tx, calls)?;
+        tx.commit()?;
+        Ok(())
+    }
+
+    /// Count all call edges.
+    pub fn count_calls(&self) -> Result<usize> {
+        let count: i64 = self
+            .conn()
+            .query_row("SELECT COUNT(*) FROM calls", [], |row| row.get(0))?;
+        Ok(count as usize)
+    }
+
+    /// Find the symbols that call `qualified`.
+    ///
+    /// Like [`crate::callgraph::CallGraph::callers`], returns one call site per
+    /// distinct caller (its first call to the target), ordered by location.
+    pub fn callers(&self, qualified: &str) -> Result<Vec<CallSite>> {
+        let sites = self.call_sites("callee", qualified)?;
+        Ok(first_site_per(sites, |site| &site.caller))
+    }
+
+    /// Find the symbols that `qualified` calls.
+    ///
+    /// Like [`crate::callgraph::CallGraph::callees`], returns one call site per
+    /// distinct callee (the first call to it), ordered by location.
+    pub fn callees(&self, qualified: &str) -> Result<Vec<CallSite>> {
+        let sites = self.call_sites("caller", qualified)?;
+        Ok(first_site_per(sites, |site| &site.callee))
+    }
+
+    /// All call edges whose `column` (`caller` or `callee`) is `qualified`.
+    fn call_sites(&self, column: &str, qualified: &str) -> Result<Vec<CallSite>> {
+        let conn = self.conn();
+        let mut stmt = conn.prepare(&format!(
+            "SELECT caller, callee, file, line, column, kind, overload, candidates FROM calls
+             WHERE {} = ?1
+             ORDER BY file, line, column, caller, callee, kind",
+            column
+        ))?;
+
+        let sites = stmt
+            .query_map(params![qualified], |row| {
+                let file: String = row.get(2)?;
+                let kind: String = row.get(5)?;
+                let candidates: Option<String> = row.get(7)?;
+                Ok(CallSite {
+                    caller: row.get(0)?,
+                    callee: row.get(1)?,
+                    location: Location::new(PathBuf::from(file), row.get(3)?, row.get(4)?),
+                    kind: str_to_call_kind(&kind),
+                    overload: row.get(6)?,
+                    candidates: candidates
+                        .and_then(|json| serde_json::from_str(&json).ok())
+                        .unwrap_or_default(),
+                })
+            })?
+            .collect::<std::result::Result<Vec<_>, _>>()?;
+
+        Ok(sites)
+    }
+
+    /// Delete all call edges whose call site is in a file.
+    pub fn delete_calls_in_file(&self, file: &Path) -> Result<usize> {
+        let file_str = file.to_string_lossy();
+        let count = self.conn().execute(
+            "DELETE FROM calls WHERE file = ?1",
+            params![file_str.as_ref()],
+        )?;
+        Ok(count)
+    }
+
+    /// Delete all call edges whose callee is no longer an indexed symbol.
+    ///
+    /// Clearing a file removes the calls made from it, but not the calls
+    /// other files make to its symbols; this removes those.
+    pub fn delete_dangling_calls(&self) -> Result<usize> {
+        let count = self.conn().execute(
+            "DELETE FROM calls WHERE callee NOT IN (SELECT qualified FROM symbols)",
+            [],
+        )?;
+        Ok(count)
+    }
+
     // =========================================================================
     // Ranking Operations
     // =========================================================================
@@ -953,6 +1166,7 @@ impl SqliteIndex {
                 s.name, s.qualified, s.kind, s.file, s.line, s.column,
                 s.end_line, s.end_column, s.visibility, s.language,
                 s.parent, s.mixins, s.attributes, s.implements, s.doc, s.signature,
+                s.extent_line, s.extent_end_line,
                 COUNT(DISTINCT r.file) as file_diversity,
                 COUNT(r.id) as total_refs
             FROM symbols s
@@ -978,6 +1192,7 @@ impl SqliteIndex {
                 CASE s.visibility
                     WHEN 'public' THEN 3
                     WHEN 'internal' THEN 2
+                    WHEN 'protected' THEN 2
                     ELSE 1
                 END DESC,
                 total_refs DESC
@@ -990,8 +1205,8 @@ impl SqliteIndex {
         let results = stmt
             .query_map(params![limit as i64], |row| {
                 let symbol = row_to_symbol(row)?;
-                let file_diversity: i64 = row.get(16)?;
-                let total_refs: i64 = row.get(17)?;
+                let file_diversity: i64 = row.get(18)?;
+                let total_refs: i64 = row.get(19)?;
 
                 let score = compute_score(
                     file_diversity as usize,
@@ -1062,6 +1277,7 @@ impl SqliteIndex {
                     s.name, s.qualified, s.kind, s.file, s.line, s.column,
                     s.end_line, s.end_column, s.visibility, s.language,
                     s.parent, s.mixins, s.attributes, s.implements, s.doc, s.signature,
+                    s.extent_line, s.extent_end_line,
                     COUNT(DISTINCT r.file) as file_diversity,
                     COUNT(r.id) as total_refs
                 FROM symbols s
@@ -1090,7 +1306,7 @@ impl SqliteIndex {
                 name, qualified, kind, file, line, column,
                 end_line, end_column, visibility, language,
                 parent, mixins, attributes, implements, doc, signature,
-                file_diversity, total_refs
+                extent_line, extent_end_line, file_diversity, total_refs
             FROM per_file_ranked
             WHERE rank_in_file <= ?1
             ORDER BY file, rank_in_file
@@ -1102,8 +1318,8 @@ impl SqliteIndex {
         let results: Vec<RankedSymbol> = stmt
             .query_map(params![symbols_per_file as i64], |row| {
                 let symbol = row_to_symbol(row)?;
-                let file_diversity: i64 = row.get(16)?;
-                let total_refs: i64 = row.get(17)?;
+                let file_diversity: i64 = row.get(18)?;
+                let total_refs: i64 = row.get(19)?;
 
                 let score = compute_score(
                     file_diversity as usize,
@@ -1331,12 +1547,16 @@ impl SqliteIndex {
             "DELETE FROM opens WHERE file = ?1",
             params![file_str.as_ref()],
         )?;
+        tx.execute(
+            "DELETE FROM calls WHERE file = ?1",
+            params![file_str.as_ref()],
+        )?;
 
         // Insert symbols
         {
             let mut stmt = tx.prepare(
-                "INSERT INTO symbols (name, qualified, kind, file, line, column, end_line, end_column, visibility, language, source, parent, mixins, attributes, implements, doc, signature)
-                 VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, 'syntactic', ?11, ?12, ?13, ?14, ?15, ?16)",
+                "INSERT INTO symbols (name, qualified, kind, file, line, column, end_line, end_column, visibility, language, source, parent, mixins, attributes, implements, doc, signature, extent_line, extent_end_line)
+                 VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, 'syntactic', ?11, ?12, ?13, ?14, ?15, ?16, ?17, ?18)",
             )?;
             for symbol in symbols {
                 stmt.execute(params![
@@ -1365,20 +1585,30 @@ impl SqliteIndex {
                         .map(|v| serde_json::to_string(v).unwrap_or_default()),
                     symbol.doc,
                     symbol.signature,
+                    symbol.extent.map(|e| e.line),
+                    symbol.extent.map(|e| e.end_line),
                 ])?;
             }
         }
 
         // Insert references
         {
-            let mut stmt =
-                tx.prepare("INSERT INTO refs (name, file, line, column) VALUES (?1, ?2, ?3, ?4)")?;
+            let mut stmt = tx.prepare(
+                "INSERT INTO refs (name, short_name, file, line, column, kind, target, arguments) VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8)",
+            )?;
             for reference in references {
                 stmt.execute(params![
                     reference.name,
+                    short_name(&reference.name),
                     file_str.as_ref(),
                     reference.location.line,
                     reference.location.column,
+                    reference_kind_to_str(reference.kind),
+                    reference.target,
+                    reference
+                        .arguments
+                        .as_ref()
+                        .map(|v| serde_json::to_string(v).unwrap_or_default()),
                 ])?;
             }
         }
@@ -1396,11 +1626,12 @@ impl SqliteIndex {
         Ok(())
     }
 
-    /// Clear all data for a file (symbols, references, opens).
+    /// Clear all data for a file (symbols, references, opens, calls).
     pub fn clear_file(&self, file: &Path) -> Result<()> {
         self.delete_symbols_in_file(file)?;
         self.delete_references_in_file(file)?;
         self.delete_opens_in_file(file)?;
+        self.delete_calls_in_file(file)?;
         Ok(())
     }
 
@@ -1563,7 +1794,9 @@ CREATE TABLE IF NOT EXISTS symbols (
     attributes TEXT,
     implements TEXT,
     doc TEXT,
-    signature TEXT
+    signature TEXT,
+    extent_line INTEGER,
+    extent_end_line INTEGER
 );
 
 CREATE INDEX IF NOT EXISTS idx_symbols_qualified ON symbols(qualified);
@@ -1616,10 +1849,15 @@ CREATE TABLE IF NOT EXISTS refs (
     name TEXT NOT NULL,
     file TEXT NOT NULL,
     line INTEGER NOT NULL,
-    column INTEGER NOT NULL
+    column INTEGER NOT NULL,
+    kind TEXT NOT NULL DEFAULT 'unknown',
+    short_name TEXT NOT NULL DEFAULT '',
+    target TEXT,
+    arguments TEXT
 );
 
 CREATE INDEX IF NOT EXISTS idx_refs_name ON refs(name);
+CREATE INDEX IF NOT EXISTS idx_refs_short_name ON refs(short_name);
 CREATE INDEX IF NOT EXISTS idx_refs_file ON refs(file);
 
 -- Open statements for resolution context
@@ -1639,10 +1877,49 @@ CREATE TABLE IF NOT EXISTS file_mtimes (
 );
 "#;
 
+/// Resolved call edges (added in schema v6, so kept separate for the migration;
+/// `overload` added in v8, `candidates` in v9).
+const CALLS_SCHEMA_SQL: &str = r#"
+CREATE TABLE IF NOT EXISTS calls (
+    id INTEGER PRIMARY KEY,
+    caller TEXT NOT NULL,
+    callee TEXT NOT NULL,
+    file TEXT NOT NULL,
+    line INTEGER NOT NULL,
+    column INTEGER NOT NULL,
+    kind TEXT NOT NULL DEFAULT 'direct',
+    overload TEXT,
+    candidates TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_calls_caller ON calls(caller);
+CREATE INDEX IF NOT EXISTS idx_calls_callee ON calls(callee);
+CREATE INDEX IF NOT EXISTS idx_calls_file ON calls(file);
+"#;
+
 // ============================================================================
 // Helper Functions
 // ============================================================================
 
+/// Build a reference from a row of
+/// `name, file, line, column, kind, target, arguments`.
+fn row_to_reference(row: &rusqlite::Row<'_>) -> rusqlite::Result<Reference> {
+    let name: String = row.get(0)?;
+    let file: String = row.get(1)?;
+    let line: u32 = row.get(2)?;
+    let column: u32 = row.get(3)?;
+    let kind: String = row.get(4)?;
+    let target: Option<String> = row.get(5)?;
+    let arguments: Option<String> = row.get(6)?;
+    Ok(Reference {
+        name,
+        location: Location::new(PathBuf::from(file), line, column),
+        kind: str_to_reference_kind(&kind),
+        target,
+        arguments: arguments.and_then(|json| serde_json::from_str(&json).ok()),
+    })
+}
+
 fn row_to_symbol(row: &rusqlite::Row<'_>) -> rusqlite::Result<Symbol> {
     let name: String = row.get(0)?;
     let qualified: String = row.get(1)?;
@@ -1664,6 +1941,8 @@ fn row_to_symbol(row: &rusqlite::Row<'_>) -> rusqlite::Result<Symbol> {
     let implements_json: Option<String> = row.get(13)?;
     let doc: Option<String> = row.get(14)?;
     let signature: Option<String> = row.get(15)?;
+    let extent_line: Option<u32> = row.get(16)?;
+    let extent_end_line: Option<u32> = row.get(17)?;
 
     let mixins = mixins_json.and_then(|j| serde_json::from_str(&j).ok());
     let attributes = attributes_json.and_then(|j| serde_json::from_str(&j).ok());
@@ -1682,6 +1961,9 @@ fn row_to_symbol(row: &rusqlite::Row<'_>) -> rusqlite::Result<Symbol> {
         implements,
         doc,
         signature,
+        extent: extent_line
+            .zip(extent_end_line)
+            .map(|(line, end_line)| Extent { line, end_line }),
     })
 }
 
@@ -1732,6 +2014,7 @@ pub(crate) fn visibility_to_str(vis: Visibility) -> &'static str {
     match vis {
         Visibility::Public => "public",
         Visibility::Internal => "internal",
+        Visibility::Protected => "protected",
         Visibility::Private => "private",
     }
 }
@@ -1740,11 +2023,88 @@ fn str_to_visibility(s: &str) -> Visibility {
     match s {
         "public" => Visibility::Public,
         "internal" => Visibility::Internal,
+        "protected" => Visibility::Protected,
         "private" => Visibility::Private,
         _ => Visibility::Public,
     }
 }
 
+pub(crate) fn reference_kind_to_str(kind: ReferenceKind) -> &'static str {
+    match kind {
+        ReferenceKind::Unknown => "unknown",
+        ReferenceKind::Call => "call",
+        ReferenceKind::TypeUse => "type_use",
+        ReferenceKind::FieldAccess => "field_access",
+        ReferenceKind::Construction => "construction",
+        ReferenceKind::IndirectCall => "indirect_call",
+    }
+}
+
+fn str_to_reference_kind(s: &str) -> ReferenceKind {
+    match s {
+        "call" => ReferenceKind::Call,
+        "type_use" => ReferenceKind::TypeUse,
+        "field_access" => ReferenceKind::FieldAccess,
+        "construction" => ReferenceKind::Construction,
+        "indirect_call" => ReferenceKind::IndirectCall,
+        _ => ReferenceKind::Unknown,
+    }
+}
+
+fn call_kind_to_str(kind: CallKind) -> &'static str {
+    match kind {
+        CallKind::Direct => "direct",
+        CallKind::Possible => "possible",
+        CallKind::Indirect => "indirect",
+    }
+}
+
+fn str_to_call_kind(s: &str) -> CallKind {
+    match s {
+        "possible" => CallKind::Possible,
+        "indirect" => CallKind::Indirect,
+        _ => CallKind::Direct,
+    }
+}
+
+/// Keep the first call site for each distinct `key`.
+fn first_site_per<F>(sites: Vec<CallSite>, key: F) -> Vec<CallSite>
+where
+    F: Fn(&CallSite) -> &String,
+{
+    let mut seen = std::collections::HashSet::new();
+    sites
+        .into_iter()
+        .filter(|site| seen.insert(key(site).clone()))
+        .collect()
+}
+
+/// Insert resolved call edges within a transaction.
+pub(crate) fn insert_calls_in_tx(tx: &rusqlite::Transaction<'_>, calls: &[CallSite]) -> Result<()> {
+    let mut stmt = tx.prepare(
+        "INSERT INTO calls (caller, callee, file, line, column, kind, overload, candidates) VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8)",
+    )?;
+    for call in calls {
+        stmt.execute(params![
+            call.caller,
+            call.callee,
+            call.location.file.to_string_lossy().as_ref(),
+            call.location.line,
+            call.location.column,
+            call_kind_to_str(call.kind),
+            call.overload,
+            (!call.candidates.is_empty())
+                .then(|| serde_json::to_string(&call.candidates).unwrap_or_default()),
+        ])?;
+    }
+    Ok(())
+}
+
+/// Last segment of a possibly qualified name ("Module.User", "a::b", "Ns\\User").
+pub(crate) fn short_name(name: &str) -> &str {
+    name.rsplit(['.', ':', '\\']).next().unwrap_or(name)
+}
+
 fn member_kind_to_str(kind: MemberKind) -> &'static str {
     match kind {
         MemberKind::Property => "property",
@@ -1786,6 +2146,7 @@ mod tests {
             implements: None,
             doc: None,
             signature: None,
+            extent: None,
         }
     }
 
@@ -2045,6 +2406,9 @@ mod tests {
         let reference = Reference {
             name: "helper".to_string(),
             location: Location::new(PathBuf::from("src/Main.fs"), 10, 5),
+            kind: ReferenceKind::Unknown,
+            target: None,
+            arguments: None,
         };
 
         index
@@ -2063,10 +2427,16 @@ mod tests {
         let ref1 = Reference {
             name: "foo".to_string(),
             location: Location::new(PathBuf::from("src/Main.fs"), 10, 5),
+            kind: ReferenceKind::Unknown,
+            target: None,
+            arguments: None,
         };
         let ref2 = Reference {
             name: "bar".to_string(),
             location: Location::new(PathBuf::from("src/Main.fs"), 20, 5),
+            kind: ReferenceKind::Call,
+            target: None,
+            arguments: None,
         };
 
         index
@@ -2078,6 +2448,296 @@ mod tests {
 
         let refs = index.references_in_file(Path::new("src/Main.fs")).unwrap();
         assert_eq!(refs.len(), 2);
+        let bar = refs.iter().find(|r| r.name == "bar").unwrap();
+        assert_eq!(bar.kind, ReferenceKind::Call);
+    }
+
+    #[test]
+    fn test_migrate_v4_adds_reference_kind() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let db_path = temp_dir.path().join("test.db");
+        drop(SqliteIndex::create(&db_path).unwrap());
+
+        // Downgrade to the v4 layout, which had no refs.kind or refs.short_name column
+        let conn = Connection::open(&db_path).unwrap();
+        conn.execute_batch(
+            "DROP INDEX idx_refs_short_name;
+             ALTER TABLE refs DROP COLUMN short_name;
+             ALTER TABLE refs DROP COLUMN kind;
+             UPDATE metadata SET value = '4' WHERE key = 'schema_version';
+             INSERT INTO refs (name, file, line, column) VALUES ('old', 'a.py', 1, 1);",
+        )
+        .unwrap();
+        drop(conn);
+
+        let index = SqliteIndex::open(&db_path).unwrap();
+        assert_eq!(index.get_schema_version().unwrap(), SCHEMA_VERSION);
+
+        let refs = index.find_references("old").unwrap();
+        assert_eq!(refs[0].kind, ReferenceKind::Unknown);
+    }
+
+    #[test]
+    fn test_find_references_matches_qualified_suffix() {
+        let index = SqliteIndex::in_memory().unwrap();
+        for (i, name) in ["User", "Models.User", "app::User", "Users", "User.name"]
+            .iter()
+            .enumerate()
+        {
+            let reference = Reference {
+                name: name.to_string(),
+                location: Location::new(PathBuf::from("a.py"), i as u32 + 1, 1),
+                kind: ReferenceKind::Unknown,
+                target: None,
+                arguments: None,
+            };
+            index
+                .insert_reference(Path::new("a.py"), &reference)
+                .unwrap();
+        }
+
+        let names = |query: &str| -> Vec<String> {
+            let mut names: Vec<String> = index
+                .find_references(query)
+                .unwrap()
+                .into_iter()
+                .map(|r| r.name)
+                .collect();
+            names.sort();
+            names
+        };
+        assert_eq!(names("User"), vec!["Models.User", "User", "app::User"]);
+        assert_eq!(names("Models.User"), vec!["Models.User"]);
+        assert_eq!(names("name"), vec!["User.name"]);
+    }
+
+    #[test]
+    fn test_migrate_v6_backfills_reference_short_names() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let db_path = temp_dir.path().join("test.db");
+        drop(SqliteIndex::create(&db_path).unwrap());
+
+        let conn = Connection::open(&db_path).unwrap();
+        conn.execute_batch(
+            "DROP INDEX idx_refs_short_name;
+             ALTER TABLE refs DROP COLUMN short_name;
+             UPDATE metadata SET value = '6' WHERE key = 'schema_version';
+             INSERT INTO refs (name, file, line, column) VALUES ('Models.User', 'a.py', 1, 1);",
+        )
+        .unwrap();
+        drop(conn);
+
+        let index = SqliteIndex::open(&db_path).unwrap();
+        assert_eq!(index.get_schema_version().unwrap(), SCHEMA_VERSION);
+        assert_eq!(index.find_references("User").unwrap().len(), 1);
+    }
+
+    #[test]
+    fn test_migrate_v7_adds_call_overloads() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let db_path = temp_dir.path().join("test.db");
+        drop(SqliteIndex::create(&db_path).unwrap());
+
+        let conn = Connection::open(&db_path).unwrap();
+        conn.execute_batch(
+            "ALTER TABLE calls DROP COLUMN overload;
+             UPDATE metadata SET value = '7' WHERE key = 'schema_version';",
+        )
+        .unwrap();
+        drop(conn);
+
+        let index = SqliteIndex::open(&db_path).unwrap();
+        assert_eq!(index.get_schema_version().unwrap(), SCHEMA_VERSION);
+        let site = CallSite {
+            overload: Some("void log(String s)".to_string()),
+            ..call("Main.run", "Main.log", 3, CallKind::Direct)
+        };
+        index.insert_calls(&[site]).unwrap();
+        assert_eq!(
+            index.callees("Main.run").unwrap()[0].overload.as_deref(),
+            Some("void log(String s)")
+        );
+    }
+
+    // =========================================================================
+    // Call Edge Tests
+    // =========================================================================
+
+    fn call(caller: &str, callee: &str, line: u32, kind: CallKind) -> CallSite {
+        CallSite {
+            caller: caller.to_string(),
+            callee: callee.to_string(),
+            location: Location::new(PathBuf::from("main.go"), line, 2),
+            kind,
+            overload: None,
+            candidates: Vec::new(),
+        }
+    }
+
+    #[test]
+    fn test_callers_and_callees() {
+        let index = SqliteIndex::in_memory().unwrap();
+        index
+            .insert_calls(&[
+                call("main.run", "main.save", 12, CallKind::Direct),
+                call("main.run", "main.save", 5, CallKind::Direct),
+                call("main.run", "main.load", 8, CallKind::Possible),
+                call("main.main", "main.save", 20, CallKind::Direct),
+            ])
+            .unwrap();
+        assert_eq!(index.count_calls().unwrap(), 4);
+
+        // One site per caller, the first by location
+        let callers = index.callers("main.save").unwrap();
+        assert_eq!(callers.len(), 2);
+        assert_eq!(callers[0].caller, "main.run");
+        assert_eq!(callers[0].location.line, 5);
+        assert_eq!(callers[1].caller, "main.main");
+
+        let callees = index.callees("main.run").unwrap();
+        let names: Vec<&str> = callees.iter().map(|c| c.callee.as_str()).collect();
+        assert_eq!(names, vec!["main.save", "main.load"]);
+        assert_eq!(callees[1].kind, CallKind::Possible);
+
+        assert_eq!(index.delete_calls_in_file(Path::new("main.go")).unwrap(), 4);
+        assert!(index.callers("main.save").unwrap().is_empty());
+    }
+
+    #[test]
+    fn test_call_overloads_round_trip() {
+        let index = SqliteIndex::in_memory().unwrap();
+        let overloaded = CallSite {
+            overload: Some("int add(int a, int b)".to_string()),
+            ..call("Calc.run", "Calc.add", 3, CallKind::Direct)
+        };
+        index
+            .insert_calls(&[
+                overloaded,
+                call("Calc.run", "Calc.log", 4, CallKind::Direct),
+            ])
+            .unwrap();
+
+        let callees = index.callees("Calc.run").unwrap();
+        assert_eq!(
+            callees[0].overload.as_deref(),
+            Some("int add(int a, int b)")
+        );
+        assert_eq!(callees[1].overload, None);
+    }
+
+    #[test]
+    fn test_migrate_v8_adds_call_candidates() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let db_path = temp_dir.path().join("test.db");
+        drop(SqliteIndex::create(&db_path).unwrap());
+
+        let conn = Connection::open(&db_path).unwrap();
+        conn.execute_batch(
+            "ALTER TABLE calls DROP COLUMN candidates;
+             UPDATE metadata SET value = '8' WHERE key = 'schema_version';",
+        )
+        .unwrap();
+        drop(conn);
+
+        let index = SqliteIndex::open(&db_path).unwrap();
+        assert_eq!(index.get_schema_version().unwrap(), SCHEMA_VERSION);
+        let ambiguous = CallSite {
+            candidates: vec![
+                "void log(int n)".to_string(),
+                "void log(long n)".to_string(),
+            ],
+            ..call("Main.run", "Main.log", 3, CallKind::Direct)
+        };
+        index
+            .insert_calls(&[
+                ambiguous,
+                call("Main.run", "Main.save", 4, CallKind::Direct),
+            ])
+            .unwrap();
+
+        let callees = index.callees("Main.run").unwrap();
+        assert_eq!(
+            callees[0].candidates,
+            ["void log(int n)", "void log(long n)"]
+        );
+        assert!(callees[1].candidates.is_empty());
+    }
+
+    #[test]
+    fn test_migrate_v9_adds_symbol_extents() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let db_path = temp_dir.path().join("test.db");
+        drop(SqliteIndex::create(&db_path).unwrap());
+
+        let conn = Connection::open(&db_path).unwrap();
+        conn.execute_batch(
+            "ALTER TABLE symbols DROP COLUMN extent_end_line;
+             ALTER TABLE symbols DROP COLUMN extent_line;
+             UPDATE metadata SET value = '9' WHERE key = 'schema_version';",
+        )
+        .unwrap();
+        drop(conn);
+
+        let index = SqliteIndex::open(&db_path).unwrap();
+        assert_eq!(index.get_schema_version().unwrap(), SCHEMA_VERSION);
+        index
+            .insert_symbol(&make_symbol("User", "models.User", "models.go", 3).with_extent(3, 6))
+            .unwrap();
+        index
+            .insert_symbol(&make_symbol("main", "main.main", "main.go", 1))
+            .unwrap();
+
+        let user = index.find_by_qualified("models.User").unwrap().unwrap();
+        assert_eq!(
+            user.extent,
+            Some(Extent {
+                line: 3,
+                end_line: 6
+            })
+        );
+        assert_eq!(user.lines(), (3, 6));
+        let main = index.find_by_qualified("main.main").unwrap().unwrap();
+        assert_eq!(main.extent, None);
+    }
+
+    #[test]
+    fn test_migrate_v10_adds_reference_targets_and_arguments() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let db_path = temp_dir.path().join("test.db");
+        drop(SqliteIndex::create(&db_path).unwrap());
+
+        let conn = Connection::open(&db_path).unwrap();
+        conn.execute_batch(
+            "ALTER TABLE refs DROP COLUMN arguments;
+             ALTER TABLE refs DROP COLUMN target;
+             UPDATE metadata SET value = '10' WHERE key = 'schema_version';
+             INSERT INTO refs (name, short_name, file, line, column) VALUES ('old', 'old', 'a.go', 1, 1);",
+        )
+        .unwrap();
+        drop(conn);
+
+        let index = SqliteIndex::open(&db_path).unwrap();
+        assert_eq!(index.get_schema_version().unwrap(), SCHEMA_VERSION);
+        let aliased = Reference {
+            name: "h.Get".to_string(),
+            location: Location::new(PathBuf::from("a.go"), 5, 2),
+            kind: ReferenceKind::Call,
+            target: Some("net/http.Get".to_string()),
+            arguments: Some(vec![Some("string".to_string()), None]),
+        };
+        index.insert_reference(Path::new("a.go"), &aliased).unwrap();
+
+        let refs = index.references_in_file(Path::new("a.go")).unwrap();
+        assert_eq!(refs.len(), 2);
+        assert_eq!(refs[0].target, None);
+        assert_eq!(refs[1].target.as_deref(), Some("net/http.Get"));
+        assert_eq!(refs[0].arguments, None);
+        assert_eq!(
+            refs[1].arguments,
+            Some(vec![Some("string".to_string()), None])
+        );
+        let found = index.find_references("h.Get").unwrap();
+        assert_eq!(found[0].target.as_deref(), Some("net/http.Get"));
     }
 
     // =========================================================================
@@ -2108,6 +2768,9 @@ mod tests {
                     &Reference {
                         name: "UserService".to_string(),
                         location: Location::new(PathBuf::from(file), line, 1),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     },
                 )
                 .unwrap();
@@ -2121,6 +2784,9 @@ mod tests {
                     &Reference {
                         name: "HelperUtils".to_string(),
                         location: Location::new(PathBuf::from("src/utils.rs"), line, 1),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     },
                 )
                 .unwrap();
@@ -2177,6 +2843,9 @@ mod tests {
                     &Reference {
                         name: "User".to_string(),
                         location: Location::new(PathBuf::from(file), 5, 1),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     },
                 )
                 .unwrap();
@@ -2189,6 +2858,9 @@ mod tests {
                 &Reference {
                     name: "User".to_string(),
                     location: Location::new(PathBuf::from("src/main.rs"), 10, 1),
+                    kind: ReferenceKind::Unknown,
+                    target: None,
+                    arguments: None,
                 },
             )
             .unwrap();
@@ -2316,6 +2988,9 @@ mod tests {
                 &Reference {
                     name: "bar".to_string(),
                     location: Location::new(PathBuf::from("src/Test.fs"), 5, 1),
+                    kind: ReferenceKind::Unknown,
+                    target: None,
+                    arguments: None,
                 },
             )
             .unwrap();
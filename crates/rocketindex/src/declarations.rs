@@ -0,0 +1,380 @@
+//! Linking C and C++ declarations to their definitions.
+//!
+//! A function declared in a header (`int add(int a, int b);`) and defined in
+//! a source file is indexed twice under one qualified name: the parser marks
+//! the prototype with the [`DECLARATION`] attribute and gives both symbols
+//! their signature. Methods declared in a class body and defined out of line
+//! (`void Shape::draw() { ... }`) are linked the same way, since the parser
+//! qualifies the definition by its scope.
+//!
+//! [`CodeIndex::definition_of`] finds the definition a declaration belongs
+//! to, and [`CodeIndex::merged_symbol`] folds the two into one symbol: the
+//! definition's location and signature, with the declaration's doc comment
+//! and visibility where the definition has none (headers carry the docs,
+//! and only the class body knows a method's access). The call graph treats a
+//! call that resolves to both as one call to the definition.
+//!
+//! Function-like macros are marked with [`MACRO`]. Their expansion is not
+//! known, so calls to them are reported as unresolved rather than as calls.
+//!
+//! # Examples
+//!
+//! ```
+//! use rocketindex::declarations::DECLARATION;
+//! use rocketindex::{CodeIndex, Location, Symbol, SymbolKind, Visibility};
+//! use std::path::PathBuf;
+//!
+//! let add = |file: &str, signature: &str| {
+//!     Symbol::new(
+//!         "add".to_string(),
+//!         "add".to_string(),
+//!         SymbolKind::Function,
+//!         Location::new(PathBuf::from(file), 3, 5),
+//!         Visibility::Public,
+//!         "c".to_string(),
+//!     )
+//!     .with_signature(Some(signature.to_string()))
+//! };
+//!
+//! let mut index = CodeIndex::new();
+//! index.add_symbol(
+//!     add("math.h", "int add(int a, int b)")
+//!         .with_attributes(Some(vec![DECLARATION.to_string()]))
+//!         .with_doc(Some("Adds two numbers.".to_string())),
+//! );
+//! index.add_symbol(add("math.c", "int add(int x, int y)"));
+//!
+//! let declaration = &index.get_all("add")[0];
+//! assert!(declaration.is_declaration());
+//! let merged = index.merged_symbol(declaration);
+//! assert_eq!(merged.location.file, PathBuf::from("math.c"));
+//! assert_eq!(merged.doc.as_deref(), Some("Adds two numbers."));
+//! ```
+
+use crate::{CodeIndex, Symbol, Visibility};
+
+/// Attribute marking a declaration without a body (a function prototype).
+pub const DECLARATION: &str = "declaration";
+
+/// Attribute marking a function-like preprocessor macro.
+pub const MACRO: &str = "macro";
+
+impl Symbol {
+    /// Whether this is a declaration whose definition is elsewhere.
+    #[must_use]
+    pub fn is_declaration(&self) -> bool {
+        self.has_attribute(DECLARATION)
+    }
+
+    /// Whether this is a function-like macro.
+    #[must_use]
+    pub fn is_macro(&self) -> bool {
+        self.has_attribute(MACRO)
+    }
+
+    pub(crate) fn has_attribute(&self, attribute: &str) -> bool {
+        self.attributes
+            .as_ref()
+            .is_some_and(|attributes| attributes.iter().any(|a| a == attribute))
+    }
+}
+
+impl CodeIndex {
+    /// The definition `declaration` declares, if it is indexed.
+    ///
+    /// The definition has the same qualified name and kind. Among overloads
+    /// it is the one with the same signature text, or failing that the only
+    /// one with the same parameter types, or the only one with as many
+    /// parameters. A `static` declaration only matches a definition in its
+    /// own file.
+    #[must_use]
+    pub fn definition_of(&self, declaration: &Symbol) -> Option<&Symbol> {
+        if !declaration.is_declaration() {
+            return None;
+        }
+        let definitions: Vec<&Symbol> = self
+            .get_all(&declaration.qualified)
+            .iter()
+            .filter(|s| s.kind == declaration.kind && !s.is_declaration())
+            .filter(|s| {
+                declaration.visibility != Visibility::Private
+                    || s.location.file == declaration.location.file
+            })
+            .collect();
+
+        let signature = declaration.signature.as_deref().map(normalize);
+        if let Some(same) = definitions
+            .iter()
+            .find(|s| signature.is_some() && s.signature.as_deref().map(normalize) == signature)
+        {
+            return Some(same);
+        }
+
+        // Parameter names may differ, or be left out of the declaration
+        let types = declaration.signature.as_deref().and_then(parameter_types);
+        let types_of = |s: &Symbol| s.signature.as_deref().and_then(parameter_types);
+        let same_types: Vec<&Symbol> = definitions
+            .iter()
+            .copied()
+            .filter(|s| types.is_some() && types_of(s) == types)
+            .collect();
+        if let [definition] = same_types[..] {
+            return Some(definition);
+        }
+
+        let arity = types.as_ref().map(Vec::len);
+        let same_arity: Vec<&Symbol> = definitions
+            .into_iter()
+            .filter(|s| arity.is_none() || types_of(s).map(|t| t.len()) == arity)
+            .collect();
+        match same_arity[..] {
+            [definition] => Some(definition),
+            _ => None,
+        }
+    }
+
+    /// The declarations whose definition is `definition`.
+    #[must_use]
+    pub fn declarations_of(&self, definition: &Symbol) -> Vec<&Symbol> {
+        self.get_all(&definition.qualified)
+            .iter()
+            .filter(|s| {
+                self.definition_of(s)
+                    .is_some_and(|d| std::ptr::eq(d, definition))
+            })
+            .collect()
+    }
+
+    /// `symbol` merged with its declaration or definition.
+    ///
+    /// For a declaration with a known definition, or a definition with
+    /// declarations, this is the definition with the doc comment of the
+    /// first declaration that has one when the definition has none, and the
+    /// visibility of a declaration nested in a type (the class body decides
+    /// a method's access). Any other symbol is returned as is.
+    #[must_use]
+    pub fn merged_symbol(&self, symbol: &Symbol) -> Symbol {
+        let definition = self.definition_of(symbol).unwrap_or(symbol);
+        let mut merged = definition.clone();
+        for declaration in self.declarations_of(definition) {
+            if merged.doc.is_none() {
+                merged.doc.clone_from(&declaration.doc);
+            }
+            if declaration.parent.is_some() {
+                merged.visibility = declaration.visibility;
+                merged.parent.clone_from(&declaration.parent);
+            }
+        }
+        merged
+    }
+
+    /// Replace each declaration in `symbols` by its definition, keeping each
+    /// symbol once, in first-seen order.
+    pub(crate) fn prefer_definitions<'a>(&'a self, symbols: Vec<&'a Symbol>) -> Vec<&'a Symbol> {
+        let mut preferred: Vec<&Symbol> = Vec::with_capacity(symbols.len());
+        for symbol in symbols {
+            let symbol = self.definition_of(symbol).unwrap_or(symbol);
+            if !preferred.iter().any(|s| std::ptr::eq(*s, symbol)) {
+                preferred.push(symbol);
+            }
+        }
+        preferred
+    }
+}
+
+/// Collapse whitespace so `int  add(int a,int b)` matches `int add(int a, int b)`.
+fn normalize(signature: &str) -> String {
+    let mut normalized = String::with_capacity(signature.len());
+    for token in signature.split_whitespace() {
+        if !normalized.is_empty() {
+            normalized.push(' ');
+        }
+        normalized.push_str(token);
+    }
+    normalized.replace(", ", ",").replace(" ,", ",")
+}
+
+/// Parameter types of a C-like signature's first parameter list, with
+/// parameter names dropped: `(const char *name, int)` gives `const char *`
+/// and `int`. `()` and `(void)` have none.
+fn parameter_types(signature: &str) -> Option<Vec<String>> {
+    let open = signature.find('(')?;
+    let mut depth = 0usize;
+    let mut params = Vec::new();
+    let mut start = open + 1;
+    for (i, c) in signature.char_indices().skip_while(|&(i, _)| i < open) {
+        match c {
+            '(' | '<' | '[' => depth += 1,
+            ')' | '>' | ']' => {
+                depth = depth.checked_sub(1)?;
+                if depth == 0 {
+                    params.push(&signature[start..i]);
+                    break;
+                }
+            }
+            ',' if depth == 1 => {
+                params.push(&signature[start..i]);
+                start = i + 1;
+            }
+            _ => {}
+        }
+    }
+    if depth != 0 {
+        return None;
+    }
+    if let [only] = params[..] {
+        if only.trim().is_empty() || only.trim() == "void" {
+            return Some(Vec::new());
+        }
+    }
+    Some(params.into_iter().map(parameter_type).collect())
+}
+
+/// A parameter without its name: the last word is a name if something other
+/// than a type keyword comes before it.
+fn parameter_type(param: &str) -> String {
+    let param = param.split('=').next().unwrap_or(param);
+    let spaced = param.replace('*', " * ").replace('&', " & ");
+    let mut tokens: Vec<&str> = spaced.split_whitespace().collect();
+    if let [.., before, last] = tokens[..] {
+        let is_name = last.chars().all(|c| c.is_alphanumeric() || c == '_')
+            && !TYPE_KEYWORDS.contains(&last)
+            && !matches!(before, "::" | "struct" | "enum" | "union" | "class");
+        if is_name {
+            tokens.pop();
+        }
+    }
+    normalize(&tokens.join(" "))
+}
+
+/// Words that end a type rather than name a parameter (`unsigned int`).
+const TYPE_KEYWORDS: &[&str] = &[
+    "char", "short", "int", "long", "float", "double", "signed", "unsigned", "bool", "const",
+    "volatile",
+];
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::{Location, SymbolKind};
+    use std::path::PathBuf;
+
+    fn function(qualified: &str, file: &str, line: u32, signature: &str) -> Symbol {
+        let name = qualified.rsplit("::").next().unwrap();
+        Symbol::new(
+            name.to_string(),
+            qualified.to_string(),
+            SymbolKind::Function,
+            Location::new(PathBuf::from(file), line, 5),
+            Visibility::Public,
+            "cpp".to_string(),
+        )
+        .with_signature(Some(signature.to_string()))
+    }
+
+    fn declaration(qualified: &str, file: &str, line: u32, signature: &str) -> Symbol {
+        function(qualified, file, line, signature)
+            .with_attributes(Some(vec![DECLARATION.to_string()]))
+    }
+
+    #[test]
+    fn test_parameter_types_drop_names() {
+        let types = |signature: &str| parameter_types(signature).unwrap();
+        assert!(types("int f()").is_empty());
+        assert!(types("int f(void)").is_empty());
+        assert_eq!(types("int f(int a, unsigned int)"), ["int", "unsigned int"]);
+        assert_eq!(
+            types("void f(const char *name, struct point p, Shape &)"),
+            ["const char *", "struct point", "Shape &"]
+        );
+        assert_eq!(
+            types("void f(std::map<int, int> m, int count = 0)"),
+            ["std::map<int,int>", "int"]
+        );
+    }
+
+    #[test]
+    fn test_declarations_link_to_matching_overloads() {
+        let mut index = CodeIndex::new();
+        for symbol in [
+            declaration("math::add", "math.h", 3, "int add(int a, int b)"),
+            declaration("math::add", "math.h", 4, "double add(double, double)"),
+            declaration("math::add", "math.h", 5, "long add(long a, long b)"),
+            declaration("math::scale", "math.h", 6, "int scale(int factor)"),
+            function("math::add", "math.cpp", 10, "int add(int a, int b)"),
+            function(
+                "math::add",
+                "math.cpp",
+                14,
+                "double add(double x, double y)",
+            ),
+            function("math::add", "math.cpp", 18, "int add(int a, int b, int c)"),
+        ] {
+            index.add_symbol(symbol);
+        }
+
+        let line_of = |symbol: Option<&Symbol>| symbol.map(|s| s.location.line);
+        let declarations = index.get_all("math::add");
+        // Same text
+        assert_eq!(line_of(index.definition_of(&declarations[0])), Some(10));
+        // Same parameter types, named differently
+        assert_eq!(line_of(index.definition_of(&declarations[1])), Some(14));
+        // Two definitions take two parameters, neither of them longs
+        assert_eq!(line_of(index.definition_of(&declarations[2])), None);
+        // A definition is not a declaration of anything
+        assert_eq!(line_of(index.definition_of(&declarations[3])), None);
+        // Declared but never defined
+        let scale = index.get("math::scale").unwrap();
+        assert_eq!(line_of(index.definition_of(scale)), None);
+
+        let definition = &index.get_all("math::add")[3];
+        assert_eq!(definition.location.line, 10);
+        let declared: Vec<u32> = index
+            .declarations_of(definition)
+            .iter()
+            .map(|s| s.location.line)
+            .collect();
+        assert_eq!(declared, vec![3]);
+    }
+
+    #[test]
+    fn test_static_declarations_stay_in_their_file() {
+        let mut index = CodeIndex::new();
+        let mut static_declaration = declaration("helper", "a.c", 1, "static int helper(void)");
+        static_declaration.visibility = Visibility::Private;
+        index.add_symbol(static_declaration);
+        let mut other = function("helper", "b.c", 3, "static int helper(void)");
+        other.visibility = Visibility::Private;
+        index.add_symbol(other);
+
+        assert!(index.definition_of(&index.get_all("helper")[0]).is_none());
+    }
+
+    #[test]
+    fn test_merged_method_keeps_class_access_and_header_docs() {
+        let mut index = CodeIndex::new();
+        let mut in_class = declaration("Shape::draw", "shape.h", 4, "void draw()")
+            .with_parent(Some("Shape".to_string()))
+            .with_doc(Some("Draws the shape.".to_string()));
+        in_class.visibility = Visibility::Protected;
+        index.add_symbol(in_class);
+        index.add_symbol(function(
+            "Shape::draw",
+            "shape.cpp",
+            12,
+            "void Shape::draw()",
+        ));
+
+        let merged = index.merged_symbol(&index.get_all("Shape::draw")[0]);
+        assert_eq!(merged.location.file, PathBuf::from("shape.cpp"));
+        assert_eq!(merged.visibility, Visibility::Protected);
+        assert_eq!(merged.parent.as_deref(), Some("Shape"));
+        assert_eq!(merged.doc.as_deref(), Some("Draws the shape."));
+        assert!(!merged.is_declaration());
+
+        let both = index.get_all("Shape::draw").iter().collect();
+        let preferred = index.prefer_definitions(both);
+        assert_eq!(preferred.len(), 1);
+        assert_eq!(preferred[0].location.line, 12);
+    }
+}
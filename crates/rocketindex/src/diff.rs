@@ -0,0 +1,597 @@
+//! Symbol-level diff between two git revisions.
+//!
+//! [`diff_revisions`] indexes the supported source files of two revisions
+//! (read straight from git objects, so the working tree is never touched)
+//! and reports which symbols were added, removed, modified, or moved.
+//!
+//! - A symbol is **modified** when the text of its definition (its body hash)
+//!   differs between the revisions.
+//! - A symbol is **moved** when it disappears in one place and reappears in
+//!   another with an identical body, or with the same name, kind, and a
+//!   near-identical signature. Moving `ProcessPayment` to another file is
+//!   reported once as a move, not as a remove plus an add.
+//!
+//! Only files whose blob changed between the revisions are parsed.
+//! Changes are grouped by file (the new location, or the old one for
+//! removed symbols) and ordered by line.
+
+use std::collections::hash_map::DefaultHasher;
+use std::collections::{HashMap, HashSet};
+use std::hash::{Hash, Hasher};
+use std::io::{BufRead, BufReader, Read, Write};
+use std::path::{Path, PathBuf};
+use std::process::{Command, Stdio};
+
+use anyhow::{bail, Context, Result};
+use serde::Serialize;
+
+use crate::fuzzy::levenshtein_distance;
+use crate::{Location, Symbol, SymbolKind};
+
+/// Minimum signature similarity (0.0-1.0) for pairing an edited, moved symbol.
+const SIGNATURE_SIMILARITY_THRESHOLD: f64 = 0.8;
+
+/// How a symbol changed between two revisions.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Serialize)]
+#[serde(rename_all = "lowercase")]
+pub enum ChangeKind {
+    /// Only present in the new revision
+    Added,
+    /// Only present in the old revision
+    Removed,
+    /// Present in both, with a different body
+    Modified,
+    /// Present in both, at a different file or qualified name
+    Moved,
+}
+
+/// A single symbol change.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct SymbolChange {
+    /// What happened to the symbol
+    pub change: ChangeKind,
+    /// Qualified name (in the new revision, or the old one for removals)
+    pub qualified: String,
+    /// Symbol kind
+    pub kind: SymbolKind,
+    /// Location (in the new revision, or the old one for removals)
+    pub location: Location,
+    /// Qualified name in the old revision, if it differs
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub old_qualified: Option<String>,
+    /// Location in the old revision (modified and moved symbols)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub old_location: Option<Location>,
+}
+
+/// Changes within one file.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct FileChanges {
+    /// File path relative to the repository root
+    pub file: PathBuf,
+    /// Changes in this file, ordered by line
+    pub changes: Vec<SymbolChange>,
+}
+
+/// Result of [`diff_revisions`].
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct RevisionDiff {
+    /// Old revision as given
+    pub old_rev: String,
+    /// New revision as given
+    pub new_rev: String,
+    /// Changes grouped by file, ordered by path
+    pub files: Vec<FileChanges>,
+}
+
+impl RevisionDiff {
+    /// Check if no symbol changed.
+    pub fn is_empty(&self) -> bool {
+        self.files.is_empty()
+    }
+
+    /// All changes across files.
+    pub fn changes(&self) -> impl Iterator<Item = &SymbolChange> {
+        self.files.iter().flat_map(|f| f.changes.iter())
+    }
+}
+
+/// A parsed symbol together with the hash of its definition text.
+#[derive(Debug, Clone)]
+struct HashedSymbol {
+    symbol: Symbol,
+    body_hash: u64,
+}
+
+/// Compare the symbols defined at `old_rev` and `new_rev` in the repository at `repo`.
+///
+/// Revisions can be anything `git rev-parse` accepts (`HEAD~1`, a branch,
+/// a tag, a commit hash).
+pub fn diff_revisions(
+    repo: &Path,
+    old_rev: &str,
+    new_rev: &str,
+    max_depth: usize,
+) -> Result<RevisionDiff> {
+    let old_tree = list_tree(repo, old_rev)?;
+    let new_tree = list_tree(repo, new_rev)?;
+
+    let old_changed: Vec<(&PathBuf, &String)> = old_tree
+        .iter()
+        .filter(|(path, blob)| new_tree.get(*path) != Some(*blob))
+        .collect();
+    let new_changed: Vec<(&PathBuf, &String)> = new_tree
+        .iter()
+        .filter(|(path, blob)| old_tree.get(*path) != Some(*blob))
+        .collect();
+
+    let old_symbols = parse_blobs(repo, &old_changed, max_depth)?;
+    let new_symbols = parse_blobs(repo, &new_changed, max_depth)?;
+
+    Ok(RevisionDiff {
+        old_rev: old_rev.to_string(),
+        new_rev: new_rev.to_string(),
+        files: group_by_file(diff_symbols(old_symbols, new_symbols)),
+    })
+}
+
+/// List supported source files at `rev` as path -> blob id.
+fn list_tree(repo: &Path, rev: &str) -> Result<HashMap<PathBuf, String>> {
+    let output = Command::new("git")
+        .arg("-C")
+        .arg(repo)
+        .args(["ls-tree", "-r", "-z", rev])
+        .output()
+        .context("Failed to run git ls-tree")?;
+    if !output.status.success() {
+        bail!(
+            "git ls-tree {} failed: {}",
+            rev,
+            String::from_utf8_lossy(&output.stderr).trim()
+        );
+    }
+
+    let stdout = String::from_utf8_lossy(&output.stdout);
+    let mut files = HashMap::new();
+    // Each entry: "<mode> <type> <object>\t<path>\0"
+    for entry in stdout.split('\0').filter(|e| !e.is_empty()) {
+        let Some((meta, path)) = entry.split_once('\t') else {
+            continue;
+        };
+        let mut fields = meta.split_whitespace();
+        let (Some(_mode), Some("blob"), Some(object)) =
+            (fields.next(), fields.next(), fields.next())
+        else {
+            continue;
+        };
+        let path = PathBuf::from(path);
+        if crate::watch::is_supported_file(&path) {
+            files.insert(path, object.to_string());
+        }
+    }
+    Ok(files)
+}
+
+/// Read blobs with a single `git cat-file --batch` and parse them.
+fn parse_blobs(
+    repo: &Path,
+    files: &[(&PathBuf, &String)],
+    max_depth: usize,
+) -> Result<Vec<HashedSymbol>> {
+    if files.is_empty() {
+        return Ok(Vec::new());
+    }
+
+    let mut child = Command::new("git")
+        .arg("-C")
+        .arg(repo)
+        .args(["cat-file", "--batch"])
+        .stdin(Stdio::piped())
+        .stdout(Stdio::piped())
+        .spawn()
+        .context("Failed to run git cat-file")?;
+
+    let mut stdin = child.stdin.take().context("git cat-file has no stdin")?;
+    let requests: String = files
+        .iter()
+        .map(|(_, blob)| format!("{}\n", blob))
+        .collect();
+    // Write from a separate thread so a full stdout pipe cannot deadlock us
+    let writer = std::thread::spawn(move || stdin.write_all(requests.as_bytes()));
+
+    let mut reader = BufReader::new(child.stdout.take().context("git cat-file has no stdout")?);
+    let mut symbols = Vec::new();
+    for (path, blob) in files {
+        let mut header = String::new();
+        reader.read_line(&mut header)?;
+        // "<object> blob <size>" or "<object> missing"
+        let size: usize = header
+            .split_whitespace()
+            .nth(2)
+            .and_then(|s| s.parse().ok())
+            .with_context(|| format!("Unexpected git cat-file output for {}: {}", blob, header))?;
+
+        let mut content = vec![0; size + 1]; // trailing newline after each object
+        reader.read_exact(&mut content)?;
+        content.truncate(size);
+
+        let source = String::from_utf8_lossy(&content);
+        symbols.extend(hash_symbols(path, &source, max_depth));
+    }
+
+    writer
+        .join()
+        .map_err(|_| anyhow::anyhow!("git cat-file writer panicked"))??;
+    child.wait()?;
+    Ok(symbols)
+}
+
+/// Parse `source` and hash the definition text of each symbol.
+fn hash_symbols(path: &Path, source: &str, max_depth: usize) -> Vec<HashedSymbol> {
+    let lines: Vec<&str> = source.lines().collect();
+    crate::extract_symbols(path, source, max_depth)
+        .symbols
+        .into_iter()
+        .map(|symbol| {
+            let body_hash = body_hash(&lines, symbol.lines());
+            HashedSymbol { symbol, body_hash }
+        })
+        .collect()
+}
+
+/// Hash the `(line, end_line)` span of a definition, ignoring indentation
+/// so that re-nesting or moving code between files keeps the same hash.
+fn body_hash(lines: &[&str], (line, end_line): (u32, u32)) -> u64 {
+    let start = (line as usize).saturating_sub(1);
+    let end = (end_line.max(line) as usize).min(lines.len());
+
+    let mut hasher = DefaultHasher::new();
+    for line in lines.get(start..end).unwrap_or_default() {
+        line.trim().hash(&mut hasher);
+    }
+    hasher.finish()
+}
+
+/// Identity of a symbol across revisions (overloads differ by signature).
+fn symbol_key(symbol: &Symbol) -> (&str, SymbolKind, Option<&str>) {
+    (
+        symbol.qualified.as_str(),
+        symbol.kind,
+        symbol.signature.as_deref(),
+    )
+}
+
+/// Pair old and new symbols and classify every difference.
+fn diff_symbols(old: Vec<HashedSymbol>, new: Vec<HashedSymbol>) -> Vec<SymbolChange> {
+    let mut changes = Vec::new();
+
+    // 1. Same qualified name, kind, and signature: modified or moved file
+    let mut old_by_key: HashMap<_, Vec<usize>> = HashMap::new();
+    for (i, entry) in old.iter().enumerate() {
+        old_by_key
+            .entry(symbol_key(&entry.symbol))
+            .or_default()
+            .push(i);
+    }
+    for indices in old_by_key.values_mut() {
+        indices.reverse(); // pop() yields source order
+    }
+
+    let mut old_matched: HashSet<usize> = HashSet::new();
+    let mut new_unmatched: Vec<usize> = Vec::new();
+    for (j, entry) in new.iter().enumerate() {
+        let matched = old_by_key
+            .get_mut(&symbol_key(&entry.symbol))
+            .and_then(|indices| indices.pop());
+        let Some(i) = matched else {
+            new_unmatched.push(j);
+            continue;
+        };
+        old_matched.insert(i);
+
+        let before = &old[i];
+        if before.body_hash != entry.body_hash {
+            changes.push(paired_change(ChangeKind::Modified, before, entry));
+        } else if before.symbol.location.file != entry.symbol.location.file {
+            changes.push(paired_change(ChangeKind::Moved, before, entry));
+        }
+    }
+
+    // 2. Remaining symbols: pair moves by body hash, then by signature similarity
+    let mut old_unmatched: Vec<usize> = (0..old.len())
+        .filter(|i| !old_matched.contains(i))
+        .collect();
+    let mut added = Vec::new();
+    for j in new_unmatched {
+        let entry = &new[j];
+        let position = old_unmatched
+            .iter()
+            .position(|&i| {
+                old[i].symbol.kind == entry.symbol.kind && old[i].body_hash == entry.body_hash
+            })
+            .or_else(|| {
+                old_unmatched
+                    .iter()
+                    .position(|&i| is_similar_signature(&old[i].symbol, &entry.symbol))
+            });
+        match position {
+            Some(p) => {
+                let i = old_unmatched.remove(p);
+                changes.push(paired_change(ChangeKind::Moved, &old[i], entry));
+            }
+            None => added.push(j),
+        }
+    }
+
+    for j in added {
+        changes.push(single_change(ChangeKind::Added, &new[j].symbol));
+    }
+    for i in old_unmatched {
+        changes.push(single_change(ChangeKind::Removed, &old[i].symbol));
+    }
+    changes
+}
+
+/// Same name and kind, and signatures that differ only slightly.
+fn is_similar_signature(old: &Symbol, new: &Symbol) -> bool {
+    if old.name != new.name || old.kind != new.kind {
+        return false;
+    }
+    match (&old.signature, &new.signature) {
+        (Some(a), Some(b)) => {
+            let longest = a.chars().count().max(b.chars().count()).max(1);
+            let similarity = 1.0 - levenshtein_distance(a, b) as f64 / longest as f64;
+            similarity >= SIGNATURE_SIMILARITY_THRESHOLD
+        }
+        _ => false,
+    }
+}
+
+fn paired_change(change: ChangeKind, old: &HashedSymbol, new: &HashedSymbol) -> SymbolChange {
+    SymbolChange {
+        change,
+        qualified: new.symbol.qualified.clone(),
+        kind: new.symbol.kind,
+        location: new.symbol.location.clone(),
+        old_qualified: (old.symbol.qualified != new.symbol.qualified)
+            .then(|| old.symbol.qualified.clone()),
+        old_location: Some(old.symbol.location.clone()),
+    }
+}
+
+fn single_change(change: ChangeKind, symbol: &Symbol) -> SymbolChange {
+    SymbolChange {
+        change,
+        qualified: symbol.qualified.clone(),
+        kind: symbol.kind,
+        location: symbol.location.clone(),
+        old_qualified: None,
+        old_location: None,
+    }
+}
+
+fn group_by_file(changes: Vec<SymbolChange>) -> Vec<FileChanges> {
+    let mut by_file: HashMap<PathBuf, Vec<SymbolChange>> = HashMap::new();
+    for change in changes {
+        by_file
+            .entry(change.location.file.clone())
+            .or_default()
+            .push(change);
+    }
+
+    let mut files: Vec<FileChanges> = by_file
+        .into_iter()
+        .map(|(file, mut changes)| {
+            changes.sort_by(|a, b| {
+                a.location
+                    .line
+                    .cmp(&b.location.line)
+                    .then(a.location.column.cmp(&b.location.column))
+                    .then(a.change.cmp(&b.change))
+                    .then_with(|| a.qualified.cmp(&b.qualified))
+            });
+            FileChanges { file, changes }
+        })
+        .collect();
+    files.sort_by(|a, b| a.file.cmp(&b.file));
+    files
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::Visibility;
+    use std::fs;
+
+    fn hashed(qualified: &str, file: &str, line: u32, body: &str) -> HashedSymbol {
+        let name = qualified.rsplit('.').next().unwrap();
+        let lines: Vec<&str> = body.lines().collect();
+        let span = lines.len() as u32;
+        // Hash the body as if it were the whole file, then place it at `line`
+        let body_hash = body_hash(&lines, (1, span));
+        let location = Location::with_end(PathBuf::from(file), line, 1, line + span - 1, 1);
+        HashedSymbol {
+            symbol: Symbol::new(
+                name.to_string(),
+                qualified.to_string(),
+                SymbolKind::Function,
+                location,
+                Visibility::Public,
+                "go".to_string(),
+            )
+            .with_signature(Some(format!("func {}()", name))),
+            body_hash,
+        }
+    }
+
+    fn summary(changes: &[SymbolChange]) -> Vec<(ChangeKind, &str, Option<&str>)> {
+        let mut summary: Vec<_> = changes
+            .iter()
+            .map(|c| (c.change, c.qualified.as_str(), c.old_qualified.as_deref()))
+            .collect();
+        summary.sort();
+        summary
+    }
+
+    #[test]
+    fn test_diff_symbols_classifies_changes() {
+        let old = vec![
+            hashed("main.Keep", "main.go", 1, "func Keep() {}"),
+            hashed("main.Edit", "main.go", 3, "func Edit() { a() }"),
+            hashed("main.Gone", "main.go", 5, "func Gone() {}"),
+        ];
+        let new = vec![
+            hashed("main.Keep", "main.go", 1, "func Keep() {}"),
+            hashed("main.Edit", "main.go", 3, "func Edit() { b() }"),
+            hashed("main.Fresh", "main.go", 5, "func Fresh() {}"),
+        ];
+
+        let changes = diff_symbols(old, new);
+        assert_eq!(
+            summary(&changes),
+            vec![
+                (ChangeKind::Added, "main.Fresh", None),
+                (ChangeKind::Removed, "main.Gone", None),
+                (ChangeKind::Modified, "main.Edit", None),
+            ]
+        );
+    }
+
+    #[test]
+    fn test_diff_symbols_detects_moves() {
+        let body = "func ProcessPayment() {\n    charge()\n}";
+        // Same qualified name, different file (Go package move within a package)
+        let old = vec![hashed("billing.ProcessPayment", "billing/a.go", 10, body)];
+        let new = vec![hashed("billing.ProcessPayment", "billing/b.go", 2, body)];
+        let changes = diff_symbols(old, new);
+        assert_eq!(changes.len(), 1);
+        assert_eq!(changes[0].change, ChangeKind::Moved);
+        assert_eq!(
+            changes[0].old_location.as_ref().unwrap().file,
+            PathBuf::from("billing/a.go")
+        );
+
+        // Different qualified name (moved to another module), identical body
+        let old = vec![hashed("billing.ProcessPayment", "billing/pay.go", 10, body)];
+        let new = vec![hashed(
+            "payments.ProcessPayment",
+            "payments/pay.go",
+            4,
+            body,
+        )];
+        let changes = diff_symbols(old, new);
+        assert_eq!(
+            summary(&changes),
+            vec![(
+                ChangeKind::Moved,
+                "payments.ProcessPayment",
+                Some("billing.ProcessPayment")
+            )]
+        );
+
+        // Moved and edited: paired by name and signature
+        let old = vec![hashed("billing.ProcessPayment", "billing/pay.go", 10, body)];
+        let new = vec![hashed(
+            "payments.ProcessPayment",
+            "payments/pay.go",
+            4,
+            "func ProcessPayment() {\n    chargeCard()\n}",
+        )];
+        let changes = diff_symbols(old, new);
+        assert_eq!(changes.len(), 1);
+        assert_eq!(changes[0].change, ChangeKind::Moved);
+    }
+
+    #[test]
+    fn test_body_hash_ignores_indentation() {
+        let flat = body_hash(&["func A() {", "}"], (1, 2));
+        let nested = body_hash(&["    func A() {", "    }"], (1, 2));
+        let edited = body_hash(&["func A() { x }", "}"], (1, 2));
+        assert_eq!(flat, nested);
+        assert_ne!(flat, edited);
+    }
+
+    #[test]
+    fn test_group_by_file_orders_files_and_lines() {
+        let changes = vec![
+            single_change(ChangeKind::Added, &hashed("b.Two", "b.go", 9, "x").symbol),
+            single_change(ChangeKind::Added, &hashed("b.One", "b.go", 2, "x").symbol),
+            single_change(ChangeKind::Removed, &hashed("a.Old", "a.go", 5, "x").symbol),
+        ];
+        let files = group_by_file(changes);
+        let files: Vec<(&Path, Vec<&str>)> = files
+            .iter()
+            .map(|f| {
+                (
+                    f.file.as_path(),
+                    f.changes.iter().map(|c| c.qualified.as_str()).collect(),
+                )
+            })
+            .collect();
+        assert_eq!(
+            files,
+            vec![
+                (Path::new("a.go"), vec!["a.Old"]),
+                (Path::new("b.go"), vec!["b.One", "b.Two"]),
+            ]
+        );
+    }
+
+    fn git(root: &Path, args: &[&str]) {
+        let output = Command::new("git")
+            .args(args)
+            .current_dir(root)
+            .output()
+            .unwrap();
+        assert!(output.status.success(), "git {:?} failed", args);
+    }
+
+    #[test]
+    fn test_diff_revisions_between_commits() {
+        let dir = tempfile::tempdir().unwrap();
+        let root = dir.path();
+        git(root, &["init", "-q"]);
+        git(root, &["config", "user.name", "Test User"]);
+        git(root, &["config", "user.email", "test@example.com"]);
+
+        fs::write(
+            root.join("pay.py"),
+            "def process_payment(amount):\n    return charge(amount)\n\n\ndef refund():\n    pass\n",
+        )
+        .unwrap();
+        fs::write(root.join("user.py"), "def create_user():\n    pass\n").unwrap();
+        fs::write(root.join("README.md"), "not indexed\n").unwrap();
+        git(root, &["add", "."]);
+        git(root, &["commit", "-q", "-m", "first"]);
+
+        // Edit refund, drop nothing, add a function, leave user.py alone
+        fs::write(
+            root.join("pay.py"),
+            "def process_payment(amount):\n    return charge(amount)\n\n\ndef refund():\n    return None\n\n\ndef void():\n    pass\n",
+        )
+        .unwrap();
+        git(root, &["commit", "-q", "-am", "second"]);
+
+        let diff = diff_revisions(root, "HEAD~1", "HEAD", 100).unwrap();
+        assert_eq!(diff.files.len(), 1);
+        assert_eq!(diff.files[0].file, PathBuf::from("pay.py"));
+
+        let changes: Vec<(ChangeKind, &str)> = diff
+            .changes()
+            .map(|c| (c.change, c.qualified.as_str()))
+            .collect();
+        assert!(
+            changes.contains(&(ChangeKind::Modified, "refund")),
+            "{:?}",
+            changes
+        );
+        assert!(
+            changes.contains(&(ChangeKind::Added, "void")),
+            "{:?}",
+            changes
+        );
+        assert!(changes.iter().all(|(_, q)| *q != "process_payment"));
+
+        assert!(diff_revisions(root, "HEAD", "no-such-ref", 100).is_err());
+    }
+}
@@ -0,0 +1,278 @@
+//! Graphviz DOT export of the call graph.
+//!
+//! [`CallGraph::write_dot`] writes a `digraph` with one node per symbol,
+//! labeled with its qualified name, and one edge per caller/callee pair.
+//! Edges that are not certain calls are styled apart: [`CallKind::Possible`]
+//! edges are dashed and [`CallKind::Indirect`] edges dotted. When a pair is
+//! called in several ways the most certain kind wins.
+//!
+//! Set [`DotOptions::root`] to export only what one symbol transitively
+//! calls, and [`DotOptions::max_depth`] to cut that off after a number of
+//! calls, which keeps large graphs readable. Nodes and edges are written in
+//! name order, so the output is stable across runs.
+//!
+//! # Examples
+//!
+//! ```
+//! use rocketindex::callgraph::CallGraph;
+//! use rocketindex::dot::DotOptions;
+//! use rocketindex::{
+//!     CodeIndex, Location, Reference, ReferenceKind, Symbol, SymbolKind, Visibility,
+//! };
+//! use std::path::PathBuf;
+//!
+//! let mut index = CodeIndex::new();
+//! for (name, line) in [("helper", 1), ("run", 5)] {
+//!     index.add_symbol(Symbol::new(
+//!         name.to_string(),
+//!         format!("main.{}", name),
+//!         SymbolKind::Function,
+//!         Location::new(PathBuf::from("main.go"), line, 6),
+//!         Visibility::Private,
+//!         "go".to_string(),
+//!     ));
+//! }
+//! index.add_reference(
+//!     PathBuf::from("main.go"),
+//!     Reference {
+//!         name: "helper".to_string(),
+//!         location: Location::new(PathBuf::from("main.go"), 6, 5),
+//!         kind: ReferenceKind::Call,
+//!         target: None,
+//!         arguments: None,
+//!     },
+//! );
+//!
+//! let mut out = Vec::new();
+//! CallGraph::build(&index)
+//!     .write_dot(&mut out, &DotOptions::default())
+//!     .unwrap();
+//! let dot = String::from_utf8(out).unwrap();
+//! assert!(dot.contains("\"main.run\" -> \"main.helper\";"));
+//! ```
+
+use std::collections::{BTreeMap, BTreeSet};
+use std::io::{self, Write};
+
+use crate::callgraph::{CallGraph, CallKind};
+
+/// Options for [`CallGraph::write_dot`].
+#[derive(Debug, Clone, Default)]
+pub struct DotOptions {
+    /// Only export the root and the symbols it transitively calls
+    pub root: Option<String>,
+    /// With a root, maximum call depth to follow (0 = unlimited)
+    pub max_depth: usize,
+}
+
+impl CallGraph {
+    /// Write the graph, or the part of it selected by `options`, as Graphviz DOT.
+    ///
+    /// Without a root every symbol with a call edge is written. An unknown
+    /// root yields a graph with just that node.
+    pub fn write_dot<W: Write>(&self, out: &mut W, options: &DotOptions) -> io::Result<()> {
+        let reached = match &options.root {
+            Some(root) => self.reachable_from(root, options.max_depth),
+            None => Vec::new(),
+        };
+        let nodes: BTreeSet<&str> = match &options.root {
+            Some(root) => std::iter::once(root.as_str())
+                .chain(reached.iter().map(|r| r.qualified.as_str()))
+                .collect(),
+            None => self
+                .sites()
+                .iter()
+                .flat_map(|site| [site.caller.as_str(), site.callee.as_str()])
+                .collect(),
+        };
+
+        // Caller/callee pair -> most certain kind
+        let mut edges: BTreeMap<(&str, &str), CallKind> = BTreeMap::new();
+        for site in self.sites() {
+            let (caller, callee) = (site.caller.as_str(), site.callee.as_str());
+            if !(nodes.contains(caller) && nodes.contains(callee)) {
+                continue;
+            }
+            edges
+                .entry((caller, callee))
+                .and_modify(|kind| *kind = (*kind).min(site.kind))
+                .or_insert(site.kind);
+        }
+
+        writeln!(out, "digraph calls {{")?;
+        for node in &nodes {
+            writeln!(out, "    {};", quote(node))?;
+        }
+        for ((caller, callee), kind) in edges {
+            let style = match kind {
+                CallKind::Direct => "",
+                CallKind::Possible => " [style=dashed]",
+                CallKind::Indirect => " [style=dotted]",
+            };
+            writeln!(out, "    {} -> {}{};", quote(caller), quote(callee), style)?;
+        }
+        writeln!(out, "}}")
+    }
+}
+
+/// A DOT quoted ID: `main.User` -> `"main.User"`.
+fn quote(id: &str) -> String {
+    format!("\"{}\"", id.replace('\\', "\\\\").replace('"', "\\\""))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::callgraph::{index_of_calls, CallGraphOptions};
+    use crate::{Location, Reference, ReferenceKind, Symbol, SymbolKind, Visibility};
+    use std::path::PathBuf;
+
+    /// main -> a -> b -> c, and main calls a twice
+    fn chain() -> CallGraph {
+        CallGraph::build(&index_of_calls(
+            &[("main", 1), ("a", 5), ("b", 9), ("c", 13), ("other", 17)],
+            &[(2, "a"), (3, "a"), (6, "b"), (10, "c"), (18, "c")],
+        ))
+    }
+
+    fn dot(graph: &CallGraph, options: &DotOptions) -> String {
+        let mut out = Vec::new();
+        graph.write_dot(&mut out, options).unwrap();
+        String::from_utf8(out).unwrap()
+    }
+
+    #[test]
+    fn test_whole_graph() {
+        assert_eq!(
+            dot(&chain(), &DotOptions::default()),
+            r#"digraph calls {
+    "main.a";
+    "main.b";
+    "main.c";
+    "main.main";
+    "main.other";
+    "main.a" -> "main.b";
+    "main.b" -> "main.c";
+    "main.main" -> "main.a";
+    "main.other" -> "main.c";
+}
+"#
+        );
+        assert_eq!(
+            dot(&CallGraph::default(), &DotOptions::default()),
+            "digraph calls {\n}\n"
+        );
+    }
+
+    #[test]
+    fn test_root_and_max_depth() {
+        let options = DotOptions {
+            root: Some("main.main".to_string()),
+            max_depth: 2,
+        };
+        let out = dot(&chain(), &options);
+        assert!(out.contains("\"main.a\" -> \"main.b\";"));
+        assert!(!out.contains("main.c"), "{}", out);
+        assert!(!out.contains("main.other"), "{}", out);
+
+        let unlimited = DotOptions {
+            max_depth: 0,
+            ..options
+        };
+        let out = dot(&chain(), &unlimited);
+        assert!(out.contains("\"main.b\" -> \"main.c\";"));
+        assert!(!out.contains("main.other"), "{}", out);
+
+        let missing = DotOptions {
+            root: Some("main.missing".to_string()),
+            max_depth: 0,
+        };
+        assert_eq!(
+            dot(&chain(), &missing),
+            "digraph calls {\n    \"main.missing\";\n}\n"
+        );
+    }
+
+    #[test]
+    fn test_uncertain_edges_are_styled() {
+        let mut index = index_of_calls(&[("describe", 10), ("helper", 20)], &[]);
+        index.add_symbol(Symbol::new(
+            "Shape".to_string(),
+            "main.Shape".to_string(),
+            SymbolKind::Interface,
+            Location::new(PathBuf::from("shapes.go"), 1, 6),
+            Visibility::Public,
+            "go".to_string(),
+        ));
+        for (owner, file, signature) in [
+            ("Shape", "shapes.go", "func Area() float64"),
+            ("Square", "square.go", "func (Square) Area() float64"),
+        ] {
+            if owner != "Shape" {
+                index.add_symbol(Symbol::new(
+                    owner.to_string(),
+                    format!("main.{}", owner),
+                    SymbolKind::Class,
+                    Location::new(PathBuf::from(file), 1, 6),
+                    Visibility::Public,
+                    "go".to_string(),
+                ));
+            }
+            index.add_symbol(
+                Symbol::new(
+                    "Area".to_string(),
+                    format!("main.{}.Area", owner),
+                    SymbolKind::Function,
+                    Location::new(PathBuf::from(file), 2, 6),
+                    Visibility::Public,
+                    "go".to_string(),
+                )
+                .with_parent(Some(format!("main.{}", owner)))
+                .with_signature(Some(signature.to_string())),
+            );
+        }
+        for (name, line, kind, target) in [
+            ("Shape.Area", 11, ReferenceKind::Call, None),
+            ("cb", 12, ReferenceKind::IndirectCall, Some("main.helper")),
+        ] {
+            index.add_reference(
+                PathBuf::from("main.go"),
+                Reference {
+                    name: name.to_string(),
+                    location: Location::new(PathBuf::from("main.go"), line, 5),
+                    kind,
+                    target: target.map(str::to_string),
+                    arguments: None,
+                },
+            );
+        }
+
+        let options = CallGraphOptions {
+            interface_dispatch: true,
+            ..CallGraphOptions::default()
+        };
+        let graph = CallGraph::build_with_options(&index, options);
+        let out = dot(&graph, &DotOptions::default());
+        assert!(
+            out.contains("\"main.describe\" -> \"main.Shape.Area\";"),
+            "{}",
+            out
+        );
+        assert!(
+            out.contains("\"main.describe\" -> \"main.Square.Area\" [style=dashed];"),
+            "{}",
+            out
+        );
+        assert!(
+            out.contains("\"main.describe\" -> \"main.helper\" [style=dotted];"),
+            "{}",
+            out
+        );
+    }
+
+    #[test]
+    fn test_quote_escapes() {
+        assert_eq!(quote("main.User"), "\"main.User\"");
+        assert_eq!(quote("a\"b\\c"), "\"a\\\"b\\\\c\"");
+    }
+}
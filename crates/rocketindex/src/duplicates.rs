@@ -0,0 +1,554 @@
+//! Duplicate and near-duplicate function bodies.
+//!
+//! [`CodeIndex::duplicates`] finds copy-pasted code: functions and methods
+//! whose source, once normalized, is identical or nearly so. Normalization
+//! drops comments (using the comment syntax of each language's
+//! [`LanguageConfig`](crate::language_config::LanguageConfig)), drops string
+//! and number literals, and replaces every identifier with one placeholder,
+//! keeping keywords and punctuation. So
+//!
+//! ```text
+//! fmt.Printf("Processing $%.2f for %s\n", amount, user.Name)
+//! fmt.Printf("Refunding $%.2f to %s\n", amount, user.Name)
+//! ```
+//!
+//! normalize to the same tokens, and renaming a variable or changing a
+//! message does not hide a copy.
+//!
+//! Similarity is the Jaccard index of the sets of 5-token shingles of two
+//! bodies: 1.0 for identical token sequences, lower as statements are added,
+//! removed, or changed. Candidate pairs are found with MinHash
+//! locality-sensitive hashing rather than comparing every pair, then scored
+//! exactly, so a whole repository is checked in roughly linear time. Pairs
+//! scoring at least [`DuplicateOptions::threshold`] are linked, and each
+//! connected group is reported as a [`DuplicateCluster`].
+//!
+//! Bodies are read like [`crate::snippet`]s: from the copy captured at
+//! index time if there is one, else from disk, over the lines of the whole
+//! declaration ([`Symbol::lines`]). Single-line symbols are skipped.
+//!
+//! # Examples
+//!
+//! ```no_run
+//! use rocketindex::CodeIndex;
+//!
+//! # let index = CodeIndex::new();
+//! for cluster in index.duplicates(30) {
+//!     println!("{:.0}% similar:", cluster.similarity * 100.0);
+//!     for symbol in &cluster.members {
+//!         println!("  {} ({}:{})", symbol.qualified, symbol.location.file.display(), symbol.location.line);
+//!     }
+//! }
+//! ```
+
+use std::collections::{BTreeMap, HashMap};
+use std::path::Path;
+
+use serde::Serialize;
+
+use crate::freshness::FileHash;
+use crate::language_config::LanguageConfigs;
+use crate::{CodeIndex, Symbol};
+
+/// Default for [`DuplicateOptions::min_tokens`].
+pub const DEFAULT_MIN_TOKENS: usize = 30;
+
+/// Default for [`DuplicateOptions::threshold`].
+pub const DEFAULT_THRESHOLD: f64 = 0.8;
+
+/// Tokens per shingle.
+const SHINGLE: usize = 5;
+
+/// MinHash bands and rows per band (bands * rows hash functions).
+const BANDS: usize = 16;
+const ROWS: usize = 2;
+
+/// Keywords kept as themselves rather than abstracted like identifiers, so
+/// control flow still distinguishes bodies.
+const KEYWORDS: &[&str] = &[
+    "and", "async", "await", "begin", "break", "case", "catch", "class", "const", "continue",
+    "def", "default", "defer", "do", "elif", "else", "end", "ensure", "except", "finally", "fn",
+    "for", "foreach", "func", "function", "go", "goto", "if", "in", "is", "lambda", "let", "loop",
+    "match", "new", "not", "or", "raise", "rescue", "return", "select", "struct", "switch", "then",
+    "throw", "try", "unless", "until", "var", "when", "while", "with", "yield",
+];
+
+/// Options for [`CodeIndex::duplicates_with_options`].
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub struct DuplicateOptions {
+    /// Ignore bodies with fewer normalized tokens than this
+    pub min_tokens: usize,
+    /// Lowest similarity (`0.0..=1.0`) at which two bodies are linked
+    pub threshold: f64,
+    /// Read the copies captured at index time, if any, rather than the files
+    /// on disk
+    pub cached: bool,
+}
+
+impl Default for DuplicateOptions {
+    fn default() -> Self {
+        Self {
+            min_tokens: DEFAULT_MIN_TOKENS,
+            threshold: DEFAULT_THRESHOLD,
+            cached: true,
+        }
+    }
+}
+
+/// A group of functions with identical or similar bodies.
+#[derive(Debug, Clone, Serialize)]
+pub struct DuplicateCluster<'a> {
+    /// The functions, by file and line
+    pub members: Vec<&'a Symbol>,
+    /// Lowest similarity among the links joining the members (1.0 for
+    /// identical bodies)
+    pub similarity: f64,
+}
+
+/// A function body reduced to normalized tokens.
+struct Body<'a> {
+    symbol: &'a Symbol,
+    /// Sorted, deduplicated shingle hashes
+    shingles: Vec<u64>,
+}
+
+impl CodeIndex {
+    /// Clusters of functions whose bodies have at least `min_tokens`
+    /// normalized tokens and are at least [`DEFAULT_THRESHOLD`] similar.
+    ///
+    /// See [`CodeIndex::duplicates_with_options`].
+    #[must_use]
+    pub fn duplicates(&self, min_tokens: usize) -> Vec<DuplicateCluster<'_>> {
+        self.duplicates_with_options(&DuplicateOptions {
+            min_tokens,
+            ..DuplicateOptions::default()
+        })
+    }
+
+    /// Clusters of functions and methods with similar bodies.
+    ///
+    /// Clusters are sorted by size, then similarity (both descending), then
+    /// by their first member's file and line.
+    #[must_use]
+    pub fn duplicates_with_options(&self, options: &DuplicateOptions) -> Vec<DuplicateCluster<'_>> {
+        let bodies = self.bodies(options);
+
+        // Bodies sharing any MinHash band are candidate pairs
+        let mut buckets: HashMap<(usize, u64), Vec<usize>> = HashMap::new();
+        for (i, body) in bodies.iter().enumerate() {
+            let signature = min_hashes(&body.shingles);
+            for (band, rows) in signature.chunks(ROWS).enumerate() {
+                let key = rows.iter().fold(band as u64, |key, &row| mix(key ^ row));
+                buckets.entry((band, key)).or_default().push(i);
+            }
+        }
+        let mut candidates: Vec<(usize, usize)> = buckets
+            .into_values()
+            .flat_map(|members| {
+                let pairs: Vec<(usize, usize)> = members
+                    .iter()
+                    .enumerate()
+                    .flat_map(|(n, &a)| members[n + 1..].iter().map(move |&b| (a, b)))
+                    .collect();
+                pairs
+            })
+            .collect();
+        candidates.sort_unstable();
+        candidates.dedup();
+
+        // Link pairs above the threshold, keeping the weakest link per group
+        let mut groups = DisjointSets::new(bodies.len());
+        for (a, b) in candidates {
+            let similarity = jaccard(&bodies[a].shingles, &bodies[b].shingles);
+            if similarity >= options.threshold {
+                groups.union(a, b, similarity);
+            }
+        }
+
+        let mut clusters: BTreeMap<usize, Vec<usize>> = BTreeMap::new();
+        for i in 0..bodies.len() {
+            clusters.entry(groups.find(i)).or_default().push(i);
+        }
+        let mut clusters: Vec<DuplicateCluster<'_>> = clusters
+            .into_iter()
+            .filter(|(_, members)| members.len() > 1)
+            .map(|(root, members)| {
+                let mut members: Vec<&Symbol> = members.iter().map(|&i| bodies[i].symbol).collect();
+                members.sort_by(|a, b| compare_location(a, b));
+                DuplicateCluster {
+                    members,
+                    similarity: groups.weakest[root],
+                }
+            })
+            .collect();
+        clusters.sort_by(|a, b| {
+            b.members
+                .len()
+                .cmp(&a.members.len())
+                .then_with(|| b.similarity.total_cmp(&a.similarity))
+                .then_with(|| compare_location(a.members[0], b.members[0]))
+        });
+        clusters
+    }
+
+    /// The normalized bodies of every function and method spanning more
+    /// than one line and with at least `options.min_tokens` tokens, by file and line.
+    fn bodies(&self, options: &DuplicateOptions) -> Vec<Body<'_>> {
+        let mut by_file: BTreeMap<&Path, Vec<&Symbol>> = BTreeMap::new();
+        for symbol in self.symbols() {
+            let (line, end_line) = symbol.lines();
+            if symbol.kind.is_callable() && end_line > line {
+                by_file
+                    .entry(symbol.location.file.as_path())
+                    .or_default()
+                    .push(symbol);
+            }
+        }
+
+        let mut bodies = Vec::new();
+        for (file, mut symbols) in by_file {
+            let Some((source, _)) = self.source_text(file, options.cached) else {
+                continue;
+            };
+            let lines: Vec<&str> = source.lines().collect();
+            let comments = CommentSyntax::for_file(file);
+            symbols.sort_by(|a, b| compare_location(a, b));
+            for symbol in symbols {
+                let (line, end_line) = symbol.lines();
+                let start = line.max(1) as usize - 1;
+                let end = (end_line as usize).min(lines.len());
+                if start >= end {
+                    continue;
+                }
+                let tokens = normalize(&lines[start..end].join("\n"), &comments);
+                if tokens.len() < options.min_tokens.max(1) {
+                    continue;
+                }
+                bodies.push(Body {
+                    symbol,
+                    shingles: shingles(&tokens),
+                });
+            }
+        }
+        bodies
+    }
+}
+
+fn compare_location(a: &Symbol, b: &Symbol) -> std::cmp::Ordering {
+    a.location
+        .file
+        .cmp(&b.location.file)
+        .then(a.location.line.cmp(&b.location.line))
+        .then(a.location.column.cmp(&b.location.column))
+}
+
+/// Comment markers recognized while tokenizing one file.
+struct CommentSyntax {
+    line: Vec<String>,
+    block: Option<(String, String)>,
+}
+
+impl CommentSyntax {
+    /// The syntax of `file`'s language, or C-style comments if it has no
+    /// config.
+    fn for_file(file: &Path) -> Self {
+        match LanguageConfigs::builtin().for_file(file) {
+            Some(config) => Self {
+                line: config.line_comments.clone(),
+                block: config.block_comment.clone(),
+            },
+            None => Self {
+                line: vec!["//".to_string()],
+                block: Some(("/*".to_string(), "*/".to_string())),
+            },
+        }
+    }
+}
+
+/// Hash the normalized tokens of `source`: comments and literals dropped,
+/// identifiers other than [`KEYWORDS`] replaced by one placeholder.
+fn normalize(source: &str, comments: &CommentSyntax) -> Vec<u64> {
+    let identifier = token_hash("$id");
+    let bytes = source.as_bytes();
+    let mut tokens = Vec::new();
+    let mut i = 0;
+    while i < bytes.len() {
+        let rest = &source[i..];
+        let c = bytes[i];
+        if c.is_ascii_whitespace() {
+            i += 1;
+        } else if let Some((open, close)) = comments
+            .block
+            .as_ref()
+            .filter(|(open, _)| rest.starts_with(open.as_str()))
+        {
+            i += rest[open.len()..]
+                .find(close.as_str())
+                .map_or(rest.len(), |end| open.len() + end + close.len());
+        } else if comments.line.iter().any(|m| rest.starts_with(m.as_str())) {
+            i += rest.find('\n').unwrap_or(rest.len());
+        } else if matches!(c, b'"' | b'\'' | b'`') {
+            i += string_len(&bytes[i..]);
+        } else if c.is_ascii_digit() {
+            i += bytes[i..]
+                .iter()
+                .position(|&b| !(b.is_ascii_alphanumeric() || b == b'_' || b == b'.'))
+                .unwrap_or(bytes.len() - i);
+        } else if c.is_ascii_alphabetic() || c == b'_' || c >= 0x80 {
+            let len = bytes[i..]
+                .iter()
+                .position(|&b| !(b.is_ascii_alphanumeric() || b == b'_' || b >= 0x80))
+                .unwrap_or(bytes.len() - i);
+            let word = &source[i..i + len];
+            tokens.push(if KEYWORDS.contains(&word) {
+                token_hash(word)
+            } else {
+                identifier
+            });
+            i += len;
+        } else {
+            tokens.push(token_hash(&source[i..i + 1]));
+            i += 1;
+        }
+    }
+    tokens
+}
+
+/// Length of the string literal at the start of `bytes`, up to and
+/// including its closing quote (or the end of the line, for `'` and `"`).
+fn string_len(bytes: &[u8]) -> usize {
+    let quote = bytes[0];
+    let mut i = 1;
+    while i < bytes.len() {
+        match bytes[i] {
+            b'\\' => i += 2,
+            b'\n' if quote != b'`' => return i,
+            b if b == quote => return i + 1,
+            _ => i += 1,
+        }
+    }
+    bytes.len()
+}
+
+fn token_hash(token: &str) -> u64 {
+    FileHash::of(token.as_bytes()).hash
+}
+
+/// Sorted, deduplicated hashes of every run of [`SHINGLE`] tokens (the whole
+/// sequence, if shorter).
+fn shingles(tokens: &[u64]) -> Vec<u64> {
+    let width = SHINGLE.min(tokens.len());
+    let mut shingles: Vec<u64> = tokens
+        .windows(width)
+        .map(|window| window.iter().fold(0, |hash, &token| mix(hash ^ token)))
+        .collect();
+    shingles.sort_unstable();
+    shingles.dedup();
+    shingles
+}
+
+/// The minimum of each of `BANDS * ROWS` hash functions over `shingles`.
+fn min_hashes(shingles: &[u64]) -> Vec<u64> {
+    (0..BANDS * ROWS)
+        .map(|seed| {
+            let seed = mix(seed as u64 + 1);
+            shingles
+                .iter()
+                .map(|&shingle| mix(shingle ^ seed))
+                .min()
+                .unwrap_or(u64::MAX)
+        })
+        .collect()
+}
+
+/// The splitmix64 finalizer: a fast, well-distributed 64-bit mix.
+fn mix(mut x: u64) -> u64 {
+    x = x.wrapping_add(0x9e37_79b9_7f4a_7c15);
+    x = (x ^ (x >> 30)).wrapping_mul(0xbf58_476d_1ce4_e5b9);
+    x = (x ^ (x >> 27)).wrapping_mul(0x94d0_49bb_1331_11eb);
+    x ^ (x >> 31)
+}
+
+/// Jaccard index of two sorted, deduplicated sets.
+fn jaccard(a: &[u64], b: &[u64]) -> f64 {
+    let (mut i, mut j, mut shared) = (0, 0, 0);
+    while i < a.len() && j < b.len() {
+        match a[i].cmp(&b[j]) {
+            std::cmp::Ordering::Less => i += 1,
+            std::cmp::Ordering::Greater => j += 1,
+            std::cmp::Ordering::Equal => {
+                shared += 1;
+                i += 1;
+                j += 1;
+            }
+        }
+    }
+    let union = a.len() + b.len() - shared;
+    if union == 0 {
+        1.0
+    } else {
+        shared as f64 / union as f64
+    }
+}
+
+/// Union-find over body indices, tracking each group's weakest link.
+struct DisjointSets {
+    parent: Vec<usize>,
+    weakest: Vec<f64>,
+}
+
+impl DisjointSets {
+    fn new(len: usize) -> Self {
+        Self {
+            parent: (0..len).collect(),
+            weakest: vec![1.0; len],
+        }
+    }
+
+    fn find(&mut self, mut i: usize) -> usize {
+        while self.parent[i] != i {
+            self.parent[i] = self.parent[self.parent[i]];
+            i = self.parent[i];
+        }
+        i
+    }
+
+    fn union(&mut self, a: usize, b: usize, similarity: f64) {
+        let (a, b) = (self.find(a), self.find(b));
+        let weakest = self.weakest[a].min(self.weakest[b]).min(similarity);
+        let root = a.min(b);
+        self.parent[a.max(b)] = root;
+        self.weakest[root] = weakest;
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::indexer::IndexOptions;
+
+    fn c_style() -> CommentSyntax {
+        CommentSyntax::for_file(Path::new("a.go"))
+    }
+
+    #[test]
+    fn test_normalization_abstracts_identifiers_and_drops_literals() {
+        let a = normalize(
+            "total := add(1, \"one\") // sum\n/* done */ return total",
+            &c_style(),
+        );
+        let b = normalize("sum := plus(2.5, `two`)\nreturn sum", &c_style());
+        assert_eq!(a, b);
+        assert_eq!(a.len(), 9);
+
+        let minus = normalize("sum := plus(2.5, `two`)\nreturn -sum", &c_style());
+        assert_ne!(a, minus);
+        let loop_ = normalize("sum := plus(2.5, `two`)\nfor sum", &c_style());
+        assert_ne!(a, loop_);
+
+        let python = CommentSyntax::for_file(Path::new("a.py"));
+        assert_eq!(
+            normalize("x = 'it\\'s' # note\nreturn x", &python),
+            normalize("y = \"other\"\nreturn y", &python)
+        );
+    }
+
+    #[test]
+    fn test_jaccard_of_shingles() {
+        let tokens: Vec<u64> = (0..20).collect();
+        assert_eq!(jaccard(&shingles(&tokens), &shingles(&tokens)), 1.0);
+
+        let mut changed = tokens.clone();
+        changed[19] = 99;
+        let similarity = jaccard(&shingles(&tokens), &shingles(&changed));
+        assert!(similarity > 0.7 && similarity < 1.0, "{}", similarity);
+        assert_eq!(jaccard(&shingles(&tokens), &shingles(&[50, 51, 52])), 0.0);
+    }
+
+    const PAYMENTS: &str = "\
+def charge(account, amount):
+    if amount <= 0:
+        raise ValueError(\"amount must be positive\")
+    fee = amount * 0.03
+    account.balance -= amount + fee
+    log(\"charged\", account.id, amount)
+    return account.balance
+
+def refund(customer, value):
+    if value <= 0:
+        raise ValueError(\"value must be positive\")
+    fee = value * 0.01
+    customer.balance += value - fee
+    log(\"refunded\", customer.id, value)
+    return customer.balance
+
+def credit(customer, value):
+    if value <= 0:
+        raise ValueError(\"value must be positive\")
+    customer.balance += value
+    notify(customer)
+    return customer.balance
+
+def report(accounts):
+    for account in accounts:
+        print(account.id, account.balance)
+";
+
+    fn payments_index() -> (tempfile::TempDir, CodeIndex) {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let root = temp_dir.path();
+        std::fs::write(root.join("payments.py"), PAYMENTS).unwrap();
+        let mut index = CodeIndex::with_root(root.to_path_buf());
+        index.index_files(&[root.join("payments.py")], &IndexOptions::default());
+        (temp_dir, index)
+    }
+
+    fn names<'a>(cluster: &DuplicateCluster<'a>) -> Vec<&'a str> {
+        cluster.members.iter().map(|s| s.name.as_str()).collect()
+    }
+
+    #[test]
+    fn test_duplicates_cluster_by_threshold() {
+        let (_dir, index) = payments_index();
+        let with_threshold = |threshold| {
+            index.duplicates_with_options(&DuplicateOptions {
+                min_tokens: 10,
+                threshold,
+                ..DuplicateOptions::default()
+            })
+        };
+
+        // charge and refund differ only in operators; credit less so
+        let strict = with_threshold(0.6);
+        assert_eq!(strict.len(), 1);
+        assert_eq!(names(&strict[0]), ["charge", "refund"]);
+        assert!(strict[0].similarity >= 0.6 && strict[0].similarity < 1.0);
+
+        let loose = with_threshold(0.2);
+        assert_eq!(names(&loose[0]), ["charge", "refund", "credit"]);
+        assert!(loose[0].similarity < strict[0].similarity);
+
+        assert!(with_threshold(1.0).is_empty());
+        assert!(index.duplicates(1000).is_empty());
+    }
+
+    #[test]
+    fn test_go_sample_payment_methods_cluster() {
+        let root = Path::new(env!("CARGO_MANIFEST_DIR"))
+            .join("../../tests/claude-plugin-integration/test-repos/go-sample");
+        let mut index = CodeIndex::with_root(root.clone());
+        index.update_files(
+            &[root.join("payment.go"), root.join("user.go")],
+            &IndexOptions::default(),
+        );
+
+        let clusters = index.duplicates_with_options(&DuplicateOptions {
+            min_tokens: 10,
+            threshold: 0.5,
+            ..DuplicateOptions::default()
+        });
+        assert_eq!(clusters.len(), 1);
+        assert_eq!(names(&clusters[0]), ["ProcessPayment", "RefundPayment"]);
+        assert_eq!(clusters[0].similarity, 1.0);
+    }
+}
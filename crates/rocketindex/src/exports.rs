@@ -0,0 +1,182 @@
+//! Export status: whether code outside a symbol's package can name it.
+//!
+//! A symbol is exported when it is declared [`Visibility::Public`] and so is
+//! every type it is declared under. Parsers record the declared visibility;
+//! in Go that is the upper-case rule, so `ProcessPayment` is public and
+//! `helper` is not. A member of an unexported type is not exported even when
+//! its own name is: other packages cannot name `user`, so the `Save` method
+//! of `func (u *user) Save()` is not part of the package's API, and neither
+//! is an upper-case field of an unexported struct.
+//!
+//! [`CallGraph::unreachable_symbols`](crate::callgraph::CallGraph::unreachable_symbols)
+//! treats exported symbols as entry points when asked, and
+//! [`SearchOptions::exported_only`](crate::search::SearchOptions::exported_only)
+//! lists a package's API.
+//!
+//! # Examples
+//!
+//! ```
+//! use rocketindex::{CodeIndex, Location, Symbol, SymbolKind, Visibility};
+//! use std::path::PathBuf;
+//!
+//! let symbol = |name: &str, qualified: &str, kind, visibility| {
+//!     Symbol::new(
+//!         name.to_string(),
+//!         qualified.to_string(),
+//!         kind,
+//!         Location::new(PathBuf::from("user.go"), 1, 6),
+//!         visibility,
+//!         "go".to_string(),
+//!     )
+//! };
+//!
+//! let mut index = CodeIndex::new();
+//! index.add_symbol(symbol("user", "main.user", SymbolKind::Class, Visibility::Private));
+//! index.add_symbol(
+//!     symbol("Save", "main.user.Save", SymbolKind::Function, Visibility::Public)
+//!         .with_parent(Some("user".to_string())),
+//! );
+//!
+//! let save = index.get("main.user.Save").unwrap();
+//! assert_eq!(save.visibility, Visibility::Public);
+//! assert!(!index.is_exported(save));
+//! ```
+
+use crate::{CodeIndex, Symbol, SymbolKind, Visibility};
+
+impl CodeIndex {
+    /// Whether `symbol` is visible outside its package: declared public,
+    /// with every enclosing type in the index public too.
+    #[must_use]
+    pub fn is_exported(&self, symbol: &Symbol) -> bool {
+        let mut current = symbol;
+        loop {
+            if current.visibility != Visibility::Public {
+                return false;
+            }
+            match self.enclosing_type(current) {
+                Some(owner) => current = owner,
+                None => return true,
+            }
+        }
+    }
+
+    /// The type `symbol` is declared directly under, if it is a member.
+    ///
+    /// Parsers record the owner in [`Symbol::parent`] in different forms (Go
+    /// keeps the bare receiver type), so the owner is found by qualified
+    /// name: the symbol's own minus its last segment.
+    pub(crate) fn enclosing_type(&self, symbol: &Symbol) -> Option<&Symbol> {
+        symbol.parent.as_ref()?;
+        let owner = symbol
+            .qualified
+            .strip_suffix(symbol.name.as_str())
+            .and_then(|prefix| {
+                prefix
+                    .strip_suffix('.')
+                    .or_else(|| prefix.strip_suffix("::"))
+            })?;
+        self.get_all(owner)
+            .iter()
+            .find(|s| s.kind.is_type() || s.kind == SymbolKind::Module)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::Location;
+    use std::path::PathBuf;
+
+    fn symbol(qualified: &str, kind: SymbolKind, visibility: Visibility) -> Symbol {
+        let name = qualified.rsplit(['.', ':']).next().unwrap();
+        Symbol::new(
+            name.to_string(),
+            qualified.to_string(),
+            kind,
+            Location::new(PathBuf::from("src/lib"), 1, 1),
+            visibility,
+            "go".to_string(),
+        )
+    }
+
+    fn member(qualified: &str, parent: &str, kind: SymbolKind, visibility: Visibility) -> Symbol {
+        symbol(qualified, kind, visibility).with_parent(Some(parent.to_string()))
+    }
+
+    #[test]
+    fn test_members_of_unexported_types_are_not_exported() {
+        use SymbolKind::{Class, Function, Member};
+        use Visibility::{Private, Public};
+
+        let mut index = CodeIndex::new();
+        for symbol in [
+            symbol("billing.ProcessPayment", Function, Public),
+            symbol("billing.helper", Function, Private),
+            symbol("billing.Service", Class, Public),
+            member("billing.Service.Charge", "Service", Function, Public),
+            member("billing.Service.retry", "Service", Function, Private),
+            symbol("billing.ledger", Class, Private),
+            member("billing.ledger.Append", "ledger", Function, Public),
+            member("billing.ledger.Total", "billing.ledger", Member, Public),
+        ] {
+            index.add_symbol(symbol);
+        }
+
+        let mut exported: Vec<&str> = index
+            .symbols()
+            .filter(|s| index.is_exported(s))
+            .map(|s| s.qualified.as_str())
+            .collect();
+        exported.sort_unstable();
+        assert_eq!(
+            exported,
+            [
+                "billing.ProcessPayment",
+                "billing.Service",
+                "billing.Service.Charge"
+            ]
+        );
+    }
+
+    #[test]
+    fn test_nested_and_protected_members() {
+        let mut index = CodeIndex::new();
+        for symbol in [
+            symbol("com.example.Outer", SymbolKind::Class, Visibility::Public),
+            member(
+                "com.example.Outer.Inner",
+                "com.example.Outer",
+                SymbolKind::Class,
+                Visibility::Internal,
+            ),
+            member(
+                "com.example.Outer.Inner.run",
+                "com.example.Outer.Inner",
+                SymbolKind::Function,
+                Visibility::Public,
+            ),
+            member(
+                "com.example.Outer.hook",
+                "com.example.Outer",
+                SymbolKind::Function,
+                Visibility::Protected,
+            ),
+            // A member whose owner is not indexed is judged on its own
+            member(
+                "crate::Widget::draw",
+                "crate::Widget",
+                SymbolKind::Function,
+                Visibility::Public,
+            ),
+        ] {
+            index.add_symbol(symbol);
+        }
+
+        let exported = |qualified: &str| index.is_exported(index.get(qualified).unwrap());
+        assert!(exported("com.example.Outer"));
+        assert!(!exported("com.example.Outer.Inner.run"));
+        assert!(!exported("com.example.Outer.hook"));
+        assert!(exported("crate::Widget::draw"));
+    }
+}
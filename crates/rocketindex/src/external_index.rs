@@ -37,7 +37,7 @@ pub struct ExternalSymbol {
 }
 
 /// Index of external symbols from .NET assemblies
-#[derive(Debug, Default)]
+#[derive(Debug, Clone, Default)]
 pub struct ExternalIndex {
     /// Symbols indexed by qualified name
     symbols: HashMap<String, ExternalSymbol>,
@@ -0,0 +1,156 @@
+//! Content hashes of indexed files, for telling which ones are out of date.
+//!
+//! [`CodeIndex::index_files`] records the size and a hash of each file's
+//! contents ([`FileHash`]) as it parses it. When the file is indexed again
+//! with the same contents, for example because a rebase or checkout rewrote
+//! it unchanged, the parse is skipped. Modification times are not trusted:
+//! only the contents decide.
+//!
+//! [`CodeIndex::stale_files`] lists the indexed files whose contents on disk
+//! no longer match, so callers can compute their own change sets. Sizes are
+//! compared first, so a file whose size changed is reported without being
+//! read.
+//!
+//! The hash is 64-bit FNV-1a, which is stable across platforms and Rust
+//! versions, so hashes saved in a snapshot stay comparable after loading.
+//!
+//! # Examples
+//!
+//! ```
+//! use rocketindex::freshness::FileHash;
+//!
+//! let hash = FileHash::of(b"package main\n");
+//! assert_eq!(hash.size, 13);
+//! assert_eq!(hash, FileHash::of(b"package main\n"));
+//! assert_ne!(hash, FileHash::of(b"package mian\n"));
+//! ```
+
+use std::fs;
+use std::path::{Path, PathBuf};
+
+use serde::{Deserialize, Serialize};
+
+use crate::CodeIndex;
+
+/// Starting state of a 64-bit FNV-1a hash (see [`fnv1a`]).
+pub(crate) const FNV_OFFSET_BASIS: u64 = 0xcbf2_9ce4_8422_2325;
+const FNV_PRIME: u64 = 0x0000_0100_0000_01b3;
+
+/// Continue the 64-bit FNV-1a hash `hash` over `bytes`.
+///
+/// Implemented here rather than with `std`'s hasher, whose output may change
+/// between Rust releases. [`crate::json`] hashes stable IDs with it too.
+pub(crate) fn fnv1a(hash: u64, bytes: &[u8]) -> u64 {
+    bytes.iter().fold(hash, |hash, &byte| {
+        (hash ^ u64::from(byte)).wrapping_mul(FNV_PRIME)
+    })
+}
+
+/// The size and content hash of a file's source.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash, Serialize, Deserialize)]
+pub struct FileHash {
+    /// Length of the contents in bytes
+    pub size: u64,
+    /// 64-bit FNV-1a hash of the contents
+    pub hash: u64,
+}
+
+impl FileHash {
+    /// Hash `contents`.
+    #[must_use]
+    pub fn of(contents: &[u8]) -> Self {
+        Self {
+            size: contents.len() as u64,
+            hash: fnv1a(FNV_OFFSET_BASIS, contents),
+        }
+    }
+
+    /// Whether the file at `path` still has these contents.
+    ///
+    /// Checks the size first and only reads the file if it matches. A file
+    /// that cannot be read does not match.
+    #[must_use]
+    pub fn matches_file(&self, path: &Path) -> bool {
+        let same_size = fs::metadata(path).is_ok_and(|metadata| metadata.len() == self.size);
+        same_size && fs::read(path).is_ok_and(|contents| Self::of(&contents) == *self)
+    }
+}
+
+impl CodeIndex {
+    /// Indexed files whose contents under `root` differ from what was indexed.
+    ///
+    /// Paths are returned as the index stores them (relative to the
+    /// workspace root), sorted. Deleted files are stale, and so are files
+    /// indexed without a recorded hash (added by hand rather than read from
+    /// disk). Files that were never indexed are not listed.
+    #[must_use]
+    pub fn stale_files(&self, root: &Path) -> Vec<PathBuf> {
+        let mut files: Vec<&PathBuf> = self.files().chain(self.hashed_files()).collect();
+        files.sort();
+        files.dedup();
+
+        files
+            .into_iter()
+            .filter(|file| {
+                self.file_hash(file)
+                    .is_none_or(|indexed| !indexed.matches_file(&root.join(file)))
+            })
+            .cloned()
+            .collect()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::indexer::IndexOptions;
+
+    #[test]
+    fn test_hash_is_fnv1a() {
+        assert_eq!(FileHash::of(b"").hash, 0xcbf2_9ce4_8422_2325);
+        assert_eq!(FileHash::of(b"a").hash, 0xaf63_dc4c_8601_ec8c);
+        assert_eq!(FileHash::of(b"foobar").hash, 0x8594_4171_f739_67e8);
+    }
+
+    #[test]
+    fn test_stale_files_compare_contents() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let root = temp_dir.path();
+        let names = [
+            "edited.py",
+            "grown.py",
+            "rewritten.py",
+            "deleted.py",
+            "same.py",
+        ];
+        for name in names {
+            fs::write(root.join(name), "def f():\n    pass\n").unwrap();
+        }
+        let paths: Vec<PathBuf> = names.iter().map(|name| root.join(name)).collect();
+        let mut index = CodeIndex::with_root(root.to_path_buf());
+        index.index_files(&paths, &IndexOptions::default());
+        assert!(index.stale_files(root).is_empty());
+
+        // Same size, different contents
+        fs::write(root.join("edited.py"), "def g():\n    pass\n").unwrap();
+        fs::write(root.join("grown.py"), "def f():\n    return 1\n").unwrap();
+        // Rewritten with identical contents (a checkout touching the file)
+        fs::write(root.join("rewritten.py"), "def f():\n    pass\n").unwrap();
+        fs::remove_file(root.join("deleted.py")).unwrap();
+
+        assert_eq!(
+            index.stale_files(root),
+            vec![
+                PathBuf::from("deleted.py"),
+                PathBuf::from("edited.py"),
+                PathBuf::from("grown.py"),
+            ]
+        );
+
+        // Re-indexing the stale files brings the index up to date
+        let stale: Vec<PathBuf> = index.stale_files(root);
+        index.update_files(&stale, &IndexOptions::default());
+        assert!(index.stale_files(root).is_empty());
+        assert!(index.file_hash(Path::new("deleted.py")).is_none());
+    }
+}
@@ -1,7 +1,8 @@
 //! Fuzzy string matching utilities for symbol lookup error recovery.
 //!
 //! Provides Levenshtein distance calculation and similar string suggestions
-//! to help agents recover from typos in symbol names.
+//! to help agents recover from typos in symbol names, and subsequence scoring
+//! for abbreviated queries like `ProcPay` -> `ProcessPayment`.
 
 /// Calculate the Levenshtein (edit) distance between two strings.
 ///
@@ -125,6 +126,138 @@ where
     suggestions
 }
 
+/// Points for each matched query character.
+const SCORE_MATCH: i64 = 16;
+/// Bonus when a matched character starts a word (`Payment` in `ProcessPayment`).
+const BONUS_BOUNDARY: i64 = 10;
+/// Bonus when a matched character directly follows the previous match.
+const BONUS_CONSECUTIVE: i64 = 8;
+/// Bonus when a matched character has the same case as the query.
+const BONUS_CASE: i64 = 2;
+/// Bonus when the candidate starts with the query (ignoring case).
+const BONUS_PREFIX: i64 = 32;
+/// Bonus when the candidate equals the query (ignoring case).
+const BONUS_EXACT: i64 = 64;
+/// Penalty for each candidate character skipped between two matches.
+const PENALTY_GAP: i64 = 1;
+/// Penalty for each candidate character not covered by the query.
+const PENALTY_LENGTH: i64 = 1;
+
+/// Score `candidate` as a fuzzy subsequence match for `query`.
+///
+/// Every character of `query` must appear in `candidate` in order, compared
+/// case-insensitively; otherwise `None` is returned. Higher scores are better
+/// matches: characters that start words (after `_`, `.`, `:` or at a
+/// lower-to-upper case change), runs of consecutive characters, exact case,
+/// prefix matches and shorter candidates all score higher.
+///
+/// # Examples
+///
+/// ```
+/// use rocketindex::fuzzy::subsequence_score;
+///
+/// assert!(subsequence_score("ProcPay", "ProcessPayment").is_some());
+/// assert!(subsequence_score("NewUsr", "NewUser").is_some());
+/// assert!(subsequence_score("PayProc", "ProcessPayment").is_none());
+///
+/// // Shorter candidates win when the match is otherwise the same
+/// let short = subsequence_score("NewUsr", "NewUser").unwrap();
+/// let long = subsequence_score("NewUsr", "NewUserService").unwrap();
+/// assert!(short > long);
+/// ```
+#[must_use]
+pub fn subsequence_score(query: &str, candidate: &str) -> Option<i64> {
+    let query: Vec<char> = query.chars().collect();
+    let candidate: Vec<char> = candidate.chars().collect();
+
+    if query.is_empty() {
+        return Some(-(candidate.len() as i64) * PENALTY_LENGTH);
+    }
+    if query.len() > candidate.len() {
+        return None;
+    }
+
+    let bonus_at: Vec<i64> = (0..candidate.len())
+        .map(|j| {
+            if is_word_start(&candidate, j) {
+                BONUS_BOUNDARY
+            } else {
+                0
+            }
+        })
+        .collect();
+
+    // best[j]: best score with the current query character matched at
+    // candidate position j (None when it cannot be matched there).
+    let mut best: Vec<Option<i64>> = vec![None; candidate.len()];
+    for (i, &q) in query.iter().enumerate() {
+        let mut next: Vec<Option<i64>> = vec![None; candidate.len()];
+        for j in i..candidate.len() {
+            let c = candidate[j];
+            if !chars_eq_ignore_case(q, c) {
+                continue;
+            }
+            let mut score = SCORE_MATCH + bonus_at[j];
+            if q == c {
+                score += BONUS_CASE;
+            }
+
+            let previous = if i == 0 {
+                // Characters skipped before the first match count as a gap
+                Some(-(j as i64) * PENALTY_GAP)
+            } else {
+                (i - 1..j)
+                    .filter_map(|k| {
+                        best[k].map(|prev| {
+                            if k + 1 == j {
+                                prev + BONUS_CONSECUTIVE
+                            } else {
+                                prev - (j - k - 1) as i64 * PENALTY_GAP
+                            }
+                        })
+                    })
+                    .max()
+            };
+            next[j] = previous.map(|prev| prev + score);
+        }
+        best = next;
+    }
+
+    let mut score = best.into_iter().flatten().max()?;
+
+    let query_lower: String = query.iter().flat_map(|c| c.to_lowercase()).collect();
+    let candidate_lower: String = candidate.iter().flat_map(|c| c.to_lowercase()).collect();
+    if candidate_lower == query_lower {
+        score += BONUS_EXACT;
+    } else if candidate_lower.starts_with(&query_lower) {
+        score += BONUS_PREFIX;
+    }
+    score -= (candidate.len() - query.len()) as i64 * PENALTY_LENGTH;
+
+    Some(score)
+}
+
+/// Compare two characters ignoring case.
+fn chars_eq_ignore_case(a: char, b: char) -> bool {
+    a == b || a.to_lowercase().eq(b.to_lowercase())
+}
+
+/// Whether the character at `index` starts a word in an identifier.
+fn is_word_start(chars: &[char], index: usize) -> bool {
+    let Some(&current) = chars.get(index) else {
+        return false;
+    };
+    if index == 0 {
+        return true;
+    }
+    let previous = chars[index - 1];
+    if matches!(previous, '_' | '.' | ':' | '-' | '/' | '#' | ' ') {
+        return current.is_alphanumeric();
+    }
+    (previous.is_lowercase() && current.is_uppercase())
+        || (!previous.is_ascii_digit() && current.is_ascii_digit())
+}
+
 /// Default maximum edit distance for suggestions.
 pub const DEFAULT_MAX_DISTANCE: usize = 3;
 
@@ -241,4 +374,42 @@ mod tests {
         assert_eq!(suggestions[0].value, "processPayment");
         assert_eq!(suggestions[0].distance, 1);
     }
+
+    #[test]
+    fn test_subsequence_score_requires_ordered_subsequence() {
+        assert!(subsequence_score("ProcPay", "ProcessPayment").is_some());
+        assert!(subsequence_score("procpay", "ProcessPayment").is_some());
+        assert!(subsequence_score("NewUsr", "NewUser").is_some());
+        assert!(subsequence_score("PayProc", "ProcessPayment").is_none());
+        assert!(subsequence_score("NewUserX", "NewUser").is_none());
+    }
+
+    #[test]
+    fn test_subsequence_score_prefers_word_starts() {
+        // "PP" should align with the capitals, not "Pro" + "p"
+        let camel = subsequence_score("pp", "ProcessPayment").unwrap();
+        let flat = subsequence_score("pp", "Processpayment").unwrap();
+        assert!(camel > flat);
+    }
+
+    #[test]
+    fn test_subsequence_score_prefers_prefix_case_and_exact() {
+        let prefix = subsequence_score("Process", "ProcessPayment").unwrap();
+        let inner = subsequence_score("Process", "HandleProcessing").unwrap();
+        assert!(prefix > inner);
+
+        let same_case = subsequence_score("NewUser", "NewUserA").unwrap();
+        let other_case = subsequence_score("NewUser", "newuserA").unwrap();
+        assert!(same_case > other_case);
+
+        let exact = subsequence_score("NewUser", "NewUser").unwrap();
+        assert!(exact > same_case);
+    }
+
+    #[test]
+    fn test_subsequence_score_prefers_shorter_candidates() {
+        let short = subsequence_score("ProcPay", "ProcessPayment").unwrap();
+        let long = subsequence_score("ProcPay", "ProcessPaymentRefund").unwrap();
+        assert!(short > long);
+    }
 }
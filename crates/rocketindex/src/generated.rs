@@ -0,0 +1,153 @@
+//! Detecting generated source files.
+//!
+//! Go marks machine-written files with a header line matching
+//! `^// Code generated .* DO NOT EDIT\.$` (see `go help generate`), and
+//! protobuf, gRPC, mock and stringer generators for other languages follow
+//! the same convention. [`is_generated_source`] recognizes the header when it
+//! is the first non-blank line of the file, with exactly that phrasing:
+//! `// Code generated by protoc-gen-go. DO NOT EDIT.` matches,
+//! `// code generated ... do not edit` and `// Code generated; edit freely.`
+//! do not.
+//!
+//! Each language declares its own marker in its
+//! [`LanguageConfig`](crate::language_config::LanguageConfig): this header
+//! for languages with `//` comments, `# Code generated ... DO NOT EDIT.` for
+//! Python and Ruby.
+//!
+//! [`extract_symbols`](crate::extract_symbols) marks every symbol of a
+//! generated file with the [`GENERATED`] attribute, so [`Symbol::is_generated`] works
+//! on any index and the flag is stored and exported with the symbol. Dead
+//! code and API reports usually want to leave these symbols out, while call
+//! resolution still needs them, so they stay in the index and each report
+//! decides:
+//! [`SearchOptions::exclude_generated`](crate::search::SearchOptions::exclude_generated)
+//! filters them from search,
+//! [`DeadCodeOptions::exclude_generated`](crate::callgraph::DeadCodeOptions::exclude_generated)
+//! from unreachable symbols,
+//! [`ApiDiffOptions::exclude_generated`](crate::api_diff::ApiDiffOptions::exclude_generated)
+//! from API comparisons, and
+//! [`IndexOptions::skip_generated`](crate::indexer::IndexOptions::skip_generated)
+//! leaves generated files out of the index entirely.
+//!
+//! # Examples
+//!
+//! ```
+//! use rocketindex::generated::is_generated_source;
+//!
+//! let source = "\n// Code generated by stringer -type=Color; DO NOT EDIT.\n\npackage main\n";
+//! assert!(is_generated_source(source));
+//! assert!(!is_generated_source("package main\n\n// Code generated by hand. DO NOT EDIT.\n"));
+//! ```
+
+use std::sync::OnceLock;
+
+use regex::Regex;
+
+use crate::parse::ParseResult;
+use crate::Symbol;
+
+/// Attribute marking a symbol defined in a generated file.
+pub const GENERATED: &str = "generated";
+
+/// The standard generated-code header.
+pub const GENERATED_HEADER: &str = r"^// Code generated .* DO NOT EDIT\.$";
+
+/// Whether `source` starts with the standard generated-code header.
+///
+/// Only the first non-blank line is checked, and it must match the header
+/// exactly, without leading or trailing whitespace.
+#[must_use]
+pub fn is_generated_source(source: &str) -> bool {
+    static HEADER: OnceLock<Regex> = OnceLock::new();
+    let header = HEADER.get_or_init(|| Regex::new(GENERATED_HEADER).expect("valid regex"));
+    source
+        .lines()
+        .find(|line| !line.trim().is_empty())
+        .is_some_and(|line| header.is_match(line))
+}
+
+impl ParseResult {
+    /// Mark every symbol as generated (see [`GENERATED`]).
+    pub fn mark_generated(&mut self) {
+        for symbol in &mut self.symbols {
+            if !symbol.is_generated() {
+                symbol
+                    .attributes
+                    .get_or_insert_with(Vec::new)
+                    .push(GENERATED.to_string());
+            }
+        }
+    }
+}
+
+impl Symbol {
+    /// Whether this symbol is defined in a generated file.
+    #[must_use]
+    pub fn is_generated(&self) -> bool {
+        self.has_attribute(GENERATED)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::{Location, SymbolKind, Visibility};
+    use std::path::PathBuf;
+
+    #[test]
+    fn test_header_must_be_the_first_non_blank_line() {
+        assert!(is_generated_source(
+            "// Code generated by protoc-gen-go. DO NOT EDIT.\npackage pb\n"
+        ));
+        assert!(is_generated_source(
+            "\r\n  \n// Code generated by mockgen. DO NOT EDIT.\r\npackage mocks\n"
+        ));
+        assert!(!is_generated_source(
+            "// Copyright 2024 Acme\n// Code generated by mockgen. DO NOT EDIT.\n"
+        ));
+        assert!(!is_generated_source(""));
+    }
+
+    #[test]
+    fn test_header_phrasing_is_exact() {
+        for line in [
+            "// code generated by mockgen. DO NOT EDIT.",
+            "// Code generated by mockgen. Do not edit.",
+            "// Code generated by mockgen. DO NOT EDIT",
+            "// Code generated by mockgen. DO NOT EDIT. ",
+            " // Code generated by mockgen. DO NOT EDIT.",
+            "//Code generated by mockgen. DO NOT EDIT.",
+            "// Code generated DO NOT EDIT.",
+            "/* Code generated by mockgen. DO NOT EDIT. */",
+        ] {
+            assert!(!is_generated_source(line), "{:?}", line);
+        }
+        assert!(is_generated_source("// Code generated  DO NOT EDIT."));
+    }
+
+    #[test]
+    fn test_mark_generated_tags_each_symbol_once() {
+        let symbol = Symbol::new(
+            "String".to_string(),
+            "main.Color.String".to_string(),
+            SymbolKind::Function,
+            Location::new(PathBuf::from("color_string.go"), 9, 16),
+            Visibility::Public,
+            "go".to_string(),
+        );
+        let mut result = ParseResult {
+            symbols: vec![symbol.with_attributes(Some(vec!["template".to_string()]))],
+            ..ParseResult::default()
+        };
+
+        result.mark_generated();
+        result.mark_generated();
+
+        let symbol = &result.symbols[0];
+        assert!(symbol.is_generated());
+        assert_eq!(
+            symbol.attributes.as_deref(),
+            Some(&["template".to_string(), GENERATED.to_string()][..])
+        );
+    }
+}
@@ -44,13 +44,18 @@
 //! When an index is serialized and moved to another machine, paths remain valid
 //! as long as the workspace structure is preserved.
 
-use std::collections::HashMap;
+use std::collections::{HashMap, HashSet};
 use std::path::{Path, PathBuf};
+use std::sync::Arc;
 
 use serde::{Deserialize, Serialize};
 
+use crate::annotations::Annotation;
+use crate::freshness::FileHash;
+use crate::indexer::Diagnostic;
+use crate::parse::ParseResult;
 use crate::type_cache::{TypeCache, TypeMember};
-use crate::{Location, Symbol};
+use crate::{Location, Symbol, SymbolKind};
 
 /// A reference to a symbol (an identifier usage, not a definition).
 ///
@@ -60,13 +65,16 @@ use crate::{Location, Symbol};
 /// # Examples
 ///
 /// ```
-/// use rocketindex::Reference;
+/// use rocketindex::{Reference, ReferenceKind};
 /// use rocketindex::Location;
 /// use std::path::PathBuf;
 ///
 /// let reference = Reference {
 ///     name: "process_payment".to_string(),
 ///     location: Location::new(PathBuf::from("src/main.rs"), 25, 10),
+///     kind: ReferenceKind::Unknown,
+///     target: None,
+///     arguments: None,
 /// };
 /// assert_eq!(reference.name, "process_payment");
 /// ```
@@ -76,6 +84,77 @@ pub struct Reference {
     pub name: String,
     /// Where the reference appears (path is relative to workspace root)
     pub location: Location,
+    /// How the identifier is used, when the parser can tell
+    #[serde(default)]
+    pub kind: ReferenceKind,
+    /// Qualified name the parser resolved the reference to from local context
+    /// (a Go import alias or a variable's declared type), tried before `name`
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub target: Option<String>,
+    /// Static types of a call's arguments, in order, where the parser could
+    /// infer them (`None` for an argument it could not); used to pick between
+    /// overloads
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub arguments: Option<Vec<Option<String>>>,
+}
+
+/// How a [`Reference`] uses the symbol it names.
+///
+/// Parsers that cannot classify a usage report [`ReferenceKind::Unknown`].
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq, Hash, Serialize, Deserialize)]
+#[serde(rename_all = "snake_case")]
+pub enum ReferenceKind {
+    /// Any usage the parser did not classify
+    #[default]
+    Unknown,
+    /// The identifier is the callee of a call expression
+    Call,
+    /// The identifier names a type, e.g. a parameter or return type
+    TypeUse,
+    /// The identifier reads or writes a field through a selector (`m.Field`)
+    FieldAccess,
+    /// The identifier names the type of a constructed value (`&User{...}`, `new User()`)
+    Construction,
+    /// A call through a function value rather than to a named function:
+    /// `callback()` after `callback := ps.RefundPayment`, or the definition of
+    /// a closure. `target` names the function the value holds.
+    IndirectCall,
+}
+
+/// The outcome of an incremental update (see [`CodeIndex::update_files`]).
+///
+/// Records which files were touched and which symbols they defined before and
+/// after the update, so derived structures such as
+/// [`CallGraph`](crate::callgraph::CallGraph) can be patched without a full rebuild.
+#[derive(Debug, Clone, Default)]
+pub struct IndexUpdate {
+    /// Files that were re-indexed or removed (relative to workspace root)
+    pub files: Vec<PathBuf>,
+    /// Symbols those files defined before the update
+    pub removed: Vec<Symbol>,
+    /// Symbols those files define after the update
+    pub added: Vec<Symbol>,
+    /// Problems found while parsing those files
+    pub diagnostics: Vec<Diagnostic>,
+}
+
+impl IndexUpdate {
+    /// Fold another update into this one.
+    pub fn merge(&mut self, other: IndexUpdate) {
+        for file in other.files {
+            if !self.files.contains(&file) {
+                self.files.push(file);
+            }
+        }
+        self.removed.extend(other.removed);
+        self.added.extend(other.added);
+        self.diagnostics.extend(other.diagnostics);
+    }
+
+    /// Check if the update touched no files.
+    pub fn is_empty(&self) -> bool {
+        self.files.is_empty()
+    }
 }
 
 /// The main index storing all symbols and their relationships.
@@ -112,7 +191,7 @@ pub struct Reference {
 /// let results = index.search("User");
 /// assert_eq!(results.len(), 1);
 /// ```
-#[derive(Debug, Default, Serialize, Deserialize)]
+#[derive(Debug, Clone, Default, Serialize, Deserialize)]
 pub struct CodeIndex {
     /// Workspace root directory (not serialized - set on load)
     #[serde(skip)]
@@ -135,6 +214,19 @@ pub struct CodeIndex {
     /// File (relative path) -> parsed opens/imports
     file_opens: HashMap<PathBuf, Vec<String>>,
 
+    /// File (relative path) -> TODO/FIXME/HACK/XXX comments
+    file_annotations: HashMap<PathBuf, Vec<Annotation>>,
+
+    /// File (relative path) -> size and content hash of the source it was
+    /// indexed from
+    #[serde(default)]
+    file_hashes: HashMap<PathBuf, FileHash>,
+
+    /// File (relative path) -> source captured at index time (not
+    /// serialized - see `IndexOptions::cache_sources`)
+    #[serde(skip)]
+    pub(crate) file_sources: HashMap<PathBuf, Arc<str>>,
+
     /// File compilation order from .fsproj (relative paths)
     /// Index 0 = first file compiled, higher = later
     /// Empty if no .fsproj was found
@@ -177,7 +269,7 @@ impl CodeIndex {
     }
 
     /// Convert an absolute path to a path relative to workspace root.
-    fn to_relative(&self, path: &Path) -> PathBuf {
+    pub(crate) fn to_relative(&self, path: &Path) -> PathBuf {
         if let Some(root) = &self.workspace_root {
             path.strip_prefix(root).unwrap_or(path).to_path_buf()
         } else {
@@ -186,7 +278,7 @@ impl CodeIndex {
     }
 
     /// Convert a relative path to an absolute path using workspace root.
-    fn to_absolute(&self, path: &Path) -> PathBuf {
+    pub(crate) fn to_absolute(&self, path: &Path) -> PathBuf {
         if path.is_absolute() {
             return path.to_path_buf();
         }
@@ -267,6 +359,36 @@ impl CodeIndex {
             .push(module);
     }
 
+    /// Add a TODO/FIXME/HACK/XXX annotation for a file.
+    ///
+    /// The file path will be converted to a relative path.
+    pub fn add_annotation(&mut self, file: PathBuf, mut annotation: Annotation) {
+        let relative_file = self.to_relative(&file);
+        annotation.location.file = self.to_relative(&annotation.location.file);
+
+        self.file_annotations
+            .entry(relative_file)
+            .or_default()
+            .push(annotation);
+    }
+
+    /// Record the size and content hash of the source a file was indexed from.
+    ///
+    /// The file path will be converted to a relative path.
+    pub(crate) fn set_file_hash(&mut self, file: &Path, hash: FileHash) {
+        let relative_file = self.to_relative(file);
+        self.file_hashes.insert(relative_file, hash);
+    }
+
+    /// The size and content hash of the source `file` was indexed from, if
+    /// it was indexed from disk.
+    ///
+    /// The file path can be either absolute or relative.
+    #[must_use]
+    pub fn file_hash(&self, file: &Path) -> Option<FileHash> {
+        self.file_hashes.get(&self.to_relative(file)).copied()
+    }
+
     /// Get a symbol by its qualified name.
     ///
     /// Note: The returned symbol's file path is relative to the workspace root.
@@ -344,6 +466,10 @@ impl CodeIndex {
     /// - The short name (e.g., "helper")
     /// - The qualified name (e.g., "Utils.helper")
     /// - Any suffix of the qualified name
+    ///
+    /// Definition sites are never included (use [`CodeIndex::get_all`] for
+    /// those), and each reference carries a [`ReferenceKind`] saying how the
+    /// symbol is used. Results are sorted by file, line, and column.
     pub fn find_references(&self, qualified_name: &str) -> Vec<&Reference> {
         let symbols = match self.definitions.get(qualified_name) {
             Some(s) if !s.is_empty() => s,
@@ -365,16 +491,32 @@ impl CodeIndex {
                 // 3. A partial qualified name: "Utils.helper"
                 // 4. A receiver.method call: "obj.helper" where obj is a variable
                 //    (for languages like Go, Java, C++ where method calls use receiver syntax)
-                if reference.name == *short_name
-                    || reference.name == qualified_name
-                    || qualified_name.ends_with(&format!(".{}", reference.name))
-                    || reference.name.ends_with(&format!(".{}", short_name))
-                {
+                //
+                // A reference the parser already resolved to another indexed
+                // symbol (see `Reference::target`) is not a match.
+                let target = reference.target.as_deref();
+                let targets_other =
+                    target.is_some_and(|t| t != qualified_name && self.definitions.contains_key(t));
+                let matches = target == Some(qualified_name)
+                    || (!targets_other
+                        && (reference.name == *short_name
+                            || reference.name == qualified_name
+                            || qualified_name.ends_with(&format!(".{}", reference.name))
+                            || reference.name.ends_with(&format!(".{}", short_name))));
+                let is_definition = symbols.iter().any(|s| s.location == reference.location);
+                if matches && !is_definition {
                     results.push(reference);
                 }
             }
         }
 
+        results.sort_by(|a, b| {
+            a.location
+                .file
+                .cmp(&b.location.file)
+                .then(a.location.line.cmp(&b.location.line))
+                .then(a.location.column.cmp(&b.location.column))
+        });
         results
     }
 
@@ -435,6 +577,75 @@ impl CodeIndex {
         results
     }
 
+    /// Find the concrete types that implement an interface.
+    ///
+    /// Combines declared implementations (symbols whose `implements` list
+    /// names the interface) with structural matches for Go, where a type
+    /// implements an interface by having all of its methods. Results are
+    /// sorted by qualified name.
+    #[must_use]
+    pub fn implementations(&self, interface: &str) -> Vec<&Symbol> {
+        let Some(target) = self
+            .get_all(interface)
+            .iter()
+            .find(|s| s.kind == SymbolKind::Interface)
+        else {
+            return Vec::new();
+        };
+
+        let declared = |name: &String| {
+            name == &target.qualified
+                || name == &target.name
+                || target.qualified.ends_with(&format!(".{}", name))
+                || target.qualified.ends_with(&format!("::{}", name))
+        };
+        // Rust declares the trait on the methods of `impl Trait for Type`,
+        // which stand for their type
+        let mut found: Vec<&Symbol> = self
+            .symbols()
+            .filter(|s| s.implements.iter().flatten().any(declared))
+            .filter_map(|s| match (&s.parent, s.kind.is_callable()) {
+                (Some(parent), true) => self
+                    .get_all(parent)
+                    .iter()
+                    .find(|t| !t.kind.is_callable() && t.kind != SymbolKind::Module),
+                _ => Some(s),
+            })
+            .collect();
+
+        if target.language == "go" {
+            found.extend(crate::languages::go::interfaces::implementations(
+                self, target,
+            ));
+        }
+
+        found.sort_by(|a, b| a.qualified.cmp(&b.qualified));
+        found.dedup_by(|a, b| a.qualified == b.qualified && a.location == b.location);
+        found
+    }
+
+    /// Iterate over every symbol in the index (in no particular order).
+    pub fn symbols(&self) -> impl Iterator<Item = &Symbol> {
+        self.definitions.values().flat_map(|syms| syms.iter())
+    }
+
+    /// Iterate over every reference in the index (in no particular order).
+    pub fn references(&self) -> impl Iterator<Item = &Reference> {
+        self.file_references.values().flat_map(|refs| refs.iter())
+    }
+
+    /// Iterate over every file with a recorded content hash (in no particular order).
+    pub(crate) fn hashed_files(&self) -> impl Iterator<Item = &PathBuf> {
+        self.file_hashes.keys()
+    }
+
+    /// Iterate over every annotation in the index (in no particular order).
+    pub(crate) fn file_annotations(&self) -> impl Iterator<Item = &Annotation> {
+        self.file_annotations
+            .values()
+            .flat_map(|found| found.iter())
+    }
+
     /// Get all qualified names in the index (for fuzzy matching).
     #[must_use]
     pub fn all_qualified_names(&self) -> Vec<String> {
@@ -480,12 +691,94 @@ impl CodeIndex {
         // Remove from file_opens
         self.file_opens.remove(&relative_file);
 
+        // Remove from file_annotations
+        self.file_annotations.remove(&relative_file);
+
+        // Remove from file_hashes
+        self.file_hashes.remove(&relative_file);
+
+        // Remove from file_sources
+        self.file_sources.remove(&relative_file);
+
         // Clean up module_files (remove file from all module entries)
         for files in self.module_files.values_mut() {
             files.retain(|f| f != &relative_file);
         }
     }
 
+    /// Replace everything indexed for `file` with a fresh parse result.
+    ///
+    /// Other files are left untouched. The file path can be either absolute or relative.
+    pub fn replace_file(&mut self, file: &Path, result: &ParseResult) -> IndexUpdate {
+        let mut update = self.remove_file(file);
+
+        for symbol in &result.symbols {
+            self.add_symbol(symbol.clone());
+        }
+        for reference in &result.references {
+            self.add_reference(file.to_path_buf(), reference.clone());
+        }
+        for open in &result.opens {
+            self.add_open(file.to_path_buf(), open.clone());
+        }
+        for annotation in &result.annotations {
+            self.add_annotation(file.to_path_buf(), annotation.clone());
+        }
+
+        update.added = self.defined_in(file);
+        update
+    }
+
+    /// Remove a file from the index, reporting the symbols it defined.
+    ///
+    /// The file path can be either absolute or relative.
+    pub fn remove_file(&mut self, file: &Path) -> IndexUpdate {
+        let removed = self.defined_in(file);
+        self.clear_file(file);
+
+        IndexUpdate {
+            files: vec![self.to_relative(file)],
+            removed,
+            added: Vec::new(),
+            diagnostics: Vec::new(),
+        }
+    }
+
+    /// Symbols whose definition lives in `file` (each reported once).
+    fn defined_in(&self, file: &Path) -> Vec<Symbol> {
+        let relative_file = self.to_relative(file);
+        let Some(names) = self.file_symbols.get(&relative_file) else {
+            return Vec::new();
+        };
+
+        let mut seen = HashSet::new();
+        names
+            .iter()
+            .filter(|name| seen.insert(name.as_str()))
+            .filter_map(|name| self.definitions.get(name))
+            .flat_map(|syms| syms.iter())
+            .filter(|s| s.location.file == relative_file)
+            .cloned()
+            .collect()
+    }
+
+    /// Re-index only the given files, leaving the rest of the index alone.
+    ///
+    /// Each path is read from disk and re-parsed; paths that no longer exist
+    /// are removed from the index, and files that fail to parse are reported
+    /// in [`IndexUpdate::diagnostics`]. A file whose contents hash the same
+    /// as when it was last indexed (touched by a rebase, say) is not
+    /// re-parsed and is left out of the update. Relative paths are resolved
+    /// against the workspace root. Files are parsed in parallel, with the
+    /// same `options` as [`CodeIndex::index_files`].
+    pub fn update_files(
+        &mut self,
+        paths: &[PathBuf],
+        options: &crate::indexer::IndexOptions,
+    ) -> IndexUpdate {
+        self.index_files(paths, options)
+    }
+
     /// Get all symbols defined in a specific module.
     ///
     /// Returns symbols whose qualified name starts with the given module prefix.
@@ -675,8 +968,9 @@ impl CodeIndex {
 #[cfg(test)]
 mod tests {
     use super::*;
+    use crate::indexer::IndexOptions;
     use crate::type_cache::{MemberKind, TypeCacheSchema, TypeMember, TypedSymbol};
-    use crate::{Location, SymbolKind, Visibility};
+    use crate::{Location, Visibility};
 
     fn make_symbol(name: &str, qualified: &str, file: &str) -> Symbol {
         Symbol {
@@ -692,6 +986,7 @@ mod tests {
             implements: None,
             doc: None,
             signature: None,
+            extent: None,
         }
     }
 
@@ -753,6 +1048,217 @@ mod tests {
         assert!(index.get("M.bar").is_some());
     }
 
+    #[test]
+    fn test_replace_file_reports_removed_and_added() {
+        let mut index = CodeIndex::new();
+        index.add_symbol(make_symbol("foo", "M.foo", "src/a.fs"));
+        index.add_symbol(make_symbol("foo", "M.foo", "src/b.fs"));
+        index.add_symbol(make_symbol("bar", "M.bar", "src/b.fs"));
+
+        let mut result = ParseResult::default();
+        result.symbols.push(make_symbol("baz", "M.baz", "src/b.fs"));
+        let update = index.replace_file(Path::new("src/b.fs"), &result);
+
+        assert_eq!(update.files, vec![PathBuf::from("src/b.fs")]);
+        let mut removed: Vec<&str> = update.removed.iter().map(|s| s.name.as_str()).collect();
+        removed.sort();
+        assert_eq!(removed, vec!["bar", "foo"]);
+        assert_eq!(update.added.len(), 1);
+        assert_eq!(update.added[0].name, "baz");
+
+        // The overload in src/a.fs is untouched
+        assert_eq!(index.get_all("M.foo").len(), 1);
+        assert_eq!(
+            index.get_all("M.foo")[0].location.file,
+            PathBuf::from("src/a.fs")
+        );
+        assert!(index.get("M.bar").is_none());
+        assert!(index.get("M.baz").is_some());
+    }
+
+    #[test]
+    fn test_find_references_classifies_go_fixture_usages() {
+        let fixture = Path::new(env!("CARGO_MANIFEST_DIR"))
+            .join("../../tests/claude-plugin-integration/test-repos/go-sample");
+        let files: Vec<PathBuf> = ["main.go", "payment.go", "user.go"]
+            .iter()
+            .map(|name| fixture.join(name))
+            .collect();
+        let mut index = CodeIndex::with_root(fixture.clone());
+        index.update_files(&files, &IndexOptions::default());
+
+        let user = index.get("main.User").expect("User should be indexed");
+        let refs: Vec<(String, u32, ReferenceKind)> = index
+            .find_references("main.User")
+            .into_iter()
+            .filter(|r| r.name == "User")
+            .map(|r| {
+                (
+                    r.location.file.display().to_string(),
+                    r.location.line,
+                    r.kind,
+                )
+            })
+            .collect();
+
+        // ProcessPayment/RefundPayment parameters, NewUser's return type and
+        // composite literal, and the FullInfo receiver
+        assert_eq!(
+            refs,
+            vec![
+                ("payment.go".to_string(), 11, ReferenceKind::TypeUse),
+                ("payment.go".to_string(), 16, ReferenceKind::TypeUse),
+                ("user.go".to_string(), 8, ReferenceKind::TypeUse),
+                ("user.go".to_string(), 9, ReferenceKind::Construction),
+                ("user.go".to_string(), 12, ReferenceKind::TypeUse),
+            ]
+        );
+        assert!(index
+            .find_references("main.User")
+            .iter()
+            .all(|r| r.location != user.location));
+
+        let kind_of = |name: &str| {
+            index
+                .references()
+                .find(|r| r.name == name)
+                .map(|r| r.kind)
+                .unwrap_or_else(|| panic!("missing reference {}", name))
+        };
+        assert_eq!(kind_of("NewUser"), ReferenceKind::Call);
+        assert_eq!(kind_of("service.ProcessPayment"), ReferenceKind::Call);
+        assert_eq!(kind_of("user.Name"), ReferenceKind::FieldAccess);
+    }
+
+    #[test]
+    fn test_update_files_only_reindexes_changed_files() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let root = temp_dir.path();
+        let fixture = Path::new(env!("CARGO_MANIFEST_DIR"))
+            .join("../../tests/claude-plugin-integration/test-repos/go-sample");
+        let files: Vec<PathBuf> = ["main.go", "payment.go", "user.go"]
+            .iter()
+            .map(|name| {
+                std::fs::copy(fixture.join(name), root.join(name)).unwrap();
+                root.join(name)
+            })
+            .collect();
+
+        let mut index = CodeIndex::with_root(root.to_path_buf());
+        index.update_files(&files, &IndexOptions::default());
+
+        let names_in = |index: &CodeIndex, file: &str| -> Vec<String> {
+            let mut names: Vec<String> = index
+                .symbols_in_file(Path::new(file))
+                .iter()
+                .map(|s| s.qualified.clone())
+                .collect();
+            names.sort();
+            names
+        };
+        let main_before = names_in(&index, "main.go");
+        let user_before = names_in(&index, "user.go");
+        let main_refs_before = index.references_in_file(Path::new("main.go")).len();
+        assert!(names_in(&index, "payment.go")
+            .iter()
+            .any(|q| q.ends_with("RefundPayment")));
+
+        // Drop RefundPayment from payment.go
+        let payment = std::fs::read_to_string(root.join("payment.go")).unwrap();
+        let cut = payment
+            .find("func (ps *PaymentService) RefundPayment")
+            .unwrap();
+        std::fs::write(root.join("payment.go"), &payment[..cut]).unwrap();
+
+        let update = index.update_files(&[PathBuf::from("payment.go")], &IndexOptions::default());
+
+        assert_eq!(update.files, vec![PathBuf::from("payment.go")]);
+        assert!(update.removed.iter().any(|s| s.name == "RefundPayment"));
+        assert!(update.added.iter().all(|s| s.name != "RefundPayment"));
+        let payment_after = names_in(&index, "payment.go");
+        assert!(payment_after.iter().all(|q| !q.ends_with("RefundPayment")));
+        assert!(payment_after.iter().any(|q| q.ends_with("ProcessPayment")));
+
+        assert_eq!(names_in(&index, "main.go"), main_before);
+        assert_eq!(names_in(&index, "user.go"), user_before);
+        assert_eq!(
+            index.references_in_file(Path::new("main.go")).len(),
+            main_refs_before
+        );
+
+        // A deleted file is dropped from the index
+        std::fs::remove_file(root.join("user.go")).unwrap();
+        let update = index.update_files(&[root.join("user.go")], &IndexOptions::default());
+        assert_eq!(update.files, vec![PathBuf::from("user.go")]);
+        assert_eq!(update.removed.len(), user_before.len());
+        assert!(!index.contains_file(Path::new("user.go")));
+        assert_eq!(names_in(&index, "main.go"), main_before);
+    }
+
+    #[test]
+    fn test_implementations_declared_and_structural() {
+        let mut index = CodeIndex::new();
+        let mut iface = make_symbol("Repository", "com.example.Repository", "src/Repo.java");
+        iface.kind = SymbolKind::Interface;
+        iface.language = "java".to_string();
+        index.add_symbol(iface);
+        let mut class = make_symbol("SqlRepository", "com.example.SqlRepository", "src/Sql.java");
+        class.kind = SymbolKind::Class;
+        class.implements = Some(vec!["Repository".to_string()]);
+        index.add_symbol(class);
+
+        let found = index.implementations("com.example.Repository");
+        assert_eq!(found.len(), 1);
+        assert_eq!(found[0].qualified, "com.example.SqlRepository");
+
+        // Go types implement interfaces implicitly
+        let mut shape = make_symbol("Shape", "shapes.Shape", "shapes.go");
+        shape.kind = SymbolKind::Interface;
+        shape.language = "go".to_string();
+        index.add_symbol(shape);
+        let mut area = make_symbol("Area", "shapes.Shape.Area", "shapes.go");
+        area.language = "go".to_string();
+        area.parent = Some("shapes.Shape".to_string());
+        area.signature = Some("func Area() float64".to_string());
+        index.add_symbol(area);
+        let mut square = make_symbol("Square", "shapes.Square", "shapes.go");
+        square.kind = SymbolKind::Class;
+        square.language = "go".to_string();
+        index.add_symbol(square);
+        let mut square_area = make_symbol("Area", "shapes.Square.Area", "shapes.go");
+        square_area.language = "go".to_string();
+        square_area.parent = Some("Square".to_string());
+        square_area.signature = Some("func (Square) Area() float64".to_string());
+        index.add_symbol(square_area);
+
+        let found = index.implementations("shapes.Shape");
+        assert_eq!(found.len(), 1);
+        assert_eq!(found[0].qualified, "shapes.Square");
+        assert!(index.implementations("shapes.Square").is_empty());
+    }
+
+    #[test]
+    fn test_implementations_from_rust_trait_impl_methods() {
+        let mut index = CodeIndex::new();
+        let mut shape = make_symbol("Shape", "geo::Shape", "src/geo.rs");
+        shape.kind = SymbolKind::Interface;
+        index.add_symbol(shape);
+        let mut circle = make_symbol("Circle", "geo::Circle", "src/geo.rs");
+        circle.kind = SymbolKind::Class;
+        index.add_symbol(circle);
+        // `impl Shape for Circle` methods name the trait; both stand for Circle
+        for method in ["area", "perimeter"] {
+            let mut m = make_symbol(method, &format!("geo::Circle::{}", method), "src/geo.rs");
+            m.parent = Some("geo::Circle".to_string());
+            m.implements = Some(vec!["Shape".to_string()]);
+            index.add_symbol(m);
+        }
+
+        let found = index.implementations("geo::Shape");
+        assert_eq!(found.len(), 1);
+        assert_eq!(found[0].qualified, "geo::Circle");
+    }
+
     #[test]
     fn test_find_references() {
         let mut index = CodeIndex::new();
@@ -766,6 +1272,9 @@ mod tests {
             Reference {
                 name: "helper".to_string(),
                 location: Location::new(PathBuf::from("src/Main.fs"), 10, 5),
+                kind: ReferenceKind::Unknown,
+                target: None,
+                arguments: None,
             },
         );
         index.add_reference(
@@ -773,6 +1282,9 @@ mod tests {
             Reference {
                 name: "Utils.helper".to_string(),
                 location: Location::new(PathBuf::from("src/Main.fs"), 15, 5),
+                kind: ReferenceKind::Unknown,
+                target: None,
+                arguments: None,
             },
         );
         index.add_reference(
@@ -780,6 +1292,9 @@ mod tests {
             Reference {
                 name: "helper".to_string(),
                 location: Location::new(PathBuf::from("src/Other.fs"), 20, 5),
+                kind: ReferenceKind::Unknown,
+                target: None,
+                arguments: None,
             },
         );
 
@@ -813,6 +1328,7 @@ mod tests {
             implements: None,
             doc: None,
             signature: None,
+            extent: None,
         };
         let sym2 = Symbol {
             name: "parse".to_string(),
@@ -827,6 +1343,7 @@ mod tests {
             implements: None,
             doc: None,
             signature: None,
+            extent: None,
         };
 
         index.add_symbol(sym1);
@@ -864,6 +1381,7 @@ mod tests {
             implements: None,
             doc: None,
             signature: None,
+            extent: None,
         };
         let sym2 = Symbol {
             name: "config".to_string(),
@@ -878,6 +1396,7 @@ mod tests {
             implements: None,
             doc: None,
             signature: None,
+            extent: None,
         };
 
         index.add_symbol(sym1);
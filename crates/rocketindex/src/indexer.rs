@@ -0,0 +1,831 @@
+//! Parallel parsing of many files into a [`CodeIndex`].
+//!
+//! [`CodeIndex::index_files`] reads and parses files on a pool of worker
+//! threads, then merges the per-file results into the index in a single
+//! sequential step. Files are merged in sorted path order, so the resulting
+//! index (including the order of overloads under one qualified name) and any
+//! [`crate::callgraph::CallGraph`] built from it are the same no matter how
+//! many workers ran or in which order they finished.
+//!
+//! # Examples
+//!
+//! ```no_run
+//! use rocketindex::indexer::IndexOptions;
+//! use rocketindex::watch::find_source_files;
+//! use rocketindex::CodeIndex;
+//! use std::path::PathBuf;
+//!
+//! let root = PathBuf::from(".");
+//! let files = find_source_files(&root).unwrap();
+//!
+//! let mut index = CodeIndex::with_root(root);
+//! let options = IndexOptions {
+//!     threads: 4,
+//!     ..IndexOptions::default()
+//! };
+//! let update = index.index_files(&files, &options);
+//! for diagnostic in &update.diagnostics {
+//!     eprintln!("{}: {}", diagnostic.file.display(), diagnostic.message);
+//! }
+//! ```
+//!
+//! A file that fails to parse does not stop the run. Tree-sitter recovers
+//! from syntax errors, so whatever the partial parse yields is indexed and
+//! each error is reported as a [`Diagnostic`] in
+//! [`IndexUpdate::diagnostics`]; files that cannot be read as text, or whose
+//! parser panics, are reported the same way with nothing indexed. It is up to
+//! the caller whether diagnostics fail the run.
+//!
+//! Files larger than [`IndexOptions::max_file_bytes`] and files that look
+//! binary (a NUL byte near the start) are skipped before parsing, so one huge
+//! generated file or a misnamed blob cannot stall the run; each is reported
+//! as a diagnostic giving the reason. [`read_source`] applies the same checks
+//! for callers that parse files themselves. With
+//! [`IndexOptions::skip_generated`], files with a generated-code header (see
+//! [`crate::generated`]) are indexed as empty, without a diagnostic, since
+//! leaving them out is what was asked for.
+//!
+//! [`IndexOptions::progress`] is called as each file finishes, for rendering
+//! a progress bar. Workers take turns calling it, so it never runs
+//! concurrently with itself and `done` only goes up:
+//!
+//! ```no_run
+//! # use rocketindex::indexer::{IndexOptions, Progress};
+//! # use rocketindex::CodeIndex;
+//! # let (mut index, files) = (CodeIndex::new(), Vec::new());
+//! let options = IndexOptions {
+//!     progress: Some(Progress::new(|done, total, _path| {
+//!         eprint!("\rindexed {done}/{total}");
+//!     })),
+//!     ..IndexOptions::default()
+//! };
+//! index.index_files(&files, &options);
+//! ```
+
+use std::fmt;
+use std::io;
+use std::panic::{self, AssertUnwindSafe};
+use std::path::{Path, PathBuf};
+use std::sync::atomic::{AtomicUsize, Ordering};
+use std::sync::{Arc, Mutex, PoisonError};
+use std::thread;
+
+use serde::Serialize;
+
+use crate::freshness::FileHash;
+use crate::language_config::LanguageConfigs;
+use crate::parse::{extract_symbols_with, ParseResult, SyntaxError};
+use crate::postprocess::PostProcessors;
+use crate::{CodeIndex, IndexUpdate, KindSet, Location};
+
+/// Default maximum recursion depth for symbol extraction (matches the config default).
+const DEFAULT_MAX_DEPTH: usize = 500;
+
+/// Default for [`IndexOptions::max_file_bytes`] (4 MiB).
+pub const DEFAULT_MAX_FILE_BYTES: u64 = 4 * 1024 * 1024;
+
+/// How much of a file is checked for NUL bytes when detecting binaries.
+const BINARY_SNIFF_BYTES: usize = 8 * 1024;
+
+/// Options for [`CodeIndex::index_files`].
+#[derive(Debug, Clone)]
+pub struct IndexOptions {
+    /// Maximum recursion depth for symbol extraction
+    pub max_depth: usize,
+    /// Number of parser threads (0 = one per available CPU)
+    pub threads: usize,
+    /// Keep symbol doc comments (disable to save memory when docs are unused)
+    pub docs: bool,
+    /// Symbol kinds to store (see [`ParseResult::retain_kinds`])
+    pub kinds: KindSet,
+    /// Skip files larger than this many bytes (0 = no limit)
+    pub max_file_bytes: u64,
+    /// Keep a copy of each indexed file's source for
+    /// [`CodeIndex::snippet`] (see [`crate::snippet`])
+    pub cache_sources: bool,
+    /// Leave files with a generated-code header out of the index (see
+    /// [`crate::generated`])
+    pub skip_generated: bool,
+    /// Called after each file is processed (parsed, skipped or removed)
+    pub progress: Option<Progress>,
+    /// Run on every parsed symbol, in registration order (see [`crate::postprocess`])
+    pub post_processors: PostProcessors,
+    /// Generated-code markers and doc comment syntax per language (see
+    /// [`crate::language_config`])
+    pub languages: LanguageConfigs,
+}
+
+impl Default for IndexOptions {
+    fn default() -> Self {
+        Self {
+            max_depth: DEFAULT_MAX_DEPTH,
+            threads: 0,
+            docs: true,
+            kinds: KindSet::all(),
+            max_file_bytes: DEFAULT_MAX_FILE_BYTES,
+            cache_sources: false,
+            skip_generated: false,
+            progress: None,
+            post_processors: PostProcessors::default(),
+            languages: LanguageConfigs::default(),
+        }
+    }
+}
+
+impl IndexOptions {
+    /// Number of worker threads to use for `file_count` files.
+    pub(crate) fn worker_count(&self, file_count: usize) -> usize {
+        let threads = if self.threads == 0 {
+            thread::available_parallelism().map_or(1, |n| n.get())
+        } else {
+            self.threads
+        };
+        threads.clamp(1, file_count.max(1))
+    }
+}
+
+/// A progress callback for [`IndexOptions::progress`].
+///
+/// Receives the number of files done so far, the number of files in the run,
+/// and the (absolute) path of the file just finished.
+#[derive(Clone)]
+pub struct Progress(Arc<ProgressFn>);
+
+type ProgressFn = dyn Fn(usize, usize, &Path) + Send + Sync;
+
+impl Progress {
+    pub fn new(callback: impl Fn(usize, usize, &Path) + Send + Sync + 'static) -> Self {
+        Self(Arc::new(callback))
+    }
+}
+
+impl fmt::Debug for Progress {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.write_str("Progress(..)")
+    }
+}
+
+/// Counts finished files for a [`Progress`] callback shared by workers.
+///
+/// The count is bumped and the callback run under one lock, so calls are
+/// serialized and `done` increases by one each time. Without a callback
+/// nothing is locked.
+pub(crate) struct ProgressTracker<'a> {
+    progress: Option<&'a Progress>,
+    total: usize,
+    done: Mutex<usize>,
+}
+
+impl<'a> ProgressTracker<'a> {
+    pub(crate) fn new(progress: Option<&'a Progress>, total: usize) -> Self {
+        Self {
+            progress,
+            total,
+            done: Mutex::new(0),
+        }
+    }
+
+    pub(crate) fn finished(&self, file: &Path) {
+        let Some(progress) = self.progress else {
+            return;
+        };
+        let mut done = self.done.lock().unwrap_or_else(PoisonError::into_inner);
+        *done += 1;
+        (progress.0)(*done, self.total, file);
+    }
+}
+
+/// A problem found while indexing one file.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct Diagnostic {
+    /// File the problem is in (relative to the workspace root)
+    pub file: PathBuf,
+    /// What went wrong
+    pub message: String,
+    /// Approximate position of the problem, if known
+    pub location: Option<Location>,
+}
+
+impl CodeIndex {
+    /// Parse `paths` in parallel and replace their entries in the index.
+    ///
+    /// Relative paths are resolved against the workspace root. Paths that do
+    /// not exist are removed from the index, like [`CodeIndex::update_files`].
+    /// Duplicate paths are indexed once. Syntax errors and unreadable files
+    /// are returned in [`IndexUpdate::diagnostics`] rather than stopping the
+    /// run.
+    ///
+    /// Files whose contents are unchanged since they were last indexed (see
+    /// [`crate::freshness`]) are not re-parsed and are left out of the
+    /// update, so their earlier diagnostics are not reported again.
+    pub fn index_files(&mut self, paths: &[PathBuf], options: &IndexOptions) -> IndexUpdate {
+        let mut files: Vec<PathBuf> = paths.iter().map(|p| self.to_absolute(p)).collect();
+        files.sort();
+        files.dedup();
+
+        let indexed: Vec<Option<FileHash>> = files.iter().map(|f| self.file_hash(f)).collect();
+        let progress = ProgressTracker::new(options.progress.as_ref(), files.len());
+        let reindexed = map_files(&files, options, |i, file| {
+            let reindexed = reindex_file(file, indexed[i], options);
+            progress.finished(file);
+            reindexed
+        });
+
+        // Single merge step, in path order, independent of worker scheduling
+        let mut update = IndexUpdate::default();
+        for (file, reindexed) in files.iter().zip(reindexed) {
+            let file_update = match reindexed {
+                Reindexed::Unchanged(source) => {
+                    if let Some(source) = source {
+                        self.set_cached_source(file, source);
+                    }
+                    continue;
+                }
+                Reindexed::Parsed(result, hash, source) => {
+                    let mut replaced = self.replace_file(file, &result);
+                    replaced.diagnostics = self.diagnostics(file, result.errors);
+                    if let Some(hash) = hash {
+                        self.set_file_hash(file, hash);
+                    }
+                    if let Some(source) = source {
+                        self.set_cached_source(file, source);
+                    }
+                    replaced
+                }
+                Reindexed::Missing => self.remove_file(file),
+            };
+            update.merge(file_update);
+        }
+        update
+    }
+
+    fn diagnostics(&self, file: &Path, errors: Vec<SyntaxError>) -> Vec<Diagnostic> {
+        let relative = self.to_relative(file);
+        errors
+            .into_iter()
+            .map(|error| Diagnostic {
+                file: relative.clone(),
+                message: error.message,
+                location: Some(Location {
+                    file: relative.clone(),
+                    ..error.location
+                }),
+            })
+            .collect()
+    }
+}
+
+/// Read and parse every file on a worker pool.
+///
+/// Returns one entry per input file, in input order; `None` marks a file
+/// that does not exist.
+pub(crate) fn parse_files(files: &[PathBuf], options: &IndexOptions) -> Vec<Option<ParseResult>> {
+    map_files(files, options, |_, file| parse_file(file, options))
+}
+
+/// Run `process` on every file (with its position in `files`) on a worker
+/// pool, returning the results in input order.
+fn map_files<T: Send>(
+    files: &[PathBuf],
+    options: &IndexOptions,
+    process: impl Fn(usize, &Path) -> T + Sync,
+) -> Vec<T> {
+    let workers = options.worker_count(files.len());
+    if workers == 1 {
+        return files
+            .iter()
+            .enumerate()
+            .map(|(i, file)| process(i, file))
+            .collect();
+    }
+
+    let next = AtomicUsize::new(0);
+    let mut results: Vec<Option<T>> = files.iter().map(|_| None).collect();
+
+    thread::scope(|scope| {
+        let handles: Vec<_> = (0..workers)
+            .map(|_| {
+                scope.spawn(|| {
+                    let mut parsed = Vec::new();
+                    loop {
+                        let i = next.fetch_add(1, Ordering::Relaxed);
+                        let Some(file) = files.get(i) else {
+                            break;
+                        };
+                        parsed.push((i, process(i, file)));
+                    }
+                    parsed
+                })
+            })
+            .collect();
+
+        for handle in handles {
+            match handle.join() {
+                Ok(parsed) => {
+                    for (i, result) in parsed {
+                        results[i] = Some(result);
+                    }
+                }
+                Err(panic) => std::panic::resume_unwind(panic),
+            }
+        }
+    });
+
+    results
+        .into_iter()
+        .map(|result| result.expect("every file is processed"))
+        .collect()
+}
+
+/// Why a source file was not parsed.
+#[derive(Debug, thiserror::Error)]
+pub enum SkipReason {
+    #[error("skipped: file is {bytes} bytes, over the {limit} byte limit")]
+    TooLarge { bytes: u64, limit: u64 },
+    #[error("skipped: binary file")]
+    Binary,
+    #[error("could not read file: {0}")]
+    Unreadable(#[from] io::Error),
+}
+
+/// Read a source file for parsing.
+///
+/// Fails without reading the contents if the file is larger than
+/// `max_file_bytes` (0 = no limit), and fails if the file looks binary or is
+/// not UTF-8.
+pub fn read_source(file: &Path, max_file_bytes: u64) -> std::result::Result<String, SkipReason> {
+    if max_file_bytes > 0 {
+        let bytes = std::fs::metadata(file)?.len();
+        if bytes > max_file_bytes {
+            return Err(SkipReason::TooLarge {
+                bytes,
+                limit: max_file_bytes,
+            });
+        }
+    }
+
+    let contents = std::fs::read(file)?;
+    if is_binary(&contents) {
+        return Err(SkipReason::Binary);
+    }
+    String::from_utf8(contents)
+        .map_err(|e| SkipReason::Unreadable(io::Error::new(io::ErrorKind::InvalidData, e)))
+}
+
+/// Whether `contents` looks binary: a NUL byte in its first few kilobytes.
+///
+/// Source text never contains NUL, while executables, images, and archives
+/// almost always do early on.
+pub fn is_binary(contents: &[u8]) -> bool {
+    contents[..contents.len().min(BINARY_SNIFF_BYTES)].contains(&0)
+}
+
+/// What [`CodeIndex::index_files`] found when it re-read one file.
+enum Reindexed {
+    /// The file does not exist
+    Missing,
+    /// The contents hash the same as when the file was last indexed (with
+    /// the contents, if they are to be cached)
+    Unchanged(Option<String>),
+    /// The parse result, with the hash of the contents it came from (`None`
+    /// if the file could not be read) and the contents, if they are to be
+    /// cached
+    Parsed(ParseResult, Option<FileHash>, Option<String>),
+}
+
+/// Read one file and parse it, unless its contents still match `indexed`.
+///
+/// Like [`FileHash::matches_file`], the sizes are compared first: a file
+/// whose size changed is re-parsed without comparing hashes.
+fn reindex_file(file: &Path, indexed: Option<FileHash>, options: &IndexOptions) -> Reindexed {
+    let indexed = indexed
+        .filter(|hash| std::fs::metadata(file).is_ok_and(|metadata| metadata.len() == hash.size));
+    let source = match read_source(file, options.max_file_bytes) {
+        Ok(source) => source,
+        Err(reason) => {
+            return match skipped(file, reason) {
+                Some(result) => Reindexed::Parsed(result, None, None),
+                None => Reindexed::Missing,
+            }
+        }
+    };
+
+    let hash = FileHash::of(source.as_bytes());
+    if indexed == Some(hash) {
+        return Reindexed::Unchanged(options.cache_sources.then_some(source));
+    }
+    let result = parse_source(file, &source, options);
+    Reindexed::Parsed(result, Some(hash), options.cache_sources.then_some(source))
+}
+
+/// Read and parse one file.
+///
+/// Returns `None` if the file does not exist. A file that exists but is
+/// skipped (see [`read_source`]) or cannot be read as text, or whose parser
+/// panics, yields an empty result carrying the reason, so the caller reports
+/// it instead of dropping it silently.
+pub(crate) fn parse_file(file: &Path, options: &IndexOptions) -> Option<ParseResult> {
+    match read_source(file, options.max_file_bytes) {
+        Ok(source) => Some(parse_source(file, &source, options)),
+        Err(reason) => skipped(file, reason),
+    }
+}
+
+/// The result for a file [`read_source`] refused: `None` if it does not
+/// exist, else an empty result carrying the reason.
+fn skipped(file: &Path, reason: SkipReason) -> Option<ParseResult> {
+    if !file.exists() {
+        return None;
+    }
+    tracing::warn!("Skipping file {:?}: {}", file, reason);
+    Some(failed(file, reason.to_string()))
+}
+
+/// Parse `source`, read from `file`, catching parser panics.
+fn parse_source(file: &Path, source: &str, options: &IndexOptions) -> ParseResult {
+    if options.skip_generated && options.languages.is_generated(file, source) {
+        return ParseResult::default();
+    }
+    let parsed = panic::catch_unwind(AssertUnwindSafe(|| {
+        extract_symbols_with(file, source, options.max_depth, &options.languages)
+    }));
+    let mut result = match parsed {
+        Ok(result) => result,
+        Err(panic) => {
+            let message = panic
+                .downcast_ref::<&str>()
+                .map(|s| s.to_string())
+                .or_else(|| panic.downcast_ref::<String>().cloned())
+                .unwrap_or_else(|| "unknown error".to_string());
+            tracing::warn!("Parser panicked on {:?}: {}", file, message);
+            return failed(file, format!("parser panicked: {}", message));
+        }
+    };
+    if !options.docs {
+        result.strip_docs();
+    }
+    result.retain_kinds(options.kinds);
+    options.post_processors.run(file, source, &mut result);
+    result
+}
+
+/// An empty parse result recording why `file` could not be parsed.
+fn failed(file: &Path, message: String) -> ParseResult {
+    ParseResult {
+        errors: vec![SyntaxError {
+            message,
+            location: Location::new(file.to_path_buf(), 1, 1),
+        }],
+        ..ParseResult::default()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::callgraph::CallGraph;
+
+    fn fixture_files() -> (PathBuf, Vec<PathBuf>) {
+        let root = Path::new(env!("CARGO_MANIFEST_DIR")).join("../../tests/fixtures/minimal");
+        let files = crate::watch::find_source_files(&root).unwrap();
+        assert!(files.len() > 10, "expected fixtures under {:?}", root);
+        (root, files)
+    }
+
+    /// Everything observable about an index, in a canonical order.
+    fn snapshot(index: &CodeIndex) -> Vec<String> {
+        let mut qualified: Vec<&str> = index.symbols().map(|s| s.qualified.as_str()).collect();
+        qualified.sort_unstable();
+        qualified.dedup();
+
+        let mut lines = Vec::new();
+        for name in qualified {
+            // get_all preserves insertion order, so overload order is checked too
+            for sym in index.get_all(name) {
+                lines.push(format!(
+                    "{} {:?} {}:{}",
+                    sym.qualified,
+                    sym.kind,
+                    sym.location.file.display(),
+                    sym.location.line
+                ));
+            }
+        }
+
+        let mut files: Vec<&PathBuf> = index.files().collect();
+        files.sort();
+        for file in files {
+            for reference in index.references_in_file(file) {
+                lines.push(format!(
+                    "ref {} {}:{}",
+                    reference.name,
+                    reference.location.file.display(),
+                    reference.location.line
+                ));
+            }
+        }
+        lines
+    }
+
+    #[test]
+    fn test_index_files_is_deterministic_across_thread_counts() {
+        let (root, files) = fixture_files();
+
+        let mut sequential = CodeIndex::with_root(root.clone());
+        sequential.index_files(
+            &files,
+            &IndexOptions {
+                threads: 1,
+                ..IndexOptions::default()
+            },
+        );
+        let expected = snapshot(&sequential);
+        let expected_sites = CallGraph::build(&sequential).sites().to_vec();
+        assert!(!expected.is_empty());
+
+        for threads in [2, 4, 8, 0] {
+            // Reverse the input so completion order differs from merge order
+            let mut reversed = files.clone();
+            reversed.reverse();
+
+            let mut parallel = CodeIndex::with_root(root.clone());
+            parallel.index_files(
+                &reversed,
+                &IndexOptions {
+                    threads,
+                    ..IndexOptions::default()
+                },
+            );
+            assert_eq!(snapshot(&parallel), expected, "threads = {}", threads);
+            assert_eq!(
+                CallGraph::build(&parallel).sites(),
+                expected_sites.as_slice(),
+                "threads = {}",
+                threads
+            );
+        }
+    }
+
+    #[test]
+    fn test_index_files_removes_missing_and_dedupes_paths() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let root = temp_dir.path();
+        std::fs::write(root.join("a.py"), "def first():\n    pass\n").unwrap();
+        std::fs::write(root.join("b.py"), "def second():\n    pass\n").unwrap();
+
+        let mut index = CodeIndex::with_root(root.to_path_buf());
+        let paths = vec![root.join("b.py"), root.join("a.py"), PathBuf::from("a.py")];
+        let update = index.index_files(&paths, &IndexOptions::default());
+        assert_eq!(
+            update.files,
+            vec![PathBuf::from("a.py"), PathBuf::from("b.py")]
+        );
+        assert_eq!(index.file_count(), 2);
+
+        std::fs::remove_file(root.join("b.py")).unwrap();
+        let update = index.index_files(&[root.join("b.py")], &IndexOptions::default());
+        assert_eq!(update.removed.len(), 1);
+        assert!(!index.contains_file(Path::new("b.py")));
+        assert!(index.contains_file(Path::new("a.py")));
+    }
+
+    #[test]
+    fn test_index_files_can_skip_docs() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let root = temp_dir.path();
+        std::fs::write(
+            root.join("a.py"),
+            "def first():\n    \"\"\"Say hello.\"\"\"\n    pass\n",
+        )
+        .unwrap();
+        let paths = vec![root.join("a.py")];
+
+        let mut index = CodeIndex::with_root(root.to_path_buf());
+        index.index_files(&paths, &IndexOptions::default());
+        assert_eq!(
+            index.get("first").unwrap().doc.as_deref(),
+            Some("Say hello.")
+        );
+
+        let mut index = CodeIndex::with_root(root.to_path_buf());
+        let options = IndexOptions {
+            docs: false,
+            ..IndexOptions::default()
+        };
+        index.index_files(&paths, &options);
+        assert!(index.get("first").unwrap().doc.is_none());
+    }
+
+    #[test]
+    fn test_generated_files_are_tagged_or_skipped() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let root = temp_dir.path();
+        std::fs::write(
+            root.join("color_string.go"),
+            "// Code generated by \"stringer -type=Color\"; DO NOT EDIT.\n\npackage main\n\nfunc (c Color) String() string { return \"\" }\n",
+        )
+        .unwrap();
+        std::fs::write(root.join("color.go"), "package main\n\ntype Color int\n").unwrap();
+        let paths = vec![root.join("color.go"), root.join("color_string.go")];
+
+        let mut index = CodeIndex::with_root(root.to_path_buf());
+        index.index_files(&paths, &IndexOptions::default());
+        assert!(index.get("main.Color.String").unwrap().is_generated());
+        assert!(!index.get("main.Color").unwrap().is_generated());
+
+        let mut index = CodeIndex::with_root(root.to_path_buf());
+        let options = IndexOptions {
+            skip_generated: true,
+            ..IndexOptions::default()
+        };
+        let update = index.index_files(&paths, &options);
+        assert!(update.diagnostics.is_empty());
+        assert!(index.get("main.Color.String").is_none());
+        assert!(index.get("main.Color").is_some());
+    }
+
+    #[test]
+    fn test_broken_go_file_is_reported_and_the_rest_indexed() {
+        let root = Path::new(env!("CARGO_MANIFEST_DIR")).join("../../tests/fixtures/go-broken");
+        let files = crate::watch::find_source_files(&root).unwrap();
+        assert_eq!(files.len(), 3);
+
+        let mut index = CodeIndex::with_root(root);
+        let update = index.index_files(&files, &IndexOptions::default());
+
+        assert!(index.get("main.main").is_some());
+        assert!(index.get("main.Greet").is_some());
+        // Partial parse of the broken file
+        assert!(index.get("main.Recovered").is_some());
+
+        assert!(!update.diagnostics.is_empty());
+        for diagnostic in &update.diagnostics {
+            assert_eq!(diagnostic.file, PathBuf::from("broken.go"));
+            let location = diagnostic.location.as_ref().unwrap();
+            assert_eq!(location.file, PathBuf::from("broken.go"));
+            assert!(location.line >= 9, "{:?}", diagnostic);
+        }
+    }
+
+    #[test]
+    fn test_files_with_unchanged_contents_are_not_reparsed() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let root = temp_dir.path();
+        std::fs::write(root.join("a.py"), "def first():\n    pass\n").unwrap();
+        std::fs::write(root.join("b.py"), "def second():\n    pass\n").unwrap();
+        let paths = vec![root.join("a.py"), root.join("b.py")];
+
+        let mut index = CodeIndex::with_root(root.to_path_buf());
+        let update = index.index_files(&paths, &IndexOptions::default());
+        assert_eq!(update.files.len(), 2);
+        let hash = index.file_hash(Path::new("a.py")).unwrap();
+        assert_eq!(hash, FileHash::of(b"def first():\n    pass\n"));
+
+        // Both files are rewritten, but only b.py changes
+        std::fs::write(root.join("a.py"), "def first():\n    pass\n").unwrap();
+        std::fs::write(root.join("b.py"), "def third():\n    pass\n").unwrap();
+        let update = index.index_files(&paths, &IndexOptions::default());
+        assert_eq!(update.files, vec![PathBuf::from("b.py")]);
+        assert!(update.removed.iter().any(|s| s.name == "second"));
+        assert!(index.get("first").is_some());
+        assert!(index.get("third").is_some());
+        assert_eq!(index.file_hash(Path::new("a.py")), Some(hash));
+    }
+
+    #[test]
+    fn test_progress_is_reported_once_per_file_in_order() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let root = temp_dir.path();
+        let mut paths = Vec::new();
+        for i in 0..20 {
+            let path = root.join(format!("m{i}.py"));
+            std::fs::write(&path, format!("def f{i}():\n    pass\n")).unwrap();
+            paths.push(path);
+        }
+        // Missing files are reported too
+        paths.push(root.join("gone.py"));
+
+        let seen = Arc::new(Mutex::new(Vec::new()));
+        let recorded = Arc::clone(&seen);
+        let options = IndexOptions {
+            threads: 4,
+            progress: Some(Progress::new(move |done, total, path| {
+                recorded
+                    .lock()
+                    .unwrap()
+                    .push((done, total, path.to_path_buf()));
+            })),
+            ..IndexOptions::default()
+        };
+        let mut index = CodeIndex::with_root(root.to_path_buf());
+        index.index_files(&paths, &options);
+
+        let seen = seen.lock().unwrap();
+        let done: Vec<usize> = seen.iter().map(|(done, _, _)| *done).collect();
+        assert_eq!(done, (1..=21).collect::<Vec<_>>());
+        assert!(seen.iter().all(|(_, total, _)| *total == 21));
+        let mut reported: Vec<PathBuf> = seen.iter().map(|(_, _, path)| path.clone()).collect();
+        reported.sort();
+        paths.sort();
+        assert_eq!(reported, paths);
+    }
+
+    #[test]
+    fn test_unreadable_file_is_a_diagnostic() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let root = temp_dir.path();
+        std::fs::write(root.join("bad.py"), [0x64, 0x65, 0x66, 0xff, 0xfe]).unwrap();
+
+        let mut index = CodeIndex::with_root(root.to_path_buf());
+        let update = index.index_files(&[root.join("bad.py")], &IndexOptions::default());
+        assert_eq!(update.diagnostics.len(), 1);
+        assert_eq!(update.diagnostics[0].file, PathBuf::from("bad.py"));
+        assert!(update.diagnostics[0]
+            .message
+            .starts_with("could not read file"));
+        assert_eq!(index.symbol_count(), 0);
+
+        // A missing file is a removal, not a problem
+        let update = index.index_files(&[root.join("gone.py")], &IndexOptions::default());
+        assert!(update.diagnostics.is_empty());
+    }
+
+    #[test]
+    fn test_oversized_and_binary_files_are_skipped_with_a_reason() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let root = temp_dir.path();
+        let big = format!("package main\n\n// {}\nfunc Big() {{}}\n", "x".repeat(200));
+        std::fs::write(root.join("big.go"), &big).unwrap();
+        let mut blob = b"package main\n".to_vec();
+        blob.extend_from_slice(&[0x7f, 0x45, 0x00, 0x01]);
+        std::fs::write(root.join("blob.go"), blob).unwrap();
+        std::fs::write(root.join("small.py"), "def small():\n    pass\n").unwrap();
+        let paths = vec![
+            root.join("big.go"),
+            root.join("blob.go"),
+            root.join("small.py"),
+        ];
+
+        let mut index = CodeIndex::with_root(root.to_path_buf());
+        let options = IndexOptions {
+            max_file_bytes: 100,
+            ..IndexOptions::default()
+        };
+        let update = index.index_files(&paths, &options);
+
+        let messages: Vec<(String, &str)> = update
+            .diagnostics
+            .iter()
+            .map(|d| (d.file.display().to_string(), d.message.as_str()))
+            .collect();
+        let expected_big = format!(
+            "skipped: file is {} bytes, over the 100 byte limit",
+            big.len()
+        );
+        assert_eq!(
+            messages,
+            vec![
+                ("big.go".to_string(), expected_big.as_str()),
+                ("blob.go".to_string(), "skipped: binary file"),
+            ]
+        );
+        assert!(index.get("small").is_some());
+        assert!(!index.contains_file(Path::new("big.go")));
+
+        // Without a limit the large file is indexed; the blob never is
+        let options = IndexOptions {
+            max_file_bytes: 0,
+            ..IndexOptions::default()
+        };
+        let update = index.index_files(&paths, &options);
+        assert_eq!(update.diagnostics.len(), 1);
+        assert!(matches!(
+            read_source(&root.join("blob.go"), 0),
+            Err(SkipReason::Binary)
+        ));
+        assert!(read_source(&root.join("big.go"), 0).is_ok());
+    }
+
+    #[test]
+    fn test_is_binary_only_sniffs_the_start() {
+        assert!(!is_binary(b"package main\n"));
+        assert!(!is_binary(b""));
+        assert!(is_binary(b"\x00ELF"));
+
+        let mut late = vec![b'a'; BINARY_SNIFF_BYTES];
+        late.push(0);
+        assert!(!is_binary(&late));
+    }
+
+    #[test]
+    fn test_worker_count() {
+        let options = IndexOptions {
+            threads: 8,
+            ..IndexOptions::default()
+        };
+        assert_eq!(options.worker_count(3), 3);
+        assert_eq!(options.worker_count(100), 8);
+        assert_eq!(options.worker_count(0), 1);
+        assert!(IndexOptions::default().worker_count(100) >= 1);
+    }
+}
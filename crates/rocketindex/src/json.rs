@@ -0,0 +1,636 @@
+//! Deterministic JSON export of the index with stable symbol IDs.
+//!
+//! [`CodeIndex::export_json`] writes every symbol and call edge in a form
+//! meant for machines: diffing two exports in CI shows exactly which symbols
+//! and calls were added, removed, or moved.
+//!
+//! # Stable IDs
+//!
+//! A symbol's ID is derived from its content, never from its position in the
+//! index: the 64-bit FNV-1a hash of its kind and qualified name, written as 16
+//! hex digits (see [`stable_id`]). In most languages the qualified name
+//! already carries the package or module the symbol is declared in
+//! (`models.User`, `app::models::User`). A Go qualified name carries only the
+//! package *name*, which packages in different directories share (`main.main`
+//! in `cmd/a` and `cmd/b`), so for Go symbols the package's directory
+//! relative to the workspace root, its import path within the module, is
+//! hashed in too (see [`stable_id_in`]). Renaming or re-kinding a symbol, or
+//! moving it to another package, changes its ID; moving it to another line or
+//! another file of the same package does not.
+//!
+//! Symbols that still share an identity collide. Each language keeps these
+//! rare: Go closures are named after their enclosing function
+//! (`main.run.func1`) and generic instantiations are not separate symbols.
+//! What remains are overloads (Java, C++) and redefinitions of one name.
+//! Colliding symbols are ordered by signature, then by directory, file name,
+//! and position, and numbered in that order; the number is hashed in as
+//! well, so the first keeps the plain ID. Overloads with distinct signatures
+//! therefore keep their IDs when their files move, and only collisions with
+//! identical signatures fall back to location.
+//!
+//! Call edges refer to symbols by ID. A callee name shared by several
+//! overloads refers to the first callable one.
+//!
+//! # Format
+//!
+//! ```text
+//! {
+//!   "calls": [
+//!     { "callee": "<id>", "caller": "<id>", "column": 5, "file": "main.go", "kind": "Direct", "line": 6 }
+//!   ],
+//!   "symbols": [
+//!     { "column": 6, "end_column": 12, "end_line": 5, "file": "main.go", "id": "<id>",
+//!       "kind": "Function", "language": "go", "line": 5, "name": "helper",
+//!       "qualified": "main.helper", "visibility": "Private" }
+//!   ],
+//!   "version": 1
+//! }
+//! ```
+//!
+//! `doc`, `parent` (a qualified name), and `signature` are included only when
+//! set, and `generated` only when true (see [`crate::generated`]). Object keys
+//! are sorted, symbols are sorted by qualified name, kind, then location, and
+//! calls by caller, callee, then location, so two runs over identical source
+//! produce byte-identical output. File paths are relative to the workspace
+//! root with `/` separators.
+//!
+//! [`CodeIndex::export_json_with_names`] writes `qualified` and `parent` in
+//! another [`NameScheme`]; IDs are still hashed from the indexed names, so
+//! exports in different schemes share IDs.
+//!
+//! # Examples
+//!
+//! ```
+//! use rocketindex::callgraph::CallGraph;
+//! use rocketindex::json::stable_id;
+//! use rocketindex::{CodeIndex, Location, Symbol, SymbolKind, Visibility};
+//! use std::path::PathBuf;
+//!
+//! let mut index = CodeIndex::new();
+//! index.add_symbol(Symbol::new(
+//!     "helper".to_string(),
+//!     "main.helper".to_string(),
+//!     SymbolKind::Function,
+//!     Location::new(PathBuf::from("main.go"), 5, 6),
+//!     Visibility::Private,
+//!     "go".to_string(),
+//! ));
+//!
+//! let mut out = Vec::new();
+//! index.export_json(&mut out, &CallGraph::build(&index)).unwrap();
+//! let json = String::from_utf8(out).unwrap();
+//! assert!(json.contains(&stable_id("main.helper", SymbolKind::Function)));
+//! ```
+
+use std::borrow::Cow;
+use std::collections::HashMap;
+use std::io::Write;
+
+use serde::Serialize;
+
+use crate::callgraph::{CallGraph, CallKind};
+use crate::freshness::{fnv1a, FNV_OFFSET_BASIS};
+use crate::naming::NameScheme;
+use crate::{CodeIndex, Result, Symbol, SymbolKind, Visibility};
+
+/// Current JSON export format version. Increment when the layout changes.
+pub const JSON_FORMAT_VERSION: u32 = 1;
+
+/// Stable ID for a symbol with the given qualified name and kind, declared
+/// outside any package directory (or in a language whose qualified names
+/// are unique on their own).
+#[must_use]
+pub fn stable_id(qualified: &str, kind: SymbolKind) -> String {
+    hash_id(qualified, kind, "", 0)
+}
+
+/// Stable ID for a Go symbol declared in the package at `package`, a
+/// `/`-separated directory relative to the workspace root (`cmd/a`). The
+/// root package (`""`) has the same IDs as [`stable_id`].
+#[must_use]
+pub fn stable_id_in(qualified: &str, kind: SymbolKind, package: &str) -> String {
+    hash_id(qualified, kind, package, 0)
+}
+
+/// FNV-1a over kind, qualified name, package directory (if any), and (for
+/// later overloads) the ordinal.
+fn hash_id(qualified: &str, kind: SymbolKind, package: &str, ordinal: usize) -> String {
+    let kind = kind.to_string();
+    // The trailing `/` keeps a package part apart from an ordinal part
+    let package = format!("{}/", package);
+    let ordinal = ordinal.to_string();
+    let mut parts = vec![kind.as_bytes(), qualified.as_bytes()];
+    if package != "/" {
+        parts.push(package.as_bytes());
+    }
+    if ordinal != "0" {
+        parts.push(ordinal.as_bytes());
+    }
+
+    let mut hash = FNV_OFFSET_BASIS;
+    for (i, part) in parts.iter().enumerate() {
+        if i > 0 {
+            // Separator, so ("ab", "c") and ("a", "bc") differ
+            hash = fnv1a(hash, &[0xff]);
+        }
+        hash = fnv1a(hash, part);
+    }
+    format!("{:016x}", hash)
+}
+
+// Fields are declared in alphabetical order so serialized keys are sorted.
+
+#[derive(Serialize)]
+struct JsonIndex<'a> {
+    calls: Vec<JsonCall>,
+    symbols: Vec<JsonSymbol<'a>>,
+    version: u32,
+}
+
+#[derive(Serialize)]
+struct JsonSymbol<'a> {
+    column: u32,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    doc: Option<&'a str>,
+    end_column: u32,
+    end_line: u32,
+    file: String,
+    #[serde(skip_serializing_if = "is_false")]
+    generated: bool,
+    id: String,
+    kind: SymbolKind,
+    language: &'a str,
+    line: u32,
+    name: &'a str,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    parent: Option<Cow<'a, str>>,
+    qualified: Cow<'a, str>,
+    /// The qualified name as indexed, which call sites refer to
+    #[serde(skip)]
+    key: &'a str,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    signature: Option<&'a str>,
+    visibility: Visibility,
+}
+
+fn is_false(value: &bool) -> bool {
+    !value
+}
+
+#[derive(Serialize)]
+struct JsonCall {
+    callee: String,
+    caller: String,
+    column: u32,
+    file: String,
+    kind: CallKind,
+    line: u32,
+}
+
+impl CodeIndex {
+    /// Every symbol paired with its stable ID (see the [module docs](self)),
+    /// in no particular order.
+    #[must_use]
+    pub fn symbols_with_ids(&self) -> Vec<(&Symbol, String)> {
+        // Group overloads and number them in source order
+        let mut by_key: HashMap<(&str, SymbolKind, String), Vec<&Symbol>> = HashMap::new();
+        for symbol in self.symbols() {
+            by_key
+                .entry((symbol.qualified.as_str(), symbol.kind, package_path(symbol)))
+                .or_default()
+                .push(symbol);
+        }
+
+        let mut symbols = Vec::new();
+        for ((qualified, kind, package), mut overloads) in by_key {
+            overloads.sort_by(|a, b| {
+                let (a_file, b_file) = (&a.location.file, &b.location.file);
+                a.signature
+                    .cmp(&b.signature)
+                    .then_with(|| a_file.parent().cmp(&b_file.parent()))
+                    .then_with(|| a_file.file_name().cmp(&b_file.file_name()))
+                    .then(a.location.line.cmp(&b.location.line))
+                    .then(a.location.column.cmp(&b.location.column))
+            });
+            for (ordinal, symbol) in overloads.into_iter().enumerate() {
+                symbols.push((symbol, hash_id(qualified, kind, &package, ordinal)));
+            }
+        }
+        symbols
+    }
+
+    /// The symbol with stable ID `id`, as written by
+    /// [`export_json`](Self::export_json).
+    #[must_use]
+    pub fn symbol_by_id(&self, id: &str) -> Option<&Symbol> {
+        self.symbols_with_ids()
+            .into_iter()
+            .find_map(|(symbol, symbol_id)| (symbol_id == id).then_some(symbol))
+    }
+
+    /// Write the index and `call_graph` as deterministic JSON (see the [module docs](self)).
+    pub fn export_json<W: Write>(&self, writer: W, call_graph: &CallGraph) -> Result<()> {
+        self.export_json_with_names(writer, call_graph, &NameScheme::default())
+    }
+
+    /// Like [`export_json`](Self::export_json), with `qualified` and
+    /// `parent` rendered in `names`. IDs are unaffected by the scheme.
+    pub fn export_json_with_names<W: Write>(
+        &self,
+        writer: W,
+        call_graph: &CallGraph,
+        names: &NameScheme,
+    ) -> Result<()> {
+        let mut symbols: Vec<JsonSymbol<'_>> = self
+            .symbols_with_ids()
+            .into_iter()
+            .map(|(symbol, id)| JsonSymbol {
+                column: symbol.location.column,
+                doc: symbol.doc.as_deref(),
+                end_column: symbol.location.end_column,
+                end_line: symbol.location.end_line,
+                file: json_path(&symbol.location.file),
+                generated: symbol.is_generated(),
+                id,
+                kind: symbol.kind,
+                language: &symbol.language,
+                line: symbol.location.line,
+                name: &symbol.name,
+                parent: symbol
+                    .parent
+                    .as_deref()
+                    .map(|parent| names.render_in(self, parent)),
+                qualified: names.render(symbol),
+                key: &symbol.qualified,
+                signature: symbol.signature.as_deref(),
+                visibility: symbol.visibility,
+            })
+            .collect();
+        symbols.sort_by(|a, b| {
+            a.qualified
+                .cmp(&b.qualified)
+                .then_with(|| a.key.cmp(b.key))
+                .then_with(|| a.kind.to_string().cmp(&b.kind.to_string()))
+                .then_with(|| a.file.cmp(&b.file))
+                .then(a.line.cmp(&b.line))
+                .then(a.column.cmp(&b.column))
+        });
+
+        // Qualified name -> ID a call edge points to: the first callable
+        // symbol with that name, or the first symbol if none is callable
+        let mut call_targets: HashMap<&str, (bool, &str)> = HashMap::new();
+        for symbol in &symbols {
+            let callable = symbol.kind.is_callable();
+            let target = call_targets
+                .entry(symbol.key)
+                .or_insert((callable, &symbol.id));
+            if callable && !target.0 {
+                *target = (callable, &symbol.id);
+            }
+        }
+
+        let mut calls: Vec<JsonCall> = call_graph
+            .sites()
+            .iter()
+            .filter_map(|site| {
+                let (_, caller) = call_targets.get(site.caller.as_str())?;
+                let (_, callee) = call_targets.get(site.callee.as_str())?;
+                Some(JsonCall {
+                    callee: callee.to_string(),
+                    caller: caller.to_string(),
+                    column: site.location.column,
+                    file: json_path(&site.location.file),
+                    kind: site.kind,
+                    line: site.location.line,
+                })
+            })
+            .collect();
+        calls.sort_by(|a, b| {
+            a.caller
+                .cmp(&b.caller)
+                .then_with(|| a.callee.cmp(&b.callee))
+                .then_with(|| a.file.cmp(&b.file))
+                .then(a.line.cmp(&b.line))
+                .then(a.column.cmp(&b.column))
+        });
+        calls.dedup_by(|a, b| {
+            a.caller == b.caller
+                && a.callee == b.callee
+                && a.file == b.file
+                && a.line == b.line
+                && a.column == b.column
+        });
+
+        let mut writer = writer;
+        serde_json::to_writer_pretty(
+            &mut writer,
+            &JsonIndex {
+                calls,
+                symbols,
+                version: JSON_FORMAT_VERSION,
+            },
+        )?;
+        writeln!(writer)?;
+        Ok(())
+    }
+}
+
+/// The package directory hashed into a symbol's ID: the directory of a Go
+/// symbol's file, empty for every other language.
+fn package_path(symbol: &Symbol) -> String {
+    match symbol.location.file.parent() {
+        Some(dir) if symbol.language == "go" => json_path(dir),
+        _ => String::new(),
+    }
+}
+
+/// Workspace-relative path with `/` separators.
+fn json_path(path: &std::path::Path) -> String {
+    path.to_string_lossy().replace('\\', "/")
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::indexer::IndexOptions;
+    use crate::{Location, Reference, ReferenceKind};
+    use std::path::{Path, PathBuf};
+
+    fn function(name: &str, file: &str, line: u32) -> Symbol {
+        Symbol::new(
+            name.to_string(),
+            format!("main.{}", name),
+            SymbolKind::Function,
+            Location::new(PathBuf::from(file), line, 6),
+            Visibility::Private,
+            "go".to_string(),
+        )
+    }
+
+    fn sample_index(symbols: Vec<Symbol>) -> CodeIndex {
+        let mut index = CodeIndex::new();
+        for symbol in symbols {
+            index.add_symbol(symbol);
+        }
+        index.add_reference(
+            PathBuf::from("main.go"),
+            Reference {
+                name: "helper".to_string(),
+                location: Location::new(PathBuf::from("main.go"), 6, 5),
+                kind: ReferenceKind::Call,
+                target: None,
+                arguments: None,
+            },
+        );
+        index
+    }
+
+    fn export(index: &CodeIndex) -> String {
+        let mut out = Vec::new();
+        index
+            .export_json(&mut out, &CallGraph::build(index))
+            .unwrap();
+        String::from_utf8(out).unwrap()
+    }
+
+    #[test]
+    fn test_export_is_byte_identical_across_insertion_order() {
+        let symbols = vec![
+            function("helper", "main.go", 1),
+            function("run", "main.go", 5),
+            function("other", "util.go", 1),
+        ];
+        let mut reversed = symbols.clone();
+        reversed.reverse();
+
+        let first = export(&sample_index(symbols));
+        assert_eq!(first, export(&sample_index(reversed)));
+        assert!(first.ends_with("}\n"));
+    }
+
+    #[test]
+    fn test_export_calls_reference_stable_ids() {
+        let index = sample_index(vec![
+            function("helper", "main.go", 1),
+            function("run", "main.go", 5),
+        ]);
+        let json: serde_json::Value = serde_json::from_str(&export(&index)).unwrap();
+
+        assert_eq!(json["version"], JSON_FORMAT_VERSION);
+        let call = &json["calls"][0];
+        assert_eq!(call["caller"], stable_id("main.run", SymbolKind::Function));
+        assert_eq!(
+            call["callee"],
+            stable_id("main.helper", SymbolKind::Function)
+        );
+        assert_eq!(call["line"], 6);
+
+        // Keys are written in sorted order, not just sorted on parse
+        let raw = export(&index);
+        let position = |key: &str| raw.find(&format!("\"{}\"", key)).unwrap();
+        assert!(position("calls") < position("symbols"));
+        assert!(position("symbols") < position("version"));
+        assert!(position("callee") < position("caller"));
+        assert!(position("end_line") < position("language"));
+        assert!(position("qualified") < position("visibility"));
+    }
+
+    #[test]
+    fn test_name_scheme_renames_but_keeps_ids_and_calls() {
+        let index = sample_index(vec![
+            function("helper", "main.go", 1),
+            function("run", "main.go", 5),
+        ]);
+        let mut out = Vec::new();
+        index
+            .export_json_with_names(&mut out, &CallGraph::build(&index), &NameScheme::Colons)
+            .unwrap();
+        let json: serde_json::Value = serde_json::from_slice(&out).unwrap();
+
+        assert_eq!(json["symbols"][0]["qualified"], "main::helper");
+        assert_eq!(
+            json["symbols"][0]["id"],
+            stable_id("main.helper", SymbolKind::Function)
+        );
+        assert_eq!(
+            json["calls"][0]["caller"],
+            stable_id("main.run", SymbolKind::Function)
+        );
+    }
+
+    #[test]
+    fn test_generated_flag_is_exported_only_when_set() {
+        let index = sample_index(vec![
+            function("helper", "main.go", 1)
+                .with_attributes(Some(vec![crate::generated::GENERATED.to_string()])),
+            function("run", "main.go", 5),
+        ]);
+        let json: serde_json::Value = serde_json::from_str(&export(&index)).unwrap();
+
+        assert_eq!(json["symbols"][0]["name"], "helper");
+        assert_eq!(json["symbols"][0]["generated"], true);
+        assert!(json["symbols"][1].get("generated").is_none());
+    }
+
+    #[test]
+    fn test_stable_id_ignores_location_but_not_kind_or_overloads() {
+        let id = stable_id("main.helper", SymbolKind::Function);
+        assert_eq!(id.len(), 16);
+        assert_ne!(id, stable_id("main.helper", SymbolKind::Value));
+        assert_ne!(id, stable_id("main.helpers", SymbolKind::Function));
+
+        let moved = sample_index(vec![function("helper", "other.go", 40)]);
+        assert!(export(&moved).contains(&id));
+
+        let overloaded = sample_index(vec![
+            function("helper", "main.go", 1),
+            function("helper", "main.go", 3),
+        ]);
+        let json: serde_json::Value = serde_json::from_str(&export(&overloaded)).unwrap();
+        let ids: Vec<&str> = json["symbols"]
+            .as_array()
+            .unwrap()
+            .iter()
+            .map(|s| s["id"].as_str().unwrap())
+            .collect();
+        assert_eq!(ids.len(), 2);
+        assert_eq!(ids[0], id);
+        assert_ne!(ids[0], ids[1]);
+    }
+
+    fn ids_by_signature(index: &CodeIndex) -> Vec<(String, String)> {
+        let json: serde_json::Value = serde_json::from_str(&export(index)).unwrap();
+        let mut ids: Vec<(String, String)> = json["symbols"]
+            .as_array()
+            .unwrap()
+            .iter()
+            .map(|s| {
+                let signature = s["signature"].as_str().unwrap_or_default();
+                let qualified = s["qualified"].as_str().unwrap();
+                (
+                    format!("{} {}", qualified, signature),
+                    s["id"].as_str().unwrap().to_string(),
+                )
+            })
+            .collect();
+        ids.sort();
+        ids
+    }
+
+    #[test]
+    fn test_overload_ids_follow_signatures_not_files() {
+        // Java, whose qualified names do not depend on the file's directory
+        let overload = |file: &str, line, signature: &str| {
+            let mut symbol =
+                function("helper", file, line).with_signature(Some(signature.to_string()));
+            symbol.language = "java".to_string();
+            symbol
+        };
+        let before = sample_index(vec![
+            overload("B.java", 1, "func helper(b int)"),
+            overload("A.java", 1, "func helper(a int)"),
+        ]);
+        // B.java moves to a directory sorting first, and both swap lines
+        let after = sample_index(vec![
+            overload("aaa/B.java", 9, "func helper(b int)"),
+            overload("A.java", 9, "func helper(a int)"),
+        ]);
+        assert_eq!(ids_by_signature(&before), ids_by_signature(&after));
+    }
+
+    #[test]
+    fn test_go_ids_include_the_package_directory() {
+        let mains = |dirs: &[&str]| {
+            let files: Vec<String> = dirs.iter().map(|d| format!("{}/main.go", d)).collect();
+            let index = sample_index(files.iter().map(|f| function("main", f, 3)).collect());
+            ids_by_signature(&index)
+        };
+
+        let ids = mains(&["cmd/a", "cmd/b"]);
+        assert_eq!(ids.len(), 2);
+        assert_ne!(ids[0].1, ids[1].1);
+        let expected = |package| stable_id_in("main.main", SymbolKind::Function, package);
+        let mut expected_ids = vec![expected("cmd/a"), expected("cmd/b")];
+        expected_ids.sort();
+        let mut actual: Vec<String> = ids.into_iter().map(|(_, id)| id).collect();
+        actual.sort();
+        assert_eq!(actual, expected_ids);
+
+        // Adding a package sorting first does not renumber the others
+        assert!(mains(&["cmd/0", "cmd/a", "cmd/b"])
+            .iter()
+            .any(|(_, id)| *id == expected("cmd/b")));
+        assert_eq!(
+            stable_id_in("main.main", SymbolKind::Function, ""),
+            stable_id("main.main", SymbolKind::Function)
+        );
+    }
+
+    #[test]
+    fn test_go_ids_survive_moves_within_a_package() {
+        let fixture = Path::new(env!("CARGO_MANIFEST_DIR"))
+            .join("../../tests/claude-plugin-integration/test-repos/go-sample");
+        let files = ["main.go", "payment.go", "user.go"];
+        let index_of = |root: &Path, files: &[&str]| {
+            let mut index = CodeIndex::with_root(root.to_path_buf());
+            let paths: Vec<PathBuf> = files.iter().map(|f| root.join(f)).collect();
+            index.update_files(&paths, &IndexOptions::default());
+            index
+        };
+        let ids = |index: &CodeIndex| -> Vec<(String, String)> {
+            let json: serde_json::Value = serde_json::from_str(&export(index)).unwrap();
+            let mut ids: Vec<(String, String)> = json["symbols"]
+                .as_array()
+                .unwrap()
+                .iter()
+                .map(|s| {
+                    (
+                        s["qualified"].as_str().unwrap().to_string(),
+                        s["id"].as_str().unwrap().to_string(),
+                    )
+                })
+                .collect();
+            ids.sort();
+            ids
+        };
+        let copy_to = |root: &Path, moved_files: &[&str]| {
+            for (from, to) in files.iter().zip(moved_files) {
+                let to = root.join(to);
+                std::fs::create_dir_all(to.parent().unwrap()).unwrap();
+                std::fs::copy(fixture.join(from), to).unwrap();
+            }
+        };
+        let original_ids = ids(&index_of(&fixture, &files));
+        assert!(original_ids.iter().any(|(name, _)| name == "main.User"));
+
+        // Another checkout, with user.go renamed: same package, same IDs
+        let renamed = tempfile::tempdir().unwrap();
+        let renamed_files = ["main.go", "payment.go", "models.go"];
+        copy_to(renamed.path(), &renamed_files);
+        assert_eq!(original_ids, ids(&index_of(renamed.path(), &renamed_files)));
+
+        // Moving user.go into models/ moves its symbols to another package
+        let moved = tempfile::tempdir().unwrap();
+        let moved_files = ["main.go", "payment.go", "models/user.go"];
+        copy_to(moved.path(), &moved_files);
+        let relocated = index_of(moved.path(), &moved_files);
+        let user = relocated.get("main.User").unwrap();
+        assert_eq!(user.location.file, Path::new("models/user.go"));
+        let moved_ids = ids(&relocated);
+        let id_of = |ids: &[(String, String)], name: &str| {
+            ids.iter().find(|(n, _)| n == name).unwrap().1.clone()
+        };
+        assert_eq!(
+            id_of(&moved_ids, "main.User"),
+            stable_id_in("main.User", user.kind, "models")
+        );
+        assert_ne!(
+            id_of(&moved_ids, "main.User"),
+            id_of(&original_ids, "main.User")
+        );
+        assert_eq!(
+            id_of(&moved_ids, "main.main"),
+            id_of(&original_ids, "main.main")
+        );
+    }
+}
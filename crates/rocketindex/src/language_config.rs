@@ -0,0 +1,478 @@
+//! Per-language comment, test, and generated-code conventions.
+//!
+//! A [`LanguageConfig`] declares what the parsers leave implicit: how
+//! comments and doc comments are written, which files and functions are
+//! tests, and which header marks a generated file. Every supported language
+//! ships one next to its parser (`languages/<lang>/config.rs`), and
+//! [`LanguageConfigs::builtin`] collects them. They drive:
+//!
+//! - test detection for [`CallGraph::test_coverage`](crate::callgraph::CallGraph::test_coverage):
+//!   [`LanguageConfigs`] is a [`TestDetector`] dispatching on file extension
+//! - generated-file detection in [`extract_symbols`](crate::extract_symbols)
+//!   and [`IndexOptions::skip_generated`](crate::indexer::IndexOptions::skip_generated)
+//! - doc comments for symbols the parser found none for, read from the lines
+//!   just above the declaration with [`LanguageConfig::doc_comment_before`]
+//!
+//! To change a convention without touching the parser, clone the built-in
+//! configs, replace one with [`LanguageConfigs::insert`], and pass the result
+//! to [`IndexOptions::languages`](crate::indexer::IndexOptions::languages) or
+//! [`CallGraph::test_coverage_with`](crate::callgraph::CallGraph::test_coverage_with).
+//! Projects can do the same from `.rocketindex.toml` with a
+//! `[languages.<name>]` table (see [`Config::languages`](crate::config::Config::languages)).
+//!
+//! # Examples
+//!
+//! ```
+//! use rocketindex::coverage::TestDetector;
+//! use rocketindex::language_config::LanguageConfigs;
+//! use regex::Regex;
+//! use std::path::Path;
+//!
+//! let mut languages = LanguageConfigs::builtin().clone();
+//! assert!(languages.is_test_file(Path::new("pkg/user_test.go")));
+//! assert!(!languages.is_test_file(Path::new("pkg/user_check.go")));
+//!
+//! let mut go = languages.get("go").unwrap().clone();
+//! go.test_files.push("*_check.go".to_string());
+//! go.test_functions = Some(Regex::new("^(Test|Check)").unwrap());
+//! languages.insert(go);
+//! assert!(languages.is_test_file(Path::new("pkg/user_check.go")));
+//! ```
+
+use std::path::Path;
+use std::sync::OnceLock;
+
+use regex::Regex;
+
+use crate::coverage::TestDetector;
+use crate::generated::{is_generated_source, GENERATED_HEADER};
+use crate::languages::{
+    c, cpp, csharp, fsharp, go, haxe, java, javascript, kotlin, objc, php, python, ruby, rust,
+    swift, typescript,
+};
+use crate::search::glob_matches;
+use crate::Symbol;
+
+/// The generated-code header for languages with `#` comments.
+pub const HASH_GENERATED_HEADER: &str = r"^# Code generated .* DO NOT EDIT\.$";
+
+/// Comment, test, and generated-code conventions of one language.
+#[derive(Debug, Clone)]
+pub struct LanguageConfig {
+    /// Language name, as in [`Symbol::language`]
+    pub language: String,
+    /// File extensions, lowercase and without the dot
+    pub extensions: Vec<String>,
+    /// Line comment markers (`//`, `#`)
+    pub line_comments: Vec<String>,
+    /// Block comment delimiters (`/*`, `*/`)
+    pub block_comment: Option<(String, String)>,
+    /// Markers opening a doc comment line (`///`) or block (`/**`)
+    pub doc_comments: Vec<String>,
+    /// Globs matching test files (see
+    /// [`SearchOptions::file_glob`](crate::search::SearchOptions::file_glob)
+    /// for the syntax)
+    pub test_files: Vec<String>,
+    /// Names of the functions in test files that are tests (`None`: every
+    /// function in a test file is)
+    pub test_functions: Option<Regex>,
+    /// Whether methods can be tests, not just top-level functions
+    pub test_methods: bool,
+    /// Matches the first non-blank line of a generated file
+    pub generated_marker: Option<Regex>,
+}
+
+impl LanguageConfig {
+    /// A config with no comment, test, or generated-code conventions.
+    #[must_use]
+    pub fn new(language: &str, extensions: &[&str]) -> Self {
+        Self {
+            language: language.to_string(),
+            extensions: extensions.iter().map(|e| e.to_string()).collect(),
+            line_comments: Vec::new(),
+            block_comment: None,
+            doc_comments: Vec::new(),
+            test_files: Vec::new(),
+            test_functions: None,
+            test_methods: true,
+            generated_marker: None,
+        }
+    }
+
+    /// A config with `//` and `/* */` comments and the standard
+    /// generated-code header (see [`crate::generated`]).
+    #[must_use]
+    pub fn c_style(language: &str, extensions: &[&str]) -> Self {
+        Self::new(language, extensions)
+            .with_comments(&["//"], Some(("/*", "*/")))
+            .with_generated_marker(GENERATED_HEADER)
+    }
+
+    /// A config with `#` comments and a `#` generated-code header.
+    #[must_use]
+    pub fn hash_style(language: &str, extensions: &[&str]) -> Self {
+        Self::new(language, extensions)
+            .with_comments(&["#"], None)
+            .with_generated_marker(HASH_GENERATED_HEADER)
+    }
+
+    /// Set the line comment markers and block comment delimiters.
+    #[must_use]
+    pub fn with_comments(mut self, line: &[&str], block: Option<(&str, &str)>) -> Self {
+        self.line_comments = line.iter().map(|m| m.to_string()).collect();
+        self.block_comment = block.map(|(open, close)| (open.to_string(), close.to_string()));
+        self
+    }
+
+    /// Set the markers opening a doc comment.
+    #[must_use]
+    pub fn with_doc_comments(mut self, markers: &[&str]) -> Self {
+        self.doc_comments = markers.iter().map(|m| m.to_string()).collect();
+        self
+    }
+
+    /// Set the test file globs and test function pattern.
+    ///
+    /// # Panics
+    ///
+    /// Panics if `functions` is not a valid regex.
+    #[must_use]
+    pub fn with_tests(mut self, files: &[&str], functions: Option<&str>, methods: bool) -> Self {
+        self.test_files = files.iter().map(|g| g.to_string()).collect();
+        self.test_functions = functions.map(|f| Regex::new(f).expect("valid test pattern"));
+        self.test_methods = methods;
+        self
+    }
+
+    /// Set the generated-code header pattern.
+    ///
+    /// # Panics
+    ///
+    /// Panics if `marker` is not a valid regex.
+    #[must_use]
+    pub fn with_generated_marker(mut self, marker: &str) -> Self {
+        self.generated_marker = Some(Regex::new(marker).expect("valid generated marker"));
+        self
+    }
+
+    /// Whether this config handles files with `path`'s extension.
+    #[must_use]
+    pub fn handles(&self, path: &Path) -> bool {
+        path.extension()
+            .and_then(|e| e.to_str())
+            .is_some_and(|e| self.extensions.iter().any(|x| x.eq_ignore_ascii_case(e)))
+    }
+
+    /// Whether the first non-blank line of `source` is the generated-code
+    /// marker.
+    #[must_use]
+    pub fn is_generated_source(&self, source: &str) -> bool {
+        let Some(marker) = &self.generated_marker else {
+            return false;
+        };
+        source
+            .lines()
+            .find(|line| !line.trim().is_empty())
+            .is_some_and(|line| marker.is_match(line))
+    }
+
+    /// The doc comment ending on the line just above `line`, without its
+    /// markers.
+    ///
+    /// Consecutive doc comment lines are joined with newlines; a block
+    /// comment counts if it opens with a doc marker. Returns `None` if the
+    /// line above is not part of a doc comment.
+    #[must_use]
+    pub fn doc_comment_before(&self, source: &str, line: u32) -> Option<String> {
+        let above: Vec<&str> = source
+            .lines()
+            .take(line.saturating_sub(1) as usize)
+            .map(str::trim)
+            .collect();
+        let last = *above.last()?;
+
+        if let Some((open, close)) = &self.block_comment {
+            if last.ends_with(close.as_str()) {
+                let start = above.iter().rposition(|l| l.starts_with(open.as_str()))?;
+                let marker = self.doc_comments.iter().find(|m| {
+                    m.starts_with(open.as_str()) && above[start].starts_with(m.as_str())
+                })?;
+                let mut text: Vec<&str> = Vec::new();
+                for (i, l) in above[start..].iter().enumerate() {
+                    let mut l = *l;
+                    if i == 0 {
+                        l = &l[marker.len()..];
+                    }
+                    l = l.strip_suffix(close.as_str()).unwrap_or(l);
+                    if i > 0 {
+                        l = l.strip_prefix('*').unwrap_or(l);
+                    }
+                    text.push(l.trim());
+                }
+                return join_doc(&text);
+            }
+        }
+
+        let line_markers: Vec<&String> = self
+            .doc_comments
+            .iter()
+            .filter(|m| {
+                self.block_comment
+                    .as_ref()
+                    .is_none_or(|(open, _)| !m.starts_with(open.as_str()))
+            })
+            .collect();
+        let mut text: Vec<&str> = above
+            .iter()
+            .rev()
+            .map_while(|l| {
+                let marker = line_markers.iter().find(|m| l.starts_with(m.as_str()))?;
+                let rest = &l[marker.len()..];
+                Some(rest.strip_prefix(' ').unwrap_or(rest).trim_end())
+            })
+            .collect();
+        text.reverse();
+        join_doc(&text)
+    }
+}
+
+/// Join doc comment lines, dropping blank lines at either end.
+fn join_doc(lines: &[&str]) -> Option<String> {
+    let start = lines.iter().position(|l| !l.is_empty())?;
+    let end = lines.iter().rposition(|l| !l.is_empty())?;
+    Some(lines[start..=end].join("\n"))
+}
+
+impl TestDetector for LanguageConfig {
+    fn is_test_file(&self, path: &Path) -> bool {
+        let path = path.to_string_lossy();
+        self.test_files.iter().any(|glob| glob_matches(glob, &path))
+    }
+
+    fn is_test(&self, symbol: &Symbol) -> bool {
+        symbol.kind.is_callable()
+            && (self.test_methods || symbol.parent.is_none())
+            && self
+                .test_functions
+                .as_ref()
+                .is_none_or(|pattern| pattern.is_match(&symbol.name))
+            && self.is_test_file(&symbol.location.file)
+    }
+}
+
+/// The configs in use, one per language, looked up by name or file
+/// extension.
+#[derive(Debug, Clone)]
+pub struct LanguageConfigs(Vec<LanguageConfig>);
+
+impl LanguageConfigs {
+    /// The configs shipped for every supported language.
+    #[must_use]
+    pub fn builtin() -> &'static Self {
+        static BUILTIN: OnceLock<LanguageConfigs> = OnceLock::new();
+        BUILTIN.get_or_init(|| {
+            Self(vec![
+                c::config::config(),
+                cpp::config::config(),
+                csharp::config::config(),
+                fsharp::config::config(),
+                go::config::config(),
+                haxe::config::config(),
+                java::config::config(),
+                javascript::config::config(),
+                kotlin::config::config(),
+                objc::config::config(),
+                php::config::config(),
+                python::config::config(),
+                ruby::config::config(),
+                rust::config::config(),
+                swift::config::config(),
+                typescript::config::config(),
+            ])
+        })
+    }
+
+    /// The config for `language` (as in [`Symbol::language`]).
+    #[must_use]
+    pub fn get(&self, language: &str) -> Option<&LanguageConfig> {
+        self.0.iter().find(|config| config.language == language)
+    }
+
+    /// The config handling `path`'s extension.
+    #[must_use]
+    pub fn for_file(&self, path: &Path) -> Option<&LanguageConfig> {
+        self.0.iter().find(|config| config.handles(path))
+    }
+
+    /// Replace the config for `config.language`, or add it for a new
+    /// language, returning the one it replaced.
+    pub fn insert(&mut self, config: LanguageConfig) -> Option<LanguageConfig> {
+        match self.0.iter_mut().find(|c| c.language == config.language) {
+            Some(existing) => Some(std::mem::replace(existing, config)),
+            None => {
+                self.0.push(config);
+                None
+            }
+        }
+    }
+
+    /// Whether `source`, read from `file`, starts with its language's
+    /// generated-code marker (the standard `//` header for unknown
+    /// languages).
+    #[must_use]
+    pub fn is_generated(&self, file: &Path, source: &str) -> bool {
+        match self.for_file(file) {
+            Some(config) => config.is_generated_source(source),
+            None => is_generated_source(source),
+        }
+    }
+}
+
+impl Default for LanguageConfigs {
+    /// The built-in configs.
+    fn default() -> Self {
+        Self::builtin().clone()
+    }
+}
+
+impl TestDetector for LanguageConfigs {
+    fn is_test_file(&self, path: &Path) -> bool {
+        self.for_file(path)
+            .is_some_and(|config| config.is_test_file(path))
+    }
+
+    fn is_test(&self, symbol: &Symbol) -> bool {
+        self.for_file(&symbol.location.file)
+            .is_some_and(|config| config.is_test(symbol))
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::{Location, SymbolKind, Visibility};
+    use std::path::PathBuf;
+
+    fn function(name: &str, file: &str, language: &str) -> Symbol {
+        Symbol::new(
+            name.to_string(),
+            format!("pkg.{}", name),
+            SymbolKind::Function,
+            Location::new(PathBuf::from(file), 1, 1),
+            Visibility::Public,
+            language.to_string(),
+        )
+    }
+
+    #[test]
+    fn test_every_language_has_a_builtin_config() {
+        let languages = LanguageConfigs::builtin();
+        for (file, language) in [
+            ("a.c", "c"),
+            ("a.hpp", "cpp"),
+            ("a.cs", "csharp"),
+            ("a.fsx", "fsharp"),
+            ("a.go", "go"),
+            ("a.hx", "haxe"),
+            ("A.java", "java"),
+            ("a.mjs", "javascript"),
+            ("a.kts", "kotlin"),
+            ("a.mm", "objc"),
+            ("a.php", "php"),
+            ("a.pyi", "python"),
+            ("a.rb", "ruby"),
+            ("a.rs", "rust"),
+            ("a.swift", "swift"),
+            ("a.TSX", "typescript"),
+        ] {
+            let config = languages.for_file(Path::new(file));
+            assert_eq!(
+                config.map(|c| c.language.as_str()),
+                Some(language),
+                "{}",
+                file
+            );
+        }
+        assert!(languages.for_file(Path::new("README.md")).is_none());
+    }
+
+    #[test]
+    fn test_test_conventions_per_language() {
+        let languages = LanguageConfigs::builtin();
+        let is_test = |name, file, language| languages.is_test(&function(name, file, language));
+
+        assert!(is_test("TestLogin", "auth/login_test.go", "go"));
+        assert!(!is_test("Testify", "auth/login_test.go", "go"));
+        assert!(is_test("test_login", "tests/test_auth.py", "python"));
+        assert!(is_test("logsIn", "src/auth.test.ts", "typescript"));
+        assert!(!is_test("logsIn", "src/auth.ts", "typescript"));
+        assert!(is_test("login", "tests/auth.rs", "rust"));
+        assert!(is_test("testLogin", "AuthTest.php", "php"));
+        assert!(!is_test("setUp", "AuthTest.php", "php"));
+
+        let method = function("TestLogin", "login_test.go", "go").with_parent(Some("Suite".into()));
+        assert!(!languages.is_test(&method));
+        let method =
+            function("testLogin", "AuthTests.swift", "swift").with_parent(Some("T".into()));
+        assert!(languages.is_test(&method));
+    }
+
+    #[test]
+    fn test_insert_overrides_one_language() {
+        let mut languages = LanguageConfigs::builtin().clone();
+        let mut python = languages.get("python").unwrap().clone();
+        python.test_files = vec!["check_*.py".to_string()];
+        python.test_functions = Some(Regex::new("^check_").unwrap());
+
+        let replaced = languages.insert(python);
+        assert_eq!(replaced.unwrap().language, "python");
+        assert!(languages.is_test(&function("check_login", "check_auth.py", "python")));
+        assert!(!languages.is_test(&function("test_login", "test_auth.py", "python")));
+        // Other languages keep their conventions
+        assert!(languages.is_test(&function("TestLogin", "login_test.go", "go")));
+
+        assert!(languages
+            .insert(LanguageConfig::new("zig", &["zig"]))
+            .is_none());
+        assert!(languages.for_file(Path::new("main.zig")).is_some());
+    }
+
+    #[test]
+    fn test_generated_markers_follow_comment_syntax() {
+        let languages = LanguageConfigs::builtin();
+        let header = "// Code generated by protoc-gen-ts. DO NOT EDIT.\n";
+        assert!(languages.is_generated(Path::new("user_pb.ts"), header));
+        assert!(!languages.is_generated(Path::new("user_pb2.py"), header));
+        assert!(languages.is_generated(
+            Path::new("user_pb2.py"),
+            "# Code generated by protoc. DO NOT EDIT.\nimport grpc\n"
+        ));
+        assert!(languages.is_generated(Path::new("notes.txt"), header));
+    }
+
+    #[test]
+    fn test_doc_comment_before() {
+        let rust = rust::config::config();
+        let source = "use std::io;\n\n/// Reads a user.\n///\n/// Returns `None` if missing.\npub fn read() {}\n";
+        assert_eq!(
+            rust.doc_comment_before(source, 6).as_deref(),
+            Some("Reads a user.\n\nReturns `None` if missing.")
+        );
+        assert_eq!(rust.doc_comment_before(source, 1), None);
+        assert_eq!(rust.doc_comment_before("// plain\nfn f() {}\n", 2), None);
+
+        let java = java::config::config();
+        let source = "/**\n * A user.\n * @since 2\n */\nclass User {}\n";
+        assert_eq!(
+            java.doc_comment_before(source, 5).as_deref(),
+            Some("A user.\n@since 2")
+        );
+        let source = "/* not docs */\nclass User {}\n";
+        assert_eq!(java.doc_comment_before(source, 2), None);
+        let source = "/** One line. */\nclass User {}\n";
+        assert_eq!(
+            java.doc_comment_before(source, 2).as_deref(),
+            Some("One line.")
+        );
+    }
+}
@@ -0,0 +1,12 @@
+//! Comment, test, and generated-code conventions for C.
+
+use crate::language_config::LanguageConfig;
+
+/// `///` and `/** */` doc comments; every function in `test_*.c` or `*_test.c`
+/// is a test.
+#[must_use]
+pub fn config() -> LanguageConfig {
+    LanguageConfig::c_style("c", &["c", "h"])
+        .with_doc_comments(&["///", "/**"])
+        .with_tests(&["test_*.c", "*_test.c"], None, true)
+}
@@ -3,8 +3,12 @@
 use std::cell::RefCell;
 use std::path::Path;
 
-use crate::parse::{find_child_by_kind, node_to_location, LanguageParser, ParseResult};
-use crate::{Reference, Symbol, SymbolKind, Visibility};
+use crate::declarations::{DECLARATION, MACRO};
+use crate::parse::{
+    collect_annotations, find_child_by_kind, node_to_location, record_extents, LanguageParser,
+    ParseResult,
+};
+use crate::{Reference, ReferenceKind, Symbol, SymbolKind, Visibility};
 
 // Thread-local parser reuse - avoids creating a new parser per file
 thread_local! {
@@ -39,6 +43,9 @@ impl LanguageParser for CParser {
 
             // Extract references in a separate pass
             extract_references_recursive(&root, source.as_bytes(), file, &mut result);
+            record_extents(&root, &mut result.symbols);
+            result.annotations =
+                collect_annotations(file, &root, source.as_bytes(), &result.symbols);
 
             result
         })
@@ -165,6 +172,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature,
+                        extent: None,
                     });
                 }
             }
@@ -197,6 +205,7 @@ fn extract_recursive(
                                 implements: None,
                                 doc,
                                 signature: None,
+                                extent: None,
                             });
                             found = true;
                             break;
@@ -227,6 +236,7 @@ fn extract_recursive(
                                     implements: None,
                                     doc,
                                     signature: None,
+                                    extent: None,
                                 });
                                 found = true;
                                 break;
@@ -278,6 +288,7 @@ fn extract_recursive(
                             implements: None,
                             doc,
                             signature: None,
+                            extent: None,
                         });
                         typedef_found = true;
                     }
@@ -304,6 +315,7 @@ fn extract_recursive(
                                         implements: None,
                                         doc,
                                         signature: None,
+                                        extent: None,
                                     });
                                     break;
                                 }
@@ -351,6 +363,7 @@ fn extract_recursive(
                                     implements: None,
                                     doc,
                                     signature: None,
+                                    extent: None,
                                 });
                             }
                         }
@@ -364,7 +377,9 @@ fn extract_recursive(
                     if let Some(name) = extract_declarator_name(&declarator, source) {
                         let qualified = qualified_name(&name, parent_path);
                         let doc = extract_doc_comments(node, source);
-                        let kind = if is_function_declarator(&declarator) {
+                        // A function prototype; its definition is linked by signature
+                        let is_function = is_function_declarator(&declarator);
+                        let kind = if is_function {
                             SymbolKind::Function
                         } else {
                             SymbolKind::Value
@@ -379,10 +394,15 @@ fn extract_recursive(
                             language: "c".to_string(),
                             parent: None,
                             mixins: None,
-                            attributes: None,
+                            attributes: is_function.then(|| vec![DECLARATION.to_string()]),
                             implements: None,
                             doc,
-                            signature: None,
+                            signature: if is_function {
+                                extract_function_signature(node, source)
+                            } else {
+                                None
+                            },
+                            extent: None,
                         });
                     }
                 }
@@ -409,6 +429,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Extract struct fields - tree-sitter-c uses field_declaration_list
@@ -450,6 +471,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Extract union fields (same structure as struct)
@@ -489,6 +511,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Extract enum values
@@ -518,6 +541,7 @@ fn extract_recursive(
                         implements: None,
                         doc: None,
                         signature: None,
+                        extent: None,
                     });
                 }
             }
@@ -538,10 +562,11 @@ fn extract_recursive(
                         language: "c".to_string(),
                         parent: None,
                         mixins: None,
-                        attributes: None,
+                        attributes: Some(vec![MACRO.to_string()]),
                         implements: None,
                         doc: None,
                         signature: None,
+                        extent: None,
                     });
                 }
             }
@@ -658,6 +683,7 @@ fn extract_struct_fields(
                             implements: None,
                             doc: None,
                             signature: None,
+                            extent: None,
                         });
                         continue;
                     }
@@ -682,6 +708,7 @@ fn extract_struct_fields(
                                     implements: None,
                                     doc: None,
                                     signature: None,
+                                    extent: None,
                                 });
                             }
                         }
@@ -720,6 +747,7 @@ fn extract_enum_values(
                             implements: None,
                             doc: None,
                             signature: None,
+                            extent: None,
                         });
                     }
                 }
@@ -741,6 +769,9 @@ fn extract_references_recursive(
             result.references.push(Reference {
                 name: name.to_string(),
                 location: node_to_location(file, node),
+                kind: ReferenceKind::Unknown,
+                target: None,
+                arguments: None,
             });
         }
     }
@@ -758,6 +789,9 @@ fn extract_references_recursive(
             result.references.push(Reference {
                 name: func_name,
                 location,
+                kind: ReferenceKind::Call,
+                target: None,
+                arguments: None,
             });
         }
     }
@@ -914,6 +948,73 @@ int add(int a, int b) {
         assert_eq!(result.symbols[0].kind, SymbolKind::Function);
     }
 
+    #[test]
+    fn marks_prototypes_as_declarations() {
+        let source = r#"
+/* Adds two numbers. */
+int add(int a, int b);
+static int helper(void);
+
+int add(int a, int b) {
+    return helper() + a + b;
+}
+"#;
+        let parser = CParser;
+        let result = parser.extract_symbols(Path::new("math.c"), source, 100);
+
+        let adds: Vec<&Symbol> = result.symbols.iter().filter(|s| s.name == "add").collect();
+        assert_eq!(adds.len(), 2);
+        assert!(adds[0].is_declaration());
+        assert_eq!(adds[0].signature.as_deref(), Some("int add(int a, int b)"));
+        assert_eq!(adds[0].doc.as_deref(), Some("Adds two numbers."));
+        assert!(!adds[1].is_declaration());
+
+        let helper = result.symbols.iter().find(|s| s.name == "helper").unwrap();
+        assert!(helper.is_declaration());
+        assert_eq!(helper.visibility, Visibility::Private);
+    }
+
+    #[test]
+    fn tolerates_preprocessor_conditionals_and_macros() {
+        let source = r#"
+#include <stdio.h>
+#define LOG(msg) fprintf(stderr, "%s\n", msg)
+
+#ifdef _WIN32
+int open_file(const char *path) { return 1; }
+#else
+int open_file(const char *path) { return 0; }
+#endif
+
+#if defined(FEATURE_X) && FEATURE_X > 1
+void feature(void) {
+    LOG("on");
+    open_file("x");
+}
+#endif
+"#;
+        let parser = CParser;
+        let result = parser.extract_symbols(Path::new("main.c"), source, 100);
+
+        let opens = result
+            .symbols
+            .iter()
+            .filter(|s| s.name == "open_file")
+            .count();
+        assert_eq!(opens, 2);
+        assert!(result.symbols.iter().any(|s| s.name == "feature"));
+        let log = result.symbols.iter().find(|s| s.name == "LOG").unwrap();
+        assert!(log.is_macro());
+
+        let calls: Vec<&str> = result
+            .references
+            .iter()
+            .filter(|r| r.kind == ReferenceKind::Call)
+            .map(|r| r.name.as_str())
+            .collect();
+        assert_eq!(calls, vec!["LOG", "open_file"]);
+    }
+
     #[test]
     fn extracts_c_struct() {
         let source = r#"
@@ -10,7 +10,7 @@ use std::path::Path;
 
 use crate::parse::ParseResult;
 use crate::resolve::{ResolutionPath, ResolveResult, SymbolResolver};
-use crate::{CodeIndex, Reference};
+use crate::{CodeIndex, Reference, ReferenceKind};
 
 pub struct CResolver;
 
@@ -21,8 +21,15 @@ impl SymbolResolver for CResolver {
         name: &str,
         from_file: &Path,
     ) -> Option<ResolveResult<'a>> {
-        // 1. Try exact name match (C has flat namespace)
-        if let Some(symbol) = index.get(name) {
+        // 1. Try exact name match (C has flat namespace), preferring a
+        // definition over a header prototype
+        let candidates = index.get_all(name);
+        if let Some(symbol) = candidates
+            .iter()
+            .rev()
+            .find(|s| !s.is_declaration())
+            .or_else(|| candidates.last())
+        {
             return Some(ResolveResult {
                 symbol,
                 resolution_path: ResolutionPath::Qualified,
@@ -71,6 +78,9 @@ impl CResolver {
                 references.push(Reference {
                     name: parent.clone(),
                     location: symbol.location.clone(),
+                    kind: ReferenceKind::Unknown,
+                    target: None,
+                    arguments: None,
                 });
             }
         }
@@ -86,6 +96,9 @@ impl CResolver {
                     end_line: 1,
                     end_column: 1,
                 },
+                kind: ReferenceKind::Unknown,
+                target: None,
+                arguments: None,
             });
         }
 
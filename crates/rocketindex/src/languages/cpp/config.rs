@@ -0,0 +1,16 @@
+//! Comment, test, and generated-code conventions for C++.
+
+use crate::language_config::LanguageConfig;
+
+/// `///` and `/** */` doc comments; every function in `*_test.cpp`,
+/// `*_test.cc`, or `*_unittest.cc` files is a test.
+#[must_use]
+pub fn config() -> LanguageConfig {
+    LanguageConfig::c_style("cpp", &["cpp", "cc", "cxx", "hpp", "hxx", "hh"])
+        .with_doc_comments(&["///", "/**"])
+        .with_tests(
+            &["test_*.cpp", "*_test.cpp", "*_test.cc", "*_unittest.cc"],
+            None,
+            true,
+        )
+}
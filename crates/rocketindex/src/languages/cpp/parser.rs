@@ -3,8 +3,12 @@
 use std::cell::RefCell;
 use std::path::Path;
 
-use crate::parse::{find_child_by_kind, node_to_location, LanguageParser, ParseResult};
-use crate::{Reference, Symbol, SymbolKind, Visibility};
+use crate::declarations::{DECLARATION, MACRO};
+use crate::parse::{
+    collect_annotations, find_child_by_kind, node_to_location, record_extents, LanguageParser,
+    ParseResult,
+};
+use crate::{Reference, ReferenceKind, Symbol, SymbolKind, Visibility};
 
 // Thread-local parser reuse - avoids creating a new parser per file
 thread_local! {
@@ -39,6 +43,9 @@ impl LanguageParser for CppParser {
 
             // Extract references in a separate pass
             extract_references_recursive(&root, source.as_bytes(), file, &mut result);
+            record_extents(&root, &mut result.symbols);
+            result.annotations =
+                collect_annotations(file, &root, source.as_bytes(), &result.symbols);
 
             result
         })
@@ -61,7 +68,7 @@ fn extract_visibility_from_specifier(node: &tree_sitter::Node, source: &[u8]) ->
         if let Some(child) = node.child(i) {
             match child.kind() {
                 "public" => return Visibility::Public,
-                "protected" => return Visibility::Internal,
+                "protected" => return Visibility::Protected,
                 "private" => return Visibility::Private,
                 _ => {}
             }
@@ -72,7 +79,7 @@ fn extract_visibility_from_specifier(node: &tree_sitter::Node, source: &[u8]) ->
         let text = text.trim().trim_end_matches(':');
         match text {
             "public" => return Visibility::Public,
-            "protected" => return Visibility::Internal,
+            "protected" => return Visibility::Protected,
             "private" => return Visibility::Private,
             _ => {}
         }
@@ -124,6 +131,13 @@ fn extract_function_signature(node: &tree_sitter::Node, source: &[u8]) -> Option
             }
         }
     }
+    // For declarations without body
+    if let Ok(text) = node.utf8_text(source) {
+        let text = text.trim();
+        if text.ends_with(';') {
+            return Some(text.trim_end_matches(';').trim().to_string());
+        }
+    }
     None
 }
 
@@ -160,6 +174,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Recurse into namespace body
@@ -185,7 +200,12 @@ fn extract_recursive(
         "function_definition" => {
             if let Some(declarator) = node.child_by_field_name("declarator") {
                 if let Some(name) = extract_declarator_name(&declarator, source) {
-                    let qualified = qualified_name(&name, parent_path);
+                    // Out-of-line definitions (`void Shape::draw() {}`) take the
+                    // scope written in the declarator
+                    let qualified = match extract_declarator_scope(&declarator, source) {
+                        Some(scope) => qualified_name(&format!("{}::{}", scope, name), parent_path),
+                        None => qualified_name(&name, parent_path),
+                    };
                     let doc = extract_doc_comments(node, source);
                     let signature = extract_function_signature(node, source);
 
@@ -202,6 +222,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature,
+                        extent: None,
                     });
                 }
             }
@@ -242,7 +263,14 @@ fn extract_recursive(
                         "function_definition" => {
                             if let Some(declarator) = child.child_by_field_name("declarator") {
                                 if let Some(name) = extract_declarator_name(&declarator, source) {
-                                    let qualified = qualified_name(&name, parent_path);
+                                    let qualified =
+                                        match extract_declarator_scope(&declarator, source) {
+                                            Some(scope) => qualified_name(
+                                                &format!("{}::{}", scope, name),
+                                                parent_path,
+                                            ),
+                                            None => qualified_name(&name, parent_path),
+                                        };
                                     let doc = extract_doc_comments(node, source);
 
                                     result.symbols.push(Symbol {
@@ -258,6 +286,7 @@ fn extract_recursive(
                                         implements: None,
                                         doc,
                                         signature: None,
+                                        extent: None,
                                     });
                                 }
                             }
@@ -332,6 +361,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Extract enum values
@@ -349,6 +379,11 @@ fn extract_recursive(
                 if let Some(name) = extract_declarator_name(&declarator, source) {
                     let qualified = qualified_name(&name, parent_path);
                     let doc = extract_doc_comments(node, source);
+                    let signature = if is_function {
+                        extract_function_signature(node, source)
+                    } else {
+                        None
+                    };
 
                     result.symbols.push(Symbol {
                         name,
@@ -363,10 +398,11 @@ fn extract_recursive(
                         language: "cpp".to_string(),
                         parent: None,
                         mixins: None,
-                        attributes: None,
+                        attributes: is_function.then(|| vec![DECLARATION.to_string()]),
                         implements: None,
                         doc,
-                        signature: None,
+                        signature,
+                        extent: None,
                     });
                 }
             }
@@ -386,6 +422,31 @@ fn extract_recursive(
             }
         }
 
+        "preproc_function_def" => {
+            // #define MACRO(x) ...
+            if let Some(name_node) = node.child_by_field_name("name") {
+                if let Ok(name) = name_node.utf8_text(source) {
+                    let qualified = qualified_name(name, parent_path);
+
+                    result.symbols.push(Symbol {
+                        name: name.to_string(),
+                        qualified,
+                        kind: SymbolKind::Function,
+                        location: node_to_location(file, &name_node),
+                        visibility: Visibility::Public,
+                        language: "cpp".to_string(),
+                        parent: None,
+                        mixins: None,
+                        attributes: Some(vec![MACRO.to_string()]),
+                        implements: None,
+                        doc: None,
+                        signature: None,
+                        extent: None,
+                    });
+                }
+            }
+        }
+
         "preproc_include" => {
             // #include <header> or #include "header"
             if let Some(path_node) = node.child_by_field_name("path") {
@@ -454,6 +515,7 @@ fn extract_class_or_struct(
                 },
                 doc,
                 signature: None,
+                extent: None,
             });
 
             // Extract class members
@@ -514,11 +576,46 @@ fn extract_class_members(
         Visibility::Private
     };
 
-    for i in 0..body.child_count() {
-        if let Some(child) = body.child(i) {
+    extract_member_nodes(
+        body,
+        source,
+        file,
+        result,
+        parent_qualified,
+        max_depth,
+        &mut current_visibility,
+    );
+}
+
+/// Extract the members among `node`'s children, descending into
+/// `#if`/`#ifdef` blocks so conditionally compiled members are kept
+fn extract_member_nodes(
+    node: &tree_sitter::Node,
+    source: &[u8],
+    file: &Path,
+    result: &mut ParseResult,
+    parent_qualified: &str,
+    max_depth: usize,
+    current_visibility: &mut Visibility,
+) {
+    for i in 0..node.child_count() {
+        if let Some(child) = node.child(i) {
             match child.kind() {
                 "access_specifier" => {
-                    current_visibility = extract_visibility_from_specifier(&child, source);
+                    *current_visibility = extract_visibility_from_specifier(&child, source);
+                }
+
+                "preproc_if" | "preproc_ifdef" | "preproc_else" | "preproc_elif"
+                | "preproc_elifdef" => {
+                    extract_member_nodes(
+                        &child,
+                        source,
+                        file,
+                        result,
+                        parent_qualified,
+                        max_depth,
+                        current_visibility,
+                    );
                 }
 
                 "function_definition" => {
@@ -533,7 +630,7 @@ fn extract_class_members(
                                 qualified,
                                 kind: SymbolKind::Function,
                                 location: node_to_location(file, &declarator),
-                                visibility: current_visibility,
+                                visibility: *current_visibility,
                                 language: "cpp".to_string(),
                                 parent: Some(parent_qualified.to_string()),
                                 mixins: None,
@@ -541,6 +638,7 @@ fn extract_class_members(
                                 implements: None,
                                 doc,
                                 signature,
+                                extent: None,
                             });
                         }
                     }
@@ -551,25 +649,32 @@ fn extract_class_members(
                         if let Some(name) = extract_declarator_name(&declarator, source) {
                             let qualified = format!("{}::{}", parent_qualified, name);
                             // Check if it's a function declaration
-                            let kind = if is_function_declarator(&declarator) {
+                            let is_function = is_function_declarator(&declarator);
+                            let kind = if is_function {
                                 SymbolKind::Function
                             } else {
                                 SymbolKind::Member
                             };
+                            let signature = if is_function {
+                                extract_function_signature(&child, source)
+                            } else {
+                                None
+                            };
 
                             result.symbols.push(Symbol {
                                 name,
                                 qualified,
                                 kind,
                                 location: node_to_location(file, &declarator),
-                                visibility: current_visibility,
+                                visibility: *current_visibility,
                                 language: "cpp".to_string(),
                                 parent: Some(parent_qualified.to_string()),
                                 mixins: None,
-                                attributes: None,
+                                attributes: is_function.then(|| vec![DECLARATION.to_string()]),
                                 implements: None,
-                                doc: None,
-                                signature: None,
+                                doc: extract_doc_comments(&child, source),
+                                signature,
+                                extent: None,
                             });
                         }
                     }
@@ -580,25 +685,32 @@ fn extract_class_members(
                     if let Some(declarator) = child.child_by_field_name("declarator") {
                         if let Some(name) = extract_declarator_name(&declarator, source) {
                             let qualified = format!("{}::{}", parent_qualified, name);
-                            let kind = if is_function_declarator(&declarator) {
+                            let is_function = is_function_declarator(&declarator);
+                            let kind = if is_function {
                                 SymbolKind::Function
                             } else {
                                 SymbolKind::Member
                             };
+                            let signature = if is_function {
+                                extract_function_signature(&child, source)
+                            } else {
+                                None
+                            };
 
                             result.symbols.push(Symbol {
                                 name,
                                 qualified,
                                 kind,
                                 location: node_to_location(file, &declarator),
-                                visibility: current_visibility,
+                                visibility: *current_visibility,
                                 language: "cpp".to_string(),
                                 parent: Some(parent_qualified.to_string()),
                                 mixins: None,
-                                attributes: None,
+                                attributes: is_function.then(|| vec![DECLARATION.to_string()]),
                                 implements: None,
-                                doc: None,
-                                signature: None,
+                                doc: extract_doc_comments(&child, source),
+                                signature,
+                                extent: None,
                             });
                         }
                     }
@@ -672,6 +784,38 @@ fn extract_declarator_name(node: &tree_sitter::Node, source: &[u8]) -> Option<St
     }
 }
 
+/// Extract the scope an out-of-line definition names in its declarator:
+/// `Shape` for `void Shape::draw()`, without template arguments
+fn extract_declarator_scope(node: &tree_sitter::Node, source: &[u8]) -> Option<String> {
+    match node.kind() {
+        "function_declarator" | "pointer_declarator" | "reference_declarator" => node
+            .child_by_field_name("declarator")
+            .and_then(|d| extract_declarator_scope(&d, source)),
+        "qualified_identifier" => {
+            let text = node.utf8_text(source).ok()?;
+            let (scope, _) = text.rsplit_once("::")?;
+            let mut depth = 0usize;
+            let scope: String = scope
+                .chars()
+                .filter(|c| match c {
+                    '<' => {
+                        depth += 1;
+                        false
+                    }
+                    '>' => {
+                        depth = depth.saturating_sub(1);
+                        false
+                    }
+                    c => depth == 0 && !c.is_whitespace(),
+                })
+                .collect();
+            let scope = scope.trim_start_matches("::");
+            (!scope.is_empty()).then(|| scope.to_string())
+        }
+        _ => None,
+    }
+}
+
 /// Check if a declarator is a function declarator
 fn is_function_declarator(node: &tree_sitter::Node) -> bool {
     match node.kind() {
@@ -722,6 +866,7 @@ fn extract_enum_values(
                             implements: None,
                             doc: None,
                             signature: None,
+                            extent: None,
                         });
                     }
                 }
@@ -745,6 +890,9 @@ fn extract_references_recursive(
                     result.references.push(Reference {
                         name: name.to_string(),
                         location: node_to_location(file, node),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -756,6 +904,9 @@ fn extract_references_recursive(
                     result.references.push(Reference {
                         name: name.to_string(),
                         location: node_to_location(file, node),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     });
                 }
                 return; // Don't recurse into qualified_identifier children
@@ -773,6 +924,9 @@ fn extract_references_recursive(
                 result.references.push(Reference {
                     name: func_name,
                     location,
+                    kind: ReferenceKind::Call,
+                    target: None,
+                    arguments: None,
                 });
             }
         }
@@ -1407,4 +1561,69 @@ public:
             ref_names
         );
     }
+
+    #[test]
+    fn qualifies_out_of_line_definitions_by_declarator_scope() {
+        let source = r#"
+namespace geo {
+class Shape {
+public:
+    // Draws the shape
+    void draw();
+};
+
+void Shape::draw() { }
+}
+
+template <typename T>
+T Box<T>::get() { return value; }
+"#;
+        let parser = CppParser;
+        let result = parser.extract_symbols(Path::new("shape.cpp"), source, 100);
+
+        let draws: Vec<&Symbol> = result
+            .symbols
+            .iter()
+            .filter(|s| s.qualified == "geo::Shape::draw")
+            .collect();
+        assert_eq!(draws.len(), 2, "{:?}", result.symbols);
+        assert!(draws[0].is_declaration());
+        assert_eq!(draws[0].doc.as_deref(), Some("Draws the shape"));
+        assert_eq!(draws[0].signature.as_deref(), Some("void draw()"));
+        assert!(!draws[1].is_declaration());
+
+        assert!(result.symbols.iter().any(|s| s.qualified == "Box::get"));
+    }
+
+    #[test]
+    fn extracts_members_inside_preprocessor_conditionals() {
+        let source = r#"
+#define LOG(msg) log_impl(msg)
+
+class Widget {
+public:
+#ifdef DEBUG
+    void dump();
+#else
+    int size;
+#endif
+private:
+    int secret;
+};
+"#;
+        let parser = CppParser;
+        let result = parser.extract_symbols(Path::new("widget.h"), source, 100);
+
+        let member = |qualified: &str| {
+            result
+                .symbols
+                .iter()
+                .find(|s| s.qualified == qualified)
+                .unwrap_or_else(|| panic!("missing {}: {:?}", qualified, result.symbols))
+        };
+        assert_eq!(member("Widget::dump").visibility, Visibility::Public);
+        assert_eq!(member("Widget::size").visibility, Visibility::Public);
+        assert_eq!(member("Widget::secret").visibility, Visibility::Private);
+        assert!(member("LOG").is_macro());
+    }
 }
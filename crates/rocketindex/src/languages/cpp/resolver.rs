@@ -10,15 +10,16 @@ use std::path::Path;
 
 use crate::parse::ParseResult;
 use crate::resolve::{ResolutionPath, ResolveResult, SymbolResolver};
-use crate::{CodeIndex, Reference, Symbol, SymbolKind};
+use crate::{CodeIndex, Reference, ReferenceKind, Symbol, SymbolKind};
 
 pub struct CppResolver;
 
 /// Select the best matching symbol from a list of candidates.
 /// Priorities:
-/// 1. Source files (.c, .cpp, .cc, .cxx)
-/// 2. Header files (.h, .hpp, .hh, .hxx)
-/// 3. Others/Last added
+/// 1. Definitions over bodiless declarations
+/// 2. Source files (.c, .cpp, .cc, .cxx)
+/// 3. Header files (.h, .hpp, .hh, .hxx)
+/// 4. Others/Last added
 fn select_best_match(symbols: &[Symbol]) -> Option<&Symbol> {
     if symbols.is_empty() {
         return None;
@@ -31,11 +32,12 @@ fn select_best_match(symbols: &[Symbol]) -> Option<&Symbol> {
             .and_then(|e| e.to_str())
             .unwrap_or("")
             .to_lowercase();
-        match ext.as_str() {
+        let rank = match ext.as_str() {
             "cpp" | "cc" | "c" | "cxx" => 2,
             "hpp" | "h" | "hh" | "hxx" => 1,
             _ => 0,
-        }
+        };
+        (!s.is_declaration(), rank)
     })
 }
 
@@ -184,6 +186,9 @@ impl CppResolver {
                 references.push(Reference {
                     name: parent.clone(),
                     location: symbol.location.clone(),
+                    kind: ReferenceKind::Unknown,
+                    target: None,
+                    arguments: None,
                 });
             }
 
@@ -193,6 +198,9 @@ impl CppResolver {
                     references.push(Reference {
                         name: base.clone(),
                         location: symbol.location.clone(),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -209,6 +217,9 @@ impl CppResolver {
                     end_line: 1,
                     end_column: 1,
                 },
+                kind: ReferenceKind::Unknown,
+                target: None,
+                arguments: None,
             });
         }
 
@@ -0,0 +1,12 @@
+//! Comment, test, and generated-code conventions for C#.
+
+use crate::language_config::LanguageConfig;
+
+/// `///` XML doc comments; every method in `*Tests.cs` or `*Test.cs` is a
+/// test.
+#[must_use]
+pub fn config() -> LanguageConfig {
+    LanguageConfig::c_style("csharp", &["cs"])
+        .with_doc_comments(&["///"])
+        .with_tests(&["*Tests.cs", "*Test.cs"], None, true)
+}
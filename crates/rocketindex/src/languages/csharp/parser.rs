@@ -3,8 +3,10 @@
 use std::cell::RefCell;
 use std::path::Path;
 
-use crate::parse::{node_to_location, LanguageParser, ParseResult};
-use crate::{Reference, Symbol, SymbolKind, Visibility};
+use crate::parse::{
+    collect_annotations, node_to_location, record_extents, LanguageParser, ParseResult,
+};
+use crate::{Reference, ReferenceKind, Symbol, SymbolKind, Visibility};
 
 // Thread-local parser reuse - avoids creating a new parser per file
 thread_local! {
@@ -51,6 +53,9 @@ impl LanguageParser for CSharpParser {
 
             // Extract references in a separate pass
             extract_references_recursive(&root, source.as_bytes(), file, &mut result);
+            record_extents(&root, &mut result.symbols);
+            result.annotations =
+                collect_annotations(file, &root, source.as_bytes(), &result.symbols);
 
             result
         })
@@ -348,6 +353,7 @@ fn extract_type_declaration(
         implements: None,
         doc,
         signature: None,
+        extent: None,
     })
 }
 
@@ -443,6 +449,7 @@ fn extract_delegate_declaration(
         implements: None,
         doc: None,
         signature: None,
+        extent: None,
     })
 }
 
@@ -614,6 +621,7 @@ fn extract_method(
         implements: None,
         doc: None,
         signature: None,
+        extent: None,
     })
 }
 
@@ -642,6 +650,7 @@ fn extract_constructor(
         implements: None,
         doc: None,
         signature: None,
+        extent: None,
     })
 }
 
@@ -679,6 +688,7 @@ fn extract_fields(
                                     implements: None,
                                     doc: None,
                                     signature: None,
+                                    extent: None,
                                 });
                             }
                         }
@@ -714,6 +724,7 @@ fn extract_property(
         implements: None,
         doc: None,
         signature: None,
+        extent: None,
     })
 }
 
@@ -764,6 +775,7 @@ fn extract_event(
         implements: None,
         doc: None,
         signature: None,
+        extent: None,
     })
 }
 
@@ -791,6 +803,7 @@ fn extract_indexer(
         implements: None,
         doc: None,
         signature: None,
+        extent: None,
     })
 }
 
@@ -835,6 +848,7 @@ fn extract_operator(
         implements: None,
         doc: None,
         signature: None,
+        extent: None,
     })
 }
 
@@ -869,6 +883,7 @@ fn extract_record_parameters(
                                     implements: None,
                                     doc: None,
                                     signature: None,
+                                    extent: None,
                                 });
                             }
                         }
@@ -906,6 +921,7 @@ fn extract_enum_members(
                         implements: None,
                         doc: None,
                         signature: None,
+                        extent: None,
                     });
                 }
             }
@@ -921,7 +937,7 @@ fn extract_visibility(node: &tree_sitter::Node, source: &[u8]) -> Visibility {
                 match text.as_str() {
                     "public" => return Visibility::Public,
                     "private" => return Visibility::Private,
-                    "protected" => return Visibility::Internal, // Map protected to Internal
+                    "protected" => return Visibility::Protected,
                     "internal" => return Visibility::Internal,
                     _ => {}
                 }
@@ -947,6 +963,9 @@ fn extract_references_recursive(
                     result.references.push(Reference {
                         name: name.to_string(),
                         location: node_to_location(file, node),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -959,6 +978,9 @@ fn extract_references_recursive(
                     result.references.push(Reference {
                         name: name.to_string(),
                         location: node_to_location(file, node),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -976,6 +998,9 @@ fn extract_references_recursive(
                             result.references.push(Reference {
                                 name: name.to_string(),
                                 location: node_to_location(file, &name_node),
+                                kind: ReferenceKind::Unknown,
+                                target: None,
+                                arguments: None,
                             });
                         }
                     }
@@ -999,6 +1024,9 @@ fn extract_references_recursive(
                                 result.references.push(Reference {
                                     name: method_name.to_string(),
                                     location: node_to_location(file, &name_node),
+                                    kind: ReferenceKind::Unknown,
+                                    target: None,
+                                    arguments: None,
                                 });
                             }
                         }
@@ -1009,6 +1037,9 @@ fn extract_references_recursive(
                             result.references.push(Reference {
                                 name: name.to_string(),
                                 location: node_to_location(file, &function),
+                                kind: ReferenceKind::Unknown,
+                                target: None,
+                                arguments: None,
                             });
                         }
                     }
@@ -1020,6 +1051,9 @@ fn extract_references_recursive(
                                     result.references.push(Reference {
                                         name: name.to_string(),
                                         location: node_to_location(file, &name_node),
+                                        kind: ReferenceKind::Unknown,
+                                        target: None,
+                                        arguments: None,
                                     });
                                 }
                             }
@@ -1502,8 +1536,7 @@ public class Example {
 
         let protected_field = result.symbols.iter().find(|s| s.name == "ProtectedField");
         assert!(protected_field.is_some());
-        // Protected maps to Internal since we don't have a Protected variant
-        assert_eq!(protected_field.unwrap().visibility, Visibility::Internal);
+        assert_eq!(protected_field.unwrap().visibility, Visibility::Protected);
 
         let internal_field = result.symbols.iter().find(|s| s.name == "InternalField");
         assert!(internal_field.is_some());
@@ -10,7 +10,7 @@ use std::path::Path;
 
 use crate::parse::ParseResult;
 use crate::resolve::{ResolutionPath, ResolveResult, SymbolResolver};
-use crate::{CodeIndex, Reference, SymbolKind};
+use crate::{CodeIndex, Reference, ReferenceKind, SymbolKind};
 
 pub struct CSharpResolver;
 
@@ -163,6 +163,9 @@ impl CSharpResolver {
                 references.push(Reference {
                     name: parent.clone(),
                     location: symbol.location.clone(),
+                    kind: ReferenceKind::Unknown,
+                    target: None,
+                    arguments: None,
                 });
             }
 
@@ -172,6 +175,9 @@ impl CSharpResolver {
                     references.push(Reference {
                         name: iface.clone(),
                         location: symbol.location.clone(),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -188,6 +194,9 @@ impl CSharpResolver {
                     end_line: 1,
                     end_column: 1,
                 },
+                kind: ReferenceKind::Unknown,
+                target: None,
+                arguments: None,
             });
         }
 
@@ -0,0 +1,13 @@
+//! Comment, test, and generated-code conventions for F#.
+
+use crate::language_config::LanguageConfig;
+
+/// `//` and `(* *)` comments, `///` doc comments; every function in
+/// `*Tests.fs` or `*Test.fs` is a test.
+#[must_use]
+pub fn config() -> LanguageConfig {
+    LanguageConfig::c_style("fsharp", &["fs", "fsi", "fsx"])
+        .with_comments(&["//"], Some(("(*", "*)")))
+        .with_doc_comments(&["///"])
+        .with_tests(&["*Tests.fs", "*Test.fs"], None, true)
+}
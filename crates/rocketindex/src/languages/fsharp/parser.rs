@@ -3,8 +3,10 @@
 use std::cell::RefCell;
 use std::path::Path;
 
-use crate::parse::{LanguageParser, ParseResult, ParseWarning, SyntaxError};
-use crate::{Location, Reference, Symbol, SymbolKind, Visibility};
+use crate::parse::{
+    collect_annotations, record_extents, LanguageParser, ParseResult, ParseWarning, SyntaxError,
+};
+use crate::{Location, Reference, ReferenceKind, Symbol, SymbolKind, Visibility};
 
 // Thread-local parser reuse - avoids creating a new parser per file
 thread_local! {
@@ -46,6 +48,9 @@ impl LanguageParser for FSharpParser {
                 None, // No parent module yet
                 max_depth,
             );
+            record_extents(&root, &mut result.symbols);
+            result.annotations =
+                collect_annotations(file, &root, source.as_bytes(), &result.symbols);
 
             result
         })
@@ -130,6 +135,7 @@ impl<'a> ExtractionContext<'a> {
             },
             doc: doc.map(|d| d.to_string()),
             signature,
+            extent: None,
         };
         self.result.symbols.push(symbol);
 
@@ -177,6 +183,7 @@ impl<'a> ExtractionContext<'a> {
                                             implements: None,
                                             doc: None,
                                             signature: None,
+                                            extent: None,
                                         });
                                     }
                                 }
@@ -216,6 +223,7 @@ impl<'a> ExtractionContext<'a> {
                                             implements: None,
                                             doc: None,
                                             signature: None,
+                                            extent: None,
                                         });
                                     }
                                 }
@@ -262,6 +270,7 @@ impl<'a> ExtractionContext<'a> {
                             implements: None,
                             doc: None,
                             signature: None,
+                            extent: None,
                         };
                         self.result.symbols.push(symbol);
                     }
@@ -532,6 +541,7 @@ fn extract_recursive_with_depth(
                         implements: None,
                         doc: None,
                         signature: None,
+                        extent: None,
                     };
                     if result.module_path.is_none() {
                         result.module_path = Some(qualified.clone());
@@ -589,6 +599,9 @@ fn extract_recursive_with_depth(
                     result.references.push(Reference {
                         name: name.to_string(),
                         location: node_to_location(file, node),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -955,6 +968,7 @@ fn handle_function_or_value_defn(
                     implements: None,
                     doc: doc.clone(),
                     signature: signature.clone(),
+                    extent: None,
                 };
                 result.symbols.push(symbol);
                 handled = true;
@@ -986,6 +1000,7 @@ fn handle_function_or_value_defn(
                     implements: None,
                     doc: doc.clone(),
                     signature: signature.clone(),
+                    extent: None,
                 };
                 result.symbols.push(symbol);
                 handled = true;
@@ -1018,6 +1033,7 @@ fn handle_function_or_value_defn(
                         implements: None,
                         doc,
                         signature,
+                        extent: None,
                     };
                     result.symbols.push(symbol);
                 }
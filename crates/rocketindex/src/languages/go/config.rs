@@ -0,0 +1,13 @@
+//! Comment, test, and generated-code conventions for Go.
+
+use crate::language_config::LanguageConfig;
+
+/// Any `//` comment directly above a declaration documents it; `go test`
+/// runs the top-level `TestXxx` functions of `_test.go` files.
+#[must_use]
+pub fn config() -> LanguageConfig {
+    LanguageConfig::c_style("go", &["go"])
+        .with_doc_comments(&["//"])
+        // `Testify` is not a test: the suffix must not start with a lowercase letter
+        .with_tests(&["*_test.go"], Some(r"^Test(\P{Ll}|$)"), false)
+}
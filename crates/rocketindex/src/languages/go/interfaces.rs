@@ -0,0 +1,330 @@
+//! Structural interface satisfaction for Go.
+//!
+//! Go types never declare the interfaces they implement: a type implements an
+//! interface when its method set contains every method the interface declares.
+//! This module recovers those relationships from indexed symbols by comparing
+//! method names and signatures (parameter and result types, ignoring names).
+//!
+//! Limitations:
+//! - Pointer and value receivers are treated alike.
+//! - Embedded interfaces that are not in the index (e.g. `io.Reader`) are ignored.
+//! - Interfaces without methods (`interface{}`, `any`) report no implementations,
+//!   since every type would qualify.
+
+use std::collections::{HashMap, HashSet};
+
+use crate::signature::Param;
+use crate::{CodeIndex, Symbol, SymbolKind};
+
+/// Find the concrete Go types whose method sets satisfy `interface`.
+///
+/// Results are sorted by qualified name.
+pub fn implementations<'a>(index: &'a CodeIndex, interface: &Symbol) -> Vec<&'a Symbol> {
+    let required = interface_methods(index, interface);
+    if required.is_empty() {
+        return Vec::new();
+    }
+
+    let methods = concrete_methods(index);
+    let mut implementers: Vec<&Symbol> = index
+        .symbols()
+        .filter(|s| s.language == "go" && is_concrete_type(s.kind))
+        .filter(|s| {
+            methods.get(s.qualified.as_str()).is_some_and(|have| {
+                required
+                    .iter()
+                    .all(|(name, sig)| have.get(name.as_str()) == Some(sig))
+            })
+        })
+        .collect();
+
+    implementers.sort_by(|a, b| a.qualified.cmp(&b.qualified));
+    implementers.dedup_by(|a, b| a.qualified == b.qualified);
+    implementers
+}
+
+/// Method name -> normalized signature for every method `interface` requires,
+/// including methods of embedded interfaces.
+fn interface_methods(index: &CodeIndex, interface: &Symbol) -> HashMap<String, MethodSignature> {
+    let mut required = HashMap::new();
+    let mut visited = HashSet::new();
+    let mut pending = vec![interface];
+
+    while let Some(current) = pending.pop() {
+        if !visited.insert(current.qualified.as_str()) {
+            continue;
+        }
+
+        for symbol in index.symbols() {
+            if symbol.kind == SymbolKind::Function
+                && symbol.parent.as_deref() == Some(current.qualified.as_str())
+            {
+                if let Some(sig) = symbol.signature.as_deref().and_then(parse_signature) {
+                    required.insert(symbol.name.clone(), sig);
+                }
+            }
+        }
+
+        for embedded in current.mixins.iter().flatten() {
+            if let Some(found) = resolve_interface(index, current, embedded) {
+                pending.push(found);
+            }
+        }
+    }
+
+    required
+}
+
+/// Resolve an embedded interface name relative to the embedding interface's package.
+fn resolve_interface<'a>(index: &'a CodeIndex, from: &Symbol, name: &str) -> Option<&'a Symbol> {
+    let package_qualified = from
+        .qualified
+        .rsplit_once('.')
+        .map(|(package, _)| format!("{}.{}", package, name));
+
+    std::iter::once(name.to_string())
+        .chain(package_qualified)
+        .flat_map(|q| index.get_all(&q).iter())
+        .find(|s| s.kind == SymbolKind::Interface)
+}
+
+/// Type qualified name -> (method name -> normalized signature).
+fn concrete_methods(index: &CodeIndex) -> HashMap<&str, HashMap<&str, MethodSignature>> {
+    let mut methods: HashMap<&str, HashMap<&str, MethodSignature>> = HashMap::new();
+
+    for symbol in index.symbols() {
+        if symbol.language != "go" || symbol.kind != SymbolKind::Function {
+            continue;
+        }
+        // Receiver methods carry the receiver type name as parent; interface
+        // methods carry the interface's qualified name and never match a
+        // concrete type below.
+        if symbol.parent.is_none() {
+            continue;
+        }
+        let owner = match symbol.qualified.rsplit_once('.') {
+            Some((owner, _)) => owner,
+            None => continue,
+        };
+        if let Some(sig) = symbol.signature.as_deref().and_then(parse_signature) {
+            methods
+                .entry(owner)
+                .or_default()
+                .insert(symbol.name.as_str(), sig);
+        }
+    }
+
+    methods
+}
+
+fn is_concrete_type(kind: SymbolKind) -> bool {
+    matches!(
+        kind,
+        SymbolKind::Class | SymbolKind::Record | SymbolKind::Type
+    )
+}
+
+/// Parameter and result types of a method, with parameter names removed.
+#[derive(Debug, Clone, PartialEq, Eq)]
+struct MethodSignature {
+    params: Vec<String>,
+    results: Vec<String>,
+}
+
+/// Parse a signature as produced by the Go parser:
+/// `func Name(params) results` or `func (recv Recv) Name(params) results`.
+fn parse_signature(signature: &str) -> Option<MethodSignature> {
+    let signature = super::signature::parse(signature)?;
+    let types = |params: &[Param]| params.iter().map(Param::type_text).collect();
+    Some(MethodSignature {
+        params: types(&signature.params),
+        results: types(&signature.results),
+    })
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::{Location, Visibility};
+    use std::path::PathBuf;
+
+    fn sig(signature: &str) -> MethodSignature {
+        parse_signature(signature).unwrap()
+    }
+
+    #[test]
+    fn test_parse_signature_ignores_names_and_receiver() {
+        assert_eq!(
+            sig("func Read(p []byte) (n int, err error)"),
+            sig("func (File) Read(buf []byte) (int, error)")
+        );
+        assert_eq!(
+            sig("func Copy(dst, src string, n int)").params,
+            vec!["string", "string", "int"]
+        );
+        assert_eq!(sig("func Close() error").results, vec!["error"]);
+        assert_eq!(
+            sig("func Send(c chan int, f func(a int) error)").params,
+            vec!["chan int", "func(a int) error"]
+        );
+        assert_eq!(sig("func Pipe(chan int)").params, vec!["chan int"]);
+        assert_eq!(
+            sig("func Apply[T any, F func(T) T](x T, f F) T"),
+            sig("func Apply(y T, g F) T")
+        );
+        assert_ne!(
+            sig("func Read(p []byte) int"),
+            sig("func Read(p string) int")
+        );
+    }
+
+    fn go_symbol(name: &str, qualified: &str, kind: SymbolKind, line: u32) -> Symbol {
+        Symbol::new(
+            name.to_string(),
+            qualified.to_string(),
+            kind,
+            Location::new(PathBuf::from("shapes.go"), line, 6),
+            Visibility::Public,
+            "go".to_string(),
+        )
+    }
+
+    fn add_method(index: &mut CodeIndex, owner: &str, name: &str, signature: &str, line: u32) {
+        index.add_symbol(
+            go_symbol(
+                name,
+                &format!("shapes.{}.{}", owner, name),
+                SymbolKind::Function,
+                line,
+            )
+            .with_parent(Some(owner.to_string()))
+            .with_signature(Some(signature.to_string())),
+        );
+    }
+
+    fn add_interface_method(index: &mut CodeIndex, iface: &str, name: &str, signature: &str) {
+        let qualified = format!("shapes.{}", iface);
+        index.add_symbol(
+            go_symbol(
+                name,
+                &format!("{}.{}", qualified, name),
+                SymbolKind::Function,
+                1,
+            )
+            .with_parent(Some(qualified))
+            .with_signature(Some(signature.to_string())),
+        );
+    }
+
+    fn shapes_index() -> CodeIndex {
+        let mut index = CodeIndex::new();
+        index.add_symbol(go_symbol("Shape", "shapes.Shape", SymbolKind::Interface, 1));
+        add_interface_method(&mut index, "Shape", "Area", "func Area() float64");
+        add_interface_method(&mut index, "Shape", "Name", "func Name() string");
+        index.add_symbol(
+            go_symbol("Solid", "shapes.Solid", SymbolKind::Interface, 5)
+                .with_mixins(Some(vec!["Shape".to_string()])),
+        );
+        add_interface_method(&mut index, "Solid", "Volume", "func Volume() float64");
+
+        index.add_symbol(go_symbol("Square", "shapes.Square", SymbolKind::Class, 10));
+        add_method(
+            &mut index,
+            "Square",
+            "Area",
+            "func (Square) Area() float64",
+            11,
+        );
+        add_method(
+            &mut index,
+            "Square",
+            "Name",
+            "func (Square) Name() string",
+            12,
+        );
+
+        index.add_symbol(go_symbol("Cube", "shapes.Cube", SymbolKind::Class, 20));
+        add_method(&mut index, "Cube", "Area", "func (Cube) Area() float64", 21);
+        add_method(&mut index, "Cube", "Name", "func (Cube) Name() string", 22);
+        add_method(
+            &mut index,
+            "Cube",
+            "Volume",
+            "func (Cube) Volume() float64",
+            23,
+        );
+
+        // Right names, wrong signature
+        index.add_symbol(go_symbol("Label", "shapes.Label", SymbolKind::Class, 30));
+        add_method(&mut index, "Label", "Area", "func (Label) Area() int", 31);
+        add_method(
+            &mut index,
+            "Label",
+            "Name",
+            "func (Label) Name() string",
+            32,
+        );
+        index
+    }
+
+    fn names<'a>(symbols: &[&'a Symbol]) -> Vec<&'a str> {
+        symbols.iter().map(|s| s.qualified.as_str()).collect()
+    }
+
+    #[test]
+    fn test_implementations_match_method_sets() {
+        let index = shapes_index();
+        let shape = index.get("shapes.Shape").unwrap();
+
+        assert_eq!(
+            names(&implementations(&index, shape)),
+            vec!["shapes.Cube", "shapes.Square"]
+        );
+    }
+
+    #[test]
+    fn test_implementations_include_embedded_interface_methods() {
+        let index = shapes_index();
+        let solid = index.get("shapes.Solid").unwrap();
+
+        assert_eq!(names(&implementations(&index, solid)), vec!["shapes.Cube"]);
+    }
+
+    #[test]
+    fn test_empty_interface_has_no_implementations() {
+        let mut index = shapes_index();
+        index.add_symbol(go_symbol("Any", "shapes.Any", SymbolKind::Interface, 40));
+        let any = index.get("shapes.Any").unwrap();
+
+        assert!(implementations(&index, any).is_empty());
+    }
+
+    #[test]
+    fn test_implementations_from_parsed_source() {
+        let source = r#"package shapes
+
+type Shape interface {
+    Area() float64
+}
+
+type Square struct{ side float64 }
+
+func (s *Square) Area() float64 { return s.side * s.side }
+
+type Label struct{}
+
+func (l Label) Text() string { return "" }
+"#;
+        let result = crate::extract_symbols(&PathBuf::from("shapes.go"), source, 100);
+        let mut index = CodeIndex::new();
+        for symbol in result.symbols {
+            index.add_symbol(symbol);
+        }
+
+        let shape = index.get("shapes.Shape").unwrap();
+        assert_eq!(
+            names(&implementations(&index, shape)),
+            vec!["shapes.Square"]
+        );
+    }
+}
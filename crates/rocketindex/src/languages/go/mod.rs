@@ -1,5 +1,10 @@
+pub mod config;
+pub mod interfaces;
 pub mod parser;
 pub mod resolver;
+pub mod signature;
+pub mod testing;
 
 pub use parser::GoParser;
 pub use resolver::GoResolver;
+pub use testing::GoTestDetector;
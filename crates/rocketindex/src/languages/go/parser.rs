@@ -1,10 +1,14 @@
 //! Symbol extraction from Go source files using tree-sitter.
 
 use std::cell::RefCell;
+use std::collections::HashMap;
 use std::path::Path;
 
-use crate::parse::{find_child_by_kind, node_to_location, LanguageParser, ParseResult};
-use crate::{Reference, Symbol, SymbolKind, Visibility};
+use crate::parse::{
+    collect_annotations, collect_syntax_errors, find_child_by_kind, node_to_location,
+    record_extents, strip_comment_markers, LanguageParser, ParseResult,
+};
+use crate::{Reference, ReferenceKind, Symbol, SymbolKind, Visibility};
 
 // Thread-local parser reuse - avoids creating a new parser per file
 thread_local! {
@@ -35,6 +39,10 @@ impl LanguageParser for GoParser {
             let mut result = ParseResult::default();
             let root = tree.root_node();
 
+            // A broken declaration becomes an ERROR node; the declarations
+            // around it are still extracted below
+            result.errors = collect_syntax_errors(file, &root, source.as_bytes());
+
             // Extract package name first for qualified names
             let package_name = extract_package_name(&root, source);
 
@@ -46,10 +54,29 @@ impl LanguageParser for GoParser {
                 package_name.as_deref(),
                 max_depth,
             );
+            resolve_selector_targets(
+                &root,
+                source.as_bytes(),
+                file,
+                &mut result,
+                package_name.as_deref(),
+            );
+            extract_function_values(
+                &root,
+                source.as_bytes(),
+                file,
+                &mut result,
+                package_name.as_deref(),
+            );
 
             // Set module path from package
             result.module_path = package_name;
 
+            record_extents(&root, &mut result.symbols);
+
+            result.annotations =
+                collect_annotations(file, &root, source.as_bytes(), &result.symbols);
+
             result
         })
     }
@@ -100,43 +127,61 @@ fn qualified_name(name: &str, package: Option<&str>) -> String {
     }
 }
 
-/// Extract doc comments from preceding comment nodes
+/// Extract the doc comment of a declaration.
+///
+/// The doc comment is the contiguous block of comments ending on the line
+/// directly above the declaration, with comment markers removed. A blank line
+/// ends the block, and so does a comment trailing code on its own line
+/// (`x := 1 // note`). Compiler directives such as `//go:generate` are skipped.
 fn extract_doc_comments(node: &tree_sitter::Node, source: &[u8]) -> Option<String> {
-    let mut docs = Vec::new();
-
-    // Look for preceding comment nodes (siblings before this node)
-    if let Some(parent) = node.parent() {
-        let mut prev_sibling = None;
-        for i in 0..parent.child_count() {
-            if let Some(child) = parent.child(i) {
-                if child.id() == node.id() {
-                    break;
-                }
-                prev_sibling = Some(child);
-            }
-        }
+    let mut block = Vec::new();
+    let mut next_row = node.start_position().row;
+    let mut current = node.prev_sibling();
 
-        // Check if the previous sibling is a comment
-        if let Some(prev) = prev_sibling {
-            if prev.kind() == "comment" {
-                if let Ok(text) = prev.utf8_text(source) {
-                    let doc = text
-                        .trim_start_matches("//")
-                        .trim_start_matches("/*")
-                        .trim_end_matches("*/")
-                        .trim();
-                    if !doc.is_empty() {
-                        docs.push(doc.to_string());
-                    }
-                }
-            }
+    while let Some(prev) = current {
+        if prev.kind() != "comment" || prev.end_position().row + 1 != next_row {
+            break;
         }
+        let trailing = prev
+            .prev_sibling()
+            .is_some_and(|before| before.end_position().row == prev.start_position().row);
+        if trailing {
+            break;
+        }
+        block.push(prev);
+        next_row = prev.start_position().row;
+        current = prev.prev_sibling();
+    }
+
+    if block.is_empty() {
+        // `type User struct {...}`: the comment sits above the `type` keyword,
+        // which belongs to the enclosing declaration rather than the spec
+        let parent = node.parent()?;
+        let ungrouped_spec = matches!(
+            parent.kind(),
+            "type_declaration" | "const_declaration" | "var_declaration"
+        ) && parent.start_position().row == node.start_position().row;
+        return if ungrouped_spec {
+            extract_doc_comments(&parent, source)
+        } else {
+            None
+        };
     }
 
-    if docs.is_empty() {
+    block.reverse();
+    let lines: Vec<&str> = block
+        .iter()
+        .filter_map(|comment| comment.utf8_text(source).ok())
+        .filter(|text| !text.starts_with("//go:"))
+        .map(strip_comment_markers)
+        .collect();
+    let doc = lines.join("\n");
+    let doc = doc.trim_matches('\n');
+
+    if doc.trim().is_empty() {
         None
     } else {
-        Some(docs.join("\n"))
+        Some(doc.to_string())
     }
 }
 
@@ -148,6 +193,11 @@ fn extract_function_signature(
 ) -> Option<String> {
     let mut sig = format!("func {}", name);
 
+    // Type parameters of a generic function: [T any, U Number]
+    if let Some(type_params) = type_parameters(node, source) {
+        sig.push_str(type_params);
+    }
+
     // Get parameters
     if let Some(params) = node.child_by_field_name("parameters") {
         if let Ok(params_text) = params.utf8_text(source) {
@@ -166,7 +216,25 @@ fn extract_function_signature(
     Some(sig)
 }
 
+/// Text of a declaration's type parameter list (`[T any]`), if it is generic.
+fn type_parameters<'a>(node: &tree_sitter::Node, source: &'a [u8]) -> Option<&'a str> {
+    node.child_by_field_name("type_parameters")?
+        .utf8_text(source)
+        .ok()
+}
+
+/// Strip type arguments from a generic type name: `Stack[T]` -> `Stack`.
+fn base_type_name(type_name: &str) -> &str {
+    type_name
+        .split_once('[')
+        .map_or(type_name, |(base, _)| base)
+        .trim_end()
+}
+
 /// Extract the receiver type from a method declaration
+///
+/// The pointer and any type arguments are stripped, so `(s *Stack[T])` and
+/// `(s Stack[T])` both yield `Stack`.
 fn extract_receiver_type(node: &tree_sitter::Node, source: &[u8]) -> Option<String> {
     let receiver = node.child_by_field_name("receiver")?;
 
@@ -177,8 +245,8 @@ fn extract_receiver_type(node: &tree_sitter::Node, source: &[u8]) -> Option<Stri
                 // Look for the type (could be pointer or value receiver)
                 if let Some(type_node) = child.child_by_field_name("type") {
                     let type_text = type_node.utf8_text(source).ok()?;
-                    // Strip pointer prefix if present
-                    let type_name = type_text.trim_start_matches('*');
+                    // Strip pointer prefix and type arguments if present
+                    let type_name = base_type_name(type_text.trim_start_matches('*'));
                     return Some(type_name.to_string());
                 }
             }
@@ -248,6 +316,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature,
+                        extent: None,
                     });
                 }
             }
@@ -268,10 +337,13 @@ fn extract_recursive(
                         (None, None) => name.to_string(),
                     };
 
-                    // Build signature with receiver
+                    // Build signature with the receiver as written: (ps *PaymentService)
                     let mut sig = String::from("func ");
-                    if let Some(recv) = &receiver_type {
-                        sig.push_str(&format!("({}) ", recv));
+                    if let Some(receiver) = node.child_by_field_name("receiver") {
+                        if let Ok(receiver_text) = receiver.utf8_text(source) {
+                            sig.push_str(receiver_text);
+                            sig.push(' ');
+                        }
                     }
                     sig.push_str(name);
                     if let Some(params) = node.child_by_field_name("parameters") {
@@ -299,6 +371,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: Some(sig),
+                        extent: None,
                     });
                 }
             }
@@ -367,6 +440,9 @@ fn extract_recursive(
                     result.references.push(Reference {
                         name: name.to_string(),
                         location: node_to_location(file, node),
+                        kind: reference_kind(node),
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -379,6 +455,9 @@ fn extract_recursive(
                     result.references.push(Reference {
                         name: name.to_string(),
                         location: node_to_location(file, node),
+                        kind: reference_kind(node),
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -395,6 +474,578 @@ fn extract_recursive(
     }
 }
 
+/// Set [`Reference::target`] for `x.Name` selectors that can be resolved locally.
+///
+/// An operand naming an import refers to that package, so with
+/// `import pay "example.com/shop/payment"` the reference `pay.ProcessPayment`
+/// targets `payment.ProcessPayment`. An operand naming a variable with a known
+/// type (a parameter, receiver, `var x T`, or `x := &T{}`) refers to a method
+/// or field of that type, so `svc.ProcessPayment` with `svc *pay.PaymentService`
+/// targets `payment.PaymentService.ProcessPayment`. Variables are tracked per
+/// function, ignoring shadowing by inner blocks.
+fn resolve_selector_targets(
+    root: &tree_sitter::Node,
+    source: &[u8],
+    file: &Path,
+    result: &mut ParseResult,
+    package: Option<&str>,
+) {
+    let mut positions: HashMap<(u32, u32), Vec<(usize, &str)>> = HashMap::new();
+    for (i, reference) in result.references.iter().enumerate() {
+        if reference.name.contains('.') {
+            positions
+                .entry((reference.location.line, reference.location.column))
+                .or_default()
+                .push((i, reference.name.as_str()));
+        }
+    }
+    if positions.is_empty() {
+        return;
+    }
+
+    let mut imports = HashMap::new();
+    let mut globals = HashMap::new();
+    let mut cursor = root.walk();
+    for child in root.named_children(&mut cursor) {
+        match child.kind() {
+            "import_declaration" => collect_import_aliases(&child, source, &mut imports),
+            "var_declaration" => collect_variable_types(&child, source, &mut globals),
+            _ => {}
+        }
+    }
+
+    let mut targets = Vec::new();
+    SelectorContext {
+        source,
+        file,
+        package,
+        imports: &imports,
+        positions: &positions,
+    }
+    .walk(root, &globals, &mut targets);
+    drop(positions);
+
+    for (i, target) in targets {
+        result.references[i].target = Some(target);
+    }
+}
+
+struct SelectorContext<'a> {
+    source: &'a [u8],
+    file: &'a Path,
+    package: Option<&'a str>,
+    /// Import name (alias or last path segment) -> package name
+    imports: &'a HashMap<String, String>,
+    /// Start of each dotted reference -> its index in `result.references` and name
+    positions: &'a HashMap<(u32, u32), Vec<(usize, &'a str)>>,
+}
+
+impl SelectorContext<'_> {
+    fn walk(
+        &self,
+        node: &tree_sitter::Node,
+        variables: &HashMap<String, String>,
+        targets: &mut Vec<(usize, String)>,
+    ) {
+        let scoped;
+        let variables = match node.kind() {
+            "function_declaration" | "method_declaration" | "func_literal" => {
+                let mut inner = variables.clone();
+                collect_variable_types(node, self.source, &mut inner);
+                scoped = inner;
+                &scoped
+            }
+            "selector_expression" => {
+                if let Some(target) = self.selector_target(node, variables) {
+                    let location = node_to_location(self.file, node);
+                    let text = node.utf8_text(self.source).unwrap_or_default();
+                    for &(i, name) in self
+                        .positions
+                        .get(&(location.line, location.column))
+                        .into_iter()
+                        .flatten()
+                    {
+                        if name == text {
+                            targets.push((i, target.clone()));
+                        }
+                    }
+                }
+                variables
+            }
+            _ => variables,
+        };
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            self.walk(&child, variables, targets);
+        }
+    }
+
+    fn selector_target(
+        &self,
+        node: &tree_sitter::Node,
+        variables: &HashMap<String, String>,
+    ) -> Option<String> {
+        let operand = node.child_by_field_name("operand")?;
+        if operand.kind() != "identifier" {
+            return None;
+        }
+        let operand = operand.utf8_text(self.source).ok()?;
+        let field = node
+            .child_by_field_name("field")?
+            .utf8_text(self.source)
+            .ok()?;
+
+        if let Some(type_name) = variables.get(operand) {
+            let owner = match type_name.split_once('.') {
+                Some((qualifier, name)) => {
+                    let package = self
+                        .imports
+                        .get(qualifier)
+                        .map_or(qualifier, String::as_str);
+                    format!("{}.{}", package, name)
+                }
+                None => qualified_name(type_name, self.package),
+            };
+            return Some(format!("{}.{}", owner, field));
+        }
+
+        // A plain `fmt.Println` already names its package
+        let package = self.imports.get(operand)?;
+        (package != operand).then(|| format!("{}.{}", package, field))
+    }
+}
+
+/// Record closures and calls through function-valued variables.
+///
+/// Each function literal inside a function becomes a symbol named like the
+/// Go runtime names it (`main.run.func1`, `main.run.func2`, and
+/// `main.run.func1.func1` for one nested in the first) whose span is the
+/// whole literal, plus an [`ReferenceKind::IndirectCall`] reference at its
+/// start so the enclosing function calls it.
+///
+/// A local variable assigned a function value (`cb := ps.RefundPayment`,
+/// `f := helper`, `g := func() {...}`) is remembered, and a later call
+/// `cb(...)` is marked as an indirect call targeting the assigned function:
+/// its qualified name when known (as in [`resolve_selector_targets`]), else
+/// the expression as written. Assignments are followed in source order per
+/// function, ignoring control flow.
+fn extract_function_values(
+    root: &tree_sitter::Node,
+    source: &[u8],
+    file: &Path,
+    result: &mut ParseResult,
+    package: Option<&str>,
+) {
+    let mut closures = HashMap::new();
+    let mut counts = HashMap::new();
+    name_closures(
+        root,
+        source,
+        file,
+        result,
+        package,
+        None,
+        &mut counts,
+        &mut closures,
+    );
+
+    let mut imports = HashMap::new();
+    let mut globals = HashMap::new();
+    let mut cursor = root.walk();
+    for child in root.named_children(&mut cursor) {
+        match child.kind() {
+            "import_declaration" => collect_import_aliases(&child, source, &mut imports),
+            "var_declaration" => collect_variable_types(&child, source, &mut globals),
+            _ => {}
+        }
+    }
+
+    let no_selectors = HashMap::new();
+    let context = FunctionValueContext {
+        selectors: SelectorContext {
+            source,
+            file,
+            package,
+            imports: &imports,
+            positions: &no_selectors,
+        },
+        closures: &closures,
+    };
+    let mut calls = HashMap::new();
+    context.walk(root, &globals, &HashMap::new(), &mut calls);
+    if calls.is_empty() {
+        return;
+    }
+
+    for reference in &mut result.references {
+        let start = (reference.location.line, reference.location.column);
+        if reference.kind != ReferenceKind::Call {
+            continue;
+        }
+        if let Some(target) = calls.get(&start) {
+            reference.kind = ReferenceKind::IndirectCall;
+            reference.target = Some(target.clone());
+        }
+    }
+}
+
+/// Add a symbol and a defining reference for every function literal inside a
+/// function, recording each literal's qualified name by node id.
+///
+/// `counts` numbers the literals of each enclosing function in source order.
+#[allow(clippy::too_many_arguments)]
+fn name_closures(
+    node: &tree_sitter::Node,
+    source: &[u8],
+    file: &Path,
+    result: &mut ParseResult,
+    package: Option<&str>,
+    enclosing: Option<&str>,
+    counts: &mut HashMap<String, usize>,
+    closures: &mut HashMap<usize, String>,
+) {
+    let declared;
+    let enclosing = match node.kind() {
+        "function_declaration" | "method_declaration" => {
+            let Some(name) = node
+                .child_by_field_name("name")
+                .and_then(|n| n.utf8_text(source).ok())
+            else {
+                return;
+            };
+            let receiver = extract_receiver_type(node, source);
+            let owner = receiver
+                .as_deref()
+                .map_or(name.to_string(), |recv| format!("{}.{}", recv, name));
+            declared = qualified_name(&owner, package);
+            Some(declared.as_str())
+        }
+        "func_literal" => match enclosing {
+            Some(outer) => {
+                let count = counts.entry(outer.to_string()).or_default();
+                *count += 1;
+                let name = format!("func{}", count);
+                declared = format!("{}.{}", outer, name);
+
+                let location = node_to_location(file, node);
+                let signature = node
+                    .child_by_field_name("body")
+                    .and_then(|body| source.get(node.start_byte()..body.start_byte()))
+                    .and_then(|text| std::str::from_utf8(text).ok())
+                    .map(|text| text.trim_end().to_string());
+                result.symbols.push(Symbol {
+                    name: name.clone(),
+                    qualified: declared.clone(),
+                    kind: SymbolKind::Function,
+                    location: location.clone(),
+                    visibility: Visibility::Private,
+                    language: "go".to_string(),
+                    parent: Some(outer.to_string()),
+                    mixins: None,
+                    attributes: None,
+                    implements: None,
+                    doc: None,
+                    signature,
+                    extent: None,
+                });
+                result.references.push(Reference {
+                    name,
+                    location,
+                    kind: ReferenceKind::IndirectCall,
+                    target: Some(declared.clone()),
+                    arguments: None,
+                });
+                closures.insert(node.id(), declared.clone());
+                Some(declared.as_str())
+            }
+            // A package-level literal (`var handler = func() {...}`) has no
+            // enclosing function to call it
+            None => None,
+        },
+        _ => enclosing,
+    };
+
+    let mut cursor = node.walk();
+    for child in node.named_children(&mut cursor) {
+        name_closures(
+            &child, source, file, result, package, enclosing, counts, closures,
+        );
+    }
+}
+
+struct FunctionValueContext<'a> {
+    selectors: SelectorContext<'a>,
+    /// Function literal node id -> its qualified name
+    closures: &'a HashMap<usize, String>,
+}
+
+impl FunctionValueContext<'_> {
+    /// Walk `node` tracking which local variables hold which function, and
+    /// record the start of each call through one, with its target.
+    fn walk(
+        &self,
+        node: &tree_sitter::Node,
+        variables: &HashMap<String, String>,
+        functions: &HashMap<String, String>,
+        calls: &mut HashMap<(u32, u32), String>,
+    ) {
+        let source = self.selectors.source;
+        match node.kind() {
+            "function_declaration" | "method_declaration" | "func_literal" => {
+                // Closures see the enclosing function's variables
+                let mut variables = variables.clone();
+                collect_variable_types(node, source, &mut variables);
+                let mut functions = if node.kind() == "func_literal" {
+                    functions.clone()
+                } else {
+                    HashMap::new()
+                };
+                self.walk_body(node, &variables, &mut functions, calls);
+            }
+            _ => {
+                let mut cursor = node.walk();
+                for child in node.named_children(&mut cursor) {
+                    self.walk(&child, variables, functions, calls);
+                }
+            }
+        }
+    }
+
+    /// Walk the statements of one function in source order, updating
+    /// `functions` as variables are assigned.
+    fn walk_body(
+        &self,
+        node: &tree_sitter::Node,
+        variables: &HashMap<String, String>,
+        functions: &mut HashMap<String, String>,
+        calls: &mut HashMap<(u32, u32), String>,
+    ) {
+        let source = self.selectors.source;
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            match child.kind() {
+                "function_declaration" | "method_declaration" | "func_literal" => {
+                    self.walk(&child, variables, functions, calls);
+                    continue;
+                }
+                "call_expression" => {
+                    if let Some(callee) = child
+                        .child_by_field_name("function")
+                        .filter(|f| f.kind() == "identifier")
+                    {
+                        let name = callee.utf8_text(source).unwrap_or_default();
+                        if let Some(target) = functions.get(name) {
+                            let location = node_to_location(self.selectors.file, &callee);
+                            calls.insert((location.line, location.column), target.clone());
+                        }
+                    }
+                }
+                _ => {}
+            }
+
+            // Values are evaluated before the assignment takes effect
+            self.walk_body(&child, variables, functions, calls);
+
+            let (names, values) = match child.kind() {
+                "short_var_declaration" | "assignment_statement" => {
+                    (field_list(&child, "left"), field_list(&child, "right"))
+                }
+                "var_spec" => {
+                    let mut names_cursor = child.walk();
+                    let names = child
+                        .children_by_field_name("name", &mut names_cursor)
+                        .collect();
+                    (names, field_list(&child, "value"))
+                }
+                _ => continue,
+            };
+            for (name, value) in names.iter().zip(values.iter()) {
+                let Ok(name) = name.utf8_text(source) else {
+                    continue;
+                };
+                match self.function_value(value, variables, functions) {
+                    Some(target) => functions.insert(name.to_string(), target),
+                    None => functions.remove(name),
+                };
+            }
+        }
+    }
+
+    /// The function `value` evaluates to, if it is a function name, a method
+    /// value, a function literal, or a variable already holding one.
+    fn function_value(
+        &self,
+        value: &tree_sitter::Node,
+        variables: &HashMap<String, String>,
+        functions: &HashMap<String, String>,
+    ) -> Option<String> {
+        let text = value.utf8_text(self.selectors.source).ok()?;
+        match value.kind() {
+            "func_literal" => self.closures.get(&value.id()).cloned(),
+            "identifier" if text == "nil" => None,
+            "identifier" => match functions.get(text) {
+                Some(target) => Some(target.clone()),
+                // A typed local such as a parameter is not a function name
+                None if variables.contains_key(text) => None,
+                None => Some(qualified_name(text, self.selectors.package)),
+            },
+            "selector_expression" => Some(
+                self.selectors
+                    .selector_target(value, variables)
+                    .unwrap_or_else(|| text.to_string()),
+            ),
+            "parenthesized_expression" => {
+                let inner = value.named_child(0)?;
+                self.function_value(&inner, variables, functions)
+            }
+            _ => None,
+        }
+    }
+}
+
+/// The named children of the expression list in `field`.
+fn field_list<'a>(node: &tree_sitter::Node<'a>, field: &str) -> Vec<tree_sitter::Node<'a>> {
+    node.child_by_field_name(field)
+        .map(|list| list.named_children(&mut list.walk()).collect())
+        .unwrap_or_default()
+}
+
+/// Map each import's name in this file to the package it imports.
+///
+/// Blank (`_`) and dot (`.`) imports introduce no name and are skipped.
+fn collect_import_aliases(
+    node: &tree_sitter::Node,
+    source: &[u8],
+    imports: &mut HashMap<String, String>,
+) {
+    if node.kind() == "import_spec" {
+        let Some(path) = node
+            .child_by_field_name("path")
+            .and_then(|p| p.utf8_text(source).ok())
+        else {
+            return;
+        };
+        let package = package_name_from_path(path.trim_matches('"'));
+        let name = match node.child_by_field_name("name") {
+            Some(alias) if alias.kind() == "package_identifier" => {
+                alias.utf8_text(source).unwrap_or(package)
+            }
+            Some(_) => return,
+            None => package,
+        };
+        imports.insert(name.to_string(), package.to_string());
+        return;
+    }
+
+    let mut cursor = node.walk();
+    for child in node.named_children(&mut cursor) {
+        collect_import_aliases(&child, source, imports);
+    }
+}
+
+/// The package name Go assumes for an import path: its last segment, without
+/// a major-version suffix (`example.com/foo/v2` and `gopkg.in/yaml.v3` give
+/// `foo` and `yaml`).
+fn package_name_from_path(path: &str) -> &str {
+    let mut segments = path.rsplit('/');
+    let mut last = segments.next().unwrap_or(path);
+    let is_version = |s: &str| {
+        s.strip_prefix('v')
+            .is_some_and(|n| !n.is_empty() && n.bytes().all(|b| b.is_ascii_digit()))
+    };
+    if is_version(last) {
+        last = segments.next().unwrap_or(last);
+    }
+    match last.rsplit_once('.') {
+        Some((name, version)) if is_version(version) => name,
+        _ => last,
+    }
+}
+
+/// Record the declared type of every variable declared in `node`: parameters,
+/// receivers, `var` specs, and `:=` with a composite literal. Nested function
+/// literals are left for their own scope.
+fn collect_variable_types(
+    node: &tree_sitter::Node,
+    source: &[u8],
+    variables: &mut HashMap<String, String>,
+) {
+    let mut cursor = node.walk();
+    match node.kind() {
+        "parameter_declaration" | "var_spec" => {
+            let declared = node
+                .child_by_field_name("type")
+                .and_then(|t| t.utf8_text(source).ok())
+                .and_then(variable_type);
+            let values: Vec<tree_sitter::Node> = node
+                .child_by_field_name("value")
+                .map(|list| list.named_children(&mut list.walk()).collect())
+                .unwrap_or_default();
+            let names: Vec<tree_sitter::Node> =
+                node.children_by_field_name("name", &mut cursor).collect();
+            for (i, name) in names.iter().enumerate() {
+                let ty = declared
+                    .clone()
+                    .or_else(|| values.get(i).and_then(|v| expression_type(v, source)));
+                if let (Ok(name), Some(ty)) = (name.utf8_text(source), ty) {
+                    variables.insert(name.to_string(), ty);
+                }
+            }
+            return;
+        }
+        "short_var_declaration" => {
+            let side = |field: &str| -> Vec<tree_sitter::Node> {
+                node.child_by_field_name(field)
+                    .map(|list| list.named_children(&mut list.walk()).collect())
+                    .unwrap_or_default()
+            };
+            for (name, value) in side("left").iter().zip(side("right").iter()) {
+                if let (Ok(name), Some(ty)) =
+                    (name.utf8_text(source), expression_type(value, source))
+                {
+                    variables.insert(name.to_string(), ty);
+                }
+            }
+            return;
+        }
+        _ => {}
+    }
+
+    for child in node.named_children(&mut cursor) {
+        // The function's own literal body is collected when the walk enters it
+        if child.kind() != "func_literal" {
+            collect_variable_types(&child, source, variables);
+        }
+    }
+}
+
+/// The type of `&T{...}` or `T{...}`.
+fn expression_type(node: &tree_sitter::Node, source: &[u8]) -> Option<String> {
+    match node.kind() {
+        "composite_literal" => node
+            .child_by_field_name("type")
+            .and_then(|t| t.utf8_text(source).ok())
+            .and_then(variable_type),
+        "unary_expression" => expression_type(&node.child_by_field_name("operand")?, source),
+        _ => None,
+    }
+}
+
+/// `*pay.PaymentService` -> `pay.PaymentService`, `Stack[int]` -> `Stack`.
+///
+/// Returns `None` for types without methods of their own (slices, maps,
+/// channels, function types).
+fn variable_type(type_text: &str) -> Option<String> {
+    let name = base_type_name(type_text.trim_start_matches('*'));
+    let is_named = !name.is_empty()
+        && name
+            .split('.')
+            .all(|part| !part.is_empty() && part.chars().all(|c| c.is_alphanumeric() || c == '_'))
+        && name.matches('.').count() <= 1;
+    is_named.then(|| name.to_string())
+}
+
 /// Extract a type specification (struct, interface, or type alias)
 fn extract_type_spec(
     node: &tree_sitter::Node,
@@ -422,6 +1073,8 @@ fn extract_type_spec(
     let qualified = qualified_name(name, package);
     let visibility = extract_visibility(name);
     let doc = extract_doc_comments(node, source);
+    // Only generic types get a signature: `type Stack[T any]`
+    let signature = type_parameters(node, source).map(|params| format!("type {}{}", name, params));
 
     match type_node.kind() {
         "struct_type" => {
@@ -437,7 +1090,8 @@ fn extract_type_spec(
                 attributes: None,
                 implements: None,
                 doc,
-                signature: None,
+                signature,
+                extent: None,
             });
 
             // Extract struct fields
@@ -510,7 +1164,8 @@ fn extract_type_spec(
                 attributes: None,
                 implements: None,
                 doc,
-                signature: None,
+                signature,
+                extent: None,
             });
 
             // Extract interface methods
@@ -537,7 +1192,8 @@ fn extract_type_spec(
                 attributes: None,
                 implements: None,
                 doc,
-                signature: None,
+                signature,
+                extent: None,
             });
         }
     }
@@ -575,6 +1231,7 @@ fn extract_struct_fields(
                             implements: None,
                             doc: None,
                             signature: None,
+                            extent: None,
                         });
                     }
                 } else {
@@ -597,6 +1254,7 @@ fn extract_struct_fields(
                             implements: None,
                             doc: None,
                             signature: None,
+                            extent: None,
                         });
                     }
                 }
@@ -703,6 +1361,7 @@ fn extract_interface_method(
                 implements: None,
                 doc: None,
                 signature: Some(sig),
+                extent: None,
             });
         }
     }
@@ -737,11 +1396,56 @@ fn extract_const_or_var_spec(
                 implements: None,
                 doc,
                 signature: None,
+                extent: None,
             });
         }
     }
 }
 
+/// Classify how a reference node uses the symbol it names.
+fn reference_kind(node: &tree_sitter::Node) -> ReferenceKind {
+    let Some(parent) = node.parent() else {
+        return ReferenceKind::Unknown;
+    };
+    let is_field = |field: &str| {
+        parent
+            .child_by_field_name(field)
+            .is_some_and(|child| child.id() == node.id())
+    };
+
+    match (node.kind(), parent.kind()) {
+        (_, "call_expression") if is_field("function") => ReferenceKind::Call,
+        // Explicit instantiation parsed as an index: `Map[int](xs)`
+        (_, "index_expression") if is_field("operand") => match parent.parent() {
+            Some(grandparent)
+                if grandparent.kind() == "call_expression"
+                    && grandparent
+                        .child_by_field_name("function")
+                        .is_some_and(|f| f.id() == parent.id()) =>
+            {
+                ReferenceKind::Call
+            }
+            _ => ReferenceKind::Unknown,
+        },
+        ("type_identifier", "composite_literal") if is_field("type") => ReferenceKind::Construction,
+        // Generic composite literal: `Stack[int]{}`
+        ("type_identifier", "generic_type")
+            if is_field("type")
+                && parent.parent().is_some_and(|literal| {
+                    literal.kind() == "composite_literal"
+                        && literal
+                            .child_by_field_name("type")
+                            .is_some_and(|t| t.id() == parent.id())
+                }) =>
+        {
+            ReferenceKind::Construction
+        }
+        ("type_identifier", _) => ReferenceKind::TypeUse,
+        ("selector_expression", _) => ReferenceKind::FieldAccess,
+        _ => ReferenceKind::Unknown,
+    }
+}
+
 /// Check if a node is a descendant of a node with the given kind
 fn is_descendant_of(node: &tree_sitter::Node, kind: &str) -> bool {
     let mut current = node.parent();
@@ -809,6 +1513,11 @@ fn is_reference_context(node: &tree_sitter::Node) -> bool {
         }
     }
 
+    // Type parameter names: [K comparable, V any]
+    if parent_kind == "type_parameter_declaration" && node.kind() == "identifier" {
+        return false;
+    }
+
     // Parameter names
     if parent_kind == "parameter_declaration" {
         if let Some(name_node) = parent.child_by_field_name("name") {
@@ -904,7 +1613,7 @@ fn is_reference_context(node: &tree_sitter::Node) -> bool {
 #[cfg(test)]
 mod tests {
     use super::*;
-    use crate::parse::LanguageParser;
+    use crate::parse::{extract_symbols, LanguageParser};
 
     #[test]
     fn extracts_go_function() {
@@ -1007,6 +1716,13 @@ func (u User) String() string {
         assert_eq!(get_name.qualified, "models.User.GetName");
         assert_eq!(get_name.kind, SymbolKind::Function);
         assert_eq!(get_name.parent, Some("User".to_string()));
+        assert_eq!(
+            get_name.signature.as_deref(),
+            Some("func (u *User) GetName() string")
+        );
+        let receiver = get_name.structured_signature().unwrap().receiver.unwrap();
+        assert_eq!(receiver.name.as_deref(), Some("u"));
+        assert_eq!(receiver.ty, "*User");
 
         let string_method = result.symbols.iter().find(|s| s.name == "String").unwrap();
         assert_eq!(string_method.qualified, "models.User.String");
@@ -1309,6 +2025,15 @@ func Printf(format string, a ...interface{}) (n int, err error) {
             .expect("Should find Printf");
         assert_eq!(printf.kind, SymbolKind::Function);
         assert!(printf.signature.as_ref().unwrap().contains("..."));
+
+        let signature = printf.structured_signature().unwrap();
+        assert!(signature.is_variadic());
+        assert_eq!(signature.params[1].ty, "interface{}");
+        assert_eq!(signature.results.len(), 2);
+        assert_eq!(
+            signature.to_string(),
+            "(format string, a ...interface{}) (n int, err error)"
+        );
     }
 
     #[test]
@@ -1570,4 +2295,329 @@ func main() {
             ref_names
         );
     }
+
+    #[test]
+    fn classifies_go_reference_kinds() {
+        let source = r#"package main
+
+func Use(m *Model) int {
+	other := &Model{Field: 1}
+	helper(other)
+	return m.Field
+}
+"#;
+        let result = extract_symbols(Path::new("main.go"), source, 100);
+        let kinds: Vec<(&str, u32, ReferenceKind)> = result
+            .references
+            .iter()
+            .filter(|r| r.name != "m" && r.name != "other")
+            .map(|r| (r.name.as_str(), r.location.line, r.kind))
+            .collect();
+
+        assert!(
+            kinds.contains(&("Model", 3, ReferenceKind::TypeUse)),
+            "{:?}",
+            kinds
+        );
+        assert!(
+            kinds.contains(&("Model", 4, ReferenceKind::Construction)),
+            "{:?}",
+            kinds
+        );
+        assert!(
+            kinds.contains(&("helper", 5, ReferenceKind::Call)),
+            "{:?}",
+            kinds
+        );
+        assert!(
+            kinds.contains(&("m.Field", 6, ReferenceKind::FieldAccess)),
+            "{:?}",
+            kinds
+        );
+    }
+
+    #[test]
+    fn extracts_leading_doc_comment_block() {
+        let source = r#"package main
+
+// NewUser creates a user.
+//
+// The name must not be empty.
+func NewUser(name string) *User {
+	return &User{Name: name}
+}
+
+var count = 1 // trailing note
+func Trailing() {}
+
+// Detached comment
+
+func Detached() {}
+
+/* Span is a block
+   comment. */
+type Span struct{}
+
+//go:generate stringer -type=Kind
+// Kind is a kind.
+type Kind int
+
+const (
+	// First is the first value.
+	First = iota
+	Second // trailing
+)
+"#;
+        let result = extract_symbols(Path::new("main.go"), source, 100);
+        let doc = |name: &str| {
+            result
+                .symbols
+                .iter()
+                .find(|s| s.name == name)
+                .unwrap_or_else(|| panic!("missing {}", name))
+                .doc
+                .clone()
+        };
+
+        assert_eq!(
+            doc("NewUser").as_deref(),
+            Some("NewUser creates a user.\n\nThe name must not be empty.")
+        );
+        assert_eq!(doc("Trailing"), None);
+        assert_eq!(doc("Detached"), None);
+        assert_eq!(doc("Span").as_deref(), Some("Span is a block\n   comment."));
+        assert_eq!(doc("Kind").as_deref(), Some("Kind is a kind."));
+        assert_eq!(doc("First").as_deref(), Some("First is the first value."));
+        assert_eq!(doc("Second"), None);
+    }
+
+    #[test]
+    fn extracts_annotations_with_enclosing_symbols() {
+        let source = r#"package main
+
+// TODO: split this file
+type User struct {
+	// FIXME(bob): validate
+	Name string
+}
+
+func (u *User) Save() error {
+	/* HACK: retry once
+	 * XXX(alice) remove after migration */
+	go func() {
+		// TODO(carol): report errors
+	}()
+	return nil // not a todo
+}
+"#;
+        let result = extract_symbols(Path::new("main.go"), source, 100);
+        let found: Vec<(&str, Option<&str>, &str, u32, u32, Option<&str>)> = result
+            .annotations
+            .iter()
+            .map(|a| {
+                (
+                    a.kind.marker(),
+                    a.author.as_deref(),
+                    a.text.as_str(),
+                    a.location.line,
+                    a.location.column,
+                    a.symbol.as_deref(),
+                )
+            })
+            .collect();
+
+        assert_eq!(
+            found,
+            vec![
+                ("TODO", None, "split this file", 3, 4, None),
+                ("FIXME", Some("bob"), "validate", 5, 5, Some("main.User")),
+                ("HACK", None, "retry once", 10, 5, Some("main.User.Save")),
+                (
+                    "XXX",
+                    Some("alice"),
+                    "remove after migration",
+                    11,
+                    5,
+                    Some("main.User.Save")
+                ),
+                (
+                    "TODO",
+                    Some("carol"),
+                    "report errors",
+                    13,
+                    6,
+                    Some("main.User.Save.func1")
+                ),
+            ]
+        );
+    }
+
+    #[test]
+    fn extracts_generic_declarations_and_instantiated_calls() {
+        let source = include_str!("../../../../../tests/fixtures/go-generics/generics.go");
+        let result = extract_symbols(Path::new("generics.go"), source, 100);
+        let symbol = |name: &str| {
+            result
+                .symbols
+                .iter()
+                .find(|s| s.name == name)
+                .unwrap_or_else(|| panic!("missing {}", name))
+        };
+
+        assert_eq!(
+            symbol("Map").signature.as_deref(),
+            Some("func Map[T, U any](xs []T, f func(T) U) []U")
+        );
+        assert_eq!(
+            symbol("NewStack").signature.as_deref(),
+            Some("func NewStack[T any]() *Stack[T]")
+        );
+        assert_eq!(
+            symbol("Stack").signature.as_deref(),
+            Some("type Stack[T any]")
+        );
+        assert_eq!(symbol("Stack").kind, SymbolKind::Class);
+        assert_eq!(symbol("Number").signature, None);
+
+        // Pointer and value receivers with type arguments land on the base type
+        for method in ["Push", "Pop", "Len"] {
+            let method = symbol(method);
+            assert_eq!(method.parent.as_deref(), Some("Stack"));
+            assert_eq!(method.qualified, format!("generics.Stack.{}", method.name));
+        }
+
+        let calls = |name: &str| {
+            result
+                .references
+                .iter()
+                .filter(|r| r.name == name && r.kind == ReferenceKind::Call)
+                .count()
+        };
+        // Explicit (`Map[int, int](...)`) and inferred (`Map(...)`) instantiations
+        assert_eq!(calls("Map"), 3);
+        assert_eq!(calls("Sum"), 2);
+        assert_eq!(calls("NewStack"), 1);
+        assert_eq!(calls("Keys"), 1);
+
+        // Type arguments never leak into names, and type parameter names
+        // (`K` in `func Keys[K comparable, ...]`) are not references
+        assert!(!result.references.iter().any(|r| r.name.contains('[')));
+        assert!(!result
+            .references
+            .iter()
+            .any(|r| r.name == "K" && (r.location.line, r.location.column) == (62, 11)));
+    }
+
+    #[test]
+    fn resolves_selector_targets_through_imports_and_variable_types() {
+        let source = include_str!("../../../../../tests/fixtures/go-multipkg/main.go");
+        let result = extract_symbols(Path::new("main.go"), source, 100);
+        let target = |name: &str| {
+            result
+                .references
+                .iter()
+                .find(|r| r.name == name)
+                .unwrap_or_else(|| panic!("missing reference {}", name))
+                .target
+                .as_deref()
+        };
+
+        // Parameter, local var, and package-level var types
+        assert_eq!(
+            target("svc.ProcessPayment"),
+            Some("payment.PaymentService.ProcessPayment")
+        );
+        assert_eq!(
+            target("p.ProcessPayment"),
+            Some("billing.Processor.ProcessPayment")
+        );
+        assert_eq!(
+            target("defaultService.ProcessPayment"),
+            Some("payment.PaymentService.ProcessPayment")
+        );
+        // The alias `pay` names package `payment`
+        assert_eq!(target("pay.ProcessPayment"), Some("payment.ProcessPayment"));
+        // An unaliased import already names its package
+        assert_eq!(target("fmt.Println"), None);
+    }
+
+    #[test]
+    fn extracts_closures_and_calls_through_function_values() {
+        let source = r#"package main
+
+func run(ps *PaymentService) {
+	callback := ps.RefundPayment
+	callback(5)
+	f := helper
+	go func() {
+		f()
+		defer func() {}()
+	}()
+	f = nil
+	f()
+}
+"#;
+        let result = extract_symbols(Path::new("main.go"), source, 100);
+
+        let closures: Vec<(&str, &str, u32)> = result
+            .symbols
+            .iter()
+            .filter(|s| s.name.starts_with("func"))
+            .map(|s| {
+                (
+                    s.qualified.as_str(),
+                    s.parent.as_deref().unwrap(),
+                    s.location.line,
+                )
+            })
+            .collect();
+        assert_eq!(
+            closures,
+            vec![
+                ("main.run.func1", "main.run", 7),
+                ("main.run.func1.func1", "main.run.func1", 9),
+            ]
+        );
+        let closure = &result.symbols.iter().find(|s| s.name == "func1").unwrap();
+        assert_eq!(closure.location.end_line, 10);
+        assert_eq!(closure.signature.as_deref(), Some("func()"));
+
+        let indirect: Vec<(&str, u32, &str)> = result
+            .references
+            .iter()
+            .filter(|r| r.kind == ReferenceKind::IndirectCall)
+            .map(|r| {
+                (
+                    r.name.as_str(),
+                    r.location.line,
+                    r.target.as_deref().unwrap(),
+                )
+            })
+            .collect();
+        assert_eq!(
+            indirect,
+            vec![
+                ("callback", 5, "main.PaymentService.RefundPayment"),
+                ("f", 8, "main.helper"),
+                ("func1", 7, "main.run.func1"),
+                ("func1", 9, "main.run.func1.func1"),
+            ]
+        );
+        // After `f = nil` the call is an ordinary unresolved call
+        assert!(result
+            .references
+            .iter()
+            .any(|r| r.name == "f" && r.location.line == 12 && r.kind == ReferenceKind::Call));
+    }
+
+    #[test]
+    fn package_name_from_import_path() {
+        assert_eq!(package_name_from_path("fmt"), "fmt");
+        assert_eq!(
+            package_name_from_path("example.com/shop/payment"),
+            "payment"
+        );
+        assert_eq!(package_name_from_path("github.com/org/lib/v2"), "lib");
+        assert_eq!(package_name_from_path("gopkg.in/yaml.v3"), "yaml");
+    }
 }
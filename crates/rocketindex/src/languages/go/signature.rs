@@ -0,0 +1,218 @@
+//! Parsing of Go signatures as stored by the Go parser.
+//!
+//! Functions are stored as `func Name[T any](params) results` and methods as
+//! `func (recv *Type) Name(params) results`, with the lists copied from source.
+
+use crate::signature::{Param, Signature};
+
+/// Split a Go signature into receiver, type parameters, parameters, and results.
+///
+/// Returns `None` if `signature` is not a `func` signature.
+pub fn parse(signature: &str) -> Option<Signature> {
+    let mut rest = signature.strip_prefix("func")?.trim_start();
+
+    let mut receiver = None;
+    if rest.starts_with('(') {
+        let (list, after) = split_parenthesized(rest)?;
+        receiver = parameters(list).into_iter().next();
+        rest = after.trim_start();
+    }
+
+    // Generic functions list type parameters after the name: Map[T any, F func(T)]
+    let open = rest.find(['(', '['])?;
+    let mut rest = &rest[open..];
+    let mut type_params = Vec::new();
+    if rest.starts_with('[') {
+        let (list, after) = split_delimited(rest, '[', ']')?;
+        type_params = parameters(list);
+        rest = after;
+    }
+
+    let (params, after) = split_parenthesized(rest)?;
+
+    let after = after.trim();
+    let results = if after.starts_with('(') {
+        parameters(split_parenthesized(after)?.0)
+    } else {
+        parameters(after)
+    };
+
+    Some(Signature {
+        receiver,
+        type_params,
+        params: parameters(params),
+        results,
+    })
+}
+
+/// Split `(inner) rest` at the matching close paren, returning `(inner, rest)`.
+fn split_parenthesized(text: &str) -> Option<(&str, &str)> {
+    split_delimited(text, '(', ')')
+}
+
+/// Split `<open>inner<close> rest` at the matching `close`, returning `(inner, rest)`.
+fn split_delimited(text: &str, open: char, close: char) -> Option<(&str, &str)> {
+    let mut depth = 0usize;
+    for (i, c) in text.char_indices() {
+        match c {
+            c if c == open => depth += 1,
+            c if c == close => {
+                depth = depth.checked_sub(1)?;
+                if depth == 0 {
+                    return Some((&text[1..i], &text[i + 1..]));
+                }
+            }
+            _ => {}
+        }
+    }
+    None
+}
+
+/// Split a parameter list on top-level commas.
+fn split_top_level(list: &str) -> Vec<&str> {
+    let mut pieces = Vec::new();
+    let mut depth = 0i32;
+    let mut start = 0;
+    for (i, c) in list.char_indices() {
+        match c {
+            '(' | '[' | '{' => depth += 1,
+            ')' | ']' | '}' => depth -= 1,
+            ',' if depth == 0 => {
+                pieces.push(list[start..i].trim());
+                start = i + 1;
+            }
+            _ => {}
+        }
+    }
+    pieces.push(list[start..].trim());
+    pieces.retain(|p| !p.is_empty());
+    pieces
+}
+
+/// Parse a Go parameter (or result) list.
+///
+/// Go lists are either all named (`a, b int, s string`) or all unnamed
+/// (`int, string`); in a named list a bare name shares the type that follows it.
+fn parameters(list: &str) -> Vec<Param> {
+    let pieces = split_top_level(list);
+    let split: Vec<Option<(&str, &str)>> = pieces.iter().map(|p| named_type(p)).collect();
+
+    if split.iter().all(Option::is_none) {
+        return pieces.iter().map(|p| param(None, p)).collect();
+    }
+
+    let mut params = Vec::with_capacity(pieces.len());
+    let mut carried = "";
+    for i in (0..pieces.len()).rev() {
+        let name = match split[i] {
+            Some((name, ty)) => {
+                carried = ty;
+                name
+            }
+            None => pieces[i],
+        };
+        params.push(param(Some(name), carried));
+    }
+    params.reverse();
+    params
+}
+
+fn param(name: Option<&str>, ty: &str) -> Param {
+    let (ty, variadic) = match ty.strip_prefix("...") {
+        Some(element) => (element, true),
+        None => (ty, false),
+    };
+    Param {
+        name: name.map(str::to_string),
+        ty: normalize_type(ty),
+        variadic,
+    }
+}
+
+/// Split a `name Type` parameter, or `None` if the piece is a bare type or name.
+fn named_type(piece: &str) -> Option<(&str, &str)> {
+    const TYPE_KEYWORDS: &[&str] = &["chan", "func", "interface", "struct", "map", "<-chan"];
+
+    let (first, rest) = piece.split_once(char::is_whitespace)?;
+    let is_identifier = first.chars().all(|c| c.is_alphanumeric() || c == '_');
+    if !is_identifier || TYPE_KEYWORDS.contains(&first) {
+        return None;
+    }
+    Some((first, rest.trim()))
+}
+
+fn normalize_type(ty: &str) -> String {
+    ty.split_whitespace().collect::<Vec<_>>().join(" ")
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn types(params: &[Param]) -> Vec<String> {
+        params.iter().map(Param::type_text).collect()
+    }
+
+    fn names(params: &[Param]) -> Vec<Option<&str>> {
+        params.iter().map(|p| p.name.as_deref()).collect()
+    }
+
+    #[test]
+    fn test_parse_method_with_receiver() {
+        let sig =
+            parse("func (ps *PaymentService) ProcessPayment(user *User, amount float64) bool")
+                .unwrap();
+        let receiver = sig.receiver.as_ref().unwrap();
+        assert_eq!(receiver.name.as_deref(), Some("ps"));
+        assert_eq!(receiver.ty, "*PaymentService");
+        assert_eq!(names(&sig.params), vec![Some("user"), Some("amount")]);
+        assert_eq!(types(&sig.params), vec!["*User", "float64"]);
+        assert_eq!(types(&sig.results), vec!["bool"]);
+        assert_eq!(sig.to_string(), "(user *User, amount float64) bool");
+
+        // Unnamed receivers are kept too
+        let sig = parse("func (Stack[T]) Len() int").unwrap();
+        assert_eq!(sig.receiver.unwrap().ty, "Stack[T]");
+    }
+
+    #[test]
+    fn test_parse_grouped_variadic_and_multiple_results() {
+        let sig = parse("func Copy(dst, src string, n int)").unwrap();
+        assert_eq!(
+            names(&sig.params),
+            vec![Some("dst"), Some("src"), Some("n")]
+        );
+        assert_eq!(types(&sig.params), vec!["string", "string", "int"]);
+        assert!(sig.results.is_empty());
+
+        let sig = parse("func Printf(format string, a ...any) (n int, err error)").unwrap();
+        assert!(sig.is_variadic());
+        assert_eq!(sig.params[1].ty, "any");
+        assert_eq!(types(&sig.params), vec!["string", "...any"]);
+        assert_eq!(names(&sig.results), vec![Some("n"), Some("err")]);
+        assert_eq!(
+            sig.to_string(),
+            "(format string, a ...any) (n int, err error)"
+        );
+
+        let sig = parse("func Join(...string) (string, error)").unwrap();
+        assert_eq!(names(&sig.params), vec![None]);
+        assert!(sig.is_variadic());
+        assert_eq!(types(&sig.results), vec!["string", "error"]);
+    }
+
+    #[test]
+    fn test_parse_function_types_and_generics() {
+        let sig = parse("func Send(c chan int, f func(a int) error) func() error").unwrap();
+        assert_eq!(types(&sig.params), vec!["chan int", "func(a int) error"]);
+        assert_eq!(types(&sig.results), vec!["func() error"]);
+
+        let sig = parse("func Map[T, U any](xs []T, f func(T) U) []U").unwrap();
+        assert_eq!(names(&sig.type_params), vec![Some("T"), Some("U")]);
+        assert_eq!(types(&sig.type_params), vec!["any", "any"]);
+        assert_eq!(types(&sig.params), vec!["[]T", "func(T) U"]);
+        assert_eq!(types(&sig.results), vec!["[]U"]);
+
+        assert!(parse("type Stack[T any]").is_none());
+    }
+}
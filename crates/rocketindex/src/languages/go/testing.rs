@@ -0,0 +1,59 @@
+//! Test function detection for Go.
+
+use std::path::Path;
+
+use crate::coverage::TestDetector;
+use crate::language_config::{LanguageConfig, LanguageConfigs};
+use crate::Symbol;
+
+/// `go test` conventions: `TestXxx` functions in `_test.go` files, as
+/// declared in the built-in Go [`LanguageConfig`].
+pub struct GoTestDetector;
+
+fn go_config() -> &'static LanguageConfig {
+    LanguageConfigs::builtin()
+        .get("go")
+        .expect("built-in Go config")
+}
+
+impl TestDetector for GoTestDetector {
+    fn is_test_file(&self, path: &Path) -> bool {
+        go_config().is_test_file(path)
+    }
+
+    fn is_test(&self, symbol: &Symbol) -> bool {
+        go_config().is_test(symbol)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::{Location, SymbolKind, Visibility};
+    use std::path::PathBuf;
+
+    fn function(name: &str, file: &str) -> Symbol {
+        Symbol::new(
+            name.to_string(),
+            format!("main.{}", name),
+            SymbolKind::Function,
+            Location::new(PathBuf::from(file), 1, 6),
+            Visibility::Public,
+            "go".to_string(),
+        )
+    }
+
+    #[test]
+    fn test_go_test_functions() {
+        let detector = GoTestDetector;
+        assert!(detector.is_test(&function("TestHelper", "main_test.go")));
+        assert!(detector.is_test(&function("Test_helper", "pkg/main_test.go")));
+        assert!(detector.is_test(&function("Test", "main_test.go")));
+
+        assert!(!detector.is_test(&function("Testify", "main_test.go")));
+        assert!(!detector.is_test(&function("setup", "main_test.go")));
+        assert!(!detector.is_test(&function("TestHelper", "main.go")));
+        let method = function("TestHelper", "main_test.go").with_parent(Some("main.Suite".into()));
+        assert!(!detector.is_test(&method));
+    }
+}
@@ -0,0 +1,12 @@
+//! Comment, test, and generated-code conventions for Haxe.
+
+use crate::language_config::LanguageConfig;
+
+/// `/** */` doc comments; `test*` methods in `*Test.hx` files are tests
+/// (utest, munit).
+#[must_use]
+pub fn config() -> LanguageConfig {
+    LanguageConfig::c_style("haxe", &["hx"])
+        .with_doc_comments(&["/**"])
+        .with_tests(&["*Test.hx"], Some("^test"), true)
+}
@@ -3,8 +3,11 @@
 use std::cell::RefCell;
 use std::path::Path;
 
-use crate::parse::{find_child_by_kind, node_to_location, LanguageParser, ParseResult};
-use crate::{Reference, Symbol, SymbolKind, Visibility};
+use crate::parse::{
+    collect_annotations, find_child_by_kind, node_to_location, record_extents, LanguageParser,
+    ParseResult,
+};
+use crate::{Reference, ReferenceKind, Symbol, SymbolKind, Visibility};
 
 // Thread-local parser reuse - avoids creating a new parser per file
 thread_local! {
@@ -46,6 +49,9 @@ impl LanguageParser for HaxeParser {
                 package.as_deref(),
                 max_depth,
             );
+            record_extents(&root, &mut result.symbols);
+            result.annotations =
+                collect_annotations(file, &root, source.as_bytes(), &result.symbols);
 
             result
         })
@@ -269,6 +275,7 @@ fn extract_recursive(
                         implements,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Recurse into class body
@@ -311,6 +318,7 @@ fn extract_recursive(
                         implements,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Recurse into interface body
@@ -350,6 +358,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Recurse into typedef body if it has one (struct typedef)
@@ -397,6 +406,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature,
+                        extent: None,
                     });
                 }
             }
@@ -423,6 +433,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
                 }
             }
@@ -479,6 +490,9 @@ fn extract_recursive(
                     result.references.push(Reference {
                         name: name.to_string(),
                         location: node_to_location(file, node),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -491,6 +505,9 @@ fn extract_recursive(
                     result.references.push(Reference {
                         name: name.to_string(),
                         location: node_to_location(file, node),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -504,6 +521,9 @@ fn extract_recursive(
                     result.references.push(Reference {
                         name: obj_text.to_string(),
                         location: node_to_location(file, &object),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -512,6 +532,9 @@ fn extract_recursive(
                     result.references.push(Reference {
                         name: ctor_text.to_string(),
                         location: node_to_location(file, &constructor),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
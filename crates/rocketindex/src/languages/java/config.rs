@@ -0,0 +1,12 @@
+//! Comment, test, and generated-code conventions for Java.
+
+use crate::language_config::LanguageConfig;
+
+/// Javadoc `/** */` comments; every method in `*Test.java`, `*Tests.java`,
+/// or `*IT.java` is a test.
+#[must_use]
+pub fn config() -> LanguageConfig {
+    LanguageConfig::c_style("java", &["java"])
+        .with_doc_comments(&["/**"])
+        .with_tests(&["*Test.java", "*Tests.java", "*IT.java"], None, true)
+}
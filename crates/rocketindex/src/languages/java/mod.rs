@@ -1,5 +1,8 @@
+pub mod config;
+pub mod overloads;
 pub mod parser;
 pub mod resolver;
+pub mod signature;
 
 pub use parser::JavaParser;
 pub use resolver::JavaResolver;
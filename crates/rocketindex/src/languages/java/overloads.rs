@@ -0,0 +1,281 @@
+//! Overload selection for Java calls.
+//!
+//! Overloaded methods and constructors share a qualified name, so resolving
+//! a call by name finds all of them. The parser records the argument types it
+//! can infer at the call site ([`Reference::arguments`](crate::Reference)),
+//! and [`select`] keeps the overloads those arguments can call. Like `javac`
+//! it tries overloads that need no boxing first, then boxing, then varargs;
+//! within a phase, exact types beat widening, which beats arguments whose
+//! types are unknown.
+
+use crate::Symbol;
+
+/// Argument matches the parameter type exactly.
+const EXACT: u32 = 3;
+/// Argument converts by widening, boxing, or `null` to a reference.
+const CONVERTS: u32 = 2;
+/// Argument's type is unknown, or a reference type that may be a subtype.
+const UNKNOWN: u32 = 1;
+
+/// The `javac` phase an overload is applicable in.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord)]
+enum Phase {
+    Strict,
+    Boxing,
+    Varargs,
+}
+
+/// Keep the overloads in `candidates` that best match `arguments`.
+///
+/// Candidates without a parseable signature are kept as weak matches. If no
+/// candidate is applicable the arguments are not trusted and all candidates
+/// are returned.
+pub fn select<'a>(candidates: &[&'a Symbol], arguments: &[Option<String>]) -> Vec<&'a Symbol> {
+    let scored: Vec<(&Symbol, Phase, u32)> = candidates
+        .iter()
+        .filter_map(|&candidate| {
+            let (phase, score) = applicability(candidate, arguments)?;
+            Some((candidate, phase, score))
+        })
+        .collect();
+    let Some(best) = scored
+        .iter()
+        .map(|&(_, phase, score)| (phase, score))
+        .min_by(|(a_phase, a_score), (b_phase, b_score)| {
+            a_phase.cmp(b_phase).then(b_score.cmp(a_score))
+        })
+    else {
+        return candidates.to_vec();
+    };
+
+    scored
+        .into_iter()
+        .filter(|&(_, phase, score)| (phase, score) == best)
+        .map(|(candidate, _, _)| candidate)
+        .collect()
+}
+
+/// The phase `candidate` accepts `arguments` in and how well it matches,
+/// or `None` if it cannot be called with them.
+fn applicability(candidate: &Symbol, arguments: &[Option<String>]) -> Option<(Phase, u32)> {
+    let Some(signature) = candidate.structured_signature() else {
+        return Some((Phase::Strict, 0));
+    };
+    let params = &signature.params;
+
+    if params.len() == arguments.len() {
+        let types = params.iter().map(|param| {
+            if param.variadic {
+                format!("{}[]", param.ty)
+            } else {
+                param.ty.clone()
+            }
+        });
+        if let Some((boxing, score)) = convert_all(types.zip(arguments)) {
+            let phase = if boxing { Phase::Boxing } else { Phase::Strict };
+            return Some((phase, score));
+        }
+    }
+
+    let (last, leading) = params.split_last()?;
+    if !last.variadic || arguments.len() < leading.len() {
+        return None;
+    }
+    let types = leading
+        .iter()
+        .map(|param| param.ty.clone())
+        .chain(std::iter::repeat(last.ty.clone()));
+    let (_, score) = convert_all(types.zip(arguments))?;
+    Some((Phase::Varargs, score))
+}
+
+/// Convert each argument to its parameter type: whether any needed boxing,
+/// and the summed score.
+fn convert_all<'a>(
+    pairs: impl Iterator<Item = (String, &'a Option<String>)>,
+) -> Option<(bool, u32)> {
+    pairs
+        .map(|(parameter, argument)| conversion(argument.as_deref(), &parameter))
+        .try_fold((false, 0), |(boxing, score), converted| {
+            let (boxes, points) = converted?;
+            Some((boxing || boxes, score + points))
+        })
+}
+
+/// Score passing an argument of static type `argument` to a parameter of
+/// type `parameter`, with whether it boxes or unboxes, or `None` if Java
+/// would reject it.
+fn conversion(argument: Option<&str>, parameter: &str) -> Option<(bool, u32)> {
+    let Some(argument) = argument else {
+        return Some((false, UNKNOWN));
+    };
+    let (argument, parameter) = (simple_type(argument), simple_type(parameter));
+
+    if argument == parameter {
+        return Some((false, EXACT));
+    }
+    if argument == "null" {
+        return (!is_primitive(&parameter)).then_some((false, CONVERTS));
+    }
+    match (is_primitive(&argument), is_primitive(&parameter)) {
+        (true, true) => widens(&argument, &parameter).then_some((false, CONVERTS)),
+        (true, false) => (boxed(&argument) == Some(parameter.as_str()) || parameter == "Object")
+            .then_some((true, CONVERTS)),
+        (false, true) => unboxed(&argument)
+            .is_some_and(|primitive| primitive == parameter || widens(primitive, &parameter))
+            .then_some((true, CONVERTS)),
+        (false, false) => {
+            if parameter == "Object" {
+                Some((false, CONVERTS))
+            } else if is_final_library_type(&parameter)
+                || argument.ends_with("[]") != parameter.ends_with("[]")
+            {
+                // Nothing else converts to a final type
+                None
+            } else {
+                Some((false, UNKNOWN))
+            }
+        }
+    }
+}
+
+/// Drop type arguments and package qualifiers: `java.util.List<String>` -> `List`.
+fn simple_type(ty: &str) -> String {
+    let mut erased = String::with_capacity(ty.len());
+    let mut depth = 0usize;
+    for c in ty.chars() {
+        match c {
+            '<' => depth += 1,
+            '>' => depth = depth.saturating_sub(1),
+            c if depth == 0 && !c.is_whitespace() => erased.push(c),
+            _ => {}
+        }
+    }
+    let dims = erased.find('[').unwrap_or(erased.len());
+    let base = &erased[..dims];
+    let base = base.rsplit('.').next().unwrap_or(base);
+    format!("{}{}", base, &erased[dims..])
+}
+
+const PRIMITIVES: [(&str, &str); 8] = [
+    ("boolean", "Boolean"),
+    ("byte", "Byte"),
+    ("short", "Short"),
+    ("char", "Character"),
+    ("int", "Integer"),
+    ("long", "Long"),
+    ("float", "Float"),
+    ("double", "Double"),
+];
+
+fn is_primitive(ty: &str) -> bool {
+    PRIMITIVES.iter().any(|&(primitive, _)| primitive == ty)
+}
+
+fn boxed(primitive: &str) -> Option<&'static str> {
+    PRIMITIVES
+        .iter()
+        .find(|&&(p, _)| p == primitive)
+        .map(|&(_, boxed)| boxed)
+}
+
+fn unboxed(ty: &str) -> Option<&'static str> {
+    PRIMITIVES
+        .iter()
+        .find(|&&(_, boxed)| boxed == ty)
+        .map(|&(primitive, _)| primitive)
+}
+
+/// Library types no other type extends, so a mismatch is a real mismatch.
+fn is_final_library_type(ty: &str) -> bool {
+    ty == "String" || unboxed(ty).is_some()
+}
+
+/// Java's widening primitive conversions.
+fn widens(from: &str, to: &str) -> bool {
+    let wider: &[&str] = match from {
+        "byte" => &["short", "int", "long", "float", "double"],
+        "short" | "char" => &["int", "long", "float", "double"],
+        "int" => &["long", "float", "double"],
+        "long" => &["float", "double"],
+        "float" => &["double"],
+        _ => &[],
+    };
+    wider.contains(&to)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::{Location, SymbolKind, Visibility};
+    use std::path::PathBuf;
+
+    fn overload(line: u32, signature: &str) -> Symbol {
+        Symbol::new(
+            "add".to_string(),
+            "com.example.Calc.add".to_string(),
+            SymbolKind::Function,
+            Location::new(PathBuf::from("Calc.java"), line, 9),
+            Visibility::Public,
+            "java".to_string(),
+        )
+        .with_signature(Some(signature.to_string()))
+    }
+
+    fn args(types: &[Option<&str>]) -> Vec<Option<String>> {
+        types.iter().map(|t| t.map(str::to_string)).collect()
+    }
+
+    fn lines(selected: Vec<&Symbol>) -> Vec<u32> {
+        selected.iter().map(|s| s.location.line).collect()
+    }
+
+    #[test]
+    fn test_select_by_arity_and_type() {
+        let overloads = [
+            overload(1, "int add(int a, int b)"),
+            overload(2, "double add(double a, double b)"),
+            overload(3, "int add(int a, int b, int c)"),
+            overload(4, "String add(String a, String b)"),
+        ];
+        let candidates: Vec<&Symbol> = overloads.iter().collect();
+
+        let select = |types: &[Option<&str>]| lines(select(&candidates, &args(types)));
+        assert_eq!(select(&[Some("int"), Some("int")]), [1]);
+        assert_eq!(select(&[Some("double"), Some("int")]), [2]);
+        assert_eq!(select(&[Some("long"), Some("long")]), [2]);
+        assert_eq!(select(&[Some("int"), None, Some("int")]), [3]);
+        assert_eq!(select(&[Some("String"), Some("null")]), [4]);
+        assert_eq!(select(&[Some("java.lang.String"), None]), [4]);
+        // Unknown arguments leave every overload of that arity
+        assert_eq!(select(&[None, None]), [1, 2, 4]);
+        // Nothing applies: keep everything rather than drop the call
+        assert_eq!(select(&[Some("boolean")]), [1, 2, 3, 4]);
+    }
+
+    #[test]
+    fn test_boxing_and_varargs_rank_below_exact_matches() {
+        let overloads = [
+            overload(1, "void log(Integer value)"),
+            overload(2, "void log(long value)"),
+            overload(3, "void log(String format, Object... args)"),
+            overload(4, "void log(Object value)"),
+        ];
+        let candidates: Vec<&Symbol> = overloads.iter().collect();
+
+        let select = |types: &[Option<&str>]| lines(select(&candidates, &args(types)));
+        assert_eq!(select(&[Some("Integer")]), [1]);
+        assert_eq!(select(&[Some("long")]), [2]);
+        // Widening beats boxing
+        assert_eq!(select(&[Some("int")]), [2]);
+        assert_eq!(select(&[Some("String"), Some("int"), Some("User")]), [3]);
+        assert_eq!(select(&[Some("User")]), [4]);
+    }
+
+    #[test]
+    fn test_simple_type() {
+        assert_eq!(simple_type("java.util.List<String>"), "List");
+        assert_eq!(simple_type("Map<String, List<Integer>>[]"), "Map[]");
+        assert_eq!(simple_type("int"), "int");
+    }
+}
@@ -1,10 +1,14 @@
 //! Symbol extraction from Java source files using tree-sitter.
 
 use std::cell::RefCell;
+use std::collections::HashMap;
 use std::path::Path;
 
-use crate::parse::{find_child_by_kind, node_to_location, LanguageParser, ParseResult};
-use crate::{Reference, Symbol, SymbolKind, Visibility};
+use crate::parse::{
+    collect_annotations, find_child_by_kind, node_to_location, record_extents, LanguageParser,
+    ParseResult,
+};
+use crate::{Reference, ReferenceKind, Symbol, SymbolKind, Visibility};
 
 // Thread-local parser reuse - avoids creating a new parser per file
 thread_local! {
@@ -46,6 +50,16 @@ impl LanguageParser for JavaParser {
                 package.as_deref(),
                 max_depth,
             );
+            resolve_call_targets(
+                &root,
+                source.as_bytes(),
+                file,
+                &mut result,
+                package.as_deref(),
+            );
+            record_extents(&root, &mut result.symbols);
+            result.annotations =
+                collect_annotations(file, &root, source.as_bytes(), &result.symbols);
 
             result
         })
@@ -83,7 +97,7 @@ fn extract_visibility(node: &tree_sitter::Node, source: &[u8]) -> Visibility {
                 if let Ok(text) = child.utf8_text(source) {
                     match text {
                         "public" => return Visibility::Public,
-                        "protected" => return Visibility::Internal,
+                        "protected" => return Visibility::Protected,
                         "private" => return Visibility::Private,
                         _ => {}
                     }
@@ -223,6 +237,7 @@ fn extract_recursive(
                         implements,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Recurse into class body
@@ -266,6 +281,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Recurse into interface body
@@ -309,27 +325,42 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Extract enum constants
                     if let Some(body) = node.child_by_field_name("body") {
                         extract_enum_constants(&body, source, file, result, &qualified);
 
-                        // Also process methods in enum
+                        // Also process methods in enum, which follow the
+                        // constants in an enum_body_declarations node
+                        let mut members = Vec::new();
                         for i in 0..body.child_count() {
                             if let Some(child) = body.child(i) {
-                                if child.kind() == "method_declaration" {
-                                    extract_recursive(
-                                        &child,
-                                        source,
-                                        file,
-                                        result,
-                                        Some(&qualified),
-                                        max_depth - 1,
+                                if child.kind() == "enum_body_declarations" {
+                                    members.extend(
+                                        (0..child.child_count()).filter_map(|j| child.child(j)),
                                     );
+                                } else {
+                                    members.push(child);
                                 }
                             }
                         }
+                        for child in members {
+                            if matches!(
+                                child.kind(),
+                                "method_declaration" | "constructor_declaration"
+                            ) {
+                                extract_recursive(
+                                    &child,
+                                    source,
+                                    file,
+                                    result,
+                                    Some(&qualified),
+                                    max_depth - 1,
+                                );
+                            }
+                        }
                     }
                     return;
                 }
@@ -358,6 +389,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Extract annotation elements (methods)
@@ -389,6 +421,7 @@ fn extract_recursive(
                                                         implements: None,
                                                         doc: None,
                                                         signature: None,
+                                                        extent: None,
                                                     });
                                                 }
                                                 break;
@@ -426,6 +459,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Extract record components from formal_parameters
@@ -450,6 +484,7 @@ fn extract_recursive(
                                                 implements: None,
                                                 doc: None,
                                                 signature: None,
+                                                extent: None,
                                             });
                                         }
                                     }
@@ -494,12 +529,13 @@ fn extract_recursive(
                         location: node_to_location(file, &name_node),
                         visibility,
                         language: "java".to_string(),
-                        parent: None,
+                        parent: package.map(str::to_string),
                         mixins: None,
                         attributes: annotations,
                         implements: None,
                         doc,
                         signature,
+                        extent: None,
                     });
                 }
             }
@@ -530,12 +566,13 @@ fn extract_recursive(
                         location: node_to_location(file, &name_node),
                         visibility,
                         language: "java".to_string(),
-                        parent: None,
+                        parent: package.map(str::to_string),
                         mixins: None,
                         attributes: annotations,
                         implements: None,
                         doc,
                         signature,
+                        extent: None,
                     });
                 }
             }
@@ -558,12 +595,13 @@ fn extract_recursive(
                             location: node_to_location(file, &name_node),
                             visibility,
                             language: "java".to_string(),
-                            parent: None,
+                            parent: package.map(str::to_string),
                             mixins: None,
                             attributes: annotations,
                             implements: None,
                             doc,
                             signature: None,
+                            extent: None,
                         });
                     }
                 }
@@ -590,6 +628,9 @@ fn extract_recursive(
                     result.references.push(Reference {
                         name: name.to_string(),
                         location: node_to_location(file, node),
+                        kind: type_reference_kind(node),
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -602,6 +643,9 @@ fn extract_recursive(
                     result.references.push(Reference {
                         name: name.to_string(),
                         location: node_to_location(file, node),
+                        kind: type_reference_kind(node),
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -614,6 +658,9 @@ fn extract_recursive(
                     result.references.push(Reference {
                         name: name.to_string(),
                         location: node_to_location(file, node),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -639,6 +686,9 @@ fn extract_recursive(
                     result.references.push(Reference {
                         name: reference_name,
                         location: node_to_location(file, &name_node),
+                        kind: ReferenceKind::Call,
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -655,6 +705,497 @@ fn extract_recursive(
     }
 }
 
+/// The kind of a type reference: the type of `new T(...)` is a construction.
+fn type_reference_kind(node: &tree_sitter::Node) -> ReferenceKind {
+    let mut parent = node.parent();
+    // `new ArrayList<String>()` names `ArrayList` inside a generic_type
+    if parent.is_some_and(|p| p.kind() == "generic_type") {
+        parent = parent.and_then(|p| p.parent());
+    }
+    match parent {
+        Some(p) if p.kind() == "object_creation_expression" => ReferenceKind::Construction,
+        _ => ReferenceKind::Unknown,
+    }
+}
+
+/// Fill in what local context says about calls: the static types of their
+/// arguments ([`Reference::arguments`]), so the call graph can tell overloads
+/// apart, and the declaring type of a receiver with a known type
+/// ([`Reference::target`]).
+///
+/// `shape.area()` with `Shape shape` in scope targets
+/// `com.example.Shape.area`, `this.reset()` and a bare `reset()` target the
+/// enclosing class, `Helper.greet()` targets `Helper`, and `new Point(1, 2)`
+/// targets the constructor `com.example.Point.Point`. Type names resolve to a
+/// type declared in the file, then a single-type import, then the file's
+/// package. Argument types are read from literals, `new`, casts, and declared
+/// variables; variables are tracked per method, ignoring shadowing by inner
+/// blocks.
+fn resolve_call_targets(
+    root: &tree_sitter::Node,
+    source: &[u8],
+    file: &Path,
+    result: &mut ParseResult,
+    package: Option<&str>,
+) {
+    let mut positions: HashMap<(u32, u32), usize> = HashMap::new();
+    for (i, reference) in result.references.iter().enumerate() {
+        if matches!(
+            reference.kind,
+            ReferenceKind::Call | ReferenceKind::Construction
+        ) {
+            positions
+                .entry((reference.location.line, reference.location.column))
+                .or_insert(i);
+        }
+    }
+    if positions.is_empty() {
+        return;
+    }
+
+    let types: HashMap<&str, &str> = result
+        .symbols
+        .iter()
+        .filter(|s| {
+            matches!(
+                s.kind,
+                SymbolKind::Class | SymbolKind::Interface | SymbolKind::Union
+            )
+        })
+        .map(|s| (s.name.as_str(), s.qualified.as_str()))
+        .collect();
+
+    let mut calls = Vec::new();
+    CallContext {
+        source,
+        file,
+        package,
+        types: &types,
+        imports: &result.opens,
+        positions: &positions,
+    }
+    .walk(root, &Scope::default(), &mut calls);
+
+    for (i, target, arguments) in calls {
+        let reference = &mut result.references[i];
+        reference.target = target;
+        reference.arguments = Some(arguments);
+    }
+}
+
+/// Commonly used `java.lang` types, which every file imports implicitly.
+const JAVA_LANG: &[&str] = &[
+    "Boolean",
+    "Byte",
+    "Character",
+    "Double",
+    "Exception",
+    "Float",
+    "Integer",
+    "Long",
+    "Math",
+    "Object",
+    "RuntimeException",
+    "Short",
+    "String",
+    "StringBuilder",
+    "System",
+    "Thread",
+];
+
+struct CallContext<'a> {
+    source: &'a [u8],
+    file: &'a Path,
+    package: Option<&'a str>,
+    /// Simple name -> qualified name of each type declared in the file
+    types: &'a HashMap<&'a str, &'a str>,
+    /// Imported names (`com.example.Shape`)
+    imports: &'a [String],
+    /// Start of each call reference -> its index in `result.references`
+    positions: &'a HashMap<(u32, u32), usize>,
+}
+
+/// What is in scope at a node: the enclosing type and the declared types of
+/// the variables visible there.
+#[derive(Clone, Default)]
+struct Scope {
+    class: Option<String>,
+    variables: HashMap<String, String>,
+}
+
+impl CallContext<'_> {
+    fn walk(
+        &self,
+        node: &tree_sitter::Node,
+        scope: &Scope,
+        calls: &mut Vec<(usize, Option<String>, Vec<Option<String>>)>,
+    ) {
+        let inner;
+        let scope = match node.kind() {
+            "class_declaration"
+            | "interface_declaration"
+            | "enum_declaration"
+            | "record_declaration" => {
+                let Some(name) = node
+                    .child_by_field_name("name")
+                    .and_then(|n| n.utf8_text(self.source).ok())
+                else {
+                    return;
+                };
+                let mut variables = scope.variables.clone();
+                if let Some(params) = node.child_by_field_name("parameters") {
+                    collect_variable_types(&params, self.source, &mut variables);
+                }
+                if let Some(body) = node.child_by_field_name("body") {
+                    let mut cursor = body.walk();
+                    for member in body.named_children(&mut cursor) {
+                        collect_fields(&member, self.source, &mut variables);
+                    }
+                }
+                inner = Scope {
+                    class: Some(match &scope.class {
+                        Some(outer) => format!("{}.{}", outer, name),
+                        None => qualified_name(name, self.package),
+                    }),
+                    variables,
+                };
+                &inner
+            }
+            "method_declaration" | "constructor_declaration" => {
+                let mut variables = scope.variables.clone();
+                collect_variable_types(node, self.source, &mut variables);
+                inner = Scope {
+                    class: scope.class.clone(),
+                    variables,
+                };
+                &inner
+            }
+            "method_invocation" => {
+                if let Some(name_node) = node.child_by_field_name("name") {
+                    let owner = match node.child_by_field_name("object") {
+                        Some(object) => self.receiver_type(&object, scope),
+                        None => scope.class.clone(),
+                    };
+                    let method = name_node.utf8_text(self.source).unwrap_or_default();
+                    self.record(
+                        &name_node,
+                        node,
+                        owner.map(|owner| format!("{}.{}", owner, method)),
+                        scope,
+                        calls,
+                    );
+                }
+                scope
+            }
+            "object_creation_expression" => {
+                // The reference sits on the type's name, inside any type arguments
+                let name_node = node.child_by_field_name("type").and_then(|ty| {
+                    if ty.kind() == "generic_type" {
+                        find_child_by_kind(&ty, "type_identifier")
+                            .or_else(|| find_child_by_kind(&ty, "scoped_type_identifier"))
+                    } else {
+                        Some(ty)
+                    }
+                });
+                if let Some(name_node) = name_node {
+                    let type_name = name_node.utf8_text(self.source).unwrap_or_default();
+                    let constructor = type_name.rsplit('.').next().unwrap_or(type_name);
+                    let target = format!("{}.{}", self.qualify(type_name), constructor);
+                    self.record(&name_node, node, Some(target), scope, calls);
+                }
+                scope
+            }
+            _ => scope,
+        };
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            self.walk(&child, scope, calls);
+        }
+    }
+
+    /// Record the target and argument types of the call whose reference
+    /// starts at `name_node`.
+    fn record(
+        &self,
+        name_node: &tree_sitter::Node,
+        call: &tree_sitter::Node,
+        target: Option<String>,
+        scope: &Scope,
+        calls: &mut Vec<(usize, Option<String>, Vec<Option<String>>)>,
+    ) {
+        let location = node_to_location(self.file, name_node);
+        let Some(&i) = self.positions.get(&(location.line, location.column)) else {
+            return;
+        };
+        let arguments = call
+            .child_by_field_name("arguments")
+            .map(|list| {
+                let mut cursor = list.walk();
+                list.named_children(&mut cursor)
+                    .filter(|argument| !argument.is_extra())
+                    .map(|argument| self.expression_type(&argument, scope))
+                    .collect()
+            })
+            .unwrap_or_default();
+        calls.push((i, target, arguments));
+    }
+
+    /// Qualified name of the type a method is called on, if known.
+    fn receiver_type(&self, object: &tree_sitter::Node, scope: &Scope) -> Option<String> {
+        match object.kind() {
+            "this" => scope.class.clone(),
+            "identifier" => {
+                let name = object.utf8_text(self.source).ok()?;
+                match scope.variables.get(name) {
+                    Some(ty) => Some(self.qualify(ty)),
+                    // `Helper.greet()` calls a static method
+                    None => name
+                        .starts_with(char::is_uppercase)
+                        .then(|| self.qualify(name)),
+                }
+            }
+            _ => self
+                .expression_type(object, scope)
+                .map(|ty| self.qualify(&ty)),
+        }
+    }
+
+    /// The static type of an expression as written, if it can be read locally.
+    fn expression_type(&self, node: &tree_sitter::Node, scope: &Scope) -> Option<String> {
+        let text = node.utf8_text(self.source).ok()?;
+        let ty = match node.kind() {
+            "decimal_integer_literal"
+            | "hex_integer_literal"
+            | "octal_integer_literal"
+            | "binary_integer_literal" => {
+                if text.ends_with(['l', 'L']) {
+                    "long"
+                } else {
+                    "int"
+                }
+            }
+            "decimal_floating_point_literal" | "hex_floating_point_literal" => {
+                if text.ends_with(['f', 'F']) {
+                    "float"
+                } else {
+                    "double"
+                }
+            }
+            "true" | "false" | "instanceof_expression" => "boolean",
+            "character_literal" => "char",
+            "string_literal" | "text_block" => "String",
+            "null_literal" => "null",
+            "object_creation_expression" | "cast_expression" => {
+                return node
+                    .child_by_field_name("type")?
+                    .utf8_text(self.source)
+                    .ok()
+                    .map(str::to_string);
+            }
+            "identifier" => return scope.variables.get(text).cloned(),
+            "this" => {
+                return scope
+                    .class
+                    .as_deref()?
+                    .rsplit('.')
+                    .next()
+                    .map(str::to_string)
+            }
+            "field_access" => {
+                if node.child_by_field_name("object")?.kind() != "this" {
+                    return None;
+                }
+                let field = node
+                    .child_by_field_name("field")?
+                    .utf8_text(self.source)
+                    .ok()?;
+                return scope.variables.get(field).cloned();
+            }
+            "parenthesized_expression" => {
+                return self.expression_type(&node.named_child(0)?, scope);
+            }
+            "unary_expression" => {
+                let operator = node
+                    .child_by_field_name("operator")?
+                    .utf8_text(self.source)
+                    .ok()?;
+                if operator == "!" {
+                    "boolean"
+                } else {
+                    return self.expression_type(&node.child_by_field_name("operand")?, scope);
+                }
+            }
+            "binary_expression" => {
+                let operator = node
+                    .child_by_field_name("operator")?
+                    .utf8_text(self.source)
+                    .ok()?;
+                if matches!(
+                    operator,
+                    "==" | "!=" | "<" | ">" | "<=" | ">=" | "&&" | "||"
+                ) {
+                    "boolean"
+                } else {
+                    let left = self.expression_type(&node.child_by_field_name("left")?, scope);
+                    let right = self.expression_type(&node.child_by_field_name("right")?, scope);
+                    return binary_type(operator, left.as_deref()?, right.as_deref()?);
+                }
+            }
+            _ => return None,
+        };
+        Some(ty.to_string())
+    }
+
+    /// Qualify a type name as written: `Shape` -> `com.example.Shape`, and
+    /// `Outer.Inner` through `Outer`. Type arguments and array dimensions
+    /// are dropped.
+    fn qualify(&self, ty: &str) -> String {
+        let name = ty.split(['<', '[']).next().unwrap_or(ty).trim();
+        if let Some((first, rest)) = name.split_once('.') {
+            // A lower-case first segment is a package: already qualified
+            if !first.starts_with(char::is_uppercase) {
+                return name.to_string();
+            }
+            return format!("{}.{}", self.qualify(first), rest);
+        }
+        if let Some(qualified) = self.types.get(name) {
+            return qualified.to_string();
+        }
+        let suffix = format!(".{}", name);
+        if let Some(import) = self.imports.iter().find(|i| i.ends_with(&suffix)) {
+            return import.clone();
+        }
+        if JAVA_LANG.contains(&name) {
+            return format!("java.lang.{}", name);
+        }
+        qualified_name(name, self.package)
+    }
+}
+
+/// The type of `left <operator> right` for arithmetic and string concatenation.
+fn binary_type(operator: &str, left: &str, right: &str) -> Option<String> {
+    const NUMERIC: [&str; 7] = ["double", "float", "long", "int", "char", "short", "byte"];
+    if operator == "+" && (left == "String" || right == "String") {
+        return Some("String".to_string());
+    }
+    if !(NUMERIC.contains(&left) && NUMERIC.contains(&right)) {
+        return None;
+    }
+    // Binary numeric promotion: the wider operand, and at least int
+    let promoted = NUMERIC[..3]
+        .iter()
+        .find(|&&ty| left == ty || right == ty)
+        .unwrap_or(&"int");
+    Some(promoted.to_string())
+}
+
+/// Record the declared type of every variable declared in `node`:
+/// parameters, fields, locals, `for` and `catch` variables, and resources.
+/// A local declared with `var` takes the type of its initializer. Nested
+/// class bodies are left for their own scope.
+fn collect_variable_types(
+    node: &tree_sitter::Node,
+    source: &[u8],
+    variables: &mut HashMap<String, String>,
+) {
+    let text = |n: tree_sitter::Node| n.utf8_text(source).ok().map(str::to_string);
+    match node.kind() {
+        "formal_parameter" | "enhanced_for_statement" | "resource" => {
+            if let (Some(name), Some(ty)) = (
+                node.child_by_field_name("name").and_then(text),
+                node.child_by_field_name("type").and_then(text),
+            ) {
+                variables.insert(name, ty);
+            }
+        }
+        "catch_formal_parameter" => {
+            if let (Some(name), Some(ty)) = (
+                node.child_by_field_name("name").and_then(text),
+                find_child_by_kind(node, "catch_type").and_then(text),
+            ) {
+                variables.insert(name, ty);
+            }
+        }
+        "spread_parameter" => {
+            // `String... parts` is a `String[]`
+            let mut cursor = node.walk();
+            let children: Vec<tree_sitter::Node> = node.named_children(&mut cursor).collect();
+            let ty = children
+                .iter()
+                .find(|c| !matches!(c.kind(), "modifiers" | "variable_declarator"))
+                .and_then(|c| text(*c));
+            let name = children
+                .iter()
+                .find(|c| c.kind() == "variable_declarator")
+                .and_then(|c| c.child_by_field_name("name"))
+                .and_then(text);
+            if let (Some(name), Some(ty)) = (name, ty) {
+                variables.insert(name, format!("{}[]", ty));
+            }
+        }
+        "local_variable_declaration" | "field_declaration" => {
+            let declared = node.child_by_field_name("type").and_then(text);
+            let mut cursor = node.walk();
+            for declarator in node.children_by_field_name("declarator", &mut cursor) {
+                let Some(name) = declarator.child_by_field_name("name").and_then(text) else {
+                    continue;
+                };
+                let ty = match declared.as_deref() {
+                    Some("var") => declarator
+                        .child_by_field_name("value")
+                        .and_then(|value| initializer_type(&value, source)),
+                    _ => declared.clone(),
+                };
+                if let Some(ty) = ty {
+                    variables.insert(name, ty);
+                }
+            }
+        }
+        _ => {}
+    }
+
+    let mut cursor = node.walk();
+    for child in node.named_children(&mut cursor) {
+        if child.kind() != "class_body" {
+            collect_variable_types(&child, source, variables);
+        }
+    }
+}
+
+/// Record the declared types of the fields a type body member declares,
+/// without descending into methods.
+fn collect_fields(
+    member: &tree_sitter::Node,
+    source: &[u8],
+    variables: &mut HashMap<String, String>,
+) {
+    match member.kind() {
+        "field_declaration" => collect_variable_types(member, source, variables),
+        // Enum fields follow the constants
+        "enum_body_declarations" => {
+            let mut cursor = member.walk();
+            for inner in member.named_children(&mut cursor) {
+                collect_fields(&inner, source, variables);
+            }
+        }
+        _ => {}
+    }
+}
+
+/// The type of a `var` initializer that names it: `new T(...)` or a cast.
+fn initializer_type(value: &tree_sitter::Node, source: &[u8]) -> Option<String> {
+    match value.kind() {
+        "object_creation_expression" | "cast_expression" => value
+            .child_by_field_name("type")?
+            .utf8_text(source)
+            .ok()
+            .map(str::to_string),
+        "string_literal" => Some("String".to_string()),
+        _ => None,
+    }
+}
+
 /// Check if a node is a descendant of a node with the given kind
 fn is_descendant_of(node: &tree_sitter::Node, kind: &str) -> bool {
     let mut current = node.parent();
@@ -819,12 +1360,16 @@ fn extract_interfaces(node: &tree_sitter::Node, source: &[u8]) -> Option<Vec<Str
                 if child.kind() == "type_list" {
                     for j in 0..child.child_count() {
                         if let Some(type_child) = child.child(j) {
-                            if type_child.kind() == "type_identifier"
-                                || type_child.kind() == "generic_type"
-                            {
-                                if let Ok(name) = type_child.utf8_text(source) {
-                                    interfaces.push(name.to_string());
+                            // `Comparable<Dog>` implements `Comparable`
+                            let name_node = match type_child.kind() {
+                                "type_identifier" => Some(type_child),
+                                "generic_type" => {
+                                    find_child_by_kind(&type_child, "type_identifier")
                                 }
+                                _ => None,
+                            };
+                            if let Some(Ok(name)) = name_node.map(|n| n.utf8_text(source)) {
+                                interfaces.push(name.to_string());
                             }
                         }
                     }
@@ -868,6 +1413,7 @@ fn extract_enum_constants(
                             implements: None,
                             doc: extract_doc_comments(&child, source),
                             signature: None,
+                            extent: None,
                         });
                     }
                 }
@@ -1096,7 +1642,7 @@ public class Example {
             .iter()
             .find(|s| s.name == "protectedMethod")
             .unwrap();
-        assert_eq!(protected.visibility, Visibility::Internal);
+        assert_eq!(protected.visibility, Visibility::Protected);
 
         let private = result
             .symbols
@@ -1385,4 +1931,152 @@ class Helper {
             ref_names
         );
     }
+
+    #[test]
+    fn keeps_members_under_their_enclosing_type() {
+        let source = r#"
+package com.example;
+
+public class Outer {
+    private int count;
+
+    public Outer() {}
+
+    public void run() {}
+
+    public static class Inner {
+        void deep() {}
+    }
+}
+
+enum Level {
+    LOW, HIGH;
+
+    int weight() { return 1; }
+}
+"#;
+        let result = extract_symbols(Path::new("Outer.java"), source, 500);
+        let parent = |qualified: &str| {
+            result
+                .symbols
+                .iter()
+                .find(|s| s.qualified == qualified)
+                .unwrap_or_else(|| panic!("missing {}", qualified))
+                .parent
+                .clone()
+        };
+
+        assert_eq!(
+            parent("com.example.Outer.count"),
+            Some("com.example.Outer".into())
+        );
+        assert_eq!(
+            parent("com.example.Outer.Outer"),
+            Some("com.example.Outer".into())
+        );
+        assert_eq!(
+            parent("com.example.Outer.run"),
+            Some("com.example.Outer".into())
+        );
+        assert_eq!(
+            parent("com.example.Outer.Inner.deep"),
+            Some("com.example.Outer.Inner".into())
+        );
+        assert_eq!(
+            parent("com.example.Level.weight"),
+            Some("com.example.Level".into())
+        );
+    }
+
+    #[test]
+    fn records_call_targets_and_argument_types() {
+        let source = r#"
+package com.example;
+
+import com.example.shapes.Shape;
+
+public class Calc {
+    private Shape shape;
+
+    public int add(int a, int b) { return a + b; }
+
+    public double add(double a, double b) { return a + b; }
+
+    public void run(String label, long total) {
+        add(1, 2);
+        this.add(1.5, 2);
+        var point = new Point(total, label.length());
+        shape.area();
+        Helper.log(label + total, null, (int) total, 'x');
+        point.move(-1, total > 0);
+    }
+}
+"#;
+        let result = extract_symbols(Path::new("Calc.java"), source, 500);
+        let call = |name: &str| {
+            let reference = result
+                .references
+                .iter()
+                .find(|r| r.name == name && r.arguments.is_some())
+                .unwrap_or_else(|| panic!("no call {}: {:?}", name, result.references));
+            let arguments: Vec<Option<&str>> = reference
+                .arguments
+                .iter()
+                .flatten()
+                .map(Option::as_deref)
+                .collect();
+            (reference.kind, reference.target.clone(), arguments)
+        };
+        let target = |t: &str| Some(t.to_string());
+
+        assert_eq!(
+            call("add"),
+            (
+                ReferenceKind::Call,
+                target("com.example.Calc.add"),
+                vec![Some("int"), Some("int")]
+            )
+        );
+        assert_eq!(
+            call("this.add"),
+            (
+                ReferenceKind::Call,
+                target("com.example.Calc.add"),
+                vec![Some("double"), Some("int")]
+            )
+        );
+        assert_eq!(
+            call("Point"),
+            (
+                ReferenceKind::Construction,
+                target("com.example.Point.Point"),
+                vec![Some("long"), None]
+            )
+        );
+        assert_eq!(
+            call("shape.area"),
+            (
+                ReferenceKind::Call,
+                target("com.example.shapes.Shape.area"),
+                vec![]
+            )
+        );
+        assert_eq!(
+            call("Helper.log"),
+            (
+                ReferenceKind::Call,
+                target("com.example.Helper.log"),
+                vec![Some("String"), Some("null"), Some("int"), Some("char")]
+            )
+        );
+        assert_eq!(
+            call("point.move"),
+            (
+                ReferenceKind::Call,
+                target("com.example.Point.move"),
+                vec![Some("int"), Some("boolean")]
+            )
+        );
+        assert_eq!(call("label.length").1, target("java.lang.String.length"));
+    }
 }
@@ -10,7 +10,7 @@ use std::path::Path;
 
 use crate::parse::ParseResult;
 use crate::resolve::{ResolutionPath, ResolveResult, SymbolResolver};
-use crate::{CodeIndex, Reference, SymbolKind};
+use crate::{CodeIndex, Reference, ReferenceKind, Symbol, SymbolKind};
 
 pub struct JavaResolver;
 
@@ -139,6 +139,14 @@ impl SymbolResolver for JavaResolver {
         // Fall back to normal resolution
         self.resolve(index, name, from_file)
     }
+
+    fn select_overloads<'a>(
+        &self,
+        overloads: &[&'a Symbol],
+        arguments: &[Option<String>],
+    ) -> Vec<&'a Symbol> {
+        super::overloads::select(overloads, arguments)
+    }
 }
 
 impl JavaResolver {
@@ -157,6 +165,9 @@ impl JavaResolver {
                 references.push(Reference {
                     name: parent.clone(),
                     location: symbol.location.clone(),
+                    kind: ReferenceKind::Unknown,
+                    target: None,
+                    arguments: None,
                 });
             }
 
@@ -166,6 +177,9 @@ impl JavaResolver {
                     references.push(Reference {
                         name: iface.clone(),
                         location: symbol.location.clone(),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -182,6 +196,9 @@ impl JavaResolver {
                     end_line: 1,
                     end_column: 1,
                 },
+                kind: ReferenceKind::Unknown,
+                target: None,
+                arguments: None,
             });
         }
 
@@ -452,6 +469,31 @@ public class Dog extends Animal {
         assert_eq!(result.unwrap().symbol.qualified, "com.example.User.save");
     }
 
+    #[test]
+    fn select_overloads_by_argument_types() {
+        let overloads: Vec<Symbol> = ["void log(int value)", "void log(String message)"]
+            .into_iter()
+            .enumerate()
+            .map(|(i, signature)| {
+                Symbol::new(
+                    "log".to_string(),
+                    "com.example.Logger.log".to_string(),
+                    SymbolKind::Function,
+                    Location::new(PathBuf::from("src/Logger.java"), i as u32 + 3, 17),
+                    Visibility::Public,
+                    "java".to_string(),
+                )
+                .with_signature(Some(signature.to_string()))
+            })
+            .collect();
+        let candidates: Vec<&Symbol> = overloads.iter().collect();
+
+        let selected = JavaResolver.select_overloads(&candidates, &[Some("String".to_string())]);
+        assert_eq!(selected.len(), 1);
+        assert_eq!(selected[0].location.line, 4);
+        assert_eq!(JavaResolver.select_overloads(&candidates, &[None]).len(), 2);
+    }
+
     #[test]
     fn returns_none_for_unknown_symbol() {
         let index = CodeIndex::new();
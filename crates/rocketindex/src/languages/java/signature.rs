@@ -0,0 +1,166 @@
+//! Parsing of Java signatures as stored by the Java parser.
+//!
+//! Methods are stored as `ReturnType name(params)` and constructors as
+//! `Name(params)`, with the parameter list copied from source.
+
+use crate::signature::{Param, Signature};
+
+/// Split a Java method or constructor signature into parameters and result.
+///
+/// A `void` method has no results. Returns `None` if `signature` has no
+/// parameter list.
+pub fn parse(signature: &str) -> Option<Signature> {
+    let open = signature.find('(')?;
+    let (head, rest) = signature.split_at(open);
+    let (params, _) = split_parenthesized(rest)?;
+
+    // `head` is `ReturnType name` for a method, `Name` for a constructor
+    let results = match head.trim().rsplit_once(char::is_whitespace) {
+        Some((ty, _)) if ty.trim() != "void" => vec![Param {
+            name: None,
+            ty: normalize_type(ty),
+            variadic: false,
+        }],
+        _ => Vec::new(),
+    };
+
+    Some(Signature {
+        receiver: None,
+        type_params: Vec::new(),
+        params: split_top_level(params).into_iter().map(param).collect(),
+        results,
+    })
+}
+
+/// Split `(inner) rest` at the matching close paren, returning `(inner, rest)`.
+fn split_parenthesized(text: &str) -> Option<(&str, &str)> {
+    let mut depth = 0usize;
+    for (i, c) in text.char_indices() {
+        match c {
+            '(' => depth += 1,
+            ')' => {
+                depth = depth.checked_sub(1)?;
+                if depth == 0 {
+                    return Some((&text[1..i], &text[i + 1..]));
+                }
+            }
+            _ => {}
+        }
+    }
+    None
+}
+
+/// Split a parameter list on commas outside generics and annotation arguments.
+fn split_top_level(list: &str) -> Vec<&str> {
+    let mut pieces = Vec::new();
+    let mut depth = 0i32;
+    let mut start = 0;
+    for (i, c) in list.char_indices() {
+        match c {
+            '(' | '<' | '[' | '{' => depth += 1,
+            ')' | '>' | ']' | '}' => depth -= 1,
+            ',' if depth == 0 => {
+                pieces.push(list[start..i].trim());
+                start = i + 1;
+            }
+            _ => {}
+        }
+    }
+    pieces.push(list[start..].trim());
+    pieces.retain(|p| !p.is_empty());
+    pieces
+}
+
+/// Parse one `Type name` parameter, dropping annotations and `final`.
+fn param(piece: &str) -> Param {
+    let piece = strip_modifiers(piece);
+    let (ty, name) = match piece.rsplit_once(char::is_whitespace) {
+        Some((ty, name)) => (ty.trim(), Some(name)),
+        None => (piece, None),
+    };
+    // C-style array parameters: `int values[]`
+    let (ty, name) = match name.and_then(|n| n.find('[').map(|i| n.split_at(i))) {
+        Some((name, dims)) => (format!("{}{}", ty, dims), Some(name)),
+        None => (ty.to_string(), name),
+    };
+    let (ty, variadic) = match ty.strip_suffix("...") {
+        Some(element) => (element.to_string(), true),
+        None => (ty, false),
+    };
+    Param {
+        name: name.map(str::to_string),
+        ty: normalize_type(&ty),
+        variadic,
+    }
+}
+
+/// Drop leading `final` and annotations (`@NonNull`, `@Size(max = 3)`).
+fn strip_modifiers(mut piece: &str) -> &str {
+    loop {
+        piece = piece.trim_start();
+        if let Some(rest) = piece.strip_prefix("final ") {
+            piece = rest;
+        } else if let Some(annotation) = piece.strip_prefix('@') {
+            let end = annotation
+                .find(|c: char| !(c.is_alphanumeric() || c == '_' || c == '.'))
+                .unwrap_or(annotation.len());
+            let rest = annotation[end..].trim_start();
+            piece = if rest.starts_with('(') {
+                split_parenthesized(rest).map_or(rest, |(_, after)| after)
+            } else {
+                rest
+            };
+        } else {
+            return piece;
+        }
+    }
+}
+
+fn normalize_type(ty: &str) -> String {
+    ty.split_whitespace().collect::<Vec<_>>().join(" ")
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn types(signature: &Signature) -> Vec<String> {
+        signature.params.iter().map(Param::type_text).collect()
+    }
+
+    #[test]
+    fn test_parse_methods_and_constructors() {
+        let signature = parse("int add(int a, int b)").unwrap();
+        assert_eq!(types(&signature), ["int", "int"]);
+        assert_eq!(signature.params[1].name.as_deref(), Some("b"));
+        assert_eq!(signature.results[0].ty, "int");
+
+        let signature = parse("void run()").unwrap();
+        assert!(signature.params.is_empty());
+        assert!(signature.results.is_empty());
+
+        let signature = parse("Point(int x, int y)").unwrap();
+        assert_eq!(types(&signature), ["int", "int"]);
+        assert!(signature.results.is_empty());
+
+        assert!(parse("int count").is_none());
+    }
+
+    #[test]
+    fn test_parse_generics_varargs_and_modifiers() {
+        let signature =
+            parse("Map<String, List<Integer>> index(Map<String, Integer> counts, String... keys)")
+                .unwrap();
+        assert_eq!(signature.results[0].ty, "Map<String, List<Integer>>");
+        assert_eq!(signature.params[0].ty, "Map<String, Integer>");
+        assert_eq!(signature.params[1].ty, "String");
+        assert!(signature.is_variadic());
+
+        let signature = parse(
+            "void save(final @NonNull User user, @Size(max = 3, min = 1) int[] codes, long ids[])",
+        )
+        .unwrap();
+        assert_eq!(types(&signature), ["User", "int[]", "long[]"]);
+        assert_eq!(signature.params[2].name.as_deref(), Some("ids"));
+    }
+}
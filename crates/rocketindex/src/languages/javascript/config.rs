@@ -0,0 +1,22 @@
+//! Comment, test, and generated-code conventions for JavaScript.
+
+use crate::language_config::LanguageConfig;
+
+/// JSDoc `/** */` comments; every function in a `*.test.js` or `*.spec.js`
+/// file or under `__tests__` is a test (test bodies are usually callbacks).
+#[must_use]
+pub fn config() -> LanguageConfig {
+    LanguageConfig::c_style("javascript", &["js", "jsx", "mjs", "cjs"])
+        .with_doc_comments(&["/**"])
+        .with_tests(
+            &[
+                "*.test.js",
+                "*.spec.js",
+                "*.test.jsx",
+                "*.spec.jsx",
+                "**/__tests__/**/*.js",
+            ],
+            None,
+            true,
+        )
+}
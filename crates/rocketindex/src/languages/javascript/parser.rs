@@ -3,8 +3,11 @@
 use std::cell::RefCell;
 use std::path::Path;
 
-use crate::parse::{find_child_by_kind, node_to_location, LanguageParser, ParseResult};
-use crate::{Reference, Symbol, SymbolKind, Visibility};
+use crate::parse::{
+    collect_annotations, find_child_by_kind, node_to_location, record_extents, LanguageParser,
+    ParseResult,
+};
+use crate::{Reference, ReferenceKind, Symbol, SymbolKind, Visibility};
 
 // Thread-local parser reuse - avoids creating a new parser per file
 thread_local! {
@@ -36,6 +39,10 @@ impl LanguageParser for JavaScriptParser {
             let root = tree.root_node();
 
             extract_recursive(&root, source.as_bytes(), file, &mut result, None, max_depth);
+            apply_export_clauses(&root, source.as_bytes(), &mut result);
+            record_extents(&root, &mut result.symbols);
+            result.annotations =
+                collect_annotations(file, &root, source.as_bytes(), &result.symbols);
 
             result
         })
@@ -133,6 +140,54 @@ fn extract_function_signature(node: &tree_sitter::Node, source: &[u8]) -> Option
     None
 }
 
+/// Mark top-level symbols exported via a separate clause as public.
+///
+/// Handles `export { a, b as c }` and `export default a`, where the declaration
+/// itself has no `export` keyword. Re-exports (`export { a } from "./x"`) name
+/// symbols from another module and are ignored.
+fn apply_export_clauses(root: &tree_sitter::Node, source: &[u8], result: &mut ParseResult) {
+    let mut exported: Vec<&str> = Vec::new();
+
+    for i in 0..root.child_count() {
+        let Some(stmt) = root.child(i) else {
+            continue;
+        };
+        if stmt.kind() != "export_statement" || stmt.child_by_field_name("source").is_some() {
+            continue;
+        }
+
+        if let Some(value) = stmt.child_by_field_name("value") {
+            if value.kind() == "identifier" {
+                if let Ok(name) = value.utf8_text(source) {
+                    exported.push(name);
+                }
+            }
+        }
+
+        if let Some(clause) = find_child_by_kind(&stmt, "export_clause") {
+            for j in 0..clause.child_count() {
+                if let Some(spec) = clause.child(j) {
+                    if spec.kind() != "export_specifier" {
+                        continue;
+                    }
+                    if let Some(Ok(name)) = spec
+                        .child_by_field_name("name")
+                        .map(|n| n.utf8_text(source))
+                    {
+                        exported.push(name);
+                    }
+                }
+            }
+        }
+    }
+
+    for symbol in &mut result.symbols {
+        if exported.contains(&symbol.qualified.as_str()) {
+            symbol.visibility = Visibility::Public;
+        }
+    }
+}
+
 /// Build qualified name with . separator
 fn qualified_name(name: &str, parent_path: Option<&str>) -> String {
     match parent_path {
@@ -177,6 +232,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Recurse into class body
@@ -209,6 +265,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature,
+                        extent: None,
                     });
                 }
             }
@@ -247,6 +304,9 @@ fn extract_recursive(
                     result.references.push(Reference {
                         name: name.to_string(),
                         location: node_to_location(file, node),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -259,6 +319,9 @@ fn extract_recursive(
                     result.references.push(Reference {
                         name: name.to_string(),
                         location: node_to_location(file, node),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -307,6 +370,7 @@ fn extract_class_body(
                                 implements: None,
                                 doc,
                                 signature,
+                                extent: None,
                             });
                         }
                     }
@@ -338,6 +402,7 @@ fn extract_class_body(
                                 implements: None,
                                 doc,
                                 signature: None,
+                                extent: None,
                             });
                         }
                     }
@@ -413,6 +478,7 @@ fn extract_variable_declarations(
                                 implements: None,
                                 doc: doc.clone(),
                                 signature,
+                                extent: None,
                             });
                         }
                     }
@@ -481,6 +547,7 @@ fn extract_object_literal_properties(
                             implements: None,
                             doc: None,
                             signature: None,
+                            extent: None,
                         });
                     }
                 }
@@ -503,6 +570,7 @@ fn extract_object_literal_properties(
                                     implements: None,
                                     doc: None,
                                     signature: None,
+                                    extent: None,
                                 });
                             }
                         }
@@ -553,6 +621,7 @@ fn extract_prototype_method_assignment(
                                 implements: None,
                                 doc,
                                 signature,
+                                extent: None,
                             });
                         }
                     }
@@ -865,6 +934,32 @@ export const multiply = (a, b) => a * b;
         assert_eq!(func.kind, SymbolKind::Function);
     }
 
+    #[test]
+    fn export_clause_marks_symbols_public() {
+        let source = r#"
+function helper() {}
+const util = () => 1;
+function internal() {}
+class Service {}
+export { helper, util as utility };
+export default Service;
+"#;
+        let result = extract_symbols(std::path::Path::new("test.js"), source, 100);
+
+        let visibility = |name: &str| {
+            result
+                .symbols
+                .iter()
+                .find(|s| s.name == name)
+                .map(|s| s.visibility)
+                .unwrap_or_else(|| panic!("Should find {}", name))
+        };
+        assert_eq!(visibility("helper"), Visibility::Public);
+        assert_eq!(visibility("util"), Visibility::Public);
+        assert_eq!(visibility("Service"), Visibility::Public);
+        assert_eq!(visibility("internal"), Visibility::Private);
+    }
+
     #[test]
     fn extracts_imports() {
         let source = r#"
@@ -0,0 +1,12 @@
+//! Comment, test, and generated-code conventions for Kotlin.
+
+use crate::language_config::LanguageConfig;
+
+/// KDoc `/** */` comments; every function in `*Test.kt` or `*Tests.kt` is a
+/// test.
+#[must_use]
+pub fn config() -> LanguageConfig {
+    LanguageConfig::c_style("kotlin", &["kt", "kts"])
+        .with_doc_comments(&["/**"])
+        .with_tests(&["*Test.kt", "*Tests.kt"], None, true)
+}
@@ -3,8 +3,11 @@
 use std::cell::RefCell;
 use std::path::Path;
 
-use crate::parse::{find_child_by_kind, node_to_location, LanguageParser, ParseResult};
-use crate::{Reference, Symbol, SymbolKind, Visibility};
+use crate::parse::{
+    collect_annotations, find_child_by_kind, node_to_location, record_extents, LanguageParser,
+    ParseResult,
+};
+use crate::{Reference, ReferenceKind, Symbol, SymbolKind, Visibility};
 
 // Thread-local parser reuse - avoids creating a new parser per file
 thread_local! {
@@ -46,6 +49,9 @@ impl LanguageParser for KotlinParser {
                 package.as_deref(),
                 max_depth,
             );
+            record_extents(&root, &mut result.symbols);
+            result.annotations =
+                collect_annotations(file, &root, source.as_bytes(), &result.symbols);
 
             result
         })
@@ -86,7 +92,7 @@ fn extract_visibility(node: &tree_sitter::Node, source: &[u8]) -> Visibility {
                         return match text {
                             "public" => Visibility::Public,
                             "private" => Visibility::Private,
-                            "protected" => Visibility::Internal,
+                            "protected" => Visibility::Protected,
                             "internal" => Visibility::Internal,
                             _ => Visibility::Public,
                         };
@@ -353,6 +359,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Extract data class constructor params
@@ -412,6 +419,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Recurse into object body
@@ -458,6 +466,7 @@ fn extract_recursive(
                 implements: None,
                 doc: None,
                 signature: None,
+                extent: None,
             });
 
             // Recurse into companion body
@@ -504,6 +513,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature,
+                        extent: None,
                     });
                 }
             }
@@ -532,6 +542,7 @@ fn extract_recursive(
                             implements: None,
                             doc,
                             signature: None,
+                            extent: None,
                         });
                     }
                 }
@@ -558,6 +569,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
                 }
             }
@@ -580,6 +592,9 @@ fn extract_recursive(
                         result.references.push(Reference {
                             name: name.to_string(),
                             location: node_to_location(file, &id),
+                            kind: ReferenceKind::Unknown,
+                            target: None,
+                            arguments: None,
                         });
                     }
                 }
@@ -605,6 +620,9 @@ fn extract_recursive(
                                 result.references.push(Reference {
                                     name: name.to_string(),
                                     location: node_to_location(file, &child),
+                                    kind: ReferenceKind::Unknown,
+                                    target: None,
+                                    arguments: None,
                                 });
                             }
                             break; // Found the callee
@@ -616,6 +634,9 @@ fn extract_recursive(
                                 result.references.push(Reference {
                                     name: full_name.to_string(),
                                     location: node_to_location(file, &child),
+                                    kind: ReferenceKind::Unknown,
+                                    target: None,
+                                    arguments: None,
                                 });
                             }
                             // Also extract just the method name (last part after the dot)
@@ -623,6 +644,9 @@ fn extract_recursive(
                                 result.references.push(Reference {
                                     name: method_name,
                                     location: node_to_location(file, &child),
+                                    kind: ReferenceKind::Unknown,
+                                    target: None,
+                                    arguments: None,
                                 });
                             }
                             break; // Found the callee
@@ -647,6 +671,9 @@ fn extract_recursive(
                         result.references.push(Reference {
                             name: name.to_string(),
                             location: node_to_location(file, node),
+                            kind: ReferenceKind::Unknown,
+                            target: None,
+                            arguments: None,
                         });
                     }
                     // Also extract just the suffix (property name)
@@ -654,6 +681,9 @@ fn extract_recursive(
                         result.references.push(Reference {
                             name: prop_name,
                             location: node_to_location(file, node),
+                            kind: ReferenceKind::Unknown,
+                            target: None,
+                            arguments: None,
                         });
                     }
                 }
@@ -667,6 +697,9 @@ fn extract_recursive(
                     result.references.push(Reference {
                         name: name.to_string(),
                         location: node_to_location(file, node),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -718,6 +751,7 @@ fn extract_primary_constructor_params(
                                     implements: None,
                                     doc: None,
                                     signature: None,
+                                    extent: None,
                                 });
                             }
                         }
@@ -760,6 +794,7 @@ fn extract_enum_entries(
                             implements: None,
                             doc: extract_doc_comments(&child, source),
                             signature: None,
+                            extent: None,
                         });
                     }
                 }
@@ -1102,7 +1137,7 @@ class Example {
             .iter()
             .find(|s| s.name == "protectedMethod")
             .unwrap();
-        assert_eq!(protected.visibility, Visibility::Internal);
+        assert_eq!(protected.visibility, Visibility::Protected);
 
         let internal = result
             .symbols
@@ -0,0 +1,12 @@
+//! Comment, test, and generated-code conventions for Objective-C.
+
+use crate::language_config::LanguageConfig;
+
+/// `///` and `/** */` doc comments; XCTest runs the `test*` methods of
+/// `*Tests.m` files.
+#[must_use]
+pub fn config() -> LanguageConfig {
+    LanguageConfig::c_style("objc", &["m", "mm"])
+        .with_doc_comments(&["///", "/**"])
+        .with_tests(&["*Tests.m", "*Tests.mm"], Some("^test"), true)
+}
@@ -3,8 +3,11 @@
 use std::cell::RefCell;
 use std::path::Path;
 
-use crate::parse::{find_child_by_kind, node_to_location, LanguageParser, ParseResult};
-use crate::{Reference, Symbol, SymbolKind, Visibility};
+use crate::parse::{
+    collect_annotations, find_child_by_kind, node_to_location, record_extents, LanguageParser,
+    ParseResult,
+};
+use crate::{Reference, ReferenceKind, Symbol, SymbolKind, Visibility};
 
 thread_local! {
     static OBJC_PARSER: RefCell<tree_sitter::Parser> = RefCell::new({
@@ -35,6 +38,9 @@ impl LanguageParser for ObjCParser {
             let root = tree.root_node();
 
             extract_recursive(&root, source.as_bytes(), file, &mut result, None, max_depth);
+            record_extents(&root, &mut result.symbols);
+            result.annotations =
+                collect_annotations(file, &root, source.as_bytes(), &result.symbols);
 
             result
         })
@@ -116,6 +122,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Recurse into body to find methods/properties
@@ -158,6 +165,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     extract_class_body(node, source, file, result, &qualified, max_depth - 1);
@@ -202,6 +210,7 @@ fn extract_recursive(
                     implements: None,
                     doc: extract_doc_comments(node, source),
                     signature: None,
+                    extent: None,
                 });
 
                 extract_class_body(node, source, file, result, &qualified, max_depth - 1);
@@ -248,6 +257,7 @@ fn extract_recursive(
                             implements: None,
                             doc,
                             signature: None,
+                            extent: None,
                         });
                     }
                 }
@@ -290,6 +300,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
                 }
             }
@@ -309,6 +320,9 @@ fn extract_recursive(
                         result.references.push(Reference {
                             name: name.to_string(),
                             location: node_to_location(file, &func_node),
+                            kind: ReferenceKind::Unknown,
+                            target: None,
+                            arguments: None,
                         });
                     }
                 }
@@ -322,6 +336,9 @@ fn extract_recursive(
                     result.references.push(Reference {
                         name: name.to_string(),
                         location: node_to_location(file, node),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -334,6 +351,9 @@ fn extract_recursive(
                 result.references.push(Reference {
                     name: name.to_string(),
                     location: node_to_location(file, node),
+                    kind: ReferenceKind::Unknown,
+                    target: None,
+                    arguments: None,
                 });
             }
         }
@@ -423,6 +443,9 @@ fn extract_class_body(
                         result.references.push(Reference {
                             name: name.to_string(),
                             location: node_to_location(file, &child),
+                            kind: ReferenceKind::Unknown,
+                            target: None,
+                            arguments: None,
                         });
                     }
                 }
@@ -436,6 +459,9 @@ fn extract_class_body(
                                 result.references.push(Reference {
                                     name: name.to_string(),
                                     location: node_to_location(file, &child),
+                                    kind: ReferenceKind::Unknown,
+                                    target: None,
+                                    arguments: None,
                                 });
                             }
                         }
@@ -532,6 +558,7 @@ fn extract_method(
             implements: None,
             doc,
             signature: None,
+            extent: None,
         });
     }
 }
@@ -634,6 +661,9 @@ fn extract_message_reference(
                                 result.references.push(Reference {
                                     name: text.to_string(),
                                     location: node_to_location(file, &child),
+                                    kind: ReferenceKind::Unknown,
+                                    target: None,
+                                    arguments: None,
                                 });
                             }
                         } else {
@@ -641,6 +671,9 @@ fn extract_message_reference(
                             result.references.push(Reference {
                                 name: text.to_string(),
                                 location: node_to_location(file, &child),
+                                kind: ReferenceKind::Unknown,
+                                target: None,
+                                arguments: None,
                             });
                         }
                     }
@@ -659,6 +692,9 @@ fn extract_message_reference(
                             result.references.push(Reference {
                                 name: text.to_string(),
                                 location: node_to_location(file, &name_node),
+                                kind: ReferenceKind::Unknown,
+                                target: None,
+                                arguments: None,
                             });
                         }
                     }
@@ -702,6 +738,7 @@ fn extract_property(
                     implements: None,
                     doc,
                     signature: None,
+                    extent: None,
                 });
             }
         }
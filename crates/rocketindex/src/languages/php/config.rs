@@ -0,0 +1,13 @@
+//! Comment, test, and generated-code conventions for PHP.
+
+use crate::language_config::LanguageConfig;
+
+/// `//`, `#`, and `/* */` comments, PHPDoc `/** */` doc comments; PHPUnit
+/// runs the `test*` methods of `*Test.php` files.
+#[must_use]
+pub fn config() -> LanguageConfig {
+    LanguageConfig::c_style("php", &["php"])
+        .with_comments(&["//", "#"], Some(("/*", "*/")))
+        .with_doc_comments(&["/**"])
+        .with_tests(&["*Test.php"], Some("^test"), true)
+}
@@ -3,8 +3,11 @@
 use std::cell::RefCell;
 use std::path::Path;
 
-use crate::parse::{find_child_by_kind, node_to_location, LanguageParser, ParseResult};
-use crate::{Reference, Symbol, SymbolKind, Visibility};
+use crate::parse::{
+    collect_annotations, find_child_by_kind, node_to_location, record_extents, LanguageParser,
+    ParseResult,
+};
+use crate::{Reference, ReferenceKind, Symbol, SymbolKind, Visibility};
 
 // Thread-local parser reuse - avoids creating a new parser per file
 thread_local! {
@@ -46,6 +49,9 @@ impl LanguageParser for PhpParser {
 
             // Extract references in a separate pass
             extract_references_recursive(&root, source.as_bytes(), file, &mut result);
+            record_extents(&root, &mut result.symbols);
+            result.annotations =
+                collect_annotations(file, &root, source.as_bytes(), &result.symbols);
 
             result
         })
@@ -61,7 +67,7 @@ fn extract_visibility(node: &tree_sitter::Node, source: &[u8]) -> Visibility {
                 if let Ok(text) = child.utf8_text(source) {
                     return match text {
                         "public" => Visibility::Public,
-                        "protected" => Visibility::Internal,
+                        "protected" => Visibility::Protected,
                         "private" => Visibility::Private,
                         _ => Visibility::Public,
                     };
@@ -326,6 +332,7 @@ fn extract_recursive(
                         implements: interfaces,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Recurse into class body
@@ -371,6 +378,7 @@ fn extract_recursive(
                         implements: parent_interfaces,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Recurse into interface body
@@ -413,6 +421,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Recurse into trait body
@@ -455,6 +464,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Recurse into enum body for cases
@@ -502,6 +512,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature,
+                        extent: None,
                     });
                 }
             }
@@ -529,6 +540,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature,
+                        extent: None,
                     });
                 }
             }
@@ -561,6 +573,7 @@ fn extract_recursive(
                                     implements: None,
                                     doc,
                                     signature: None,
+                                    extent: None,
                                 });
                             }
                         }
@@ -594,6 +607,7 @@ fn extract_recursive(
                                     implements: None,
                                     doc,
                                     signature: None,
+                                    extent: None,
                                 });
                             }
                         }
@@ -682,6 +696,7 @@ fn extract_enum_case(
                         implements: None,
                         doc: extract_doc_comments(node, source),
                         signature: None,
+                        extent: None,
                     });
                     break;
                 }
@@ -705,6 +720,9 @@ fn extract_references_recursive(
                     result.references.push(Reference {
                         name: name.to_string(),
                         location: node_to_location(file, node),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -716,6 +734,9 @@ fn extract_references_recursive(
                     result.references.push(Reference {
                         name: name.to_string(),
                         location: node_to_location(file, node),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     });
                 }
                 return; // Don't recurse into qualified_name children
@@ -1023,7 +1044,7 @@ class User {
             .iter()
             .find(|s| s.name == "age")
             .expect("Should find age property");
-        assert_eq!(age_prop.visibility, Visibility::Internal);
+        assert_eq!(age_prop.visibility, Visibility::Protected);
     }
 
     #[test]
@@ -1102,7 +1123,7 @@ class Example {
             .iter()
             .find(|s| s.name == "protectedMethod")
             .unwrap();
-        assert_eq!(protected.visibility, Visibility::Internal);
+        assert_eq!(protected.visibility, Visibility::Protected);
 
         let private = result
             .symbols
@@ -10,7 +10,7 @@ use std::path::Path;
 
 use crate::parse::ParseResult;
 use crate::resolve::{ResolutionPath, ResolveResult, SymbolResolver};
-use crate::{CodeIndex, Reference, SymbolKind};
+use crate::{CodeIndex, Reference, ReferenceKind, SymbolKind};
 
 pub struct PhpResolver;
 
@@ -160,6 +160,9 @@ impl PhpResolver {
                 references.push(Reference {
                     name: parent.clone(),
                     location: symbol.location.clone(),
+                    kind: ReferenceKind::Unknown,
+                    target: None,
+                    arguments: None,
                 });
             }
 
@@ -169,6 +172,9 @@ impl PhpResolver {
                     references.push(Reference {
                         name: iface.clone(),
                         location: symbol.location.clone(),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -179,6 +185,9 @@ impl PhpResolver {
                     references.push(Reference {
                         name: trait_name.clone(),
                         location: symbol.location.clone(),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -195,6 +204,9 @@ impl PhpResolver {
                     end_line: 1,
                     end_column: 1,
                 },
+                kind: ReferenceKind::Unknown,
+                target: None,
+                arguments: None,
             });
         }
 
@@ -0,0 +1,15 @@
+//! Comment, test, and generated-code conventions for Python.
+
+use crate::language_config::LanguageConfig;
+
+/// Doc comments are docstrings, which the parser reads from the body;
+/// pytest runs the `test*` functions and methods of `test_*.py` and
+/// `*_test.py` files.
+#[must_use]
+pub fn config() -> LanguageConfig {
+    LanguageConfig::hash_style("python", &["py", "pyi"]).with_tests(
+        &["test_*.py", "*_test.py"],
+        Some("^test"),
+        true,
+    )
+}
@@ -1,5 +1,8 @@
+pub mod config;
 pub mod parser;
 pub mod resolver;
+pub mod testing;
 
 pub use parser::PythonParser;
 pub use resolver::PythonResolver;
+pub use testing::PythonTestDetector;
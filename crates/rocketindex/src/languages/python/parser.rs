@@ -3,8 +3,11 @@
 use std::cell::RefCell;
 use std::path::Path;
 
-use crate::parse::{find_child_by_kind, node_to_location, LanguageParser, ParseResult};
-use crate::{Reference, Symbol, SymbolKind, Visibility};
+use crate::parse::{
+    collect_annotations, find_child_by_kind, node_to_location, record_extents, LanguageParser,
+    ParseResult,
+};
+use crate::{Reference, ReferenceKind, Symbol, SymbolKind, Visibility};
 
 // Thread-local parser reuse - avoids creating a new parser per file
 thread_local! {
@@ -36,6 +39,9 @@ impl LanguageParser for PythonParser {
             let root = tree.root_node();
 
             extract_recursive(&root, source.as_bytes(), file, &mut result, None, max_depth);
+            record_extents(&root, &mut result.symbols);
+            result.annotations =
+                collect_annotations(file, &root, source.as_bytes(), &result.symbols);
 
             result
         })
@@ -244,6 +250,7 @@ fn extract_class_attribute(
                         implements: None,
                         doc: None,
                         signature: None,
+                        extent: None,
                     });
                 }
             }
@@ -268,11 +275,11 @@ fn extract_recursive(
             // Extract decorators and then process the actual definition
             let decorators = extract_decorators(node, source);
 
-            // Find the class_definition or function_definition child
             for i in 0..node.child_count() {
                 if let Some(child) = node.child(i) {
                     let kind = child.kind();
-                    if kind == "class_definition" {
+                    if kind == "class_definition" || kind == "function_definition" {
+                        // The definition handles its own recursion
                         extract_definition_with_decorators(
                             &child,
                             source,
@@ -282,22 +289,20 @@ fn extract_recursive(
                             max_depth,
                             Some(&decorators),
                         );
-                        return; // Class handles its own recursion
-                    } else if kind == "function_definition" {
-                        extract_definition_with_decorators(
+                    } else {
+                        // Decorator expressions are references
+                        extract_recursive(
                             &child,
                             source,
                             file,
                             result,
                             current_module,
-                            max_depth,
-                            Some(&decorators),
+                            max_depth - 1,
                         );
-                        // Continue to recurse for references (don't return)
-                        break;
                     }
                 }
             }
+            return;
         }
 
         "class_definition" => {
@@ -323,7 +328,7 @@ fn extract_recursive(
                 max_depth,
                 None,
             );
-            // Don't return - continue to recurse into function body for references
+            return; // Function handles its own recursion
         }
 
         "assignment" => {
@@ -349,6 +354,7 @@ fn extract_recursive(
                                 implements: None,
                                 doc: None,
                                 signature: None,
+                                extent: None,
                             });
                         }
                     }
@@ -421,6 +427,9 @@ fn extract_recursive(
                     result.references.push(Reference {
                         name: name.to_string(),
                         location: node_to_location(file, node),
+                        kind: reference_kind(node),
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -434,6 +443,9 @@ fn extract_recursive(
                     result.references.push(Reference {
                         name: name.to_string(),
                         location: node_to_location(file, node),
+                        kind: reference_kind(node),
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -493,6 +505,7 @@ fn extract_definition_with_decorators(
                         implements,
                         doc,
                         signature,
+                        extent: None,
                     });
 
                     // Process class body
@@ -535,12 +548,8 @@ fn extract_definition_with_decorators(
                         Visibility::Public
                     };
 
-                    // Use dot separator for methods, like Python conventions
-                    let separator = ".";
-                    let qualified = match current_module {
-                        Some(m) => format!("{}{}{}", m, separator, name),
-                        None => name.to_string(),
-                    };
+                    // Methods and nested functions are dot-qualified: Class.method, outer.inner
+                    let qualified = qualified_name(name, current_module);
 
                     // Extract signature and docstring
                     let signature = extract_function_signature(node, source, name);
@@ -548,7 +557,7 @@ fn extract_definition_with_decorators(
 
                     result.symbols.push(Symbol {
                         name: name.to_string(),
-                        qualified,
+                        qualified: qualified.clone(),
                         kind: SymbolKind::Function,
                         location: node_to_location(file, &name_node),
                         visibility,
@@ -559,7 +568,23 @@ fn extract_definition_with_decorators(
                         implements: None,
                         doc,
                         signature,
+                        extent: None,
                     });
+
+                    // Recurse with the function as scope so nested definitions
+                    // are qualified under it; the body yields references
+                    for i in 0..node.child_count() {
+                        if let Some(child) = node.child(i) {
+                            extract_recursive(
+                                &child,
+                                source,
+                                file,
+                                result,
+                                Some(&qualified),
+                                max_depth - 1,
+                            );
+                        }
+                    }
                 }
             }
         }
@@ -568,6 +593,21 @@ fn extract_definition_with_decorators(
     }
 }
 
+/// Classify an identifier or attribute reference: the callee of a call
+/// expression is a [`ReferenceKind::Call`].
+fn reference_kind(node: &tree_sitter::Node) -> ReferenceKind {
+    let is_callee = node
+        .parent()
+        .filter(|parent| parent.kind() == "call")
+        .and_then(|call| call.child_by_field_name("function"))
+        .is_some_and(|function| function.id() == node.id());
+    if is_callee {
+        ReferenceKind::Call
+    } else {
+        ReferenceKind::Unknown
+    }
+}
+
 /// Maximum recursion depth for helper functions to prevent stack overflow
 const MAX_HELPER_DEPTH: usize = 50;
 
@@ -1238,4 +1278,196 @@ def main():
             ref_names
         );
     }
+
+    #[test]
+    fn extracts_nested_functions_with_qualified_names() {
+        let source = r#"
+def outer():
+    def inner():
+        def innermost():
+            pass
+        return innermost()
+    return inner()
+
+class Service:
+    def run(self):
+        def step():
+            pass
+        step()
+"#;
+        let result = extract_symbols(std::path::Path::new("test.py"), source, 100);
+
+        let qualified: Vec<&str> = result
+            .symbols
+            .iter()
+            .map(|s| s.qualified.as_str())
+            .collect();
+        for expected in [
+            "outer",
+            "outer.inner",
+            "outer.inner.innermost",
+            "Service",
+            "Service.run",
+            "Service.run.step",
+        ] {
+            assert!(
+                qualified.contains(&expected),
+                "missing {}: {:?}",
+                expected,
+                qualified
+            );
+        }
+        assert!(!qualified.contains(&"inner"));
+    }
+
+    #[test]
+    fn extracts_decorated_functions_once() {
+        let source = r#"
+@app.route("/users")
+@login_required
+def list_users():
+    return render()
+
+class Api:
+    @staticmethod
+    def version():
+        pass
+"#;
+        let result = extract_symbols(std::path::Path::new("test.py"), source, 100);
+
+        let list_users: Vec<_> = result
+            .symbols
+            .iter()
+            .filter(|s| s.name == "list_users")
+            .collect();
+        assert_eq!(list_users.len(), 1, "{:?}", list_users);
+        assert_eq!(
+            list_users[0].attributes,
+            Some(vec![
+                "app.route(\"/users\")".to_string(),
+                "login_required".to_string()
+            ])
+        );
+
+        let version: Vec<_> = result
+            .symbols
+            .iter()
+            .filter(|s| s.name == "version")
+            .collect();
+        assert_eq!(version.len(), 1);
+        assert_eq!(version[0].qualified, "Api.version");
+        assert_eq!(
+            version[0].attributes,
+            Some(vec!["staticmethod".to_string()])
+        );
+
+        // Decorators are still references
+        assert!(result.references.iter().any(|r| r.name == "login_required"));
+    }
+
+    #[test]
+    fn marks_call_references() {
+        let source = r#"
+def main(items):
+    total = len(items)
+    self.client.get(total)
+    return items
+"#;
+        let result = extract_symbols(std::path::Path::new("test.py"), source, 100);
+
+        let kind_of = |name: &str| {
+            result
+                .references
+                .iter()
+                .find(|r| r.name == name)
+                .map(|r| r.kind)
+        };
+        assert_eq!(kind_of("len"), Some(ReferenceKind::Call));
+        assert_eq!(kind_of("self.client.get"), Some(ReferenceKind::Call));
+        assert_eq!(kind_of("items"), Some(ReferenceKind::Unknown));
+        assert_eq!(kind_of("total"), Some(ReferenceKind::Unknown));
+    }
+
+    #[test]
+    fn handles_tab_and_mixed_indentation() {
+        // Tabs in one class, spaces in another, tabs inside a space-indented block
+        let source = "class Tabbed:\n\tdef method(self):\n\t\tdef inner():\n\t\t\treturn helper()\n\t\treturn inner()\n\nclass Spaced:\n    def method(self):\n\tpass\n\ndef after():\n    pass\n";
+        let result = extract_symbols(std::path::Path::new("test.py"), source, 100);
+
+        let find = |qualified: &str| {
+            result
+                .symbols
+                .iter()
+                .find(|s| s.qualified == qualified)
+                .unwrap_or_else(|| panic!("missing {}: {:?}", qualified, result.symbols))
+        };
+        assert_eq!(find("Tabbed.method").location.line, 2);
+        let inner = find("Tabbed.method.inner");
+        assert_eq!(inner.location.line, 3);
+        // Columns count bytes, so each tab is one column
+        assert_eq!(inner.location.column, 7);
+        assert_eq!(find("Spaced.method").location.line, 8);
+        assert_eq!(find("after").location.line, 11);
+        assert!(result.errors.is_empty(), "{:?}", result.errors);
+    }
+
+    #[test]
+    fn python_call_graph_records_unresolved_calls() {
+        use crate::callgraph::CallGraph;
+        use crate::CodeIndex;
+
+        let source = r#"
+def process(items):
+    return len(items)
+
+def main():
+    items = load()
+    process(items)
+    print(items)
+"#;
+        let result = extract_symbols(std::path::Path::new("app.py"), source, 100);
+        let mut index = CodeIndex::new();
+        index.replace_file(std::path::Path::new("app.py"), &result);
+        let graph = CallGraph::build(&index);
+
+        let callers: Vec<&str> = graph
+            .callers("process")
+            .iter()
+            .map(|site| site.caller.as_str())
+            .collect();
+        assert_eq!(callers, vec!["main"]);
+
+        let unresolved: Vec<(&str, &str)> = graph
+            .unresolved_calls()
+            .iter()
+            .map(|call| (call.caller.as_str(), call.callee.as_str()))
+            .collect();
+        assert_eq!(
+            unresolved,
+            vec![("process", "len"), ("main", "load"), ("main", "print")]
+        );
+    }
+
+    #[test]
+    fn extracts_annotations_from_hash_comments() {
+        let source = r#"# TODO(alice): drop python 2 support
+class Calculator:
+    def add(self, a, b):
+        # FIXME: overflow
+        return a + b
+"#;
+        let result = extract_symbols(std::path::Path::new("calc.py"), source, 100);
+
+        assert_eq!(result.annotations.len(), 2);
+        let todo = &result.annotations[0];
+        assert_eq!(todo.author.as_deref(), Some("alice"));
+        assert_eq!(todo.text, "drop python 2 support");
+        assert_eq!((todo.location.line, todo.location.column), (1, 3));
+        assert_eq!(todo.symbol, None);
+
+        let fixme = &result.annotations[1];
+        assert_eq!(fixme.text, "overflow");
+        assert_eq!((fixme.location.line, fixme.location.column), (4, 11));
+        assert_eq!(fixme.symbol.as_deref(), Some("Calculator.add"));
+    }
 }
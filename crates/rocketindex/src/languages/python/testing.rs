@@ -0,0 +1,56 @@
+//! Test function detection for Python.
+
+use std::path::Path;
+
+use crate::coverage::TestDetector;
+use crate::language_config::{LanguageConfig, LanguageConfigs};
+use crate::Symbol;
+
+/// pytest conventions: `test*` functions and methods in `test_*.py` or
+/// `*_test.py` files, as declared in the built-in Python [`LanguageConfig`].
+pub struct PythonTestDetector;
+
+fn python_config() -> &'static LanguageConfig {
+    LanguageConfigs::builtin()
+        .get("python")
+        .expect("built-in Python config")
+}
+
+impl TestDetector for PythonTestDetector {
+    fn is_test_file(&self, path: &Path) -> bool {
+        python_config().is_test_file(path)
+    }
+
+    fn is_test(&self, symbol: &Symbol) -> bool {
+        python_config().is_test(symbol)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::{Location, SymbolKind, Visibility};
+    use std::path::PathBuf;
+
+    fn function(name: &str, file: &str) -> Symbol {
+        Symbol::new(
+            name.to_string(),
+            format!("tests.{}", name),
+            SymbolKind::Function,
+            Location::new(PathBuf::from(file), 1, 5),
+            Visibility::Public,
+            "python".to_string(),
+        )
+    }
+
+    #[test]
+    fn test_python_test_functions() {
+        let detector = PythonTestDetector;
+        assert!(detector.is_test(&function("test_login", "tests/test_auth.py")));
+        assert!(detector.is_test(&function("test_login", "auth_test.py")));
+
+        assert!(!detector.is_test(&function("login", "tests/test_auth.py")));
+        assert!(!detector.is_test(&function("test_login", "auth.py")));
+        assert!(!detector.is_test(&function("test_login", "testing.py")));
+    }
+}
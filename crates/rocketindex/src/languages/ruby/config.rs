@@ -0,0 +1,12 @@
+//! Comment, test, and generated-code conventions for Ruby.
+
+use crate::language_config::LanguageConfig;
+
+/// Any `#` comment directly above a declaration documents it (RDoc); every
+/// method in `*_test.rb`, `test_*.rb`, or `*_spec.rb` is a test.
+#[must_use]
+pub fn config() -> LanguageConfig {
+    LanguageConfig::hash_style("ruby", &["rb"])
+        .with_doc_comments(&["#"])
+        .with_tests(&["*_test.rb", "test_*.rb", "*_spec.rb"], None, true)
+}
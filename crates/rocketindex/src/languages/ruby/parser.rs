@@ -3,8 +3,11 @@
 use std::cell::RefCell;
 use std::path::Path;
 
-use crate::parse::{find_child_by_kind, node_to_location, LanguageParser, ParseResult};
-use crate::{Reference, Symbol, SymbolKind, Visibility};
+use crate::parse::{
+    collect_annotations, find_child_by_kind, node_to_location, record_extents, LanguageParser,
+    ParseResult,
+};
+use crate::{Reference, ReferenceKind, Symbol, SymbolKind, Visibility};
 
 // Thread-local parser reuse - avoids creating a new parser per file
 thread_local! {
@@ -36,6 +39,9 @@ impl LanguageParser for RubyParser {
             let root = tree.root_node();
 
             extract_recursive(&root, source.as_bytes(), file, &mut result, None, max_depth);
+            record_extents(&root, &mut result.symbols);
+            result.annotations =
+                collect_annotations(file, &root, source.as_bytes(), &result.symbols);
 
             result
         })
@@ -159,7 +165,7 @@ impl From<VisibilityState> for Visibility {
         match state {
             VisibilityState::Public => Visibility::Public,
             VisibilityState::Private => Visibility::Private,
-            VisibilityState::Protected => Visibility::Internal, // Ruby protected maps to Internal
+            VisibilityState::Protected => Visibility::Protected,
         }
     }
 }
@@ -246,6 +252,7 @@ fn extract_recursive_inner(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Process children with this module context
@@ -334,6 +341,7 @@ fn extract_recursive_inner(
                         implements: None,
                         doc,
                         signature,
+                        extent: None,
                     });
                 }
             }
@@ -354,6 +362,9 @@ fn extract_recursive_inner(
                         result.references.push(Reference {
                             name: text.to_string(),
                             location: node_to_location(file, node),
+                            kind: ReferenceKind::Unknown,
+                            target: None,
+                            arguments: None,
                         });
                     }
                 }
@@ -416,6 +427,7 @@ fn extract_recursive_inner(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
                 }
             }
@@ -440,6 +452,7 @@ fn extract_recursive_inner(
                             implements: None,
                             doc: None,
                             signature: None,
+                            extent: None,
                         });
 
                         // Check if this is a Struct.new or similar pattern with a block
@@ -507,6 +520,7 @@ fn extract_recursive_inner(
                     implements: None,
                     doc: None,
                     signature: None,
+                    extent: None,
                 });
             }
         }
@@ -576,6 +590,7 @@ fn extract_recursive_inner(
                                                 implements: None,
                                                 doc: None,
                                                 signature: None,
+                                                extent: None,
                                             });
                                             // Only take the first argument (the new alias name)
                                             break;
@@ -628,6 +643,7 @@ fn extract_recursive_inner(
                                             implements: None,
                                             doc: None,
                                             signature: None,
+                                            extent: None,
                                         });
                                     }
                                 }
@@ -665,6 +681,7 @@ fn extract_recursive_inner(
                                                 implements: None,
                                                 doc: None,
                                                 signature: None,
+                                                extent: None,
                                             });
                                             // Only take the first symbol argument
                                             break;
@@ -778,6 +795,7 @@ fn extract_recursive_inner(
                                     implements: None,
                                     doc: None,
                                     signature: None,
+                                    extent: None,
                                 });
                             }
                         }
@@ -816,6 +834,7 @@ fn extract_recursive_inner(
                                                 implements: None,
                                                 doc: None,
                                                 signature: None,
+                                                extent: None,
                                             });
                                             // Only take the first symbol argument
                                             break;
@@ -860,6 +879,7 @@ fn extract_recursive_inner(
                                                 implements: None,
                                                 doc: None,
                                                 signature: None,
+                                                extent: None,
                                             });
                                             // Only take the first symbol argument
                                             break;
@@ -892,6 +912,9 @@ fn extract_recursive_inner(
                                             result.references.push(Reference {
                                                 name: method_name,
                                                 location: node_to_location(file, &arg),
+                                                kind: ReferenceKind::Unknown,
+                                                target: None,
+                                                arguments: None,
                                             });
                                             // Only take the first symbol (method name)
                                             break;
@@ -916,6 +939,9 @@ fn extract_recursive_inner(
                                             result.references.push(Reference {
                                                 name: method_name,
                                                 location: node_to_location(file, &arg),
+                                                kind: ReferenceKind::Unknown,
+                                                target: None,
+                                                arguments: None,
                                             });
                                             // Only take the first symbol
                                             break;
@@ -941,6 +967,9 @@ fn extract_recursive_inner(
                         result.references.push(Reference {
                             name: method_name,
                             location: node_to_location(file, &method),
+                            kind: ReferenceKind::Unknown,
+                            target: None,
+                            arguments: None,
                         });
                     }
                 }
@@ -954,6 +983,9 @@ fn extract_recursive_inner(
                     result.references.push(Reference {
                         name: name.to_string(),
                         location: node_to_location(file, node),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -966,6 +998,9 @@ fn extract_recursive_inner(
                     result.references.push(Reference {
                         name: name.to_string(),
                         location: node_to_location(file, node),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -1735,7 +1770,7 @@ end
             .unwrap();
         assert_eq!(
             protected_method.visibility,
-            Visibility::Internal, // Using Internal for protected
+            Visibility::Protected,
             "Methods after 'protected' should be protected"
         );
 
@@ -0,0 +1,12 @@
+//! Comment, test, and generated-code conventions for Rust.
+
+use crate::language_config::LanguageConfig;
+
+/// `///` and `/** */` doc comments; every function under a `tests/`
+/// directory is a test (`#[test]` functions are not told apart by name).
+#[must_use]
+pub fn config() -> LanguageConfig {
+    LanguageConfig::c_style("rust", &["rs"])
+        .with_doc_comments(&["///", "/**"])
+        .with_tests(&["**/tests/**/*.rs"], None, true)
+}
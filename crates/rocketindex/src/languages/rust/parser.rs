@@ -3,8 +3,11 @@
 use std::cell::RefCell;
 use std::path::Path;
 
-use crate::parse::{find_child_by_kind, node_to_location, LanguageParser, ParseResult};
-use crate::{Reference, Symbol, SymbolKind, Visibility};
+use crate::parse::{
+    collect_annotations, find_child_by_kind, node_to_location, record_extents, LanguageParser,
+    ParseResult,
+};
+use crate::{Reference, ReferenceKind, Symbol, SymbolKind, Visibility};
 
 // Thread-local parser reuse - avoids creating a new parser per file
 thread_local! {
@@ -45,6 +48,9 @@ impl LanguageParser for RustParser {
             let root = tree.root_node();
 
             extract_recursive(&root, source.as_bytes(), file, &mut result, None, max_depth);
+            record_extents(&root, &mut result.symbols);
+            result.annotations =
+                collect_annotations(file, &root, source.as_bytes(), &result.symbols);
 
             result
         })
@@ -220,6 +226,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Recurse into module body
@@ -265,6 +272,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature,
+                        extent: None,
                     });
                 }
             }
@@ -290,6 +298,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
                 }
             }
@@ -315,6 +324,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Extract enum variants
@@ -345,6 +355,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Extract trait methods
@@ -390,6 +401,8 @@ fn extract_recursive(
                                     result,
                                     &impl_path,
                                     trait_name.as_deref(),
+                                    parent_path,
+                                    max_depth - 1,
                                 );
                             } else if child.kind() == "const_item" || child.kind() == "type_item" {
                                 extract_recursive(
@@ -428,6 +441,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
                 }
             }
@@ -453,6 +467,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
                 }
             }
@@ -478,6 +493,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
                 }
             }
@@ -502,6 +518,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
                 }
             }
@@ -513,15 +530,50 @@ fn extract_recursive(
         }
 
         "macro_invocation" => {
+            // Inside a function a macro is a call we cannot expand: record it
+            // as written (`println!`) so it shows up as an unresolved call,
+            // or resolves to a `macro_rules!` in the workspace via the target.
+            let in_function = has_ancestor_kind(node, "function_item");
+            if let Some(path) = node.child_by_field_name("macro").filter(|_| in_function) {
+                if let Ok(name) = path.utf8_text(source) {
+                    result.references.push(Reference {
+                        name: format!("{}!", name),
+                        location: node_to_location(file, &path),
+                        kind: ReferenceKind::Call,
+                        target: Some(name.to_string()),
+                        arguments: None,
+                    });
+                }
+            }
+
             // Handle macro invocations like cfg_rt! { pub fn spawn(...) }
             // The content inside braces is tokenized, not parsed as items.
             // We need to re-parse the token_tree content as Rust source.
             if let Some(token_tree) = find_child_by_kind(node, "token_tree") {
+                if in_function {
+                    extract_macro_argument_calls(&token_tree, source, file, result);
+                }
                 extract_from_macro_body(&token_tree, source, file, result, parent_path, max_depth);
             }
             return; // Don't recurse normally - we've handled the content
         }
 
+        // `value.method(...)`: the field is not an identifier, so record the
+        // whole receiver path as the callee
+        "field_expression" if is_call_target(node) => {
+            if let Ok(name) = node.utf8_text(source) {
+                if is_plain_path(name) {
+                    result.references.push(Reference {
+                        name: name.to_string(),
+                        location: node_to_location(file, node),
+                        kind: ReferenceKind::Call,
+                        target: None,
+                        arguments: None,
+                    });
+                }
+            }
+        }
+
         // Extract references from identifiers and type identifiers
         "identifier" | "type_identifier" | "scoped_identifier" => {
             if is_reference_context(node) {
@@ -529,6 +581,13 @@ fn extract_recursive(
                     result.references.push(Reference {
                         name: name.to_string(),
                         location: node_to_location(file, node),
+                        kind: if is_call_target(node) {
+                            ReferenceKind::Call
+                        } else {
+                            ReferenceKind::Unknown
+                        },
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -545,6 +604,109 @@ fn extract_recursive(
     }
 }
 
+/// Whether `node` is the function being called: `f(..)`, `Type::new(..)`,
+/// `value.method(..)`, or the same with turbofish (`parse::<T>(..)`).
+fn is_call_target(node: &tree_sitter::Node) -> bool {
+    let Some(parent) = node.parent() else {
+        return false;
+    };
+    let is_function_of = |call: &tree_sitter::Node, callee: &tree_sitter::Node| {
+        call.kind() == "call_expression"
+            && call
+                .child_by_field_name("function")
+                .is_some_and(|f| f.id() == callee.id())
+    };
+    if is_function_of(&parent, node) {
+        return true;
+    }
+    parent.kind() == "generic_function"
+        && parent
+            .child_by_field_name("function")
+            .is_some_and(|f| f.id() == node.id())
+        && parent
+            .parent()
+            .is_some_and(|call| is_function_of(&call, &parent))
+}
+
+/// `self.items.push` or `user.name` but not `make().build` or `v[0].len`.
+fn is_plain_path(text: &str) -> bool {
+    !text.is_empty()
+        && text
+            .split('.')
+            .all(|part| !part.is_empty() && part.chars().all(|c| c.is_alphanumeric() || c == '_'))
+}
+
+fn has_ancestor_kind(node: &tree_sitter::Node, kind: &str) -> bool {
+    let mut current = node.parent();
+    while let Some(parent) = current {
+        if parent.kind() == kind {
+            return true;
+        }
+        current = parent.parent();
+    }
+    false
+}
+
+/// Record calls written inside macro arguments, like `helper()` and
+/// `user.full_info()` in `println!("{} {}", helper(), user.full_info())`.
+///
+/// Macro arguments are an unparsed token tree, so a call is recognized as a
+/// path of identifiers joined by `.` or `::` followed by a `(...)` group.
+fn extract_macro_argument_calls(
+    token_tree: &tree_sitter::Node,
+    source: &[u8],
+    file: &Path,
+    result: &mut ParseResult,
+) {
+    // The path being read: its start node and text so far
+    let mut path: Option<(tree_sitter::Node, String)> = None;
+    // Whether the last token was a `.` or `::` continuing `path`
+    let mut joined = false;
+
+    for i in 0..token_tree.child_count() {
+        let Some(child) = token_tree.child(i) else {
+            continue;
+        };
+        match child.kind() {
+            "identifier" | "self" => {
+                let text = child.utf8_text(source).unwrap_or_default();
+                path = match path.take() {
+                    Some((start, mut name)) if joined => {
+                        name.push_str(text);
+                        Some((start, name))
+                    }
+                    _ => Some((child, text.to_string())),
+                };
+                joined = false;
+            }
+            "." | "::" if path.is_some() && !joined => {
+                if let Some((_, name)) = path.as_mut() {
+                    name.push_str(child.kind());
+                }
+                joined = true;
+            }
+            "token_tree" => {
+                let is_call = child.utf8_text(source).is_ok_and(|t| t.starts_with('('));
+                if let Some((start, name)) = path.take().filter(|_| is_call && !joined) {
+                    result.references.push(Reference {
+                        name,
+                        location: node_to_location(file, &start),
+                        kind: ReferenceKind::Call,
+                        target: None,
+                        arguments: None,
+                    });
+                }
+                joined = false;
+                extract_macro_argument_calls(&child, source, file, result);
+            }
+            _ => {
+                path = None;
+                joined = false;
+            }
+        }
+    }
+}
+
 /// Extract symbols from macro body content by re-parsing as Rust source.
 /// Macro invocations like `cfg_rt! { pub fn spawn(...) }` have their body
 /// tokenized but not parsed. We extract the inner content and parse it.
@@ -801,6 +963,7 @@ fn extract_from_reparsed_tree(
                         implements: None,
                         doc,
                         signature,
+                        extent: None,
                     });
                 }
             }
@@ -829,6 +992,7 @@ fn extract_from_reparsed_tree(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
                 }
             }
@@ -857,6 +1021,7 @@ fn extract_from_reparsed_tree(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
                 }
             }
@@ -885,6 +1050,7 @@ fn extract_from_reparsed_tree(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
                 }
             }
@@ -943,6 +1109,7 @@ fn extract_from_reparsed_tree(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Recurse into module body
@@ -991,6 +1158,7 @@ fn extract_from_reparsed_tree(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
                 }
             }
@@ -1019,6 +1187,7 @@ fn extract_from_reparsed_tree(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
                 }
             }
@@ -1080,6 +1249,7 @@ fn extract_enum_variants(
                             implements: None,
                             doc: extract_doc_comments(&child, source),
                             signature: None,
+                            extent: None,
                         });
                     }
                 }
@@ -1203,14 +1373,21 @@ fn extract_impl_trait_name(node: &tree_sitter::Node, source: &[u8]) -> Option<St
     None
 }
 
-/// Extract a method from an impl block
+/// Extract a method from an impl block, then the references in its
+/// parameters and body.
+///
+/// Methods of `impl Trait for Type` are qualified under the type and list the
+/// trait in `implements`.
+#[allow(clippy::too_many_arguments)]
 fn extract_impl_method(
     node: &tree_sitter::Node,
     source: &[u8],
     file: &Path,
     result: &mut ParseResult,
     impl_path: &str,
-    _trait_name: Option<&str>,
+    trait_name: Option<&str>,
+    parent_path: Option<&str>,
+    max_depth: usize,
 ) {
     if let Some(name_node) = node.child_by_field_name("name") {
         if let Ok(name) = name_node.utf8_text(source) {
@@ -1229,12 +1406,19 @@ fn extract_impl_method(
                 parent: Some(impl_path.to_string()),
                 mixins: None,
                 attributes: extract_attributes(node, source),
-                implements: None,
+                implements: trait_name.map(|t| vec![t.to_string()]),
                 doc,
                 signature,
+                extent: None,
             });
         }
     }
+
+    for i in 0..node.child_count() {
+        if let Some(child) = node.child(i) {
+            extract_recursive(&child, source, file, result, parent_path, max_depth);
+        }
+    }
 }
 
 /// Extract use statements
@@ -1695,4 +1879,136 @@ cfg_net! {
         let tcp = result.symbols.iter().find(|s| s.name == "TcpListener");
         assert!(tcp.is_some(), "Should find TcpListener struct inside macro");
     }
+
+    const SHAPES: &str = r#"
+mod shapes {
+    pub trait Shape {
+        fn area(&self) -> f64;
+    }
+
+    pub struct Circle {
+        pub r: f64,
+    }
+
+    impl Circle {
+        pub fn diameter(&self) -> f64 {
+            self.scale(2.0)
+        }
+
+        fn scale(&self, by: f64) -> f64 {
+            self.r * by
+        }
+    }
+
+    impl Shape for Circle {
+        fn area(&self) -> f64 {
+            square(self.r) * 3.14
+        }
+    }
+
+    pub fn square(x: f64) -> f64 {
+        x * x
+    }
+}
+
+fn main() {
+    let c = shapes::Circle { r: 1.0 };
+    println!("{} {}", c.area(), shapes::square(2.0));
+}
+"#;
+
+    fn calls(result: &ParseResult) -> Vec<&str> {
+        result
+            .references
+            .iter()
+            .filter(|r| r.kind == ReferenceKind::Call)
+            .map(|r| r.name.as_str())
+            .collect()
+    }
+
+    #[test]
+    fn marks_calls_in_functions_methods_and_macro_arguments() {
+        let result = extract_symbols(std::path::Path::new("shapes.rs"), SHAPES, 100);
+        let calls = calls(&result);
+
+        // Method bodies are walked, and method calls keep their receiver
+        assert!(calls.contains(&"self.scale"), "calls: {:?}", calls);
+        assert!(calls.contains(&"square"), "calls: {:?}", calls);
+        // Calls inside macro arguments
+        assert!(calls.contains(&"c.area"), "calls: {:?}", calls);
+        assert!(calls.contains(&"shapes::square"), "calls: {:?}", calls);
+
+        // The macro itself is a call site, with its path as the target
+        let println = result
+            .references
+            .iter()
+            .find(|r| r.name == "println!")
+            .expect("println! call site");
+        assert_eq!(println.kind, ReferenceKind::Call);
+        assert_eq!(println.target.as_deref(), Some("println"));
+
+        // Plain uses are not calls
+        let r = result
+            .references
+            .iter()
+            .find(|r| r.name == "x")
+            .expect("x reference");
+        assert_eq!(r.kind, ReferenceKind::Unknown);
+    }
+
+    #[test]
+    fn trait_impl_methods_belong_to_type_and_trait() {
+        let result = extract_symbols(std::path::Path::new("shapes.rs"), SHAPES, 100);
+        let area = result
+            .symbols
+            .iter()
+            .find(|s| s.qualified == "shapes::Circle::area")
+            .expect("area method");
+        assert_eq!(area.parent.as_deref(), Some("shapes::Circle"));
+        assert_eq!(area.implements, Some(vec!["Shape".to_string()]));
+
+        let diameter = result
+            .symbols
+            .iter()
+            .find(|s| s.qualified == "shapes::Circle::diameter")
+            .expect("diameter method");
+        assert_eq!(diameter.implements, None);
+    }
+
+    #[test]
+    fn trait_impls_and_calls_reach_the_index_and_call_graph() {
+        use crate::callgraph::CallGraph;
+        use crate::CodeIndex;
+
+        let file = std::path::Path::new("shapes.rs");
+        let mut index = CodeIndex::new();
+        index.replace_file(file, &extract_symbols(file, SHAPES, 100));
+
+        let implementations: Vec<&str> = index
+            .implementations("shapes::Shape")
+            .iter()
+            .map(|s| s.qualified.as_str())
+            .collect();
+        assert_eq!(implementations, vec!["shapes::Circle"]);
+
+        let graph = CallGraph::build(&index);
+        let callers: Vec<&str> = graph
+            .callers("shapes::square")
+            .iter()
+            .map(|s| s.caller.as_str())
+            .collect();
+        assert_eq!(callers, vec!["shapes::Circle::area", "main"]);
+        let callers: Vec<&str> = graph
+            .callers("shapes::Circle::scale")
+            .iter()
+            .map(|s| s.caller.as_str())
+            .collect();
+        assert_eq!(callers, vec!["shapes::Circle::diameter"]);
+
+        // Macros without a definition in the workspace stay unresolved
+        assert!(graph
+            .unresolved_calls_from("main")
+            .iter()
+            .any(|call| call.callee == "println!"));
+    }
 }
@@ -0,0 +1,12 @@
+//! Comment, test, and generated-code conventions for Swift.
+
+use crate::language_config::LanguageConfig;
+
+/// `///` and `/** */` doc comments; XCTest runs the `test*` methods of
+/// `*Tests.swift` files.
+#[must_use]
+pub fn config() -> LanguageConfig {
+    LanguageConfig::c_style("swift", &["swift"])
+        .with_doc_comments(&["///", "/**"])
+        .with_tests(&["*Tests.swift"], Some("^test"), true)
+}
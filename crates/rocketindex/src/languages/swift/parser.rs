@@ -3,8 +3,11 @@
 use std::cell::RefCell;
 use std::path::Path;
 
-use crate::parse::{find_child_by_kind, node_to_location, LanguageParser, ParseResult};
-use crate::{Reference, Symbol, SymbolKind, Visibility};
+use crate::parse::{
+    collect_annotations, find_child_by_kind, node_to_location, record_extents, LanguageParser,
+    ParseResult,
+};
+use crate::{Reference, ReferenceKind, Symbol, SymbolKind, Visibility};
 
 // Thread-local parser reuse - avoids creating a new parser per file
 thread_local! {
@@ -36,6 +39,9 @@ impl LanguageParser for SwiftParser {
             let root = tree.root_node();
 
             extract_recursive(&root, source.as_bytes(), file, &mut result, None, max_depth);
+            record_extents(&root, &mut result.symbols);
+            result.annotations =
+                collect_annotations(file, &root, source.as_bytes(), &result.symbols);
 
             result
         })
@@ -409,6 +415,7 @@ fn extract_recursive(
                     implements: None,
                     doc,
                     signature: None,
+                    extent: None,
                 });
 
                 // Extract enum cases if this is an enum
@@ -463,6 +470,7 @@ fn extract_recursive(
                     implements: None,
                     doc,
                     signature: None,
+                    extent: None,
                 });
 
                 // Recurse into protocol body
@@ -509,6 +517,7 @@ fn extract_recursive(
                     implements: None,
                     doc,
                     signature,
+                    extent: None,
                 });
             }
         }
@@ -533,6 +542,7 @@ fn extract_recursive(
                     implements: None,
                     doc,
                     signature: None,
+                    extent: None,
                 });
             }
         }
@@ -556,6 +566,7 @@ fn extract_recursive(
                     implements: None,
                     doc,
                     signature: None,
+                    extent: None,
                 });
             }
         }
@@ -614,6 +625,9 @@ fn extract_recursive(
                     result.references.push(Reference {
                         name: name.to_string(),
                         location: node_to_location(file, &id),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -634,6 +648,9 @@ fn extract_recursive(
                             result.references.push(Reference {
                                 name: name.to_string(),
                                 location: node_to_location(file, &callee),
+                                kind: ReferenceKind::Unknown,
+                                target: None,
+                                arguments: None,
                             });
                         }
                     }
@@ -644,6 +661,9 @@ fn extract_recursive(
                             result.references.push(Reference {
                                 name: name.to_string(),
                                 location: node_to_location(file, &callee),
+                                kind: ReferenceKind::Unknown,
+                                target: None,
+                                arguments: None,
                             });
                         }
                     }
@@ -662,6 +682,9 @@ fn extract_recursive(
                         result.references.push(Reference {
                             name: name.to_string(),
                             location: node_to_location(file, node),
+                            kind: ReferenceKind::Unknown,
+                            target: None,
+                            arguments: None,
                         });
                     }
                 }
@@ -675,6 +698,9 @@ fn extract_recursive(
                     result.references.push(Reference {
                         name: name.to_string(),
                         location: node_to_location(file, node),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -723,6 +749,7 @@ fn extract_enum_cases(
                         implements: None,
                         doc: extract_doc_comments(&child, source),
                         signature: None,
+                        extent: None,
                     });
                 }
             }
@@ -0,0 +1,22 @@
+//! Comment, test, and generated-code conventions for TypeScript.
+
+use crate::language_config::LanguageConfig;
+
+/// TSDoc `/** */` comments; every function in a `*.test.ts` or `*.spec.ts`
+/// file or under `__tests__` is a test (test bodies are usually callbacks).
+#[must_use]
+pub fn config() -> LanguageConfig {
+    LanguageConfig::c_style("typescript", &["ts", "tsx"])
+        .with_doc_comments(&["/**"])
+        .with_tests(
+            &[
+                "*.test.ts",
+                "*.spec.ts",
+                "*.test.tsx",
+                "*.spec.tsx",
+                "**/__tests__/**/*.ts",
+            ],
+            None,
+            true,
+        )
+}
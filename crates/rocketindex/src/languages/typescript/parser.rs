@@ -3,8 +3,11 @@
 use std::cell::RefCell;
 use std::path::Path;
 
-use crate::parse::{find_child_by_kind, node_to_location, LanguageParser, ParseResult};
-use crate::{Reference, Symbol, SymbolKind, Visibility};
+use crate::parse::{
+    collect_annotations, collect_syntax_errors, find_child_by_kind, node_to_location,
+    record_extents, LanguageParser, ParseResult,
+};
+use crate::{Reference, ReferenceKind, Symbol, SymbolKind, Visibility};
 
 // Thread-local parser reuse - avoids creating a new parser per file
 thread_local! {
@@ -62,32 +65,13 @@ impl LanguageParser for TypeScriptParser {
             let mut result = ParseResult::default();
             let root = tree.root_node();
 
-            // Check for syntax errors
-            if root.has_error() {
-                // Simple error traversal
-                let mut cursor = root.walk();
-                let mut recurse = true;
-                while recurse {
-                    let node = cursor.node();
-                    if node.is_error() || node.is_missing() {
-                        result.errors.push(crate::parse::SyntaxError {
-                            message: format!("Syntax error at {:?}", node.range()),
-                            location: node_to_location(file, &node),
-                        });
-                    }
-                    if cursor.goto_first_child() {
-                        continue;
-                    }
-                    while !cursor.goto_next_sibling() {
-                        if !cursor.goto_parent() {
-                            recurse = false;
-                            break;
-                        }
-                    }
-                }
-            }
+            result.errors = collect_syntax_errors(file, &root, source.as_bytes());
 
             extract_recursive(&root, source.as_bytes(), file, &mut result, None, max_depth);
+            apply_export_clauses(&root, source.as_bytes(), &mut result);
+            record_extents(&root, &mut result.symbols);
+            result.annotations =
+                collect_annotations(file, &root, source.as_bytes(), &result.symbols);
 
             result
         })
@@ -228,6 +212,54 @@ fn extract_function_signature(node: &tree_sitter::Node, source: &[u8]) -> Option
     None
 }
 
+/// Mark top-level symbols exported via a separate clause as public.
+///
+/// Handles `export { a, b as c }` and `export default a`, where the declaration
+/// itself has no `export` keyword. Re-exports (`export { a } from "./x"`) name
+/// symbols from another module and are ignored.
+fn apply_export_clauses(root: &tree_sitter::Node, source: &[u8], result: &mut ParseResult) {
+    let mut exported: Vec<&str> = Vec::new();
+
+    for i in 0..root.child_count() {
+        let Some(stmt) = root.child(i) else {
+            continue;
+        };
+        if stmt.kind() != "export_statement" || stmt.child_by_field_name("source").is_some() {
+            continue;
+        }
+
+        if let Some(value) = stmt.child_by_field_name("value") {
+            if value.kind() == "identifier" {
+                if let Ok(name) = value.utf8_text(source) {
+                    exported.push(name);
+                }
+            }
+        }
+
+        if let Some(clause) = find_child_by_kind(&stmt, "export_clause") {
+            for j in 0..clause.child_count() {
+                if let Some(spec) = clause.child(j) {
+                    if spec.kind() != "export_specifier" {
+                        continue;
+                    }
+                    if let Some(Ok(name)) = spec
+                        .child_by_field_name("name")
+                        .map(|n| n.utf8_text(source))
+                    {
+                        exported.push(name);
+                    }
+                }
+            }
+        }
+    }
+
+    for symbol in &mut result.symbols {
+        if exported.contains(&symbol.qualified.as_str()) {
+            symbol.visibility = Visibility::Public;
+        }
+    }
+}
+
 /// Build qualified name with . separator (JS/TS convention)
 fn qualified_name(name: &str, parent_path: Option<&str>) -> String {
     match parent_path {
@@ -276,6 +308,7 @@ fn extract_recursive(
                         implements,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Recurse into class body
@@ -310,6 +343,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Recurse into interface body for method signatures
@@ -348,6 +382,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
                 }
             }
@@ -373,6 +408,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Extract enum members
@@ -405,6 +441,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature,
+                        extent: None,
                     });
                 }
             }
@@ -461,6 +498,7 @@ fn extract_recursive(
                         implements: None,
                         doc,
                         signature: None,
+                        extent: None,
                     });
 
                     // Recurse into module body
@@ -490,6 +528,9 @@ fn extract_recursive(
                     result.references.push(Reference {
                         name: name.to_string(),
                         location: node_to_location(file, node),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -502,6 +543,9 @@ fn extract_recursive(
                     result.references.push(Reference {
                         name: name.to_string(),
                         location: node_to_location(file, node),
+                        kind: ReferenceKind::Unknown,
+                        target: None,
+                        arguments: None,
                     });
                 }
             }
@@ -551,6 +595,7 @@ fn extract_class_body(
                                 implements: None,
                                 doc,
                                 signature,
+                                extent: None,
                             });
 
                             // For constructors, extract parameter properties
@@ -583,6 +628,7 @@ fn extract_class_body(
                                 implements: None,
                                 doc,
                                 signature: None,
+                                extent: None,
                             });
                         }
                     }
@@ -638,6 +684,7 @@ fn extract_interface_body(
                                 implements: None,
                                 doc,
                                 signature,
+                                extent: None,
                             });
                         }
                     }
@@ -662,6 +709,7 @@ fn extract_interface_body(
                                 implements: None,
                                 doc,
                                 signature: None,
+                                extent: None,
                             });
                         }
                     }
@@ -708,6 +756,7 @@ fn extract_enum_members(
                             implements: None,
                             doc: extract_doc_comments(&child, source),
                             signature: None,
+                            extent: None,
                         });
                     }
                 }
@@ -754,6 +803,7 @@ fn extract_constructor_parameter_properties(
                                     implements: None,
                                     doc: None,
                                     signature: None,
+                                    extent: None,
                                 });
                             }
                         }
@@ -784,7 +834,7 @@ fn extract_parameter_visibility(param: &tree_sitter::Node, source: &[u8]) -> Vis
                 if let Ok(text) = child.utf8_text(source) {
                     return match text {
                         "public" => Visibility::Public,
-                        "protected" => Visibility::Internal,
+                        "protected" => Visibility::Protected,
                         "private" => Visibility::Private,
                         _ => Visibility::Public,
                     };
@@ -845,6 +895,7 @@ fn extract_variable_declarations(
                                 implements: None,
                                 doc: doc.clone(),
                                 signature,
+                                extent: None,
                             });
                         }
                     }
@@ -865,7 +916,7 @@ fn extract_method_visibility(node: &tree_sitter::Node, source: &[u8]) -> Visibil
                 if let Ok(text) = child.utf8_text(source) {
                     return match text {
                         "public" => Visibility::Public,
-                        "protected" => Visibility::Internal,
+                        "protected" => Visibility::Protected,
                         "private" => Visibility::Private,
                         _ => Visibility::Public, // default to public in TS
                     };
@@ -1474,6 +1525,32 @@ export const multiply = (a: number, b: number): number => a * b;
         assert_eq!(func.kind, SymbolKind::Function);
     }
 
+    #[test]
+    fn export_clause_marks_symbols_public() {
+        let source = r#"
+function helper() {}
+const util = () => 1;
+function internal() {}
+class Service {}
+export { helper, util as utility };
+export default Service;
+"#;
+        let result = extract_symbols(std::path::Path::new("test.ts"), source, 100);
+
+        let visibility = |name: &str| {
+            result
+                .symbols
+                .iter()
+                .find(|s| s.name == name)
+                .map(|s| s.visibility)
+                .unwrap_or_else(|| panic!("Should find {}", name))
+        };
+        assert_eq!(visibility("helper"), Visibility::Public);
+        assert_eq!(visibility("util"), Visibility::Public);
+        assert_eq!(visibility("Service"), Visibility::Public);
+        assert_eq!(visibility("internal"), Visibility::Private);
+    }
+
     #[test]
     fn extracts_imports() {
         let source = r#"
@@ -1591,7 +1668,7 @@ class Service {
             .iter()
             .find(|s| s.name == "protectedMethod")
             .expect("Should find protectedMethod");
-        assert_eq!(protected_m.visibility, Visibility::Internal);
+        assert_eq!(protected_m.visibility, Visibility::Protected);
 
         let private_m = result
             .symbols
@@ -1706,7 +1783,7 @@ class Controller {
             .iter()
             .find(|s| s.name == "config")
             .expect("Should find config");
-        assert_eq!(config.visibility, Visibility::Internal); // protected maps to Internal
+        assert_eq!(config.visibility, Visibility::Protected);
         assert_eq!(config.qualified, "Controller.config");
     }
     #[test]
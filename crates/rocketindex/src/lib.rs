@@ -30,7 +30,7 @@
 //! - [`Symbol`]: A symbol extracted from source code (function, class, etc.)
 //! - [`Location`]: Source location with file path and line/column positions
 //! - [`SymbolKind`]: The type of symbol (Function, Class, Module, etc.)
-//! - [`Visibility`]: Access modifier (Public, Private, Internal)
+//! - [`Visibility`]: Access modifier (Public, Internal, Protected, Private)
 //!
 //! # Indexing
 //!
@@ -47,28 +47,64 @@
 use serde::{Deserialize, Serialize};
 use std::path::PathBuf;
 
+pub mod annotations;
+pub mod api_diff;
 pub mod batch;
+pub mod callgraph;
+pub mod cancel;
+pub mod centrality;
+pub mod chunks;
 pub mod config;
+pub mod constructors;
+pub mod context;
+pub mod coverage;
 pub mod db;
+pub mod declarations;
+pub mod diff;
+pub mod dot;
+pub mod duplicates;
+pub mod exports;
 pub mod external_index;
+pub mod freshness;
 pub mod fsproj;
 pub mod fuzzy;
+pub mod generated;
 pub mod git;
 pub mod index;
+pub mod indexer;
+pub mod json;
+pub mod language_config;
 pub mod languages;
+pub mod lsif;
+pub mod members;
+pub mod metrics;
+pub mod naming;
+pub mod outline;
+pub mod packages;
 pub mod parse;
 pub mod pidfile;
+pub mod position;
+pub mod postprocess;
+pub mod query;
 pub mod ranking;
+pub mod rename;
 pub mod resolve;
+pub mod scip;
+pub mod search;
+pub mod signature;
+pub mod snapshot;
+pub mod snippet;
 pub mod spider;
 pub mod stacktrace;
+pub mod stream;
 pub mod type_cache;
+pub mod warm;
 pub mod watch;
 
 // Re-export main types
 pub use db::SqliteIndex;
 pub use fsproj::{find_fsproj_files, parse_fsproj, FsprojInfo};
-pub use index::{CodeIndex, Reference};
+pub use index::{CodeIndex, IndexUpdate, Reference, ReferenceKind};
 pub use parse::{extract_symbols, ParseWarning, SyntaxError};
 pub use ranking::{DetailLevel, RankedSymbol, RankingConfig};
 pub use resolve::ResolveResult;
@@ -130,6 +166,19 @@ impl Location {
     }
 }
 
+/// The lines a declaration spans, from its first token to the end of its body.
+///
+/// A symbol's [`Location`] covers only its name (what rename, go-to-definition
+/// and SCIP occurrences need); its extent covers the whole declaration, so a
+/// function's body or the members of a type fall inside it.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash, Serialize, Deserialize)]
+pub struct Extent {
+    /// First line of the declaration (1-indexed)
+    pub line: u32,
+    /// Last line of the declaration (inclusive)
+    pub end_line: u32,
+}
+
 /// The kind of symbol (function, type, module, etc.)
 ///
 /// # Examples
@@ -147,7 +196,7 @@ impl Location {
 /// // - Interface: trait, interface, or protocol
 /// // - Value: constant or variable
 /// ```
-#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize, Deserialize)]
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash, Serialize, Deserialize)]
 pub enum SymbolKind {
     Module,
     Function,
@@ -185,14 +234,113 @@ impl SymbolKind {
     pub const fn is_callable(self) -> bool {
         matches!(self, SymbolKind::Function | SymbolKind::Member)
     }
+
+    /// Returns true if this kind declares a type.
+    ///
+    /// Types are what members belong to, constructors build, and references
+    /// name in type positions.
+    #[must_use]
+    pub const fn is_type(self) -> bool {
+        matches!(
+            self,
+            SymbolKind::Class
+                | SymbolKind::Record
+                | SymbolKind::Union
+                | SymbolKind::Interface
+                | SymbolKind::Type
+        )
+    }
+
+    /// Every symbol kind, in declaration order.
+    pub const ALL: [SymbolKind; 9] = [
+        SymbolKind::Module,
+        SymbolKind::Function,
+        SymbolKind::Value,
+        SymbolKind::Type,
+        SymbolKind::Record,
+        SymbolKind::Union,
+        SymbolKind::Interface,
+        SymbolKind::Class,
+        SymbolKind::Member,
+    ];
+}
+
+/// A set of [`SymbolKind`]s, used to choose which symbols get indexed.
+///
+/// The default set contains every kind.
+///
+/// # Examples
+///
+/// ```
+/// use rocketindex::{KindSet, SymbolKind};
+///
+/// let callables = KindSet::only(&[SymbolKind::Function, SymbolKind::Member]);
+/// assert!(callables.contains(SymbolKind::Function));
+/// assert!(!callables.contains(SymbolKind::Value));
+///
+/// let no_values = KindSet::all().excluding(&[SymbolKind::Value]);
+/// assert!(no_values.contains(SymbolKind::Class));
+/// assert!(!no_values.contains(SymbolKind::Value));
+/// ```
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash)]
+pub struct KindSet(u16);
+
+impl KindSet {
+    /// Every symbol kind.
+    #[must_use]
+    pub fn all() -> Self {
+        Self::only(&SymbolKind::ALL)
+    }
+
+    /// Just the given kinds.
+    #[must_use]
+    pub fn only(kinds: &[SymbolKind]) -> Self {
+        Self(kinds.iter().fold(0, |bits, &kind| bits | Self::bit(kind)))
+    }
+
+    /// This set without the given kinds.
+    #[must_use]
+    pub fn excluding(self, kinds: &[SymbolKind]) -> Self {
+        Self(self.0 & !Self::only(kinds).0)
+    }
+
+    #[must_use]
+    pub fn contains(self, kind: SymbolKind) -> bool {
+        self.0 & Self::bit(kind) != 0
+    }
+
+    /// Whether the set contains every kind (so filtering is a no-op).
+    #[must_use]
+    pub fn is_all(self) -> bool {
+        self == Self::all()
+    }
+
+    fn bit(kind: SymbolKind) -> u16 {
+        1 << kind as u16
+    }
+}
+
+impl Default for KindSet {
+    fn default() -> Self {
+        Self::all()
+    }
 }
 
-/// Visibility of a symbol
+/// Visibility of a symbol, as declared.
+///
+/// Whether code outside the symbol's package can reach it also depends on
+/// its enclosing types, see [`CodeIndex::is_exported`].
 #[derive(Debug, Clone, Copy, Default, PartialEq, Eq, Serialize, Deserialize)]
 pub enum Visibility {
+    /// Visible everywhere (`public`, `pub`, an upper-case Go name)
     #[default]
     Public,
+    /// Visible within the module, assembly, or package (`internal`,
+    /// `pub(crate)`, Java package-private)
     Internal,
+    /// Visible to subclasses (`protected`)
+    Protected,
+    /// Visible only within the declaring type or file
     Private,
 }
 
@@ -277,6 +425,9 @@ pub struct Symbol {
     /// Type signature (e.g., "int -> int -> int" for F# functions)
     #[serde(default, skip_serializing_if = "Option::is_none")]
     pub signature: Option<String>,
+    /// Lines of the whole declaration, if the parser recorded them
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub extent: Option<Extent>,
 }
 
 impl Symbol {
@@ -301,6 +452,7 @@ impl Symbol {
             implements: None,
             doc: None,
             signature: None,
+            extent: None,
         }
     }
 
@@ -339,6 +491,27 @@ impl Symbol {
         self.signature = signature;
         self
     }
+
+    /// Create a symbol with the lines of its whole declaration
+    pub fn with_extent(mut self, line: u32, end_line: u32) -> Self {
+        self.extent = Some(Extent { line, end_line });
+        self
+    }
+
+    /// First and last line of the symbol's declaration.
+    ///
+    /// Uses the [`Extent`] if the parser recorded one, else the location,
+    /// which covers just the name for most parsed symbols.
+    #[must_use]
+    pub fn lines(&self) -> (u32, u32) {
+        match self.extent {
+            Some(extent) => (extent.line, extent.end_line.max(extent.line)),
+            None => (
+                self.location.line,
+                self.location.end_line.max(self.location.line),
+            ),
+        }
+    }
 }
 
 /// Errors that can occur during indexing
@@ -410,6 +583,24 @@ mod tests {
         assert!(!SymbolKind::Class.is_callable());
     }
 
+    #[test]
+    fn test_symbol_kind_is_type() {
+        let types: Vec<SymbolKind> = SymbolKind::ALL
+            .into_iter()
+            .filter(|kind| kind.is_type())
+            .collect();
+        assert_eq!(
+            types,
+            vec![
+                SymbolKind::Type,
+                SymbolKind::Record,
+                SymbolKind::Union,
+                SymbolKind::Interface,
+                SymbolKind::Class,
+            ]
+        );
+    }
+
     #[test]
     fn test_symbol_creation() {
         let sym = Symbol::new(
@@ -0,0 +1,511 @@
+//! LSIF export for the index.
+//!
+//! [LSIF](https://microsoft.github.io/language-server-protocol/specifications/lsif/0.4.0/specification/)
+//! is the older, JSON-based predecessor of SCIP that some code intelligence
+//! tools still require. [`CodeIndex::export_lsif`] writes the index as an LSIF
+//! graph in the line-delimited JSON dump format: one vertex or edge per line.
+//!
+//! Each symbol gets a `resultSet` with a `definitionResult`, a
+//! `referenceResult`, and (when the symbol has a signature or doc comment) a
+//! `hoverResult`. Each file becomes a `document` whose definition and
+//! reference `range`s point at those result sets, framed by `$event`
+//! begin/end vertices. References resolve to symbols the same way as for
+//! [SCIP](crate::scip).
+//!
+//! The graph is streamed one document at a time: per-symbol vertices are
+//! written when a symbol is first seen, and a document's ranges are written
+//! and forgotten before the next document starts, so memory use does not grow
+//! with the size of the output.
+//!
+//! Positions are 0-indexed and taken from the index, whose columns are byte
+//! offsets; they equal the UTF-16 offsets LSIF declares for ASCII source.
+//!
+//! # Examples
+//!
+//! ```
+//! use rocketindex::{CodeIndex, Location, Symbol, SymbolKind, Visibility};
+//! use std::path::PathBuf;
+//!
+//! let mut index = CodeIndex::new();
+//! index.add_symbol(Symbol::new(
+//!     "helper".to_string(),
+//!     "main.helper".to_string(),
+//!     SymbolKind::Function,
+//!     Location::new(PathBuf::from("main.go"), 5, 6),
+//!     Visibility::Private,
+//!     "go".to_string(),
+//! ));
+//!
+//! let mut out = Vec::new();
+//! index.export_lsif(&mut out).unwrap();
+//! let dump = String::from_utf8(out).unwrap();
+//! assert!(dump.lines().next().unwrap().contains("\"metaData\""));
+//! ```
+
+use std::collections::{BTreeMap, BTreeSet, HashMap};
+use std::io::Write;
+use std::path::Path;
+
+use serde_json::{json, Value};
+
+use crate::scip::{location_key, scip_range};
+use crate::{CodeIndex, Location, Result, Symbol};
+
+/// LSIF protocol version written to the `metaData` vertex.
+pub const LSIF_VERSION: &str = "0.4.3";
+
+/// A symbol's identity within one export.
+type SymbolKey<'a> = (&'a str, &'a Path, u32, u32);
+
+/// IDs of the per-symbol vertices, which outlive the document they were first seen in.
+#[derive(Clone, Copy)]
+struct SymbolResults {
+    result_set: u64,
+    definitions: u64,
+    references: u64,
+}
+
+impl CodeIndex {
+    /// Stream the index as an LSIF dump (see the [module docs](crate::lsif)).
+    ///
+    /// Documents are ordered by path and ranges by position, so exporting the
+    /// same index twice produces identical output.
+    pub fn export_lsif<W: Write>(&self, writer: W) -> Result<()> {
+        let mut out = LsifWriter { writer, next_id: 1 };
+
+        let mut project_root = self
+            .workspace_root()
+            .map(file_uri)
+            .unwrap_or_else(|| "file:///".to_string());
+        if !project_root.ends_with('/') {
+            project_root.push('/');
+        }
+        out.vertex(json!({
+            "label": "metaData",
+            "version": LSIF_VERSION,
+            "projectRoot": project_root,
+            "positionEncoding": "utf-16",
+            "toolInfo": { "name": "rocketindex", "version": env!("CARGO_PKG_VERSION") },
+        }))?;
+
+        let mut definitions_by_file: BTreeMap<&Path, Vec<&Symbol>> = BTreeMap::new();
+        for symbol in self.symbols() {
+            definitions_by_file
+                .entry(&symbol.location.file)
+                .or_default()
+                .push(symbol);
+        }
+        let definition_sites: BTreeSet<(&Path, u32, u32)> =
+            self.symbols().map(|s| location_key(&s.location)).collect();
+        let files: BTreeSet<&Path> = definitions_by_file
+            .keys()
+            .copied()
+            .chain(self.references().map(|r| r.location.file.as_path()))
+            .collect();
+
+        let mut results: HashMap<SymbolKey<'_>, SymbolResults> = HashMap::new();
+
+        for file in files {
+            let mut definitions = definitions_by_file.remove(file).unwrap_or_default();
+            definitions.sort_by_key(|s| (s.location.line, s.location.column));
+
+            // Some parsers also report the name at a definition site as a reference
+            let mut references: Vec<(&Location, &str, &Symbol)> = self
+                .references_in_file(file)
+                .iter()
+                .filter(|r| !definition_sites.contains(&location_key(&r.location)))
+                .filter_map(|r| {
+                    let target = self.resolve_scip_reference(r)?;
+                    Some((&r.location, r.name.as_str(), target))
+                })
+                .collect();
+            references.sort_by_key(|(location, _, _)| (location.line, location.column));
+
+            let language = definitions
+                .first()
+                .or_else(|| references.first().map(|(_, _, target)| target))
+                .map_or("", |s| s.language.as_str());
+            let document = out.vertex(json!({
+                "label": "document",
+                "uri": file_uri(&self.to_absolute(file)),
+                "languageId": lsif_language(language),
+            }))?;
+            out.event("begin", document)?;
+
+            let mut ranges = Vec::new();
+            // (symbol results, ranges), in first-seen order
+            let mut defined: Vec<(SymbolResults, Vec<u64>)> = Vec::new();
+            let mut referenced: Vec<(SymbolResults, Vec<u64>)> = Vec::new();
+
+            for symbol in definitions {
+                let symbol_results = out.symbol_results(&mut results, symbol)?;
+                let range = out.range(&symbol.location, &symbol.name)?;
+                out.edge("next", range, symbol_results.result_set)?;
+                ranges.push(range);
+                group(&mut defined, symbol_results, range);
+            }
+            for (location, name, target) in references {
+                let symbol_results = out.symbol_results(&mut results, target)?;
+                let range = out.range(location, name)?;
+                out.edge("next", range, symbol_results.result_set)?;
+                ranges.push(range);
+                group(&mut referenced, symbol_results, range);
+            }
+
+            if !ranges.is_empty() {
+                out.edge_many("contains", document, &ranges)?;
+            }
+            for (symbol_results, ranges) in &defined {
+                out.item(symbol_results.definitions, ranges, document, None)?;
+                out.item(
+                    symbol_results.references,
+                    ranges,
+                    document,
+                    Some("definitions"),
+                )?;
+            }
+            for (symbol_results, ranges) in &referenced {
+                out.item(
+                    symbol_results.references,
+                    ranges,
+                    document,
+                    Some("references"),
+                )?;
+            }
+
+            out.event("end", document)?;
+        }
+
+        out.writer.flush()?;
+        Ok(())
+    }
+}
+
+/// Append `range` to the entry for `symbol_results`, adding the entry if needed.
+fn group(groups: &mut Vec<(SymbolResults, Vec<u64>)>, symbol_results: SymbolResults, range: u64) {
+    match groups
+        .iter_mut()
+        .find(|(r, _)| r.result_set == symbol_results.result_set)
+    {
+        Some((_, ranges)) => ranges.push(range),
+        None => groups.push((symbol_results, vec![range])),
+    }
+}
+
+/// Writes vertices and edges as JSON lines, assigning sequential IDs.
+struct LsifWriter<W: Write> {
+    writer: W,
+    next_id: u64,
+}
+
+impl<W: Write> LsifWriter<W> {
+    fn emit(&mut self, kind: &str, mut element: Value) -> Result<u64> {
+        let id = self.next_id;
+        self.next_id += 1;
+        element["id"] = json!(id);
+        element["type"] = json!(kind);
+        serde_json::to_writer(&mut self.writer, &element)?;
+        self.writer.write_all(b"\n")?;
+        Ok(id)
+    }
+
+    fn vertex(&mut self, vertex: Value) -> Result<u64> {
+        self.emit("vertex", vertex)
+    }
+
+    /// A document `$event` vertex (`kind` is `begin` or `end`).
+    fn event(&mut self, kind: &str, document: u64) -> Result<u64> {
+        self.vertex(
+            json!({ "label": "$event", "kind": kind, "scope": "document", "data": document }),
+        )
+    }
+
+    fn edge(&mut self, label: &str, out_v: u64, in_v: u64) -> Result<u64> {
+        self.emit(
+            "edge",
+            json!({ "label": label, "outV": out_v, "inV": in_v }),
+        )
+    }
+
+    fn edge_many(&mut self, label: &str, out_v: u64, in_vs: &[u64]) -> Result<u64> {
+        self.emit(
+            "edge",
+            json!({ "label": label, "outV": out_v, "inVs": in_vs }),
+        )
+    }
+
+    fn item(
+        &mut self,
+        out_v: u64,
+        in_vs: &[u64],
+        document: u64,
+        property: Option<&str>,
+    ) -> Result<u64> {
+        let mut edge =
+            json!({ "label": "item", "outV": out_v, "inVs": in_vs, "document": document });
+        if let Some(property) = property {
+            edge["property"] = json!(property);
+        }
+        self.emit("edge", edge)
+    }
+
+    fn range(&mut self, location: &Location, text: &str) -> Result<u64> {
+        let range = scip_range(location, text);
+        let (start_line, start_char, end_line, end_char) = match range[..] {
+            [line, start, end] => (line, start, line, end),
+            [start_line, start, end_line, end] => (start_line, start, end_line, end),
+            _ => unreachable!("scip_range returns three or four elements"),
+        };
+        self.vertex(json!({
+            "label": "range",
+            "start": { "line": start_line, "character": start_char },
+            "end": { "line": end_line, "character": end_char },
+        }))
+    }
+
+    /// The result vertices for `symbol`, writing them on first use.
+    fn symbol_results<'a>(
+        &mut self,
+        results: &mut HashMap<SymbolKey<'a>, SymbolResults>,
+        symbol: &'a Symbol,
+    ) -> Result<SymbolResults> {
+        let key = (
+            symbol.qualified.as_str(),
+            symbol.location.file.as_path(),
+            symbol.location.line,
+            symbol.location.column,
+        );
+        if let Some(existing) = results.get(&key) {
+            return Ok(*existing);
+        }
+
+        let result_set = self.vertex(json!({ "label": "resultSet" }))?;
+        let definitions = self.vertex(json!({ "label": "definitionResult" }))?;
+        self.edge("textDocument/definition", result_set, definitions)?;
+        let references = self.vertex(json!({ "label": "referenceResult" }))?;
+        self.edge("textDocument/references", result_set, references)?;
+
+        let mut contents = Vec::new();
+        if let Some(signature) = &symbol.signature {
+            contents.push(json!({ "language": symbol.language, "value": signature }));
+        }
+        if let Some(doc) = &symbol.doc {
+            contents.push(json!(doc));
+        }
+        if !contents.is_empty() {
+            let hover = self.vertex(json!({
+                "label": "hoverResult",
+                "result": { "contents": contents },
+            }))?;
+            self.edge("textDocument/hover", result_set, hover)?;
+        }
+
+        let symbol_results = SymbolResults {
+            result_set,
+            definitions,
+            references,
+        };
+        results.insert(key, symbol_results);
+        Ok(symbol_results)
+    }
+}
+
+fn file_uri(path: &Path) -> String {
+    let path = path.to_string_lossy().replace('\\', "/");
+    if path.starts_with('/') {
+        format!("file://{}", path)
+    } else {
+        format!("file:///{}", path)
+    }
+}
+
+/// Map our language identifiers to LSP language IDs.
+fn lsif_language(language: &str) -> &str {
+    match language {
+        "objc" => "objective-c",
+        other => other,
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::{Reference, ReferenceKind, SymbolKind, Visibility};
+    use std::path::PathBuf;
+
+    fn function(name: &str, file: &str, line: u32) -> Symbol {
+        Symbol::new(
+            name.to_string(),
+            format!("main.{}", name),
+            SymbolKind::Function,
+            Location::new(PathBuf::from(file), line, 6),
+            Visibility::Private,
+            "go".to_string(),
+        )
+    }
+
+    fn sample_index() -> CodeIndex {
+        let mut index = CodeIndex::with_root(PathBuf::from("/work/app"));
+        index.add_symbol(
+            function("helper", "util.go", 3)
+                .with_signature(Some("func helper() int".to_string()))
+                .with_doc(Some("Returns one.".to_string())),
+        );
+        index.add_symbol(function("run", "main.go", 5));
+        for line in [6, 7] {
+            index.add_reference(
+                PathBuf::from("main.go"),
+                Reference {
+                    name: "helper".to_string(),
+                    location: Location::new(PathBuf::from("main.go"), line, 2),
+                    kind: ReferenceKind::Call,
+                    target: None,
+                    arguments: None,
+                },
+            );
+        }
+        index
+    }
+
+    fn export(index: &CodeIndex) -> Vec<Value> {
+        let mut out = Vec::new();
+        index.export_lsif(&mut out).unwrap();
+        String::from_utf8(out)
+            .unwrap()
+            .lines()
+            .map(|line| serde_json::from_str(line).expect("each line is a JSON element"))
+            .collect()
+    }
+
+    /// Check the structural rules LSIF consumers rely on.
+    fn validate(elements: &[Value]) {
+        let mut vertices: HashMap<u64, String> = HashMap::new();
+        let mut open_documents: BTreeSet<u64> = BTreeSet::new();
+        let mut range_owner: HashMap<u64, u64> = HashMap::new();
+
+        assert_eq!(elements[0]["label"], "metaData");
+        for (i, element) in elements.iter().enumerate() {
+            let id = element["id"].as_u64().unwrap();
+            assert_eq!(id, i as u64 + 1, "IDs are sequential");
+            let label = element["label"].as_str().unwrap().to_string();
+
+            match element["type"].as_str().unwrap() {
+                "vertex" => {
+                    if label == "$event" {
+                        let document = element["data"].as_u64().unwrap();
+                        assert_eq!(vertices[&document], "document");
+                        match element["kind"].as_str().unwrap() {
+                            "begin" => assert!(open_documents.insert(document)),
+                            "end" => assert!(open_documents.remove(&document)),
+                            other => panic!("unexpected event {}", other),
+                        }
+                    }
+                    vertices.insert(id, label);
+                }
+                "edge" => {
+                    let out_v = element["outV"].as_u64().unwrap();
+                    assert!(
+                        vertices.contains_key(&out_v),
+                        "{} outV emitted first",
+                        label
+                    );
+                    let in_vs: Vec<u64> = match element.get("inV") {
+                        Some(in_v) => vec![in_v.as_u64().unwrap()],
+                        None => element["inVs"]
+                            .as_array()
+                            .unwrap()
+                            .iter()
+                            .map(|v| v.as_u64().unwrap())
+                            .collect(),
+                    };
+                    for in_v in &in_vs {
+                        assert!(vertices.contains_key(in_v), "{} inV emitted first", label);
+                    }
+
+                    match label.as_str() {
+                        "contains" => {
+                            assert!(open_documents.contains(&out_v));
+                            for range in in_vs {
+                                assert_eq!(vertices[&range], "range");
+                                assert!(range_owner.insert(range, out_v).is_none());
+                            }
+                        }
+                        "item" => {
+                            let document = element["document"].as_u64().unwrap();
+                            assert!(open_documents.contains(&document));
+                            for range in in_vs {
+                                assert_eq!(range_owner.get(&range), Some(&document));
+                            }
+                        }
+                        "next" => assert_eq!(vertices[&in_vs[0]], "resultSet"),
+                        _ => {}
+                    }
+                }
+                other => panic!("unexpected element type {}", other),
+            }
+        }
+        assert!(open_documents.is_empty(), "every document is ended");
+        let ranges = vertices.values().filter(|l| *l == "range").count();
+        assert_eq!(ranges, range_owner.len(), "every range is in a document");
+    }
+
+    #[test]
+    fn test_export_lsif_produces_valid_graph() {
+        let elements = export(&sample_index());
+        validate(&elements);
+
+        let documents: Vec<&str> = elements
+            .iter()
+            .filter(|e| e["label"] == "document")
+            .map(|e| e["uri"].as_str().unwrap())
+            .collect();
+        assert_eq!(
+            documents,
+            vec!["file:///work/app/main.go", "file:///work/app/util.go"]
+        );
+        assert_eq!(elements[0]["projectRoot"], "file:///work/app/");
+    }
+
+    #[test]
+    fn test_export_lsif_links_references_and_hover() {
+        let elements = export(&sample_index());
+        let by_id = |id: &Value| {
+            elements
+                .iter()
+                .find(|e| e["id"] == *id)
+                .unwrap_or_else(|| panic!("no element {}", id))
+        };
+
+        // Both references to helper share one item edge into its referenceResult
+        let reference_items: Vec<&Value> = elements
+            .iter()
+            .filter(|e| e["label"] == "item" && e["property"] == "references")
+            .collect();
+        assert_eq!(reference_items.len(), 1);
+        assert_eq!(reference_items[0]["inVs"].as_array().unwrap().len(), 2);
+        let start = &by_id(&reference_items[0]["inVs"][0])["start"];
+        assert_eq!(
+            (start["line"].as_u64(), start["character"].as_u64()),
+            (Some(5), Some(1))
+        );
+
+        let hovers: Vec<&Value> = elements
+            .iter()
+            .filter(|e| e["label"] == "hoverResult")
+            .collect();
+        assert_eq!(hovers.len(), 1);
+        let contents = &hovers[0]["result"]["contents"];
+        assert_eq!(contents[0]["value"], "func helper() int");
+        assert_eq!(contents[0]["language"], "go");
+        assert_eq!(contents[1], "Returns one.");
+    }
+
+    #[test]
+    fn test_export_lsif_is_deterministic() {
+        let mut first = Vec::new();
+        let mut second = Vec::new();
+        sample_index().export_lsif(&mut first).unwrap();
+        sample_index().export_lsif(&mut second).unwrap();
+        assert_eq!(first, second);
+    }
+}
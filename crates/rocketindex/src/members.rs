@@ -0,0 +1,233 @@
+//! Members of a type: its fields, methods, and (optionally) constructors.
+//!
+//! [`CodeIndex::members`] groups the symbols declared under a type so that
+//! navigation can show `User` owning `Name`, `Email`, and `FullInfo`. A symbol
+//! is a member of a type when its qualified name is the type's qualified name
+//! plus its own name and it has a parent, which holds for struct fields and
+//! methods alike. Go methods keep the receiver type without the pointer, so
+//! `func (u User)` and `func (u *User)` both land on `User`.
+//!
+//! Go has no constructor syntax, but `func NewUser(...) *User` is the idiom.
+//! When asked, such functions are reported as [`MemberRole::Constructor`]
+//! members of the type they return, see [`CodeIndex::constructed_type`].
+//!
+//! # Examples
+//!
+//! ```
+//! use rocketindex::members::MemberRole;
+//! use rocketindex::{CodeIndex, Location, Symbol, SymbolKind, Visibility};
+//! use std::path::PathBuf;
+//!
+//! let symbol = |name: &str, qualified: &str, kind, line| {
+//!     Symbol::new(
+//!         name.to_string(),
+//!         qualified.to_string(),
+//!         kind,
+//!         Location::new(PathBuf::from("user.go"), line, 6),
+//!         Visibility::Public,
+//!         "go".to_string(),
+//!     )
+//! };
+//!
+//! let mut index = CodeIndex::new();
+//! index.add_symbol(symbol("User", "main.User", SymbolKind::Class, 3));
+//! index.add_symbol(
+//!     symbol("Name", "main.User.Name", SymbolKind::Member, 4)
+//!         .with_parent(Some("main.User".to_string())),
+//! );
+//! index.add_symbol(
+//!     symbol("NewUser", "main.NewUser", SymbolKind::Function, 8)
+//!         .with_signature(Some("func NewUser(name string) *User".to_string())),
+//! );
+//!
+//! let members = index.members("main.User", true);
+//! assert_eq!(members[0].symbol.name, "Name");
+//! assert_eq!(members[0].role, MemberRole::Field);
+//! assert_eq!(members[1].role, MemberRole::Constructor);
+//! ```
+
+use serde::Serialize;
+
+use crate::{CodeIndex, Symbol, SymbolKind};
+
+/// How a member relates to its type.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize)]
+#[serde(rename_all = "snake_case")]
+pub enum MemberRole {
+    /// A field or property
+    Field,
+    /// A method, including methods declared by an interface
+    Method,
+    /// A function returning a new value of the type
+    Constructor,
+    /// A type nested inside the type
+    Type,
+}
+
+/// A symbol that belongs to a type.
+#[derive(Debug, Clone, Copy, Serialize)]
+pub struct Member<'a> {
+    pub symbol: &'a Symbol,
+    pub role: MemberRole,
+}
+
+impl CodeIndex {
+    /// Fields, methods, and nested types of the type named `type_qualified`.
+    ///
+    /// With `constructors`, Go constructor functions for the type are included
+    /// as well. Members are sorted by location. Returns an empty list if the
+    /// type is not in the index.
+    #[must_use]
+    pub fn members(&self, type_qualified: &str, constructors: bool) -> Vec<Member<'_>> {
+        let Some(owner) = self
+            .get_all(type_qualified)
+            .iter()
+            .find(|s| s.kind.is_type())
+        else {
+            return Vec::new();
+        };
+
+        let mut members: Vec<Member<'_>> = self
+            .symbols()
+            .filter(|s| s.parent.is_some() && is_member_of(s, owner))
+            .map(|symbol| Member {
+                symbol,
+                role: member_role(symbol),
+            })
+            .collect();
+
+        if constructors && owner.language == "go" {
+            members.extend(
+                self.symbols()
+                    .filter(|s| {
+                        self.constructed_type(s)
+                            .is_some_and(|t| t.qualified == owner.qualified)
+                    })
+                    .map(|symbol| Member {
+                        symbol,
+                        role: MemberRole::Constructor,
+                    }),
+            );
+        }
+
+        members.sort_by(|a, b| {
+            let (a, b) = (&a.symbol.location, &b.symbol.location);
+            a.file
+                .cmp(&b.file)
+                .then(a.line.cmp(&b.line))
+                .then(a.column.cmp(&b.column))
+        });
+        members
+    }
+}
+
+/// Whether `symbol` is declared directly under `owner` (`owner.qualified` + `.` + name).
+fn is_member_of(symbol: &Symbol, owner: &Symbol) -> bool {
+    symbol
+        .qualified
+        .strip_suffix(symbol.name.as_str())
+        .and_then(|prefix| prefix.strip_suffix('.'))
+        == Some(owner.qualified.as_str())
+}
+
+fn member_role(symbol: &Symbol) -> MemberRole {
+    match symbol.kind {
+        SymbolKind::Function => MemberRole::Method,
+        // Members with parameters are methods; the rest are fields
+        SymbolKind::Member if symbol.signature.as_deref().is_some_and(|s| s.contains('(')) => {
+            MemberRole::Method
+        }
+        kind if kind.is_type() => MemberRole::Type,
+        _ => MemberRole::Field,
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::indexer::IndexOptions;
+    use std::path::{Path, PathBuf};
+
+    fn parse_go(file: &str, source: &str) -> CodeIndex {
+        let mut index = CodeIndex::new();
+        let result = crate::extract_symbols(Path::new(file), source, 100);
+        index.replace_file(Path::new(file), &result);
+        index
+    }
+
+    fn names<'a>(members: &[Member<'a>], role: MemberRole) -> Vec<&'a str> {
+        members
+            .iter()
+            .filter(|m| m.role == role)
+            .map(|m| m.symbol.name.as_str())
+            .collect()
+    }
+
+    #[test]
+    fn test_members_group_fields_and_methods_of_go_fixture() {
+        let root = Path::new(env!("CARGO_MANIFEST_DIR"))
+            .join("../../tests/claude-plugin-integration/test-repos/go-sample");
+        let mut index = CodeIndex::with_root(root.clone());
+        let files: Vec<PathBuf> = ["user.go", "payment.go", "main.go"]
+            .iter()
+            .map(|f| root.join(f))
+            .collect();
+        index.update_files(&files, &IndexOptions::default());
+
+        let members = index.members("main.User", false);
+        assert_eq!(names(&members, MemberRole::Field), vec!["Name", "Email"]);
+        assert_eq!(names(&members, MemberRole::Method), vec!["FullInfo"]);
+        assert!(names(&members, MemberRole::Constructor).is_empty());
+
+        let members = index.members("main.User", true);
+        assert_eq!(names(&members, MemberRole::Constructor), vec!["NewUser"]);
+        // NewPaymentService returns *PaymentService, not *User
+        let service = index.members("main.PaymentService", true);
+        assert_eq!(
+            names(&service, MemberRole::Constructor),
+            vec!["NewPaymentService"]
+        );
+        assert_eq!(
+            names(&service, MemberRole::Method),
+            vec!["ProcessPayment", "RefundPayment"]
+        );
+    }
+
+    #[test]
+    fn test_pointer_and_value_receivers_share_a_type() {
+        let index = parse_go(
+            "stack.go",
+            r#"package stack
+
+type Stack struct {
+	items []int
+}
+
+func (s Stack) Len() int { return len(s.items) }
+
+func (s *Stack) Push(v int) { s.items = append(s.items, v) }
+
+func NewStack() (*Stack, error) { return &Stack{}, nil }
+
+func newStack() *Stack { return &Stack{} }
+
+func Fresh() *Stack { return &Stack{} }
+"#,
+        );
+
+        let members = index.members("stack.Stack", true);
+        assert_eq!(names(&members, MemberRole::Field), vec!["items"]);
+        assert_eq!(names(&members, MemberRole::Method), vec!["Len", "Push"]);
+        assert_eq!(names(&members, MemberRole::Constructor), vec!["NewStack"]);
+    }
+
+    #[test]
+    fn test_members_of_unknown_or_non_type_symbol() {
+        let index = parse_go(
+            "main.go",
+            "package main\n\nfunc helper() int { return 1 }\n",
+        );
+        assert!(index.members("main.Missing", true).is_empty());
+        assert!(index.members("main.helper", true).is_empty());
+    }
+}
@@ -0,0 +1,172 @@
+//! Coupling metrics for symbols: fan-in, fan-out and reference counts.
+//!
+//! [`CodeIndex::symbol_metrics`] reports how many distinct symbols call a
+//! symbol (fan-in) and how many distinct symbols it calls (fan-out), both
+//! taken from a [`CallGraph`]. For types, which are referenced rather than
+//! called, it also counts the sites that mention the type (see
+//! [`CodeIndex::find_references`]). [`CodeIndex::all_symbol_metrics`] reports
+//! every symbol at once, most-called first, which is a quick way to find the
+//! code a change is most likely to ripple out from.
+//!
+//! # Examples
+//!
+//! ```
+//! use rocketindex::callgraph::CallGraph;
+//! use rocketindex::{
+//!     CodeIndex, Location, Reference, ReferenceKind, Symbol, SymbolKind, Visibility,
+//! };
+//! use std::path::PathBuf;
+//!
+//! // class Cart at line 1; checkout (line 5) builds a Cart and calls save
+//! let mut index = CodeIndex::new();
+//! for (name, kind, line) in [
+//!     ("Cart", SymbolKind::Class, 1),
+//!     ("checkout", SymbolKind::Function, 5),
+//!     ("save", SymbolKind::Function, 9),
+//! ] {
+//!     index.add_symbol(Symbol::new(
+//!         name.to_string(),
+//!         format!("shop.{}", name),
+//!         kind,
+//!         Location::new(PathBuf::from("shop.py"), line, 5),
+//!         Visibility::Public,
+//!         "python".to_string(),
+//!     ));
+//! }
+//! for (name, kind, line) in [
+//!     ("Cart", ReferenceKind::Construction, 6),
+//!     ("save", ReferenceKind::Call, 7),
+//! ] {
+//!     index.add_reference(
+//!         PathBuf::from("shop.py"),
+//!         Reference {
+//!             name: name.to_string(),
+//!             location: Location::new(PathBuf::from("shop.py"), line, 5),
+//!             kind,
+//!             target: None,
+//!             arguments: None,
+//!         },
+//!     );
+//! }
+//!
+//! let graph = CallGraph::build(&index);
+//! let checkout = index.symbol_metrics(&graph, "shop.checkout").unwrap();
+//! assert_eq!(checkout.fan_in, 0);
+//! assert_eq!(checkout.references, None);
+//! let cart = index.symbol_metrics(&graph, "shop.Cart").unwrap();
+//! assert_eq!(cart.references, Some(1));
+//! ```
+
+use std::collections::BTreeSet;
+
+use serde::Serialize;
+
+use crate::callgraph::CallGraph;
+use crate::CodeIndex;
+
+/// Coupling metrics for one symbol.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct SymbolMetrics {
+    /// Qualified name of the symbol
+    pub qualified: String,
+    /// Number of distinct symbols that call this one
+    pub fan_in: usize,
+    /// Number of distinct symbols this one calls
+    pub fan_out: usize,
+    /// For types, the number of sites that reference the type
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub references: Option<usize>,
+}
+
+impl CodeIndex {
+    /// Metrics for the symbol named `qualified`, or `None` if it is not
+    /// indexed.
+    ///
+    /// `graph` should be built from this index. A recursive symbol counts
+    /// itself among its callers and callees.
+    #[must_use]
+    pub fn symbol_metrics(&self, graph: &CallGraph, qualified: &str) -> Option<SymbolMetrics> {
+        let symbol = self.get(qualified)?;
+        Some(SymbolMetrics {
+            qualified: qualified.to_string(),
+            fan_in: graph.callers(qualified).len(),
+            fan_out: graph.callees(qualified).len(),
+            references: symbol
+                .kind
+                .is_type()
+                .then(|| self.find_references(qualified).len()),
+        })
+    }
+
+    /// Metrics for every indexed symbol.
+    ///
+    /// Results are sorted by descending fan-in, ties by qualified name.
+    #[must_use]
+    pub fn all_symbol_metrics(&self, graph: &CallGraph) -> Vec<SymbolMetrics> {
+        let names: BTreeSet<&str> = self.symbols().map(|s| s.qualified.as_str()).collect();
+        let mut metrics: Vec<SymbolMetrics> = names
+            .into_iter()
+            .filter_map(|name| self.symbol_metrics(graph, name))
+            .collect();
+        metrics.sort_by(|a, b| {
+            b.fan_in
+                .cmp(&a.fan_in)
+                .then_with(|| a.qualified.cmp(&b.qualified))
+        });
+        metrics
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::indexer::IndexOptions;
+    use std::path::Path;
+
+    fn minimal_go_index() -> CodeIndex {
+        let root = Path::new(env!("CARGO_MANIFEST_DIR")).join("../../tests/fixtures/minimal/go");
+        let mut index = CodeIndex::with_root(root.clone());
+        index.update_files(&[root.join("main.go")], &IndexOptions::default());
+        index
+    }
+
+    #[test]
+    fn test_helper_counts_distinct_callers() {
+        let index = minimal_go_index();
+        let graph = CallGraph::build(&index);
+
+        // Called from mainFunction, callerB and NewMyStruct
+        let helper = index.symbol_metrics(&graph, "main.helper").unwrap();
+        assert_eq!(helper.fan_in, 3);
+        assert_eq!(helper.fan_out, 0);
+        assert_eq!(helper.references, None);
+
+        let caller_b = index.symbol_metrics(&graph, "main.callerB").unwrap();
+        assert_eq!((caller_b.fan_in, caller_b.fan_out), (0, 2));
+
+        // Returned as *MyStruct and built with a composite literal
+        let my_struct = index.symbol_metrics(&graph, "main.MyStruct").unwrap();
+        assert!(my_struct.references.unwrap() >= 2);
+        let method = index
+            .symbol_metrics(&graph, "main.MyStruct.Method")
+            .unwrap();
+        assert_eq!((method.fan_in, method.references), (0, None));
+        assert!(index.symbol_metrics(&graph, "main.missing").is_none());
+    }
+
+    #[test]
+    fn test_all_metrics_sorted_by_fan_in() {
+        let index = minimal_go_index();
+        let graph = CallGraph::build(&index);
+
+        let names: Vec<(String, usize)> = index
+            .all_symbol_metrics(&graph)
+            .into_iter()
+            .map(|m| (m.qualified, m.fan_in))
+            .collect();
+        assert_eq!(names[0], ("main.helper".to_string(), 3));
+        assert_eq!(names[1], ("main.mainFunction".to_string(), 2));
+        assert!(names.iter().any(|(name, _)| name == "main.MyStruct.Method"));
+        assert!(names.windows(2).all(|w| w[0].1 >= w[1].1));
+    }
+}
@@ -0,0 +1,227 @@
+//! Rendering qualified names in a consumer's preferred format.
+//!
+//! Each language indexes qualified names with its own separators: Go and
+//! Java use `pkg.Type.Method`, C++ and Rust `ns::Type::method`, Ruby
+//! `Module#method`, and PHP `Ns\Type`. A [`NameScheme`] rewrites those
+//! separators uniformly when a name is shown, so search, JSON export and
+//! query answers can agree on one format. The index always keeps the names
+//! as parsed; stable IDs (see [`stable_id`](crate::json::stable_id)) are
+//! computed from them and do not change with the scheme.
+//!
+//! # Examples
+//!
+//! ```
+//! use rocketindex::naming::NameScheme;
+//! use rocketindex::{Location, Symbol, SymbolKind, Visibility};
+//! use std::path::PathBuf;
+//!
+//! let method = Symbol::new(
+//!     "Area".to_string(),
+//!     "shapes.Circle.Area".to_string(),
+//!     SymbolKind::Function,
+//!     Location::new(PathBuf::from("circle.go"), 9, 18),
+//!     Visibility::Public,
+//!     "go".to_string(),
+//! );
+//!
+//! assert_eq!(NameScheme::default().render(&method), "shapes.Circle.Area");
+//! assert_eq!(NameScheme::Colons.render(&method), "shapes::Circle::Area");
+//! assert_eq!(NameScheme::Slash.render(&method), "shapes/Circle/Area");
+//!
+//! let custom = NameScheme::custom(|s: &Symbol| format!("{}:{}", s.language, s.qualified));
+//! assert_eq!(custom.render(&method), "go:shapes.Circle.Area");
+//! ```
+
+use std::borrow::Cow;
+use std::fmt;
+use std::str::FromStr;
+use std::sync::Arc;
+
+use serde::{Deserialize, Serialize};
+
+use crate::{CodeIndex, Symbol};
+
+/// Renders a symbol's qualified name for display.
+pub type RenderFn = dyn Fn(&Symbol) -> String + Send + Sync;
+
+/// How qualified names are rendered in search results, exports and query
+/// answers.
+#[derive(Clone, Default, Serialize, Deserialize)]
+#[serde(rename_all = "snake_case")]
+pub enum NameScheme {
+    /// Names as indexed: `pkg.Type.Method` for Go, `ns::Type::method` for C++
+    #[default]
+    Native,
+    /// `pkg.Type.Method`
+    Dotted,
+    /// `pkg::Type::Method`
+    Colons,
+    /// `pkg/Type/Method`
+    Slash,
+    /// A caller-supplied rendering
+    #[serde(skip)]
+    Custom(Arc<RenderFn>),
+}
+
+impl NameScheme {
+    /// A scheme that renders every symbol with `render`.
+    pub fn custom(render: impl Fn(&Symbol) -> String + Send + Sync + 'static) -> Self {
+        Self::Custom(Arc::new(render))
+    }
+
+    /// The separator placed between name segments, if the scheme has a fixed one.
+    pub fn separator(&self) -> Option<&'static str> {
+        match self {
+            Self::Dotted => Some("."),
+            Self::Colons => Some("::"),
+            Self::Slash => Some("/"),
+            Self::Native | Self::Custom(_) => None,
+        }
+    }
+
+    /// Render `symbol`'s qualified name.
+    pub fn render<'a>(&self, symbol: &'a Symbol) -> Cow<'a, str> {
+        match self {
+            Self::Custom(render) => Cow::Owned(render(symbol)),
+            _ => self.render_qualified(&symbol.qualified),
+        }
+    }
+
+    /// Render a qualified name known only as text, such as a call site's
+    /// caller or a symbol's parent.
+    ///
+    /// A custom scheme needs the symbol itself, so the name is looked up in
+    /// `index`; names with no symbol there are returned unchanged.
+    pub fn render_in<'a>(&self, index: &CodeIndex, qualified: &'a str) -> Cow<'a, str> {
+        match self {
+            Self::Custom(render) => match index.get(qualified) {
+                Some(symbol) => Cow::Owned(render(symbol)),
+                None => Cow::Borrowed(qualified),
+            },
+            _ => self.render_qualified(qualified),
+        }
+    }
+
+    fn render_qualified<'a>(&self, qualified: &'a str) -> Cow<'a, str> {
+        match self.separator() {
+            Some(separator) => Cow::Owned(segments(qualified).join(separator)),
+            None => Cow::Borrowed(qualified),
+        }
+    }
+}
+
+impl fmt::Debug for NameScheme {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            Self::Native => f.write_str("Native"),
+            Self::Dotted => f.write_str("Dotted"),
+            Self::Colons => f.write_str("Colons"),
+            Self::Slash => f.write_str("Slash"),
+            Self::Custom(_) => f.write_str("Custom(..)"),
+        }
+    }
+}
+
+impl FromStr for NameScheme {
+    type Err = String;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match s {
+            "native" => Ok(Self::Native),
+            "dotted" => Ok(Self::Dotted),
+            "colons" => Ok(Self::Colons),
+            "slash" => Ok(Self::Slash),
+            _ => Err(format!(
+                "unknown name scheme '{}' (expected native, dotted, colons, or slash)",
+                s
+            )),
+        }
+    }
+}
+
+/// Split a qualified name on any language's separator (`::`, `.`, `#`, `\`).
+fn segments(qualified: &str) -> Vec<&str> {
+    let mut segments = Vec::new();
+    let mut start = 0;
+    let mut chars = qualified.char_indices().peekable();
+    while let Some((i, c)) = chars.next() {
+        let width = match c {
+            ':' if matches!(chars.peek(), Some((_, ':'))) => {
+                chars.next();
+                2
+            }
+            '.' | '#' | '\\' => 1,
+            _ => continue,
+        };
+        segments.push(&qualified[start..i]);
+        start = i + width;
+    }
+    segments.push(&qualified[start..]);
+    segments
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::{Location, SymbolKind, Visibility};
+    use std::path::PathBuf;
+
+    fn symbol(qualified: &str, language: &str) -> Symbol {
+        Symbol::new(
+            qualified
+                .rsplit(['.', ':', '#'])
+                .next()
+                .unwrap()
+                .to_string(),
+            qualified.to_string(),
+            SymbolKind::Function,
+            Location::new(PathBuf::from("src/lib"), 1, 1),
+            Visibility::Public,
+            language.to_string(),
+        )
+    }
+
+    #[test]
+    fn test_fixed_schemes_rewrite_every_language_separator() {
+        let cases = [
+            (symbol("main.Server.Start", "go"), "main/Server/Start"),
+            (symbol("geo::Shape::draw", "cpp"), "geo/Shape/draw"),
+            (
+                symbol("Billing::Invoice#total", "ruby"),
+                "Billing/Invoice/total",
+            ),
+            (symbol("App\\Models\\User", "php"), "App/Models/User"),
+        ];
+        for (symbol, slashed) in &cases {
+            assert_eq!(NameScheme::Slash.render(symbol), *slashed);
+            assert_eq!(NameScheme::Native.render(symbol), symbol.qualified);
+            assert_eq!(
+                NameScheme::Colons.render(symbol),
+                slashed.replace('/', "::")
+            );
+            assert_eq!(NameScheme::Dotted.render(symbol), slashed.replace('/', "."));
+        }
+    }
+
+    #[test]
+    fn test_custom_scheme_renders_names_found_in_the_index() {
+        let mut index = CodeIndex::new();
+        index.add_symbol(symbol("main.run", "go"));
+        let scheme = NameScheme::custom(|s| s.name.to_uppercase());
+
+        assert_eq!(scheme.render_in(&index, "main.run"), "RUN");
+        assert_eq!(scheme.render_in(&index, "main.missing"), "main.missing");
+        assert_eq!(
+            NameScheme::Colons.render_in(&index, "main.missing"),
+            "main::missing"
+        );
+    }
+
+    #[test]
+    fn test_schemes_parse_from_names() {
+        assert!(matches!("colons".parse(), Ok(NameScheme::Colons)));
+        assert!("kebab".parse::<NameScheme>().is_err());
+        let scheme: NameScheme = serde_json::from_str("\"slash\"").unwrap();
+        assert_eq!(scheme.separator(), Some("/"));
+    }
+}
@@ -0,0 +1,222 @@
+//! Per-file symbol outline for editor outline views.
+//!
+//! [`CodeIndex::file_outline`] arranges the symbols defined in one file as a
+//! tree in source order. A symbol is nested under the symbol in the same file
+//! whose qualified name is its own minus the last segment (`.` or `::`), so
+//! struct fields and methods land under their type even when, as with Go
+//! methods, they are declared after the type's closing brace. Symbols whose
+//! container is not in the file are top-level. Each item spans the lines of
+//! the symbol's whole declaration ([`Symbol::lines`]).
+//!
+//! # Examples
+//!
+//! ```
+//! use rocketindex::{CodeIndex, Location, Symbol, SymbolKind, Visibility};
+//! use std::path::{Path, PathBuf};
+//!
+//! let symbol = |name: &str, qualified: &str, kind, line, end_line| {
+//!     Symbol::new(
+//!         name.to_string(),
+//!         qualified.to_string(),
+//!         kind,
+//!         Location::new(PathBuf::from("user.go"), line, 6),
+//!         Visibility::Public,
+//!         "go".to_string(),
+//!     )
+//!     .with_extent(line, end_line)
+//! };
+//!
+//! let mut index = CodeIndex::new();
+//! index.add_symbol(symbol("User", "main.User", SymbolKind::Class, 3, 6));
+//! index.add_symbol(symbol("FullInfo", "main.User.FullInfo", SymbolKind::Function, 12, 14));
+//! index.add_symbol(symbol("NewUser", "main.NewUser", SymbolKind::Function, 8, 10));
+//!
+//! let outline = index.file_outline(Path::new("user.go"));
+//! assert_eq!(outline[0].symbol.name, "User");
+//! assert_eq!(outline[0].children[0].symbol.name, "FullInfo");
+//! assert_eq!(outline[1].symbol.name, "NewUser");
+//! assert_eq!((outline[1].start_line, outline[1].end_line), (8, 10));
+//! ```
+
+use std::collections::HashMap;
+use std::path::Path;
+
+use serde::Serialize;
+
+use crate::{CodeIndex, Symbol};
+
+/// A symbol in a file outline, with the symbols nested under it.
+#[derive(Debug, Clone, Serialize)]
+pub struct OutlineItem<'a> {
+    pub symbol: &'a Symbol,
+    /// First line of the symbol's declaration (1-indexed)
+    pub start_line: u32,
+    /// Last line of the symbol's declaration (1-indexed, inclusive)
+    pub end_line: u32,
+    /// Nested symbols in source order
+    pub children: Vec<OutlineItem<'a>>,
+}
+
+impl CodeIndex {
+    /// The symbols defined in `file` as a tree, in source order.
+    ///
+    /// The file path can be either absolute or relative. Returns an empty
+    /// list if the file is not indexed.
+    #[must_use]
+    pub fn file_outline(&self, file: &Path) -> Vec<OutlineItem<'_>> {
+        let relative = self.to_relative(file);
+        let mut symbols: Vec<&Symbol> = self
+            .symbols_in_file(file)
+            .into_iter()
+            .filter(|s| s.location.file == relative)
+            .collect();
+        symbols.sort_by(|a, b| {
+            let ((a_line, a_end), (b_line, b_end)) = (a.lines(), b.lines());
+            a_line
+                .cmp(&b_line)
+                .then(a.location.column.cmp(&b.location.column))
+                // An enclosing symbol that starts at the same place comes first
+                .then(b_end.cmp(&a_end))
+        });
+
+        // The first (outermost) symbol of each qualified name
+        let mut by_name: HashMap<&str, usize> = HashMap::new();
+        for (i, symbol) in symbols.iter().enumerate() {
+            by_name.entry(symbol.qualified.as_str()).or_insert(i);
+        }
+
+        let mut children: Vec<Vec<usize>> = vec![Vec::new(); symbols.len()];
+        let mut roots = Vec::new();
+        for (i, symbol) in symbols.iter().enumerate() {
+            match container(&symbol.qualified).and_then(|c| by_name.get(c)) {
+                Some(&parent) if parent != i => children[parent].push(i),
+                _ => roots.push(i),
+            }
+        }
+
+        roots
+            .into_iter()
+            .map(|i| build(i, &symbols, &children))
+            .collect()
+    }
+}
+
+/// `main.User.Name` -> `main.User`, `shapes::Circle::area` -> `shapes::Circle`.
+fn container(qualified: &str) -> Option<&str> {
+    let dot = qualified.rfind('.');
+    let colons = qualified.rfind("::");
+    match (dot, colons) {
+        (Some(d), Some(c)) if c > d => Some(&qualified[..c]),
+        (Some(d), _) => Some(&qualified[..d]),
+        (None, Some(c)) => Some(&qualified[..c]),
+        (None, None) => None,
+    }
+}
+
+fn build<'a>(i: usize, symbols: &[&'a Symbol], children: &[Vec<usize>]) -> OutlineItem<'a> {
+    let symbol = symbols[i];
+    let (start_line, end_line) = symbol.lines();
+    OutlineItem {
+        symbol,
+        start_line,
+        end_line,
+        children: children[i]
+            .iter()
+            .map(|&child| build(child, symbols, children))
+            .collect(),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::indexer::IndexOptions;
+    use crate::{Location, SymbolKind, Visibility};
+    use std::path::PathBuf;
+
+    fn symbol(qualified: &str, file: &str, line: u32, end_line: u32) -> Symbol {
+        let name = qualified.rsplit([':', '.']).next().unwrap();
+        Symbol::new(
+            name.to_string(),
+            qualified.to_string(),
+            SymbolKind::Function,
+            Location::with_end(PathBuf::from(file), line, 1, end_line, 1),
+            Visibility::Public,
+            "rust".to_string(),
+        )
+    }
+
+    fn shape(items: &[OutlineItem<'_>]) -> Vec<String> {
+        items
+            .iter()
+            .map(|item| {
+                if item.children.is_empty() {
+                    item.symbol.name.clone()
+                } else {
+                    format!("{}({})", item.symbol.name, shape(&item.children).join(" "))
+                }
+            })
+            .collect()
+    }
+
+    #[test]
+    fn test_outline_nests_by_qualified_name_in_source_order() {
+        let mut index = CodeIndex::new();
+        for s in [
+            symbol("shapes::Circle::area", "shapes.rs", 12, 14),
+            symbol("shapes::Circle", "shapes.rs", 3, 6),
+            symbol("shapes::Circle::radius", "shapes.rs", 4, 4),
+            symbol("shapes::unit", "shapes.rs", 8, 10),
+            symbol("shapes::Circle::Kind", "shapes.rs", 16, 20),
+            symbol("shapes::Circle::Kind::Round", "shapes.rs", 17, 17),
+            // Defined in another file: neither listed nor a container
+            symbol("shapes::Square", "square.rs", 1, 5),
+            symbol("shapes::Square::side", "shapes.rs", 22, 24),
+        ] {
+            index.add_symbol(s);
+        }
+
+        let outline = index.file_outline(Path::new("shapes.rs"));
+        assert_eq!(
+            shape(&outline),
+            vec!["Circle(radius area Kind(Round))", "unit", "side"]
+        );
+        assert_eq!((outline[0].start_line, outline[0].end_line), (3, 6));
+        assert!(index.file_outline(Path::new("missing.rs")).is_empty());
+    }
+
+    #[test]
+    fn test_outline_keeps_overloads_in_their_own_file() {
+        let mut index = CodeIndex::new();
+        index.add_symbol(symbol("app.run", "a.py", 1, 2));
+        index.add_symbol(symbol("app.run", "b.py", 5, 6));
+
+        let outline = index.file_outline(Path::new("b.py"));
+        assert_eq!(outline.len(), 1);
+        assert_eq!(outline[0].start_line, 5);
+    }
+
+    #[test]
+    fn test_outline_of_go_sample_user() {
+        let root = Path::new(env!("CARGO_MANIFEST_DIR"))
+            .join("../../tests/claude-plugin-integration/test-repos/go-sample");
+        let mut index = CodeIndex::with_root(root.clone());
+        index.update_files(&[root.join("user.go")], &IndexOptions::default());
+
+        let outline = index.file_outline(&root.join("user.go"));
+        assert_eq!(
+            shape(&outline),
+            vec!["User(Name Email FullInfo)", "NewUser"]
+        );
+        assert_eq!((outline[0].start_line, outline[0].end_line), (3, 6));
+        assert_eq!(outline[1].start_line, 8);
+    }
+
+    #[test]
+    fn test_container() {
+        assert_eq!(container("main.User.Name"), Some("main.User"));
+        assert_eq!(container("a::b::c"), Some("a::b"));
+        assert_eq!(container("Mod::Class.method"), Some("Mod::Class"));
+        assert_eq!(container("main"), None);
+    }
+}
@@ -0,0 +1,504 @@
+//! Package dependency graph: which packages import which.
+//!
+//! [`CodeIndex::package_graph`] is a coarser view than the
+//! [`CallGraph`](crate::callgraph::CallGraph): each indexed file is assigned to
+//! the package its top-level symbols live in (`billing.ProcessPayment` belongs
+//! to `billing`), and every import statement in the file becomes an
+//! [`ImportEdge`] from that package to the imported one.
+//!
+//! Imports are matched to indexed packages by exact name, then by directory
+//! (the Go import path `example.com/app/billing` matches a package whose files
+//! live in `billing/`), then by last path segment. Imports that match nothing
+//! become external packages, such as the standard library.
+//!
+//! [`PackageGraph::cycles`] reports import cycles between indexed packages
+//! together with the edges that form them, so a cycle introduced mid-refactor
+//! can be traced back to the import statements that need to change.
+//!
+//! # Examples
+//!
+//! ```
+//! use rocketindex::{CodeIndex, Location, Symbol, SymbolKind, Visibility};
+//! use std::path::PathBuf;
+//!
+//! let mut index = CodeIndex::new();
+//! for (package, file) in [("billing", "billing/pay.go"), ("users", "users/user.go")] {
+//!     index.add_symbol(Symbol::new(
+//!         "Run".to_string(),
+//!         format!("{}.Run", package),
+//!         SymbolKind::Function,
+//!         Location::new(PathBuf::from(file), 3, 6),
+//!         Visibility::Public,
+//!         "go".to_string(),
+//!     ));
+//! }
+//! index.add_open(PathBuf::from("billing/pay.go"), "example.com/app/users".to_string());
+//! index.add_open(PathBuf::from("users/user.go"), "example.com/app/billing".to_string());
+//!
+//! let graph = index.package_graph();
+//! assert_eq!(graph.imports_of("billing")[0].to, "users");
+//!
+//! let cycles = graph.cycles();
+//! assert_eq!(cycles[0].packages, vec!["billing", "users"]);
+//! assert_eq!(cycles[0].edges[0].file, PathBuf::from("billing/pay.go"));
+//! ```
+
+use std::collections::{BTreeMap, BTreeSet, HashMap};
+use std::path::{Path, PathBuf};
+
+use serde::Serialize;
+
+use crate::CodeIndex;
+
+/// A package in the graph.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct PackageNode {
+    /// Package name as it appears in qualified symbol names
+    pub name: String,
+    /// Indexed files belonging to the package (empty for external packages)
+    pub files: Vec<PathBuf>,
+    /// Whether the package is imported but not defined in the index
+    pub external: bool,
+}
+
+/// One import of a package by a file of another package.
+#[derive(Debug, Clone, PartialEq, Eq, PartialOrd, Ord, Serialize)]
+pub struct ImportEdge {
+    /// Importing package
+    pub from: String,
+    /// Imported package
+    pub to: String,
+    /// File containing the import (relative to workspace root)
+    pub file: PathBuf,
+    /// The import as written, e.g. `example.com/app/billing`
+    pub import: String,
+}
+
+/// A set of packages that import each other, directly or transitively.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct ImportCycle {
+    /// Packages in the cycle, sorted by name
+    pub packages: Vec<String>,
+    /// Imports between packages of the cycle; removing enough of them breaks it
+    pub edges: Vec<ImportEdge>,
+}
+
+/// Import dependencies between packages, built by [`CodeIndex::package_graph`].
+#[derive(Debug, Clone, Default)]
+pub struct PackageGraph {
+    /// Packages sorted by name
+    packages: Vec<PackageNode>,
+    /// Edges sorted by (from, to, file, import)
+    edges: Vec<ImportEdge>,
+}
+
+impl CodeIndex {
+    /// Build the package import graph from each file's import statements.
+    ///
+    /// Files whose symbols carry no package prefix (e.g. top-level Python
+    /// scripts) are left out.
+    #[must_use]
+    pub fn package_graph(&self) -> PackageGraph {
+        let mut package_files: BTreeMap<String, Vec<PathBuf>> = BTreeMap::new();
+        let mut file_packages: Vec<(PathBuf, String)> = Vec::new();
+        for file in self.files() {
+            if let Some(package) = self.file_package(file) {
+                package_files
+                    .entry(package.clone())
+                    .or_default()
+                    .push(file.clone());
+                file_packages.push((file.clone(), package));
+            }
+        }
+        for files in package_files.values_mut() {
+            files.sort();
+        }
+
+        let matcher = ImportMatcher::new(&package_files);
+        let mut edges = BTreeSet::new();
+        let mut external = BTreeSet::new();
+        for (file, package) in &file_packages {
+            for import in self.opens_for_file(file) {
+                let target = match matcher.resolve(import) {
+                    Some(target) => target.to_string(),
+                    None => {
+                        external.insert(import.clone());
+                        import.clone()
+                    }
+                };
+                if target == *package {
+                    continue;
+                }
+                edges.insert(ImportEdge {
+                    from: package.clone(),
+                    to: target,
+                    file: file.clone(),
+                    import: import.clone(),
+                });
+            }
+        }
+
+        let mut packages: Vec<PackageNode> = package_files
+            .into_iter()
+            .map(|(name, files)| PackageNode {
+                name,
+                files,
+                external: false,
+            })
+            .chain(external.into_iter().map(|name| PackageNode {
+                name,
+                files: Vec::new(),
+                external: true,
+            }))
+            .collect();
+        packages.sort_by(|a, b| a.name.cmp(&b.name));
+
+        PackageGraph {
+            packages,
+            edges: edges.into_iter().collect(),
+        }
+    }
+
+    /// The package a file belongs to: the most common module prefix of its
+    /// top-level symbols (ties go to the alphabetically first).
+    fn file_package(&self, file: &Path) -> Option<String> {
+        let mut counts: BTreeMap<&str, usize> = BTreeMap::new();
+        for symbol in self.symbols_in_file(file) {
+            if symbol.parent.is_some() || symbol.location.file != file {
+                continue;
+            }
+            if let Some((module, _)) = symbol.qualified.rsplit_once('.') {
+                *counts.entry(module).or_default() += 1;
+            }
+        }
+        let best = counts.values().copied().max()?;
+        counts
+            .into_iter()
+            .find(|&(_, count)| count == best)
+            .map(|(module, _)| module.to_string())
+    }
+}
+
+impl PackageGraph {
+    /// All packages, indexed and external, sorted by name.
+    pub fn packages(&self) -> &[PackageNode] {
+        &self.packages
+    }
+
+    /// All import edges, sorted by importing package, imported package, then file.
+    pub fn edges(&self) -> &[ImportEdge] {
+        &self.edges
+    }
+
+    /// Look up a package by name.
+    pub fn package(&self, name: &str) -> Option<&PackageNode> {
+        self.packages
+            .binary_search_by(|p| p.name.as_str().cmp(name))
+            .ok()
+            .map(|i| &self.packages[i])
+    }
+
+    /// Imports made by files of `package`.
+    pub fn imports_of(&self, package: &str) -> Vec<&ImportEdge> {
+        self.edges.iter().filter(|e| e.from == package).collect()
+    }
+
+    /// Imports of `package` made by files of other packages.
+    pub fn importers_of(&self, package: &str) -> Vec<&ImportEdge> {
+        self.edges.iter().filter(|e| e.to == package).collect()
+    }
+
+    /// Import cycles between indexed packages, sorted by their first package.
+    ///
+    /// Each cycle is a strongly connected component of the graph, so packages
+    /// caught in several overlapping cycles are reported once, together.
+    pub fn cycles(&self) -> Vec<ImportCycle> {
+        let names: Vec<&str> = self.packages.iter().map(|p| p.name.as_str()).collect();
+        let ids: HashMap<&str, usize> = names.iter().enumerate().map(|(i, n)| (*n, i)).collect();
+        let mut successors: Vec<Vec<usize>> = vec![Vec::new(); names.len()];
+        for edge in &self.edges {
+            let (from, to) = (ids[edge.from.as_str()], ids[edge.to.as_str()]);
+            if successors[from].last() != Some(&to) {
+                successors[from].push(to);
+            }
+        }
+
+        let mut cycles: Vec<ImportCycle> = strongly_connected(&successors)
+            .into_iter()
+            .filter(|component| component.len() > 1)
+            .map(|component| {
+                let mut packages: Vec<String> =
+                    component.iter().map(|&i| names[i].to_string()).collect();
+                packages.sort();
+                let edges = self
+                    .edges
+                    .iter()
+                    .filter(|e| {
+                        packages.binary_search(&e.from).is_ok()
+                            && packages.binary_search(&e.to).is_ok()
+                    })
+                    .cloned()
+                    .collect();
+                ImportCycle { packages, edges }
+            })
+            .collect();
+        cycles.sort_by(|a, b| a.packages.cmp(&b.packages));
+        cycles
+    }
+}
+
+/// Maps import strings to indexed package names.
+struct ImportMatcher<'a> {
+    /// Package names, for exact matches
+    names: BTreeSet<&'a str>,
+    /// Directory (relative, `/`-separated) -> package defined there
+    directories: Vec<(String, &'a str)>,
+}
+
+impl<'a> ImportMatcher<'a> {
+    fn new(package_files: &'a BTreeMap<String, Vec<PathBuf>>) -> Self {
+        let mut directories = Vec::new();
+        for (package, files) in package_files {
+            for file in files {
+                let dir = file
+                    .parent()
+                    .map(|d| d.to_string_lossy().replace('\\', "/"))
+                    .unwrap_or_default();
+                if !dir.is_empty() {
+                    directories.push((dir, package.as_str()));
+                }
+            }
+        }
+        // Longest directory first, so the most specific match wins
+        directories.sort_by(|a, b| b.0.len().cmp(&a.0.len()).then_with(|| a.cmp(b)));
+        directories.dedup();
+
+        Self {
+            names: package_files.keys().map(|k| k.as_str()).collect(),
+            directories,
+        }
+    }
+
+    fn resolve(&self, import: &str) -> Option<&'a str> {
+        if let Some(name) = self.names.get(import) {
+            return Some(*name);
+        }
+
+        let path = import.trim_matches('"').replace('\\', "/");
+        let by_directory = self.directories.iter().find(|(dir, _)| {
+            path == *dir
+                || path
+                    .strip_suffix(dir.as_str())
+                    .is_some_and(|rest| rest.ends_with('/'))
+        });
+        if let Some((_, package)) = by_directory {
+            return Some(package);
+        }
+
+        let last = path.rsplit(['/', '.']).next().unwrap_or(&path);
+        if last != import {
+            return self.names.get(last).copied();
+        }
+        None
+    }
+}
+
+/// Tarjan's strongly connected components over an adjacency list.
+///
+/// Shared with [`crate::callgraph::CallGraph::cycles`].
+pub(crate) fn strongly_connected(successors: &[Vec<usize>]) -> Vec<Vec<usize>> {
+    struct State<'a> {
+        successors: &'a [Vec<usize>],
+        index: Vec<Option<usize>>,
+        low: Vec<usize>,
+        on_stack: Vec<bool>,
+        stack: Vec<usize>,
+        next: usize,
+        components: Vec<Vec<usize>>,
+    }
+
+    fn connect(state: &mut State<'_>, node: usize) {
+        state.index[node] = Some(state.next);
+        state.low[node] = state.next;
+        state.next += 1;
+        state.stack.push(node);
+        state.on_stack[node] = true;
+
+        for &succ in &state.successors[node] {
+            match state.index[succ] {
+                None => {
+                    connect(state, succ);
+                    state.low[node] = state.low[node].min(state.low[succ]);
+                }
+                Some(succ_index) if state.on_stack[succ] => {
+                    state.low[node] = state.low[node].min(succ_index);
+                }
+                Some(_) => {}
+            }
+        }
+
+        if Some(state.low[node]) == state.index[node] {
+            let mut component = Vec::new();
+            while let Some(member) = state.stack.pop() {
+                state.on_stack[member] = false;
+                component.push(member);
+                if member == node {
+                    break;
+                }
+            }
+            state.components.push(component);
+        }
+    }
+
+    let count = successors.len();
+    let mut state = State {
+        successors,
+        index: vec![None; count],
+        low: vec![0; count],
+        on_stack: vec![false; count],
+        stack: Vec::new(),
+        next: 0,
+        components: Vec::new(),
+    };
+    for node in 0..count {
+        if state.index[node].is_none() {
+            connect(&mut state, node);
+        }
+    }
+    state.components
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::{Location, Symbol, SymbolKind, Visibility};
+
+    fn add_package_file(index: &mut CodeIndex, package: &str, file: &str, imports: &[&str]) {
+        index.add_symbol(Symbol::new(
+            "Run".to_string(),
+            format!("{}.Run", package),
+            SymbolKind::Function,
+            Location::new(PathBuf::from(file), 3, 6),
+            Visibility::Public,
+            "go".to_string(),
+        ));
+        for import in imports {
+            index.add_open(PathBuf::from(file), import.to_string());
+        }
+    }
+
+    #[test]
+    fn test_package_graph_resolves_imports_and_externals() {
+        let mut index = CodeIndex::new();
+        add_package_file(
+            &mut index,
+            "main",
+            "main.go",
+            &["fmt", "example.com/app/billing"],
+        );
+        add_package_file(&mut index, "billing", "billing/pay.go", &["strings"]);
+        add_package_file(&mut index, "storage", "internal/db/store.go", &[]);
+        add_package_file(
+            &mut index,
+            "billing",
+            "billing/refund.go",
+            &["example.com/app/internal/db"],
+        );
+
+        let graph = index.package_graph();
+
+        let names: Vec<(&str, bool)> = graph
+            .packages()
+            .iter()
+            .map(|p| (p.name.as_str(), p.external))
+            .collect();
+        assert_eq!(
+            names,
+            vec![
+                ("billing", false),
+                ("fmt", true),
+                ("main", false),
+                ("storage", false),
+                ("strings", true),
+            ]
+        );
+        assert_eq!(graph.package("billing").unwrap().files.len(), 2);
+
+        let edges: Vec<(&str, &str, &Path)> = graph
+            .edges()
+            .iter()
+            .map(|e| (e.from.as_str(), e.to.as_str(), e.file.as_path()))
+            .collect();
+        assert_eq!(
+            edges,
+            vec![
+                ("billing", "storage", Path::new("billing/refund.go")),
+                ("billing", "strings", Path::new("billing/pay.go")),
+                ("main", "billing", Path::new("main.go")),
+                ("main", "fmt", Path::new("main.go")),
+            ]
+        );
+        assert_eq!(graph.importers_of("billing")[0].from, "main");
+        assert!(graph.cycles().is_empty());
+    }
+
+    #[test]
+    fn test_package_graph_reports_cycles_with_edges() {
+        let mut index = CodeIndex::new();
+        add_package_file(&mut index, "a", "a/a.go", &["example.com/m/b"]);
+        add_package_file(&mut index, "b", "b/b.go", &["example.com/m/c"]);
+        add_package_file(&mut index, "c", "c/c.go", &["example.com/m/a", "fmt"]);
+        add_package_file(&mut index, "d", "d/d.go", &["example.com/m/a"]);
+        add_package_file(&mut index, "e", "e/e.go", &["example.com/m/f"]);
+        add_package_file(&mut index, "f", "f/f.go", &["example.com/m/e"]);
+
+        let cycles = index.package_graph().cycles();
+        assert_eq!(cycles.len(), 2);
+        assert_eq!(cycles[0].packages, vec!["a", "b", "c"]);
+        let cut: Vec<(&str, &str, &Path)> = cycles[0]
+            .edges
+            .iter()
+            .map(|e| (e.from.as_str(), e.to.as_str(), e.file.as_path()))
+            .collect();
+        assert_eq!(
+            cut,
+            vec![
+                ("a", "b", Path::new("a/a.go")),
+                ("b", "c", Path::new("b/b.go")),
+                ("c", "a", Path::new("c/c.go")),
+            ]
+        );
+        assert_eq!(cycles[1].packages, vec!["e", "f"]);
+    }
+
+    #[test]
+    fn test_file_package_prefers_top_level_symbols() {
+        let mut index = CodeIndex::new();
+        add_package_file(&mut index, "users", "users/user.go", &[]);
+        index.add_symbol(
+            Symbol::new(
+                "FullInfo".to_string(),
+                "users.User.FullInfo".to_string(),
+                SymbolKind::Function,
+                Location::new(PathBuf::from("users/user.go"), 10, 6),
+                Visibility::Public,
+                "go".to_string(),
+            )
+            .with_parent(Some("users.User".to_string())),
+        );
+        // A script without a package prefix is left out
+        index.add_symbol(Symbol::new(
+            "main".to_string(),
+            "main".to_string(),
+            SymbolKind::Function,
+            Location::new(PathBuf::from("script.py"), 1, 5),
+            Visibility::Public,
+            "python".to_string(),
+        ));
+        index.add_open(PathBuf::from("script.py"), "users".to_string());
+
+        let graph = index.package_graph();
+        let names: Vec<&str> = graph.packages().iter().map(|p| p.name.as_str()).collect();
+        assert_eq!(names, vec!["users"]);
+    }
+}
@@ -51,13 +51,17 @@
 //! assert_eq!(go_result.symbols[0].name, "Hello");
 //! ```
 
+use std::collections::{HashMap, HashSet};
 use std::path::Path;
 
+use crate::annotations::{Annotation, Marker};
+use crate::language_config::LanguageConfigs;
+
 use crate::languages::{
     c, cpp, csharp, fsharp, go, haxe, java, javascript, kotlin, objc, php, python, ruby, rust,
     swift, typescript,
 };
-use crate::{Location, Reference, Symbol};
+use crate::{Extent, KindSet, Location, Reference, Symbol};
 
 /// A syntax error detected during parsing.
 #[derive(Debug, Clone, PartialEq, Eq)]
@@ -92,6 +96,64 @@ pub struct ParseResult {
     pub errors: Vec<SyntaxError>,
     /// Warnings generated during parsing (non-fatal issues like depth limits)
     pub warnings: Vec<ParseWarning>,
+    /// TODO/FIXME/HACK/XXX comments
+    pub annotations: Vec<Annotation>,
+}
+
+impl ParseResult {
+    /// Drop the doc comments of all symbols.
+    ///
+    /// Used when docs are disabled so they are freed before the symbols reach
+    /// the index.
+    pub fn strip_docs(&mut self) {
+        for symbol in &mut self.symbols {
+            symbol.doc = None;
+        }
+    }
+
+    /// Keep only the symbols whose kind is in `kinds`.
+    ///
+    /// References made from inside a dropped function or method are dropped
+    /// too (so the call graph cannot attribute them to a neighbouring
+    /// function), as are references that name a symbol dropped from this
+    /// file. References to symbols in other files are kept; if their target
+    /// was dropped they simply resolve to nothing.
+    pub fn retain_kinds(&mut self, kinds: KindSet) {
+        if kinds.is_all() {
+            return;
+        }
+
+        // Same rule as the call graph: a reference belongs to the last
+        // callable starting on or before its line
+        let mut callables: Vec<(u32, bool)> = self
+            .symbols
+            .iter()
+            .filter(|s| s.kind.is_callable())
+            .map(|s| (s.location.line, kinds.contains(s.kind)))
+            .collect();
+        callables.sort_unstable();
+
+        let kept: HashSet<&str> = self
+            .symbols
+            .iter()
+            .filter(|s| kinds.contains(s.kind))
+            .flat_map(|s| [s.name.as_str(), s.qualified.as_str()])
+            .collect();
+        let dropped: HashSet<String> = self
+            .symbols
+            .iter()
+            .filter(|s| !kinds.contains(s.kind))
+            .flat_map(|s| [s.name.clone(), s.qualified.clone()])
+            .filter(|name| !kept.contains(name.as_str()))
+            .collect();
+
+        self.references.retain(|reference| {
+            let after = callables.partition_point(|(line, _)| *line <= reference.location.line);
+            let in_dropped_callable = after.checked_sub(1).is_some_and(|i| !callables[i].1);
+            !in_dropped_callable && !dropped.contains(&reference.name)
+        });
+        self.symbols.retain(|s| kinds.contains(s.kind));
+    }
 }
 
 /// Trait for language-specific parsers.
@@ -102,6 +164,10 @@ pub trait LanguageParser: Send + Sync {
 /// Extract symbols and references from source code.
 ///
 /// Dispatches to the appropriate language parser based on file extension.
+/// Symbols of a file with a generated-code header are marked as generated
+/// (see [`crate::generated`]), and symbols the parser found no doc comment
+/// for get the one directly above them, if any. Both follow the built-in
+/// [`LanguageConfigs`]; see [`extract_symbols_with`] for others.
 ///
 /// # Arguments
 /// * `file` - Path to the source file (for location tracking and language detection)
@@ -111,13 +177,24 @@ pub trait LanguageParser: Send + Sync {
 /// # Returns
 /// A `ParseResult` containing all extracted symbols, references, and syntax errors.
 pub fn extract_symbols(file: &Path, source: &str, max_depth: usize) -> ParseResult {
+    extract_symbols_with(file, source, max_depth, LanguageConfigs::builtin())
+}
+
+/// [`extract_symbols`] with the generated-code markers and doc comment
+/// syntax of `languages`.
+pub fn extract_symbols_with(
+    file: &Path,
+    source: &str,
+    max_depth: usize,
+    languages: &LanguageConfigs,
+) -> ParseResult {
     let extension = file
         .extension()
         .and_then(|e| e.to_str())
         .unwrap_or_default()
         .to_lowercase();
 
-    match extension.as_str() {
+    let mut result = match extension.as_str() {
         "c" | "h" => c::CParser.extract_symbols(file, source, max_depth),
         "cpp" | "cc" | "cxx" | "hpp" | "hxx" | "hh" => {
             cpp::CppParser.extract_symbols(file, source, max_depth)
@@ -142,7 +219,16 @@ pub fn extract_symbols(file: &Path, source: &str, max_depth: usize) -> ParseResu
             tracing::warn!("Unsupported file extension: {}", extension);
             ParseResult::default()
         }
+    };
+    if languages.is_generated(file, source) {
+        result.mark_generated();
+    }
+    if let Some(config) = languages.for_file(file) {
+        for symbol in result.symbols.iter_mut().filter(|s| s.doc.is_none()) {
+            symbol.doc = config.doc_comment_before(source, symbol.location.line);
+        }
     }
+    result
 }
 
 /// Convert a tree-sitter node position to our Location type.
@@ -158,6 +244,203 @@ pub fn node_to_location(file: &Path, node: &tree_sitter::Node) -> Location {
     )
 }
 
+/// Record the syntax errors in a parsed tree.
+///
+/// Tree-sitter recovers from errors by wrapping unparseable text in `ERROR`
+/// nodes and inserting zero-width `MISSING` nodes, so the rest of the tree is
+/// still usable. Only the outermost error of a nested run is reported.
+pub fn collect_syntax_errors(
+    file: &Path,
+    root: &tree_sitter::Node,
+    source: &[u8],
+) -> Vec<SyntaxError> {
+    let mut errors = Vec::new();
+    if !root.has_error() {
+        return errors;
+    }
+
+    let mut cursor = root.walk();
+    loop {
+        let node = cursor.node();
+        let is_error = node.is_error() || node.is_missing();
+        if is_error {
+            let message = if node.is_missing() {
+                format!("missing `{}`", node.kind())
+            } else {
+                let text = node.utf8_text(source).unwrap_or_default();
+                let first_line = text.lines().next().unwrap_or_default().trim();
+                format!("unexpected `{}`", first_line)
+            };
+            errors.push(SyntaxError {
+                message,
+                location: node_to_location(file, &node),
+            });
+        }
+        if !is_error && node.has_error() && cursor.goto_first_child() {
+            continue;
+        }
+        while !cursor.goto_next_sibling() {
+            if !cursor.goto_parent() {
+                return errors;
+            }
+        }
+    }
+}
+
+/// Remove the comment markers from one line of a comment, keeping the text
+/// as written.
+///
+/// Handles line comments (`//`, `///`, `//!`, `#`) and block comments
+/// (`/* */`); one leading space after a line comment marker is dropped.
+pub fn strip_comment_markers(text: &str) -> &str {
+    let line = ["///", "//!", "//", "#"]
+        .iter()
+        .find_map(|marker| text.strip_prefix(marker));
+    if let Some(line) = line {
+        line.strip_prefix(' ').unwrap_or(line).trim_end()
+    } else {
+        text.trim_start_matches("/*").trim_end_matches("*/").trim()
+    }
+}
+
+/// Record the TODO/FIXME/HACK/XXX annotations in a parsed tree.
+///
+/// Every comment line starting with a marker (see [`Marker::parse`]) becomes
+/// an annotation. Its symbol is the innermost of `symbols` whose declaration
+/// node contains the comment, matched by the position of the declaration or
+/// of its `name` field, so call this after the symbols are extracted.
+pub fn collect_annotations(
+    file: &Path,
+    root: &tree_sitter::Node,
+    source: &[u8],
+    symbols: &[Symbol],
+) -> Vec<Annotation> {
+    let declared: HashMap<(usize, usize), &str> = symbols
+        .iter()
+        .filter(|s| s.location.file == file)
+        .map(|s| {
+            let position = (
+                (s.location.line as usize).saturating_sub(1),
+                (s.location.column as usize).saturating_sub(1),
+            );
+            (position, s.qualified.as_str())
+        })
+        .collect();
+    let enclosing = |comment: &tree_sitter::Node| -> Option<String> {
+        let mut current = comment.parent();
+        while let Some(node) = current {
+            let name = node.child_by_field_name("name");
+            let found = [Some(node), name].into_iter().flatten().find_map(|n| {
+                let start = n.start_position();
+                declared.get(&(start.row, start.column))
+            });
+            if let Some(qualified) = found {
+                return Some(qualified.to_string());
+            }
+            current = node.parent();
+        }
+        None
+    };
+
+    let mut annotations = Vec::new();
+    let mut cursor = root.walk();
+    loop {
+        let node = cursor.node();
+        let is_comment = node.kind() == "comment" || node.kind().ends_with("_comment");
+        if is_comment {
+            let text = node.utf8_text(source).unwrap_or_default();
+            let start = node.start_position();
+            for (i, line) in text.split('\n').enumerate() {
+                let stripped = strip_comment_markers(line);
+                let Some(marker) = Marker::parse(stripped) else {
+                    continue;
+                };
+                // `stripped` is a subslice of `line`
+                let offset = stripped.as_ptr() as usize - line.as_ptr() as usize + marker.offset;
+                let column = if i == 0 {
+                    start.column + offset
+                } else {
+                    offset
+                };
+                annotations.push(Annotation {
+                    kind: marker.kind,
+                    author: marker.author.map(str::to_string),
+                    text: marker.text.to_string(),
+                    location: Location::new(
+                        file.to_path_buf(),
+                        (start.row + i + 1) as u32,
+                        (column + 1) as u32,
+                    ),
+                    symbol: enclosing(&node),
+                });
+            }
+        }
+        if !is_comment && cursor.goto_first_child() {
+            continue;
+        }
+        while !cursor.goto_next_sibling() {
+            if !cursor.goto_parent() {
+                return annotations;
+            }
+        }
+    }
+}
+
+/// Record the [`Extent`] of each symbol's declaration in a parsed tree.
+///
+/// Parsers locate a symbol at its name. Its declaration is the node whose
+/// `name` field starts there, or for C-style declarators the node whose
+/// chain of `declarator` fields leads there; the outermost such node wins, so
+/// a C function spans its definition rather than just its declarator. Call
+/// this after the symbols are extracted. Symbols already given an extent are
+/// left alone.
+pub fn record_extents(root: &tree_sitter::Node, symbols: &mut [Symbol]) {
+    let mut declarations = Vec::new();
+    let mut cursor = root.walk();
+    loop {
+        let node = cursor.node();
+        let extent = Extent {
+            line: node.start_position().row as u32 + 1,
+            end_line: node.end_position().row as u32 + 1,
+        };
+        let mut names: Vec<tree_sitter::Node> =
+            node.child_by_field_name("name").into_iter().collect();
+        let mut declarator = node.child_by_field_name("declarator");
+        while let Some(inner) = declarator {
+            names.push(inner);
+            names.extend(inner.child_by_field_name("name"));
+            declarator = inner.child_by_field_name("declarator");
+        }
+        for name in names {
+            let start = name.start_position();
+            declarations.push(((start.row as u32 + 1, start.column as u32 + 1), extent));
+        }
+
+        if cursor.goto_first_child() {
+            continue;
+        }
+        while !cursor.goto_next_sibling() {
+            if !cursor.goto_parent() {
+                assign_extents(symbols, &declarations);
+                return;
+            }
+        }
+    }
+}
+
+/// Give each symbol without an extent the first of `declarations` whose name
+/// starts at the symbol's line and column.
+fn assign_extents(symbols: &mut [Symbol], declarations: &[((u32, u32), Extent)]) {
+    let mut by_name: HashMap<(u32, u32), Extent> = HashMap::new();
+    for &(position, extent) in declarations {
+        by_name.entry(position).or_insert(extent);
+    }
+    for symbol in symbols.iter_mut().filter(|s| s.extent.is_none()) {
+        let position = (symbol.location.line, symbol.location.column);
+        symbol.extent = by_name.get(&position).copied();
+    }
+}
+
 /// Find a child node by its kind.
 /// Uses cursor-based iteration for O(n) instead of O(n²) performance.
 pub fn find_child_by_kind<'a>(
@@ -177,3 +460,143 @@ pub fn find_child_by_kind<'a>(
     }
     None
 }
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::callgraph::CallGraph;
+    use crate::{CodeIndex, ReferenceKind, SymbolKind, Visibility};
+    use std::path::PathBuf;
+
+    fn symbol(name: &str, kind: SymbolKind, line: u32) -> Symbol {
+        Symbol::new(
+            name.to_string(),
+            format!("main.{}", name),
+            kind,
+            Location::new(PathBuf::from("main.go"), line, 6),
+            Visibility::Private,
+            "go".to_string(),
+        )
+    }
+
+    fn call(name: &str, line: u32) -> Reference {
+        Reference {
+            name: name.to_string(),
+            location: Location::new(PathBuf::from("main.go"), line, 2),
+            kind: ReferenceKind::Call,
+            target: None,
+            arguments: None,
+        }
+    }
+
+    /// `run` calls `helper` and `build`; `build` (a value holding a func)
+    /// and `Config` are not callables worth keeping for call-graph work.
+    fn sample() -> ParseResult {
+        ParseResult {
+            symbols: vec![
+                symbol("helper", SymbolKind::Function, 1),
+                symbol("Config", SymbolKind::Class, 3),
+                symbol("build", SymbolKind::Value, 5),
+                symbol("run", SymbolKind::Function, 7),
+                symbol("Method", SymbolKind::Member, 12),
+            ],
+            references: vec![call("helper", 8), call("build", 9), call("helper", 13)],
+            ..ParseResult::default()
+        }
+    }
+
+    fn kinds(result: &ParseResult) -> Vec<SymbolKind> {
+        result.symbols.iter().map(|s| s.kind).collect()
+    }
+
+    #[test]
+    fn test_retain_all_kinds_is_a_no_op() {
+        let mut result = sample();
+        result.retain_kinds(KindSet::all());
+        assert_eq!(result.symbols.len(), 5);
+        assert_eq!(result.references.len(), 3);
+    }
+
+    #[test]
+    fn test_retain_kinds_drops_symbols_and_references_to_them() {
+        let mut result = sample();
+        result.retain_kinds(KindSet::only(&[SymbolKind::Function, SymbolKind::Member]));
+        assert_eq!(
+            kinds(&result),
+            vec![
+                SymbolKind::Function,
+                SymbolKind::Function,
+                SymbolKind::Member
+            ]
+        );
+        let names: Vec<&str> = result.references.iter().map(|r| r.name.as_str()).collect();
+        assert_eq!(names, vec!["helper", "helper"]);
+    }
+
+    #[test]
+    fn test_excluded_callables_leave_no_dangling_call_edges() {
+        let mut result = sample();
+        result.retain_kinds(KindSet::all().excluding(&[SymbolKind::Member]));
+
+        // The call from inside the dropped method goes with it
+        assert_eq!(result.references.len(), 2);
+
+        let mut index = CodeIndex::new();
+        index.replace_file(Path::new("main.go"), &result);
+        let graph = CallGraph::build(&index);
+        // Otherwise the call at line 13 would be attributed to `run`
+        let helper_calls: Vec<(&str, u32)> = graph
+            .sites()
+            .iter()
+            .filter(|site| site.callee == "main.helper")
+            .map(|site| (site.caller.as_str(), site.location.line))
+            .collect();
+        assert_eq!(helper_calls, vec![("main.run", 8)]);
+        assert!(graph
+            .sites()
+            .iter()
+            .all(|site| index.get(&site.caller).is_some() && index.get(&site.callee).is_some()));
+    }
+
+    #[test]
+    fn test_first_declaration_at_a_name_gives_the_extent() {
+        let mut symbols = vec![
+            symbol("helper", SymbolKind::Function, 1),
+            symbol("run", SymbolKind::Function, 7).with_extent(7, 7),
+            symbol("local", SymbolKind::Value, 20),
+        ];
+        let outer = Extent {
+            line: 1,
+            end_line: 4,
+        };
+        let declarator = Extent {
+            line: 1,
+            end_line: 1,
+        };
+        assign_extents(
+            &mut symbols,
+            &[((1, 6), outer), ((1, 6), declarator), ((7, 6), outer)],
+        );
+        assert_eq!(symbols[0].extent, Some(outer));
+        assert_eq!(symbols[1].lines(), (7, 7));
+        assert_eq!(symbols[2].extent, None);
+        assert_eq!(symbols[2].lines(), (20, 20));
+    }
+
+    #[test]
+    fn test_parsed_symbols_span_their_declarations() {
+        let go = "package main\n\ntype User struct {\n\tName string\n}\n\nfunc (u *User) Greet() string {\n\treturn \"hi \" + u.Name\n}\n";
+        let result = extract_symbols(Path::new("user.go"), go, 100);
+        let lines = |result: &ParseResult, name: &str| {
+            let symbol = result.symbols.iter().find(|s| s.name == name).unwrap();
+            (symbol.location.line, symbol.lines())
+        };
+        assert_eq!(lines(&result, "User"), (3, (3, 5)));
+        assert_eq!(lines(&result, "Greet"), (7, (7, 9)));
+
+        let python = "class Greeter:\n    def greet(self):\n        return 1\n\nx = 1\n";
+        let result = extract_symbols(Path::new("greeter.py"), python, 100);
+        assert_eq!(lines(&result, "Greeter"), (1, (1, 3)));
+        assert_eq!(lines(&result, "greet"), (2, (2, 3)));
+    }
+}
@@ -0,0 +1,393 @@
+//! Symbol lookup by source position for "go to definition".
+//!
+//! [`CodeIndex::symbol_at`] takes a file and a 1-indexed line and column and
+//! returns the symbol at that position, together with how the position
+//! relates to it:
+//!
+//! - on the name of a definition: that symbol, as a [`PositionRole::Definition`]
+//! - on a reference: the symbol it refers to, as a [`PositionRole::Reference`]
+//! - anywhere else on the lines of a definition: that symbol, as
+//!   [`PositionRole::Enclosing`]
+//!
+//! When spans nest (a method inside a type, a call inside an argument list),
+//! the innermost one wins.
+//!
+//! # Examples
+//!
+//! ```
+//! use rocketindex::position::PositionRole;
+//! use rocketindex::{
+//!     CodeIndex, Location, Reference, ReferenceKind, Symbol, SymbolKind, Visibility,
+//! };
+//! use std::path::{Path, PathBuf};
+//!
+//! let file = PathBuf::from("main.go");
+//! let mut index = CodeIndex::new();
+//! for (name, line, end_line) in [("helper", 5, 7), ("mainFunction", 9, 12)] {
+//!     index.add_symbol(Symbol::new(
+//!         name.to_string(),
+//!         format!("main.{}", name),
+//!         SymbolKind::Function,
+//!         Location::new(file.clone(), line, 6),
+//!         Visibility::Private,
+//!         "go".to_string(),
+//!     )
+//!     .with_extent(line, end_line));
+//! }
+//! // `x := helper()` inside mainFunction
+//! index.add_reference(
+//!     file.clone(),
+//!     Reference {
+//!         name: "helper".to_string(),
+//!         location: Location::with_end(file.clone(), 10, 10, 10, 16),
+//!         kind: ReferenceKind::Call,
+//!         target: None,
+//!         arguments: None,
+//!     },
+//! );
+//!
+//! let at = index.symbol_at(Path::new("main.go"), 10, 12).unwrap();
+//! assert_eq!(at.symbol.qualified, "main.helper");
+//! assert_eq!(at.role, PositionRole::Reference);
+//!
+//! let at = index.symbol_at(Path::new("main.go"), 11, 5).unwrap();
+//! assert_eq!(at.symbol.qualified, "main.mainFunction");
+//! assert_eq!(at.role, PositionRole::Enclosing);
+//! ```
+
+use std::path::Path;
+
+use serde::Serialize;
+
+use crate::{CodeIndex, Location, Reference, Symbol};
+
+/// How a position relates to the symbol found there.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize)]
+#[serde(rename_all = "snake_case")]
+pub enum PositionRole {
+    /// The position is on the symbol's name where it is defined
+    Definition,
+    /// The position is on a reference to the symbol
+    Reference,
+    /// The position is inside the symbol's definition, but not on its name
+    /// or on a resolvable reference
+    Enclosing,
+}
+
+/// The symbol at a source position (see [`CodeIndex::symbol_at`]).
+#[derive(Debug, Clone, Serialize)]
+pub struct SymbolAt<'a> {
+    pub symbol: &'a Symbol,
+    pub role: PositionRole,
+    /// The reference under the position, for [`PositionRole::Reference`]
+    pub reference: Option<&'a Reference>,
+}
+
+impl CodeIndex {
+    /// The symbol defined or referenced at `line`:`column` (both 1-indexed)
+    /// in `file`.
+    ///
+    /// The file path can be either absolute or relative. Returns `None` if
+    /// the position is outside every symbol and reference in the file.
+    #[must_use]
+    pub fn symbol_at(&self, file: &Path, line: u32, column: u32) -> Option<SymbolAt<'_>> {
+        let relative = self.to_relative(file);
+        let position = (line, column);
+        let defined: Vec<&Symbol> = self
+            .symbols_in_file(file)
+            .into_iter()
+            .filter(|s| s.location.file == relative)
+            .collect();
+
+        let on_name = innermost(
+            defined
+                .iter()
+                .copied()
+                .filter(|s| contains(&name_span(&s.location, &s.name), position)),
+            |s| s.location.clone(),
+        );
+        if let Some(symbol) = on_name {
+            return Some(SymbolAt {
+                symbol,
+                role: PositionRole::Definition,
+                reference: None,
+            });
+        }
+
+        let reference = innermost(
+            self.references_in_file(file).iter().filter(|r| {
+                contains(&reference_span(&r.location, &r.name), position)
+                    && r.location.file == relative
+            }),
+            |r| r.location.clone(),
+        );
+        if let Some(symbol) = reference.and_then(|r| self.referenced_symbol(r)) {
+            return Some(SymbolAt {
+                symbol,
+                role: PositionRole::Reference,
+                reference,
+            });
+        }
+
+        let enclosing = innermost(
+            defined
+                .iter()
+                .copied()
+                .filter(|s| contains(&declaration_span(s), position)),
+            declaration_span,
+        );
+        enclosing.map(|symbol| SymbolAt {
+            symbol,
+            role: PositionRole::Enclosing,
+            reference: None,
+        })
+    }
+
+    /// The symbol a reference most likely refers to.
+    ///
+    /// Tries the reference's target, the name as a qualified name, then the
+    /// language resolver, and finally the symbols sharing its last segment,
+    /// preferring ones in the same file.
+    fn referenced_symbol(&self, reference: &Reference) -> Option<&Symbol> {
+        let from_file = &reference.location.file;
+        if let Some(symbol) = reference.target.as_deref().and_then(|t| self.get(t)) {
+            return Some(symbol);
+        }
+        if let Some(symbol) = self.get(&reference.name) {
+            return Some(symbol);
+        }
+        if let Some(result) = self
+            .resolve(&reference.name, from_file)
+            .or_else(|| self.resolve_dotted(&reference.name, from_file))
+        {
+            return Some(result.symbol);
+        }
+
+        let short = last_segment(&reference.name);
+        self.symbols().filter(|s| s.name == short).min_by(|a, b| {
+            (&a.location.file != from_file)
+                .cmp(&(&b.location.file != from_file))
+                .then_with(|| a.qualified.cmp(&b.qualified))
+        })
+    }
+}
+
+/// The name written at the start of `location`.
+fn name_span(location: &Location, name: &str) -> Location {
+    let width = name.chars().count() as u32;
+    Location::with_end(
+        location.file.clone(),
+        location.line,
+        location.column,
+        location.line,
+        location.column + width,
+    )
+}
+
+/// The text a reference covers: its recorded span, or its name if the
+/// parser recorded only a start position.
+fn reference_span(location: &Location, name: &str) -> Location {
+    if (location.end_line, location.end_column) > (location.line, location.column) {
+        location.clone()
+    } else {
+        name_span(location, name)
+    }
+}
+
+/// The text a symbol's declaration covers: every column of the lines of its
+/// [`Extent`](crate::Extent), or its location if the parser recorded none.
+fn declaration_span(symbol: &Symbol) -> Location {
+    match symbol.extent {
+        Some(extent) => Location::with_end(
+            symbol.location.file.clone(),
+            extent.line,
+            1,
+            extent.end_line + 1,
+            1,
+        ),
+        None => symbol.location.clone(),
+    }
+}
+
+/// Whether `position` is in the half-open span `[start, end)` of `location`.
+fn contains(location: &Location, position: (u32, u32)) -> bool {
+    let start = (location.line, location.column);
+    let end = (location.end_line, location.end_column);
+    start <= position && position < end
+}
+
+/// The item whose span starts last, ties broken by the one that ends first.
+fn innermost<'a, T: 'a>(
+    items: impl Iterator<Item = &'a T>,
+    location: impl Fn(&T) -> Location,
+) -> Option<&'a T> {
+    items.max_by(|a, b| {
+        let (a, b) = (location(a), location(b));
+        (a.line, a.column)
+            .cmp(&(b.line, b.column))
+            .then_with(|| (b.end_line, b.end_column).cmp(&(a.end_line, a.end_column)))
+    })
+}
+
+fn last_segment(name: &str) -> &str {
+    let tail = name.rsplit("::").next().unwrap_or(name);
+    tail.rsplit('.').next().unwrap_or(tail)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::indexer::IndexOptions;
+    use crate::{ReferenceKind, SymbolKind, Visibility};
+    use std::path::PathBuf;
+
+    /// A symbol named at `span`'s start whose declaration runs to its end line.
+    fn symbol(qualified: &str, kind: SymbolKind, span: (u32, u32, u32, u32)) -> Symbol {
+        let name = qualified.rsplit('.').next().unwrap();
+        let end_column = span.1 + name.len() as u32;
+        Symbol::new(
+            name.to_string(),
+            qualified.to_string(),
+            kind,
+            Location::with_end(PathBuf::from("main.go"), span.0, span.1, span.0, end_column),
+            Visibility::Private,
+            "go".to_string(),
+        )
+        .with_extent(span.0, span.2)
+    }
+
+    fn reference(name: &str, target: Option<&str>, span: (u32, u32, u32, u32)) -> Reference {
+        Reference {
+            name: name.to_string(),
+            location: Location::with_end(PathBuf::from("main.go"), span.0, span.1, span.2, span.3),
+            kind: ReferenceKind::Call,
+            target: target.map(str::to_string),
+            arguments: None,
+        }
+    }
+
+    /// Mirrors tests/fixtures/minimal/go/main.go
+    fn minimal_go_index() -> CodeIndex {
+        let mut index = CodeIndex::new();
+        for s in [
+            symbol("main.helper", SymbolKind::Function, (5, 6, 7, 2)),
+            symbol("main.mainFunction", SymbolKind::Function, (9, 6, 12, 2)),
+            symbol("main.MyStruct", SymbolKind::Class, (23, 6, 25, 2)),
+            symbol("main.MyStruct.Field", SymbolKind::Member, (24, 5, 24, 14)),
+            symbol("main.NewMyStruct", SymbolKind::Function, (27, 6, 29, 2)),
+        ] {
+            index.add_symbol(s);
+        }
+        for r in [
+            // x := helper()
+            reference("helper", None, (10, 10, 10, 16)),
+            // fmt.Println(x)
+            reference("fmt.Println", None, (11, 5, 11, 16)),
+            // &MyStruct{Field: helper()}
+            reference("MyStruct", None, (28, 13, 28, 21)),
+            reference("helper", None, (28, 29, 28, 35)),
+        ] {
+            index.add_reference(PathBuf::from("main.go"), r);
+        }
+        index
+    }
+
+    fn at(index: &CodeIndex, line: u32, column: u32) -> Option<(&str, PositionRole)> {
+        index
+            .symbol_at(Path::new("main.go"), line, column)
+            .map(|at| (at.symbol.qualified.as_str(), at.role))
+    }
+
+    #[test]
+    fn test_reference_resolves_to_definition() {
+        let index = minimal_go_index();
+        assert_eq!(
+            at(&index, 10, 10),
+            Some(("main.helper", PositionRole::Reference))
+        );
+        assert_eq!(
+            at(&index, 10, 15),
+            Some(("main.helper", PositionRole::Reference))
+        );
+        // Just past the name is back in the enclosing function
+        assert_eq!(
+            at(&index, 10, 16),
+            Some(("main.mainFunction", PositionRole::Enclosing))
+        );
+
+        let found = index.symbol_at(Path::new("main.go"), 28, 30).unwrap();
+        assert_eq!(found.symbol.qualified, "main.helper");
+        assert_eq!(found.reference.unwrap().location.line, 28);
+        assert_eq!(
+            at(&index, 28, 15),
+            Some(("main.MyStruct", PositionRole::Reference))
+        );
+    }
+
+    #[test]
+    fn test_definitions_and_innermost_enclosing_symbol() {
+        let index = minimal_go_index();
+        assert_eq!(
+            at(&index, 5, 8),
+            Some(("main.helper", PositionRole::Definition))
+        );
+        assert_eq!(
+            at(&index, 24, 5),
+            Some(("main.MyStruct.Field", PositionRole::Definition))
+        );
+        // Inside the field's span but past its name
+        assert_eq!(
+            at(&index, 24, 12),
+            Some(("main.MyStruct.Field", PositionRole::Enclosing))
+        );
+        assert_eq!(
+            at(&index, 25, 1),
+            Some(("main.MyStruct", PositionRole::Enclosing))
+        );
+        // An unresolved call falls back to the enclosing function
+        assert_eq!(
+            at(&index, 11, 9),
+            Some(("main.mainFunction", PositionRole::Enclosing))
+        );
+        assert_eq!(at(&index, 2, 1), None);
+        assert!(index.symbol_at(Path::new("other.go"), 10, 10).is_none());
+    }
+
+    #[test]
+    fn test_reference_target_and_same_file_preference() {
+        let mut index = minimal_go_index();
+        let mut elsewhere = symbol("util.helper", SymbolKind::Function, (1, 6, 3, 2));
+        elsewhere.location.file = PathBuf::from("util/util.go");
+        index.add_symbol(elsewhere);
+        index.add_reference(
+            PathBuf::from("main.go"),
+            reference("util.helper", Some("util.helper"), (20, 5, 20, 16)),
+        );
+
+        // The bare name prefers the definition in the same file
+        assert_eq!(at(&index, 10, 12).unwrap().0, "main.helper");
+        assert_eq!(at(&index, 20, 10).unwrap().0, "util.helper");
+    }
+
+    #[test]
+    fn test_symbol_at_in_minimal_go_fixture() {
+        let root = Path::new(env!("CARGO_MANIFEST_DIR")).join("../../tests/fixtures/minimal/go");
+        let mut index = CodeIndex::with_root(root.clone());
+        index.update_files(&[root.join("main.go")], &IndexOptions::default());
+
+        // On `helper` in `x := helper()` inside mainFunction
+        let found = index.symbol_at(&root.join("main.go"), 10, 11).unwrap();
+        assert_eq!(found.symbol.qualified, "main.helper");
+        assert_eq!(found.role, PositionRole::Reference);
+        assert_eq!(found.symbol.location.line, 5);
+
+        // On `return 42` in helper's body, and on mainFunction's closing brace
+        let found = index.symbol_at(&root.join("main.go"), 6, 5).unwrap();
+        assert_eq!(found.symbol.qualified, "main.helper");
+        assert_eq!(found.role, PositionRole::Enclosing);
+        let found = index.symbol_at(&root.join("main.go"), 12, 1).unwrap();
+        assert_eq!(found.symbol.qualified, "main.mainFunction");
+        assert_eq!(found.role, PositionRole::Enclosing);
+        assert!(index.symbol_at(&root.join("main.go"), 8, 1).is_none());
+    }
+}
@@ -0,0 +1,278 @@
+//! Pluggable post-processing of parsed symbols.
+//!
+//! A [`PostProcessor`] enriches symbols with metadata the parsers do not
+//! produce: a tag for symbols following a naming convention, a complexity
+//! score, an owner looked up elsewhere. Register processors in
+//! [`IndexOptions::post_processors`](crate::indexer::IndexOptions::post_processors);
+//! the indexer runs them on every symbol of a file right after it is parsed
+//! (and after [`IndexOptions::kinds`](crate::indexer::IndexOptions::kinds)
+//! filtering), on the worker thread that parsed it. For each symbol the
+//! processors run in registration order, so a later one sees what an earlier
+//! one attached.
+//!
+//! Metadata is stored as `key=value` entries in [`Symbol::attributes`], so
+//! it is persisted and exported with the symbol without a schema change; use
+//! [`Symbol::metadata`] and [`Symbol::set_metadata`] rather than editing the
+//! entries by hand. Keys should not contain `=`.
+//!
+//! Processors see the file through a [`ParseContext`]: its path, source text,
+//! and the references and imports the parser found. The syntax tree itself
+//! is not kept once symbols are extracted; a processor that needs it parses
+//! [`ParseContext::source`] with the grammar it wants.
+//!
+//! [`LineCount`] is a built-in example that records how many lines each
+//! function spans.
+//!
+//! # Examples
+//!
+//! ```no_run
+//! use rocketindex::indexer::IndexOptions;
+//! use rocketindex::postprocess::{LineCount, ParseContext, PostProcessor, PostProcessors};
+//! use rocketindex::{CodeIndex, Symbol};
+//!
+//! struct HandlerTag;
+//!
+//! impl PostProcessor for HandlerTag {
+//!     fn process(&self, symbol: &mut Symbol, _context: &ParseContext<'_>) {
+//!         if symbol.name.ends_with("Handler") {
+//!             symbol.set_metadata("role", "handler");
+//!         }
+//!     }
+//! }
+//!
+//! let mut post_processors = PostProcessors::default();
+//! post_processors.push(LineCount);
+//! post_processors.push(HandlerTag);
+//! let options = IndexOptions {
+//!     post_processors,
+//!     ..IndexOptions::default()
+//! };
+//!
+//! # let files = Vec::new();
+//! let mut index = CodeIndex::new();
+//! index.index_files(&files, &options);
+//! for symbol in index.symbols().filter(|s| s.metadata("role") == Some("handler")) {
+//!     println!("{} spans {:?} lines", symbol.qualified, symbol.metadata("lines"));
+//! }
+//! ```
+
+use std::fmt;
+use std::path::Path;
+use std::sync::Arc;
+
+use crate::parse::ParseResult;
+use crate::{Reference, Symbol};
+
+/// Metadata key [`LineCount`] records a function's line count under.
+pub const LINES: &str = "lines";
+
+/// A hook run on every symbol after its file is parsed.
+pub trait PostProcessor: Send + Sync {
+    /// Inspect or modify `symbol`, typically by attaching metadata with
+    /// [`Symbol::set_metadata`].
+    fn process(&self, symbol: &mut Symbol, context: &ParseContext<'_>);
+}
+
+/// The file a symbol was parsed from, as seen by a [`PostProcessor`].
+#[derive(Debug, Clone, Copy)]
+pub struct ParseContext<'a> {
+    /// Path of the file, as passed to the indexer
+    pub file: &'a Path,
+    /// Full source text of the file
+    pub source: &'a str,
+    /// References the parser found in the file
+    pub references: &'a [Reference],
+    /// Imports and opens the parser found in the file
+    pub opens: &'a [String],
+}
+
+impl<'a> ParseContext<'a> {
+    /// The full source lines of `symbol`'s declaration.
+    pub fn lines_of(&self, symbol: &Symbol) -> impl Iterator<Item = &'a str> {
+        let (line, end_line) = symbol.lines();
+        let start = line.max(1) as usize - 1;
+        let end = end_line as usize;
+        self.source.lines().skip(start).take(end - start)
+    }
+}
+
+/// Post-processors to run, in registration order.
+#[derive(Clone, Default)]
+pub struct PostProcessors(Vec<Arc<dyn PostProcessor>>);
+
+impl PostProcessors {
+    /// Register `processor` to run after those already registered.
+    pub fn push(&mut self, processor: impl PostProcessor + 'static) {
+        self.0.push(Arc::new(processor));
+    }
+
+    /// Whether no processor is registered.
+    pub fn is_empty(&self) -> bool {
+        self.0.is_empty()
+    }
+
+    /// Run every processor on every symbol of `result`, parsed from `file`.
+    pub fn run(&self, file: &Path, source: &str, result: &mut ParseResult) {
+        if self.0.is_empty() {
+            return;
+        }
+        let context = ParseContext {
+            file,
+            source,
+            references: &result.references,
+            opens: &result.opens,
+        };
+        for symbol in &mut result.symbols {
+            for processor in &self.0 {
+                processor.process(symbol, &context);
+            }
+        }
+    }
+}
+
+impl fmt::Debug for PostProcessors {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        write!(f, "PostProcessors({})", self.0.len())
+    }
+}
+
+/// Records the number of lines each function or method spans under
+/// [`LINES`].
+///
+/// The count covers the whole declaration, from the first line of its
+/// signature to the last line of its body (see [`Symbol::lines`]).
+#[derive(Debug, Clone, Copy, Default)]
+pub struct LineCount;
+
+impl PostProcessor for LineCount {
+    fn process(&self, symbol: &mut Symbol, _context: &ParseContext<'_>) {
+        if symbol.kind.is_callable() {
+            let (line, end_line) = symbol.lines();
+            symbol.set_metadata(LINES, end_line - line + 1);
+        }
+    }
+}
+
+impl Symbol {
+    /// The metadata value stored under `key` (see [`Symbol::set_metadata`]).
+    pub fn metadata(&self, key: &str) -> Option<&str> {
+        self.attributes
+            .as_ref()?
+            .iter()
+            .find_map(|attribute| metadata_value(attribute, key))
+    }
+
+    /// Store `value` under `key`, replacing any earlier value.
+    pub fn set_metadata(&mut self, key: &str, value: impl fmt::Display) {
+        let entry = format!("{}={}", key, value);
+        let attributes = self.attributes.get_or_insert_with(Vec::new);
+        match attributes
+            .iter_mut()
+            .find(|attribute| metadata_value(attribute, key).is_some())
+        {
+            Some(existing) => *existing = entry,
+            None => attributes.push(entry),
+        }
+    }
+}
+
+/// The value of a `key=value` attribute, if it is one for `key`.
+fn metadata_value<'a>(attribute: &'a str, key: &str) -> Option<&'a str> {
+    attribute.strip_prefix(key)?.strip_prefix('=')
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::indexer::IndexOptions;
+    use crate::{CodeIndex, Location, SymbolKind, Visibility};
+    use std::path::PathBuf;
+    use std::sync::Mutex;
+
+    fn symbol(name: &str, kind: SymbolKind, line: u32, end_line: u32) -> Symbol {
+        Symbol::new(
+            name.to_string(),
+            format!("main.{}", name),
+            kind,
+            Location::new(PathBuf::from("main.go"), line, 6),
+            Visibility::Public,
+            "go".to_string(),
+        )
+        .with_extent(line, end_line)
+    }
+
+    /// Records the order it saw symbols and what `LineCount` had attached.
+    struct Recorder(Arc<Mutex<Vec<String>>>);
+
+    impl PostProcessor for Recorder {
+        fn process(&self, symbol: &mut Symbol, context: &ParseContext<'_>) {
+            self.0.lock().unwrap().push(format!(
+                "{}:{:?}:{}",
+                symbol.name,
+                symbol.metadata(LINES),
+                context.file.display()
+            ));
+            symbol.set_metadata("seen", true);
+        }
+    }
+
+    #[test]
+    fn test_processors_run_per_symbol_in_registration_order() {
+        let mut result = ParseResult {
+            symbols: vec![
+                symbol("run", SymbolKind::Function, 3, 7),
+                symbol("Config", SymbolKind::Class, 9, 12),
+            ],
+            ..ParseResult::default()
+        };
+        let seen = Arc::new(Mutex::new(Vec::new()));
+        let mut processors = PostProcessors::default();
+        processors.push(LineCount);
+        processors.push(Recorder(seen.clone()));
+
+        processors.run(Path::new("main.go"), "", &mut result);
+
+        assert_eq!(
+            *seen.lock().unwrap(),
+            vec!["run:Some(\"5\"):main.go", "Config:None:main.go"]
+        );
+        assert_eq!(result.symbols[0].metadata(LINES), Some("5"));
+        assert_eq!(result.symbols[1].metadata("seen"), Some("true"));
+    }
+
+    #[test]
+    fn test_set_metadata_replaces_only_its_own_key() {
+        let mut symbol = symbol("run", SymbolKind::Function, 1, 1);
+        symbol.attributes = Some(vec!["template".to_string(), "linesx=9".to_string()]);
+
+        symbol.set_metadata(LINES, 3);
+        symbol.set_metadata(LINES, 4);
+
+        assert_eq!(symbol.metadata(LINES), Some("4"));
+        assert_eq!(symbol.metadata("linesx"), Some("9"));
+        assert_eq!(symbol.metadata("template"), None);
+        assert_eq!(symbol.attributes.as_ref().unwrap().len(), 3);
+    }
+
+    #[test]
+    fn test_line_count_of_parsed_functions() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let file = temp_dir.path().join("pay.py");
+        std::fs::write(
+            &file,
+            "def charge(amount):\n    total = amount\n    return total\n\ndef noop(): pass\n",
+        )
+        .unwrap();
+        let mut post_processors = PostProcessors::default();
+        post_processors.push(LineCount);
+        let options = IndexOptions {
+            post_processors,
+            ..IndexOptions::default()
+        };
+
+        let mut index = CodeIndex::with_root(temp_dir.path().to_path_buf());
+        index.index_files(&[file], &options);
+        assert_eq!(index.get("charge").unwrap().metadata(LINES), Some("3"));
+        assert_eq!(index.get("noop").unwrap().metadata(LINES), Some("1"));
+    }
+}
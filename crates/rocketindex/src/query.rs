@@ -0,0 +1,325 @@
+//! Batched lookups against an in-memory index and its call graph.
+//!
+//! [`CodeIndex::batch_query`] answers a list of mixed [`Query`]s in one call
+//! and returns one [`QueryOutcome`] per query, in order. Callers such as the
+//! MCP server hold their project lock once for the whole batch instead of
+//! once per lookup. A query that fails (an unknown symbol) yields an error in
+//! its own slot and the rest of the batch is still answered. Identical
+//! queries in a batch are answered once.
+//!
+//! Queries deserialize from JSON tagged by `op`:
+//!
+//! ```text
+//! [
+//!   { "op": "definition", "symbol": "main.helper" },
+//!   { "op": "callers", "symbol": "main.helper" },
+//!   { "op": "callees", "symbol": "main.run" },
+//!   { "op": "search", "pattern": "Payment*", "limit": 10 }
+//! ]
+//! ```
+//!
+//! # Examples
+//!
+//! ```
+//! use rocketindex::callgraph::CallGraph;
+//! use rocketindex::query::{Query, QueryResult};
+//! use rocketindex::{CodeIndex, Location, Symbol, SymbolKind, Visibility};
+//! use std::path::PathBuf;
+//!
+//! let mut index = CodeIndex::new();
+//! index.add_symbol(Symbol::new(
+//!     "helper".to_string(),
+//!     "main.helper".to_string(),
+//!     SymbolKind::Function,
+//!     Location::new(PathBuf::from("main.go"), 5, 6),
+//!     Visibility::Private,
+//!     "go".to_string(),
+//! ));
+//! let graph = CallGraph::build(&index);
+//!
+//! let outcomes = index.batch_query(
+//!     &graph,
+//!     &[
+//!         Query::Definition { symbol: "helper".to_string() },
+//!         Query::Callers { symbol: "main.missing".to_string() },
+//!     ],
+//! );
+//! assert!(matches!(&outcomes[0], Ok(QueryResult::Definition(s)) if s[0].qualified == "main.helper"));
+//! assert!(outcomes[1].is_err());
+//! ```
+
+use std::collections::HashMap;
+
+use serde::{Deserialize, Serialize};
+
+use crate::callgraph::{CallGraph, CallSite};
+use crate::{CodeIndex, Symbol};
+
+/// Default maximum number of results for [`Query::Search`].
+pub const DEFAULT_SEARCH_LIMIT: usize = 20;
+
+/// One lookup in a batch.
+#[derive(Debug, Clone, PartialEq, Eq, Hash, Serialize, Deserialize)]
+#[serde(tag = "op", rename_all = "snake_case")]
+pub enum Query {
+    /// Where is `symbol` defined? A qualified name, or a bare name when
+    /// no symbol has that qualified name.
+    Definition { symbol: String },
+    /// Which call sites call `symbol` (a qualified name)?
+    Callers { symbol: String },
+    /// Which call sites does `symbol` (a qualified name) contain?
+    Callees { symbol: String },
+    /// Which symbols match `pattern` (see [`CodeIndex::search`])?
+    Search {
+        pattern: String,
+        #[serde(default = "default_search_limit")]
+        limit: usize,
+    },
+}
+
+fn default_search_limit() -> usize {
+    DEFAULT_SEARCH_LIMIT
+}
+
+/// The answer to one [`Query`], of the matching variant.
+#[derive(Debug, Clone, Serialize)]
+#[serde(tag = "op", content = "results", rename_all = "snake_case")]
+pub enum QueryResult<'a> {
+    /// Every definition (overloads included), in index order
+    Definition(Vec<&'a Symbol>),
+    /// One call site per caller, as [`CallGraph::callers`]
+    Callers(Vec<&'a CallSite>),
+    /// One call site per callee, as [`CallGraph::callees`]
+    Callees(Vec<&'a CallSite>),
+    /// Matching symbols, most relevant first
+    Search(Vec<&'a Symbol>),
+}
+
+/// Why a single query in a batch failed.
+#[derive(Debug, Clone, PartialEq, Eq, thiserror::Error, Serialize)]
+pub enum QueryError {
+    #[error("Symbol not found: {0}")]
+    SymbolNotFound(String),
+}
+
+/// The result of one query in a batch.
+pub type QueryOutcome<'a> = std::result::Result<QueryResult<'a>, QueryError>;
+
+impl CodeIndex {
+    /// Answer every query in `queries` against this index and `call_graph`.
+    ///
+    /// Returns one outcome per query, in the same order.
+    #[must_use]
+    pub fn batch_query<'a>(
+        &'a self,
+        call_graph: &'a CallGraph,
+        queries: &[Query],
+    ) -> Vec<QueryOutcome<'a>> {
+        let mut answered: HashMap<&Query, QueryOutcome<'a>> = HashMap::new();
+        queries
+            .iter()
+            .map(|query| {
+                answered
+                    .entry(query)
+                    .or_insert_with(|| self.answer(call_graph, query))
+                    .clone()
+            })
+            .collect()
+    }
+
+    fn answer<'a>(&'a self, call_graph: &'a CallGraph, query: &Query) -> QueryOutcome<'a> {
+        let not_found = |symbol: &str| QueryError::SymbolNotFound(symbol.to_string());
+        match query {
+            Query::Definition { symbol } => {
+                let mut definitions: Vec<&Symbol> = self.get_all(symbol).iter().collect();
+                if definitions.is_empty() {
+                    definitions = self
+                        .search(symbol)
+                        .into_iter()
+                        .filter(|s| s.name == *symbol)
+                        .collect();
+                }
+                if definitions.is_empty() {
+                    return Err(not_found(symbol));
+                }
+                Ok(QueryResult::Definition(definitions))
+            }
+            Query::Callers { symbol } => {
+                let sites = call_graph.callers(symbol);
+                if sites.is_empty() && self.get_all(symbol).is_empty() {
+                    return Err(not_found(symbol));
+                }
+                Ok(QueryResult::Callers(sites))
+            }
+            Query::Callees { symbol } => {
+                let sites = call_graph.callees(symbol);
+                if sites.is_empty() && self.get_all(symbol).is_empty() {
+                    return Err(not_found(symbol));
+                }
+                Ok(QueryResult::Callees(sites))
+            }
+            Query::Search { pattern, limit } => {
+                let mut symbols = self.search(pattern);
+                symbols.truncate(*limit);
+                Ok(QueryResult::Search(symbols))
+            }
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::{Location, Reference, ReferenceKind, SymbolKind, Visibility};
+    use std::path::PathBuf;
+
+    fn sample() -> (CodeIndex, CallGraph) {
+        let mut index = CodeIndex::new();
+        for (name, line) in [("helper", 1), ("run", 5), ("unused", 9)] {
+            index.add_symbol(Symbol::new(
+                name.to_string(),
+                format!("main.{}", name),
+                SymbolKind::Function,
+                Location::new(PathBuf::from("main.go"), line, 6),
+                Visibility::Private,
+                "go".to_string(),
+            ));
+        }
+        index.add_reference(
+            PathBuf::from("main.go"),
+            Reference {
+                name: "helper".to_string(),
+                location: Location::new(PathBuf::from("main.go"), 6, 5),
+                kind: ReferenceKind::Call,
+                target: None,
+                arguments: None,
+            },
+        );
+        let graph = CallGraph::build(&index);
+        (index, graph)
+    }
+
+    fn symbol(s: &str) -> String {
+        s.to_string()
+    }
+
+    #[test]
+    fn test_batch_answers_mixed_queries_in_order() {
+        let (index, graph) = sample();
+        let queries = [
+            Query::Callers {
+                symbol: symbol("main.helper"),
+            },
+            Query::Definition {
+                symbol: symbol("main.run"),
+            },
+            Query::Callees {
+                symbol: symbol("main.run"),
+            },
+            Query::Search {
+                pattern: symbol("*e*"),
+                limit: 2,
+            },
+            Query::Callers {
+                symbol: symbol("main.unused"),
+            },
+        ];
+        let outcomes = index.batch_query(&graph, &queries);
+        assert_eq!(outcomes.len(), queries.len());
+
+        match &outcomes[0] {
+            Ok(QueryResult::Callers(sites)) => assert_eq!(sites[0].caller, "main.run"),
+            other => panic!("{:?}", other),
+        }
+        match &outcomes[1] {
+            Ok(QueryResult::Definition(symbols)) => assert_eq!(symbols[0].location.line, 5),
+            other => panic!("{:?}", other),
+        }
+        match &outcomes[2] {
+            Ok(QueryResult::Callees(sites)) => assert_eq!(sites[0].callee, "main.helper"),
+            other => panic!("{:?}", other),
+        }
+        match &outcomes[3] {
+            Ok(QueryResult::Search(symbols)) => assert_eq!(symbols.len(), 2),
+            other => panic!("{:?}", other),
+        }
+        // A known symbol nobody calls is an empty answer, not an error
+        match &outcomes[4] {
+            Ok(QueryResult::Callers(sites)) => assert!(sites.is_empty()),
+            other => panic!("{:?}", other),
+        }
+    }
+
+    #[test]
+    fn test_failed_queries_do_not_fail_the_batch() {
+        let (index, graph) = sample();
+        let outcomes = index.batch_query(
+            &graph,
+            &[
+                Query::Definition {
+                    symbol: symbol("main.missing"),
+                },
+                Query::Definition {
+                    symbol: symbol("helper"),
+                },
+                Query::Callees {
+                    symbol: symbol("main.missing"),
+                },
+                Query::Definition {
+                    symbol: symbol("main.missing"),
+                },
+            ],
+        );
+        assert_eq!(
+            outcomes[0].as_ref().unwrap_err(),
+            &QueryError::SymbolNotFound(symbol("main.missing"))
+        );
+        // A bare name falls back to matching symbol names
+        assert!(
+            matches!(&outcomes[1], Ok(QueryResult::Definition(s)) if s[0].qualified == "main.helper")
+        );
+        assert!(outcomes[2].is_err());
+        assert_eq!(
+            outcomes[3].as_ref().unwrap_err().to_string(),
+            "Symbol not found: main.missing"
+        );
+        assert!(index.batch_query(&graph, &[]).is_empty());
+    }
+
+    #[test]
+    fn test_queries_deserialize_from_tagged_json() {
+        let queries: Vec<Query> = serde_json::from_str(
+            r#"[
+                {"op": "definition", "symbol": "main.helper"},
+                {"op": "callers", "symbol": "main.helper"},
+                {"op": "callees", "symbol": "main.run"},
+                {"op": "search", "pattern": "Payment*"}
+            ]"#,
+        )
+        .unwrap();
+        assert_eq!(
+            queries[3],
+            Query::Search {
+                pattern: symbol("Payment*"),
+                limit: DEFAULT_SEARCH_LIMIT,
+            }
+        );
+        assert_eq!(
+            queries[2],
+            Query::Callees {
+                symbol: symbol("main.run")
+            }
+        );
+        assert!(serde_json::from_str::<Query>(r#"{"op": "references", "symbol": "x"}"#).is_err());
+
+        let (index, graph) = sample();
+        let json = serde_json::to_value(
+            index.batch_query(&graph, &queries[..1])[0]
+                .as_ref()
+                .unwrap(),
+        )
+        .unwrap();
+        assert_eq!(json["op"], "definition");
+        assert_eq!(json["results"][0]["qualified"], "main.helper");
+    }
+}
@@ -114,7 +114,7 @@ pub fn kind_weight(kind: SymbolKind) -> u32 {
 pub fn visibility_weight(visibility: Visibility) -> u32 {
     match visibility {
         Visibility::Public => 3,
-        Visibility::Internal => 2,
+        Visibility::Internal | Visibility::Protected => 2,
         Visibility::Private => 1,
     }
 }
@@ -0,0 +1,324 @@
+//! Rename-impact preview: every edit a rename would make, without making it.
+//!
+//! [`CodeIndex::rename_impact`] collects the definition sites of a symbol and
+//! every reference reported by [`CodeIndex::find_references`], then reads the
+//! source files to turn each site into an exact byte range covering just the
+//! old name (so `service.ProcessPayment` yields the range of `ProcessPayment`).
+//! A caller can apply the edits in each file from last to first without
+//! re-parsing. Nothing is written to disk.
+//!
+//! The preview also reports collisions: symbols that already use the new name
+//! in the same scope, such as renaming `main.helper` to `mainFunction` when
+//! `main.mainFunction` exists.
+//!
+//! # Examples
+//!
+//! ```no_run
+//! use rocketindex::CodeIndex;
+//! use std::path::PathBuf;
+//!
+//! let index = CodeIndex::with_root(PathBuf::from("."));
+//! let impact = index.rename_impact("main.helper", "assist").unwrap();
+//! for file in &impact.files {
+//!     for edit in file.edits.iter().rev() {
+//!         println!("{}: replace bytes {}..{}", file.file.display(), edit.start, edit.end);
+//!     }
+//! }
+//! ```
+
+use std::collections::BTreeMap;
+use std::path::{Path, PathBuf};
+
+use serde::Serialize;
+
+use crate::{CodeIndex, IndexError, Location, Result, Symbol};
+
+/// Everything a rename would touch.
+#[derive(Debug, Clone, Serialize)]
+pub struct RenameImpact {
+    /// Qualified name of the symbol being renamed
+    pub symbol: String,
+    pub old_name: String,
+    pub new_name: String,
+    /// Edits grouped by file, sorted by path
+    pub files: Vec<FileEdits>,
+    /// Existing symbols that already use the new name in the same scope
+    pub collisions: Vec<Symbol>,
+    /// Reported sites whose source text no longer matches the old name
+    /// (usually because the file changed since it was indexed)
+    pub unmatched: Vec<Location>,
+}
+
+impl RenameImpact {
+    /// Total number of edits across all files.
+    #[must_use]
+    pub fn edit_count(&self) -> usize {
+        self.files.iter().map(|f| f.edits.len()).sum()
+    }
+
+    /// Whether the new name already exists in the symbol's scope.
+    #[must_use]
+    pub fn has_collisions(&self) -> bool {
+        !self.collisions.is_empty()
+    }
+}
+
+/// Edits within one file.
+#[derive(Debug, Clone, Serialize)]
+pub struct FileEdits {
+    /// Path relative to the workspace root
+    pub file: PathBuf,
+    /// Edits sorted by byte offset, never overlapping
+    pub edits: Vec<RenameEdit>,
+}
+
+/// Replace `start..end` (the old name) with the new name.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct RenameEdit {
+    /// Byte offset of the first byte of the old name
+    pub start: usize,
+    /// Byte offset just past the old name
+    pub end: usize,
+    /// Position of the old name (1-indexed line and column)
+    pub line: u32,
+    pub column: u32,
+    /// Whether this is a definition site rather than a reference
+    pub definition: bool,
+}
+
+impl CodeIndex {
+    /// Preview renaming the symbol `qualified_name` to `new_name`.
+    ///
+    /// Source files are read from disk, relative to the workspace root.
+    /// Returns [`IndexError::SymbolNotFound`] if the symbol is not indexed.
+    pub fn rename_impact(&self, qualified_name: &str, new_name: &str) -> Result<RenameImpact> {
+        let definitions = self.get_all(qualified_name);
+        let Some(first) = definitions.first() else {
+            return Err(IndexError::SymbolNotFound(qualified_name.to_string()));
+        };
+        let old_name = first.name.clone();
+
+        // File -> (location, is definition)
+        let mut sites: BTreeMap<&Path, Vec<(&Location, bool)>> = BTreeMap::new();
+        for symbol in definitions {
+            sites
+                .entry(&symbol.location.file)
+                .or_default()
+                .push((&symbol.location, true));
+        }
+        for reference in self.find_references(qualified_name) {
+            sites
+                .entry(&reference.location.file)
+                .or_default()
+                .push((&reference.location, false));
+        }
+
+        let mut files = Vec::new();
+        let mut unmatched = Vec::new();
+        for (file, locations) in sites {
+            let source = std::fs::read_to_string(self.to_absolute(file))?;
+            let lines = line_starts(&source);
+
+            let mut edits: Vec<RenameEdit> = Vec::new();
+            for (location, definition) in locations {
+                match name_range(&source, &lines, location, &old_name) {
+                    Some((start, end)) => edits.push(RenameEdit {
+                        start,
+                        end,
+                        line: location.end_line,
+                        column: (start - lines[location.end_line as usize - 1] + 1) as u32,
+                        definition,
+                    }),
+                    None => unmatched.push(location.clone()),
+                }
+            }
+            edits.sort_by_key(|e| (e.start, !e.definition));
+            edits.dedup_by_key(|e| e.start);
+
+            if !edits.is_empty() {
+                files.push(FileEdits {
+                    file: file.to_path_buf(),
+                    edits,
+                });
+            }
+        }
+
+        let new_qualified = match qualified_name.rsplit_once('.') {
+            Some((scope, _)) => format!("{}.{}", scope, new_name),
+            None => new_name.to_string(),
+        };
+        let collisions = if new_name == old_name {
+            Vec::new()
+        } else {
+            self.get_all(&new_qualified).to_vec()
+        };
+
+        Ok(RenameImpact {
+            symbol: qualified_name.to_string(),
+            old_name,
+            new_name: new_name.to_string(),
+            files,
+            collisions,
+            unmatched,
+        })
+    }
+}
+
+/// Byte offset of the start of each line.
+fn line_starts(source: &str) -> Vec<usize> {
+    std::iter::once(0)
+        .chain(source.match_indices('\n').map(|(i, _)| i + 1))
+        .collect()
+}
+
+/// Byte offset of a 1-indexed line and (byte) column, if it is inside `source`.
+fn offset(source: &str, lines: &[usize], line: u32, column: u32) -> Option<usize> {
+    let start = *lines.get((line as usize).checked_sub(1)?)?;
+    let offset = start + (column as usize).checked_sub(1)?;
+    (offset <= source.len()).then_some(offset)
+}
+
+/// Byte range of `name` within `location`.
+///
+/// A qualified reference such as `pkg.helper` spans more than the name, so
+/// the name is taken from the end of the location.
+fn name_range(
+    source: &str,
+    lines: &[usize],
+    location: &Location,
+    name: &str,
+) -> Option<(usize, usize)> {
+    let start = offset(source, lines, location.line, location.column)?;
+    let end = offset(source, lines, location.end_line, location.end_column)?;
+    let text = source.get(start..end)?;
+    if !text.ends_with(name) {
+        return None;
+    }
+    let name_start = end - name.len();
+    // Reject partial identifiers (`xhelper` when renaming `helper`)
+    let preceded_by_identifier = source[start..name_start]
+        .chars()
+        .next_back()
+        .is_some_and(|c| c.is_alphanumeric() || c == '_');
+    (!preceded_by_identifier).then_some((name_start, end))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::{Reference, ReferenceKind, SymbolKind, Visibility};
+
+    const SOURCE: &str = concat!(
+        "package main\n",
+        "\n",
+        "func helper() int { return 1 }\n",
+        "\n",
+        "func mainFunction() int {\n",
+        "\treturn helper() + util.helper()\n",
+        "}\n",
+    );
+
+    fn function(name: &str, line: u32, column: u32) -> Symbol {
+        Symbol::new(
+            name.to_string(),
+            format!("main.{}", name),
+            SymbolKind::Function,
+            Location::with_end(
+                PathBuf::from("main.go"),
+                line,
+                column,
+                line,
+                column + name.len() as u32,
+            ),
+            Visibility::Private,
+            "go".to_string(),
+        )
+    }
+
+    fn reference(name: &str, line: u32, column: u32) -> Reference {
+        Reference {
+            name: name.to_string(),
+            location: Location::with_end(
+                PathBuf::from("main.go"),
+                line,
+                column,
+                line,
+                column + name.len() as u32,
+            ),
+            kind: ReferenceKind::Call,
+            target: None,
+            arguments: None,
+        }
+    }
+
+    fn sample_index(root: &Path) -> CodeIndex {
+        std::fs::write(root.join("main.go"), SOURCE).unwrap();
+        let mut index = CodeIndex::with_root(root.to_path_buf());
+        index.add_symbol(function("helper", 3, 6));
+        index.add_symbol(function("mainFunction", 5, 6));
+        index.add_reference(PathBuf::from("main.go"), reference("helper", 6, 9));
+        index.add_reference(PathBuf::from("main.go"), reference("util.helper", 6, 20));
+        index
+    }
+
+    #[test]
+    fn test_rename_impact_reports_exact_byte_ranges() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let index = sample_index(temp_dir.path());
+
+        let impact = index.rename_impact("main.helper", "assist").unwrap();
+        assert_eq!(impact.old_name, "helper");
+        assert_eq!(impact.files.len(), 1);
+        assert_eq!(impact.edit_count(), 3);
+        assert!(!impact.has_collisions());
+        assert!(impact.unmatched.is_empty());
+
+        let edits = &impact.files[0].edits;
+        assert!(edits[0].definition);
+        assert!(edits[1..].iter().all(|e| !e.definition));
+        for edit in edits {
+            assert_eq!(&SOURCE[edit.start..edit.end], "helper");
+        }
+        // The qualified reference edits only the trailing name
+        assert_eq!((edits[2].line, edits[2].column), (6, 25));
+
+        // Applying the edits back to front produces the renamed source
+        let mut renamed = SOURCE.to_string();
+        for edit in edits.iter().rev() {
+            renamed.replace_range(edit.start..edit.end, "assist");
+        }
+        assert!(renamed.contains("func assist() int"));
+        assert!(renamed.contains("return assist() + util.assist()"));
+
+        // Preview only: the file on disk is untouched
+        let on_disk = std::fs::read_to_string(temp_dir.path().join("main.go")).unwrap();
+        assert_eq!(on_disk, SOURCE);
+    }
+
+    #[test]
+    fn test_rename_impact_flags_collisions_in_scope() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let index = sample_index(temp_dir.path());
+
+        let impact = index.rename_impact("main.helper", "mainFunction").unwrap();
+        assert!(impact.has_collisions());
+        assert_eq!(impact.collisions[0].qualified, "main.mainFunction");
+    }
+
+    #[test]
+    fn test_rename_impact_reports_stale_sites_and_missing_symbols() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let mut index = sample_index(temp_dir.path());
+        // Points at `return` rather than a `helper`
+        index.add_reference(PathBuf::from("main.go"), reference("helper", 6, 2));
+
+        let impact = index.rename_impact("main.helper", "assist").unwrap();
+        assert_eq!(impact.edit_count(), 3);
+        assert_eq!(impact.unmatched.len(), 1);
+
+        assert!(matches!(
+            index.rename_impact("main.missing", "other"),
+            Err(IndexError::SymbolNotFound(_))
+        ));
+    }
+}
@@ -9,7 +9,8 @@
 use std::path::Path;
 
 use crate::languages::{
-    c, cpp, csharp, fsharp, java, javascript, kotlin, objc, php, ruby, swift, typescript,
+    c, cpp, csharp, fsharp, go, haxe, java, javascript, kotlin, objc, php, python, ruby, rust,
+    swift, typescript,
 };
 use crate::type_cache::TypeMember;
 use crate::{CodeIndex, Symbol};
@@ -65,6 +66,44 @@ pub trait SymbolResolver: Send + Sync {
     ) -> Option<ResolveResult<'a>> {
         self.resolve(index, name, from_file)
     }
+
+    /// Narrow `overloads`, callables sharing one qualified name, to those a
+    /// call can reach given the static types of its arguments (`None` for an
+    /// argument whose type is unknown).
+    ///
+    /// Languages without overloading keep every candidate, which is the
+    /// default. Returning more than one marks the call as ambiguous.
+    fn select_overloads<'a>(
+        &self,
+        overloads: &[&'a Symbol],
+        arguments: &[Option<String>],
+    ) -> Vec<&'a Symbol> {
+        let _ = arguments;
+        overloads.to_vec()
+    }
+}
+
+/// The resolver for symbols of `language` (as in [`Symbol::language`]).
+pub(crate) fn resolver_for_language(language: &str) -> Option<&'static dyn SymbolResolver> {
+    match language {
+        "c" => Some(&c::CResolver),
+        "cpp" => Some(&cpp::CppResolver),
+        "csharp" => Some(&csharp::CSharpResolver),
+        "fsharp" => Some(&fsharp::FSharpResolver),
+        "go" => Some(&go::GoResolver),
+        "haxe" => Some(&haxe::HaxeResolver),
+        "java" => Some(&java::JavaResolver),
+        "javascript" => Some(&javascript::JavaScriptResolver),
+        "kotlin" => Some(&kotlin::KotlinResolver),
+        "objc" => Some(&objc::ObjCResolver),
+        "php" => Some(&php::PhpResolver),
+        "python" => Some(&python::PythonResolver),
+        "ruby" => Some(&ruby::RubyResolver),
+        "rust" => Some(&rust::RustResolver),
+        "swift" => Some(&swift::SwiftResolver),
+        "typescript" => Some(&typescript::TypeScriptResolver),
+        _ => None,
+    }
 }
 
 impl CodeIndex {
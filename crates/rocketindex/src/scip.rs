@@ -0,0 +1,933 @@
+//! SCIP export for the index.
+//!
+//! [SCIP](https://github.com/sourcegraph/scip) is the code intelligence format
+//! used by Sourcegraph and a growing number of editors and tools.
+//! [`CodeIndex::export_scip`] writes the index as a single SCIP `Index`
+//! protobuf message: one document per file, a definition occurrence and
+//! symbol information for every symbol, and a reference occurrence for every
+//! reference that resolves to an indexed symbol.
+//!
+//! # Symbol monikers
+//!
+//! SCIP symbols are derived from qualified names, never from positions or
+//! array indices, so they are stable across runs and line up between
+//! repositories that index the same package:
+//!
+//! | Qualified name         | SCIP symbol                                  |
+//! |------------------------|----------------------------------------------|
+//! | `main.helper`          | `rocketindex . . . main/helper().`           |
+//! | `main.MyStruct`        | `rocketindex . . . main/MyStruct#`           |
+//! | `main.MyStruct.Method` | `rocketindex . . . main/MyStruct#Method().`  |
+//! | `main.MyStruct.Field`  | `rocketindex . . . main/MyStruct#Field.`     |
+//!
+//! Overloads sharing a qualified name get SCIP method disambiguators
+//! (`Method(+1).`) in source order.
+//!
+//! # Examples
+//!
+//! ```
+//! use rocketindex::{CodeIndex, Location, Symbol, SymbolKind, Visibility};
+//! use std::path::PathBuf;
+//!
+//! let mut index = CodeIndex::new();
+//! let symbol = Symbol::new(
+//!     "helper".to_string(),
+//!     "main.helper".to_string(),
+//!     SymbolKind::Function,
+//!     Location::new(PathBuf::from("main.go"), 5, 6),
+//!     Visibility::Private,
+//!     "go".to_string(),
+//! );
+//! assert_eq!(index.scip_symbol(&symbol), "rocketindex . . . main/helper().");
+//! index.add_symbol(symbol);
+//!
+//! let mut out = Vec::new();
+//! index.export_scip(&mut out).unwrap();
+//! assert!(!out.is_empty());
+//! ```
+
+use std::collections::{BTreeMap, BTreeSet};
+use std::io::Write;
+use std::path::{Path, PathBuf};
+
+use crate::{CodeIndex, Location, Reference, Result, Symbol, SymbolKind};
+
+/// Scheme used for every exported SCIP symbol.
+pub const SCIP_SCHEME: &str = "rocketindex";
+
+/// `SymbolRole.Definition` from scip.proto
+const ROLE_DEFINITION: u64 = 0x1;
+/// `TextEncoding.UTF8` from scip.proto
+const TEXT_ENCODING_UTF8: u64 = 1;
+/// `PositionEncoding.UTF8CodeUnitOffsetFromLineStart` from scip.proto
+/// (tree-sitter columns are byte offsets)
+const POSITION_ENCODING_UTF8: u64 = 1;
+
+impl CodeIndex {
+    /// Serialize the index as a SCIP `Index` protobuf message.
+    ///
+    /// Documents are ordered by path and occurrences by position, so exporting
+    /// the same index twice produces identical bytes.
+    pub fn export_scip<W: Write>(&self, mut writer: W) -> Result<()> {
+        let mut index = Message::default();
+        index.message(1, self.scip_metadata());
+
+        for (file, document) in self.scip_documents() {
+            index.message(2, document.encode(&file));
+        }
+
+        writer.write_all(&index.buf)?;
+        writer.flush()?;
+        Ok(())
+    }
+
+    /// Get the SCIP symbol (moniker) for an indexed symbol.
+    ///
+    /// See the [module docs](crate::scip) for the format.
+    #[must_use]
+    pub fn scip_symbol(&self, symbol: &Symbol) -> String {
+        let segments = split_qualified(&symbol.qualified);
+        let mut descriptors = String::new();
+
+        for (name, prefix_end) in &segments[..segments.len() - 1] {
+            let prefix = &symbol.qualified[..*prefix_end];
+            let is_type = self.get_all(prefix).iter().any(|s| s.kind.is_type());
+            descriptors.push_str(&escape_descriptor(name));
+            descriptors.push(if is_type { '#' } else { '/' });
+        }
+
+        let (name, _) = segments[segments.len() - 1];
+        descriptors.push_str(&escape_descriptor(name));
+        match symbol.kind {
+            SymbolKind::Module => descriptors.push('/'),
+            kind if kind.is_type() => descriptors.push('#'),
+            _ if is_method_like(symbol) => {
+                descriptors.push('(');
+                let overload = self.overload_position(symbol);
+                if overload > 0 {
+                    descriptors.push_str(&format!("+{}", overload));
+                }
+                descriptors.push_str(").");
+            }
+            _ => descriptors.push('.'),
+        }
+
+        format!("{} . . . {}", SCIP_SCHEME, descriptors)
+    }
+
+    fn scip_metadata(&self) -> Message {
+        let mut tool_info = Message::default();
+        tool_info.string(1, "rocketindex");
+        tool_info.string(2, env!("CARGO_PKG_VERSION"));
+
+        let mut metadata = Message::default();
+        // Field 1 (protocol version) is UnspecifiedProtocolVersion, the proto3 default
+        metadata.message(2, tool_info);
+        if let Some(root) = self.workspace_root() {
+            metadata.string(3, &format!("file://{}", root.display()));
+        }
+        metadata.varint(4, TEXT_ENCODING_UTF8);
+        metadata
+    }
+
+    fn scip_documents(&self) -> BTreeMap<PathBuf, ScipDocument> {
+        let mut documents: BTreeMap<PathBuf, ScipDocument> = BTreeMap::new();
+        let mut definition_sites: BTreeSet<(&Path, u32, u32)> = BTreeSet::new();
+
+        for symbol in self.symbols() {
+            let scip_symbol = self.scip_symbol(symbol);
+            let document = documents.entry(symbol.location.file.clone()).or_default();
+            document
+                .language
+                .get_or_insert_with(|| symbol.language.clone());
+            document.occurrences.push(ScipOccurrence {
+                range: scip_range(&symbol.location, &symbol.name),
+                symbol: scip_symbol.clone(),
+                roles: ROLE_DEFINITION,
+            });
+            document.symbols.push((
+                symbol_position(symbol),
+                self.symbol_information(symbol, scip_symbol),
+            ));
+            definition_sites.insert(location_key(&symbol.location));
+        }
+
+        for reference in self.references() {
+            // Some parsers also report the name at a definition site as a reference
+            if definition_sites.contains(&location_key(&reference.location)) {
+                continue;
+            }
+            let target = match self.resolve_scip_reference(reference) {
+                Some(target) => target,
+                None => continue,
+            };
+            documents
+                .entry(reference.location.file.clone())
+                .or_default()
+                .occurrences
+                .push(ScipOccurrence {
+                    range: scip_range(&reference.location, &reference.name),
+                    symbol: self.scip_symbol(target),
+                    roles: 0,
+                });
+        }
+
+        for document in documents.values_mut() {
+            document.occurrences.sort_by(|a, b| {
+                a.range
+                    .cmp(&b.range)
+                    .then_with(|| a.symbol.cmp(&b.symbol))
+                    .then(b.roles.cmp(&a.roles))
+            });
+            document
+                .occurrences
+                .dedup_by(|a, b| a.range == b.range && a.symbol == b.symbol);
+            document.symbols.sort_by(|a, b| a.0.cmp(&b.0));
+        }
+
+        documents
+    }
+
+    fn symbol_information(&self, symbol: &Symbol, scip_symbol: String) -> Message {
+        let mut info = Message::default();
+        info.string(1, &scip_symbol);
+        if let Some(signature) = &symbol.signature {
+            info.string(3, &format!("```{}\n{}\n```", symbol.language, signature));
+        }
+        if let Some(doc) = &symbol.doc {
+            info.string(3, doc);
+        }
+        info.varint(5, scip_kind(symbol));
+        info.string(6, &symbol.name);
+        if let Some(parent) = self.enclosing_symbol(symbol) {
+            info.string(8, &self.scip_symbol(parent));
+        }
+        info
+    }
+
+    /// The symbol whose qualified name is this symbol's qualified prefix.
+    fn enclosing_symbol(&self, symbol: &Symbol) -> Option<&Symbol> {
+        let segments = split_qualified(&symbol.qualified);
+        if segments.len() < 2 {
+            return None;
+        }
+        let (_, prefix_end) = segments[segments.len() - 2];
+        self.get(&symbol.qualified[..prefix_end])
+    }
+
+    /// Position of `symbol` among the definitions sharing its qualified name.
+    fn overload_position(&self, symbol: &Symbol) -> usize {
+        let mut overloads: Vec<&Symbol> = self.get_all(&symbol.qualified).iter().collect();
+        if overloads.len() < 2 {
+            return 0;
+        }
+        overloads.sort_by_key(|s| symbol_position(s));
+        overloads
+            .iter()
+            .position(|s| s.location == symbol.location)
+            .unwrap_or(0)
+    }
+
+    /// Resolve a reference to the indexed symbol it names.
+    ///
+    /// Uses the language resolver first, then falls back to exact, import-qualified,
+    /// and same-module lookups (the latter covers languages such as Go whose
+    /// resolver is not wired into [`CodeIndex::resolve`]). Ambiguous short
+    /// names are left unresolved rather than guessed.
+    pub(crate) fn resolve_scip_reference(&self, reference: &Reference) -> Option<&Symbol> {
+        let name = reference.name.as_str();
+        let from_file = reference.location.file.as_path();
+
+        if let Some(result) = self.resolve(name, from_file) {
+            return Some(result.symbol);
+        }
+
+        let visible = |qualified: &str| {
+            self.get_all(qualified)
+                .iter()
+                .find(|s| self.can_reference(from_file, &s.location.file))
+        };
+
+        if let Some(symbol) = visible(name) {
+            return Some(symbol);
+        }
+
+        for open in self.opens_for_file(from_file) {
+            if let Some(symbol) = visible(&format!("{}.{}", open, name)) {
+                return Some(symbol);
+            }
+        }
+
+        // Modules enclosing the file's own definitions, innermost first
+        let mut modules: Vec<&str> = self
+            .symbols_in_file(from_file)
+            .iter()
+            .filter_map(|s| s.qualified.rsplit_once('.').map(|(module, _)| module))
+            .collect::<BTreeSet<_>>()
+            .into_iter()
+            .collect();
+        modules.sort_by_key(|m| std::cmp::Reverse(m.len()));
+
+        modules
+            .into_iter()
+            .find_map(|module| visible(&format!("{}.{}", module, name)))
+    }
+}
+
+/// Occurrences and symbol information collected for one file.
+#[derive(Default)]
+struct ScipDocument {
+    language: Option<String>,
+    occurrences: Vec<ScipOccurrence>,
+    /// (position, encoded SymbolInformation) so symbols can be emitted in source order
+    symbols: Vec<((PathBuf, u32, u32), Message)>,
+}
+
+impl ScipDocument {
+    fn encode(self, file: &Path) -> Message {
+        let mut document = Message::default();
+        document.string(1, &file.to_string_lossy().replace('\\', "/"));
+
+        for occurrence in &self.occurrences {
+            let mut msg = Message::default();
+            msg.packed(1, &occurrence.range);
+            msg.string(2, &occurrence.symbol);
+            msg.varint(3, occurrence.roles);
+            document.message(2, msg);
+        }
+        for (_, info) in self.symbols {
+            document.message(3, info);
+        }
+
+        if let Some(language) = &self.language {
+            document.string(4, scip_language(language));
+        }
+        document.varint(6, POSITION_ENCODING_UTF8);
+        document
+    }
+}
+
+struct ScipOccurrence {
+    /// `[start_line, start_char, end_line, end_char]`, or three elements when
+    /// the range is on a single line (0-indexed)
+    range: Vec<u32>,
+    symbol: String,
+    roles: u64,
+}
+
+/// Convert a 1-indexed location to a SCIP range.
+///
+/// Locations created without an end position span `text` on the start line.
+pub(crate) fn scip_range(location: &Location, text: &str) -> Vec<u32> {
+    let start_line = location.line.saturating_sub(1);
+    let start_char = location.column.saturating_sub(1);
+    let (end_line, end_char) =
+        if (location.end_line, location.end_column) > (location.line, location.column) {
+            (
+                location.end_line.saturating_sub(1),
+                location.end_column.saturating_sub(1),
+            )
+        } else {
+            (start_line, start_char + text.len() as u32)
+        };
+
+    if end_line == start_line {
+        vec![start_line, start_char, end_char]
+    } else {
+        vec![start_line, start_char, end_line, end_char]
+    }
+}
+
+fn symbol_position(symbol: &Symbol) -> (PathBuf, u32, u32) {
+    (
+        symbol.location.file.clone(),
+        symbol.location.line,
+        symbol.location.column,
+    )
+}
+
+pub(crate) fn location_key(location: &Location) -> (&Path, u32, u32) {
+    (location.file.as_path(), location.line, location.column)
+}
+
+/// Functions, and members whose signature takes parameters (methods rather than fields).
+fn is_method_like(symbol: &Symbol) -> bool {
+    match symbol.kind {
+        SymbolKind::Function => true,
+        SymbolKind::Member => symbol
+            .signature
+            .as_deref()
+            .is_some_and(|sig| sig.contains('(')),
+        _ => false,
+    }
+}
+
+/// Split a qualified name into segments, each paired with the byte offset
+/// where the prefix ending in that segment stops.
+///
+/// Both `.` and `::` are treated as separators.
+fn split_qualified(qualified: &str) -> Vec<(&str, usize)> {
+    let mut segments = Vec::new();
+    let mut start = 0;
+    let bytes = qualified.as_bytes();
+    let mut i = 0;
+
+    while i < bytes.len() {
+        let separator_len = if bytes[i] == b'.' {
+            1
+        } else if bytes[i..].starts_with(b"::") {
+            2
+        } else {
+            0
+        };
+        if separator_len > 0 && i > start {
+            segments.push((&qualified[start..i], i));
+            start = i + separator_len;
+            i = start;
+        } else {
+            i += 1;
+        }
+    }
+    segments.push((&qualified[start..], qualified.len()));
+    segments
+}
+
+/// Escape a descriptor name per the SCIP symbol grammar.
+fn escape_descriptor(name: &str) -> String {
+    let simple = !name.is_empty()
+        && name
+            .chars()
+            .all(|c| c.is_ascii_alphanumeric() || matches!(c, '_' | '+' | '-' | '$'));
+    if simple {
+        name.to_string()
+    } else {
+        format!("`{}`", name.replace('`', "``"))
+    }
+}
+
+/// Map a symbol to a `SymbolInformation.Kind` value from scip.proto.
+fn scip_kind(symbol: &Symbol) -> u64 {
+    const CLASS: u64 = 7;
+    const FIELD: u64 = 15;
+    const FUNCTION: u64 = 17;
+    const INTERFACE: u64 = 21;
+    const METHOD: u64 = 26;
+    const MODULE: u64 = 29;
+    const STRUCT: u64 = 49;
+    const TYPE: u64 = 54;
+    const UNION: u64 = 59;
+    const VARIABLE: u64 = 61;
+
+    match symbol.kind {
+        SymbolKind::Module => MODULE,
+        SymbolKind::Function if symbol.parent.is_some() => METHOD,
+        SymbolKind::Function => FUNCTION,
+        SymbolKind::Value => VARIABLE,
+        SymbolKind::Type => TYPE,
+        SymbolKind::Record => STRUCT,
+        SymbolKind::Union => UNION,
+        SymbolKind::Interface => INTERFACE,
+        SymbolKind::Class => CLASS,
+        SymbolKind::Member if is_method_like(symbol) => METHOD,
+        SymbolKind::Member => FIELD,
+    }
+}
+
+/// Map our language identifiers to SCIP `Language` enum names.
+fn scip_language(language: &str) -> &str {
+    match language {
+        "c" => "C",
+        "cpp" => "CPP",
+        "csharp" => "CSharp",
+        "fsharp" => "FSharp",
+        "go" => "Go",
+        "java" => "Java",
+        "javascript" => "JavaScript",
+        "kotlin" => "Kotlin",
+        "objc" => "ObjectiveC",
+        "php" => "PHP",
+        "python" => "Python",
+        "ruby" => "Ruby",
+        "rust" => "Rust",
+        "swift" => "Swift",
+        "typescript" => "TypeScript",
+        other => other,
+    }
+}
+
+/// Minimal protobuf (proto3) encoder for the SCIP messages we emit.
+///
+/// Default values are skipped, as proto3 encoders do.
+#[derive(Debug, Default)]
+struct Message {
+    buf: Vec<u8>,
+}
+
+impl Message {
+    const WIRE_VARINT: u32 = 0;
+    const WIRE_LEN: u32 = 2;
+
+    fn key(&mut self, field: u32, wire_type: u32) {
+        self.raw_varint(u64::from(field << 3 | wire_type));
+    }
+
+    fn raw_varint(&mut self, mut value: u64) {
+        while value >= 0x80 {
+            self.buf.push((value as u8) | 0x80);
+            value >>= 7;
+        }
+        self.buf.push(value as u8);
+    }
+
+    fn bytes(&mut self, field: u32, bytes: &[u8]) {
+        self.key(field, Self::WIRE_LEN);
+        self.raw_varint(bytes.len() as u64);
+        self.buf.extend_from_slice(bytes);
+    }
+
+    fn varint(&mut self, field: u32, value: u64) {
+        if value != 0 {
+            self.key(field, Self::WIRE_VARINT);
+            self.raw_varint(value);
+        }
+    }
+
+    fn string(&mut self, field: u32, value: &str) {
+        if !value.is_empty() {
+            self.bytes(field, value.as_bytes());
+        }
+    }
+
+    fn message(&mut self, field: u32, message: Message) {
+        self.bytes(field, &message.buf);
+    }
+
+    fn packed(&mut self, field: u32, values: &[u32]) {
+        let mut packed = Message::default();
+        for &value in values {
+            packed.raw_varint(u64::from(value));
+        }
+        self.bytes(field, &packed.buf);
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::indexer::IndexOptions;
+    use crate::{ReferenceKind, Visibility};
+
+    /// Decoded view of a SCIP document, used to check the exported bytes.
+    #[derive(Debug, Default)]
+    struct DecodedDocument {
+        relative_path: String,
+        language: String,
+        /// (range, symbol, roles)
+        occurrences: Vec<(Vec<u32>, String, u64)>,
+        /// (symbol, display name, kind, enclosing symbol)
+        symbols: Vec<(String, String, u64, String)>,
+    }
+
+    enum Value<'a> {
+        Varint(u64),
+        Bytes(&'a [u8]),
+    }
+
+    fn read_varint(buf: &[u8], pos: &mut usize) -> u64 {
+        let mut value = 0u64;
+        let mut shift = 0;
+        loop {
+            let byte = buf[*pos];
+            *pos += 1;
+            value |= u64::from(byte & 0x7f) << shift;
+            if byte & 0x80 == 0 {
+                return value;
+            }
+            shift += 7;
+            assert!(shift < 64, "varint too long");
+        }
+    }
+
+    /// Decode one message into fields, validating each against `schema`
+    /// (field number -> expected wire type).
+    fn decode<'a>(buf: &'a [u8], message: &str, schema: &[(u32, u32)]) -> Vec<(u32, Value<'a>)> {
+        let mut fields = Vec::new();
+        let mut pos = 0;
+        while pos < buf.len() {
+            let key = read_varint(buf, &mut pos);
+            let (field, wire_type) = ((key >> 3) as u32, (key & 7) as u32);
+            let expected = schema
+                .iter()
+                .find(|(f, _)| *f == field)
+                .unwrap_or_else(|| panic!("{} has no field {}", message, field));
+            assert_eq!(expected.1, wire_type, "{}.{} wire type", message, field);
+            let value = match wire_type {
+                0 => Value::Varint(read_varint(buf, &mut pos)),
+                2 => {
+                    let len = read_varint(buf, &mut pos) as usize;
+                    pos += len;
+                    Value::Bytes(&buf[pos - len..pos])
+                }
+                other => panic!("unexpected wire type {}", other),
+            };
+            fields.push((field, value));
+        }
+        assert_eq!(pos, buf.len(), "{} overran its buffer", message);
+        fields
+    }
+
+    fn text(bytes: &[u8]) -> String {
+        String::from_utf8(bytes.to_vec()).expect("strings must be UTF-8")
+    }
+
+    fn bytes_of<'a>(value: &Value<'a>) -> &'a [u8] {
+        match value {
+            Value::Bytes(b) => b,
+            Value::Varint(_) => unreachable!("schema guarantees length-delimited"),
+        }
+    }
+
+    fn varint_of(value: &Value<'_>) -> u64 {
+        match value {
+            Value::Varint(v) => *v,
+            Value::Bytes(_) => unreachable!("schema guarantees varint"),
+        }
+    }
+
+    // Field numbers and wire types from scip.proto
+    const INDEX: &[(u32, u32)] = &[(1, 2), (2, 2), (3, 2)];
+    const METADATA: &[(u32, u32)] = &[(1, 0), (2, 2), (3, 2), (4, 0)];
+    const TOOL_INFO: &[(u32, u32)] = &[(1, 2), (2, 2), (3, 2)];
+    const DOCUMENT: &[(u32, u32)] = &[(1, 2), (2, 2), (3, 2), (4, 2), (5, 2), (6, 0)];
+    const OCCURRENCE: &[(u32, u32)] = &[(1, 2), (2, 2), (3, 0), (4, 2), (5, 0), (6, 2), (7, 2)];
+    const SYMBOL_INFORMATION: &[(u32, u32)] =
+        &[(1, 2), (3, 2), (4, 2), (5, 0), (6, 2), (7, 2), (8, 2)];
+
+    /// Decode an exported index, validating every message against the SCIP schema.
+    fn decode_index(buf: &[u8]) -> (String, Vec<DecodedDocument>) {
+        let mut tool_name = String::new();
+        let mut documents = Vec::new();
+
+        for (field, value) in decode(buf, "Index", INDEX) {
+            match field {
+                1 => {
+                    for (field, value) in decode(bytes_of(&value), "Metadata", METADATA) {
+                        if field == 2 {
+                            for (field, value) in decode(bytes_of(&value), "ToolInfo", TOOL_INFO) {
+                                if field == 1 {
+                                    tool_name = text(bytes_of(&value));
+                                }
+                            }
+                        }
+                    }
+                }
+                2 => documents.push(decode_document(bytes_of(&value))),
+                _ => {}
+            }
+        }
+
+        (tool_name, documents)
+    }
+
+    fn decode_document(buf: &[u8]) -> DecodedDocument {
+        let mut document = DecodedDocument::default();
+        for (field, value) in decode(buf, "Document", DOCUMENT) {
+            match field {
+                1 => document.relative_path = text(bytes_of(&value)),
+                2 => {
+                    let mut occurrence = (Vec::new(), String::new(), 0);
+                    for (field, value) in decode(bytes_of(&value), "Occurrence", OCCURRENCE) {
+                        match field {
+                            1 => {
+                                let packed = bytes_of(&value);
+                                let mut pos = 0;
+                                while pos < packed.len() {
+                                    occurrence.0.push(read_varint(packed, &mut pos) as u32);
+                                }
+                            }
+                            2 => occurrence.1 = text(bytes_of(&value)),
+                            3 => occurrence.2 = varint_of(&value),
+                            _ => {}
+                        }
+                    }
+                    assert!(
+                        occurrence.0.len() == 3 || occurrence.0.len() == 4,
+                        "range must have 3 or 4 elements: {:?}",
+                        occurrence.0
+                    );
+                    document.occurrences.push(occurrence);
+                }
+                3 => {
+                    let mut info = (String::new(), String::new(), 0, String::new());
+                    let fields = decode(bytes_of(&value), "SymbolInformation", SYMBOL_INFORMATION);
+                    for (field, value) in fields {
+                        match field {
+                            1 => info.0 = text(bytes_of(&value)),
+                            3 => {
+                                text(bytes_of(&value));
+                            }
+                            5 => info.2 = varint_of(&value),
+                            6 => info.1 = text(bytes_of(&value)),
+                            8 => info.3 = text(bytes_of(&value)),
+                            _ => {}
+                        }
+                    }
+                    document.symbols.push(info);
+                }
+                4 => document.language = text(bytes_of(&value)),
+                _ => {}
+            }
+        }
+        document
+    }
+
+    /// Check a symbol against the SCIP symbol grammar for our scheme.
+    fn assert_valid_scip_symbol(symbol: &str) {
+        let descriptors = symbol
+            .strip_prefix("rocketindex . . . ")
+            .unwrap_or_else(|| panic!("bad symbol header: {}", symbol));
+        assert!(!descriptors.is_empty());
+        assert!(
+            descriptors.ends_with('.') || descriptors.ends_with('#') || descriptors.ends_with('/'),
+            "bad descriptor suffix: {}",
+            symbol
+        );
+    }
+
+    fn export(index: &CodeIndex) -> Vec<DecodedDocument> {
+        let mut out = Vec::new();
+        index.export_scip(&mut out).unwrap();
+        let (tool, documents) = decode_index(&out);
+        assert_eq!(tool, "rocketindex");
+        for document in &documents {
+            for (_, symbol, _) in &document.occurrences {
+                assert_valid_scip_symbol(symbol);
+            }
+            for (symbol, _, _, _) in &document.symbols {
+                assert_valid_scip_symbol(symbol);
+            }
+        }
+        documents
+    }
+
+    fn make_symbol(name: &str, qualified: &str, kind: SymbolKind, line: u32) -> Symbol {
+        Symbol::new(
+            name.to_string(),
+            qualified.to_string(),
+            kind,
+            Location::with_end(
+                PathBuf::from("main.go"),
+                line,
+                6,
+                line,
+                6 + name.len() as u32,
+            ),
+            Visibility::Public,
+            "go".to_string(),
+        )
+    }
+
+    /// Mirrors tests/fixtures/minimal/go/main.go
+    fn minimal_go_index() -> CodeIndex {
+        let mut index = CodeIndex::new();
+        index.add_symbol(make_symbol(
+            "helper",
+            "main.helper",
+            SymbolKind::Function,
+            5,
+        ));
+        index.add_symbol(make_symbol(
+            "mainFunction",
+            "main.mainFunction",
+            SymbolKind::Function,
+            9,
+        ));
+        index.add_symbol(make_symbol(
+            "MyStruct",
+            "main.MyStruct",
+            SymbolKind::Class,
+            23,
+        ));
+        index.add_symbol(
+            make_symbol("Field", "main.MyStruct.Field", SymbolKind::Member, 24)
+                .with_parent(Some("main.MyStruct".to_string())),
+        );
+        index.add_symbol(
+            make_symbol("Method", "main.MyStruct.Method", SymbolKind::Function, 31)
+                .with_parent(Some("MyStruct".to_string())),
+        );
+        for (name, line, column) in [
+            ("helper", 10, 10),
+            ("fmt.Println", 11, 5),
+            ("MyStruct", 27, 22),
+        ] {
+            index.add_reference(
+                PathBuf::from("main.go"),
+                Reference {
+                    name: name.to_string(),
+                    location: Location::new(PathBuf::from("main.go"), line, column),
+                    kind: ReferenceKind::Unknown,
+                    target: None,
+                    arguments: None,
+                },
+            );
+        }
+        index
+    }
+
+    #[test]
+    fn test_scip_symbols_derive_from_qualified_names() {
+        let index = minimal_go_index();
+        let symbol = |q: &str| index.scip_symbol(index.get(q).unwrap());
+
+        assert_eq!(symbol("main.helper"), "rocketindex . . . main/helper().");
+        assert_eq!(symbol("main.MyStruct"), "rocketindex . . . main/MyStruct#");
+        assert_eq!(
+            symbol("main.MyStruct.Method"),
+            "rocketindex . . . main/MyStruct#Method()."
+        );
+        assert_eq!(
+            symbol("main.MyStruct.Field"),
+            "rocketindex . . . main/MyStruct#Field."
+        );
+    }
+
+    #[test]
+    fn test_scip_symbols_are_stable_across_insertion_order() {
+        let mut reversed = CodeIndex::new();
+        let index = minimal_go_index();
+        let mut symbols: Vec<Symbol> = index.symbols().cloned().collect();
+        symbols.sort_by_key(|s| std::cmp::Reverse(s.location.line));
+        for symbol in symbols {
+            reversed.add_symbol(symbol);
+        }
+
+        for symbol in index.symbols() {
+            assert_eq!(index.scip_symbol(symbol), reversed.scip_symbol(symbol));
+        }
+    }
+
+    #[test]
+    fn test_scip_overloads_get_disambiguators() {
+        let mut index = CodeIndex::new();
+        index.add_symbol(
+            make_symbol("add", "Calc.add", SymbolKind::Member, 10)
+                .with_signature(Some("int add(int a, int b)".to_string())),
+        );
+        index.add_symbol(
+            make_symbol("add", "Calc.add", SymbolKind::Member, 3)
+                .with_signature(Some("int add(int a)".to_string())),
+        );
+
+        let mut symbols: Vec<String> = index
+            .get_all("Calc.add")
+            .iter()
+            .map(|s| index.scip_symbol(s))
+            .collect();
+        symbols.sort();
+        assert_eq!(
+            symbols,
+            vec![
+                "rocketindex . . . Calc/add().",
+                "rocketindex . . . Calc/add(+1)."
+            ]
+        );
+    }
+
+    #[test]
+    fn test_split_qualified_handles_both_separators() {
+        let names: Vec<&str> = split_qualified("crate::db::Index.open")
+            .into_iter()
+            .map(|(name, _)| name)
+            .collect();
+        assert_eq!(names, vec!["crate", "db", "Index", "open"]);
+        assert_eq!(escape_descriptor("operator+="), "`operator+=`");
+        assert_eq!(escape_descriptor("snake_case"), "snake_case");
+    }
+
+    #[test]
+    fn test_export_round_trip() {
+        let documents = export(&minimal_go_index());
+        assert_eq!(documents.len(), 1);
+
+        let document = &documents[0];
+        assert_eq!(document.relative_path, "main.go");
+        assert_eq!(document.language, "Go");
+        assert_eq!(document.symbols.len(), 5);
+
+        let method = document.symbols.iter().find(|s| s.1 == "Method").unwrap();
+        assert_eq!(method.2, 26, "Go methods are SCIP methods");
+        assert_eq!(method.3, "rocketindex . . . main/MyStruct#");
+
+        let definitions: Vec<&(Vec<u32>, String, u64)> = document
+            .occurrences
+            .iter()
+            .filter(|o| o.2 & ROLE_DEFINITION != 0)
+            .collect();
+        assert_eq!(definitions.len(), 5);
+        assert_eq!(definitions[0].0, vec![4, 5, 11]);
+
+        // helper() and MyStruct resolve; fmt.Println is external and is dropped
+        let references: Vec<(&[u32], &str)> = document
+            .occurrences
+            .iter()
+            .filter(|o| o.2 == 0)
+            .map(|o| (o.0.as_slice(), o.1.as_str()))
+            .collect();
+        assert_eq!(
+            references,
+            vec![
+                (&[9, 9, 15][..], "rocketindex . . . main/helper()."),
+                (&[26, 21, 29][..], "rocketindex . . . main/MyStruct#"),
+            ]
+        );
+    }
+
+    #[test]
+    fn test_export_is_deterministic() {
+        let index = minimal_go_index();
+        let mut first = Vec::new();
+        let mut second = Vec::new();
+        index.export_scip(&mut first).unwrap();
+        index.export_scip(&mut second).unwrap();
+        assert_eq!(first, second);
+    }
+
+    #[test]
+    fn test_export_go_fixtures() {
+        let fixture = Path::new(env!("CARGO_MANIFEST_DIR"))
+            .join("../../tests/claude-plugin-integration/test-repos/go-sample");
+        let mut index = CodeIndex::with_root(fixture.clone());
+        let files: Vec<PathBuf> = ["main.go", "payment.go", "user.go"]
+            .iter()
+            .map(|name| fixture.join(name))
+            .collect();
+        index.update_files(&files, &IndexOptions::default());
+
+        let documents = export(&index);
+        let paths: Vec<&str> = documents.iter().map(|d| d.relative_path.as_str()).collect();
+        assert_eq!(paths, vec!["main.go", "payment.go", "user.go"]);
+        assert!(documents.iter().all(|d| d.language == "Go"));
+
+        let main = &documents[0];
+        let referenced: Vec<&str> = main
+            .occurrences
+            .iter()
+            .filter(|o| o.2 == 0)
+            .map(|o| o.1.as_str())
+            .collect();
+        assert!(referenced.contains(&"rocketindex . . . main/NewUser()."));
+        assert!(referenced.contains(&"rocketindex . . . main/NewPaymentService()."));
+
+        // Every reference points at a symbol defined somewhere in the export
+        let defined: BTreeSet<&str> = documents
+            .iter()
+            .flat_map(|d| d.symbols.iter().map(|s| s.0.as_str()))
+            .collect();
+        for document in &documents {
+            for (_, symbol, _) in &document.occurrences {
+                assert!(defined.contains(symbol.as_str()), "dangling {}", symbol);
+            }
+        }
+    }
+}
@@ -0,0 +1,687 @@
+//! Fuzzy symbol search over the in-memory index.
+//!
+//! [`CodeIndex::search_symbols`] matches abbreviated queries as ordered
+//! subsequences (`ProcPay` finds `ProcessPayment`, `NewUsr` finds `NewUser`)
+//! and ranks results with [`crate::fuzzy::subsequence_score`]. Results can be
+//! filtered by [`SymbolKind`], by a file glob, to exported symbols only, and
+//! to hand-written code (see [`crate::generated`]). An empty query matches
+//! every symbol (in any mode but exact), so an empty query restricted to a
+//! package's files and to exported symbols lists the package's API.
+//!
+//! [`SearchOptions::mode`] selects another way of matching: exact, prefix,
+//! substring, or a regular expression (see [`MatchMode`]). Matching ignores
+//! case unless [`SearchOptions::case_sensitive`] is set. A regex is compiled
+//! once per search and matched against qualified names unless
+//! [`SearchOptions::target`] says otherwise, so `(?i)^New` with
+//! [`MatchTarget::Name`] finds every constructor-style function. An invalid
+//! regex is an error, not an empty result.
+//!
+//! A search over a large index with an expensive regex can be bounded with
+//! [`SearchOptions::cancel`]: once the [`CancelToken`] fires, the search
+//! stops with [`SearchError::Cancelled`].
+//!
+//! # Examples
+//!
+//! ```
+//! use rocketindex::search::{MatchMode, MatchTarget, SearchOptions};
+//! use rocketindex::{CodeIndex, Location, Symbol, SymbolKind, Visibility};
+//! use std::path::PathBuf;
+//!
+//! let mut index = CodeIndex::new();
+//! index.add_symbol(Symbol::new(
+//!     "ProcessPayment".to_string(),
+//!     "billing.ProcessPayment".to_string(),
+//!     SymbolKind::Function,
+//!     Location::new(PathBuf::from("billing/pay.go"), 10, 6),
+//!     Visibility::Public,
+//!     "go".to_string(),
+//! ));
+//!
+//! let options = SearchOptions {
+//!     kinds: vec![SymbolKind::Function],
+//!     file_glob: Some("billing/*.go".to_string()),
+//!     ..SearchOptions::default()
+//! };
+//! let matches = index.search_symbols("ProcPay", &options).unwrap();
+//! assert_eq!(matches[0].symbol.name, "ProcessPayment");
+//!
+//! let options = SearchOptions {
+//!     mode: MatchMode::Regex,
+//!     target: MatchTarget::Name,
+//!     ..SearchOptions::default()
+//! };
+//! assert_eq!(index.search_symbols("^Process", &options).unwrap().len(), 1);
+//! assert!(index.search_symbols("Process(", &options).is_err());
+//! ```
+
+use std::borrow::Cow;
+
+use regex::{Regex, RegexBuilder};
+
+use crate::cancel::{CancelError, CancelToken};
+use crate::fuzzy::subsequence_score;
+use crate::naming::NameScheme;
+use crate::{CodeIndex, Symbol, SymbolKind};
+
+/// Default maximum number of results returned by [`CodeIndex::search_symbols`].
+pub const DEFAULT_SEARCH_LIMIT: usize = 50;
+
+/// How a query is compared with symbol names.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Default)]
+pub enum MatchMode {
+    /// The whole name equals the query
+    Exact,
+    /// The name starts with the query
+    Prefix,
+    /// The name contains the query
+    Substring,
+    /// The query's characters appear in the name in order (`ProcPay` finds
+    /// `ProcessPayment`), scored by [`crate::fuzzy::subsequence_score`]
+    #[default]
+    Fuzzy,
+    /// The query is a regular expression found anywhere in the name (anchor
+    /// it with `^` and `$`)
+    Regex,
+}
+
+/// Which name of a symbol a query is compared with.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Default)]
+pub enum MatchTarget {
+    /// Qualified names for regexes and for queries containing a separator
+    /// (`.`, `::`, or that of [`SearchOptions::names`]), short names otherwise
+    #[default]
+    Auto,
+    /// The short name (`NewUser`)
+    Name,
+    /// The qualified name (`users.NewUser`), rendered in [`SearchOptions::names`]
+    Qualified,
+}
+
+/// Why a search could not run.
+#[derive(Debug, thiserror::Error)]
+pub enum SearchError {
+    #[error("Invalid regex: {0}")]
+    InvalidRegex(#[from] regex::Error),
+    #[error(transparent)]
+    Cancelled(#[from] CancelError),
+}
+
+/// Filters and limits for [`CodeIndex::search_symbols`].
+#[derive(Debug, Clone)]
+pub struct SearchOptions {
+    /// How the query is compared with names.
+    pub mode: MatchMode,
+    /// Compare case-sensitively (a regex's own `(?i)` flag still applies).
+    pub case_sensitive: bool,
+    /// Which name the query is compared with.
+    pub target: MatchTarget,
+    /// Only return symbols of these kinds (empty means all kinds).
+    ///
+    /// Methods are indexed as [`SymbolKind::Function`] with a parent, and
+    /// structs as [`SymbolKind::Class`] or [`SymbolKind::Record`] depending
+    /// on the language.
+    pub kinds: Vec<SymbolKind>,
+    /// Only return symbols defined in files matching this glob.
+    ///
+    /// Paths are matched relative to the workspace root with `/` separators.
+    /// `*` and `?` do not cross directories, `**` does. A pattern without a
+    /// `/` is matched against the file name alone, so `*.go` matches
+    /// `pkg/main.go`.
+    pub file_glob: Option<String>,
+    /// Only return symbols visible outside their package (see
+    /// [`CodeIndex::is_exported`]).
+    pub exported_only: bool,
+    /// Leave out symbols defined in generated files (see
+    /// [`Symbol::is_generated`]).
+    pub exclude_generated: bool,
+    /// How qualified names are written in queries and shown in results.
+    ///
+    /// Qualified queries are matched against names rendered with this
+    /// scheme, so `billing/Pay` finds `billing.ProcessPayment` under
+    /// [`NameScheme::Slash`].
+    pub names: NameScheme,
+    /// Maximum number of results (0 means unlimited).
+    pub limit: usize,
+    /// Stop the search once this token is cancelled or its deadline passes.
+    pub cancel: Option<CancelToken>,
+}
+
+impl Default for SearchOptions {
+    fn default() -> Self {
+        Self {
+            mode: MatchMode::default(),
+            case_sensitive: false,
+            target: MatchTarget::default(),
+            kinds: Vec::new(),
+            file_glob: None,
+            exported_only: false,
+            exclude_generated: false,
+            names: NameScheme::default(),
+            limit: DEFAULT_SEARCH_LIMIT,
+            cancel: None,
+        }
+    }
+}
+
+/// A symbol returned by [`CodeIndex::search_symbols`] with its match score.
+#[derive(Debug, Clone, Copy)]
+pub struct SymbolMatch<'a> {
+    /// The matched symbol
+    pub symbol: &'a Symbol,
+    /// Match score (higher is better)
+    pub score: i64,
+}
+
+impl CodeIndex {
+    /// Search for symbols whose name matches `query` in [`SearchOptions::mode`]
+    /// (by default, contains it as a subsequence).
+    ///
+    /// Queries containing `.` or `::` (or the separator of
+    /// [`SearchOptions::names`]) are matched against qualified names instead
+    /// of short names, as are regexes (see [`MatchTarget`]). Results are
+    /// ordered by descending score; ties are broken by name, then qualified
+    /// name, then location, so the order is deterministic. Modes other than
+    /// fuzzy score shorter names higher.
+    ///
+    /// # Errors
+    ///
+    /// Returns [`SearchError::InvalidRegex`] if the mode is
+    /// [`MatchMode::Regex`] and `query` does not compile, and
+    /// [`SearchError::Cancelled`] if [`SearchOptions::cancel`] fires before
+    /// every symbol was matched.
+    pub fn search_symbols(
+        &self,
+        query: &str,
+        options: &SearchOptions,
+    ) -> Result<Vec<SymbolMatch<'_>>, SearchError> {
+        let matcher = Matcher::new(query, options)?;
+        let match_qualified = match options.target {
+            MatchTarget::Name => false,
+            MatchTarget::Qualified => true,
+            MatchTarget::Auto => {
+                options.mode == MatchMode::Regex
+                    || query.contains('.')
+                    || query.contains("::")
+                    || options
+                        .names
+                        .separator()
+                        .is_some_and(|sep| query.contains(sep))
+            }
+        };
+
+        let candidates = self
+            .symbols()
+            .filter(|sym| options.kinds.is_empty() || options.kinds.contains(&sym.kind))
+            .filter(|sym| match options.file_glob.as_deref() {
+                Some(glob) => glob_matches(glob, &sym.location.file.to_string_lossy()),
+                None => true,
+            })
+            .filter(|sym| !options.exported_only || self.is_exported(sym))
+            .filter(|sym| !options.exclude_generated || !sym.is_generated());
+        let mut matches: Vec<SymbolMatch<'_>> = Vec::new();
+        for sym in candidates {
+            if let Some(cancel) = &options.cancel {
+                cancel.check()?;
+            }
+            let target = if match_qualified {
+                options.names.render(sym)
+            } else {
+                Cow::Borrowed(sym.name.as_str())
+            };
+            if let Some(score) = matcher.score(&target) {
+                matches.push(SymbolMatch { symbol: sym, score });
+            }
+        }
+
+        matches.sort_by(|a, b| {
+            b.score
+                .cmp(&a.score)
+                .then_with(|| a.symbol.name.cmp(&b.symbol.name))
+                .then_with(|| a.symbol.qualified.cmp(&b.symbol.qualified))
+                .then_with(|| a.symbol.location.file.cmp(&b.symbol.location.file))
+                .then_with(|| a.symbol.location.line.cmp(&b.symbol.location.line))
+                .then_with(|| a.symbol.location.column.cmp(&b.symbol.location.column))
+        });
+
+        if options.limit > 0 {
+            matches.truncate(options.limit);
+        }
+        Ok(matches)
+    }
+}
+
+/// A query prepared once for [`SearchOptions::mode`].
+enum Matcher<'q> {
+    Fuzzy {
+        query: &'q str,
+        case_sensitive: bool,
+    },
+    Text {
+        mode: MatchMode,
+        query: Cow<'q, str>,
+        case_sensitive: bool,
+    },
+    Regex(Regex),
+}
+
+impl<'q> Matcher<'q> {
+    fn new(query: &'q str, options: &SearchOptions) -> Result<Self, SearchError> {
+        let case_sensitive = options.case_sensitive;
+        Ok(match options.mode {
+            MatchMode::Fuzzy => Self::Fuzzy {
+                query,
+                case_sensitive,
+            },
+            MatchMode::Regex => Self::Regex(
+                RegexBuilder::new(query)
+                    .case_insensitive(!case_sensitive)
+                    .build()?,
+            ),
+            mode => Self::Text {
+                mode,
+                query: if case_sensitive {
+                    Cow::Borrowed(query)
+                } else {
+                    Cow::Owned(query.to_lowercase())
+                },
+                case_sensitive,
+            },
+        })
+    }
+
+    /// Score `target`, or `None` if it does not match.
+    fn score(&self, target: &str) -> Option<i64> {
+        let matched = match self {
+            Self::Fuzzy {
+                query,
+                case_sensitive,
+            } => {
+                let score = subsequence_score(query, target)?;
+                return (!case_sensitive || is_subsequence(query, target)).then_some(score);
+            }
+            Self::Text {
+                mode,
+                query,
+                case_sensitive,
+            } => {
+                let target = if *case_sensitive {
+                    Cow::Borrowed(target)
+                } else {
+                    Cow::Owned(target.to_lowercase())
+                };
+                match mode {
+                    MatchMode::Exact => *target == **query,
+                    MatchMode::Prefix => target.starts_with(&**query),
+                    _ => target.contains(&**query),
+                }
+            }
+            Self::Regex(regex) => regex.is_match(target),
+        };
+        matched.then(|| -(target.chars().count() as i64))
+    }
+}
+
+/// Whether `query`'s characters appear in `target` in order, with exact case.
+fn is_subsequence(query: &str, target: &str) -> bool {
+    let mut target = target.chars();
+    query.chars().all(|q| target.any(|c| c == q))
+}
+
+/// Match a workspace-relative path against a file glob.
+pub(crate) fn glob_matches(glob: &str, path: &str) -> bool {
+    let path = path.replace('\\', "/");
+    let path = path.trim_start_matches("./");
+    let glob = glob.trim_start_matches("./");
+
+    if glob.contains('/') {
+        glob_match_bytes(glob.as_bytes(), path.as_bytes())
+    } else {
+        let file_name = path.rsplit('/').next().unwrap_or(path);
+        glob_match_bytes(glob.as_bytes(), file_name.as_bytes())
+    }
+}
+
+fn glob_match_bytes(glob: &[u8], path: &[u8]) -> bool {
+    match glob.first() {
+        None => path.is_empty(),
+        Some(b'*') if glob.get(1) == Some(&b'*') => {
+            // `**/` also matches zero directories
+            let rest = &glob[2..];
+            if let Some(after_slash) = rest.strip_prefix(b"/") {
+                if glob_match_bytes(after_slash, path) {
+                    return true;
+                }
+            }
+            (0..=path.len()).any(|i| glob_match_bytes(rest, &path[i..]))
+        }
+        Some(b'*') => {
+            let rest = &glob[1..];
+            for i in 0..=path.len() {
+                if glob_match_bytes(rest, &path[i..]) {
+                    return true;
+                }
+                if path.get(i) == Some(&b'/') {
+                    break;
+                }
+            }
+            false
+        }
+        Some(b'?') => match path.first() {
+            Some(&c) if c != b'/' => glob_match_bytes(&glob[1..], &path[1..]),
+            _ => false,
+        },
+        Some(&c) => path.first() == Some(&c) && glob_match_bytes(&glob[1..], &path[1..]),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::generated::GENERATED;
+    use crate::{Location, Visibility};
+    use std::path::PathBuf;
+
+    fn make_symbol(name: &str, qualified: &str, kind: SymbolKind, file: &str) -> Symbol {
+        Symbol::new(
+            name.to_string(),
+            qualified.to_string(),
+            kind,
+            Location::new(PathBuf::from(file), 1, 1),
+            Visibility::Public,
+            "go".to_string(),
+        )
+    }
+
+    fn sample_index() -> CodeIndex {
+        let mut index = CodeIndex::new();
+        for (name, qualified, kind, file) in [
+            (
+                "ProcessPayment",
+                "billing.ProcessPayment",
+                SymbolKind::Function,
+                "billing/pay.go",
+            ),
+            (
+                "ProcessPaymentRefund",
+                "billing.ProcessPaymentRefund",
+                SymbolKind::Function,
+                "billing/refund.go",
+            ),
+            (
+                "PaymentProcessor",
+                "billing.PaymentProcessor",
+                SymbolKind::Class,
+                "billing/processor.go",
+            ),
+            (
+                "NewUser",
+                "users.NewUser",
+                SymbolKind::Function,
+                "users/user.go",
+            ),
+            (
+                "NewUserService",
+                "users.NewUserService",
+                SymbolKind::Function,
+                "users/service.go",
+            ),
+            ("User", "users.User", SymbolKind::Class, "users/user.go"),
+        ] {
+            index.add_symbol(make_symbol(name, qualified, kind, file));
+        }
+        index
+    }
+
+    fn names<'a>(matches: &[SymbolMatch<'a>]) -> Vec<&'a str> {
+        matches.iter().map(|m| m.symbol.name.as_str()).collect()
+    }
+
+    #[test]
+    fn test_search_symbols_abbreviations() {
+        let index = sample_index();
+
+        let matches = index
+            .search_symbols("ProcPay", &SearchOptions::default())
+            .unwrap();
+        assert_eq!(
+            names(&matches),
+            vec!["ProcessPayment", "ProcessPaymentRefund"]
+        );
+
+        let matches = index
+            .search_symbols("NewUsr", &SearchOptions::default())
+            .unwrap();
+        assert_eq!(names(&matches), vec!["NewUser", "NewUserService"]);
+    }
+
+    #[test]
+    fn test_search_symbols_filters_kind_and_glob() {
+        let index = sample_index();
+
+        let options = SearchOptions {
+            kinds: vec![SymbolKind::Class],
+            ..SearchOptions::default()
+        };
+        let matches = index.search_symbols("pay", &options).unwrap();
+        assert_eq!(names(&matches), vec!["PaymentProcessor"]);
+
+        let options = SearchOptions {
+            file_glob: Some("users/*.go".to_string()),
+            ..SearchOptions::default()
+        };
+        let matches = index.search_symbols("u", &options).unwrap();
+        assert_eq!(names(&matches), vec!["User", "NewUser", "NewUserService"]);
+
+        let options = SearchOptions {
+            file_glob: Some("refund.go".to_string()),
+            ..SearchOptions::default()
+        };
+        let matches = index.search_symbols("ProcPay", &options).unwrap();
+        assert_eq!(names(&matches), vec!["ProcessPaymentRefund"]);
+    }
+
+    #[test]
+    fn test_search_symbols_limit_and_qualified_queries() {
+        let index = sample_index();
+
+        let options = SearchOptions {
+            limit: 1,
+            ..SearchOptions::default()
+        };
+        let matches = index.search_symbols("NewUsr", &options).unwrap();
+        assert_eq!(names(&matches), vec!["NewUser"]);
+
+        let matches = index
+            .search_symbols("users.New", &SearchOptions::default())
+            .unwrap();
+        assert_eq!(names(&matches), vec!["NewUser", "NewUserService"]);
+
+        let options = SearchOptions {
+            names: NameScheme::Slash,
+            ..SearchOptions::default()
+        };
+        let matches = index.search_symbols("users/NewUserS", &options).unwrap();
+        assert_eq!(names(&matches), vec!["NewUserService"]);
+    }
+
+    #[test]
+    fn test_search_symbols_lists_a_package_api() {
+        let mut index = sample_index();
+        for (name, qualified, kind, file) in [
+            (
+                "validate",
+                "users.validate",
+                SymbolKind::Function,
+                "users/user.go",
+            ),
+            (
+                "session",
+                "users.session",
+                SymbolKind::Class,
+                "users/session.go",
+            ),
+        ] {
+            let mut symbol = make_symbol(name, qualified, kind, file);
+            symbol.visibility = Visibility::Private;
+            index.add_symbol(symbol);
+        }
+        // Exported method of an unexported type
+        index.add_symbol(
+            make_symbol(
+                "Close",
+                "users.session.Close",
+                SymbolKind::Function,
+                "users/session.go",
+            )
+            .with_parent(Some("session".to_string())),
+        );
+
+        let options = SearchOptions {
+            file_glob: Some("users/*.go".to_string()),
+            exported_only: true,
+            ..SearchOptions::default()
+        };
+        let matches = index.search_symbols("", &options).unwrap();
+        assert_eq!(names(&matches), vec!["User", "NewUser", "NewUserService"]);
+    }
+
+    #[test]
+    fn test_search_symbols_can_exclude_generated_code() {
+        let mut index = CodeIndex::new();
+        index.add_symbol(make_symbol(
+            "Color",
+            "main.Color",
+            SymbolKind::Type,
+            "color.go",
+        ));
+        index.add_symbol(
+            make_symbol(
+                "ColorString",
+                "main.ColorString",
+                SymbolKind::Function,
+                "color_string.go",
+            )
+            .with_attributes(Some(vec![GENERATED.to_string()])),
+        );
+
+        let all = index
+            .search_symbols("Color", &SearchOptions::default())
+            .unwrap();
+        assert_eq!(names(&all), vec!["Color", "ColorString"]);
+
+        let options = SearchOptions {
+            exclude_generated: true,
+            ..SearchOptions::default()
+        };
+        let hand_written = index.search_symbols("Color", &options).unwrap();
+        assert_eq!(names(&hand_written), vec!["Color"]);
+    }
+
+    #[test]
+    fn test_search_symbols_ties_break_by_name() {
+        let mut index = CodeIndex::new();
+        for name in ["Zeta", "Beta", "Meta"] {
+            index.add_symbol(make_symbol(
+                name,
+                &format!("pkg.{name}"),
+                SymbolKind::Function,
+                "pkg/a.go",
+            ));
+        }
+
+        let matches = index
+            .search_symbols("eta", &SearchOptions::default())
+            .unwrap();
+        assert_eq!(matches[0].score, matches[2].score);
+        assert_eq!(names(&matches), vec!["Beta", "Meta", "Zeta"]);
+    }
+
+    #[test]
+    fn test_search_modes_and_case_sensitivity() {
+        let index = sample_index();
+        let search = |query: &str, mode: MatchMode, case_sensitive: bool| {
+            let options = SearchOptions {
+                mode,
+                case_sensitive,
+                ..SearchOptions::default()
+            };
+            let matches = index.search_symbols(query, &options).unwrap();
+            names(&matches).join(",")
+        };
+
+        assert_eq!(search("newuser", MatchMode::Exact, false), "NewUser");
+        assert_eq!(search("newuser", MatchMode::Exact, true), "");
+        assert_eq!(
+            search("NewUser", MatchMode::Prefix, true),
+            "NewUser,NewUserService"
+        );
+        assert_eq!(
+            search("refund", MatchMode::Substring, false),
+            "ProcessPaymentRefund"
+        );
+        assert_eq!(
+            search("ProcPay", MatchMode::Fuzzy, true),
+            "ProcessPayment,ProcessPaymentRefund"
+        );
+        assert_eq!(search("procpay", MatchMode::Fuzzy, true), "");
+    }
+
+    #[test]
+    fn test_regex_search_targets_qualified_or_short_names() {
+        let index = sample_index();
+        let regex = |target: MatchTarget| SearchOptions {
+            mode: MatchMode::Regex,
+            case_sensitive: true,
+            target,
+            ..SearchOptions::default()
+        };
+
+        let matches = index
+            .search_symbols("^users\\.", &regex(MatchTarget::Auto))
+            .unwrap();
+        assert_eq!(names(&matches), vec!["User", "NewUser", "NewUserService"]);
+
+        let matches = index
+            .search_symbols("(?i)^new", &regex(MatchTarget::Name))
+            .unwrap();
+        assert_eq!(names(&matches), vec!["NewUser", "NewUserService"]);
+        let matches = index
+            .search_symbols("^New", &regex(MatchTarget::Qualified))
+            .unwrap();
+        assert!(matches.is_empty());
+
+        assert!(matches!(
+            index.search_symbols("New(", &regex(MatchTarget::Name)),
+            Err(SearchError::InvalidRegex(_))
+        ));
+    }
+
+    #[test]
+    fn test_cancelled_search_is_an_error() {
+        let index = sample_index();
+        let cancel = CancelToken::new();
+        let options = SearchOptions {
+            mode: MatchMode::Regex,
+            cancel: Some(cancel.clone()),
+            ..SearchOptions::default()
+        };
+        assert_eq!(index.search_symbols("^users", &options).unwrap().len(), 3);
+
+        cancel.cancel();
+        assert!(matches!(
+            index.search_symbols("^users", &options),
+            Err(SearchError::Cancelled(CancelError::Cancelled))
+        ));
+    }
+
+    #[test]
+    fn test_glob_matches() {
+        assert!(glob_matches("*.go", "pkg/main.go"));
+        assert!(glob_matches("pkg/*.go", "pkg/main.go"));
+        assert!(!glob_matches("pkg/*.go", "pkg/sub/main.go"));
+        assert!(glob_matches("pkg/**/*.go", "pkg/main.go"));
+        assert!(glob_matches("pkg/**/*.go", "pkg/sub/deep/main.go"));
+        assert!(glob_matches("**/main_?.go", "a/b/main_1.go"));
+        assert!(!glob_matches("*.rs", "pkg/main.go"));
+    }
+}
@@ -0,0 +1,359 @@
+//! Structured function signatures: receiver, parameters, and results.
+//!
+//! Parsers store a function's signature as source text in
+//! [`Symbol::signature`], which is what hover text shows.
+//! [`Symbol::structured_signature`] splits that text into named, typed parts
+//! so callers can compare or search signatures without re-parsing source.
+//! Grouped names are expanded (`dst, src string` becomes two parameters), a
+//! variadic parameter is flagged rather than folded into its type, and every
+//! result of a multiple-return function is kept.
+//!
+//! Go and Java signatures are structured so far; other languages return
+//! `None`.
+//!
+//! [`CodeIndex::match_signature`] finds every function and method with a
+//! given shape, whatever type declares it: parameter and result types must
+//! match in order, while names are ignored.
+//!
+//! # Examples
+//!
+//! ```
+//! use rocketindex::{Location, Symbol, SymbolKind, Visibility};
+//! use std::path::PathBuf;
+//!
+//! let symbol = Symbol::new(
+//!     "ProcessPayment".to_string(),
+//!     "main.PaymentService.ProcessPayment".to_string(),
+//!     SymbolKind::Function,
+//!     Location::new(PathBuf::from("payment.go"), 12, 26),
+//!     Visibility::Public,
+//!     "go".to_string(),
+//! )
+//! .with_signature(Some(
+//!     "func (ps *PaymentService) ProcessPayment(user *User, amount float64) bool".to_string(),
+//! ));
+//!
+//! let signature = symbol.structured_signature().unwrap();
+//! assert_eq!(signature.to_string(), "(user *User, amount float64) bool");
+//! let receiver = signature.receiver.unwrap();
+//! assert_eq!(receiver.name.as_deref(), Some("ps"));
+//! assert_eq!(receiver.ty, "*PaymentService");
+//! assert_eq!(signature.params[1].ty, "float64");
+//! ```
+
+use std::fmt;
+
+use serde::{Deserialize, Serialize};
+
+use crate::{CodeIndex, Symbol};
+
+/// A parameter, result, receiver, or type parameter.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct Param {
+    /// Name as declared, if any (results and interface parameters are often unnamed)
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub name: Option<String>,
+    /// Type with whitespace normalized, without the variadic `...`
+    /// (for a type parameter, its constraint)
+    pub ty: String,
+    /// Whether this is a variadic parameter (`args ...string`)
+    #[serde(default, skip_serializing_if = "std::ops::Not::not")]
+    pub variadic: bool,
+}
+
+impl Param {
+    /// The type as written at the declaration, including a variadic `...`.
+    #[must_use]
+    pub fn type_text(&self) -> String {
+        if self.variadic {
+            format!("...{}", self.ty)
+        } else {
+            self.ty.clone()
+        }
+    }
+}
+
+impl fmt::Display for Param {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match &self.name {
+            Some(name) => write!(f, "{} {}", name, self.type_text()),
+            None => f.write_str(&self.type_text()),
+        }
+    }
+}
+
+/// The typed parts of a function or method signature.
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct Signature {
+    /// Method receiver (`ps *PaymentService`)
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub receiver: Option<Param>,
+    /// Type parameters of a generic function, with their constraints
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub type_params: Vec<Param>,
+    pub params: Vec<Param>,
+    pub results: Vec<Param>,
+}
+
+impl Signature {
+    /// Whether the last parameter is variadic.
+    #[must_use]
+    pub fn is_variadic(&self) -> bool {
+        self.params.last().is_some_and(|p| p.variadic)
+    }
+}
+
+/// Parameters and results: `(user *User, amount float64) bool`.
+impl fmt::Display for Signature {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        write!(f, "({})", join(&self.params))?;
+        match self.results.as_slice() {
+            [] => Ok(()),
+            [single] if single.name.is_none() => write!(f, " {}", single),
+            results => write!(f, " ({})", join(results)),
+        }
+    }
+}
+
+fn join(params: &[Param]) -> String {
+    params
+        .iter()
+        .map(Param::to_string)
+        .collect::<Vec<_>>()
+        .join(", ")
+}
+
+/// Options for [`CodeIndex::match_signature`].
+#[derive(Debug, Clone, Copy, Default)]
+pub struct SignatureMatchOptions {
+    /// When the query has a receiver, only match methods whose receiver is a
+    /// pointer (`*T`) exactly when the query's is. Receivers are otherwise
+    /// ignored.
+    pub pointer_receivers: bool,
+}
+
+impl Signature {
+    /// Whether `other` has the same parameter and result types.
+    ///
+    /// Names, receivers, and type parameters are not compared.
+    #[must_use]
+    pub fn same_types(&self, other: &Signature) -> bool {
+        same_param_types(&self.params, &other.params)
+            && same_param_types(&self.results, &other.results)
+    }
+}
+
+fn same_param_types(a: &[Param], b: &[Param]) -> bool {
+    a.len() == b.len()
+        && a.iter()
+            .zip(b)
+            .all(|(a, b)| a.ty == b.ty && a.variadic == b.variadic)
+}
+
+fn is_pointer(receiver: &Param) -> bool {
+    receiver.ty.starts_with('*')
+}
+
+impl CodeIndex {
+    /// Functions and methods whose signature has the same parameter and
+    /// result types as `signature` (see [`Signature::same_types`]),
+    /// ordered by file then line.
+    #[must_use]
+    pub fn match_signature(
+        &self,
+        signature: &Signature,
+        options: SignatureMatchOptions,
+    ) -> Vec<&Symbol> {
+        let receiver = signature
+            .receiver
+            .as_ref()
+            .filter(|_| options.pointer_receivers);
+
+        let mut matches: Vec<&Symbol> = self
+            .symbols()
+            .filter(|symbol| symbol.kind.is_callable())
+            .filter(|symbol| {
+                symbol.structured_signature().is_some_and(|candidate| {
+                    candidate.same_types(signature)
+                        && receiver.is_none_or(|receiver| {
+                            candidate
+                                .receiver
+                                .as_ref()
+                                .is_some_and(|r| is_pointer(r) == is_pointer(receiver))
+                        })
+                })
+            })
+            .collect();
+        matches.sort_by(|a, b| {
+            a.location
+                .file
+                .cmp(&b.location.file)
+                .then(a.location.line.cmp(&b.location.line))
+                .then_with(|| a.qualified.cmp(&b.qualified))
+        });
+        matches
+    }
+}
+
+impl Symbol {
+    /// Parse [`Symbol::signature`] into its parts.
+    ///
+    /// Returns `None` if the symbol has no signature, it is not a function
+    /// signature, or its language is not supported.
+    #[must_use]
+    pub fn structured_signature(&self) -> Option<Signature> {
+        let text = self.signature.as_deref()?;
+        match self.language.as_str() {
+            "go" => crate::languages::go::signature::parse(text),
+            "java" => crate::languages::java::signature::parse(text),
+            _ => None,
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::languages::go::signature::parse;
+    use crate::{Location, SymbolKind, Visibility};
+    use std::path::PathBuf;
+
+    fn param(name: Option<&str>, ty: &str, variadic: bool) -> Param {
+        Param {
+            name: name.map(str::to_string),
+            ty: ty.to_string(),
+            variadic,
+        }
+    }
+
+    #[test]
+    fn test_display_matches_go_syntax() {
+        let signature = Signature {
+            receiver: None,
+            type_params: Vec::new(),
+            params: vec![
+                param(Some("format"), "string", false),
+                param(Some("args"), "any", true),
+            ],
+            results: vec![param(None, "int", false), param(None, "error", false)],
+        };
+        assert_eq!(
+            signature.to_string(),
+            "(format string, args ...any) (int, error)"
+        );
+        assert!(signature.is_variadic());
+
+        let named = Signature {
+            results: vec![param(Some("n"), "int", false)],
+            ..Signature::default()
+        };
+        assert_eq!(named.to_string(), "() (n int)");
+        assert!(!named.is_variadic());
+    }
+
+    #[test]
+    fn test_serializes_without_empty_parts() {
+        let signature = Signature {
+            params: vec![param(Some("x"), "int", false)],
+            ..Signature::default()
+        };
+        let json = serde_json::to_value(&signature).unwrap();
+        assert_eq!(
+            json,
+            serde_json::json!({ "params": [{ "name": "x", "ty": "int" }], "results": [] })
+        );
+    }
+
+    fn payments_index() -> CodeIndex {
+        let mut index = CodeIndex::new();
+        for (line, qualified, signature) in [
+            (
+                3,
+                "main.PaymentService.ProcessPayment",
+                "func (ps *PaymentService) ProcessPayment(user *User, amount float64) bool",
+            ),
+            (
+                9,
+                "main.PaymentService.Split",
+                "func (ps *PaymentService) Split(user *User, amounts ...float64) bool",
+            ),
+            (
+                15,
+                "main.MockPayments.Charge",
+                "func (m MockPayments) Charge(u *User, amt float64) bool",
+            ),
+            (
+                20,
+                "main.processPayment",
+                "func processPayment(user *User, amount float64) bool",
+            ),
+            (
+                25,
+                "main.Refund",
+                "func Refund(user *User, amount float64) error",
+            ),
+        ] {
+            let name = qualified.rsplit('.').next().unwrap();
+            index.add_symbol(
+                Symbol::new(
+                    name.to_string(),
+                    qualified.to_string(),
+                    SymbolKind::Function,
+                    Location::new(PathBuf::from("payments.go"), line, 1),
+                    Visibility::Public,
+                    "go".to_string(),
+                )
+                .with_signature(Some(signature.to_string())),
+            );
+        }
+        index
+    }
+
+    fn qualified_names<'a>(symbols: &[&'a Symbol]) -> Vec<&'a str> {
+        symbols.iter().map(|s| s.qualified.as_str()).collect()
+    }
+
+    #[test]
+    fn test_match_signature_ignores_names_and_declaring_type() {
+        let index = payments_index();
+        let query = parse("func ProcessPayment(u *User, a float64) bool").unwrap();
+
+        let matches = index.match_signature(&query, SignatureMatchOptions::default());
+        assert_eq!(
+            qualified_names(&matches),
+            vec![
+                "main.PaymentService.ProcessPayment",
+                "main.MockPayments.Charge",
+                "main.processPayment"
+            ]
+        );
+    }
+
+    #[test]
+    fn test_match_signature_can_distinguish_pointer_receivers() {
+        let index = payments_index();
+        let options = SignatureMatchOptions {
+            pointer_receivers: true,
+        };
+
+        let pointer = parse("func (s *Service) Pay(u *User, a float64) bool").unwrap();
+        assert_eq!(
+            qualified_names(&index.match_signature(&pointer, options)),
+            vec!["main.PaymentService.ProcessPayment"]
+        );
+
+        let value = parse("func (s Service) Pay(u *User, a float64) bool").unwrap();
+        assert_eq!(
+            qualified_names(&index.match_signature(&value, options)),
+            vec!["main.MockPayments.Charge"]
+        );
+
+        // Without the option the receiver is ignored
+        assert_eq!(
+            index
+                .match_signature(&value, SignatureMatchOptions::default())
+                .len(),
+            3
+        );
+    }
+}
@@ -0,0 +1,254 @@
+//! Save a [`CodeIndex`] and its [`CallGraph`] to a single file and load them back.
+//!
+//! Rebuilding a large workspace on every CLI invocation is wasteful, so
+//! [`CodeIndex::save`] writes the full symbol table, references, and call
+//! graph to disk and [`CodeIndex::load`] restores them without re-parsing.
+//!
+//! # File format
+//!
+//! The file starts with a one-line header naming the format version,
+//! followed by the JSON body:
+//!
+//! ```text
+//! rocketindex-snapshot 4
+//! {"index":{...},"call_sites":[...],"unresolved_calls":[...],"call_graph_options":{...}}
+//! ```
+//!
+//! [`CodeIndex::load`] checks the header before reading the body and refuses
+//! files written with a different [`SNAPSHOT_VERSION`], so an old snapshot is
+//! rebuilt instead of being misread.
+//!
+//! Saving is atomic: the snapshot is written to a temporary file next to the
+//! target and renamed over it, so a crash mid-save leaves the previous
+//! snapshot intact.
+//!
+//! The workspace root and any type cache or external index are not stored;
+//! call [`CodeIndex::set_workspace_root`] after loading.
+
+use std::fs::{self, File};
+use std::io::{BufRead, BufReader, BufWriter, Write};
+use std::path::{Path, PathBuf};
+
+use serde::{Deserialize, Serialize};
+
+use crate::callgraph::{CallGraph, CallGraphOptions, CallSite, UnresolvedCall};
+use crate::{CodeIndex, IndexError, Result};
+
+/// Current snapshot format version. Increment when the serialized layout changes.
+pub const SNAPSHOT_VERSION: u32 = 6;
+
+/// First token of the header line.
+const SNAPSHOT_MAGIC: &str = "rocketindex-snapshot";
+
+#[derive(Serialize)]
+struct SnapshotRef<'a> {
+    index: &'a CodeIndex,
+    call_sites: &'a [CallSite],
+    unresolved_calls: &'a [UnresolvedCall],
+    call_graph_options: CallGraphOptions,
+}
+
+#[derive(Deserialize)]
+struct Snapshot {
+    index: CodeIndex,
+    call_sites: Vec<CallSite>,
+    unresolved_calls: Vec<UnresolvedCall>,
+    call_graph_options: CallGraphOptions,
+}
+
+impl CodeIndex {
+    /// Save the index and its call graph to `path`.
+    ///
+    /// The file is replaced atomically; parent directories must exist.
+    pub fn save(&self, path: &Path, call_graph: &CallGraph) -> Result<()> {
+        let temp_path = temp_path_for(path);
+        let result = write_snapshot(&temp_path, self, call_graph)
+            .and_then(|()| fs::rename(&temp_path, path).map_err(IndexError::from));
+        if result.is_err() {
+            let _ = fs::remove_file(&temp_path);
+        }
+        result
+    }
+
+    /// Load an index and call graph written by [`CodeIndex::save`].
+    ///
+    /// Returns an error if the file is not a snapshot, was written by an
+    /// incompatible version, or is truncated.
+    pub fn load(path: &Path) -> Result<(CodeIndex, CallGraph)> {
+        let mut reader = BufReader::new(File::open(path)?);
+
+        let mut header = String::new();
+        reader.read_line(&mut header)?;
+        let version = parse_header(&header)?;
+        if version != SNAPSHOT_VERSION {
+            return Err(invalid_data(format!(
+                "Index snapshot version {} is not supported (expected {}); rebuild the index",
+                version, SNAPSHOT_VERSION
+            )));
+        }
+
+        let snapshot: Snapshot = serde_json::from_reader(reader)?;
+        let call_graph = CallGraph::from_sorted_sites(
+            snapshot.call_sites,
+            snapshot.unresolved_calls,
+            snapshot.call_graph_options,
+        );
+        Ok((snapshot.index, call_graph))
+    }
+}
+
+fn write_snapshot(path: &Path, index: &CodeIndex, call_graph: &CallGraph) -> Result<()> {
+    let file = File::create(path)?;
+    let mut writer = BufWriter::new(file);
+    writeln!(writer, "{} {}", SNAPSHOT_MAGIC, SNAPSHOT_VERSION)?;
+    serde_json::to_writer(
+        &mut writer,
+        &SnapshotRef {
+            index,
+            call_sites: call_graph.sites(),
+            unresolved_calls: call_graph.unresolved_calls(),
+            call_graph_options: call_graph.options(),
+        },
+    )?;
+    let file = writer.into_inner().map_err(|e| e.into_error())?;
+    file.sync_all()?;
+    Ok(())
+}
+
+/// Parse the version from a `rocketindex-snapshot <version>` header line.
+fn parse_header(header: &str) -> Result<u32> {
+    let mut parts = header.split_whitespace();
+    if parts.next() != Some(SNAPSHOT_MAGIC) {
+        return Err(invalid_data("Not a RocketIndex snapshot file".to_string()));
+    }
+    parts
+        .next()
+        .and_then(|v| v.parse().ok())
+        .ok_or_else(|| invalid_data("Invalid snapshot version header".to_string()))
+}
+
+/// Temporary file in the same directory as `path`, so the rename stays on one filesystem.
+fn temp_path_for(path: &Path) -> PathBuf {
+    let file_name = path
+        .file_name()
+        .map(|name| name.to_string_lossy().into_owned())
+        .unwrap_or_else(|| "index".to_string());
+    path.with_file_name(format!(".{}.{}.tmp", file_name, std::process::id()))
+}
+
+fn invalid_data(message: String) -> IndexError {
+    IndexError::IoError(std::io::Error::new(
+        std::io::ErrorKind::InvalidData,
+        message,
+    ))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::{Location, Reference, ReferenceKind, Symbol, SymbolKind, Visibility};
+
+    fn sample_index() -> CodeIndex {
+        let mut index = CodeIndex::new();
+        for (name, line) in [("main", 1), ("helper", 10)] {
+            index.add_symbol(Symbol::new(
+                name.to_string(),
+                format!("main.{}", name),
+                SymbolKind::Function,
+                Location::with_end(PathBuf::from("main.go"), line, 6, line + 3, 2),
+                Visibility::Public,
+                "go".to_string(),
+            ));
+        }
+        index.add_reference(
+            PathBuf::from("main.go"),
+            Reference {
+                name: "helper".to_string(),
+                location: Location::new(PathBuf::from("main.go"), 2, 5),
+                kind: ReferenceKind::Unknown,
+                target: None,
+                arguments: None,
+            },
+        );
+        index.add_reference(
+            PathBuf::from("main.go"),
+            Reference {
+                name: "fmt.Println".to_string(),
+                location: Location::new(PathBuf::from("main.go"), 3, 5),
+                kind: ReferenceKind::Call,
+                target: None,
+                arguments: None,
+            },
+        );
+        index
+    }
+
+    #[test]
+    fn test_save_and_load_round_trip() {
+        let dir = tempfile::tempdir().unwrap();
+        let path = dir.path().join("index.snapshot");
+        let index = sample_index();
+        let graph = CallGraph::build(&index);
+        assert_eq!(graph.len(), 1);
+
+        index.save(&path, &graph).unwrap();
+        let (loaded, loaded_graph) = CodeIndex::load(&path).unwrap();
+
+        assert_eq!(loaded.symbol_count(), index.symbol_count());
+        assert_eq!(loaded.get("main.helper").unwrap().location.line, 10);
+        assert_eq!(loaded.references().count(), 2);
+        assert_eq!(loaded_graph.sites(), graph.sites());
+        assert_eq!(loaded_graph.unresolved_calls().len(), 1);
+        assert_eq!(loaded_graph.unresolved_calls(), graph.unresolved_calls());
+        assert_eq!(loaded_graph.callers("main.helper")[0].caller, "main.main");
+        assert_eq!(loaded_graph.callees("main.main")[0].callee, "main.helper");
+    }
+
+    #[test]
+    fn test_save_replaces_existing_and_leaves_no_temp_file() {
+        let dir = tempfile::tempdir().unwrap();
+        let path = dir.path().join("index.snapshot");
+        fs::write(&path, "old contents").unwrap();
+
+        let index = sample_index();
+        index.save(&path, &CallGraph::build(&index)).unwrap();
+
+        assert!(CodeIndex::load(&path).is_ok());
+        let entries: Vec<_> = fs::read_dir(dir.path()).unwrap().collect();
+        assert_eq!(entries.len(), 1);
+    }
+
+    #[test]
+    fn test_load_rejects_other_versions() {
+        let dir = tempfile::tempdir().unwrap();
+        let path = dir.path().join("index.snapshot");
+        let index = sample_index();
+        index.save(&path, &CallGraph::build(&index)).unwrap();
+
+        let contents = fs::read_to_string(&path).unwrap();
+        let newer = contents.replacen(
+            &format!("{} {}", SNAPSHOT_MAGIC, SNAPSHOT_VERSION),
+            &format!("{} {}", SNAPSHOT_MAGIC, SNAPSHOT_VERSION + 1),
+            1,
+        );
+        fs::write(&path, newer).unwrap();
+
+        let err = CodeIndex::load(&path).unwrap_err();
+        assert!(err.to_string().contains("not supported"), "{}", err);
+    }
+
+    #[test]
+    fn test_load_rejects_foreign_and_truncated_files() {
+        let dir = tempfile::tempdir().unwrap();
+        let path = dir.path().join("index.snapshot");
+
+        fs::write(&path, "{\"definitions\":{}}").unwrap();
+        assert!(CodeIndex::load(&path).is_err());
+
+        let index = sample_index();
+        index.save(&path, &CallGraph::build(&index)).unwrap();
+        let contents = fs::read_to_string(&path).unwrap();
+        fs::write(&path, &contents[..contents.len() / 2]).unwrap();
+        assert!(CodeIndex::load(&path).is_err());
+    }
+}
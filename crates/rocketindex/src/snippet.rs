@@ -0,0 +1,280 @@
+//! Source snippets of symbol definitions.
+//!
+//! [`CodeIndex::definition`] looks a symbol up by its stable ID (see
+//! [`crate::json`]) and, if [`DefinitionOptions::source`] is set, returns the
+//! text of its definition with [`DefinitionOptions::context_lines`] lines of
+//! surrounding context, so a hover or a prompt can show a method's body
+//! without re-reading and slicing the file. [`CodeIndex::snippet`] does the
+//! same for a symbol already at hand.
+//!
+//! The definition spans the lines of the symbol's whole declaration (see
+//! [`Symbol::lines`]). Context is clamped at the start and end of the file.
+//!
+//! Text is read from the file on disk, or from the copy the indexer captured
+//! when [`IndexOptions::cache_sources`](crate::indexer::IndexOptions::cache_sources)
+//! was set, which keeps snippets in line with the indexed locations while
+//! the file is being edited. [`DefinitionOptions::cached`] chooses between
+//! them; files without a captured copy are always read from disk. Captured
+//! copies are not saved in snapshots.
+//!
+//! # Examples
+//!
+//! ```no_run
+//! use rocketindex::snippet::DefinitionOptions;
+//! use rocketindex::CodeIndex;
+//!
+//! # let index = CodeIndex::new();
+//! # let id = String::new();
+//! let options = DefinitionOptions {
+//!     source: true,
+//!     context_lines: 2,
+//!     ..DefinitionOptions::default()
+//! };
+//! if let Some(definition) = index.definition(&id, &options) {
+//!     let location = &definition.symbol.location;
+//!     println!("{}:{}", location.file.display(), location.line);
+//!     if let Some(snippet) = definition.snippet {
+//!         println!("{}", snippet.text);
+//!     }
+//! }
+//! ```
+
+use std::borrow::Cow;
+use std::path::Path;
+use std::sync::Arc;
+
+use serde::Serialize;
+
+use crate::{CodeIndex, Symbol};
+
+/// Default for [`DefinitionOptions::context_lines`].
+pub const DEFAULT_CONTEXT_LINES: u32 = 2;
+
+/// What [`CodeIndex::definition`] returns along with the symbol.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub struct DefinitionOptions {
+    /// Include the source text of the definition
+    pub source: bool,
+    /// Lines of context before and after the definition
+    pub context_lines: u32,
+    /// Read the copy captured at index time, if there is one, rather than
+    /// the file on disk
+    pub cached: bool,
+}
+
+impl Default for DefinitionOptions {
+    fn default() -> Self {
+        Self {
+            source: false,
+            context_lines: DEFAULT_CONTEXT_LINES,
+            cached: true,
+        }
+    }
+}
+
+/// A symbol's definition, as returned by [`CodeIndex::definition`].
+#[derive(Debug, Clone, Serialize)]
+pub struct Definition<'a> {
+    /// Stable ID of the symbol
+    pub id: String,
+    /// The symbol, with the location of its definition
+    pub symbol: &'a Symbol,
+    /// The definition's source, if requested and readable
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub snippet: Option<Snippet>,
+}
+
+/// Source lines around a definition.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct Snippet {
+    /// First line of `text` (1-based)
+    pub start_line: u32,
+    /// Last line of `text` (inclusive)
+    pub end_line: u32,
+    /// The lines, joined with `\n`
+    pub text: String,
+    /// Whether `text` came from the copy captured at index time
+    pub cached: bool,
+}
+
+impl CodeIndex {
+    /// The symbol with stable ID `id`, with its source if
+    /// [`DefinitionOptions::source`] is set.
+    ///
+    /// Returns `None` if no symbol has that ID. The snippet is `None` if the
+    /// source was not requested or the file cannot be read.
+    #[must_use]
+    pub fn definition(&self, id: &str, options: &DefinitionOptions) -> Option<Definition<'_>> {
+        let symbol = self.symbol_by_id(id)?;
+        let snippet = if options.source {
+            self.snippet(symbol, options)
+        } else {
+            None
+        };
+        Some(Definition {
+            id: id.to_string(),
+            symbol,
+            snippet,
+        })
+    }
+
+    /// The source of `symbol`'s definition with
+    /// [`DefinitionOptions::context_lines`] lines around it, or `None` if its
+    /// file cannot be read or ends before the definition.
+    ///
+    /// [`DefinitionOptions::source`] is ignored.
+    #[must_use]
+    pub fn snippet(&self, symbol: &Symbol, options: &DefinitionOptions) -> Option<Snippet> {
+        let (source, cached) = self.source_text(&symbol.location.file, options.cached)?;
+
+        let lines: Vec<&str> = source.lines().collect();
+        let (line, end) = symbol.lines();
+        let line = line.max(1);
+        if line as usize > lines.len() {
+            return None;
+        }
+        let end = end.max(line);
+        let start_line = line.saturating_sub(options.context_lines).max(1);
+        let end_line = end
+            .saturating_add(options.context_lines)
+            .min(lines.len() as u32);
+
+        Some(Snippet {
+            start_line,
+            end_line,
+            text: lines[start_line as usize - 1..end_line as usize].join("\n"),
+            cached,
+        })
+    }
+
+    /// The source `file` was indexed from, if the indexer captured it (see
+    /// [`IndexOptions::cache_sources`](crate::indexer::IndexOptions::cache_sources)).
+    ///
+    /// The file path can be either absolute or relative.
+    #[must_use]
+    pub fn cached_source(&self, file: &Path) -> Option<&str> {
+        self.file_sources
+            .get(&self.to_relative(file))
+            .map(|source| &**source)
+    }
+
+    /// The text of `file`: the copy captured at index time if `cached` and
+    /// there is one (with `true`), else the file on disk (with `false`).
+    pub(crate) fn source_text(&self, file: &Path, cached: bool) -> Option<(Cow<'_, str>, bool)> {
+        match self.cached_source(file).filter(|_| cached) {
+            Some(source) => Some((Cow::Borrowed(source), true)),
+            None => {
+                let source = std::fs::read_to_string(self.to_absolute(file)).ok()?;
+                Some((Cow::Owned(source), false))
+            }
+        }
+    }
+
+    /// Keep a copy of the source `file` was indexed from.
+    pub(crate) fn set_cached_source(&mut self, file: &Path, source: String) {
+        let relative = self.to_relative(file);
+        self.file_sources.insert(relative, Arc::from(source));
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::indexer::IndexOptions;
+
+    const SOURCE: &str =
+        "import os\n\ndef first():\n    return 1\n\ndef second():\n    x = 2\n    return x\n";
+
+    fn indexed(cache_sources: bool) -> (tempfile::TempDir, CodeIndex) {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let root = temp_dir.path();
+        std::fs::write(root.join("a.py"), SOURCE).unwrap();
+
+        let mut index = CodeIndex::with_root(root.to_path_buf());
+        let options = IndexOptions {
+            cache_sources,
+            ..IndexOptions::default()
+        };
+        index.index_files(&[root.join("a.py")], &options);
+        (temp_dir, index)
+    }
+
+    fn id_of(index: &CodeIndex, name: &str) -> String {
+        index
+            .symbols_with_ids()
+            .into_iter()
+            .find_map(|(symbol, id)| (symbol.name == name).then_some(id))
+            .unwrap()
+    }
+
+    fn with_context(context_lines: u32) -> DefinitionOptions {
+        DefinitionOptions {
+            source: true,
+            context_lines,
+            ..DefinitionOptions::default()
+        }
+    }
+
+    #[test]
+    fn test_definition_includes_source_when_requested() {
+        let (_dir, index) = indexed(false);
+        let id = id_of(&index, "first");
+
+        let definition = index
+            .definition(&id, &DefinitionOptions::default())
+            .unwrap();
+        assert_eq!(definition.symbol.name, "first");
+        assert!(definition.snippet.is_none());
+
+        let snippet = index.definition(&id, &with_context(0)).unwrap().snippet;
+        assert_eq!(
+            snippet,
+            Some(Snippet {
+                start_line: 3,
+                end_line: 4,
+                text: "def first():\n    return 1".to_string(),
+                cached: false,
+            })
+        );
+
+        assert!(index.definition("missing", &with_context(0)).is_none());
+    }
+
+    #[test]
+    fn test_context_is_clamped_at_file_boundaries() {
+        let (_dir, index) = indexed(false);
+
+        let first = index.get("first").unwrap();
+        let snippet = index.snippet(first, &with_context(5)).unwrap();
+        assert_eq!((snippet.start_line, snippet.end_line), (1, 8));
+
+        let second = index.get("second").unwrap();
+        let snippet = index.snippet(second, &with_context(1)).unwrap();
+        assert_eq!((snippet.start_line, snippet.end_line), (5, 8));
+        assert_eq!(snippet.text, "\ndef second():\n    x = 2\n    return x");
+    }
+
+    #[test]
+    fn test_cached_copy_survives_edits_on_disk() {
+        let (dir, index) = indexed(true);
+        std::fs::write(dir.path().join("a.py"), "# rewritten\n").unwrap();
+        let first = index.get("first").unwrap();
+
+        let snippet = index.snippet(first, &with_context(0)).unwrap();
+        assert!(snippet.cached);
+        assert_eq!(snippet.text, "def first():\n    return 1");
+
+        let from_disk = DefinitionOptions {
+            cached: false,
+            ..with_context(0)
+        };
+        assert!(index.snippet(first, &from_disk).is_none());
+
+        let (dir, index) = indexed(false);
+        std::fs::write(dir.path().join("a.py"), "# rewritten\n").unwrap();
+        assert!(index.cached_source(Path::new("a.py")).is_none());
+        assert!(index
+            .snippet(index.get("first").unwrap(), &with_context(0))
+            .is_none());
+    }
+}
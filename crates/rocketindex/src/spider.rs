@@ -7,6 +7,7 @@
 use std::collections::{HashSet, VecDeque};
 use std::path::Path;
 
+use crate::cancel::{CancelToken, Partial};
 use crate::index::Reference;
 use crate::{CodeIndex, Symbol};
 
@@ -59,6 +60,19 @@ impl SpiderResult {
 /// A `SpiderResult` containing all reachable symbols in breadth-first order.
 #[must_use]
 pub fn spider(index: &CodeIndex, entry_point: &str, max_depth: usize) -> SpiderResult {
+    spider_with_cancel(index, entry_point, max_depth, &CancelToken::new()).value
+}
+
+/// [`spider`], stopping once `cancel` fires.
+///
+/// A truncated result holds the nodes visited before the crawl stopped.
+#[must_use]
+pub fn spider_with_cancel(
+    index: &CodeIndex,
+    entry_point: &str,
+    max_depth: usize,
+    cancel: &CancelToken,
+) -> Partial<SpiderResult> {
     let mut result = SpiderResult::new();
     let mut visited: HashSet<String> = HashSet::new();
     let mut queue: VecDeque<(String, usize)> = VecDeque::new();
@@ -67,6 +81,9 @@ pub fn spider(index: &CodeIndex, entry_point: &str, max_depth: usize) -> SpiderR
     queue.push_back((entry_point.to_string(), 0));
 
     while let Some((qualified_name, depth)) = queue.pop_front() {
+        if cancel.is_cancelled() {
+            return Partial::truncated(result);
+        }
         // Skip if already visited
         if visited.contains(&qualified_name) {
             continue;
@@ -92,9 +109,14 @@ pub fn spider(index: &CodeIndex, entry_point: &str, max_depth: usize) -> SpiderR
 
                 // Try to resolve each reference and add to queue
                 for reference in references {
-                    if let Some(resolved) =
+                    let targeted = reference.target.as_ref().filter(|target| {
+                        index.get(target).is_some_and(|s| {
+                            index.can_reference(&symbol.location.file, &s.location.file)
+                        })
+                    });
+                    if let Some(resolved) = targeted.cloned().or_else(|| {
                         try_resolve_reference(index, &reference.name, opens, &symbol.location.file)
-                    {
+                    }) {
                         if !visited.contains(&resolved) {
                             queue.push_back((resolved, depth + 1));
                         }
@@ -116,7 +138,7 @@ pub fn spider(index: &CodeIndex, entry_point: &str, max_depth: usize) -> SpiderR
         }
     }
 
-    result
+    Partial::complete(result)
 }
 
 /// Try to resolve a reference name to a qualified symbol name.
@@ -209,6 +231,19 @@ pub fn spider_from_file(index: &CodeIndex, file: &Path, max_depth: usize) -> Spi
 /// A `SpiderResult` containing all callers in breadth-first order.
 #[must_use]
 pub fn reverse_spider(index: &CodeIndex, entry_point: &str, max_depth: usize) -> SpiderResult {
+    reverse_spider_with_cancel(index, entry_point, max_depth, &CancelToken::new()).value
+}
+
+/// [`reverse_spider`], stopping once `cancel` fires.
+///
+/// A truncated result holds the callers found before the crawl stopped.
+#[must_use]
+pub fn reverse_spider_with_cancel(
+    index: &CodeIndex,
+    entry_point: &str,
+    max_depth: usize,
+    cancel: &CancelToken,
+) -> Partial<SpiderResult> {
     let mut result = SpiderResult::new();
     let mut visited: HashSet<String> = HashSet::new();
     let mut queue: VecDeque<(String, usize)> = VecDeque::new();
@@ -217,6 +252,9 @@ pub fn reverse_spider(index: &CodeIndex, entry_point: &str, max_depth: usize) ->
     queue.push_back((entry_point.to_string(), 0));
 
     while let Some((qualified_name, depth)) = queue.pop_front() {
+        if cancel.is_cancelled() {
+            return Partial::truncated(result);
+        }
         // Skip if already visited
         if visited.contains(&qualified_name) {
             continue;
@@ -257,7 +295,7 @@ pub fn reverse_spider(index: &CodeIndex, entry_point: &str, max_depth: usize) ->
         }
     }
 
-    result
+    Partial::complete(result)
 }
 
 /// Find the symbol that contains a given reference (for determining callers).
@@ -310,7 +348,7 @@ pub fn format_spider_result(result: &SpiderResult) -> String {
 #[cfg(test)]
 mod tests {
     use super::*;
-    use crate::{Location, Reference, SymbolKind, Visibility};
+    use crate::{Location, Reference, ReferenceKind, SymbolKind, Visibility};
     use std::path::PathBuf;
 
     fn make_symbol(name: &str, qualified: &str, file: &str, line: u32) -> Symbol {
@@ -337,6 +375,7 @@ mod tests {
             implements: None,
             doc: None,
             signature: None,
+            extent: None,
         }
     }
 
@@ -344,6 +383,9 @@ mod tests {
         Reference {
             name: name.to_string(),
             location: Location::new(PathBuf::from(file), line, 1),
+            kind: ReferenceKind::Unknown,
+            target: None,
+            arguments: None,
         }
     }
 
@@ -470,6 +512,30 @@ mod tests {
     // Reverse Spider Tests
     // =========================================================================
 
+    #[test]
+    fn test_cancelled_spider_is_truncated() {
+        let mut index = CodeIndex::new();
+        index.add_symbol(make_symbol("main", "Program.main", "src/Program.fs", 10));
+        index.add_symbol(make_symbol("helper", "Program.helper", "src/Program.fs", 5));
+        index.add_reference(
+            PathBuf::from("src/Program.fs"),
+            make_reference("Program.helper", "src/Program.fs", 11),
+        );
+
+        let cancel = CancelToken::with_timeout(std::time::Duration::from_secs(60));
+        let complete = spider_with_cancel(&index, "Program.main", 5, &cancel);
+        assert!(!complete.truncated);
+        assert_eq!(complete.value.nodes.len(), 2);
+
+        cancel.cancel();
+        let forward = spider_with_cancel(&index, "Program.main", 5, &cancel);
+        assert!(forward.truncated);
+        assert!(forward.value.nodes.is_empty());
+        let reverse = reverse_spider_with_cancel(&index, "Program.helper", 5, &cancel);
+        assert!(reverse.truncated);
+        assert!(reverse.value.nodes.is_empty());
+    }
+
     #[test]
     fn test_reverse_spider_single_node() {
         let mut index = CodeIndex::new();
@@ -0,0 +1,570 @@
+//! Memory-bounded indexing that streams results to a [`Sink`].
+//!
+//! [`CodeIndex::index_files`] keeps every symbol and reference of a workspace
+//! in memory. For very large repositories, [`index_streaming`] instead hands
+//! each file's results to a [`Sink`] as soon as they are ready and keeps only
+//! what call resolution needs.
+//!
+//! Resolving a call can require a symbol from a file that has not been parsed
+//! yet, so indexing runs in two passes:
+//!
+//! 1. **Definitions.** Every file is parsed and its symbols and opens are sent
+//!    to [`Sink::definitions`]. They are also kept in a symbol table (a
+//!    [`CodeIndex`] with no references). References are dropped.
+//! 2. **References.** Every file is parsed again and its references are
+//!    resolved against the now-complete symbol table, like
+//!    [`CallGraph::build_with_options`]. The references, call sites, and
+//!    unresolved calls are sent to [`Sink::references`] and then dropped.
+//!
+//! Peak memory is the symbol table plus the files being parsed at once (one
+//! per worker thread). The cost is that each file is parsed twice. A file that
+//! changes between the passes may get references that do not match its
+//! definitions; re-index it to fix that.
+//!
+//! All paths given to a sink are relative to the workspace root.
+//!
+//! # Examples
+//!
+//! ```no_run
+//! use rocketindex::stream::{index_streaming, JsonlSink, StreamOptions};
+//! use rocketindex::watch::find_source_files;
+//! use std::path::Path;
+//!
+//! let root = Path::new(".");
+//! let files = find_source_files(root).unwrap();
+//! let out = std::fs::File::create("index.jsonl").unwrap();
+//!
+//! let mut sink = JsonlSink::new(std::io::BufWriter::new(out));
+//! let stats = index_streaming(root, &files, &StreamOptions::default(), &mut sink).unwrap();
+//! println!("{} symbols, {} calls", stats.symbols, stats.calls);
+//! ```
+//!
+//! [`CallGraph::build_with_options`]: crate::callgraph::CallGraph::build_with_options
+
+use std::io::Write;
+use std::path::{Path, PathBuf};
+
+use serde::Serialize;
+
+use crate::callgraph::{CallGraphOptions, CallResolver, CallSite, UnresolvedCall};
+use crate::indexer::{parse_files, IndexOptions, ProgressTracker};
+use crate::{CodeIndex, Reference, Result, SqliteIndex, Symbol};
+
+/// Receives indexing results one file at a time.
+///
+/// [`index_streaming`] calls [`Sink::definitions`] (or [`Sink::removed`], for
+/// a file that cannot be read) once for every file, then [`Sink::references`]
+/// once for every readable file, then [`Sink::finish`]. Files are visited in
+/// sorted path order in both passes.
+pub trait Sink {
+    /// Symbols and opens declared in `file`.
+    fn definitions(&mut self, file: &Path, symbols: &[Symbol], opens: &[String]) -> Result<()>;
+
+    /// `file` could not be read (usually because it was deleted).
+    fn removed(&mut self, _file: &Path) -> Result<()> {
+        Ok(())
+    }
+
+    /// References in `file`, with the call sites and unresolved calls they produce.
+    fn references(
+        &mut self,
+        file: &Path,
+        references: &[Reference],
+        calls: &[CallSite],
+        unresolved: &[UnresolvedCall],
+    ) -> Result<()>;
+
+    /// Called once after the last file.
+    fn finish(&mut self) -> Result<()> {
+        Ok(())
+    }
+}
+
+/// Options for [`index_streaming`].
+#[derive(Debug, Clone, Default)]
+pub struct StreamOptions {
+    /// Parsing options (threads, depth, docs, kinds, progress)
+    ///
+    /// A file counts towards [`IndexOptions::progress`] once both passes
+    /// are done with it.
+    pub index: IndexOptions,
+    /// Which call edges to resolve
+    pub call_graph: CallGraphOptions,
+}
+
+/// Totals for one [`index_streaming`] run.
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq, Serialize)]
+pub struct StreamStats {
+    /// Files that were read and parsed
+    pub files: usize,
+    pub symbols: usize,
+    pub references: usize,
+    pub calls: usize,
+    pub unresolved: usize,
+}
+
+/// Parse `files` under `root` in two passes and stream the results to `sink`.
+///
+/// Relative paths are resolved against `root`. Files that cannot be read are
+/// skipped; duplicate paths are indexed once. Stops at the first error
+/// returned by the sink.
+pub fn index_streaming<S: Sink + ?Sized>(
+    root: &Path,
+    files: &[PathBuf],
+    options: &StreamOptions,
+    sink: &mut S,
+) -> Result<StreamStats> {
+    let mut table = CodeIndex::with_root(root.to_path_buf());
+    stream_files(&mut table, files, options, sink)
+}
+
+/// Stream `files`, resolving calls against `table` once its entries for
+/// `files` have been replaced by what pass 1 finds.
+fn stream_files<S: Sink + ?Sized>(
+    table: &mut CodeIndex,
+    files: &[PathBuf],
+    options: &StreamOptions,
+    sink: &mut S,
+) -> Result<StreamStats> {
+    let mut files: Vec<PathBuf> = files.iter().map(|p| table.to_absolute(p)).collect();
+    files.sort();
+    files.dedup();
+
+    let chunk_size = options.index.worker_count(files.len());
+    let mut stats = StreamStats::default();
+    let progress = ProgressTracker::new(options.index.progress.as_ref(), files.len());
+
+    // Pass 1: definitions
+    let mut parsed_files = Vec::new();
+    for chunk in files.chunks(chunk_size) {
+        for (file, result) in chunk.iter().zip(parse_files(chunk, &options.index)) {
+            let relative = table.to_relative(file);
+            table.remove_file(&relative);
+            let Some(result) = result else {
+                sink.removed(&relative)?;
+                progress.finished(file);
+                continue;
+            };
+            let symbols: Vec<Symbol> = result
+                .symbols
+                .into_iter()
+                .map(|mut symbol| {
+                    symbol.location.file = table.to_relative(&symbol.location.file);
+                    symbol
+                })
+                .collect();
+            sink.definitions(&relative, &symbols, &result.opens)?;
+
+            stats.files += 1;
+            stats.symbols += symbols.len();
+            for symbol in symbols {
+                table.add_symbol(symbol);
+            }
+            for open in result.opens {
+                table.add_open(relative.clone(), open);
+            }
+            parsed_files.push(file.clone());
+        }
+    }
+
+    // Pass 2: references, resolved against the complete symbol table
+    let resolver = CallResolver::new(table, options.call_graph);
+    for chunk in parsed_files.chunks(chunk_size) {
+        for (file, result) in chunk.iter().zip(parse_files(chunk, &options.index)) {
+            let relative = table.to_relative(file);
+            let references: Vec<Reference> = result
+                .map(|result| result.references)
+                .unwrap_or_default()
+                .into_iter()
+                .map(|mut reference| {
+                    reference.location.file = relative.clone();
+                    reference
+                })
+                .collect();
+            let (calls, unresolved) = resolver.resolve(&references);
+            sink.references(&relative, &references, &calls, &unresolved)?;
+
+            stats.references += references.len();
+            stats.calls += calls.len();
+            stats.unresolved += unresolved.len();
+            progress.finished(file);
+        }
+    }
+
+    sink.finish()?;
+    Ok(stats)
+}
+
+/// One line of [`JsonlSink`] output.
+#[derive(Serialize)]
+#[serde(tag = "type", rename_all = "snake_case")]
+enum Record<'a> {
+    Symbol(&'a Symbol),
+    Reference(&'a Reference),
+    Call(&'a CallSite),
+    Unresolved(&'a UnresolvedCall),
+}
+
+/// Writes one JSON object per line, tagged with a `"type"` of `symbol`,
+/// `reference`, `call`, or `unresolved`.
+///
+/// Definitions come first, so a reader sees every symbol before any call.
+pub struct JsonlSink<W: Write> {
+    writer: W,
+}
+
+impl<W: Write> JsonlSink<W> {
+    pub fn new(writer: W) -> Self {
+        Self { writer }
+    }
+
+    /// Return the underlying writer.
+    pub fn into_inner(self) -> W {
+        self.writer
+    }
+
+    fn write(&mut self, record: &Record<'_>) -> Result<()> {
+        serde_json::to_writer(&mut self.writer, record)?;
+        self.writer.write_all(b"\n")?;
+        Ok(())
+    }
+}
+
+impl<W: Write> Sink for JsonlSink<W> {
+    fn definitions(&mut self, _file: &Path, symbols: &[Symbol], _opens: &[String]) -> Result<()> {
+        for symbol in symbols {
+            self.write(&Record::Symbol(symbol))?;
+        }
+        Ok(())
+    }
+
+    fn references(
+        &mut self,
+        _file: &Path,
+        references: &[Reference],
+        calls: &[CallSite],
+        unresolved: &[UnresolvedCall],
+    ) -> Result<()> {
+        for reference in references {
+            self.write(&Record::Reference(reference))?;
+        }
+        for call in calls {
+            self.write(&Record::Call(call))?;
+        }
+        for call in unresolved {
+            self.write(&Record::Unresolved(call))?;
+        }
+        Ok(())
+    }
+
+    fn finish(&mut self) -> Result<()> {
+        self.writer.flush()?;
+        Ok(())
+    }
+}
+
+/// Stores results in a [`SqliteIndex`], one transaction per file and pass.
+///
+/// Definitions replace everything previously stored for the file; call sites
+/// go to the `calls` table. Unresolved calls are not stored.
+pub struct SqliteSink<'a> {
+    db: &'a SqliteIndex,
+}
+
+impl<'a> SqliteSink<'a> {
+    pub fn new(db: &'a SqliteIndex) -> Self {
+        Self { db }
+    }
+}
+
+impl Sink for SqliteSink<'_> {
+    fn definitions(&mut self, file: &Path, symbols: &[Symbol], opens: &[String]) -> Result<()> {
+        let opens: Vec<(String, u32)> = opens
+            .iter()
+            .enumerate()
+            .map(|(i, open)| (open.clone(), i as u32 + 1))
+            .collect();
+        self.db.update_file_data(file, symbols, &[], &opens)
+    }
+
+    fn removed(&mut self, file: &Path) -> Result<()> {
+        self.db.clear_file(file)
+    }
+
+    fn references(
+        &mut self,
+        file: &Path,
+        references: &[Reference],
+        calls: &[CallSite],
+        _unresolved: &[UnresolvedCall],
+    ) -> Result<()> {
+        let refs: Vec<(&Path, &Reference)> = references.iter().map(|r| (file, r)).collect();
+        self.db.insert_references(&refs)?;
+        self.db.insert_calls(calls)
+    }
+}
+
+impl SqliteIndex {
+    /// Re-index `files` under `root`, writing each file in its own transactions.
+    ///
+    /// Calls from the updated files are resolved against every symbol in the
+    /// database, which is loaded into memory for the duration of the update.
+    /// Files that cannot be read are removed. Call sites in other files whose
+    /// target was removed are deleted, so no stored edge points at a symbol
+    /// that no longer exists. Those files are not re-parsed: a call that now
+    /// resolves to a different symbol is only found by updating its file.
+    pub fn update_files(
+        &self,
+        root: &Path,
+        files: &[PathBuf],
+        options: &StreamOptions,
+    ) -> Result<StreamStats> {
+        let mut table = self.symbol_table(Some(root))?;
+        let stats = stream_files(&mut table, files, options, &mut SqliteSink::new(self))?;
+        self.delete_dangling_calls()?;
+        Ok(stats)
+    }
+
+    /// Resolve the stored references of `files` and replace their call
+    /// sites, one transaction per file.
+    ///
+    /// Use this after writing symbols and references some other way (as
+    /// `rkt index` does, in chunks) once every file's symbols are stored.
+    /// Calls are resolved against every symbol in the database, and calls to
+    /// symbols that no longer exist are deleted. Returns the number of call
+    /// sites written.
+    pub fn resolve_calls(&self, files: &[PathBuf], options: CallGraphOptions) -> Result<usize> {
+        let table = self.symbol_table(None)?;
+        let resolver = CallResolver::new(&table, options);
+        let mut count = 0;
+        for file in files {
+            let (calls, _) = resolver.resolve(&self.references_in_file(file)?);
+            self.replace_calls_in_file(file, &calls)?;
+            count += calls.len();
+        }
+        self.delete_dangling_calls()?;
+        Ok(count)
+    }
+
+    /// Every stored symbol and open, for resolving calls against.
+    ///
+    /// Without a `root`, paths are kept exactly as stored.
+    pub(crate) fn symbol_table(&self, root: Option<&Path>) -> Result<CodeIndex> {
+        let mut table = match root {
+            Some(root) => CodeIndex::with_root(root.to_path_buf()),
+            None => CodeIndex::new(),
+        };
+        for symbol in self.get_all_symbols_ordered()? {
+            table.add_symbol(symbol);
+        }
+        for file in self.list_files()? {
+            for open in self.opens_for_file(&file)? {
+                table.add_open(file.clone(), open);
+            }
+        }
+        Ok(table)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::callgraph::CallGraph;
+
+    /// Definitions and calls collected in memory.
+    #[derive(Default)]
+    struct Collect {
+        defined: Vec<PathBuf>,
+        calls: Vec<CallSite>,
+        unresolved: Vec<UnresolvedCall>,
+        finished: bool,
+    }
+
+    impl Sink for Collect {
+        fn definitions(
+            &mut self,
+            file: &Path,
+            _symbols: &[Symbol],
+            _opens: &[String],
+        ) -> Result<()> {
+            self.defined.push(file.to_path_buf());
+            Ok(())
+        }
+
+        fn references(
+            &mut self,
+            _file: &Path,
+            _references: &[Reference],
+            calls: &[CallSite],
+            unresolved: &[UnresolvedCall],
+        ) -> Result<()> {
+            assert!(!self.defined.is_empty());
+            self.calls.extend_from_slice(calls);
+            self.unresolved.extend_from_slice(unresolved);
+            Ok(())
+        }
+
+        fn finish(&mut self) -> Result<()> {
+            self.finished = true;
+            Ok(())
+        }
+    }
+
+    /// `a.py` calls into `b.py`, which sorts after it.
+    fn workspace() -> (tempfile::TempDir, Vec<PathBuf>) {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let root = temp_dir.path();
+        std::fs::write(
+            root.join("a.py"),
+            "from b import helper\n\ndef main():\n    helper()\n    missing()\n",
+        )
+        .unwrap();
+        std::fs::write(root.join("b.py"), "def helper():\n    return 1\n").unwrap();
+        let files = vec![root.join("b.py"), PathBuf::from("a.py"), root.join("a.py")];
+        (temp_dir, files)
+    }
+
+    #[test]
+    fn test_streaming_matches_in_memory_call_graph() {
+        let (temp_dir, files) = workspace();
+        let root = temp_dir.path();
+
+        let mut sink = Collect::default();
+        let stats = index_streaming(root, &files, &StreamOptions::default(), &mut sink).unwrap();
+
+        let mut index = CodeIndex::with_root(root.to_path_buf());
+        index.index_files(&files, &IndexOptions::default());
+        let graph = CallGraph::build(&index);
+
+        assert_eq!(
+            sink.defined,
+            vec![PathBuf::from("a.py"), PathBuf::from("b.py")]
+        );
+        assert!(sink.finished);
+        assert!(!sink.calls.is_empty());
+        assert_eq!(sink.calls, graph.sites());
+        assert_eq!(sink.unresolved, graph.unresolved_calls());
+        assert_eq!(stats.files, 2);
+        assert_eq!(stats.symbols, index.symbol_count());
+        assert_eq!(stats.calls, sink.calls.len());
+    }
+
+    #[test]
+    fn test_jsonl_sink_writes_tagged_records() {
+        let (temp_dir, files) = workspace();
+
+        let mut sink = JsonlSink::new(Vec::new());
+        let stats = index_streaming(
+            temp_dir.path(),
+            &files,
+            &StreamOptions::default(),
+            &mut sink,
+        )
+        .unwrap();
+        let output = String::from_utf8(sink.into_inner()).unwrap();
+
+        let records: Vec<serde_json::Value> = output
+            .lines()
+            .map(|line| serde_json::from_str(line).unwrap())
+            .collect();
+        let count = |kind: &str| records.iter().filter(|r| r["type"] == kind).count();
+        assert_eq!(count("symbol"), stats.symbols);
+        assert_eq!(count("reference"), stats.references);
+        assert_eq!(count("call"), stats.calls);
+        assert_eq!(count("unresolved"), stats.unresolved);
+
+        let call = records.iter().find(|r| r["type"] == "call").unwrap();
+        assert_eq!(call["caller"], "main");
+        assert_eq!(call["location"]["file"], "a.py");
+    }
+
+    #[test]
+    fn test_sqlite_sink_stores_symbols_and_calls() {
+        let (temp_dir, files) = workspace();
+        let db = SqliteIndex::in_memory().unwrap();
+
+        let stats = index_streaming(
+            temp_dir.path(),
+            &files,
+            &StreamOptions::default(),
+            &mut SqliteSink::new(&db),
+        )
+        .unwrap();
+
+        assert_eq!(db.count_symbols().unwrap(), stats.symbols);
+        assert_eq!(db.count_calls().unwrap(), stats.calls);
+        assert!(stats.calls > 0);
+
+        // Streaming again replaces rather than duplicates
+        index_streaming(
+            temp_dir.path(),
+            &files,
+            &StreamOptions::default(),
+            &mut SqliteSink::new(&db),
+        )
+        .unwrap();
+        assert_eq!(db.count_symbols().unwrap(), stats.symbols);
+        assert_eq!(db.count_calls().unwrap(), stats.calls);
+    }
+
+    #[test]
+    fn test_sqlite_update_files_resolves_against_stored_symbols() {
+        let (temp_dir, files) = workspace();
+        let root = temp_dir.path();
+        let db = SqliteIndex::in_memory().unwrap();
+        db.update_files(root, &files, &StreamOptions::default())
+            .unwrap();
+        assert_eq!(db.callers("helper").unwrap()[0].caller, "main");
+
+        // Updating only a.py still resolves its call into b.py
+        std::fs::write(
+            root.join("a.py"),
+            "def main():\n    pass\n\ndef run():\n    helper()\n",
+        )
+        .unwrap();
+        let stats = db
+            .update_files(root, &[PathBuf::from("a.py")], &StreamOptions::default())
+            .unwrap();
+        assert_eq!(stats.files, 1);
+        let callers = db.callers("helper").unwrap();
+        assert_eq!(callers.len(), 1);
+        assert_eq!(callers[0].caller, "run");
+        assert!(db.callees("main").unwrap().is_empty());
+
+        // A deleted file is cleared
+        std::fs::remove_file(root.join("b.py")).unwrap();
+        db.update_files(root, &[PathBuf::from("b.py")], &StreamOptions::default())
+            .unwrap();
+        assert!(db.find_by_qualified("helper").unwrap().is_none());
+        // and so are the calls a.py made into it
+        assert!(db.callers("helper").unwrap().is_empty());
+        assert!(db.callees("run").unwrap().is_empty());
+    }
+
+    #[test]
+    fn test_sqlite_resolve_calls_from_stored_references() {
+        let (temp_dir, _) = workspace();
+        let db = SqliteIndex::in_memory().unwrap();
+        // Symbols and references written per file, without call sites
+        for name in ["a.py", "b.py"] {
+            let file = temp_dir.path().join(name);
+            let source = std::fs::read_to_string(&file).unwrap();
+            let result = crate::extract_symbols(&file, &source, 500);
+            db.update_file_data(&file, &result.symbols, &result.references, &[])
+                .unwrap();
+        }
+        assert!(db.callers("helper").unwrap().is_empty());
+
+        let stored = db.list_files().unwrap();
+        let count = db
+            .resolve_calls(&stored, CallGraphOptions::default())
+            .unwrap();
+        assert!(count > 0);
+        assert_eq!(db.callers("helper").unwrap()[0].caller, "main");
+
+        // Resolving again replaces rather than duplicates
+        db.resolve_calls(&stored, CallGraphOptions::default())
+            .unwrap();
+        assert_eq!(db.count_calls().unwrap(), count);
+    }
+}
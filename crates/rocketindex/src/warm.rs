@@ -0,0 +1,463 @@
+//! Background warming of derived structures, with an atomic swap.
+//!
+//! Several queries need structures derived from the whole index: the call
+//! graph and its reverse edges ([`CallGraph`]), the implementations of every
+//! interface, and [`centrality`](crate::centrality) scores. Building them on
+//! the first query after a reload makes that query slow. A [`WarmIndex`]
+//! holds an index together with all of them, built up front.
+//!
+//! [`LiveIndex`] serves one [`WarmIndex`] at a time and applies updates in
+//! the background (stale-while-revalidate). [`LiveIndex::update`] queues a
+//! change and returns immediately; a worker thread applies it to a copy of
+//! the current index, patches the call graph, recomputes implementations and
+//! centrality, and only then swaps the result in. Until the swap, queries
+//! keep reading the previous generation, so they never observe a
+//! half-built index. Updates queued while a build is running are applied
+//! together in the next one.
+//!
+//! [`LiveIndex::is_ready`] and [`LiveIndex::wait_ready`] tell whether every
+//! queued update has been swapped in, and [`LiveIndex::stats`] reports how
+//! long builds took.
+//!
+//! # Examples
+//!
+//! ```
+//! use rocketindex::warm::{LiveIndex, WarmOptions};
+//! use rocketindex::CodeIndex;
+//! use std::path::PathBuf;
+//! use std::time::Duration;
+//!
+//! let live = LiveIndex::new(CodeIndex::new(), WarmOptions::default());
+//! live.update(|index| index.remove_file(&PathBuf::from("gone.py")));
+//!
+//! // Readers get the last fully built generation without waiting
+//! let current = live.current();
+//! println!("{} symbols", current.index().symbol_count());
+//!
+//! assert!(live.wait_ready(Duration::from_secs(5)));
+//! assert_eq!(live.stats().generation, 1);
+//! ```
+
+use std::collections::HashMap;
+use std::sync::{Arc, Condvar, Mutex, RwLock};
+use std::thread;
+use std::time::{Duration, Instant};
+
+use crate::callgraph::{CallGraph, CallGraphOptions};
+use crate::centrality::{CentralityOptions, SymbolCentrality};
+use crate::{CodeIndex, IndexUpdate, SymbolKind};
+
+/// Options for building a [`WarmIndex`].
+#[derive(Debug, Clone, Copy, Default)]
+pub struct WarmOptions {
+    /// Options the call graph is built with
+    pub call_graph: CallGraphOptions,
+    /// Compute centrality scores with these options (`None` to skip them)
+    pub centrality: Option<CentralityOptions>,
+}
+
+/// An index with its derived structures fully built.
+#[derive(Debug)]
+pub struct WarmIndex {
+    index: CodeIndex,
+    call_graph: CallGraph,
+    /// Interface qualified name -> implementing types' qualified names
+    implementations: HashMap<String, Vec<String>>,
+    centrality: Vec<SymbolCentrality>,
+    generation: u64,
+    build_duration: Duration,
+}
+
+impl WarmIndex {
+    /// Build every derived structure of `index`.
+    #[must_use]
+    pub fn build(index: CodeIndex, options: &WarmOptions) -> Self {
+        let start = Instant::now();
+        let call_graph = CallGraph::build_with_options(&index, options.call_graph);
+        Self::warm(index, call_graph, options, 0, start)
+    }
+
+    /// Finish building from an up-to-date call graph.
+    fn warm(
+        index: CodeIndex,
+        call_graph: CallGraph,
+        options: &WarmOptions,
+        generation: u64,
+        start: Instant,
+    ) -> Self {
+        let implementations = index
+            .symbols()
+            .filter(|s| s.kind == SymbolKind::Interface)
+            .map(|interface| {
+                let found = index
+                    .implementations(&interface.qualified)
+                    .into_iter()
+                    .map(|s| s.qualified.clone())
+                    .collect();
+                (interface.qualified.clone(), found)
+            })
+            .collect();
+        let centrality = options
+            .centrality
+            .map(|options| call_graph.centrality_with_options(options))
+            .unwrap_or_default();
+
+        Self {
+            index,
+            call_graph,
+            implementations,
+            centrality,
+            generation,
+            build_duration: start.elapsed(),
+        }
+    }
+
+    /// The index.
+    #[must_use]
+    pub fn index(&self) -> &CodeIndex {
+        &self.index
+    }
+
+    /// The call graph of the index.
+    #[must_use]
+    pub fn call_graph(&self) -> &CallGraph {
+        &self.call_graph
+    }
+
+    /// Qualified names of the types implementing the interface with
+    /// qualified name `interface`, as [`CodeIndex::implementations`] returns
+    /// them. Empty if there is no such interface.
+    #[must_use]
+    pub fn implementations(&self, interface: &str) -> &[String] {
+        self.implementations
+            .get(interface)
+            .map_or(&[], |found| found.as_slice())
+    }
+
+    /// Centrality scores, by descending score (empty if
+    /// [`WarmOptions::centrality`] was `None`).
+    #[must_use]
+    pub fn centrality(&self) -> &[SymbolCentrality] {
+        &self.centrality
+    }
+
+    /// How many updates were swapped in before this index (0 for the first).
+    #[must_use]
+    pub fn generation(&self) -> u64 {
+        self.generation
+    }
+
+    /// Time spent applying updates and building the derived structures.
+    #[must_use]
+    pub fn build_duration(&self) -> Duration {
+        self.build_duration
+    }
+}
+
+/// Build metrics of a [`LiveIndex`], from [`LiveIndex::stats`].
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq)]
+pub struct WarmStats {
+    /// Generation of the index currently served
+    pub generation: u64,
+    /// Updates queued but not yet swapped in
+    pub pending: usize,
+    /// Duration of the most recent background build, if there was one
+    pub last_build: Option<Duration>,
+    /// Total time spent in background builds
+    pub total_build: Duration,
+}
+
+type Update = Box<dyn FnOnce(&mut CodeIndex) -> IndexUpdate + Send>;
+
+struct Shared {
+    current: RwLock<Arc<WarmIndex>>,
+    state: Mutex<State>,
+    ready: Condvar,
+    options: WarmOptions,
+}
+
+#[derive(Default)]
+struct State {
+    /// Updates for the next build
+    queued: Vec<Update>,
+    /// Updates taken by the running build
+    building: usize,
+    /// Whether a worker thread is running
+    running: bool,
+    last_build: Option<Duration>,
+    total_build: Duration,
+}
+
+/// A [`WarmIndex`] that is rebuilt in the background after each update.
+///
+/// Clones share the same index and worker. See the [module
+/// docs](crate::warm).
+#[derive(Clone)]
+pub struct LiveIndex {
+    shared: Arc<Shared>,
+}
+
+impl std::fmt::Debug for LiveIndex {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        f.debug_struct("LiveIndex")
+            .field("stats", &self.stats())
+            .finish_non_exhaustive()
+    }
+}
+
+impl LiveIndex {
+    /// Serve `index`, building its derived structures before returning.
+    #[must_use]
+    pub fn new(index: CodeIndex, options: WarmOptions) -> Self {
+        let warm = WarmIndex::build(index, &options);
+        Self {
+            shared: Arc::new(Shared {
+                current: RwLock::new(Arc::new(warm)),
+                state: Mutex::new(State::default()),
+                ready: Condvar::new(),
+                options,
+            }),
+        }
+    }
+
+    /// The most recent fully built index.
+    ///
+    /// Holding on to it keeps that generation alive; later updates do not
+    /// change it.
+    #[must_use]
+    pub fn current(&self) -> Arc<WarmIndex> {
+        self.shared
+            .current
+            .read()
+            .expect("LiveIndex lock poisoned")
+            .clone()
+    }
+
+    /// Queue a change to the index and return without waiting for it.
+    ///
+    /// `apply` runs on a worker thread, against a copy of the latest index,
+    /// and returns what it changed so the call graph can be patched (see
+    /// [`CallGraph::apply_update`]); for example
+    /// `|index| index.index_files(&files, &options)`. The result is swapped
+    /// in once all derived structures are rebuilt.
+    pub fn update<F>(&self, apply: F)
+    where
+        F: FnOnce(&mut CodeIndex) -> IndexUpdate + Send + 'static,
+    {
+        let mut state = self.shared.state.lock().expect("LiveIndex lock poisoned");
+        state.queued.push(Box::new(apply));
+        if !state.running {
+            state.running = true;
+            let shared = self.shared.clone();
+            thread::spawn(move || shared.run());
+        }
+    }
+
+    /// Whether every queued update has been swapped in.
+    #[must_use]
+    pub fn is_ready(&self) -> bool {
+        !self
+            .shared
+            .state
+            .lock()
+            .expect("LiveIndex lock poisoned")
+            .running
+    }
+
+    /// Block until every queued update has been swapped in, or `timeout`
+    /// passes. Returns whether the index is ready.
+    pub fn wait_ready(&self, timeout: Duration) -> bool {
+        let state = self.shared.state.lock().expect("LiveIndex lock poisoned");
+        let (state, _) = self
+            .shared
+            .ready
+            .wait_timeout_while(state, timeout, |state| state.running)
+            .expect("LiveIndex lock poisoned");
+        !state.running
+    }
+
+    /// Current generation and build metrics.
+    #[must_use]
+    pub fn stats(&self) -> WarmStats {
+        let generation = self.current().generation;
+        let state = self.shared.state.lock().expect("LiveIndex lock poisoned");
+        WarmStats {
+            generation,
+            pending: state.queued.len() + state.building,
+            last_build: state.last_build,
+            total_build: state.total_build,
+        }
+    }
+}
+
+impl Shared {
+    /// Worker loop: build and swap in generations until no updates are queued.
+    fn run(&self) {
+        loop {
+            let updates = {
+                let mut state = self.state.lock().expect("LiveIndex lock poisoned");
+                if state.queued.is_empty() {
+                    state.running = false;
+                    self.ready.notify_all();
+                    return;
+                }
+                state.building = state.queued.len();
+                std::mem::take(&mut state.queued)
+            };
+
+            let start = Instant::now();
+            let base = self
+                .current
+                .read()
+                .expect("LiveIndex lock poisoned")
+                .clone();
+            let mut index = base.index.clone();
+            let mut update = IndexUpdate::default();
+            for apply in updates {
+                update.merge(apply(&mut index));
+            }
+            let mut call_graph = base.call_graph.clone();
+            call_graph.apply_update(&index, &update);
+            let generation = base.generation + 1;
+            drop(base);
+            let warm = WarmIndex::warm(index, call_graph, &self.options, generation, start);
+            let duration = warm.build_duration;
+            tracing::debug!(
+                "Warmed index generation {} in {:.3}s",
+                warm.generation,
+                duration.as_secs_f64()
+            );
+
+            *self.current.write().expect("LiveIndex lock poisoned") = Arc::new(warm);
+            let mut state = self.state.lock().expect("LiveIndex lock poisoned");
+            state.building = 0;
+            state.last_build = Some(duration);
+            state.total_build += duration;
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::{Location, Reference, ReferenceKind, Symbol, Visibility};
+    use std::path::PathBuf;
+    use std::sync::mpsc::channel;
+
+    fn symbol(name: &str, kind: SymbolKind, line: u32) -> Symbol {
+        Symbol::new(
+            name.to_string(),
+            format!("main.{}", name),
+            kind,
+            Location::new(PathBuf::from("main.go"), line, 1),
+            Visibility::Public,
+            "go".to_string(),
+        )
+    }
+
+    /// `main.caller` calls `main.callee`; `main.Store` has an implementation.
+    fn index() -> CodeIndex {
+        let mut index = CodeIndex::new();
+        index.add_symbol(symbol("caller", SymbolKind::Function, 1));
+        index.add_symbol(symbol("callee", SymbolKind::Function, 10));
+        index.add_symbol(symbol("Store", SymbolKind::Interface, 20));
+        let mut memory = symbol("MemoryStore", SymbolKind::Class, 30);
+        memory.implements = Some(vec!["main.Store".to_string()]);
+        index.add_symbol(memory);
+        index.add_reference(
+            PathBuf::from("main.go"),
+            Reference {
+                name: "callee".to_string(),
+                location: Location::new(PathBuf::from("main.go"), 2, 5),
+                kind: ReferenceKind::Call,
+                target: None,
+                arguments: None,
+            },
+        );
+        index
+    }
+
+    fn with_centrality() -> WarmOptions {
+        WarmOptions {
+            centrality: Some(CentralityOptions::default()),
+            ..WarmOptions::default()
+        }
+    }
+
+    #[test]
+    fn test_build_warms_every_derived_structure() {
+        let warm = WarmIndex::build(index(), &with_centrality());
+        assert_eq!(warm.generation(), 0);
+        assert_eq!(warm.call_graph().callers("main.callee").len(), 1);
+        assert_eq!(warm.implementations("main.Store"), ["main.MemoryStore"]);
+        assert!(warm.implementations("main.Missing").is_empty());
+        assert_eq!(warm.centrality()[0].qualified, "main.callee");
+
+        let cold = WarmIndex::build(index(), &WarmOptions::default());
+        assert!(cold.centrality().is_empty());
+    }
+
+    #[test]
+    fn test_queries_see_the_previous_index_until_the_swap() {
+        let live = LiveIndex::new(index(), with_centrality());
+        let (started_tx, started) = channel();
+        let (release, release_rx) = channel::<()>();
+        live.update(move |index| {
+            index.add_symbol(symbol("added", SymbolKind::Function, 40));
+            started_tx.send(()).unwrap();
+            release_rx.recv().unwrap();
+            IndexUpdate::default()
+        });
+        started.recv().unwrap();
+
+        // Mid-build: the old generation is still served in full
+        assert!(!live.is_ready());
+        assert!(!live.wait_ready(Duration::from_millis(10)));
+        let old = live.current();
+        assert_eq!(old.generation(), 0);
+        assert!(old.index().get("main.added").is_none());
+        assert_eq!(old.implementations("main.Store").len(), 1);
+        assert_eq!(live.stats().pending, 1);
+
+        release.send(()).unwrap();
+        assert!(live.wait_ready(Duration::from_secs(5)));
+        let new = live.current();
+        assert_eq!(new.generation(), 1);
+        assert!(new.index().get("main.added").is_some());
+        assert!(old.index().get("main.added").is_none());
+
+        let stats = live.stats();
+        assert_eq!(stats.pending, 0);
+        assert_eq!(stats.last_build, Some(new.build_duration()));
+    }
+
+    #[test]
+    fn test_updates_queued_during_a_build_are_applied_together() {
+        let live = LiveIndex::new(index(), WarmOptions::default());
+        let (started_tx, started) = channel();
+        let (release, release_rx) = channel::<()>();
+        live.update(move |_| {
+            started_tx.send(()).unwrap();
+            release_rx.recv().unwrap();
+            IndexUpdate::default()
+        });
+        started.recv().unwrap();
+
+        live.update(|index| index.remove_file(&PathBuf::from("main.go")));
+        live.update(|index| {
+            index.add_symbol(symbol("fresh", SymbolKind::Function, 1));
+            IndexUpdate::default()
+        });
+        assert_eq!(live.stats().pending, 3);
+
+        release.send(()).unwrap();
+        assert!(live.wait_ready(Duration::from_secs(5)));
+        let current = live.current();
+        assert_eq!(current.generation(), 2);
+        assert!(current.index().get("main.caller").is_none());
+        assert!(current.index().get("main.fresh").is_some());
+        // The removal was passed on to the call graph
+        assert!(current.call_graph().callers("main.callee").is_empty());
+        assert!(current.implementations("main.Store").is_empty());
+    }
+}
@@ -6,10 +6,16 @@
 //! Two watcher implementations are provided:
 //! - `FileWatcher`: Simple, low-level watcher (legacy)
 //! - `DebouncedFileWatcher`: Recommended watcher with event debouncing and rename tracking
+//!
+//! [`IndexWatcher`] builds on the debouncer to keep an in-memory [`CodeIndex`]
+//! up to date on a background thread, or to queue updates on a
+//! [`LiveIndex`] that is rebuilt without blocking queries.
 
 use std::path::{Path, PathBuf};
 use std::sync::mpsc::{channel, Receiver};
-use std::time::Duration;
+use std::sync::{Arc, RwLock};
+use std::thread::{self, JoinHandle};
+use std::time::{Duration, Instant};
 
 use notify::event::{DataChange, ModifyKind};
 use notify::{Config, Event, EventKind, RecommendedWatcher, RecursiveMode, Watcher};
@@ -17,6 +23,10 @@ use notify_debouncer_full::{
     new_debouncer, DebounceEventResult, DebouncedEvent, Debouncer, RecommendedCache,
 };
 
+use crate::indexer::IndexOptions;
+use crate::warm::LiveIndex;
+use crate::{CodeIndex, IndexUpdate};
+
 /// Events emitted by the file watcher.
 #[derive(Debug, Clone)]
 pub enum WatchEvent {
@@ -322,6 +332,236 @@ impl DebouncedFileWatcher {
     }
 }
 
+/// Options for [`IndexWatcher`].
+#[derive(Debug, Clone)]
+pub struct IndexWatchOptions {
+    /// How long the tree must be quiet before a burst of changes is applied
+    pub debounce: Duration,
+    /// Options used to re-index changed files
+    pub index: IndexOptions,
+}
+
+impl Default for IndexWatchOptions {
+    fn default() -> Self {
+        Self {
+            debounce: DEFAULT_DEBOUNCE_DURATION,
+            index: IndexOptions::default(),
+        }
+    }
+}
+
+/// One applied burst of changes, passed to the [`IndexWatcher`] callback.
+#[derive(Debug, Clone)]
+pub struct IndexRefresh {
+    /// Files that were re-indexed or removed, with their symbols before and after
+    pub update: IndexUpdate,
+    /// Time spent updating the index
+    pub duration: Duration,
+}
+
+/// Keeps a shared [`CodeIndex`] in sync with the source files under a root.
+///
+/// Events are debounced, so a burst of changes (a `git checkout`, a
+/// formatter run) is applied as a single [`CodeIndex::index_files`] call once
+/// the tree has been quiet for [`IndexWatchOptions::debounce`]. Files that are
+/// created, modified, deleted, or renamed are re-indexed or removed. So are
+/// whole directories: every source file under a created or renamed-to
+/// directory is indexed, and every indexed file under a removed or
+/// renamed-from directory is dropped. Hidden and excluded directories
+/// (`.git`, `node_modules`, ...) are ignored.
+///
+/// After each update, `on_refresh` runs on the watcher thread with the write
+/// lock already released, so it can read the index or tell a client to
+/// invalidate its caches. Watching stops when the watcher is dropped.
+///
+/// # Example
+/// ```ignore
+/// let index = Arc::new(RwLock::new(CodeIndex::with_root(root.clone())));
+/// let watcher = IndexWatcher::start(&root, index.clone(), IndexWatchOptions::default(), |refresh| {
+///     println!("re-indexed {} files", refresh.update.files.len());
+/// })?;
+/// ```
+pub struct IndexWatcher {
+    debouncer: Option<Debouncer<RecommendedWatcher, RecommendedCache>>,
+    worker: Option<JoinHandle<()>>,
+}
+
+impl IndexWatcher {
+    /// Start watching `root` and applying changes to `index`.
+    ///
+    /// `index` should already be populated and rooted at `root`; only
+    /// changes made after this call are picked up.
+    pub fn start<F>(
+        root: &Path,
+        index: Arc<RwLock<CodeIndex>>,
+        options: IndexWatchOptions,
+        mut on_refresh: F,
+    ) -> Result<Self, notify::Error>
+    where
+        F: FnMut(&IndexRefresh) + Send + 'static,
+    {
+        let root_dir = root.to_path_buf();
+        Self::spawn(root, options.debounce, move |paths| {
+            let refresh = {
+                let mut index = index.write().expect("CodeIndex lock poisoned");
+                let files = changed_files(&index, &root_dir, &paths);
+                if files.is_empty() {
+                    return;
+                }
+                let start = Instant::now();
+                let update = index.index_files(&files, &options.index);
+                IndexRefresh {
+                    update,
+                    duration: start.elapsed(),
+                }
+            };
+            if !refresh.update.is_empty() {
+                on_refresh(&refresh);
+            }
+        })
+    }
+
+    /// Start watching `root` and queueing changes on `live`.
+    ///
+    /// Unlike [`IndexWatcher::start`], queries are never blocked by an
+    /// update: each burst of changes is applied in the background with
+    /// [`LiveIndex::update`], and the previous index is served until the
+    /// rebuilt one is swapped in. Use [`LiveIndex::wait_ready`] or
+    /// [`LiveIndex::stats`] to follow progress.
+    pub fn start_live(
+        root: &Path,
+        live: LiveIndex,
+        options: IndexWatchOptions,
+    ) -> Result<Self, notify::Error> {
+        let root_dir = root.to_path_buf();
+        let index_options = Arc::new(options.index);
+        Self::spawn(root, options.debounce, move |paths| {
+            let files = changed_files(live.current().index(), &root_dir, &paths);
+            if files.is_empty() {
+                return;
+            }
+            let options = index_options.clone();
+            live.update(move |index| index.index_files(&files, &options));
+        })
+    }
+
+    /// Watch `root` and pass each debounced burst of changed paths (mapped
+    /// back under `root`) to `handle` on a worker thread.
+    fn spawn<F>(root: &Path, debounce: Duration, mut handle: F) -> Result<Self, notify::Error>
+    where
+        F: FnMut(Vec<PathBuf>) + Send + 'static,
+    {
+        let (tx, rx) = channel();
+        let mut debouncer = new_debouncer(debounce, None, move |result: DebounceEventResult| {
+            let _ = tx.send(result);
+        })?;
+        debouncer
+            .watch(root, RecursiveMode::Recursive)
+            .map_err(|e| notify::Error::generic(&e.to_string()))?;
+
+        // Events carry canonical paths (`/private/var/...` on macOS), while
+        // the index may be rooted at a non-canonical path
+        let watched_root = root.canonicalize().unwrap_or_else(|_| root.to_path_buf());
+        let root = root.to_path_buf();
+
+        let worker = thread::spawn(move || {
+            // Ends once the debouncer is dropped and the channel disconnects
+            for result in rx {
+                let events = match result {
+                    Ok(events) => events,
+                    Err(errors) => {
+                        for error in errors {
+                            tracing::warn!("Debounced watch error: {:?}", error);
+                        }
+                        continue;
+                    }
+                };
+
+                let paths: Vec<PathBuf> = events
+                    .iter()
+                    .filter(|event| is_meaningful_event(&event.kind))
+                    .flat_map(|event| event.paths.iter())
+                    .map(|path| match path.strip_prefix(&watched_root) {
+                        Ok(relative) => root.join(relative),
+                        Err(_) => path.clone(),
+                    })
+                    .collect();
+                if !paths.is_empty() {
+                    handle(paths);
+                }
+            }
+        });
+
+        Ok(Self {
+            debouncer: Some(debouncer),
+            worker: Some(worker),
+        })
+    }
+
+    /// Stop watching and wait for any in-progress update to finish.
+    pub fn stop(mut self) {
+        self.shutdown();
+    }
+
+    fn shutdown(&mut self) {
+        // Dropping the debouncer drops its event handler, which ends the worker loop
+        drop(self.debouncer.take());
+        if let Some(worker) = self.worker.take() {
+            let _ = worker.join();
+        }
+    }
+}
+
+impl Drop for IndexWatcher {
+    fn drop(&mut self) {
+        self.shutdown();
+    }
+}
+
+/// Files to re-index (or remove) for a set of changed paths under `root`.
+///
+/// A path may be a source file (re-indexed, or removed if it is gone), an
+/// existing directory (every source file under it), or a directory that no
+/// longer exists (every indexed file under it). Anything else is ignored.
+fn changed_files(index: &CodeIndex, root: &Path, paths: &[PathBuf]) -> Vec<PathBuf> {
+    let mut files = Vec::new();
+    for path in paths {
+        if is_ignored_path(root, path) {
+            continue;
+        }
+        if path.is_dir() {
+            match find_source_files(path) {
+                Ok(found) => files.extend(found),
+                Err(e) => tracing::warn!("Failed to scan directory {:?}: {}", path, e),
+            }
+        } else if is_supported_file(path) {
+            files.push(path.clone());
+        } else if !path.exists() {
+            // A removed directory, or the old name of a renamed one
+            files.extend(
+                index
+                    .files()
+                    .map(|file| index.to_absolute(file))
+                    .filter(|file| file.starts_with(path)),
+            );
+        }
+    }
+    files.sort();
+    files.dedup();
+    files
+}
+
+/// Whether `path` is inside a hidden or excluded directory below `root`.
+fn is_ignored_path(root: &Path, path: &Path) -> bool {
+    let Ok(relative) = path.strip_prefix(root) else {
+        return true;
+    };
+    relative.components().any(|component| {
+        let name = component.as_os_str().to_string_lossy();
+        name.starts_with('.') || crate::config::DEFAULT_EXCLUDE_DIRS.contains(&name.as_ref())
+    })
+}
+
 /// Check if a path is a supported source file.
 /// Supported: C, C++, C#, F#, Go, Java, JavaScript, Kotlin, Objective-C, PHP, Python, Ruby, Rust, Swift, TypeScript.
 pub fn is_supported_file(path: &Path) -> bool {
@@ -408,6 +648,21 @@ pub fn find_source_files_with_config(
     root: &Path,
     exclude_dirs: &[&str],
     respect_gitignore: bool,
+) -> std::io::Result<Vec<PathBuf>> {
+    find_source_files_with_patterns(root, exclude_dirs, &[], respect_gitignore)
+}
+
+/// Find all supported source files, also skipping paths matching `ignore_patterns`.
+///
+/// Patterns use gitignore syntax relative to `root` (`vendor/`,
+/// `**/*_generated.go`). Like `.gitignore` entries and `exclude_dirs`, they
+/// are applied during the walk, so ignored directories are never descended
+/// into.
+pub fn find_source_files_with_patterns(
+    root: &Path,
+    exclude_dirs: &[&str],
+    ignore_patterns: &[&str],
+    respect_gitignore: bool,
 ) -> std::io::Result<Vec<PathBuf>> {
     use ignore::overrides::OverrideBuilder;
     use ignore::WalkBuilder;
@@ -423,6 +678,13 @@ pub fn find_source_files_with_config(
             tracing::warn!("Invalid exclude pattern '{}': {}", pattern, e);
         }
     }
+    for pattern in ignore_patterns {
+        // Overrides are whitelists unless negated, so negate to exclude
+        let pattern = format!("!{}", pattern.trim_start_matches('!'));
+        if let Err(e) = override_builder.add(&pattern) {
+            tracing::warn!("Invalid ignore pattern '{}': {}", pattern, e);
+        }
+    }
     let overrides = match override_builder.build() {
         Ok(o) => o,
         Err(e) => {
@@ -882,4 +1144,122 @@ mod tests {
             file_events
         );
     }
+
+    // ==================== IndexWatcher Tests ====================
+
+    fn indexed(root: &Path) -> CodeIndex {
+        let mut index = CodeIndex::with_root(root.to_path_buf());
+        let files = find_source_files(root).unwrap();
+        index.index_files(&files, &IndexOptions::default());
+        index
+    }
+
+    #[test]
+    fn test_changed_files_handles_directory_renames() {
+        let dir = tempfile::TempDir::new().unwrap();
+        let root = dir.path();
+        std::fs::create_dir_all(root.join("old/nested")).unwrap();
+        std::fs::write(root.join("old/a.py"), "def first():\n    pass\n").unwrap();
+        std::fs::write(root.join("old/nested/b.py"), "def second():\n    pass\n").unwrap();
+        let mut index = indexed(root);
+        assert_eq!(index.file_count(), 2);
+
+        std::fs::rename(root.join("old"), root.join("new")).unwrap();
+        let files = changed_files(&index, root, &[root.join("old"), root.join("new")]);
+        assert_eq!(
+            files,
+            vec![
+                root.join("new/a.py"),
+                root.join("new/nested/b.py"),
+                root.join("old/a.py"),
+                root.join("old/nested/b.py"),
+            ]
+        );
+
+        index.index_files(&files, &IndexOptions::default());
+        assert!(index.contains_file(Path::new("new/nested/b.py")));
+        assert!(!index.contains_file(Path::new("old/a.py")));
+        assert_eq!(index.file_count(), 2);
+    }
+
+    #[test]
+    fn test_changed_files_skips_ignored_and_unsupported_paths() {
+        let dir = tempfile::TempDir::new().unwrap();
+        let root = dir.path();
+        std::fs::create_dir_all(root.join(".git/objects")).unwrap();
+        std::fs::create_dir_all(root.join("node_modules/pkg")).unwrap();
+        std::fs::write(root.join("node_modules/pkg/index.js"), "function f() {}\n").unwrap();
+        std::fs::write(root.join("README.md"), "# readme\n").unwrap();
+        let index = CodeIndex::with_root(root.to_path_buf());
+
+        let paths = [
+            root.join(".git/objects"),
+            root.join("node_modules/pkg"),
+            root.join("node_modules/pkg/index.js"),
+            root.join("README.md"),
+            PathBuf::from("/elsewhere/main.py"),
+        ];
+        assert!(changed_files(&index, root, &paths).is_empty());
+
+        // A deleted source file is still reported so it can be removed
+        assert_eq!(
+            changed_files(&index, root, &[root.join("gone.py")]),
+            vec![root.join("gone.py")]
+        );
+    }
+
+    #[test]
+    fn test_index_watcher_refreshes_index_and_notifies() {
+        let dir = tempfile::TempDir::new().unwrap();
+        let root = dir.path().to_path_buf();
+        std::fs::write(root.join("a.py"), "def first():\n    pass\n").unwrap();
+        let index = Arc::new(RwLock::new(indexed(&root)));
+
+        let (tx, rx) = channel();
+        let options = IndexWatchOptions {
+            debounce: Duration::from_millis(50),
+            ..IndexWatchOptions::default()
+        };
+        let watcher = IndexWatcher::start(&root, index.clone(), options, move |refresh| {
+            let _ = tx.send(refresh.clone());
+        })
+        .unwrap();
+
+        std::fs::write(root.join("b.py"), "def second():\n    pass\n").unwrap();
+        let refresh = rx
+            .recv_timeout(Duration::from_secs(5))
+            .expect("expected a refresh after creating b.py");
+        assert!(refresh.update.files.contains(&PathBuf::from("b.py")));
+        assert!(index.read().unwrap().get("second").is_some());
+
+        watcher.stop();
+    }
+
+    #[test]
+    fn test_live_index_watcher_swaps_in_rebuilt_index() {
+        let dir = tempfile::TempDir::new().unwrap();
+        let root = dir.path().to_path_buf();
+        std::fs::write(root.join("a.py"), "def first():\n    pass\n").unwrap();
+        let live = LiveIndex::new(indexed(&root), Default::default());
+
+        let options = IndexWatchOptions {
+            debounce: Duration::from_millis(50),
+            ..IndexWatchOptions::default()
+        };
+        let watcher = IndexWatcher::start_live(&root, live.clone(), options).unwrap();
+
+        std::fs::write(root.join("b.py"), "def second():\n    first()\n").unwrap();
+        let deadline = Instant::now() + Duration::from_secs(5);
+        while live.current().generation() == 0 && Instant::now() < deadline {
+            thread::sleep(Duration::from_millis(20));
+        }
+        assert!(live.wait_ready(Duration::from_secs(5)));
+        let current = live.current();
+        assert!(current.index().get("second").is_some());
+        let first = &current.index().get("first").unwrap().qualified;
+        assert_eq!(current.call_graph().callers(first).len(), 1);
+        assert!(live.stats().last_build.is_some());
+
+        watcher.stop();
+    }
 }
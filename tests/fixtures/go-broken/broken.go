@@ -0,0 +1,11 @@
+package main
+
+// Recovered is declared before the syntax error below.
+func Recovered() int {
+	return 1
+}
+
+// Deliberately malformed: unclosed parameter list and dangling operator.
+func Broken(a int {
+	return a +
+}
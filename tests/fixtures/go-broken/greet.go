@@ -0,0 +1,5 @@
+package main
+
+func Greet(name string) string {
+	return "hello, " + name
+}
@@ -0,0 +1,74 @@
+package generics
+
+import "fmt"
+
+// Number is a constraint satisfied by the built-in numeric types.
+type Number interface {
+	~int | ~int64 | ~float64
+}
+
+// Stack is a LIFO stack of any element type.
+type Stack[T any] struct {
+	items []T
+}
+
+// NewStack creates an empty stack.
+func NewStack[T any]() *Stack[T] {
+	return &Stack[T]{}
+}
+
+func (s *Stack[T]) Push(v T) {
+	s.items = append(s.items, v)
+}
+
+func (s *Stack[T]) Pop() (T, bool) {
+	var zero T
+	if len(s.items) == 0 {
+		return zero, false
+	}
+	v := s.items[len(s.items)-1]
+	s.items = s.items[:len(s.items)-1]
+	return v, true
+}
+
+func (s Stack[T]) Len() int {
+	return len(s.items)
+}
+
+// Pair holds two values of possibly different types.
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// Map applies f to every element of xs.
+func Map[T, U any](xs []T, f func(T) U) []U {
+	out := make([]U, 0, len(xs))
+	for _, x := range xs {
+		out = append(out, f(x))
+	}
+	return out
+}
+
+// Sum adds up numbers of any numeric type.
+func Sum[N Number](xs []N) N {
+	var total N
+	for _, x := range xs {
+		total += x
+	}
+	return total
+}
+
+func Keys[K comparable, V any](pairs []Pair[K, V]) []K {
+	return Map[Pair[K, V], K](pairs, func(p Pair[K, V]) K { return p.Key })
+}
+
+func run() {
+	s := NewStack[int]()
+	s.Push(1)
+	doubled := Map[int, int]([]int{1, 2}, func(x int) int { return x * 2 })
+	lengths := Map([]string{"a"}, func(x string) int { return len(x) })
+	total := Sum[int](doubled)
+	fmt.Println(s.Len(), lengths, total, Sum(doubled))
+	_ = Keys([]Pair[string, int]{{Key: "a", Value: 1}})
+}
@@ -0,0 +1,10 @@
+package billing
+
+// Processor has a method with the same name as payment's, so a call by short
+// name alone would be ambiguous.
+type Processor struct{}
+
+// ProcessPayment records an invoice.
+func (p Processor) ProcessPayment(amount float64) bool {
+	return amount > 0
+}
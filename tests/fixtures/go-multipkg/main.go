@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+
+	"example.com/shop/billing"
+	pay "example.com/shop/payment"
+)
+
+var defaultService = &pay.PaymentService{}
+
+func checkout(svc *pay.PaymentService, amount float64) bool {
+	return svc.ProcessPayment(amount)
+}
+
+func invoice(amount float64) bool {
+	var p billing.Processor
+	return p.ProcessPayment(amount)
+}
+
+func quick(amount float64) bool {
+	return pay.ProcessPayment(amount)
+}
+
+func fallback(amount float64) bool {
+	return defaultService.ProcessPayment(amount)
+}
+
+func main() {
+	svc := pay.NewPaymentService()
+	fmt.Println(checkout(svc, 10), invoice(10), quick(10), fallback(10))
+}
@@ -0,0 +1,25 @@
+package payment
+
+// PaymentService charges customers.
+type PaymentService struct {
+	fee float64
+}
+
+// NewPaymentService returns a service with the default fee.
+func NewPaymentService() *PaymentService {
+	return &PaymentService{fee: 0.3}
+}
+
+// ProcessPayment charges amount plus the service fee.
+func (ps *PaymentService) ProcessPayment(amount float64) bool {
+	return validate(amount + ps.fee)
+}
+
+// ProcessPayment charges amount without a service.
+func ProcessPayment(amount float64) bool {
+	return validate(amount)
+}
+
+func validate(amount float64) bool {
+	return amount > 0
+}